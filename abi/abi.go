@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/filecoin-project/go-leb128"
+	"github.com/ipfs/go-cid"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/libp2p/go-libp2p-peer"
 
@@ -60,9 +61,20 @@ const (
 	Predicate
 	// Parameters is a slice of individually encodable parameters
 	Parameters
+	// Addresses is a slice of address.Address
+	Addresses
+	// Cid is a cid.Cid
+	Cid
 )
 
 func (t Type) String() string {
+	if t.IsOptional() {
+		return "optional " + t.Elem().String()
+	}
+	if rt, ok := structGoType(t); ok {
+		return rt.String()
+	}
+
 	switch t {
 	case Invalid:
 		return "<invalid>"
@@ -104,6 +116,10 @@ func (t Type) String() string {
 		return "*types.Predicate"
 	case Parameters:
 		return "[]interface{}"
+	case Addresses:
+		return "[]address.Address"
+	case Cid:
+		return "cid.Cid"
 	default:
 		return "<unknown type>"
 	}
@@ -116,6 +132,16 @@ type Value struct {
 }
 
 func (av *Value) String() string {
+	if av.Type.IsOptional() {
+		if isZero(av) {
+			return "<none>"
+		}
+		return (&Value{Type: av.Type.Elem(), Val: av.Val}).String()
+	}
+	if av.Type.IsStruct() {
+		return fmt.Sprintf("%+v", av.Val)
+	}
+
 	switch av.Type {
 	case Invalid:
 		return "<invalid>"
@@ -157,6 +183,10 @@ func (av *Value) String() string {
 		return fmt.Sprint(av.Val.(*types.Predicate))
 	case Parameters:
 		return fmt.Sprint(av.Val.([]interface{}))
+	case Addresses:
+		return fmt.Sprint(av.Val.([]address.Address))
+	case Cid:
+		return av.Val.(cid.Cid).String()
 	default:
 		return "<unknown type>"
 	}
@@ -173,6 +203,19 @@ func (ate typeError) Error() string {
 
 // Serialize serializes the value into raw bytes. Only works on valid supported types.
 func (av *Value) Serialize() ([]byte, error) {
+	if av.Type.IsOptional() {
+		return (&Value{Type: av.Type.Elem(), Val: av.Val}).Serialize()
+	}
+	if rt, ok := structGoType(av.Type); ok {
+		if reflect.TypeOf(av.Val) != rt {
+			return nil, &typeError{reflect.Zero(rt).Interface(), av.Val}
+		}
+		if reflect.ValueOf(av.Val).IsNil() {
+			return nil, nil
+		}
+		return cbor.DumpObject(av.Val)
+	}
+
 	switch av.Type {
 	case Invalid:
 		return nil, ErrInvalidType
@@ -308,6 +351,20 @@ func (av *Value) Serialize() ([]byte, error) {
 		}
 
 		return cbor.DumpObject(p)
+	case Addresses:
+		addrs, ok := av.Val.([]address.Address)
+		if !ok {
+			return nil, &typeError{[]address.Address{}, av.Val}
+		}
+
+		return cbor.DumpObject(addrs)
+	case Cid:
+		c, ok := av.Val.(cid.Cid)
+		if !ok {
+			return nil, &typeError{cid.Undef, av.Val}
+		}
+
+		return c.Bytes(), nil
 	default:
 		return nil, fmt.Errorf("unrecognized Type: %d", av.Type)
 	}
@@ -361,8 +418,16 @@ func ToValues(i []interface{}) ([]*Value, error) {
 			out = append(out, &Value{Type: Predicate, Val: v})
 		case []interface{}:
 			out = append(out, &Value{Type: Parameters, Val: v})
+		case []address.Address:
+			out = append(out, &Value{Type: Addresses, Val: v})
+		case cid.Cid:
+			out = append(out, &Value{Type: Cid, Val: v})
 		default:
-			return nil, fmt.Errorf("unsupported type: %T", v)
+			t, ok := structTypeOf(v)
+			if !ok {
+				return nil, fmt.Errorf("unsupported type: %T", v)
+			}
+			out = append(out, &Value{Type: t, Val: v})
 		}
 	}
 	return out, nil
@@ -385,6 +450,28 @@ func FromValues(vals []*Value) []interface{} {
 // Deserialize converts the given bytes to the requested type and returns an
 // ABI Value for it.
 func Deserialize(data []byte, t Type) (*Value, error) {
+	if t.IsOptional() {
+		if len(data) == 0 {
+			return zeroValue(t)
+		}
+		v, err := Deserialize(data, t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		v.Type = t
+		return v, nil
+	}
+	if rt, ok := structGoType(t); ok {
+		if len(data) == 0 {
+			return &Value{Type: t, Val: reflect.Zero(rt).Interface()}, nil
+		}
+		ptr := reflect.New(rt.Elem())
+		if err := cbor.DecodeInto(data, ptr.Interface()); err != nil {
+			return nil, err
+		}
+		return &Value{Type: t, Val: ptr.Interface()}, nil
+	}
+
 	switch t {
 	case Address:
 		addr, err := address.NewFromBytes(data)
@@ -515,6 +602,24 @@ func Deserialize(data []byte, t Type) (*Value, error) {
 			Type: t,
 			Val:  parameters,
 		}, nil
+	case Addresses:
+		var addrs []address.Address
+		if err := cbor.DecodeInto(data, &addrs); err != nil {
+			return nil, err
+		}
+		return &Value{
+			Type: t,
+			Val:  addrs,
+		}, nil
+	case Cid:
+		c, err := cid.Cast(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Value{
+			Type: t,
+			Val:  c,
+		}, nil
 	case Invalid:
 		return nil, ErrInvalidType
 	default:
@@ -542,11 +647,13 @@ var typeTable = map[Type]reflect.Type{
 	PoStProof:      reflect.TypeOf(types.PoStProof{}),
 	Predicate:      reflect.TypeOf(&types.Predicate{}),
 	Parameters:     reflect.TypeOf([]interface{}{}),
+	Addresses:      reflect.TypeOf([]address.Address{}),
+	Cid:            reflect.TypeOf(cid.Cid{}),
 }
 
 // TypeMatches returns whether or not 'val' is the go type expected for the given ABI type
 func TypeMatches(t Type, val reflect.Type) bool {
-	rt, ok := typeTable[t]
+	rt, ok := goType(t)
 	if !ok {
 		return false
 	}
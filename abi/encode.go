@@ -29,28 +29,41 @@ func EncodeValues(vals []*Value) ([]byte, error) {
 }
 
 // DecodeValues decodes an array of abi values from the given buffer, using the
-// provided type information.
+// provided type information. Optional values left out of the buffer are
+// decoded as the zero values of their types.
 func DecodeValues(data []byte, types []Type) ([]*Value, error) {
-	if len(types) > 0 && len(data) == 0 {
-		return nil, fmt.Errorf("expected %d parameters, but got 0", len(types))
+	required, err := requiredParams(types)
+	if err != nil {
+		return nil, err
+	}
+
+	if required > 0 && len(data) == 0 {
+		return nil, paramCountError(required, len(types), 0)
 	}
 
-	if len(data) == 0 {
+	if len(types) == 0 && len(data) == 0 {
 		return nil, nil
 	}
 
 	var arr [][]byte
-	if err := cbor.DecodeInto(data, &arr); err != nil {
-		return nil, err
+	if len(data) > 0 {
+		if err := cbor.DecodeInto(data, &arr); err != nil {
+			return nil, err
+		}
 	}
 
-	if len(arr) != len(types) {
-		return nil, fmt.Errorf("expected %d parameters, but got %d", len(types), len(arr))
+	if len(arr) < required || len(arr) > len(types) {
+		return nil, paramCountError(required, len(types), len(arr))
 	}
 
 	out := make([]*Value, 0, len(types))
 	for i, t := range types {
-		v, err := Deserialize(arr[i], t)
+		var v *Value
+		if i < len(arr) {
+			v, err = Deserialize(arr[i], t)
+		} else {
+			v, err = zeroValue(t)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -59,6 +72,13 @@ func DecodeValues(data []byte, types []Type) ([]*Value, error) {
 	return out, nil
 }
 
+func paramCountError(required, total, got int) error {
+	if required == total {
+		return fmt.Errorf("expected %d parameters, but got %d", total, got)
+	}
+	return fmt.Errorf("expected %d to %d parameters, but got %d", required, total, got)
+}
+
 // ToEncodedValues converts from a list of go abi-compatible values to abi values and then encodes to raw bytes.
 func ToEncodedValues(params ...interface{}) ([]byte, error) {
 	vals, err := ToValues(params)
@@ -0,0 +1,137 @@
+package abi
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	cbor "github.com/ipfs/go-ipld-cbor"
+)
+
+const (
+	// firstStruct is the type Struct assigns to the first struct it registers.
+	firstStruct = Type(1 << 16)
+	// optionalFlag marks the types of optional parameters.
+	optionalFlag = Type(1 << 32)
+)
+
+// structs records the go types of the struct types Struct registered.
+var structs = struct {
+	sync.RWMutex
+	byType   map[Type]reflect.Type
+	byGoType map[reflect.Type]Type
+	next     Type
+}{
+	byType:   map[Type]reflect.Type{},
+	byGoType: map[reflect.Type]Type{},
+	next:     firstStruct,
+}
+
+// Struct registers the struct pointed to by example as an ABI type and
+// returns it. Values of the type are pointers to the struct, encoded as CBOR,
+// so the struct is registered with the CBOR atlas too. Registering a struct
+// again returns the same type. Actors register the structs they take as
+// parameters when their package is initialized.
+func Struct(example interface{}) Type {
+	rt := reflect.TypeOf(example)
+	if rt == nil || rt.Kind() != reflect.Ptr || rt.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("abi.Struct needs a pointer to a struct, got %T", example))
+	}
+
+	structs.Lock()
+	defer structs.Unlock()
+
+	if t, ok := structs.byGoType[rt]; ok {
+		return t
+	}
+
+	cbor.RegisterCborType(reflect.New(rt.Elem()).Elem().Interface())
+
+	t := structs.next
+	structs.next++
+	structs.byType[t] = rt
+	structs.byGoType[rt] = t
+	return t
+}
+
+// Optional returns the type of an optional parameter of type t. Optional
+// parameters may only come last in a function signature. Callers may leave
+// them out, in which case the function gets the zero value of t's go type.
+func Optional(t Type) Type {
+	return t | optionalFlag
+}
+
+// IsOptional returns whether t is the type of an optional parameter.
+func (t Type) IsOptional() bool {
+	return t&optionalFlag != 0
+}
+
+// Elem returns the type of the values of t, which is t itself unless t is
+// optional.
+func (t Type) Elem() Type {
+	return t &^ optionalFlag
+}
+
+// IsStruct returns whether t is a type registered with Struct.
+func (t Type) IsStruct() bool {
+	_, ok := structGoType(t)
+	return ok
+}
+
+func structGoType(t Type) (reflect.Type, bool) {
+	structs.RLock()
+	defer structs.RUnlock()
+	rt, ok := structs.byType[t.Elem()]
+	return rt, ok
+}
+
+func structTypeOf(v interface{}) (Type, bool) {
+	structs.RLock()
+	defer structs.RUnlock()
+	t, ok := structs.byGoType[reflect.TypeOf(v)]
+	return t, ok
+}
+
+// goType returns the go type of the values of t.
+func goType(t Type) (reflect.Type, bool) {
+	if rt, ok := structGoType(t); ok {
+		return rt, true
+	}
+	rt, ok := typeTable[t.Elem()]
+	return rt, ok
+}
+
+// zeroValue returns the value an optional parameter of type t gets when it
+// is left out.
+func zeroValue(t Type) (*Value, error) {
+	rt, ok := goType(t)
+	if !ok {
+		return nil, fmt.Errorf("no go type for %s", t)
+	}
+	return &Value{Type: t, Val: reflect.Zero(rt).Interface()}, nil
+}
+
+// isZero returns whether v holds the zero value of its type.
+func isZero(v *Value) bool {
+	if v.Val == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v.Val)
+	return reflect.DeepEqual(v.Val, reflect.Zero(rv.Type()).Interface())
+}
+
+// requiredParams returns the number of parameters in ts that are not
+// optional, and an error if an optional parameter is followed by one that is
+// not.
+func requiredParams(ts []Type) (int, error) {
+	required := len(ts)
+	for required > 0 && ts[required-1].IsOptional() {
+		required--
+	}
+	for _, t := range ts[:required] {
+		if t.IsOptional() {
+			return 0, fmt.Errorf("optional parameter of type %s is followed by a required one", t.Elem())
+		}
+	}
+	return required, nil
+}
@@ -0,0 +1,122 @@
+package abi
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+type registeredTestStruct struct {
+	Name   string
+	Amount *types.AttoFIL
+	Target address.Address
+}
+
+var registeredTestStructType = Struct(&registeredTestStruct{})
+
+func TestStruct(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("registering again returns the same type", func(t *testing.T) {
+		assert.Equal(t, registeredTestStructType, Struct(&registeredTestStruct{}))
+		assert.True(t, registeredTestStructType.IsStruct())
+		assert.False(t, Address.IsStruct())
+		assert.Equal(t, "*abi.registeredTestStruct", registeredTestStructType.String())
+		assert.True(t, TypeMatches(registeredTestStructType, reflect.TypeOf(&registeredTestStruct{})))
+	})
+
+	t.Run("round trips through encoding", func(t *testing.T) {
+		val := &registeredTestStruct{Name: "foo", Amount: types.NewAttoFILFromFIL(3), Target: address.TestAddress}
+
+		data, err := ToEncodedValues(val, big.NewInt(7))
+		require.NoError(t, err)
+
+		vals, err := DecodeValues(data, []Type{registeredTestStructType, Integer})
+		require.NoError(t, err)
+		assert.Equal(t, registeredTestStructType, vals[0].Type)
+		assert.Equal(t, val, vals[0].Val)
+		assert.NoError(t, ValidateValues(vals))
+	})
+
+	t.Run("nil structs are invalid", func(t *testing.T) {
+		var val *registeredTestStruct
+		assert.Error(t, ValidateValue(&Value{Type: registeredTestStructType, Val: val}))
+	})
+
+	t.Run("only pointers to structs can be registered", func(t *testing.T) {
+		assert.Panics(t, func() { Struct(registeredTestStruct{}) })
+		assert.Panics(t, func() { Struct(big.NewInt(1)) })
+	})
+}
+
+func TestOptional(t *testing.T) {
+	tf.UnitTest(t)
+
+	sig := []Type{Address, Optional(AttoFIL), Optional(registeredTestStructType)}
+
+	t.Run("types", func(t *testing.T) {
+		assert.True(t, Optional(AttoFIL).IsOptional())
+		assert.False(t, AttoFIL.IsOptional())
+		assert.Equal(t, AttoFIL, Optional(AttoFIL).Elem())
+		assert.Equal(t, "optional *types.AttoFIL", Optional(AttoFIL).String())
+		assert.True(t, TypeMatches(Optional(AttoFIL), reflect.TypeOf(&types.AttoFIL{})))
+	})
+
+	t.Run("trailing optional params may be left out", func(t *testing.T) {
+		data, err := ToEncodedValues(address.TestAddress)
+		require.NoError(t, err)
+
+		vals, err := DecodeValues(data, sig)
+		require.NoError(t, err)
+		require.Len(t, vals, 3)
+		assert.Equal(t, address.TestAddress, vals[0].Val)
+		assert.Equal(t, (*types.AttoFIL)(nil), vals[1].Val)
+		assert.Equal(t, (*registeredTestStruct)(nil), vals[2].Val)
+		assert.NoError(t, ValidateValues(vals))
+
+		data, err = ToEncodedValues(address.TestAddress, types.NewAttoFILFromFIL(2))
+		require.NoError(t, err)
+
+		vals, err = DecodeValues(data, sig)
+		require.NoError(t, err)
+		assert.Equal(t, types.NewAttoFILFromFIL(2), vals[1].Val)
+		assert.Equal(t, Optional(AttoFIL), vals[1].Type)
+	})
+
+	t.Run("required params may not be left out", func(t *testing.T) {
+		_, err := DecodeValues(nil, sig)
+		assert.EqualError(t, err, "expected 1 to 3 parameters, but got 0")
+
+		_, err = ParseValues([]string{}, sig)
+		assert.EqualError(t, err, "expected 1 to 3 parameters, but got 0")
+	})
+
+	t.Run("optional params must come last", func(t *testing.T) {
+		data, err := ToEncodedValues(address.TestAddress, address.TestAddress)
+		require.NoError(t, err)
+
+		_, err = DecodeValues(data, []Type{Optional(Address), Address})
+		assert.Error(t, err)
+	})
+
+	t.Run("present optional params are validated", func(t *testing.T) {
+		err := ValidateValue(&Value{Type: Optional(AttoFIL), Val: types.NewAttoFIL(big.NewInt(-1))})
+		assert.Error(t, err)
+	})
+
+	t.Run("parsing", func(t *testing.T) {
+		vals, err := ParseValues([]string{address.TestAddress.String(), "1.5"}, sig)
+		require.NoError(t, err)
+		require.Len(t, vals, 3)
+		amt, _ := types.NewAttoFILFromFILString("1.5")
+		assert.Equal(t, amt, vals[1].Val)
+		assert.Equal(t, (*registeredTestStruct)(nil), vals[2].Val)
+	})
+}
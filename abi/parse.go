@@ -0,0 +1,115 @@
+package abi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-peer"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ParseValues parses each string in strs into a value of the type at the same
+// position in ts. It is meant for parameters typed on the command line.
+// Optional parameters may be left out.
+func ParseValues(strs []string, ts []Type) ([]*Value, error) {
+	required, err := requiredParams(ts)
+	if err != nil {
+		return nil, err
+	}
+	if len(strs) < required || len(strs) > len(ts) {
+		return nil, paramCountError(required, len(ts), len(strs))
+	}
+
+	out := make([]*Value, 0, len(ts))
+	for i, t := range ts {
+		if i >= len(strs) {
+			v, err := zeroValue(t)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+			continue
+		}
+		v, err := ParseValue(t, strs[i])
+		if err != nil {
+			return nil, fmt.Errorf("param %d: %s", i, err)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// ParseValue parses s into a value of type t. Amounts of AttoFIL are given in
+// FIL, bytes are given hex encoded and addresses are comma separated. Only
+// scalar types and lists of addresses can be parsed.
+func ParseValue(t Type, s string) (*Value, error) {
+	if t.IsOptional() {
+		v, err := ParseValue(t.Elem(), s)
+		if err != nil {
+			return nil, err
+		}
+		v.Type = t
+		return v, nil
+	}
+
+	var val interface{}
+	var ok = true
+	var err error
+
+	switch t {
+	case Address:
+		val, err = address.NewFromString(s)
+	case AttoFIL:
+		val, ok = types.NewAttoFILFromFILString(s)
+	case BytesAmount:
+		val, ok = types.NewBytesAmountFromString(s, 10)
+	case ChannelID:
+		val, ok = types.NewChannelIDFromString(s, 10)
+	case BlockHeight:
+		val, ok = types.NewBlockHeightFromString(s, 10)
+	case Integer:
+		val, ok = big.NewInt(0).SetString(s, 10)
+	case Bytes:
+		val, err = hex.DecodeString(s)
+	case String:
+		val = s
+	case PeerID:
+		val, err = peer.IDB58Decode(s)
+	case SectorID:
+		val, err = strconv.ParseUint(s, 10, 64)
+	case Boolean:
+		val, err = strconv.ParseBool(s)
+	case Addresses:
+		val, err = parseAddresses(s)
+	case Cid:
+		val, err = cid.Decode(s)
+	default:
+		return nil, fmt.Errorf("cannot parse values of type %s", t)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %s", t, s, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid %s %q", t, s)
+	}
+	return &Value{Type: t, Val: val}, nil
+}
+
+func parseAddresses(s string) ([]address.Address, error) {
+	var addrs []address.Address
+	for _, a := range strings.Split(s, ",") {
+		addr, err := address.NewFromString(strings.TrimSpace(a))
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
@@ -0,0 +1,81 @@
+package abi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestParseValue(t *testing.T) {
+	tf.UnitTest(t)
+
+	addr := address.NewForTestGetter()()
+
+	valid := []struct {
+		t   Type
+		s   string
+		val interface{}
+	}{
+		{Address, addr.String(), addr},
+		{AttoFIL, "1.5", types.NewAttoFIL(big.NewInt(1500000000000000000))},
+		{BytesAmount, "1024", types.NewBytesAmount(1024)},
+		{ChannelID, "3", types.NewChannelID(3)},
+		{BlockHeight, "12", types.NewBlockHeight(12)},
+		{Integer, "-4", big.NewInt(-4)},
+		{Bytes, "cafe", []byte{0xca, 0xfe}},
+		{String, "hello", "hello"},
+		{SectorID, "7", uint64(7)},
+		{Boolean, "true", true},
+		{Addresses, addr.String() + "," + addr.String(), []address.Address{addr, addr}},
+		{Cid, types.AccountActorCodeCid.String(), types.AccountActorCodeCid},
+	}
+	for _, tc := range valid {
+		t.Run(tc.t.String(), func(t *testing.T) {
+			v, err := ParseValue(tc.t, tc.s)
+			require.NoError(t, err)
+			assert.Equal(t, tc.t, v.Type)
+			assert.Equal(t, (&Value{Type: tc.t, Val: tc.val}).String(), v.String())
+		})
+	}
+
+	invalid := []struct {
+		t Type
+		s string
+	}{
+		{Address, "notanaddress"},
+		{AttoFIL, "lots"},
+		{BlockHeight, "1.5"},
+		{Bytes, "xyz"},
+		{SectorID, "-1"},
+		{Boolean, "maybe"},
+		{UintArray, "[1,2]"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.t.String(), func(t *testing.T) {
+			_, err := ParseValue(tc.t, tc.s)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	tf.UnitTest(t)
+
+	vals, err := ParseValues([]string{"1", "abc"}, []Type{SectorID, String})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), vals[0].Val)
+	assert.Equal(t, "abc", vals[1].Val)
+
+	_, err = ParseValues([]string{"1"}, []Type{SectorID, String})
+	assert.Error(t, err)
+
+	_, err = ParseValues([]string{"x", "abc"}, []Type{SectorID, String})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "param 0")
+}
@@ -0,0 +1,115 @@
+package abi
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ValidateValues checks every value with ValidateValue and returns the first
+// error, naming the position of the offending value.
+func ValidateValues(vals []*Value) error {
+	for i, v := range vals {
+		if err := ValidateValue(v); err != nil {
+			return fmt.Errorf("param %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+// ValidateValue checks that a value is sane for its type: pointer values are
+// not nil, cids are defined, non-empty addresses use a known protocol, and
+// amounts, heights and channel ids are not negative. Actor methods are only
+// called with parameters that pass these checks so they need not repeat them.
+func ValidateValue(v *Value) error {
+	if v == nil {
+		return fmt.Errorf("nil value")
+	}
+
+	if v.Type.IsOptional() {
+		if isZero(v) {
+			return nil
+		}
+		return ValidateValue(&Value{Type: v.Type.Elem(), Val: v.Val})
+	}
+	if v.Type.IsStruct() && isZero(v) {
+		return typeMismatch(v)
+	}
+
+	switch v.Type {
+	case Address:
+		addr, ok := v.Val.(address.Address)
+		if !ok {
+			return typeMismatch(v)
+		}
+		if addr.Empty() {
+			return nil
+		}
+		switch addr.Protocol() {
+		case address.ID, address.SECP256K1, address.Actor, address.BLS:
+			return nil
+		default:
+			return fmt.Errorf("address %s has unknown protocol %d", addr, addr.Protocol())
+		}
+	case AttoFIL:
+		amt, ok := v.Val.(*types.AttoFIL)
+		if !ok || amt == nil {
+			return typeMismatch(v)
+		}
+		if amt.IsNegative() {
+			return fmt.Errorf("negative amount %s", amt)
+		}
+	case BytesAmount:
+		amt, ok := v.Val.(*types.BytesAmount)
+		if !ok || amt == nil {
+			return typeMismatch(v)
+		}
+		if amt.IsNegative() {
+			return fmt.Errorf("negative size %s", amt)
+		}
+	case BlockHeight:
+		bh, ok := v.Val.(*types.BlockHeight)
+		if !ok || bh == nil {
+			return typeMismatch(v)
+		}
+		if bh.LessThan(types.NewBlockHeight(0)) {
+			return fmt.Errorf("negative block height %s", bh)
+		}
+	case ChannelID:
+		id, ok := v.Val.(*types.ChannelID)
+		if !ok || id == nil {
+			return typeMismatch(v)
+		}
+		if id.IsNegative() {
+			return fmt.Errorf("negative channel id %s", id)
+		}
+	case Integer:
+		if i, ok := v.Val.(*big.Int); !ok || i == nil {
+			return typeMismatch(v)
+		}
+	case Addresses:
+		addrs, ok := v.Val.([]address.Address)
+		if !ok {
+			return typeMismatch(v)
+		}
+		for _, addr := range addrs {
+			if err := ValidateValue(&Value{Type: Address, Val: addr}); err != nil {
+				return err
+			}
+		}
+	case Cid:
+		if c, ok := v.Val.(cid.Cid); !ok || !c.Defined() {
+			return typeMismatch(v)
+		}
+	}
+
+	return nil
+}
+
+func typeMismatch(v *Value) error {
+	return fmt.Errorf("expected non-nil %s but got %T", v.Type, v.Val)
+}
@@ -0,0 +1,70 @@
+package abi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestValidateValue(t *testing.T) {
+	tf.UnitTest(t)
+
+	valid := map[string]*Value{
+		"address":         {Type: Address, Val: address.NewForTestGetter()()},
+		"empty address":   {Type: Address, Val: address.Undef},
+		"zero amount":     {Type: AttoFIL, Val: types.ZeroAttoFIL},
+		"amount":          {Type: AttoFIL, Val: types.NewAttoFILFromFIL(3)},
+		"size":            {Type: BytesAmount, Val: types.NewBytesAmount(1024)},
+		"block height":    {Type: BlockHeight, Val: types.NewBlockHeight(7)},
+		"channel id":      {Type: ChannelID, Val: types.NewChannelID(2)},
+		"integer":         {Type: Integer, Val: big.NewInt(-4)},
+		"nil predicate":   {Type: Predicate, Val: (*types.Predicate)(nil)},
+		"unchecked bytes": {Type: Bytes, Val: []byte(nil)},
+		"cid":             {Type: Cid, Val: types.AccountActorCodeCid},
+	}
+	for name, v := range valid {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, ValidateValue(v))
+		})
+	}
+
+	negativeSize, _ := types.NewBytesAmountFromString("-1", 10)
+	negativeHeight, _ := types.NewBlockHeightFromString("-1", 10)
+	negativeChannel, _ := types.NewChannelIDFromString("-1", 10)
+
+	invalid := map[string]*Value{
+		"nil value":             nil,
+		"nil amount":            {Type: AttoFIL, Val: (*types.AttoFIL)(nil)},
+		"negative amount":       {Type: AttoFIL, Val: types.NewAttoFIL(big.NewInt(-1))},
+		"negative size":         {Type: BytesAmount, Val: negativeSize},
+		"negative block height": {Type: BlockHeight, Val: negativeHeight},
+		"negative channel id":   {Type: ChannelID, Val: negativeChannel},
+		"nil integer":           {Type: Integer, Val: (*big.Int)(nil)},
+		"undefined cid":         {Type: Cid, Val: cid.Undef},
+		"mistyped value":        {Type: AttoFIL, Val: "1"},
+	}
+	for name, v := range invalid {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, ValidateValue(v))
+		})
+	}
+}
+
+func TestValidateValues(t *testing.T) {
+	tf.UnitTest(t)
+
+	err := ValidateValues([]*Value{
+		{Type: AttoFIL, Val: types.NewAttoFILFromFIL(1)},
+		{Type: AttoFIL, Val: types.NewAttoFIL(big.NewInt(-1))},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "param 1")
+
+	assert.NoError(t, ValidateValues(nil))
+}
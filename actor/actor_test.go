@@ -2,6 +2,7 @@ package actor_test
 
 import (
 	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/ipfs/go-cid"
@@ -101,6 +102,10 @@ func (a *MockActor) Six(ctx exec.VMContext) (uint8, error) {
 	return 0, fmt.Errorf("NOT A REVERT OR FAULT -- PROGRAMMER ERROR")
 }
 
+func (a *MockActor) Seven(ctx exec.VMContext, amount *types.AttoFIL) (uint8, error) {
+	return 0, nil
+}
+
 func NewMockActor(list exec.Exports) *MockActor {
 	return &MockActor{
 		exports: list,
@@ -108,10 +113,14 @@ func NewMockActor(list exec.Exports) *MockActor {
 }
 
 func makeCtx(method string) exec.VMContext {
+	return makeCtxWithParams(method, nil)
+}
+
+func makeCtxWithParams(method string, params []byte) exec.VMContext {
 	addrGetter := address.NewForTestGetter()
 
 	vmCtxParams := vm.NewContextParams{
-		Message:     types.NewMessage(addrGetter(), addrGetter(), 0, nil, method, nil),
+		Message:     types.NewMessage(addrGetter(), addrGetter(), 0, nil, method, params),
 		GasTracker:  vm.NewGasTracker(),
 		BlockHeight: types.NewBlockHeight(0),
 	}
@@ -183,6 +192,25 @@ func TestMakeTypedExportSuccess(t *testing.T) {
 			_, _, _ = exportedFunc(makeCtx("six"))
 		})
 	})
+
+	t.Run("with invalid params", func(t *testing.T) {
+		a := NewMockActor(map[string]*exec.FunctionSignature{
+			"seven": {
+				Params: []abi.Type{abi.AttoFIL},
+				Return: nil,
+			},
+		})
+
+		params, err := abi.ToEncodedValues(types.NewAttoFIL(big.NewInt(-1)))
+		require.NoError(t, err)
+
+		_, exitCode, err := MakeTypedExport(a, "seven")(makeCtxWithParams("seven", params))
+
+		require.Error(t, err)
+		assert.True(t, errors.ShouldRevert(err))
+		assert.Contains(t, err.Error(), "negative amount")
+		assert.Equal(t, uint8(1), exitCode)
+	})
 }
 
 func TestMakeTypedExportFail(t *testing.T) {
@@ -0,0 +1,163 @@
+package faucet
+
+import (
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	xerrors "github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+func init() {
+	cbor.RegisterCborType(State{})
+}
+
+const (
+	// ErrInvalidParameters indicates the faucet was created with a non positive amount or period.
+	ErrInvalidParameters = 33
+	// ErrTooSoon indicates the address was already funded during the current period.
+	ErrTooSoon = 34
+)
+
+// Errors map error codes to revert errors this actor may return.
+var Errors = map[uint8]error{
+	ErrInvalidParameters: errors.NewCodedRevertError(ErrInvalidParameters, "faucet amount and period must be positive"),
+	ErrTooSoon:           errors.NewCodedRevertError(ErrTooSoon, "address was already funded by the faucet during this period"),
+}
+
+// Actor dispenses its funds to whoever asks for them on test networks, so
+// that users can fund their wallets without asking the operators of the
+// network. Each address receives at most Amount every Period blocks.
+type Actor struct{}
+
+// State is the faucet actor's storage.
+type State struct {
+	// Amount is what an address receives from a tap.
+	Amount *types.AttoFIL
+	// Period is the number of blocks after which an address may tap again.
+	Period *types.BlockHeight
+	// Taps maps the addresses funded to the block height they were last
+	// funded at.
+	Taps map[string]*types.BlockHeight
+}
+
+// NewState creates the state of a faucet dispensing amount per address every
+// period blocks.
+func NewState(amount *types.AttoFIL, period *types.BlockHeight) *State {
+	return &State{
+		Amount: amount,
+		Period: period,
+		Taps:   make(map[string]*types.BlockHeight),
+	}
+}
+
+// NextTap returns the block height from which addr may tap again.
+func (s *State) NextTap(addr address.Address) *types.BlockHeight {
+	last, ok := s.Taps[addr.String()]
+	if !ok {
+		return types.NewBlockHeight(0)
+	}
+	return last.Add(s.Period)
+}
+
+// NewActor returns a new faucet actor holding balance.
+func NewActor(balance *types.AttoFIL) *actor.Actor {
+	return actor.NewActor(types.FaucetActorCodeCid, balance)
+}
+
+// InitializeState stores the actor's initial data structure.
+func (fa *Actor) InitializeState(storage exec.Storage, initializerData interface{}) error {
+	faucetState, ok := initializerData.(*State)
+	if !ok {
+		return errors.NewFaultError("Initial state to faucet actor is not a faucet.State struct")
+	}
+
+	if !faucetState.Amount.IsPositive() || !faucetState.Period.GreaterThan(types.NewBlockHeight(0)) {
+		return Errors[ErrInvalidParameters]
+	}
+
+	stateBytes, err := cbor.DumpObject(faucetState)
+	if err != nil {
+		return xerrors.Wrap(err, "failed to cbor marshal object")
+	}
+
+	id, err := storage.Put(stateBytes)
+	if err != nil {
+		return err
+	}
+
+	return storage.Commit(id, cid.Undef)
+}
+
+var _ exec.ExecutableActor = (*Actor)(nil)
+
+// Exports returns the actor's exports.
+func (fa *Actor) Exports() exec.Exports {
+	return faucetExports
+}
+
+var faucetExports = exec.Exports{
+	"tap": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address},
+		Return: []abi.Type{abi.AttoFIL},
+	},
+	"getNextTap": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address},
+		Return: []abi.Type{abi.BlockHeight},
+	},
+}
+
+// Tap sends the faucet amount to addr, unless addr was already funded during
+// the current period. It fails once the faucet runs out of funds. It returns
+// the amount sent.
+func (fa *Actor) Tap(ctx exec.VMContext, addr address.Address) (*types.AttoFIL, uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.BlockHeight().LessThan(state.NextTap(addr)) {
+			return nil, Errors[ErrTooSoon]
+		}
+
+		if state.Taps == nil {
+			state.Taps = make(map[string]*types.BlockHeight)
+		}
+		state.Taps[addr.String()] = ctx.BlockHeight()
+
+		if _, _, err := ctx.Send(addr, "", state.Amount, nil); err != nil {
+			return nil, err
+		}
+
+		return state.Amount, nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	return out.(*types.AttoFIL), 0, nil
+}
+
+// GetNextTap returns the block height from which addr may tap the faucet
+// again.
+func (fa *Actor) GetNextTap(ctx exec.VMContext, addr address.Address) (*types.BlockHeight, uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		return nil, nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	return state.NextTap(addr), 0, nil
+}
@@ -0,0 +1,82 @@
+package faucet_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	. "github.com/filecoin-project/go-filecoin/actor/builtin/faucet"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+func TestFaucetInitializeState(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	err := consensus.SetupFaucet(ctx, st, vms, types.NewAttoFILFromFIL(100), NewState(types.NewZeroAttoFIL(), types.NewBlockHeight(10)))
+	assert.Equal(t, Errors[ErrInvalidParameters], err)
+
+	err = consensus.SetupFaucet(ctx, st, vms, types.NewAttoFILFromFIL(100), NewState(types.NewAttoFILFromFIL(1), types.NewBlockHeight(0)))
+	assert.Equal(t, Errors[ErrInvalidParameters], err)
+
+	require.NoError(t, consensus.SetupFaucet(ctx, st, vms, types.NewAttoFILFromFIL(100), NewState(types.NewAttoFILFromFIL(1), types.NewBlockHeight(10))))
+	faucetActor := state.MustGetActor(st, address.FaucetAddress)
+	assert.Equal(t, types.FaucetActorCodeCid, faucetActor.Code)
+	assert.Equal(t, types.NewAttoFILFromFIL(100), faucetActor.Balance)
+}
+
+func TestFaucetTap(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+	require.NoError(t, consensus.SetupFaucet(ctx, st, vms, types.NewAttoFILFromFIL(25), NewState(types.NewAttoFILFromFIL(10), types.NewBlockHeight(100))))
+
+	tap := func(t *testing.T, st state.Tree, vms vm.StorageMap, addr address.Address, height uint64) *consensus.ApplicationResult {
+		msg := types.NewMessage(address.TestAddress, address.FaucetAddress, core.MustGetNonce(st, address.TestAddress), types.NewZeroAttoFIL(), "tap", actor.MustConvertParams(addr))
+		res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(height))
+		require.NoError(t, err)
+		return res
+	}
+
+	addrGetter := address.NewForTestGetter()
+	alice := addrGetter()
+	bob := addrGetter()
+
+	t.Run("funds the address once per period", func(t *testing.T) {
+		res := tap(t, st, vms, alice, 5)
+		require.NoError(t, res.ExecutionError)
+		assert.True(t, types.NewAttoFILFromFIL(10).Equal(state.MustGetActor(st, alice).Balance))
+
+		res = tap(t, st, vms, alice, 104)
+		assert.Equal(t, Errors[ErrTooSoon], res.ExecutionError)
+
+		res = tap(t, st, vms, alice, 105)
+		require.NoError(t, res.ExecutionError)
+		assert.True(t, types.NewAttoFILFromFIL(20).Equal(state.MustGetActor(st, alice).Balance))
+
+		var faucetState State
+		builtin.RequireReadState(t, vms, address.FaucetAddress, state.MustGetActor(st, address.FaucetAddress), &faucetState)
+		assert.Equal(t, types.NewBlockHeight(205), faucetState.NextTap(alice))
+		assert.Equal(t, types.NewBlockHeight(0), faucetState.NextTap(bob))
+	})
+
+	t.Run("fails once out of funds", func(t *testing.T) {
+		res := tap(t, st, vms, bob, 110)
+		assert.Error(t, res.ExecutionError)
+		assert.True(t, types.NewAttoFILFromFIL(5).Equal(state.MustGetActor(st, address.FaucetAddress).Balance))
+	})
+}
@@ -0,0 +1,291 @@
+package initactor
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-hamt-ipld"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	xerrors "github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/vesting"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+func init() {
+	cbor.RegisterCborType(State{})
+}
+
+const (
+	// ErrUnknownCode indicates exec was asked to create an actor it cannot create.
+	ErrUnknownCode = 33
+	// ErrInvalidParams indicates the params given to exec do not match the actor's constructor.
+	ErrInvalidParams = 34
+	// ErrNotFound indicates no actor has the given address or id.
+	ErrNotFound = 35
+)
+
+// Errors map error codes to revert errors this actor may return.
+var Errors = map[uint8]error{
+	ErrUnknownCode:   errors.NewCodedRevertError(ErrUnknownCode, "actors of this code cannot be created with exec"),
+	ErrInvalidParams: errors.NewCodedRevertError(ErrInvalidParams, "params do not match the constructor of the actor"),
+	ErrNotFound:      errors.NewCodedRevertError(ErrNotFound, "no actor has this address or id"),
+}
+
+// Actor creates new actor instances and assigns each of them a compact
+// numeric id. It keeps the mapping between the ids and the robust addresses
+// of the actors, which unlike the ids do not change when the chain reorgs.
+type Actor struct{}
+
+// State is the init actor's storage.
+type State struct {
+	// NextID is the id the next actor or payment channel gets.
+	NextID uint64
+	// IDs maps the robust addresses of the actors to their ids.
+	IDs cid.Cid `refmt:",omitempty"`
+	// Addresses maps the ids of the actors, in decimal, to their robust addresses.
+	Addresses cid.Cid `refmt:",omitempty"`
+}
+
+// constructor builds the initial state of an actor created by exec from its
+// creator, the value it is funded with and the params of the exec message.
+type constructor struct {
+	params   []abi.Type
+	newState func(creator address.Address, value *types.AttoFIL, params []interface{}) (interface{}, error)
+}
+
+// constructors are the actors exec can create, indexed by their code.
+var constructors = map[cid.Cid]*constructor{
+	types.MultisigActorCodeCid: {
+		params: []abi.Type{abi.Addresses, abi.Integer},
+		newState: func(creator address.Address, value *types.AttoFIL, params []interface{}) (interface{}, error) {
+			required := params[1].(*big.Int)
+			if !required.IsUint64() {
+				return nil, multisig.Errors[multisig.ErrInvalidSigners]
+			}
+			return multisig.NewState(params[0].([]address.Address), required.Uint64()), nil
+		},
+	},
+	types.VestingActorCodeCid: {
+		params: []abi.Type{abi.Address, abi.BlockHeight, abi.BlockHeight, abi.BlockHeight},
+		newState: func(creator address.Address, value *types.AttoFIL, params []interface{}) (interface{}, error) {
+			start, cliff, end := params[1].(*types.BlockHeight), params[2].(*types.BlockHeight), params[3].(*types.BlockHeight)
+			return vesting.NewState(creator, params[0].(address.Address), value, start, cliff, end), nil
+		},
+	},
+}
+
+// NewActor returns a new init actor.
+func NewActor() *actor.Actor {
+	return actor.NewActor(types.InitActorCodeCid, types.NewZeroAttoFIL())
+}
+
+// InitializeState stores the actor's initial data structure.
+func (a *Actor) InitializeState(storage exec.Storage, initializerData interface{}) error {
+	stateBytes, err := cbor.DumpObject(&State{})
+	if err != nil {
+		return xerrors.Wrap(err, "failed to cbor marshal object")
+	}
+
+	id, err := storage.Put(stateBytes)
+	if err != nil {
+		return err
+	}
+
+	return storage.Commit(id, cid.Undef)
+}
+
+var _ exec.ExecutableActor = (*Actor)(nil)
+
+// Exports returns the actor's exports.
+func (a *Actor) Exports() exec.Exports {
+	return initExports
+}
+
+var initExports = exec.Exports{
+	"exec": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Cid, abi.Bytes},
+		Return: []abi.Type{abi.Address},
+	},
+	"assignID": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.Integer},
+	},
+	"getActorIDForAddress": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address},
+		Return: []abi.Type{abi.Integer},
+	},
+	"getAddressForActorID": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: []abi.Type{abi.Address},
+	},
+}
+
+// Exec creates an actor running code, funded with the value of the message
+// and initialized from params, the abi encoded parameters of the actor's
+// constructor. It assigns the actor the next id and returns its robust
+// address.
+func (a *Actor) Exec(vmctx exec.VMContext, code cid.Cid, params []byte) (address.Address, uint8, error) {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
+		return address.Undef, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	ctor, ok := constructors[code]
+	if !ok {
+		return address.Undef, ErrUnknownCode, Errors[ErrUnknownCode]
+	}
+
+	vals, err := abi.DecodeValues(params, ctor.params)
+	if err != nil || abi.ValidateValues(vals) != nil {
+		return address.Undef, ErrInvalidParams, Errors[ErrInvalidParams]
+	}
+
+	value := vmctx.Message().Value
+	initialState, err := ctor.newState(vmctx.Message().From, value, abi.FromValues(vals))
+	if err != nil {
+		return address.Undef, errors.CodeError(err), err
+	}
+
+	var state State
+	out, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		addr, err := vmctx.AddressForNewActor()
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not get address for new actor")
+		}
+
+		if err := assignID(&state, vmctx.Storage(), addr); err != nil {
+			return nil, err
+		}
+
+		if err := vmctx.CreateNewActor(addr, code, initialState); err != nil {
+			return nil, err
+		}
+
+		if _, _, err := vmctx.Send(addr, "", value, nil); err != nil {
+			return nil, err
+		}
+
+		return addr, nil
+	})
+	if err != nil {
+		return address.Undef, errors.CodeError(err), err
+	}
+
+	return out.(address.Address), 0, nil
+}
+
+// AssignID returns the next id without creating an actor. The payment
+// broker uses it to give payment channels ids that do not depend on the
+// nonce of the payer.
+func (a *Actor) AssignID(vmctx exec.VMContext) (*big.Int, uint8, error) {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	out, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		id := state.NextID
+		state.NextID++
+		return id, nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	return big.NewInt(0).SetUint64(out.(uint64)), 0, nil
+}
+
+// GetActorIDForAddress returns the id of the actor with the given robust
+// address.
+func (a *Actor) GetActorIDForAddress(vmctx exec.VMContext, addr address.Address) (*big.Int, uint8, error) {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	out, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		return find(vmctx.Storage(), state.IDs, addr.String(), uint64(0))
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	return big.NewInt(0).SetUint64(out.(uint64)), 0, nil
+}
+
+// GetAddressForActorID returns the robust address of the actor with the
+// given id.
+func (a *Actor) GetAddressForActorID(vmctx exec.VMContext, id *big.Int) (address.Address, uint8, error) {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
+		return address.Undef, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	if !id.IsUint64() {
+		return address.Undef, ErrNotFound, Errors[ErrNotFound]
+	}
+
+	var state State
+	out, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		return find(vmctx.Storage(), state.Addresses, idKey(id.Uint64()), address.Address{})
+	})
+	if err != nil {
+		return address.Undef, errors.CodeError(err), err
+	}
+
+	return out.(address.Address), 0, nil
+}
+
+// assignID gives the actor at addr the next id and records the mapping in
+// both directions.
+func assignID(state *State, storage exec.Storage, addr address.Address) error {
+	ctx := context.Background()
+
+	id := state.NextID
+	state.NextID++
+
+	var err error
+	state.IDs, err = actor.SetKeyValue(ctx, storage, state.IDs, addr.String(), id)
+	if err != nil {
+		return errors.FaultErrorWrapf(err, "could not set id of actor %s", addr)
+	}
+
+	state.Addresses, err = actor.SetKeyValue(ctx, storage, state.Addresses, idKey(id), addr)
+	if err != nil {
+		return errors.FaultErrorWrapf(err, "could not set address of actor %d", id)
+	}
+
+	return nil
+}
+
+// find looks key up in the lookup stored at c, whose values are of the type
+// of valueType.
+func find(storage exec.Storage, c cid.Cid, key string, valueType interface{}) (interface{}, error) {
+	ctx := context.Background()
+
+	lookup, err := actor.LoadTypedLookup(ctx, storage, c, valueType)
+	if err != nil {
+		return nil, errors.FaultErrorWrapf(err, "could not load lookup with CID: %s", c)
+	}
+
+	value, err := lookup.Find(ctx, key)
+	if err != nil {
+		if err == hamt.ErrNotFound {
+			return nil, Errors[ErrNotFound]
+		}
+		return nil, errors.FaultErrorWrapf(err, "could not find %s in lookup", key)
+	}
+
+	return value, nil
+}
+
+func idKey(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
@@ -0,0 +1,100 @@
+package initactor_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	. "github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+func sendToInit(t *testing.T, st state.Tree, vms vm.StorageMap, value *types.AttoFIL, method string, params ...interface{}) *consensus.ApplicationResult {
+	msg := types.NewMessage(address.TestAddress, address.InitAddress, core.MustGetNonce(st, address.TestAddress), value, method, actor.MustConvertParams(params...))
+	res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+	require.NoError(t, err)
+	return res
+}
+
+func TestInitExec(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	signers := []address.Address{address.TestAddress, address.TestAddress2}
+	ctorParams, err := abi.ToEncodedValues(signers, big.NewInt(1))
+	require.NoError(t, err)
+
+	exec := func() address.Address {
+		res := sendToInit(t, st, vms, types.NewAttoFILFromFIL(10), "exec", types.MultisigActorCodeCid, ctorParams)
+		require.NoError(t, res.ExecutionError)
+		addr, err := address.NewFromBytes(res.Receipt.Return[0])
+		require.NoError(t, err)
+		return addr
+	}
+
+	t.Run("creates a funded actor and assigns it an id", func(t *testing.T) {
+		first := exec()
+		second := exec()
+		assert.NotEqual(t, first, second)
+
+		act, err := st.GetActor(ctx, second)
+		require.NoError(t, err)
+		assert.Equal(t, types.MultisigActorCodeCid, act.Code)
+		assert.Equal(t, types.NewAttoFILFromFIL(10), act.Balance)
+
+		res := sendToInit(t, st, vms, types.NewZeroAttoFIL(), "getActorIDForAddress", first)
+		require.NoError(t, res.ExecutionError)
+		firstID := big.NewInt(0).SetBytes(res.Receipt.Return[0])
+
+		res = sendToInit(t, st, vms, types.NewZeroAttoFIL(), "getActorIDForAddress", second)
+		require.NoError(t, res.ExecutionError)
+		secondID := big.NewInt(0).SetBytes(res.Receipt.Return[0])
+		assert.Equal(t, big.NewInt(0).Add(firstID, big.NewInt(1)), secondID)
+
+		res = sendToInit(t, st, vms, types.NewZeroAttoFIL(), "getAddressForActorID", secondID)
+		require.NoError(t, res.ExecutionError)
+		addr, err := address.NewFromBytes(res.Receipt.Return[0])
+		require.NoError(t, err)
+		assert.Equal(t, second, addr)
+	})
+
+	t.Run("assigns ids without creating actors", func(t *testing.T) {
+		res := sendToInit(t, st, vms, types.NewZeroAttoFIL(), "assignID")
+		require.NoError(t, res.ExecutionError)
+		id := big.NewInt(0).SetBytes(res.Receipt.Return[0])
+
+		res = sendToInit(t, st, vms, types.NewZeroAttoFIL(), "getActorIDForAddress", exec())
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, big.NewInt(0).Add(id, big.NewInt(1)), big.NewInt(0).SetBytes(res.Receipt.Return[0]))
+
+		res = sendToInit(t, st, vms, types.NewZeroAttoFIL(), "getAddressForActorID", id)
+		assert.Equal(t, Errors[ErrNotFound], res.ExecutionError)
+	})
+
+	t.Run("rejects unknown code and mismatched params", func(t *testing.T) {
+		res := sendToInit(t, st, vms, types.NewZeroAttoFIL(), "exec", types.MinerActorCodeCid, ctorParams)
+		assert.Equal(t, Errors[ErrUnknownCode], res.ExecutionError)
+
+		res = sendToInit(t, st, vms, types.NewZeroAttoFIL(), "exec", types.VestingActorCodeCid, ctorParams)
+		assert.Equal(t, Errors[ErrInvalidParams], res.ExecutionError)
+	})
+
+	t.Run("reports addresses without an id", func(t *testing.T) {
+		res := sendToInit(t, st, vms, types.NewZeroAttoFIL(), "getActorIDForAddress", address.TestAddress2)
+		assert.Equal(t, Errors[ErrNotFound], res.ExecutionError)
+	})
+}
@@ -23,6 +23,7 @@ import (
 func init() {
 	cbor.RegisterCborType(State{})
 	cbor.RegisterCborType(Ask{})
+	cbor.RegisterCborType(PreCommittedSector{})
 }
 
 // MaximumPublicKeySize is a limit on how big a public key can be.
@@ -46,6 +47,24 @@ const GracePeriodBlocks = 100
 // See https://github.com/filecoin-project/go-filecoin/issues/1887
 const PieceInclusionGracePeriodBlocks = 10000
 
+// SealTicketMaxAgeBlocks is the maximum number of blocks that may elapse
+// between the height at which a sector's seal ticket was sampled and the
+// precommitment of that sector.
+// TODO: what is a secure value for this? Value is arbitrary right now.
+const SealTicketMaxAgeBlocks = 1000
+
+// ProveCommitDelayBlocks is the number of blocks a miner has to wait after a
+// sector has been precommitted before the interactive seal challenge is
+// sampled from the chain and the sector can be proven.
+// TODO: what is a secure value for this? Value is arbitrary right now.
+const ProveCommitDelayBlocks = 3
+
+// ProveCommitTimeoutBlocks is the number of blocks after a sector has been
+// precommitted after which the precommitment expires and the sector can no
+// longer be proven.
+// TODO: what is a fair value for this? Value is arbitrary right now.
+const ProveCommitTimeoutBlocks = 1000
+
 const (
 	// ErrPublicKeyTooBig indicates an invalid public key.
 	ErrPublicKeyTooBig = 33
@@ -67,6 +86,24 @@ const (
 	ErrInvalidSealProof = 41
 	// ErrGetProofsModeFailed indicates the call to get the proofs mode failed.
 	ErrGetProofsModeFailed = 42
+	// ErrSectorNotPreCommitted indicates the sector has not been precommitted.
+	ErrSectorNotPreCommitted = 43
+	// ErrInvalidSealTicket indicates the seal ticket height is in the future or too old.
+	ErrInvalidSealTicket = 44
+	// ErrProveCommitTooEarly indicates the interactive seal challenge is not yet available.
+	ErrProveCommitTooEarly = 45
+	// ErrPreCommitExpired indicates the sector's precommitment has expired.
+	ErrPreCommitExpired = 46
+	// ErrNoStorageFault indicates the miner has not missed a PoSt deadline.
+	ErrNoStorageFault = 47
+	// ErrInsufficientCollateral indicates the collateral does not cover the pledge.
+	ErrInsufficientCollateral = 48
+	// ErrPledgeInUse indicates the pledge is needed for the miner's committed sectors.
+	ErrPledgeInUse = 49
+	// ErrSectorNotCommitted indicates the sector has not been committed.
+	ErrSectorNotCommitted = 50
+	// ErrNotBootstrapMiner indicates the method may only be called on bootstrap miners.
+	ErrNotBootstrapMiner = 51
 )
 
 // Errors map error codes to revert errors this actor may return.
@@ -81,6 +118,15 @@ var Errors = map[uint8]error{
 	ErrAskNotFound:             errors.NewCodedRevertErrorf(ErrAskNotFound, "no ask was found"),
 	ErrInvalidSealProof:        errors.NewCodedRevertErrorf(ErrInvalidSealProof, "seal proof was invalid"),
 	ErrGetProofsModeFailed:     errors.NewCodedRevertErrorf(ErrGetProofsModeFailed, "failed to get proofs mode"),
+	ErrSectorNotPreCommitted:   errors.NewCodedRevertErrorf(ErrSectorNotPreCommitted, "sector not precommitted"),
+	ErrInvalidSealTicket:       errors.NewCodedRevertErrorf(ErrInvalidSealTicket, "seal ticket must be sampled within %d blocks before precommit", SealTicketMaxAgeBlocks),
+	ErrProveCommitTooEarly:     errors.NewCodedRevertErrorf(ErrProveCommitTooEarly, "sector cannot be proven until %d blocks after precommit", ProveCommitDelayBlocks),
+	ErrPreCommitExpired:        errors.NewCodedRevertErrorf(ErrPreCommitExpired, "sector precommitment expired"),
+	ErrNoStorageFault:          errors.NewCodedRevertErrorf(ErrNoStorageFault, "miner has not missed a PoSt deadline"),
+	ErrInsufficientCollateral:  errors.NewCodedRevertErrorf(ErrInsufficientCollateral, "collateral does not cover the pledge"),
+	ErrPledgeInUse:             errors.NewCodedRevertErrorf(ErrPledgeInUse, "pledge cannot drop below the number of committed sectors"),
+	ErrSectorNotCommitted:      errors.NewCodedRevertErrorf(ErrSectorNotCommitted, "sector not committed"),
+	ErrNotBootstrapMiner:       errors.NewCodedRevertErrorf(ErrNotBootstrapMiner, "only bootstrap miners may commit sectors without precommitting them"),
 }
 
 // Actor is the miner actor.
@@ -104,10 +150,27 @@ type Ask struct {
 	ID     *big.Int
 }
 
+// PreCommittedSector is a sector whose commitments have been posted to chain
+// but which has not yet been proven. The seal ticket binds the replica to the
+// chain at TicketHeight, and the interactive seal challenge is sampled from
+// the chain ProveCommitDelayBlocks after PreCommitHeight.
+type PreCommittedSector struct {
+	Commitments     types.Commitments
+	TicketHeight    *types.BlockHeight
+	Ticket          []byte
+	PreCommitHeight *types.BlockHeight
+}
+
 // State is the miner actors storage.
 type State struct {
+	// Owner is the address of the account that controls the miner's funds
+	// and may change its worker.
 	Owner address.Address
 
+	// Worker is the address of the account whose key signs blocks and
+	// submits PoSts on the miner's behalf. It always corresponds to PublicKey.
+	Worker address.Address
+
 	// PeerID references the libp2p identity that the miner is operating.
 	PeerID peer.ID
 
@@ -132,6 +195,15 @@ type State struct {
 	// See also: https://github.com/polydawn/refmt/issues/35
 	SectorCommitments map[string]types.Commitments
 
+	// SectorCommitHeights maps (stringified) sector id to the block height at
+	// which the sector was committed, from which the miner dates the deals
+	// stored in it.
+	SectorCommitHeights map[string]*types.BlockHeight
+
+	// PreCommittedSectors maps (stringified) sector id to sectors which have
+	// been precommitted but not yet proven.
+	PreCommittedSectors map[string]*PreCommittedSector
+
 	LastUsedSectorID uint64
 
 	ProvingPeriodStart *types.BlockHeight
@@ -146,16 +218,19 @@ func NewActor() *actor.Actor {
 }
 
 // NewState creates a miner state struct
-func NewState(owner address.Address, key []byte, pledge *big.Int, pid peer.ID, collateral *types.AttoFIL) *State {
+func NewState(owner, worker address.Address, key []byte, pledge *big.Int, pid peer.ID, collateral *types.AttoFIL) *State {
 	return &State{
-		Owner:             owner,
-		PeerID:            pid,
-		PublicKey:         key,
-		PledgeSectors:     pledge,
-		Collateral:        collateral,
-		SectorCommitments: make(map[string]types.Commitments),
-		Power:             big.NewInt(0),
-		NextAskID:         big.NewInt(0),
+		Owner:               owner,
+		Worker:              worker,
+		PeerID:              pid,
+		PublicKey:           key,
+		PledgeSectors:       pledge,
+		Collateral:          collateral,
+		SectorCommitments:   make(map[string]types.Commitments),
+		SectorCommitHeights: make(map[string]*types.BlockHeight),
+		PreCommittedSectors: make(map[string]*PreCommittedSector),
+		Power:               big.NewInt(0),
+		NextAskID:           big.NewInt(0),
 	}
 }
 
@@ -200,6 +275,18 @@ var minerExports = exec.Exports{
 		Params: []abi.Type{abi.Integer},
 		Return: []abi.Type{abi.Bytes},
 	},
+	"removeAsk": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: nil,
+	},
+	"getWorker": &exec.FunctionSignature{
+		Params: nil,
+		Return: []abi.Type{abi.Address},
+	},
+	"changeWorker": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Bytes},
+		Return: nil,
+	},
 	"getOwner": &exec.FunctionSignature{
 		Params: nil,
 		Return: []abi.Type{abi.Address},
@@ -212,6 +299,14 @@ var minerExports = exec.Exports{
 		Params: []abi.Type{abi.SectorID, abi.Bytes, abi.Bytes, abi.Bytes, abi.PoRepProof},
 		Return: []abi.Type{},
 	},
+	"preCommitSector": &exec.FunctionSignature{
+		Params: []abi.Type{abi.SectorID, abi.Bytes, abi.Bytes, abi.Bytes, abi.BlockHeight},
+		Return: []abi.Type{},
+	},
+	"proveCommitSector": &exec.FunctionSignature{
+		Params: []abi.Type{abi.SectorID, abi.PoRepProof},
+		Return: []abi.Type{},
+	},
 	"getKey": &exec.FunctionSignature{
 		Params: []abi.Type{},
 		Return: []abi.Type{abi.Bytes},
@@ -228,6 +323,18 @@ var minerExports = exec.Exports{
 		Params: []abi.Type{},
 		Return: []abi.Type{abi.Integer},
 	},
+	"addPledge": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: []abi.Type{},
+	},
+	"withdrawPledge": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: []abi.Type{},
+	},
+	"getCollateral": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.AttoFIL},
+	},
 	"getPower": &exec.FunctionSignature{
 		Params: []abi.Type{},
 		Return: []abi.Type{abi.Integer},
@@ -240,6 +347,10 @@ var minerExports = exec.Exports{
 		Params: []abi.Type{abi.Bytes, abi.SectorID, abi.Bytes},
 		Return: []abi.Type{},
 	},
+	"verifyPieceStorage": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Bytes, abi.SectorID, abi.Bytes},
+		Return: []abi.Type{},
+	},
 	"getProvingPeriodStart": &exec.FunctionSignature{
 		Params: []abi.Type{},
 		Return: []abi.Type{abi.BlockHeight},
@@ -248,10 +359,22 @@ var minerExports = exec.Exports{
 		Params: nil,
 		Return: []abi.Type{abi.CommitmentsMap},
 	},
+	"getSectorCommitHeight": &exec.FunctionSignature{
+		Params: []abi.Type{abi.SectorID},
+		Return: []abi.Type{abi.BlockHeight},
+	},
+	"removeSector": &exec.FunctionSignature{
+		Params: []abi.Type{abi.SectorID},
+		Return: []abi.Type{},
+	},
 	"isBootstrapMiner": &exec.FunctionSignature{
 		Params: nil,
 		Return: []abi.Type{abi.Boolean},
 	},
+	"slashStorageFault": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{},
+	},
 }
 
 // Exports returns the miner actors exported functions.
@@ -262,7 +385,7 @@ func (ma *Actor) Exports() exec.Exports {
 // AddAsk adds an ask to this miners ask list
 func (ma *Actor) AddAsk(ctx exec.VMContext, price *types.AttoFIL, expiry *big.Int) (*big.Int, uint8,
 	error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -309,16 +432,19 @@ func (ma *Actor) AddAsk(ctx exec.VMContext, price *types.AttoFIL, expiry *big.In
 	return askID, 0, nil
 }
 
-// GetAsks returns all the asks for this miner. (TODO: this isnt a great function signature, it returns the asks in a
-// serialized array. Consider doing this some other way)
+// GetAsks returns the ids of all unexpired asks for this miner. (TODO: this isnt a great function signature, it returns
+// the asks in a serialized array. Consider doing this some other way)
 func (ma *Actor) GetAsks(ctx exec.VMContext) ([]uint64, uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 	var state State
 	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
 		var askids []uint64
 		for _, ask := range state.Asks {
+			if !ctx.BlockHeight().LessThan(ask.Expiry) {
+				continue
+			}
 			if !ask.ID.IsUint64() {
 				return nil, errors.NewFaultErrorf("miner ask has invalid ID (bad invariant)")
 			}
@@ -339,9 +465,37 @@ func (ma *Actor) GetAsks(ctx exec.VMContext) ([]uint64, uint8, error) {
 	return askids, 0, nil
 }
 
+// RemoveAsk retires the ask with the given ID before it expires.
+func (ma *Actor) RemoveAsk(ctx exec.VMContext, askid *big.Int) (uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Owner {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		for i, a := range state.Asks {
+			if a.ID.Cmp(askid) == 0 {
+				state.Asks = append(state.Asks[:i], state.Asks[i+1:]...)
+				return nil, nil
+			}
+		}
+
+		return nil, Errors[ErrAskNotFound]
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
 // GetAsk returns an ask by ID
 func (ma *Actor) GetAsk(ctx exec.VMContext, askid *big.Int) ([]byte, uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -380,7 +534,7 @@ func (ma *Actor) GetAsk(ctx exec.VMContext, askid *big.Int) ([]byte, uint8, erro
 
 // GetOwner returns the miners owner.
 func (ma *Actor) GetOwner(ctx exec.VMContext) (address.Address, uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return address.Undef, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -400,9 +554,66 @@ func (ma *Actor) GetOwner(ctx exec.VMContext) (address.Address, uint8, error) {
 	return a, 0, nil
 }
 
+// GetWorker returns the miner's worker.
+func (ma *Actor) GetWorker(ctx exec.VMContext) (address.Address, uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return address.Undef, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		return state.Worker, nil
+	})
+	if err != nil {
+		return address.Undef, errors.CodeError(err), err
+	}
+
+	a, ok := out.(address.Address)
+	if !ok {
+		return address.Undef, 1, errors.NewFaultErrorf("expected an Address return value from call, but got %T instead", out)
+	}
+
+	return a, 0, nil
+}
+
+// ChangeWorker replaces the miner's worker with the account holding the
+// given public key. Only the owner may change the worker. Blocks must be
+// signed and PoSts submitted with the new worker key from then on.
+func (ma *Actor) ChangeWorker(ctx exec.VMContext, key []byte) (uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Owner {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		if len(key) > MaximumPublicKeySize {
+			return nil, Errors[ErrPublicKeyTooBig]
+		}
+
+		worker, err := address.NewSecp256k1Address(key)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not derive worker address from key")
+		}
+
+		state.Worker = worker
+		state.PublicKey = key
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
 // GetLastUsedSectorID returns the last used sector id.
 func (ma *Actor) GetLastUsedSectorID(ctx exec.VMContext) (uint64, uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return 0, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 	var state State
@@ -429,7 +640,7 @@ func (ma *Actor) IsBootstrapMiner(ctx exec.VMContext) (bool, uint8, error) {
 
 // GetSectorCommitments returns all sector commitments posted by this miner.
 func (ma *Actor) GetSectorCommitments(ctx exec.VMContext) (map[string]types.Commitments, uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -450,65 +661,120 @@ func (ma *Actor) GetSectorCommitments(ctx exec.VMContext) (map[string]types.Comm
 }
 
 // CommitSector adds a commitment to the specified sector. The sector must not
-// already be committed.
+// already be committed. CommitSector skips the precommitment and its seal
+// ticket, so it is only for the bootstrap miners gengen sets up in the
+// genesis block. All other miners commit sectors with PreCommitSector and
+// ProveCommitSector.
 func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR, commRStar []byte, proof types.PoRepProof) (uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
-	if len(commD) != int(types.CommitmentBytesLen) {
-		return 1, errors.NewRevertError("invalid sized commD")
+
+	if !ma.Bootstrap {
+		return ErrNotBootstrapMiner, Errors[ErrNotBootstrapMiner]
 	}
-	if len(commR) != int(types.CommitmentBytesLen) {
-		return 1, errors.NewRevertError("invalid sized commR")
+
+	comms, err := commitmentsFromBytes(commD, commR, commRStar)
+	if err != nil {
+		return 1, err
 	}
-	if len(commRStar) != int(types.CommitmentBytesLen) {
-		return 1, errors.NewRevertError("invalid sized commRStar")
+
+	if code, err := ma.verifySealProof(ctx, sectorID, comms, proof); err != nil {
+		return code, err
 	}
 
-	// As with submitPoSt messages, bootstrap miner actors don't verify
-	// the commitSector messages that they are sent.
+	var state State
+	_, err = actor.WithState(ctx, &state, func() (interface{}, error) {
+		// verify that the caller is authorized to perform update
+		if ctx.Message().From != state.Owner {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		return nil, commitSector(ctx, &state, sectorID, comms)
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// PreCommitSector records the commitments of a sealed sector along with the
+// height of the seal ticket the replica was bound to. The sector can be
+// proven with ProveCommitSector once the interactive seal challenge has been
+// sampled from the chain, ProveCommitDelayBlocks after this message lands.
+func (ma *Actor) PreCommitSector(ctx exec.VMContext, sectorID uint64, commD, commR, commRStar []byte, ticketHeight *types.BlockHeight) (uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	comms, err := commitmentsFromBytes(commD, commR, commRStar)
+	if err != nil {
+		return 1, err
+	}
+
+	if ticketHeight == nil || ticketHeight.GreaterEqual(ctx.BlockHeight()) {
+		return ErrInvalidSealTicket, Errors[ErrInvalidSealTicket]
+	}
+	if ticketHeight.Add(types.NewBlockHeight(SealTicketMaxAgeBlocks)).LessThan(ctx.BlockHeight()) {
+		return ErrInvalidSealTicket, Errors[ErrInvalidSealTicket]
+	}
+
+	// As with commitSector messages, bootstrap miner actors don't sample the
+	// chain for seal tickets.
 	//
 	// This switching will be removed when issue #2270 is completed.
+	var ticket []byte
 	if !ma.Bootstrap {
-		// This unfortunate environment variable-checking needs to happen because
-		// the PoRep verification operation needs to know some things (e.g. size)
-		// about the sector for which the proof was generated in order to verify.
-		//
-		// It is undefined behavior for a miner using "LiveProofsMode" to verify
-		// a proof created by a miner in "TestProofsMode"(and vice-versa).
-		//
-		proofsMode, err := GetProofsMode(ctx)
+		ticket, err = ctx.SampleChainRandomness(ticketHeight)
 		if err != nil {
-			return ErrGetProofsModeFailed, Errors[ErrGetProofsModeFailed]
+			return 1, errors.RevertErrorWrap(err, "failed to sample chain for seal ticket")
 		}
+	}
 
-		var sectorSize types.SectorSize
-		if proofsMode == types.TestProofsMode {
-			sectorSize = types.OneKiBSectorSize
-		} else {
-			sectorSize = types.TwoHundredFiftySixMiBSectorSize
+	sectorIDstr := strconv.FormatUint(sectorID, 10)
+
+	var state State
+	_, err = actor.WithState(ctx, &state, func() (interface{}, error) {
+		// verify that the caller is authorized to perform update
+		if ctx.Message().From != state.Owner {
+			return nil, Errors[ErrCallerUnauthorized]
 		}
 
-		req := proofs.VerifySealRequest{}
-		copy(req.CommD[:], commD)
-		copy(req.CommR[:], commR)
-		copy(req.CommRStar[:], commRStar)
-		req.Proof = proof
-		req.ProverID = sectorbuilder.AddressToProverID(ctx.Message().To)
-		req.SectorID = sectorbuilder.SectorIDToBytes(sectorID)
-		req.SectorSize = sectorSize
+		if _, ok := state.SectorCommitments[sectorIDstr]; ok {
+			return nil, Errors[ErrSectorCommitted]
+		}
 
-		res, err := (&proofs.RustVerifier{}).VerifySeal(req)
-		if err != nil {
-			return 1, errors.RevertErrorWrap(err, "failed to verify seal proof")
+		// Sectors are stored by id, so precommitting a sector again simply
+		// replaces the previous (e.g. expired) precommitment.
+		if state.PreCommittedSectors == nil {
+			state.PreCommittedSectors = make(map[string]*PreCommittedSector)
 		}
-		if !res.IsValid {
-			return ErrInvalidSealProof, Errors[ErrInvalidSealProof]
+		state.PreCommittedSectors[sectorIDstr] = &PreCommittedSector{
+			Commitments:     comms,
+			TicketHeight:    ticketHeight,
+			Ticket:          ticket,
+			PreCommitHeight: ctx.BlockHeight(),
 		}
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// ProveCommitSector verifies the seal proof of a precommitted sector and, if
+// it is valid, commits the sector. The proof may only be submitted after the
+// interactive seal challenge has been sampled from the chain and before the
+// precommitment expires.
+func (ma *Actor) ProveCommitSector(ctx exec.VMContext, sectorID uint64, proof types.PoRepProof) (uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
-	// TODO: use uint64 instead of this abomination, once refmt is fixed
-	// https://github.com/polydawn/refmt/issues/35
 	sectorIDstr := strconv.FormatUint(sectorID, 10)
 
 	var state State
@@ -518,33 +784,196 @@ func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR,
 			return nil, Errors[ErrCallerUnauthorized]
 		}
 
-		_, ok := state.SectorCommitments[sectorIDstr]
-		if ok {
-			return nil, Errors[ErrSectorCommitted]
+		preCommit, ok := state.PreCommittedSectors[sectorIDstr]
+		if !ok {
+			return nil, Errors[ErrSectorNotPreCommitted]
 		}
 
-		if state.Power.Cmp(big.NewInt(0)) == 0 {
-			state.ProvingPeriodStart = ctx.BlockHeight()
+		challengeHeight := preCommit.PreCommitHeight.Add(types.NewBlockHeight(ProveCommitDelayBlocks))
+		if ctx.BlockHeight().LessEqual(challengeHeight) {
+			return nil, Errors[ErrProveCommitTooEarly]
 		}
-		inc := big.NewInt(1)
-		state.Power = state.Power.Add(state.Power, inc)
-		comms := types.Commitments{
-			CommD:     types.CommD{},
-			CommR:     types.CommR{},
-			CommRStar: types.CommRStar{},
+
+		expiry := preCommit.PreCommitHeight.Add(types.NewBlockHeight(ProveCommitTimeoutBlocks))
+		if ctx.BlockHeight().GreaterThan(expiry) {
+			return nil, Errors[ErrPreCommitExpired]
+		}
+
+		// TODO: the seal ticket and interactive challenge are not yet inputs to
+		// seal verification in rust-fil-proofs. Once they are, sample the
+		// challenge at challengeHeight and pass it along with the ticket.
+		if _, err := ma.verifySealProof(ctx, sectorID, preCommit.Commitments, proof); err != nil {
+			return nil, err
 		}
-		copy(comms.CommD[:], commD)
-		copy(comms.CommR[:], commR)
-		copy(comms.CommRStar[:], commRStar)
-		state.LastUsedSectorID = sectorID
-		state.SectorCommitments[sectorIDstr] = comms
-		_, ret, err := ctx.Send(address.StorageMarketAddress, "updatePower", nil, []interface{}{inc})
+
+		delete(state.PreCommittedSectors, sectorIDstr)
+
+		return nil, commitSector(ctx, &state, sectorID, preCommit.Commitments)
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// verifySealProof verifies the seal proof for the given sector commitments.
+// Bootstrap miner actors don't verify seal proofs.
+func (ma *Actor) verifySealProof(ctx exec.VMContext, sectorID uint64, comms types.Commitments, proof types.PoRepProof) (uint8, error) {
+	// As with submitPoSt messages, bootstrap miner actors don't verify
+	// the commitSector messages that they are sent.
+	//
+	// This switching will be removed when issue #2270 is completed.
+	if ma.Bootstrap {
+		return 0, nil
+	}
+
+	// This unfortunate environment variable-checking needs to happen because
+	// the PoRep verification operation needs to know some things (e.g. size)
+	// about the sector for which the proof was generated in order to verify.
+	//
+	// It is undefined behavior for a miner using "LiveProofsMode" to verify
+	// a proof created by a miner in "TestProofsMode"(and vice-versa).
+	//
+	proofsMode, err := GetProofsMode(ctx)
+	if err != nil {
+		return ErrGetProofsModeFailed, Errors[ErrGetProofsModeFailed]
+	}
+
+	var sectorSize types.SectorSize
+	if proofsMode == types.TestProofsMode {
+		sectorSize = types.OneKiBSectorSize
+	} else {
+		sectorSize = types.TwoHundredFiftySixMiBSectorSize
+	}
+
+	req := proofs.VerifySealRequest{
+		CommD:      comms.CommD,
+		CommR:      comms.CommR,
+		CommRStar:  comms.CommRStar,
+		Proof:      proof,
+		ProverID:   sectorbuilder.AddressToProverID(ctx.Message().To),
+		SectorID:   sectorbuilder.SectorIDToBytes(sectorID),
+		SectorSize: sectorSize,
+	}
+
+	res, err := (&proofs.RustVerifier{}).VerifySeal(req)
+	if err != nil {
+		return 1, errors.RevertErrorWrap(err, "failed to verify seal proof")
+	}
+	if !res.IsValid {
+		return ErrInvalidSealProof, Errors[ErrInvalidSealProof]
+	}
+
+	return 0, nil
+}
+
+// commitSector records the commitments for the given sector in state and
+// increments the miner's power. It must be called from within WithState.
+func commitSector(ctx exec.VMContext, state *State, sectorID uint64, comms types.Commitments) error {
+	// TODO: use uint64 instead of this abomination, once refmt is fixed
+	// https://github.com/polydawn/refmt/issues/35
+	sectorIDstr := strconv.FormatUint(sectorID, 10)
+
+	_, ok := state.SectorCommitments[sectorIDstr]
+	if ok {
+		return Errors[ErrSectorCommitted]
+	}
+
+	// every committed sector must be covered by the pledge
+	if state.Power.Cmp(state.PledgeSectors) >= 0 {
+		return Errors[ErrInsufficientPledge]
+	}
+
+	if state.Power.Cmp(big.NewInt(0)) == 0 {
+		state.ProvingPeriodStart = ctx.BlockHeight()
+	}
+	inc := big.NewInt(1)
+	state.Power = state.Power.Add(state.Power, inc)
+	state.LastUsedSectorID = sectorID
+	state.SectorCommitments[sectorIDstr] = comms
+	if state.SectorCommitHeights == nil {
+		state.SectorCommitHeights = make(map[string]*types.BlockHeight)
+	}
+	state.SectorCommitHeights[sectorIDstr] = ctx.BlockHeight()
+	_, ret, err := ctx.Send(address.StorageMarketAddress, "updatePower", nil, []interface{}{inc})
+	if err != nil {
+		return err
+	}
+	if ret != 0 {
+		return Errors[ErrStoragemarketCallFailed]
+	}
+	return nil
+}
+
+// GetSectorCommitHeight returns the block height at which the given sector
+// was committed.
+func (ma *Actor) GetSectorCommitHeight(ctx exec.VMContext, sectorID uint64) (*types.BlockHeight, uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	ret, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		sectorIDstr := strconv.FormatUint(sectorID, 10)
+		if _, ok := state.SectorCommitments[sectorIDstr]; !ok {
+			return nil, Errors[ErrSectorNotCommitted]
+		}
+
+		// sectors committed before commit heights were recorded date from
+		// the genesis block
+		height, ok := state.SectorCommitHeights[sectorIDstr]
+		if !ok {
+			return types.NewBlockHeight(0), nil
+		}
+		return height, nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	height, ok := ret.(*types.BlockHeight)
+	if !ok {
+		return nil, 1, errors.NewFaultErrorf("expected *types.BlockHeight to be returned, but got %T instead", ret)
+	}
+
+	return height, 0, nil
+}
+
+// RemoveSector removes a committed sector the miner no longer stores, e.g.
+// because all the deals in it expired, and decrements the miner's power.
+// The sector is no longer challenged by PoSts, and the pledge it used can be
+// withdrawn or used for another sector.
+func (ma *Actor) RemoveSector(ctx exec.VMContext, sectorID uint64) (uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		// sectors are removed by the worker, though the owner may stand in for it
+		if ctx.Message().From != state.Worker && ctx.Message().From != state.Owner {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		sectorIDstr := strconv.FormatUint(sectorID, 10)
+		if _, ok := state.SectorCommitments[sectorIDstr]; !ok {
+			return nil, Errors[ErrSectorNotCommitted]
+		}
+
+		dec := big.NewInt(-1)
+		_, ret, err := ctx.Send(address.StorageMarketAddress, "updatePower", nil, []interface{}{dec})
 		if err != nil {
 			return nil, err
 		}
 		if ret != 0 {
 			return nil, Errors[ErrStoragemarketCallFailed]
 		}
+
+		delete(state.SectorCommitments, sectorIDstr)
+		delete(state.SectorCommitHeights, sectorIDstr)
+		state.Power = state.Power.Add(state.Power, dec)
+
 		return nil, nil
 	})
 	if err != nil {
@@ -554,10 +983,35 @@ func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR,
 	return 0, nil
 }
 
+// commitmentsFromBytes validates the sizes of the given commitments and
+// copies them into a types.Commitments.
+func commitmentsFromBytes(commD, commR, commRStar []byte) (types.Commitments, error) {
+	if len(commD) != int(types.CommitmentBytesLen) {
+		return types.Commitments{}, errors.NewRevertError("invalid sized commD")
+	}
+	if len(commR) != int(types.CommitmentBytesLen) {
+		return types.Commitments{}, errors.NewRevertError("invalid sized commR")
+	}
+	if len(commRStar) != int(types.CommitmentBytesLen) {
+		return types.Commitments{}, errors.NewRevertError("invalid sized commRStar")
+	}
+
+	comms := types.Commitments{
+		CommD:     types.CommD{},
+		CommR:     types.CommR{},
+		CommRStar: types.CommRStar{},
+	}
+	copy(comms.CommD[:], commD)
+	copy(comms.CommR[:], commR)
+	copy(comms.CommRStar[:], commRStar)
+
+	return comms, nil
+}
+
 // VerifyPieceInclusion verifies that proof proves that the data represented by commP is included in the sector.
 // This method returns nothing if the verification succeeds and returns a revert error if verification fails.
 func (ma *Actor) VerifyPieceInclusion(ctx exec.VMContext, commP []byte, sectorID uint64, proof []byte) (uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -581,27 +1035,62 @@ func (ma *Actor) VerifyPieceInclusion(ctx exec.VMContext, commP []byte, sectorID
 			return nil, errors.NewRevertError("proofs out of date")
 		}
 
-		// Verify proof proves CommP is in sector's CommD
-		var typedCommP types.CommP
-		copy(typedCommP[:], commP)
-		valid, err := verifyInclusionProof(typedCommP, commitment.CommD, proof)
-		if err != nil {
-			return nil, err
+		return nil, checkPieceInclusion(commitment, commP, proof)
+	})
+
+	return errors.CodeError(err), err
+}
+
+// VerifyPieceStorage verifies that the miner still stores the data represented
+// by commP in the sector: the sector is committed, the miner is not late
+// submitting the PoSt of its current proving period, and proof proves that
+// commP is included in the sector. It is meant to be the condition of the
+// payment vouchers of a storage deal, see the conditions package, with proof
+// supplied by the miner when redeeming.
+// This method returns nothing if the verification succeeds and returns a revert error if verification fails.
+func (ma *Actor) VerifyPieceStorage(ctx exec.VMContext, commP []byte, sectorID uint64, proof []byte) (uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		commitment, ok := state.SectorCommitments[strconv.FormatUint(sectorID, 10)]
+		if !ok {
+			return nil, errors.NewRevertError("sector not committed")
 		}
 
-		if !valid {
-			return nil, errors.NewRevertError("invalid inclusion proof")
+		// A miner past the deadline of its proving period has not proven it
+		// still stores its sectors.
+		if state.ProvingPeriodStart == nil || ctx.BlockHeight().GreaterThan(provingPeriodDeadline(state)) {
+			return nil, errors.NewRevertError("proofs out of date")
 		}
 
-		return nil, nil
+		return nil, checkPieceInclusion(commitment, commP, proof)
 	})
 
 	return errors.CodeError(err), err
 }
 
+// checkPieceInclusion returns a revert error unless proof proves that commP
+// is included in the sector with commitment.
+func checkPieceInclusion(commitment types.Commitments, commP []byte, proof []byte) error {
+	var typedCommP types.CommP
+	copy(typedCommP[:], commP)
+	valid, err := verifyInclusionProof(typedCommP, commitment.CommD, proof)
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return errors.NewRevertError("invalid inclusion proof")
+	}
+	return nil
+}
+
 // GetKey returns the public key for this miner.
 func (ma *Actor) GetKey(ctx exec.VMContext) ([]byte, uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -623,7 +1112,7 @@ func (ma *Actor) GetKey(ctx exec.VMContext) ([]byte, uint8, error) {
 
 // GetPeerID returns the libp2p peer ID that this miner can be reached at.
 func (ma *Actor) GetPeerID(ctx exec.VMContext) (peer.ID, uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return peer.ID(""), exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -643,7 +1132,7 @@ func (ma *Actor) GetPeerID(ctx exec.VMContext) (peer.ID, uint8, error) {
 
 // UpdatePeerID is used to update the peerID this miner is operating under.
 func (ma *Actor) UpdatePeerID(ctx exec.VMContext, pid peer.ID) (uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -667,7 +1156,7 @@ func (ma *Actor) UpdatePeerID(ctx exec.VMContext, pid peer.ID) (uint8, error) {
 
 // GetPledge returns the number of pledged sectors
 func (ma *Actor) GetPledge(ctx exec.VMContext) (*big.Int, uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -687,9 +1176,131 @@ func (ma *Actor) GetPledge(ctx exec.VMContext) (*big.Int, uint8, error) {
 	return pledgeSectors, 0, nil
 }
 
+// AddPledge increases the miner's pledge by the given number of sectors. The
+// value of the message is added to the miner's collateral, which must cover
+// the new pledge.
+func (ma *Actor) AddPledge(ctx exec.VMContext, sectors *big.Int) (uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	if sectors.Sign() <= 0 {
+		return 1, errors.NewRevertError("must pledge a positive number of sectors")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Owner {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		pledge := big.NewInt(0).Add(state.PledgeSectors, sectors)
+		collateral := state.Collateral.Add(ctx.Message().Value)
+
+		required, err := minimumCollateral(ctx, pledge)
+		if err != nil {
+			return nil, err
+		}
+		if collateral.LessThan(required) {
+			return nil, Errors[ErrInsufficientCollateral]
+		}
+
+		state.PledgeSectors = pledge
+		state.Collateral = collateral
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// WithdrawPledge decreases the miner's pledge by the given number of sectors
+// and returns the collateral no longer needed to the owner. The pledge must
+// still cover every committed sector, as those back the miner's deals.
+func (ma *Actor) WithdrawPledge(ctx exec.VMContext, sectors *big.Int) (uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	if sectors.Sign() <= 0 {
+		return 1, errors.NewRevertError("must withdraw a positive number of sectors")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Owner {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		pledge := big.NewInt(0).Sub(state.PledgeSectors, sectors)
+		if pledge.Cmp(state.Power) < 0 {
+			return nil, Errors[ErrPledgeInUse]
+		}
+
+		required, err := minimumCollateral(ctx, pledge)
+		if err != nil {
+			return nil, err
+		}
+
+		state.PledgeSectors = pledge
+		if state.Collateral.GreaterThan(required) {
+			released := state.Collateral.Sub(required)
+			if _, _, err := ctx.Send(state.Owner, "", released, nil); err != nil {
+				return nil, err
+			}
+			state.Collateral = required
+		}
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// GetCollateral returns the collateral the miner has locked up for its pledge.
+func (ma *Actor) GetCollateral(ctx exec.VMContext) (*types.AttoFIL, uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	ret, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		return state.Collateral, nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	collateral, ok := ret.(*types.AttoFIL)
+	if !ok {
+		return nil, 1, errors.NewFaultErrorf("expected *types.AttoFIL to be returned, but got %T instead", ret)
+	}
+
+	return collateral, 0, nil
+}
+
+// minimumCollateral asks the storage market for the collateral required to
+// pledge the given number of sectors.
+func minimumCollateral(ctx exec.VMContext, sectors *big.Int) (*types.AttoFIL, error) {
+	ret, code, err := ctx.Send(address.StorageMarketAddress, "getMinimumCollateral", types.NewZeroAttoFIL(), []interface{}{sectors})
+	if err != nil {
+		return nil, err
+	}
+	if code != 0 {
+		return nil, Errors[ErrStoragemarketCallFailed]
+	}
+	return types.NewAttoFILFromBytes(ret[0]), nil
+}
+
 // GetPower returns the amount of proven sectors for this miner.
 func (ma *Actor) GetPower(ctx exec.VMContext) (*big.Int, uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -712,14 +1323,14 @@ func (ma *Actor) GetPower(ctx exec.VMContext) (*big.Int, uint8, error) {
 // SubmitPoSt is used to submit a coalesced PoST to the chain to convince the chain
 // that you have been actually storing the files you claim to be.
 func (ma *Actor) SubmitPoSt(ctx exec.VMContext, poStProofs []types.PoStProof) (uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
 	var state State
 	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
-		// verify that the caller is authorized to perform update
-		if ctx.Message().From != state.Owner {
+		// PoSts are submitted by the worker, though the owner may stand in for it
+		if ctx.Message().From != state.Worker && ctx.Message().From != state.Owner {
 			return nil, Errors[ErrCallerUnauthorized]
 		}
 
@@ -796,9 +1407,68 @@ func (ma *Actor) SubmitPoSt(ctx exec.VMContext, poStProofs []types.PoStProof) (u
 	return 0, nil
 }
 
+// SlashStorageFault penalizes a miner that failed to submit a PoSt for its
+// proving period, including the grace period after it. Anyone may send this
+// message once the deadline has passed. The miner's collateral is returned to
+// the network account, which pays out block rewards, and its faulted sectors
+// and all of its power are removed from the storage market.
+func (ma *Actor) SlashStorageFault(ctx exec.VMContext) (uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		// a miner without power has nothing to prove, and has already been
+		// slashed if it ever had any
+		if state.Power.Sign() == 0 {
+			return nil, Errors[ErrNoStorageFault]
+		}
+
+		if ctx.BlockHeight().LessEqual(provingPeriodDeadline(state)) {
+			return nil, Errors[ErrNoStorageFault]
+		}
+
+		if state.Collateral != nil && state.Collateral.IsPositive() {
+			_, _, err := ctx.Send(address.NetworkAddress, "", state.Collateral, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		delta := big.NewInt(0).Neg(state.Power)
+		_, ret, err := ctx.Send(address.StorageMarketAddress, "updatePower", nil, []interface{}{delta})
+		if err != nil {
+			return nil, err
+		}
+		if ret != 0 {
+			return nil, Errors[ErrStoragemarketCallFailed]
+		}
+
+		state.Collateral = types.NewZeroAttoFIL()
+		state.PledgeSectors = big.NewInt(0)
+		state.Power = big.NewInt(0)
+		state.SectorCommitments = make(map[string]types.Commitments)
+		state.SectorCommitHeights = make(map[string]*types.BlockHeight)
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// provingPeriodDeadline returns the last block height at which the miner may
+// submit the PoSt for its current proving period.
+func provingPeriodDeadline(state State) *types.BlockHeight {
+	return state.ProvingPeriodStart.Add(types.NewBlockHeight(ProvingPeriodBlocks + GracePeriodBlocks))
+}
+
 // GetProvingPeriodStart returns the current ProvingPeriodStart value.
 func (ma *Actor) GetProvingPeriodStart(ctx exec.VMContext) (*types.BlockHeight, uint8, error) {
-	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -816,7 +1486,7 @@ func (ma *Actor) GetProvingPeriodStart(ctx exec.VMContext) (*types.BlockHeight,
 }
 
 func currentProvingPeriodPoStChallengeSeed(ctx exec.VMContext, state State) (types.PoStChallengeSeed, error) {
-	bytes, err := ctx.SampleChainRandomness(state.ProvingPeriodStart)
+	bytes, err := ctx.Rand(state.ProvingPeriodStart)
 	if err != nil {
 		return types.PoStChallengeSeed{}, err
 	}
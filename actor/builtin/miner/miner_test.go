@@ -115,6 +115,43 @@ func TestAskFunctions(t *testing.T) {
 	var askids []uint64
 	require.NoError(t, actor.UnmarshalStorage(result.Receipt.Return[0], &askids))
 	assert.Len(t, askids, 2)
+
+	// expired asks are not listed
+	msg = types.NewMessage(address.TestAddress, minerAddr, 6, types.NewZeroAttoFIL(), "getAsks", nil)
+	result, err = th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(203))
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+
+	askids = nil
+	require.NoError(t, actor.UnmarshalStorage(result.Receipt.Return[0], &askids))
+	assert.Equal(t, []uint64{0}, askids)
+
+	// only the owner can remove an ask
+	pdata = actor.MustConvertParams(big.NewInt(0))
+	msg = types.NewMessage(address.TestAddress2, minerAddr, core.MustGetNonce(st, address.TestAddress2), types.NewZeroAttoFIL(), "removeAsk", pdata)
+	result, err = th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(5))
+	require.NoError(t, err)
+	assert.Equal(t, Errors[ErrCallerUnauthorized], result.ExecutionError)
+
+	msg = types.NewMessage(address.TestAddress, minerAddr, 7, types.NewZeroAttoFIL(), "removeAsk", pdata)
+	result, err = th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(5))
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+
+	msg = types.NewMessage(address.TestAddress, minerAddr, 8, types.NewZeroAttoFIL(), "getAsks", nil)
+	result, err = th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(5))
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+
+	askids = nil
+	require.NoError(t, actor.UnmarshalStorage(result.Receipt.Return[0], &askids))
+	assert.Equal(t, []uint64{1}, askids)
+
+	// removing it again fails
+	msg = types.NewMessage(address.TestAddress, minerAddr, 9, types.NewZeroAttoFIL(), "removeAsk", pdata)
+	result, err = th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(5))
+	require.NoError(t, err)
+	assert.Equal(t, Errors[ErrAskNotFound], result.ExecutionError)
 }
 
 func TestGetKey(t *testing.T) {
@@ -133,10 +170,80 @@ func TestGetKey(t *testing.T) {
 	assert.Equal(t, result[0], signature)
 }
 
+func TestGetWorkerAndChangeWorker(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("worker is derived from the miner key and can be changed by the owner", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		st, vms := core.CreateStorages(ctx, t)
+
+		origKey := []byte("my public key")
+		minerAddr := createTestMiner(t, st, vms, address.TestAddress, origKey, th.RequireRandomPeerID(t))
+
+		origWorker, err := address.NewSecp256k1Address(origKey)
+		require.NoError(t, err)
+
+		result := callQueryMethodSuccess("getWorker", ctx, t, st, vms, address.TestAddress, minerAddr)
+		worker, err := address.NewFromBytes(result[0])
+		require.NoError(t, err)
+		assert.Equal(t, origWorker, worker)
+
+		newKey := []byte("my new public key")
+		changeWorkerMsg := types.NewMessage(
+			address.TestAddress,
+			minerAddr,
+			core.MustGetNonce(st, address.TestAddress),
+			types.NewAttoFILFromFIL(0),
+			"changeWorker",
+			actor.MustConvertParams(newKey))
+
+		applyMsgResult, err := th.ApplyTestMessage(st, vms, changeWorkerMsg, types.NewBlockHeight(0))
+		require.NoError(t, err)
+		require.NoError(t, applyMsgResult.ExecutionError)
+		require.Equal(t, uint8(0), applyMsgResult.Receipt.ExitCode)
+
+		newWorker, err := address.NewSecp256k1Address(newKey)
+		require.NoError(t, err)
+
+		result = callQueryMethodSuccess("getWorker", ctx, t, st, vms, address.TestAddress, minerAddr)
+		worker, err = address.NewFromBytes(result[0])
+		require.NoError(t, err)
+		assert.Equal(t, newWorker, worker)
+
+		result = callQueryMethodSuccess("getKey", ctx, t, st, vms, address.TestAddress, minerAddr)
+		assert.Equal(t, newKey, result[0])
+	})
+
+	t.Run("authorization failure while changing worker", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		st, vms := core.CreateStorages(ctx, t)
+
+		minerAddr := createTestMiner(t, st, vms, address.TestAddress, []byte("my public key"), th.RequireRandomPeerID(t))
+
+		// TestAddress2 doesn't own the miner
+		changeWorkerMsg := types.NewMessage(
+			address.TestAddress2,
+			minerAddr,
+			core.MustGetNonce(st, address.TestAddress2),
+			types.NewAttoFILFromFIL(0),
+			"changeWorker",
+			actor.MustConvertParams([]byte("other public key")))
+
+		applyMsgResult, err := th.ApplyTestMessage(st, vms, changeWorkerMsg, types.NewBlockHeight(0))
+		require.NoError(t, err)
+		require.Equal(t, Errors[ErrCallerUnauthorized], applyMsgResult.ExecutionError)
+		require.NotEqual(t, uint8(0), applyMsgResult.Receipt.ExitCode)
+	})
+}
+
 func TestCBOREncodeState(t *testing.T) {
 	tf.UnitTest(t)
 
-	state := NewState(address.TestAddress, []byte{}, big.NewInt(1), th.RequireRandomPeerID(t), types.NewZeroAttoFIL())
+	state := NewState(address.TestAddress, address.TestAddress, []byte{}, big.NewInt(1), th.RequireRandomPeerID(t), types.NewZeroAttoFIL())
 
 	state.SectorCommitments["1"] = types.Commitments{
 		CommD:     types.CommD{},
@@ -302,6 +409,108 @@ func TestMinerCommitSector(t *testing.T) {
 	require.Equal(t, uint8(0x23), res.Receipt.ExitCode)
 }
 
+func TestMinerCommitSectorBootstrapOnly(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	// Miners created after the genesis block are not bootstrap miners.
+	pdata := actor.MustConvertParams(big.NewInt(100), []byte("my public key"), th.RequireRandomPeerID(t))
+	nonce := core.MustGetNonce(st, address.TestAddress)
+	msg := types.NewMessage(address.TestAddress, address.StorageMarketAddress, nonce, types.NewAttoFILFromFIL(100), "createMiner", pdata)
+	createRes, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(1))
+	require.NoError(t, err)
+	minerAddr, err := address.NewFromBytes(createRes.Receipt.Return[0])
+	require.NoError(t, err)
+
+	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", nil, uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+	require.NoError(t, err)
+	assert.EqualError(t, res.ExecutionError, Errors[ErrNotBootstrapMiner].Error())
+	assert.Equal(t, uint8(ErrNotBootstrapMiner), res.Receipt.ExitCode)
+
+	// the sector was not committed
+	result := callQueryMethodSuccess("getPower", ctx, t, st, vms, address.TestAddress, minerAddr)
+	assert.Equal(t, []byte{}, result[0])
+}
+
+func TestMinerPreCommitAndProveCommitSector(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	minerAddr := createTestMiner(t, st, vms, address.TestAddress, []byte("my public key"), th.RequireRandomPeerID(t))
+
+	commR := th.MakeCommitment()
+	commRStar := th.MakeCommitment()
+	commD := th.MakeCommitment()
+	proof := th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen())
+
+	t.Run("proveCommitSector fails if sector has not been precommitted", func(t *testing.T) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "proveCommitSector", nil, uint64(7), proof)
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrSectorNotPreCommitted], res.ExecutionError)
+	})
+
+	t.Run("preCommitSector fails if the seal ticket is not in the past", func(t *testing.T) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "preCommitSector", nil, uint64(7), commD, commR, commRStar, types.NewBlockHeight(3))
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrInvalidSealTicket], res.ExecutionError)
+	})
+
+	t.Run("preCommitSector fails if the seal ticket is too old", func(t *testing.T) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, SealTicketMaxAgeBlocks+3, "preCommitSector", nil, uint64(7), commD, commR, commRStar, types.NewBlockHeight(2))
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrInvalidSealTicket], res.ExecutionError)
+	})
+
+	t.Run("sector is committed after precommit and delayed prove", func(t *testing.T) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 5, "preCommitSector", nil, uint64(1), commD, commR, commRStar, types.NewBlockHeight(2))
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+		require.Equal(t, uint8(0), res.Receipt.ExitCode)
+
+		// precommitting does not add power
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 6, "getPower", nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(0), big.NewInt(0).SetBytes(res.Receipt.Return[0]))
+
+		// the interactive challenge is not available yet
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 5+ProveCommitDelayBlocks, "proveCommitSector", nil, uint64(1), proof)
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrProveCommitTooEarly], res.ExecutionError)
+
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 5+ProveCommitDelayBlocks+1, "proveCommitSector", nil, uint64(1), proof)
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+		require.Equal(t, uint8(0), res.Receipt.ExitCode)
+
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 10, "getPower", nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(1), big.NewInt(0).SetBytes(res.Receipt.Return[0]))
+
+		// the sector cannot be precommitted or proven again
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 11, "preCommitSector", nil, uint64(1), commD, commR, commRStar, types.NewBlockHeight(10))
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrSectorCommitted], res.ExecutionError)
+
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 11, "proveCommitSector", nil, uint64(1), proof)
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrSectorNotPreCommitted], res.ExecutionError)
+	})
+
+	t.Run("proveCommitSector fails once the precommitment has expired", func(t *testing.T) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 20, "preCommitSector", nil, uint64(2), commD, commR, commRStar, types.NewBlockHeight(19))
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 20+ProveCommitTimeoutBlocks+1, "proveCommitSector", nil, uint64(2), proof)
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrPreCommitExpired], res.ExecutionError)
+	})
+}
+
 func TestMinerSubmitPoSt(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -345,6 +554,194 @@ func TestMinerSubmitPoSt(t *testing.T) {
 	require.EqualError(t, res.ExecutionError, "submitted PoSt late, need to pay a fee")
 }
 
+func TestMinerPledgeCollateral(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	minerAddr := createTestMinerWith(10, 1, t, st, vms, address.TestAddress, []byte("my public key"), th.RequireRandomPeerID(t))
+
+	apply := func(val, bh uint64, method string, params ...interface{}) *consensus.ApplicationResult {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, val, bh, method, nil, params...)
+		require.NoError(t, err)
+		return res
+	}
+
+	commitSector := func(sectorID uint64) *consensus.ApplicationResult {
+		return apply(0, 3, "commitSector", sectorID, th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+	}
+
+	getCollateral := func() *types.AttoFIL {
+		res := apply(0, 3, "getCollateral")
+		require.NoError(t, res.ExecutionError)
+		return types.NewAttoFILFromBytes(res.Receipt.Return[0])
+	}
+
+	perSector, _ := types.NewAttoFILFromFILString("0.001")
+
+	res := commitSector(1)
+	require.NoError(t, res.ExecutionError)
+
+	t.Run("withdrawPledge cannot drop the pledge below the committed sectors", func(t *testing.T) {
+		res := apply(0, 3, "withdrawPledge", big.NewInt(10))
+		assert.Equal(t, Errors[ErrPledgeInUse], res.ExecutionError)
+	})
+
+	t.Run("withdrawPledge returns the collateral no longer needed", func(t *testing.T) {
+		ownerActor, err := st.GetActor(ctx, address.TestAddress)
+		require.NoError(t, err)
+		ownerBalance := ownerActor.Balance
+
+		res := apply(0, 3, "withdrawPledge", big.NewInt(9))
+		require.NoError(t, res.ExecutionError)
+
+		assert.Equal(t, perSector, getCollateral())
+
+		minerActor, err := st.GetActor(ctx, minerAddr)
+		require.NoError(t, err)
+		assert.Equal(t, perSector, minerActor.Balance)
+
+		ownerActor, err = st.GetActor(ctx, address.TestAddress)
+		require.NoError(t, err)
+		assert.True(t, ownerActor.Balance.GreaterThan(ownerBalance))
+
+		pledge := callQueryMethodSuccess("getPledge", ctx, t, st, vms, address.TestAddress, minerAddr)
+		assert.Equal(t, big.NewInt(1), big.NewInt(0).SetBytes(pledge[0]))
+	})
+
+	t.Run("commitSector fails once the pledge is used up", func(t *testing.T) {
+		res := commitSector(2)
+		assert.Equal(t, Errors[ErrInsufficientPledge], res.ExecutionError)
+	})
+
+	t.Run("addPledge requires collateral for the new pledge", func(t *testing.T) {
+		res := apply(0, 3, "addPledge", big.NewInt(1))
+		assert.Equal(t, Errors[ErrInsufficientCollateral], res.ExecutionError)
+
+		res = apply(1, 3, "addPledge", big.NewInt(1))
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, perSector.Add(types.NewAttoFILFromFIL(1)), getCollateral())
+
+		res = commitSector(2)
+		require.NoError(t, res.ExecutionError)
+	})
+}
+
+func TestMinerSlashStorageFault(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	minerAddr := createTestMinerWith(100, 100, t, st, vms, address.TestAddress, []byte("my public key"), th.RequireRandomPeerID(t))
+
+	slash := func(bh uint64) *consensus.ApplicationResult {
+		msg := types.NewMessage(address.TestAddress2, minerAddr, core.MustGetNonce(st, address.TestAddress2), types.NewZeroAttoFIL(), "slashStorageFault", nil)
+		res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(bh))
+		require.NoError(t, err)
+		return res
+	}
+
+	getBalance := func(addr address.Address) *types.AttoFIL {
+		act, err := st.GetActor(ctx, addr)
+		require.NoError(t, err)
+		return act.Balance
+	}
+
+	// a miner without power cannot be slashed
+	res := slash(3)
+	assert.Equal(t, Errors[ErrNoStorageFault], res.ExecutionError)
+
+	// committing a sector at height 3 starts the proving period
+	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", nil, uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+	require.NoError(t, err)
+	require.NoError(t, res.ExecutionError)
+
+	totalStorage := callQueryMethodSuccess("getTotalStorage", ctx, t, st, vms, address.TestAddress, address.StorageMarketAddress)
+	require.Equal(t, big.NewInt(1), big.NewInt(0).SetBytes(totalStorage[0]))
+
+	deadline := uint64(3 + ProvingPeriodBlocks + GracePeriodBlocks)
+
+	t.Run("slashStorageFault fails before the PoSt deadline", func(t *testing.T) {
+		res := slash(deadline)
+		assert.Equal(t, Errors[ErrNoStorageFault], res.ExecutionError)
+	})
+
+	t.Run("slashStorageFault removes power and collateral after a missed PoSt", func(t *testing.T) {
+		networkBalance := getBalance(address.NetworkAddress)
+
+		res := slash(deadline + 1)
+		require.NoError(t, res.ExecutionError)
+		require.Equal(t, uint8(0), res.Receipt.ExitCode)
+
+		power := callQueryMethodSuccess("getPower", ctx, t, st, vms, address.TestAddress, minerAddr)
+		assert.Equal(t, big.NewInt(0), big.NewInt(0).SetBytes(power[0]))
+
+		totalStorage := callQueryMethodSuccess("getTotalStorage", ctx, t, st, vms, address.TestAddress, address.StorageMarketAddress)
+		assert.Equal(t, big.NewInt(0), big.NewInt(0).SetBytes(totalStorage[0]))
+
+		assert.True(t, getBalance(minerAddr).IsZero())
+		assert.Equal(t, networkBalance.Add(types.NewAttoFILFromFIL(100)), getBalance(address.NetworkAddress))
+
+		// the miner cannot be slashed twice
+		res = slash(deadline + 2)
+		assert.Equal(t, Errors[ErrNoStorageFault], res.ExecutionError)
+	})
+}
+
+func TestMinerRemoveSector(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	minerAddr := createTestMiner(t, st, vms, address.TestAddress, []byte("my public key"), th.RequireRandomPeerID(t))
+
+	for _, sectorID := range []uint64{1, 2} {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3+sectorID, "commitSector", nil, sectorID, th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+	}
+
+	t.Run("getSectorCommitHeight returns the height a sector was committed at", func(t *testing.T) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 10, "getSectorCommitHeight", nil, uint64(2))
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, types.NewBlockHeight(5), types.NewBlockHeightFromBytes(res.Receipt.Return[0]))
+	})
+
+	t.Run("removeSector fails if the caller is neither worker nor owner", func(t *testing.T) {
+		msg := types.NewMessage(address.TestAddress2, minerAddr, core.MustGetNonce(st, address.TestAddress2), types.NewZeroAttoFIL(), "removeSector", actor.MustConvertParams(uint64(1)))
+		res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(10))
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrCallerUnauthorized], res.ExecutionError)
+	})
+
+	t.Run("removeSector removes the sector and its power", func(t *testing.T) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 10, "removeSector", nil, uint64(1))
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+		require.Equal(t, uint8(0), res.Receipt.ExitCode)
+
+		power := callQueryMethodSuccess("getPower", ctx, t, st, vms, address.TestAddress, minerAddr)
+		assert.Equal(t, big.NewInt(1), big.NewInt(0).SetBytes(power[0]))
+
+		totalStorage := callQueryMethodSuccess("getTotalStorage", ctx, t, st, vms, address.TestAddress, address.StorageMarketAddress)
+		assert.Equal(t, big.NewInt(1), big.NewInt(0).SetBytes(totalStorage[0]))
+
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 10, "getSectorCommitHeight", nil, uint64(1))
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrSectorNotCommitted], res.ExecutionError)
+	})
+
+	t.Run("removeSector fails for sectors which are not committed", func(t *testing.T) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 10, "removeSector", nil, uint64(1))
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrSectorNotCommitted], res.ExecutionError)
+	})
+}
+
 func TestVerifyPIP(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -451,6 +848,58 @@ func TestVerifyPIP(t *testing.T) {
 	})
 }
 
+func TestVerifyPieceStorage(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	ancestors := th.RequireTipSetChain(t, 10)
+	minerAddr := createTestMiner(t, st, vms, address.TestAddress, []byte("my public key"), th.RequireRandomPeerID(t))
+
+	sectorID := uint64(1)
+	commD := th.MakeCommitment()
+
+	// committing the sector at height 3 starts the proving period
+	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", ancestors, sectorID, commD, th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+	require.NoError(t, err)
+	require.NoError(t, res.ExecutionError)
+
+	runVerify := func(t *testing.T, bh uint64, commP []byte, sectorID uint64, proof []byte) error {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, bh, "verifyPieceStorage", ancestors, commP, sectorID, proof)
+		require.NoError(t, err)
+		return res.ExecutionError
+	}
+
+	commP := th.MakeCommitment()
+	// TODO: fake pip, see TestVerifyPIP
+	pip := append(append([]byte{}, commP[:]...), commD[:]...)
+	deadline := uint64(3 + ProvingPeriodBlocks + GracePeriodBlocks)
+
+	t.Run("succeeds until the PoSt deadline", func(t *testing.T) {
+		assert.NoError(t, runVerify(t, 4, commP, sectorID, pip))
+		assert.NoError(t, runVerify(t, deadline, commP, sectorID, pip))
+	})
+
+	t.Run("fails once the miner misses its PoSt", func(t *testing.T) {
+		err := runVerify(t, deadline+1, commP, sectorID, pip)
+		require.Error(t, err)
+		assert.Equal(t, "proofs out of date", err.Error())
+	})
+
+	t.Run("fails for a sector not committed", func(t *testing.T) {
+		err := runVerify(t, 4, commP, sectorID+1, pip)
+		require.Error(t, err)
+		assert.Equal(t, "sector not committed", err.Error())
+	})
+
+	t.Run("fails for an invalid proof", func(t *testing.T) {
+		err := runVerify(t, 4, th.MakeCommitment(), sectorID, pip)
+		require.Error(t, err)
+		assert.Equal(t, "invalid inclusion proof", err.Error())
+	})
+}
+
 func TestGetProofsMode(t *testing.T) {
 	ctx := context.Background()
 	st, vms := core.CreateStorages(ctx, t)
@@ -0,0 +1,334 @@
+package multisig
+
+import (
+	"math/big"
+	"strconv"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	xerrors "github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+func init() {
+	cbor.RegisterCborType(State{})
+	cbor.RegisterCborType(Transaction{})
+}
+
+const (
+	// ErrInvalidSigners indicates the signers or the number of required approvals are invalid.
+	ErrInvalidSigners = 33
+	// ErrNotSigner indicates the sender is not one of the signers.
+	ErrNotSigner = 34
+	// ErrUnknownTransaction indicates there is no pending transaction with the given id.
+	ErrUnknownTransaction = 35
+	// ErrAlreadyApproved indicates the sender has already approved the transaction.
+	ErrAlreadyApproved = 36
+	// ErrNotProposer indicates a transaction was canceled by someone other than its proposer.
+	ErrNotProposer = 37
+	// ErrInvalidParams indicates the params of a proposed transaction do not match their types.
+	ErrInvalidParams = 38
+	// ErrSendToSelf indicates a transaction was proposed to the multisig itself.
+	ErrSendToSelf = 39
+)
+
+// Errors map error codes to revert errors this actor may return.
+var Errors = map[uint8]error{
+	ErrInvalidSigners:     errors.NewCodedRevertError(ErrInvalidSigners, "signers must be distinct and required approvals must be between 1 and the number of signers"),
+	ErrNotSigner:          errors.NewCodedRevertError(ErrNotSigner, "sender is not a signer"),
+	ErrUnknownTransaction: errors.NewCodedRevertError(ErrUnknownTransaction, "unknown transaction"),
+	ErrAlreadyApproved:    errors.NewCodedRevertError(ErrAlreadyApproved, "transaction already approved by sender"),
+	ErrNotProposer:        errors.NewCodedRevertError(ErrNotProposer, "only the proposer may cancel a transaction"),
+	ErrInvalidParams:      errors.NewCodedRevertError(ErrInvalidParams, "transaction params do not match their types"),
+	ErrSendToSelf:         errors.NewCodedRevertError(ErrSendToSelf, "multisig cannot send a transaction to itself"),
+}
+
+// Actor is a multisig account. It holds funds on behalf of a set of signers
+// and only sends a message once enough of them have approved it.
+type Actor struct{}
+
+// State is the multisig actor's storage.
+type State struct {
+	// Signers are the addresses that may propose and approve transactions.
+	Signers []address.Address
+	// Required is the number of approvals a transaction needs to be sent.
+	Required uint64
+	// NextTxID is the id the next proposed transaction will get.
+	NextTxID uint64
+	// Pending are the transactions awaiting approval, keyed by their id in decimal.
+	Pending map[string]*Transaction
+}
+
+// Transaction is a message proposed by one of the signers.
+type Transaction struct {
+	ID         uint64
+	Proposer   address.Address
+	To         address.Address
+	Value      *types.AttoFIL
+	Method     string
+	ParamTypes []uint64
+	Params     []byte
+	Approvals  []address.Address
+}
+
+// NewState creates a multisig state requiring required of signers to approve
+// each transaction.
+func NewState(signers []address.Address, required uint64) *State {
+	return &State{
+		Signers:  signers,
+		Required: required,
+		Pending:  map[string]*Transaction{},
+	}
+}
+
+// NewActor returns a new multisig actor.
+func NewActor() *actor.Actor {
+	return actor.NewActor(types.MultisigActorCodeCid, types.NewZeroAttoFIL())
+}
+
+// InitializeState stores the actor's initial data structure.
+func (msa *Actor) InitializeState(storage exec.Storage, initializerData interface{}) error {
+	multisigState, ok := initializerData.(*State)
+	if !ok {
+		return errors.NewFaultError("Initial state to multisig actor is not a multisig.State struct")
+	}
+
+	if !validSigners(multisigState.Signers, multisigState.Required) {
+		return Errors[ErrInvalidSigners]
+	}
+
+	stateBytes, err := cbor.DumpObject(multisigState)
+	if err != nil {
+		return xerrors.Wrap(err, "failed to cbor marshal object")
+	}
+
+	id, err := storage.Put(stateBytes)
+	if err != nil {
+		return err
+	}
+
+	return storage.Commit(id, cid.Undef)
+}
+
+var _ exec.ExecutableActor = (*Actor)(nil)
+
+// Exports returns the actor's exports.
+func (msa *Actor) Exports() exec.Exports {
+	return multisigExports
+}
+
+var multisigExports = exec.Exports{
+	"propose": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address, abi.AttoFIL, abi.String, abi.UintArray, abi.Bytes},
+		Return: []abi.Type{abi.Integer},
+	},
+	"approve": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: nil,
+	},
+	"cancel": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: nil,
+	},
+	"getSigners": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.Addresses, abi.Integer},
+	},
+}
+
+// Propose proposes a transaction sending value to the given address and
+// calling method with params, encoded as abi values of paramTypes. The
+// proposer's approval is counted, so the transaction is sent right away if
+// it needs only one. It returns the id of the transaction.
+func (msa *Actor) Propose(ctx exec.VMContext, to address.Address, value *types.AttoFIL, method string, paramTypes []uint64, params []byte) (*big.Int, uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	if to == ctx.Message().To {
+		return nil, ErrSendToSelf, Errors[ErrSendToSelf]
+	}
+
+	if _, err := decodeParams(paramTypes, params); err != nil {
+		return nil, ErrInvalidParams, Errors[ErrInvalidParams]
+	}
+
+	var state State
+	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		proposer := ctx.Message().From
+		if !isSigner(state.Signers, proposer) {
+			return nil, Errors[ErrNotSigner]
+		}
+
+		tx := &Transaction{
+			ID:         state.NextTxID,
+			Proposer:   proposer,
+			To:         to,
+			Value:      value,
+			Method:     method,
+			ParamTypes: paramTypes,
+			Params:     params,
+			Approvals:  []address.Address{proposer},
+		}
+		state.NextTxID++
+
+		if err := approveTransaction(ctx, &state, tx); err != nil {
+			return nil, err
+		}
+
+		return big.NewInt(0).SetUint64(tx.ID), nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	return out.(*big.Int), 0, nil
+}
+
+// Approve adds the sender's approval to a pending transaction and sends it
+// once it has the required number of approvals.
+func (msa *Actor) Approve(ctx exec.VMContext, txID *big.Int) (uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		signer := ctx.Message().From
+		if !isSigner(state.Signers, signer) {
+			return nil, Errors[ErrNotSigner]
+		}
+
+		tx, ok := state.Pending[txID.String()]
+		if !ok {
+			return nil, Errors[ErrUnknownTransaction]
+		}
+
+		if isSigner(tx.Approvals, signer) {
+			return nil, Errors[ErrAlreadyApproved]
+		}
+		tx.Approvals = append(tx.Approvals, signer)
+
+		return nil, approveTransaction(ctx, &state, tx)
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// Cancel removes a pending transaction. Only its proposer may cancel it.
+func (msa *Actor) Cancel(ctx exec.VMContext, txID *big.Int) (uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		tx, ok := state.Pending[txID.String()]
+		if !ok {
+			return nil, Errors[ErrUnknownTransaction]
+		}
+
+		if tx.Proposer != ctx.Message().From {
+			return nil, Errors[ErrNotProposer]
+		}
+
+		delete(state.Pending, txID.String())
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// GetSigners returns the signers and the number of approvals a transaction
+// needs.
+func (msa *Actor) GetSigners(ctx exec.VMContext) ([]address.Address, *big.Int, uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return nil, nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		return nil, nil
+	})
+	if err != nil {
+		return nil, nil, errors.CodeError(err), err
+	}
+
+	return state.Signers, big.NewInt(0).SetUint64(state.Required), 0, nil
+}
+
+// approveTransaction sends tx if it has enough approvals and otherwise keeps
+// it pending. A transaction is removed before it is sent, so a failing send
+// reverts the approval along with everything else.
+func approveTransaction(ctx exec.VMContext, state *State, tx *Transaction) error {
+	key := strconv.FormatUint(tx.ID, 10)
+	if uint64(len(tx.Approvals)) < state.Required {
+		if state.Pending == nil {
+			state.Pending = map[string]*Transaction{}
+		}
+		state.Pending[key] = tx
+		return nil
+	}
+	delete(state.Pending, key)
+
+	params, err := decodeParams(tx.ParamTypes, tx.Params)
+	if err != nil {
+		return Errors[ErrInvalidParams]
+	}
+
+	_, _, err = ctx.Send(tx.To, tx.Method, tx.Value, params)
+	return err
+}
+
+func decodeParams(paramTypes []uint64, params []byte) ([]interface{}, error) {
+	types := make([]abi.Type, len(paramTypes))
+	for i, t := range paramTypes {
+		types[i] = abi.Type(t)
+	}
+
+	vals, err := abi.DecodeValues(params, types)
+	if err != nil {
+		return nil, err
+	}
+	if err := abi.ValidateValues(vals); err != nil {
+		return nil, err
+	}
+	return abi.FromValues(vals), nil
+}
+
+func validSigners(signers []address.Address, required uint64) bool {
+	if required == 0 || required > uint64(len(signers)) {
+		return false
+	}
+
+	seen := map[address.Address]bool{}
+	for _, signer := range signers {
+		if seen[signer] {
+			return false
+		}
+		seen[signer] = true
+	}
+	return true
+}
+
+func isSigner(signers []address.Address, addr address.Address) bool {
+	for _, signer := range signers {
+		if signer == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package multisig_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	. "github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+func createTestMultisig(t *testing.T, st state.Tree, vms vm.StorageMap, signers []address.Address, required int64, value *types.AttoFIL) (address.Address, *consensus.ApplicationResult) {
+	ctorParams, err := abi.ToEncodedValues(signers, big.NewInt(required))
+	require.NoError(t, err)
+	pdata := actor.MustConvertParams(types.MultisigActorCodeCid, ctorParams)
+	msg := types.NewMessage(address.TestAddress, address.InitAddress, core.MustGetNonce(st, address.TestAddress), value, "exec", pdata)
+	res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+	require.NoError(t, err)
+	if res.ExecutionError != nil {
+		return address.Undef, res
+	}
+
+	addr, err := address.NewFromBytes(res.Receipt.Return[0])
+	require.NoError(t, err)
+	return addr, res
+}
+
+func TestMultisigCreate(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	signers := []address.Address{address.TestAddress, address.TestAddress2}
+
+	t.Run("creates a funded multisig", func(t *testing.T) {
+		addr, res := createTestMultisig(t, st, vms, signers, 2, types.NewAttoFILFromFIL(100))
+		require.NoError(t, res.ExecutionError)
+
+		msActor, err := st.GetActor(ctx, addr)
+		require.NoError(t, err)
+		assert.Equal(t, types.MultisigActorCodeCid, msActor.Code)
+		assert.Equal(t, types.NewAttoFILFromFIL(100), msActor.Balance)
+
+		var msState State
+		builtin.RequireReadState(t, vms, addr, msActor, &msState)
+		assert.Equal(t, signers, msState.Signers)
+		assert.Equal(t, uint64(2), msState.Required)
+	})
+
+	t.Run("rejects invalid signers", func(t *testing.T) {
+		_, res := createTestMultisig(t, st, vms, signers, 3, types.NewZeroAttoFIL())
+		assert.Equal(t, Errors[ErrInvalidSigners], res.ExecutionError)
+
+		_, res = createTestMultisig(t, st, vms, signers, 0, types.NewZeroAttoFIL())
+		assert.Equal(t, Errors[ErrInvalidSigners], res.ExecutionError)
+
+		_, res = createTestMultisig(t, st, vms, []address.Address{address.TestAddress, address.TestAddress}, 1, types.NewZeroAttoFIL())
+		assert.Equal(t, Errors[ErrInvalidSigners], res.ExecutionError)
+	})
+}
+
+func TestMultisigProposeApproveCancel(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	signers := []address.Address{address.TestAddress, address.TestAddress2}
+	msAddr, res := createTestMultisig(t, st, vms, signers, 2, types.NewAttoFILFromFIL(100))
+	require.NoError(t, res.ExecutionError)
+
+	target := address.NewForTestGetter()()
+
+	send := func(from address.Address, method string, params ...interface{}) *consensus.ApplicationResult {
+		msg := types.NewMessage(from, msAddr, core.MustGetNonce(st, from), types.NewZeroAttoFIL(), method, actor.MustConvertParams(params...))
+		res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(t, err)
+		return res
+	}
+
+	propose := func(from address.Address, value *types.AttoFIL) *big.Int {
+		res := send(from, "propose", target, value, "", []uint64{}, []byte{})
+		require.NoError(t, res.ExecutionError)
+		return big.NewInt(0).SetBytes(res.Receipt.Return[0])
+	}
+
+	getBalance := func(addr address.Address) *types.AttoFIL {
+		act, err := st.GetActor(ctx, addr)
+		require.NoError(t, err)
+		if act.Balance == nil {
+			return types.NewZeroAttoFIL()
+		}
+		return act.Balance
+	}
+
+	t.Run("a transaction is sent once it has enough approvals", func(t *testing.T) {
+		txID := propose(address.TestAddress, types.NewAttoFILFromFIL(10))
+		assert.Equal(t, big.NewInt(0), txID)
+		assert.True(t, getBalance(target).IsZero())
+
+		// the proposer has already approved
+		res := send(address.TestAddress, "approve", txID)
+		assert.Equal(t, Errors[ErrAlreadyApproved], res.ExecutionError)
+
+		res = send(address.TestAddress2, "approve", txID)
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, types.NewAttoFILFromFIL(10), getBalance(target))
+		assert.Equal(t, types.NewAttoFILFromFIL(90), getBalance(msAddr))
+
+		// the transaction is no longer pending
+		res = send(address.TestAddress2, "approve", txID)
+		assert.Equal(t, Errors[ErrUnknownTransaction], res.ExecutionError)
+	})
+
+	t.Run("only signers may propose and approve", func(t *testing.T) {
+		res := send(address.TestAddress, "approve", big.NewInt(0))
+		assert.Equal(t, Errors[ErrUnknownTransaction], res.ExecutionError)
+
+		// TestAddress is not a signer of this multisig
+		other, res := createTestMultisig(t, st, vms, []address.Address{address.TestAddress2}, 1, types.NewAttoFILFromFIL(1))
+		require.NoError(t, res.ExecutionError)
+
+		pdata := actor.MustConvertParams(target, types.NewAttoFILFromFIL(1), "", []uint64{}, []byte{})
+		msg := types.NewMessage(address.TestAddress, other, core.MustGetNonce(st, address.TestAddress), types.NewZeroAttoFIL(), "propose", pdata)
+		res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrNotSigner], res.ExecutionError)
+
+		msg = types.NewMessage(address.TestAddress, other, core.MustGetNonce(st, address.TestAddress), types.NewZeroAttoFIL(), "approve", actor.MustConvertParams(big.NewInt(0)))
+		res, err = th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrNotSigner], res.ExecutionError)
+	})
+
+	t.Run("each proposal gets its own id and only the proposer may cancel it", func(t *testing.T) {
+		txID := propose(address.TestAddress2, types.NewAttoFILFromFIL(5))
+		assert.Equal(t, big.NewInt(1), txID)
+
+		res := send(address.TestAddress, "cancel", txID)
+		assert.Equal(t, Errors[ErrNotProposer], res.ExecutionError)
+
+		res = send(address.TestAddress2, "cancel", txID)
+		require.NoError(t, res.ExecutionError)
+
+		res = send(address.TestAddress, "approve", txID)
+		assert.Equal(t, Errors[ErrUnknownTransaction], res.ExecutionError)
+		assert.Equal(t, types.NewAttoFILFromFIL(10), getBalance(target))
+	})
+
+	t.Run("proposed params must match their types", func(t *testing.T) {
+		res := send(address.TestAddress, "propose", target, types.NewZeroAttoFIL(), "someMethod", []uint64{uint64(abi.Address)}, []byte("garbage"))
+		assert.Equal(t, Errors[ErrInvalidParams], res.ExecutionError)
+
+		res = send(address.TestAddress, "propose", msAddr, types.NewZeroAttoFIL(), "", []uint64{}, []byte{})
+		assert.Equal(t, Errors[ErrSendToSelf], res.ExecutionError)
+	})
+}
@@ -2,7 +2,9 @@ package paymentbroker
 
 import (
 	"context"
+	"math/big"
 
+	"github.com/filecoin-project/go-leb128"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-hamt-ipld"
 	cbor "github.com/ipfs/go-ipld-cbor"
@@ -40,6 +42,10 @@ const (
 	ErrTooEarly = 43
 	//ErrConditionInvalid indicates that the condition attached to a voucher did not execute successfully
 	ErrConditionInvalid = 44
+	// ErrChannelsValueMismatch indicates the value sent to create channels is not the sum of their amounts.
+	ErrChannelsValueMismatch = 45
+	// ErrUnknownScheme indicates an attempt to create a payment channel with an unknown signature scheme.
+	ErrUnknownScheme = 46
 )
 
 // CancelDelayBlockTime is the number of rounds given to the target to respond after the channel
@@ -48,6 +54,10 @@ const (
 // See https://github.com/filecoin-project/go-filecoin/issues/1887
 const CancelDelayBlockTime = 10000
 
+// RedemptionHistoryLength is the number of most recent redemptions a payment
+// channel keeps in its history.
+const RedemptionHistoryLength = 16
+
 // Errors map error codes to revert errors this actor may return.
 var Errors = map[uint8]error{
 	ErrTooEarly:                 errors.NewCodedRevertError(ErrTooEarly, "block height too low to redeem voucher"),
@@ -61,10 +71,105 @@ var Errors = map[uint8]error{
 	ErrExpired:                  errors.NewCodedRevertError(ErrExpired, "block height has exceeded channel's end of life"),
 	ErrAlreadyWithdrawn:         errors.NewCodedRevertError(ErrAlreadyWithdrawn, "update amount has already been redeemed"),
 	ErrInvalidSignature:         errors.NewCodedRevertErrorf(ErrInvalidSignature, "signature failed to validate"),
+	ErrChannelsValueMismatch:    errors.NewCodedRevertError(ErrChannelsValueMismatch, "value sent does not match the sum of the channel amounts"),
+	ErrUnknownScheme:            errors.NewCodedRevertError(ErrUnknownScheme, "unknown voucher signature scheme"),
 }
 
 func init() {
 	cbor.RegisterCborType(PaymentChannel{})
+	cbor.RegisterCborType(ChannelEvent{})
+	cbor.RegisterCborType(Redemption{})
+	cbor.RegisterCborType(ChannelRequest{})
+}
+
+const (
+	// TopicRedeem is the topic of the event emitted when a voucher is redeemed.
+	TopicRedeem = "redeem"
+	// TopicClose is the topic of the event emitted when a channel is closed.
+	TopicClose = "close"
+)
+
+// ChannelEvent is the cbor encoded payload of the events the payment broker
+// emits.
+type ChannelEvent struct {
+	Payer   address.Address  `json:"payer"`
+	Channel *types.ChannelID `json:"channel"`
+	// Amount is the amount of the voucher redeemed or closed with.
+	Amount *types.AttoFIL `json:"amount"`
+}
+
+// ChannelRequest describes a payment channel createChannels creates.
+type ChannelRequest struct {
+	// Target is the address of the account the channel pays.
+	Target address.Address
+	// Eol is the block height at which the channel expires.
+	Eol *types.BlockHeight
+	// Amount is the part of the value of the message deposited in the
+	// channel.
+	Amount *types.AttoFIL
+	// Scheme is the signature scheme of the channel's vouchers, the scheme
+	// of the payer's address if nil.
+	Scheme *SignatureScheme
+}
+
+// ChannelRequests is the parameter of createChannels.
+type ChannelRequests struct {
+	Channels []ChannelRequest
+}
+
+// channelRequestsType is the abi type of *ChannelRequests.
+var channelRequestsType = abi.Struct(&ChannelRequests{})
+
+// Redemption records a voucher redeemed against a payment channel.
+type Redemption struct {
+	// Amount is the amount of the voucher, the total redeemed from the
+	// channel after the redemption.
+	Amount *types.AttoFIL `json:"amount"`
+	// Paid is the amount transferred to the redeemer by the redemption.
+	Paid *types.AttoFIL `json:"paid"`
+	// Height is the block height of the redemption.
+	Height *types.BlockHeight `json:"height"`
+	// Redeemer is the address that redeemed the voucher.
+	Redeemer address.Address `json:"redeemer"`
+}
+
+// SignatureScheme identifies how the vouchers of a payment channel are signed.
+type SignatureScheme uint64
+
+const (
+	// SchemeSecp256k1 is for vouchers signed with a secp256k1 key. It is the
+	// zero value so channels created before schemes were recorded use it.
+	SchemeSecp256k1 = SignatureScheme(iota)
+	// SchemeBLS is for vouchers signed with a BLS key.
+	SchemeBLS
+)
+
+// signatureVerifiers maps each signature scheme to the function verifying
+// signatures of that scheme. Vouchers of unknown schemes never verify.
+var signatureVerifiers = map[SignatureScheme]func(data []byte, addr address.Address, sig types.Signature) bool{
+	SchemeSecp256k1: types.IsValidSignature,
+	SchemeBLS:       types.IsValidBLSSignature,
+}
+
+// SchemeForAddress returns the signature scheme of vouchers signed by addr.
+// Addresses that are not BLS addresses sign with secp256k1.
+func SchemeForAddress(addr address.Address) SignatureScheme {
+	if addr.Protocol() == address.BLS {
+		return SchemeBLS
+	}
+	return SchemeSecp256k1
+}
+
+// channelScheme returns the signature scheme a channel of payer is created
+// with: scheme if it is given, else the scheme of payer's address.
+func channelScheme(payer address.Address, scheme *SignatureScheme) (SignatureScheme, error) {
+	if scheme == nil {
+		return SchemeForAddress(payer), nil
+	}
+	if _, ok := signatureVerifiers[*scheme]; !ok {
+		return 0, Errors[ErrUnknownScheme]
+	}
+	return *scheme, nil
 }
 
 // PaymentChannel records the intent to pay funds to a target account.
@@ -94,6 +199,30 @@ type PaymentChannel struct {
 	// payment channel yet. This is necessary because AmountRedeemed can still be
 	// zero in the event of a zero-value voucher
 	Redeemed bool `json:"redeemed"`
+
+	// Scheme is the signature scheme of the channel's vouchers, fixed when the
+	// channel is created so that it does not change if the payer's wallet does
+	Scheme SignatureScheme `json:"scheme"`
+
+	// History holds the last RedemptionHistoryLength redemptions of the
+	// channel, the oldest first
+	History []*Redemption `json:"history"`
+
+	// Assignments is the number of times the channel was assigned a new
+	// target. Assignment signatures cover it so that they cannot be replayed
+	Assignments uint64 `json:"assignments"`
+
+	// StateVersion is the version of the channel's state in
+	// PaymentChannelSchema
+	StateVersion uint64 `json:"state_version,omitempty" refmt:",omitempty"`
+}
+
+// PaymentChannelSchema is the schema of the state of payment channels. Add a
+// migration and bump its version when a change to PaymentChannel breaks
+// decoding the channels already stored.
+var PaymentChannelSchema = &actor.StateSchema{
+	Version:    0,
+	Migrations: map[uint64]actor.StateMigration{},
 }
 
 // Actor provides a mechanism for off chain payments.
@@ -116,22 +245,34 @@ func (pb *Actor) Exports() exec.Exports {
 var _ exec.ExecutableActor = (*Actor)(nil)
 
 var paymentBrokerExports = exec.Exports{
+	"assignTarget": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address, abi.ChannelID, abi.Address, abi.Bytes, abi.Bytes},
+		Return: nil,
+	},
 	"cancel": &exec.FunctionSignature{
 		Params: []abi.Type{abi.ChannelID},
 		Return: nil,
 	},
 	"close": &exec.FunctionSignature{
-		Params: []abi.Type{abi.Address, abi.ChannelID, abi.AttoFIL, abi.BlockHeight, abi.Predicate, abi.Bytes, abi.Parameters},
+		Params: []abi.Type{abi.Address, abi.ChannelID, abi.AttoFIL, abi.BlockHeight, abi.Predicate, abi.Bytes, abi.Optional(abi.Parameters)},
 		Return: nil,
 	},
 	"createChannel": &exec.FunctionSignature{
-		Params: []abi.Type{abi.Address, abi.BlockHeight},
+		Params: []abi.Type{abi.Address, abi.BlockHeight, abi.Optional(abi.Integer)},
 		Return: []abi.Type{abi.ChannelID},
 	},
+	"createChannels": &exec.FunctionSignature{
+		Params: []abi.Type{channelRequestsType},
+		Return: []abi.Type{abi.UintArray},
+	},
 	"extend": &exec.FunctionSignature{
 		Params: []abi.Type{abi.ChannelID, abi.BlockHeight},
 		Return: nil,
 	},
+	"history": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address, abi.ChannelID},
+		Return: []abi.Type{abi.Bytes},
+	},
 	"ls": &exec.FunctionSignature{
 		Params: []abi.Type{abi.Address},
 		Return: []abi.Type{abi.Bytes},
@@ -141,11 +282,15 @@ var paymentBrokerExports = exec.Exports{
 		Return: nil,
 	},
 	"redeem": &exec.FunctionSignature{
-		Params: []abi.Type{abi.Address, abi.ChannelID, abi.AttoFIL, abi.BlockHeight, abi.Predicate, abi.Bytes, abi.Parameters},
+		Params: []abi.Type{abi.Address, abi.ChannelID, abi.AttoFIL, abi.BlockHeight, abi.Predicate, abi.Bytes, abi.Optional(abi.Parameters)},
+		Return: nil,
+	},
+	"settle": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address, abi.ChannelID},
 		Return: nil,
 	},
 	"voucher": &exec.FunctionSignature{
-		Params: []abi.Type{abi.ChannelID, abi.AttoFIL, abi.BlockHeight, abi.Predicate},
+		Params: []abi.Type{abi.ChannelID, abi.AttoFIL, abi.BlockHeight, abi.Optional(abi.Predicate)},
 		Return: []abi.Type{abi.Bytes},
 	},
 }
@@ -153,12 +298,14 @@ var paymentBrokerExports = exec.Exports{
 // CreateChannel creates a new payment channel from the caller to the target.
 // The value attached to the invocation is used as the deposit, and the channel
 // will expire and return all of its money to the owner after the given block height.
-func (pb *Actor) CreateChannel(vmctx exec.VMContext, target address.Address, eol *types.BlockHeight) (*types.ChannelID, uint8, error) {
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+// The vouchers of the channel are signed with the given signature scheme, by
+// default the scheme of the caller's address.
+func (pb *Actor) CreateChannel(vmctx exec.VMContext, target address.Address, eol *types.BlockHeight, scheme *big.Int) (*types.ChannelID, uint8, error) {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
-	// require that from account be an account actor to ensure nonce is a valid id
+	// require that from account be an account actor, as only accounts can sign vouchers
 	if !vmctx.IsFromAccountActor() {
 		return nil, errors.CodeError(Errors[ErrNonAccountActor]), Errors[ErrNonAccountActor]
 	}
@@ -166,42 +313,132 @@ func (pb *Actor) CreateChannel(vmctx exec.VMContext, target address.Address, eol
 	ctx := context.Background()
 	storage := vmctx.Storage()
 	payerAddress := vmctx.Message().From
-	channelID := types.NewChannelID(uint64(vmctx.Message().Nonce))
+	var channelID *types.ChannelID
+
+	var schemeParam *SignatureScheme
+	if scheme != nil {
+		if !scheme.IsUint64() {
+			return nil, errors.CodeError(Errors[ErrUnknownScheme]), Errors[ErrUnknownScheme]
+		}
+		s := SignatureScheme(scheme.Uint64())
+		schemeParam = &s
+	}
 
 	err := withPayerChannels(ctx, storage, payerAddress, func(byChannelID exec.Lookup) error {
-		// check to see if payment channel is duplicate
-		_, err := byChannelID.Find(ctx, channelID.KeyString())
-		if err != hamt.ErrNotFound { // we expect to not find the payment channel
-			if err == nil {
-				return Errors[ErrDuplicateChannel]
-			}
-			return errors.FaultErrorWrapf(err, "Error retrieving payment channel")
+		id, err := createChannel(ctx, vmctx, byChannelID, target, eol, vmctx.Message().Value, schemeParam)
+		channelID = types.NewChannelID(id)
+		return err
+	})
+
+	if err != nil {
+		// ensure error is properly wrapped
+		if !errors.IsFault(err) && !errors.ShouldRevert(err) {
+			return nil, 1, errors.FaultErrorWrap(err, "Error creating payment channel")
 		}
+		return nil, errors.CodeError(err), err
+	}
 
-		// add payment channel and commit
-		err = byChannelID.Set(ctx, channelID.KeyString(), &PaymentChannel{
-			Target:         target,
-			Amount:         vmctx.Message().Value,
-			AmountRedeemed: types.NewAttoFILFromFIL(0),
-			AgreedEol:      eol,
-			Eol:            eol,
-		})
-		if err != nil {
-			return errors.FaultErrorWrap(err, "Could not set payment channel")
+	return channelID, 0, nil
+}
+
+// CreateChannels creates a payment channel from the caller for each of the
+// requests, splitting the value attached to the invocation between them, so
+// that a payer opening channels to several targets sends a single message.
+// The value must be the sum of the amounts of the requests. It returns the
+// ids of the channels in the order of the requests.
+func (pb *Actor) CreateChannels(vmctx exec.VMContext, requests *ChannelRequests) ([]uint64, uint8, error) {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	// require that from account be an account actor, as only accounts can sign vouchers
+	if !vmctx.IsFromAccountActor() {
+		return nil, errors.CodeError(Errors[ErrNonAccountActor]), Errors[ErrNonAccountActor]
+	}
+
+	var reqs []ChannelRequest
+	if requests != nil {
+		reqs = requests.Channels
+	}
+
+	total := types.NewZeroAttoFIL()
+	for _, req := range reqs {
+		if req.Amount == nil || req.Eol == nil {
+			return nil, errors.CodeError(Errors[ErrChannelsValueMismatch]), Errors[ErrChannelsValueMismatch]
 		}
+		total = total.Add(req.Amount)
+	}
+	if !total.Equal(vmctx.Message().Value) {
+		return nil, errors.CodeError(Errors[ErrChannelsValueMismatch]), Errors[ErrChannelsValueMismatch]
+	}
 
+	ctx := context.Background()
+	storage := vmctx.Storage()
+	payerAddress := vmctx.Message().From
+	var ids []uint64
+
+	err := withPayerChannels(ctx, storage, payerAddress, func(byChannelID exec.Lookup) error {
+		for _, req := range reqs {
+			id, err := createChannel(ctx, vmctx, byChannelID, req.Target, req.Eol, req.Amount, req.Scheme)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
 		return nil
 	})
 
 	if err != nil {
 		// ensure error is properly wrapped
 		if !errors.IsFault(err) && !errors.ShouldRevert(err) {
-			return nil, 1, errors.FaultErrorWrap(err, "Error creating payment channel")
+			return nil, 1, errors.FaultErrorWrap(err, "Error creating payment channels")
 		}
 		return nil, errors.CodeError(err), err
 	}
 
-	return channelID, 0, nil
+	return ids, 0, nil
+}
+
+// createChannel adds a channel from the caller to target holding amount to
+// byChannelID, the channels of the caller, and returns its id. The vouchers
+// of the channel are signed with scheme, see channelScheme.
+func createChannel(ctx context.Context, vmctx exec.VMContext, byChannelID exec.Lookup, target address.Address, eol *types.BlockHeight, amount *types.AttoFIL, scheme *SignatureScheme) (uint64, error) {
+	sigScheme, err := channelScheme(vmctx.Message().From, scheme)
+	if err != nil {
+		return 0, err
+	}
+
+	// the init actor hands out channel ids, so they do not depend on the payer's nonce
+	ret, _, err := vmctx.Send(address.InitAddress, "assignID", types.NewZeroAttoFIL(), nil)
+	if err != nil {
+		return 0, err
+	}
+	id := big.NewInt(0).SetBytes(ret[0]).Uint64()
+	channelID := types.NewChannelID(id)
+
+	// check to see if payment channel is duplicate
+	_, err = byChannelID.Find(ctx, channelID.KeyString())
+	if err != hamt.ErrNotFound { // we expect to not find the payment channel
+		if err == nil {
+			return 0, Errors[ErrDuplicateChannel]
+		}
+		return 0, errors.FaultErrorWrapf(err, "Error retrieving payment channel")
+	}
+
+	// add payment channel and commit
+	err = byChannelID.Set(ctx, channelID.KeyString(), &PaymentChannel{
+		Target:         target,
+		Amount:         amount,
+		AmountRedeemed: types.NewAttoFILFromFIL(0),
+		AgreedEol:      eol,
+		Eol:            eol,
+		Scheme:         sigScheme,
+	})
+	if err != nil {
+		return 0, errors.FaultErrorWrap(err, "Could not set payment channel")
+	}
+
+	return id, nil
 }
 
 // Redeem is called by the target account to withdraw funds with authorization from the payer.
@@ -217,20 +454,19 @@ func (pb *Actor) CreateChannel(vmctx exec.VMContext, target address.Address, eol
 // target Close(500)           -> Payer: 1500, Target: 500, Channel: 0
 //
 // If a condition is provided in the voucher:
-// - The parameters provided in the condition will be combined with redeemerConditionParams
+// - The parameters provided in the condition will be combined with redeemerConditionParams,
+//   which are optional and may be left out of the message
 // - A message will be sent to the the condition.To address using the condition.Method with the combined params
 // - If the message returns an error the condition is considered to be false and the redeem will fail
 func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types.ChannelID, amt *types.AttoFIL,
 	validAt *types.BlockHeight, condition *types.Predicate, sig []byte, redeemerConditionParams []interface{}) (uint8, error) {
 
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	// the voucher signature is checked too
+	gasTable := vmctx.GasTable()
+	if err := vmctx.Charge(gasTable.MethodCall + gasTable.SignatureVerification); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
-	if !VerifyVoucherSignature(payer, chid, amt, validAt, condition, sig) {
-		return errors.CodeError(Errors[ErrInvalidSignature]), Errors[ErrInvalidSignature]
-	}
-
 	ctx := context.Background()
 	storage := vmctx.Storage()
 
@@ -248,6 +484,10 @@ func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types
 			return errors.NewFaultError("Expected PaymentChannel from channels lookup")
 		}
 
+		if !VerifyVoucherSignature(channel.Scheme, payer, chid, amt, validAt, condition, sig) {
+			return Errors[ErrInvalidSignature]
+		}
+
 		// validate the amount can be sent to the target and send payment to that address.
 		err = validateAndUpdateChannel(vmctx, vmctx.Message().From, channel, amt, validAt, condition, redeemerConditionParams)
 		if err != nil {
@@ -272,6 +512,10 @@ func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types
 		return errors.CodeError(err), err
 	}
 
+	if err := emitChannelEvent(vmctx, TopicRedeem, payer, chid, amt); err != nil {
+		return errors.CodeError(err), err
+	}
+
 	return 0, nil
 }
 
@@ -279,20 +523,19 @@ func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types
 // funds remaining in the channel to the payer account and deletes the channel.
 //
 // If a condition is provided in the voucher:
-// - The parameters provided in the condition will be combined with redeemerConditionParams
+// - The parameters provided in the condition will be combined with redeemerConditionParams,
+//   which are optional and may be left out of the message
 // - A message will be sent to the the condition.To address using the condition.Method with the combined params
 // - If the message returns an error the condition is considered to be false and the redeem will fail
 func (pb *Actor) Close(vmctx exec.VMContext, payer address.Address, chid *types.ChannelID, amt *types.AttoFIL,
 	validAt *types.BlockHeight, condition *types.Predicate, sig []byte, redeemerConditionParams []interface{}) (uint8, error) {
 
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	// the voucher signature is checked too
+	gasTable := vmctx.GasTable()
+	if err := vmctx.Charge(gasTable.MethodCall + gasTable.SignatureVerification); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
-	if !VerifyVoucherSignature(payer, chid, amt, validAt, condition, sig) {
-		return errors.CodeError(Errors[ErrInvalidSignature]), Errors[ErrInvalidSignature]
-	}
-
 	ctx := context.Background()
 	storage := vmctx.Storage()
 
@@ -310,6 +553,10 @@ func (pb *Actor) Close(vmctx exec.VMContext, payer address.Address, chid *types.
 			return errors.NewFaultError("Expected PaymentChannel from channels lookup")
 		}
 
+		if !VerifyVoucherSignature(channel.Scheme, payer, chid, amt, validAt, condition, sig) {
+			return Errors[ErrInvalidSignature]
+		}
+
 		// validate the amount can be sent to the target and send payment to that address.
 		err = validateAndUpdateChannel(vmctx, vmctx.Message().From, channel, amt, validAt, condition, redeemerConditionParams)
 		if err != nil {
@@ -333,13 +580,17 @@ func (pb *Actor) Close(vmctx exec.VMContext, payer address.Address, chid *types.
 		return errors.CodeError(err), err
 	}
 
+	if err := emitChannelEvent(vmctx, TopicClose, payer, chid, amt); err != nil {
+		return errors.CodeError(err), err
+	}
+
 	return 0, nil
 }
 
 // Extend can be used by the owner of a channel to add more funds to it and
 // extend the Channel's lifespan.
 func (pb *Actor) Extend(vmctx exec.VMContext, chid *types.ChannelID, eol *types.BlockHeight) (uint8, error) {
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -392,7 +643,7 @@ func (pb *Actor) Extend(vmctx exec.VMContext, chid *types.ChannelID, eol *types.
 // their payments. In the time before the channel is closed, a target can
 // potentially dispute a closer.
 func (pb *Actor) Cancel(vmctx exec.VMContext, chid *types.ChannelID) (uint8, error) {
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -414,20 +665,115 @@ func (pb *Actor) Cancel(vmctx exec.VMContext, chid *types.ChannelID) (uint8, err
 			return errors.NewFaultError("Expected PaymentChannel from channels lookup")
 		}
 
-		eol := vmctx.BlockHeight().Add(types.NewBlockHeight(CancelDelayBlockTime))
+		startSettlement(vmctx, channel)
 
-		// eol can only be decreased
-		if channel.Eol.GreaterThan(eol) {
-			channel.Eol = eol
+		return byChannelID.Set(ctx, chid.KeyString(), channel)
+	})
+
+	if err != nil {
+		// ensure error is properly wrapped
+		if !errors.IsFault(err) && !errors.ShouldRevert(err) {
+			return 1, errors.FaultErrorWrap(err, "Error cancelling channel")
+		}
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// Settle is Cancel for the target of a channel: it lowers the EOL of the
+// payment channel of payer to 1 blocktime from now, so that a target done
+// with a channel does not have to wait for an unresponsive payer to cancel it
+// or for its EOL. The target should redeem its last voucher first, as
+// redeeming resets the EOL.
+func (pb *Actor) Settle(vmctx exec.VMContext, payer address.Address, chid *types.ChannelID) (uint8, error) {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	ctx := context.Background()
+	storage := vmctx.Storage()
+
+	err := withPayerChannels(ctx, storage, payer, func(byChannelID exec.Lookup) error {
+		chInt, err := byChannelID.Find(ctx, chid.KeyString())
+		if err != nil {
+			if err == hamt.ErrNotFound {
+				return Errors[ErrUnknownChannel]
+			}
+			return errors.FaultErrorWrapf(err, "Could not retrieve payment channel with ID: %s", chid)
+		}
+
+		channel, ok := chInt.(*PaymentChannel)
+		if !ok {
+			return errors.NewFaultError("Expected PaymentChannel from channels lookup")
 		}
 
+		if vmctx.Message().From != channel.Target {
+			return Errors[ErrWrongTarget]
+		}
+
+		startSettlement(vmctx, channel)
+
 		return byChannelID.Set(ctx, chid.KeyString(), channel)
 	})
 
 	if err != nil {
 		// ensure error is properly wrapped
 		if !errors.IsFault(err) && !errors.ShouldRevert(err) {
-			return 1, errors.FaultErrorWrap(err, "Error cancelling channel")
+			return 1, errors.FaultErrorWrap(err, "Error settling channel")
+		}
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// AssignTarget moves the channel of payer to newTarget, along with its
+// unredeemed balance, for instance when a miner rotates the key it is paid
+// to. Both the payer and the current target must sign the assignment, see
+// SignAssignment. Anyone may send the message. Vouchers the payer signed
+// for the channel remain valid for the new target.
+func (pb *Actor) AssignTarget(vmctx exec.VMContext, payer address.Address, chid *types.ChannelID, newTarget address.Address, payerSig []byte, targetSig []byte) (uint8, error) {
+	// both signatures are checked
+	gasTable := vmctx.GasTable()
+	if err := vmctx.Charge(gasTable.MethodCall + 2*gasTable.SignatureVerification); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	ctx := context.Background()
+	storage := vmctx.Storage()
+
+	err := withPayerChannels(ctx, storage, payer, func(byChannelID exec.Lookup) error {
+		chInt, err := byChannelID.Find(ctx, chid.KeyString())
+		if err != nil {
+			if err == hamt.ErrNotFound {
+				return Errors[ErrUnknownChannel]
+			}
+			return errors.FaultErrorWrapf(err, "Could not retrieve payment channel with ID: %s", chid)
+		}
+
+		channel, ok := chInt.(*PaymentChannel)
+		if !ok {
+			return errors.NewFaultError("Expected PaymentChannel from channels lookup")
+		}
+
+		if !VerifyAssignmentSignature(channel.Scheme, payer, payer, chid, newTarget, channel.Assignments, payerSig) {
+			return Errors[ErrInvalidSignature]
+		}
+		if !VerifyAssignmentSignature(SchemeForAddress(channel.Target), channel.Target, payer, chid, newTarget, channel.Assignments, targetSig) {
+			return Errors[ErrInvalidSignature]
+		}
+
+		channel.Target = newTarget
+		channel.Assignments++
+
+		return byChannelID.Set(ctx, chid.KeyString(), channel)
+	})
+
+	if err != nil {
+		// ensure error is properly wrapped
+		if !errors.IsFault(err) && !errors.ShouldRevert(err) {
+			return 1, errors.FaultErrorWrap(err, "Error assigning channel target")
 		}
 		return errors.CodeError(err), err
 	}
@@ -438,7 +784,7 @@ func (pb *Actor) Cancel(vmctx exec.VMContext, chid *types.ChannelID) (uint8, err
 // Reclaim is used by the owner of a channel to reclaim unspent funds in timed
 // out payment Channels they own.
 func (pb *Actor) Reclaim(vmctx exec.VMContext, chid *types.ChannelID) (uint8, error) {
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -489,7 +835,7 @@ func (pb *Actor) Reclaim(vmctx exec.VMContext, chid *types.ChannelID) (uint8, er
 // first send a message based on the condition and require a successful response
 // for funds to be transferred.
 func (pb *Actor) Voucher(vmctx exec.VMContext, chid *types.ChannelID, amount *types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate) ([]byte, uint8, error) {
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
 		return []byte{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -551,7 +897,7 @@ func (pb *Actor) Voucher(vmctx exec.VMContext, chid *types.ChannelID, amount *ty
 // Ls returns all payment channels for a given payer address.
 // The slice of channels will be returned as cbor encoded map from string channelId to PaymentChannel.
 func (pb *Actor) Ls(vmctx exec.VMContext, payer address.Address) ([]byte, uint8, error) {
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
 		return []byte{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -592,6 +938,100 @@ func (pb *Actor) Ls(vmctx exec.VMContext, payer address.Address) ([]byte, uint8,
 	return channelsBytes, 0, nil
 }
 
+// History returns the most recent redemptions of the channel chid of payer,
+// the oldest first, as a cbor encoded slice of Redemption. Channels are only
+// unique per payer, so the payer is needed to find the channel.
+func (pb *Actor) History(vmctx exec.VMContext, payer address.Address, chid *types.ChannelID) ([]byte, uint8, error) {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
+		return []byte{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	ctx := context.Background()
+	storage := vmctx.Storage()
+	history := []*Redemption{}
+
+	err := withPayerChannelsForReading(ctx, storage, payer, func(byChannelID exec.Lookup) error {
+		chInt, err := byChannelID.Find(ctx, chid.KeyString())
+		if err != nil {
+			if err == hamt.ErrNotFound {
+				return Errors[ErrUnknownChannel]
+			}
+			return errors.FaultErrorWrapf(err, "Could not retrieve payment channel with ID: %s", chid)
+		}
+
+		channel, ok := chInt.(*PaymentChannel)
+		if !ok {
+			return errors.NewFaultError("Expected PaymentChannel from channels lookup")
+		}
+		if channel.History != nil {
+			history = channel.History
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		// ensure error is properly wrapped
+		if !errors.IsFault(err) && !errors.ShouldRevert(err) {
+			return nil, 1, errors.FaultErrorWrap(err, "Error reading channel history")
+		}
+		return nil, errors.CodeError(err), err
+	}
+
+	historyBytes, err := actor.MarshalStorage(history)
+	if err != nil {
+		return nil, 1, errors.FaultErrorWrap(err, "Error marshalling channel history")
+	}
+
+	return historyBytes, 0, nil
+}
+
+// AllChannels returns all the payment channels recorded in the payment
+// broker's storage, indexed by the payer and then by the channel id. It only
+// reads storage and is meant for inspecting the state outside of the VM.
+func AllChannels(ctx context.Context, storage exec.Storage) (map[string]map[string]*PaymentChannel, error) {
+	channels := map[string]map[string]*PaymentChannel{}
+
+	byPayer, err := actor.LoadLookup(ctx, storage, storage.Head())
+	if err != nil {
+		return nil, err
+	}
+
+	payers, err := byPayer.Values(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, payer := range payers {
+		byChannelCID, ok := payer.Value.(cid.Cid)
+		if !ok {
+			return nil, errors.NewFaultError("Paymentbroker payer is not a Cid")
+		}
+
+		byChannelID, err := actor.LoadVersionedLookup(ctx, storage, byChannelCID, &PaymentChannel{}, PaymentChannelSchema)
+		if err != nil {
+			return nil, err
+		}
+
+		kvs, err := byChannelID.Values(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		payerChannels := map[string]*PaymentChannel{}
+		for _, kv := range kvs {
+			pc, ok := kv.Value.(*PaymentChannel)
+			if !ok {
+				return nil, errors.NewFaultError("Expected PaymentChannel from channel lookup")
+			}
+			payerChannels[kv.Key] = pc
+		}
+		channels[payer.Key] = payerChannels
+	}
+
+	return channels, nil
+}
+
 func validateAndUpdateChannel(ctx exec.VMContext, target address.Address, channel *PaymentChannel, amt *types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate, redeemerSuppliedParams []interface{}) error {
 	if err := checkCondition(ctx, channel, condition, redeemerSuppliedParams); err != nil {
 		return err
@@ -627,9 +1067,30 @@ func validateAndUpdateChannel(ctx exec.VMContext, target address.Address, channe
 	// update amount redeemed from this channel
 	channel.AmountRedeemed = amt
 
+	channel.History = append(channel.History, &Redemption{
+		Amount:   amt,
+		Paid:     updateAmount,
+		Height:   ctx.BlockHeight(),
+		Redeemer: ctx.Message().From,
+	})
+	if len(channel.History) > RedemptionHistoryLength {
+		channel.History = channel.History[len(channel.History)-RedemptionHistoryLength:]
+	}
+
 	return nil
 }
 
+// startSettlement lowers the eol of channel to CancelDelayBlockTime from now,
+// leaving the target that long to redeem its vouchers.
+func startSettlement(vmctx exec.VMContext, channel *PaymentChannel) {
+	eol := vmctx.BlockHeight().Add(types.NewBlockHeight(CancelDelayBlockTime))
+
+	// eol can only be decreased
+	if channel.Eol.GreaterThan(eol) {
+		channel.Eol = eol
+	}
+}
+
 func reclaim(ctx context.Context, vmctx exec.VMContext, byChannelID exec.Lookup, payer address.Address, chid *types.ChannelID, channel *PaymentChannel) error {
 	amt := channel.Amount.Sub(channel.AmountRedeemed)
 	if amt.LessEqual(types.ZeroAttoFIL) {
@@ -655,6 +1116,15 @@ func reclaim(ctx context.Context, vmctx exec.VMContext, byChannelID exec.Lookup,
 // voucher signature.
 const separator = 0x0
 
+// emitChannelEvent emits an event with the given topic about a payment channel.
+func emitChannelEvent(vmctx exec.VMContext, topic string, payer address.Address, chid *types.ChannelID, amt *types.AttoFIL) error {
+	payload, err := cbor.DumpObject(&ChannelEvent{Payer: payer, Channel: chid, Amount: amt})
+	if err != nil {
+		return errors.FaultErrorWrap(err, "failed to encode channel event")
+	}
+	return vmctx.EmitEvent(topic, payload)
+}
+
 // SignVoucher creates the signature for the given combination of
 // channel, amount, validAt (earliest block height for redeem) and from address.
 // It does so by signing the following bytes: (channelID | 0x0 | amount | 0x0 | validAt)
@@ -667,13 +1137,54 @@ func SignVoucher(channelID *types.ChannelID, amount *types.AttoFIL, validAt *typ
 }
 
 // VerifyVoucherSignature returns whether the voucher's signature is valid
-func VerifyVoucherSignature(payer address.Address, chid *types.ChannelID, amt *types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate, sig []byte) bool {
+// under the given signature scheme, the scheme of the voucher's channel.
+func VerifyVoucherSignature(scheme SignatureScheme, payer address.Address, chid *types.ChannelID, amt *types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate, sig []byte) bool {
+	verify, ok := signatureVerifiers[scheme]
+	if !ok {
+		return false
+	}
+
 	data, err := createVoucherSignatureData(chid, amt, validAt, condition)
 	// the only error is failure to encode the values
 	if err != nil {
 		return false
 	}
-	return types.IsValidSignature(data, payer, sig)
+	return verify(data, payer, sig)
+}
+
+// SignAssignment returns the signature of addr, the payer or the current
+// target of the channel of payer, on the assignment of the channel to
+// newTarget. assignments is the number of times the channel was assigned a
+// new target before.
+func SignAssignment(payer address.Address, channelID *types.ChannelID, newTarget address.Address, assignments uint64, addr address.Address, signer types.Signer) (types.Signature, error) {
+	return signer.SignBytes(createAssignmentSignatureData(payer, channelID, newTarget, assignments), addr)
+}
+
+// VerifyAssignmentSignature returns whether sig is the signature of signer
+// under the given signature scheme on the assignment of the channel of payer
+// to newTarget.
+func VerifyAssignmentSignature(scheme SignatureScheme, signer address.Address, payer address.Address, chid *types.ChannelID, newTarget address.Address, assignments uint64, sig []byte) bool {
+	verify, ok := signatureVerifiers[scheme]
+	if !ok {
+		return false
+	}
+	return verify(createAssignmentSignatureData(payer, chid, newTarget, assignments), signer, sig)
+}
+
+// assignmentSignaturePrefix starts assignment signature data so that it
+// cannot be mistaken for a voucher.
+var assignmentSignaturePrefix = []byte("assignTarget")
+
+func createAssignmentSignatureData(payer address.Address, channelID *types.ChannelID, newTarget address.Address, assignments uint64) []byte {
+	data := append([]byte{}, assignmentSignaturePrefix...)
+	data = append(data, separator)
+	data = append(data, payer.Bytes()...)
+	data = append(data, separator)
+	data = append(data, channelID.Bytes()...)
+	data = append(data, separator)
+	data = append(data, newTarget.Bytes()...)
+	data = append(data, separator)
+	return append(data, leb128.FromUInt64(assignments)...)
 }
 
 func createVoucherSignatureData(channelID *types.ChannelID, amount *types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate) ([]byte, error) {
@@ -751,7 +1262,7 @@ func findByChannelLookup(ctx context.Context, storage exec.Storage, byPayer exec
 		return nil, errors.NewFaultError("Paymentbroker payer is not a Cid")
 	}
 
-	return actor.LoadTypedLookup(ctx, storage, byChannelCID, &PaymentChannel{})
+	return actor.LoadVersionedLookup(ctx, storage, byChannelCID, &PaymentChannel{}, PaymentChannelSchema)
 }
 
 // checkCondition combines params in the condition with the redeemerSuppliedParams, sends a message
@@ -20,6 +20,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	. "github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/core"
 	"github.com/filecoin-project/go-filecoin/exec"
@@ -79,6 +80,98 @@ func TestPaymentBrokerCreateChannel(t *testing.T) {
 	assert.Equal(t, target, channel.Target)
 	assert.Equal(t, types.NewBlockHeight(10), channel.AgreedEol)
 	assert.Equal(t, types.NewBlockHeight(10), channel.Eol)
+	assert.Equal(t, SchemeSecp256k1, channel.Scheme)
+}
+
+func TestPaymentBrokerCreateChannelWithScheme(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	payer := address.TestAddress
+	target := address.NewForTestGetter()()
+	_, st, vms := requireGenesis(ctx, t, target)
+
+	t.Run("records the scheme in the channel", func(t *testing.T) {
+		pdata := core.MustConvertParams(target, big.NewInt(10), big.NewInt(int64(SchemeBLS)))
+		msg := types.NewMessage(payer, address.PaymentBrokerAddress, 0, types.NewAttoFILFromFIL(1000), "createChannel", pdata)
+
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		st.Flush(ctx)
+
+		channel := requireGetPaymentChannel(t, ctx, st, vms, payer, types.NewChannelIDFromBytes(result.Receipt.Return[0]))
+		assert.Equal(t, SchemeBLS, channel.Scheme)
+	})
+
+	t.Run("rejects unknown schemes", func(t *testing.T) {
+		pdata := core.MustConvertParams(target, big.NewInt(10), big.NewInt(99))
+		msg := types.NewMessage(payer, address.PaymentBrokerAddress, 1, types.NewAttoFILFromFIL(1000), "createChannel", pdata)
+
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrUnknownScheme), result.Receipt.ExitCode)
+		assert.Equal(t, Errors[ErrUnknownScheme].Error(), result.Receipt.Error)
+	})
+}
+
+func TestPaymentBrokerCreateChannels(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	payer := address.TestAddress
+	addrGetter := address.NewForTestGetter()
+	target1, target2 := addrGetter(), addrGetter()
+	_, st, vms := requireGenesis(ctx, t, target1, target2)
+
+	bls := SchemeBLS
+	requests := &ChannelRequests{Channels: []ChannelRequest{
+		{Target: target1, Eol: types.NewBlockHeight(10), Amount: types.NewAttoFILFromFIL(300)},
+		{Target: target2, Eol: types.NewBlockHeight(20), Amount: types.NewAttoFILFromFIL(700), Scheme: &bls},
+	}}
+
+	t.Run("value must be the sum of the amounts", func(t *testing.T) {
+		pdata := core.MustConvertParams(requests)
+		msg := types.NewMessage(payer, address.PaymentBrokerAddress, 0, types.NewAttoFILFromFIL(999), "createChannels", pdata)
+
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrChannelsValueMismatch), result.Receipt.ExitCode)
+		assert.Equal(t, Errors[ErrChannelsValueMismatch].Error(), result.Receipt.Error)
+	})
+
+	t.Run("creates a channel per request", func(t *testing.T) {
+		pdata := core.MustConvertParams(requests)
+		msg := types.NewMessage(payer, address.PaymentBrokerAddress, 1, types.NewAttoFILFromFIL(1000), "createChannels", pdata)
+
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		st.Flush(ctx)
+
+		v, err := abi.Deserialize(result.Receipt.Return[0], abi.UintArray)
+		require.NoError(t, err)
+		ids := v.Val.([]uint64)
+		require.Len(t, ids, 2)
+		assert.NotEqual(t, ids[0], ids[1])
+
+		paymentBroker := state.MustGetActor(st, address.PaymentBrokerAddress)
+		assert.Equal(t, types.NewAttoFILFromFIL(1000), paymentBroker.Balance)
+
+		for i, req := range requests.Channels {
+			channel := requireGetPaymentChannel(t, ctx, st, vms, payer, types.NewChannelID(ids[i]))
+			assert.Equal(t, req.Target, channel.Target)
+			assert.Equal(t, req.Amount, channel.Amount)
+			assert.Equal(t, req.Eol, channel.Eol)
+			assert.Equal(t, types.NewAttoFILFromFIL(0), channel.AmountRedeemed)
+		}
+		assert.Equal(t, SchemeSecp256k1, requireGetPaymentChannel(t, ctx, st, vms, payer, types.NewChannelID(ids[0])).Scheme)
+		assert.Equal(t, SchemeBLS, requireGetPaymentChannel(t, ctx, st, vms, payer, types.NewChannelID(ids[1])).Scheme)
+	})
 }
 
 func TestPaymentBrokerUpdate(t *testing.T) {
@@ -105,6 +198,56 @@ func TestPaymentBrokerUpdate(t *testing.T) {
 	assert.Equal(t, sys.target, channel.Target)
 }
 
+func TestPaymentBrokerHistory(t *testing.T) {
+	tf.UnitTest(t)
+
+	sys := setup(t)
+
+	history := func() []*Redemption {
+		ret, code, err := sys.CallQueryMethod("history", 100, sys.payer, sys.channelID)
+		require.NoError(t, err)
+		require.Equal(t, uint8(0), code)
+
+		var history []*Redemption
+		require.NoError(t, cbor.DecodeInto(ret[0], &history))
+		return history
+	}
+
+	assert.Empty(t, history())
+
+	for i, amt := range []uint64{100, 300} {
+		result, err := sys.ApplyRedeemMessageWithBlockHeight(sys.target, amt, uint64(i), uint64(i+1))
+		require.NoError(t, err)
+		require.Equal(t, uint8(0), result.Receipt.ExitCode)
+	}
+
+	redemptions := history()
+	require.Len(t, redemptions, 2)
+	assert.Equal(t, types.NewAttoFILFromFIL(300), redemptions[1].Amount)
+	assert.Equal(t, types.NewAttoFILFromFIL(200), redemptions[1].Paid)
+	assert.Equal(t, types.NewBlockHeight(2), redemptions[1].Height)
+	assert.Equal(t, sys.target, redemptions[1].Redeemer)
+
+	t.Run("keeps only the most recent redemptions", func(t *testing.T) {
+		for i := uint64(0); i < RedemptionHistoryLength; i++ {
+			result, err := sys.ApplyRedeemMessageWithBlockHeight(sys.target, 301+i, 2+i, 3+i)
+			require.NoError(t, err)
+			require.Equal(t, uint8(0), result.Receipt.ExitCode)
+		}
+
+		redemptions := history()
+		require.Len(t, redemptions, RedemptionHistoryLength)
+		assert.Equal(t, types.NewAttoFILFromFIL(301), redemptions[0].Amount)
+		assert.Equal(t, types.NewAttoFILFromFIL(300+RedemptionHistoryLength), redemptions[RedemptionHistoryLength-1].Amount)
+	})
+
+	t.Run("errors for an unknown channel", func(t *testing.T) {
+		_, code, err := sys.CallQueryMethod("history", 100, sys.payer, types.NewChannelID(999))
+		require.Error(t, err)
+		assert.Equal(t, uint8(ErrUnknownChannel), code)
+	})
+}
+
 func TestPaymentBrokerRedeemWithCondition(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -581,6 +724,35 @@ func TestPaymentBrokerClose(t *testing.T) {
 	assert.Equal(t, payerBalancePriorToClose.Add(types.NewAttoFILFromFIL(900)), payerActor.Balance)
 }
 
+func TestPaymentBrokerRedeemAndCloseEmitEvents(t *testing.T) {
+	tf.UnitTest(t)
+
+	sys := setup(t)
+
+	requireChannelEvent := func(result *consensus.ApplicationResult, topic string, amount uint64) {
+		require.NoError(t, result.ExecutionError)
+		require.Len(t, result.Receipt.Events, 1)
+
+		event := result.Receipt.Events[0]
+		assert.Equal(t, address.PaymentBrokerAddress, event.Emitter)
+		assert.Equal(t, topic, event.Topic)
+
+		var payload ChannelEvent
+		require.NoError(t, cbor.DecodeInto(event.Payload, &payload))
+		assert.Equal(t, sys.payer, payload.Payer)
+		assert.Equal(t, sys.channelID, payload.Channel)
+		assert.Equal(t, types.NewAttoFILFromFIL(amount), payload.Amount)
+	}
+
+	result, err := sys.ApplyRedeemMessage(sys.target, 100, 0)
+	require.NoError(t, err)
+	requireChannelEvent(result, TopicRedeem, 100)
+
+	result, err = sys.ApplyCloseMessage(sys.target, 200, 1)
+	require.NoError(t, err)
+	requireChannelEvent(result, TopicClose, 200)
+}
+
 func TestPaymentBrokerCloseErrorsBeforeValidAt(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -825,6 +997,97 @@ func TestPaymentBrokerCancel(t *testing.T) {
 	assert.Equal(t, types.NewBlockHeight(10100), channel.Eol)
 }
 
+func TestPaymentBrokerSettle(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("target can start settlement", func(t *testing.T) {
+		sys := setup(t)
+
+		pdata := core.MustConvertParams(sys.payer, sys.channelID)
+		msg := types.NewMessage(sys.target, address.PaymentBrokerAddress, 0, types.NewAttoFILFromFIL(0), "settle", pdata)
+
+		result, err := sys.ApplyMessage(msg, 100)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+		assert.Equal(t, uint8(0), result.Receipt.ExitCode)
+
+		paymentBroker := state.MustGetActor(sys.st, address.PaymentBrokerAddress)
+		channel := sys.retrieveChannel(paymentBroker)
+
+		assert.Equal(t, types.NewBlockHeight(20000), channel.AgreedEol)
+		assert.Equal(t, types.NewBlockHeight(10100), channel.Eol)
+	})
+
+	t.Run("only the target can settle", func(t *testing.T) {
+		sys := setup(t)
+
+		pdata := core.MustConvertParams(sys.payer, sys.channelID)
+		msg := types.NewMessage(sys.payer, address.PaymentBrokerAddress, 1, types.NewAttoFILFromFIL(0), "settle", pdata)
+
+		result, err := sys.ApplyMessage(msg, 100)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrWrongTarget), result.Receipt.ExitCode)
+	})
+
+	t.Run("settling an unknown channel fails", func(t *testing.T) {
+		sys := setup(t)
+
+		pdata := core.MustConvertParams(sys.payer, types.NewChannelID(999))
+		msg := types.NewMessage(sys.target, address.PaymentBrokerAddress, 0, types.NewAttoFILFromFIL(0), "settle", pdata)
+
+		result, err := sys.ApplyMessage(msg, 100)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrUnknownChannel), result.Receipt.ExitCode)
+	})
+}
+
+func TestPaymentBrokerAssignTarget(t *testing.T) {
+	tf.UnitTest(t)
+
+	sys := setup(t)
+	target := mockSigner.Addresses[1]
+	newTarget := sys.addressGetter()
+	channelID := establishChannel(sys.ctx, sys.st, sys.vms, sys.payer, target, 1, types.NewAttoFILFromFIL(1000), types.NewBlockHeight(20000))
+
+	sign := func(signer address.Address, assignments uint64) []byte {
+		sig, err := SignAssignment(sys.payer, channelID, newTarget, assignments, signer, mockSigner)
+		require.NoError(t, err)
+		return sig
+	}
+
+	t.Run("needs the signature of the target", func(t *testing.T) {
+		pdata := core.MustConvertParams(sys.payer, channelID, newTarget, sign(sys.payer, 0), sign(sys.payer, 0))
+		msg := types.NewMessage(sys.target, address.PaymentBrokerAddress, 0, types.NewAttoFILFromFIL(0), "assignTarget", pdata)
+
+		result, err := sys.ApplyMessage(msg, 100)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrInvalidSignature), result.Receipt.ExitCode)
+	})
+
+	t.Run("moves the channel to the new target", func(t *testing.T) {
+		pdata := core.MustConvertParams(sys.payer, channelID, newTarget, sign(sys.payer, 0), sign(target, 0))
+		msg := types.NewMessage(sys.target, address.PaymentBrokerAddress, 1, types.NewAttoFILFromFIL(0), "assignTarget", pdata)
+
+		result, err := sys.ApplyMessage(msg, 100)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		channel := requireGetPaymentChannel(t, sys.ctx, sys.st, sys.vms, sys.payer, channelID)
+		assert.Equal(t, newTarget, channel.Target)
+		assert.Equal(t, types.NewAttoFILFromFIL(1000), channel.Amount)
+		assert.Equal(t, uint64(1), channel.Assignments)
+	})
+
+	t.Run("signatures cannot be replayed", func(t *testing.T) {
+		pdata := core.MustConvertParams(sys.payer, channelID, newTarget, sign(sys.payer, 0), sign(target, 0))
+		msg := types.NewMessage(sys.target, address.PaymentBrokerAddress, 2, types.NewAttoFILFromFIL(0), "assignTarget", pdata)
+
+		result, err := sys.ApplyMessage(msg, 100)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrInvalidSignature), result.Receipt.ExitCode)
+	})
+}
+
 func TestPaymentBrokerLs(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -998,8 +1261,8 @@ func TestSignVoucher(t *testing.T) {
 		sig, err := SignVoucher(channelId, value, blockHeight, payer, nilCondition, mockSigner)
 		require.NoError(err)
 
-		assert.True(VerifyVoucherSignature(payer, channelId, value, blockHeight, nilCondition, sig))
-		assert.False(VerifyVoucherSignature(payer, channelId, value, blockHeight, condition, sig))
+		assert.True(VerifyVoucherSignature(SchemeSecp256k1, payer, channelId, value, blockHeight, nilCondition, sig))
+		assert.False(VerifyVoucherSignature(SchemeSecp256k1, payer, channelId, value, blockHeight, condition, sig))
 	})
 
 	t.Run("validates signatures with condition", func(t *testing.T) {
@@ -1009,9 +1272,84 @@ func TestSignVoucher(t *testing.T) {
 		sig, err := SignVoucher(channelId, value, blockHeight, payer, condition, mockSigner)
 		require.NoError(err)
 
-		assert.True(VerifyVoucherSignature(payer, channelId, value, blockHeight, condition, sig))
-		assert.False(VerifyVoucherSignature(payer, channelId, value, blockHeight, nilCondition, sig))
+		assert.True(VerifyVoucherSignature(SchemeSecp256k1, payer, channelId, value, blockHeight, condition, sig))
+		assert.False(VerifyVoucherSignature(SchemeSecp256k1, payer, channelId, value, blockHeight, nilCondition, sig))
 	})
+
+	t.Run("validates signatures under the channel's scheme", func(t *testing.T) {
+		require := require.New(t)
+		assert := assert.New(t)
+
+		signer := newBLSSigner()
+		blsPayer := signer.address()
+
+		sig, err := SignVoucher(channelId, value, blockHeight, blsPayer, condition, signer)
+		require.NoError(err)
+
+		assert.True(VerifyVoucherSignature(SchemeBLS, blsPayer, channelId, value, blockHeight, condition, sig))
+		assert.False(VerifyVoucherSignature(SchemeBLS, blsPayer, channelId, value, blockHeight, nilCondition, sig))
+		assert.False(VerifyVoucherSignature(SchemeSecp256k1, blsPayer, channelId, value, blockHeight, condition, sig))
+
+		secpSig, err := SignVoucher(channelId, value, blockHeight, payer, condition, mockSigner)
+		require.NoError(err)
+		assert.False(VerifyVoucherSignature(SchemeBLS, payer, channelId, value, blockHeight, condition, secpSig))
+		assert.False(VerifyVoucherSignature(SignatureScheme(99), payer, channelId, value, blockHeight, condition, secpSig))
+	})
+}
+
+func TestSchemeForAddress(t *testing.T) {
+	tf.UnitTest(t)
+
+	assert.Equal(t, SchemeBLS, SchemeForAddress(newBLSSigner().address()))
+	assert.Equal(t, SchemeSecp256k1, SchemeForAddress(mockSigner.Addresses[0]))
+}
+
+// blsSigner signs with a single BLS key.
+type blsSigner struct {
+	privateKey bls.PrivateKey
+}
+
+func newBLSSigner() *blsSigner {
+	return &blsSigner{privateKey: bls.PrivateKeyGenerate()}
+}
+
+func (s *blsSigner) address() address.Address {
+	pubKey := bls.PrivateKeyPublicKey(s.privateKey)
+	addr, err := address.NewBLSAddress(pubKey[:])
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
+
+func (s *blsSigner) SignBytes(data []byte, addr address.Address) (types.Signature, error) {
+	sig := bls.PrivateKeySign(s.privateKey, data)
+	return sig[:], nil
+}
+
+func TestPaymentBrokerAllChannels(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	payer := address.TestAddress
+	target := address.NewForTestGetter()()
+	_, st, vms := requireGenesis(ctx, t, target)
+
+	channelID1 := establishChannel(ctx, st, vms, payer, target, 0, types.NewAttoFILFromFIL(1000), types.NewBlockHeight(10))
+	channelID2 := establishChannel(ctx, st, vms, payer, target, 1, types.NewAttoFILFromFIL(2000), types.NewBlockHeight(20))
+
+	paymentBroker, err := st.GetActor(ctx, address.PaymentBrokerAddress)
+	require.NoError(t, err)
+
+	channels, err := AllChannels(ctx, vms.NewStorage(address.PaymentBrokerAddress, paymentBroker))
+	require.NoError(t, err)
+
+	require.Len(t, channels, 1)
+	payerChannels := channels[payer.String()]
+	require.Len(t, payerChannels, 2)
+	assert.Equal(t, types.NewAttoFILFromFIL(1000), payerChannels[channelID1.KeyString()].Amount)
+	assert.Equal(t, types.NewAttoFILFromFIL(2000), payerChannels[channelID2.KeyString()].Amount)
+	assert.Equal(t, target, payerChannels[channelID2.KeyString()].Target)
 }
 
 func establishChannel(ctx context.Context, st state.Tree, vms vm.StorageMap, from address.Address, target address.Address, nonce uint64, amt *types.AttoFIL, eol *types.BlockHeight) *types.ChannelID {
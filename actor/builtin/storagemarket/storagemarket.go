@@ -111,12 +111,16 @@ var storageMarketExports = exec.Exports{
 		Params: []abi.Type{},
 		Return: []abi.Type{abi.ProofsMode},
 	},
+	"getMinimumCollateral": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: []abi.Type{abi.AttoFIL},
+	},
 }
 
 // CreateMiner creates a new miner with the a pledge of the given amount of sectors. The
 // miners collateral is set by the value in the message.
 func (sma *Actor) CreateMiner(vmctx exec.VMContext, pledge *big.Int, publicKey []byte, pid peer.ID) (address.Address, uint8, error) {
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
 		return address.Undef, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -136,7 +140,14 @@ func (sma *Actor) CreateMiner(vmctx exec.VMContext, pledge *big.Int, publicKey [
 			return nil, Errors[ErrInsufficientCollateral]
 		}
 
-		minerInitializationParams := miner.NewState(vmctx.Message().From, publicKey, pledge, pid, vmctx.Message().Value)
+		// The miner's worker is the account holding the block signing key,
+		// which need not be the owner creating the miner.
+		worker, err := address.NewSecp256k1Address(publicKey)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not derive worker address from public key")
+		}
+
+		minerInitializationParams := miner.NewState(vmctx.Message().From, worker, publicKey, pledge, pid, vmctx.Message().Value)
 
 		actorCodeCid := types.MinerActorCodeCid
 		if vmctx.BlockHeight().Equal(types.NewBlockHeight(0)) {
@@ -172,7 +183,7 @@ func (sma *Actor) CreateMiner(vmctx exec.VMContext, pledge *big.Int, publicKey [
 // This occurs either when a miner adds a new commitment, or when one is removed
 // (via slashing or willful removal). The delta is in number of sectors.
 func (sma *Actor) UpdatePower(vmctx exec.VMContext, delta *big.Int) (uint8, error) {
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -207,7 +218,7 @@ func (sma *Actor) UpdatePower(vmctx exec.VMContext, delta *big.Int) (uint8, erro
 
 // GetTotalStorage returns the total amount of proven storage in the system.
 func (sma *Actor) GetTotalStorage(vmctx exec.VMContext) (*big.Int, uint8, error) {
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -229,7 +240,7 @@ func (sma *Actor) GetTotalStorage(vmctx exec.VMContext) (*big.Int, uint8, error)
 
 // GetSectorSize returns the sector size of the block chain
 func (sma *Actor) GetProofsMode(vmctx exec.VMContext) (types.ProofsMode, uint8, error) {
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
 		return 0, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
@@ -249,6 +260,16 @@ func (sma *Actor) GetProofsMode(vmctx exec.VMContext) (types.ProofsMode, uint8,
 	return size, 0, nil
 }
 
+// GetMinimumCollateral returns the collateral a miner must lock up to pledge
+// the given number of sectors. Miners consult it whenever their pledge changes.
+func (sma *Actor) GetMinimumCollateral(vmctx exec.VMContext, sectors *big.Int) (*types.AttoFIL, uint8, error) {
+	if err := vmctx.Charge(vmctx.GasTable().MethodCall); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	return MinimumCollateral(sectors), 0, nil
+}
+
 // MinimumCollateral returns the minimum required amount of collateral for a given pledge
 func MinimumCollateral(sectors *big.Int) *types.AttoFIL {
 	return MinimumCollateralPerSector.MulBigInt(sectors)
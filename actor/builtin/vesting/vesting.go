@@ -0,0 +1,234 @@
+package vesting
+
+import (
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	xerrors "github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+func init() {
+	cbor.RegisterCborType(State{})
+}
+
+const (
+	// ErrInvalidSchedule indicates the start, cliff and end of a schedule are out of order.
+	ErrInvalidSchedule = 33
+	// ErrNotBeneficiary indicates funds were withdrawn by someone other than the beneficiary.
+	ErrNotBeneficiary = 34
+	// ErrNotOwner indicates a schedule was revoked by someone other than its owner.
+	ErrNotOwner = 35
+	// ErrRevoked indicates the schedule has already been revoked.
+	ErrRevoked = 36
+	// ErrNothingToWithdraw indicates no vested funds are left to withdraw.
+	ErrNothingToWithdraw = 37
+)
+
+// Errors map error codes to revert errors this actor may return.
+var Errors = map[uint8]error{
+	ErrInvalidSchedule:   errors.NewCodedRevertError(ErrInvalidSchedule, "schedule must start no later than its cliff and end no earlier than its cliff"),
+	ErrNotBeneficiary:    errors.NewCodedRevertError(ErrNotBeneficiary, "only the beneficiary may withdraw vested funds"),
+	ErrNotOwner:          errors.NewCodedRevertError(ErrNotOwner, "only the owner may revoke the schedule"),
+	ErrRevoked:           errors.NewCodedRevertError(ErrRevoked, "schedule has already been revoked"),
+	ErrNothingToWithdraw: errors.NewCodedRevertError(ErrNothingToWithdraw, "no vested funds to withdraw"),
+}
+
+// Actor holds locked funds and releases them to a beneficiary as they vest.
+// Nothing vests before the cliff. From the cliff on, the funds vest linearly
+// over the blocks from start to end, so a schedule whose cliff is its end
+// releases everything at once.
+type Actor struct{}
+
+// State is the vesting actor's storage.
+type State struct {
+	// Owner created the schedule and may revoke the funds that have not vested.
+	Owner address.Address
+	// Beneficiary may withdraw the funds that have vested.
+	Beneficiary address.Address
+	// Total is the amount vesting over the whole schedule.
+	Total *types.AttoFIL
+	// Withdrawn is the amount the beneficiary has withdrawn so far.
+	Withdrawn *types.AttoFIL
+
+	Start *types.BlockHeight
+	Cliff *types.BlockHeight
+	End   *types.BlockHeight
+
+	// RevokedAt is the block height at which the schedule stopped vesting,
+	// or nil if it has not been revoked.
+	RevokedAt *types.BlockHeight
+}
+
+// NewState creates a schedule vesting total from owner to beneficiary.
+func NewState(owner, beneficiary address.Address, total *types.AttoFIL, start, cliff, end *types.BlockHeight) *State {
+	return &State{
+		Owner:       owner,
+		Beneficiary: beneficiary,
+		Total:       total,
+		Withdrawn:   types.NewZeroAttoFIL(),
+		Start:       start,
+		Cliff:       cliff,
+		End:         end,
+	}
+}
+
+// VestedAt returns the amount that has vested by the given block height.
+func (s *State) VestedAt(height *types.BlockHeight) *types.AttoFIL {
+	if s.RevokedAt != nil && height.GreaterThan(s.RevokedAt) {
+		height = s.RevokedAt
+	}
+
+	switch {
+	case height.LessThan(s.Cliff):
+		return types.NewZeroAttoFIL()
+	case height.GreaterEqual(s.End):
+		return s.Total
+	default:
+		elapsed := height.Sub(s.Start).AsBigInt()
+		duration := s.End.Sub(s.Start).AsBigInt()
+		return s.Total.MulBigInt(elapsed).DivBigInt(duration)
+	}
+}
+
+// NewActor returns a new vesting actor.
+func NewActor() *actor.Actor {
+	return actor.NewActor(types.VestingActorCodeCid, types.NewZeroAttoFIL())
+}
+
+// InitializeState stores the actor's initial data structure.
+func (va *Actor) InitializeState(storage exec.Storage, initializerData interface{}) error {
+	vestingState, ok := initializerData.(*State)
+	if !ok {
+		return errors.NewFaultError("Initial state to vesting actor is not a vesting.State struct")
+	}
+
+	if vestingState.Cliff.LessThan(vestingState.Start) || vestingState.End.LessThan(vestingState.Cliff) {
+		return Errors[ErrInvalidSchedule]
+	}
+
+	stateBytes, err := cbor.DumpObject(vestingState)
+	if err != nil {
+		return xerrors.Wrap(err, "failed to cbor marshal object")
+	}
+
+	id, err := storage.Put(stateBytes)
+	if err != nil {
+		return err
+	}
+
+	return storage.Commit(id, cid.Undef)
+}
+
+var _ exec.ExecutableActor = (*Actor)(nil)
+
+// Exports returns the actor's exports.
+func (va *Actor) Exports() exec.Exports {
+	return vestingExports
+}
+
+var vestingExports = exec.Exports{
+	"withdrawVested": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.AttoFIL},
+	},
+	"revoke": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.AttoFIL},
+	},
+	"getVested": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.AttoFIL, abi.AttoFIL},
+	},
+}
+
+// WithdrawVested sends the funds that have vested but not yet been withdrawn
+// to the beneficiary and returns the amount sent.
+func (va *Actor) WithdrawVested(ctx exec.VMContext) (*types.AttoFIL, uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Beneficiary {
+			return nil, Errors[ErrNotBeneficiary]
+		}
+
+		amount := state.VestedAt(ctx.BlockHeight()).Sub(state.Withdrawn)
+		if !amount.IsPositive() {
+			return nil, Errors[ErrNothingToWithdraw]
+		}
+		state.Withdrawn = state.Withdrawn.Add(amount)
+
+		if _, _, err := ctx.Send(state.Beneficiary, "", amount, nil); err != nil {
+			return nil, err
+		}
+
+		return amount, nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	return out.(*types.AttoFIL), 0, nil
+}
+
+// Revoke stops the schedule at the current block height and returns the
+// funds that have not vested to the owner. The beneficiary may still
+// withdraw what vested before. It returns the amount returned to the owner.
+func (va *Actor) Revoke(ctx exec.VMContext) (*types.AttoFIL, uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Owner {
+			return nil, Errors[ErrNotOwner]
+		}
+
+		if state.RevokedAt != nil {
+			return nil, Errors[ErrRevoked]
+		}
+
+		unvested := state.Total.Sub(state.VestedAt(ctx.BlockHeight()))
+		state.RevokedAt = ctx.BlockHeight()
+
+		if unvested.IsPositive() {
+			if _, _, err := ctx.Send(state.Owner, "", unvested, nil); err != nil {
+				return nil, err
+			}
+		}
+
+		return unvested, nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	return out.(*types.AttoFIL), 0, nil
+}
+
+// GetVested returns the amount that has vested by the current block height
+// and the amount that has been withdrawn.
+func (va *Actor) GetVested(ctx exec.VMContext) (*types.AttoFIL, *types.AttoFIL, uint8, error) {
+	if err := ctx.Charge(ctx.GasTable().MethodCall); err != nil {
+		return nil, nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		return nil, nil
+	})
+	if err != nil {
+		return nil, nil, errors.CodeError(err), err
+	}
+
+	return state.VestedAt(ctx.BlockHeight()), state.Withdrawn, 0, nil
+}
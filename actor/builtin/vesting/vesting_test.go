@@ -0,0 +1,163 @@
+package vesting_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	. "github.com/filecoin-project/go-filecoin/actor/builtin/vesting"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+func createTestVesting(t *testing.T, st state.Tree, vms vm.StorageMap, beneficiary address.Address, start, cliff, end uint64, value *types.AttoFIL) (address.Address, *consensus.ApplicationResult) {
+	ctorParams, err := abi.ToEncodedValues(beneficiary, types.NewBlockHeight(start), types.NewBlockHeight(cliff), types.NewBlockHeight(end))
+	require.NoError(t, err)
+	pdata := actor.MustConvertParams(types.VestingActorCodeCid, ctorParams)
+	msg := types.NewMessage(address.TestAddress, address.InitAddress, core.MustGetNonce(st, address.TestAddress), value, "exec", pdata)
+	res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+	require.NoError(t, err)
+	if res.ExecutionError != nil {
+		return address.Undef, res
+	}
+
+	addr, err := address.NewFromBytes(res.Receipt.Return[0])
+	require.NoError(t, err)
+	return addr, res
+}
+
+func TestVestingCreate(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	t.Run("creates a funded schedule", func(t *testing.T) {
+		addr, res := createTestVesting(t, st, vms, address.TestAddress2, 10, 20, 110, types.NewAttoFILFromFIL(100))
+		require.NoError(t, res.ExecutionError)
+
+		vestingActor, err := st.GetActor(ctx, addr)
+		require.NoError(t, err)
+		assert.Equal(t, types.VestingActorCodeCid, vestingActor.Code)
+		assert.Equal(t, types.NewAttoFILFromFIL(100), vestingActor.Balance)
+
+		var vestingState State
+		builtin.RequireReadState(t, vms, addr, vestingActor, &vestingState)
+		assert.Equal(t, address.TestAddress, vestingState.Owner)
+		assert.Equal(t, address.TestAddress2, vestingState.Beneficiary)
+		assert.Equal(t, types.NewAttoFILFromFIL(100), vestingState.Total)
+		assert.Nil(t, vestingState.RevokedAt)
+	})
+
+	t.Run("rejects schedules out of order", func(t *testing.T) {
+		_, res := createTestVesting(t, st, vms, address.TestAddress2, 10, 5, 110, types.NewZeroAttoFIL())
+		assert.Equal(t, Errors[ErrInvalidSchedule], res.ExecutionError)
+
+		_, res = createTestVesting(t, st, vms, address.TestAddress2, 10, 20, 15, types.NewZeroAttoFIL())
+		assert.Equal(t, Errors[ErrInvalidSchedule], res.ExecutionError)
+	})
+}
+
+func TestVestedAt(t *testing.T) {
+	tf.UnitTest(t)
+
+	linear := NewState(address.TestAddress, address.TestAddress2, types.NewAttoFILFromFIL(100), types.NewBlockHeight(10), types.NewBlockHeight(10), types.NewBlockHeight(110))
+	assert.Equal(t, types.NewZeroAttoFIL(), linear.VestedAt(types.NewBlockHeight(5)))
+	assert.Equal(t, types.NewZeroAttoFIL(), linear.VestedAt(types.NewBlockHeight(10)))
+	assert.Equal(t, types.NewAttoFILFromFIL(25), linear.VestedAt(types.NewBlockHeight(35)))
+	assert.Equal(t, types.NewAttoFILFromFIL(100), linear.VestedAt(types.NewBlockHeight(200)))
+
+	cliff := NewState(address.TestAddress, address.TestAddress2, types.NewAttoFILFromFIL(100), types.NewBlockHeight(10), types.NewBlockHeight(60), types.NewBlockHeight(110))
+	assert.Equal(t, types.NewZeroAttoFIL(), cliff.VestedAt(types.NewBlockHeight(59)))
+	assert.Equal(t, types.NewAttoFILFromFIL(50), cliff.VestedAt(types.NewBlockHeight(60)))
+
+	allAtOnce := NewState(address.TestAddress, address.TestAddress2, types.NewAttoFILFromFIL(100), types.NewBlockHeight(10), types.NewBlockHeight(110), types.NewBlockHeight(110))
+	assert.Equal(t, types.NewZeroAttoFIL(), allAtOnce.VestedAt(types.NewBlockHeight(109)))
+	assert.Equal(t, types.NewAttoFILFromFIL(100), allAtOnce.VestedAt(types.NewBlockHeight(110)))
+
+	linear.RevokedAt = types.NewBlockHeight(35)
+	assert.Equal(t, types.NewAttoFILFromFIL(25), linear.VestedAt(types.NewBlockHeight(200)))
+}
+
+func TestVestingWithdrawAndRevoke(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	owner := address.TestAddress
+	beneficiary := address.TestAddress2
+	vestingAddr, res := createTestVesting(t, st, vms, beneficiary, 10, 10, 110, types.NewAttoFILFromFIL(100))
+	require.NoError(t, res.ExecutionError)
+
+	send := func(from address.Address, method string, height uint64) *consensus.ApplicationResult {
+		msg := types.NewMessage(from, vestingAddr, core.MustGetNonce(st, from), types.NewZeroAttoFIL(), method, nil)
+		res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(height))
+		require.NoError(t, err)
+		return res
+	}
+
+	getBalance := func(addr address.Address) *types.AttoFIL {
+		act, err := st.GetActor(ctx, addr)
+		require.NoError(t, err)
+		return act.Balance
+	}
+
+	t.Run("only the beneficiary may withdraw", func(t *testing.T) {
+		res := send(owner, "withdrawVested", 35)
+		assert.Equal(t, Errors[ErrNotBeneficiary], res.ExecutionError)
+	})
+
+	t.Run("nothing can be withdrawn before the start", func(t *testing.T) {
+		res := send(beneficiary, "withdrawVested", 5)
+		assert.Equal(t, Errors[ErrNothingToWithdraw], res.ExecutionError)
+	})
+
+	t.Run("the beneficiary withdraws what has vested", func(t *testing.T) {
+		before := getBalance(beneficiary)
+
+		res := send(beneficiary, "withdrawVested", 35)
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, types.NewAttoFILFromFIL(25), types.NewAttoFILFromBytes(res.Receipt.Return[0]))
+		assert.Equal(t, before.Add(types.NewAttoFILFromFIL(25)), getBalance(beneficiary))
+		assert.Equal(t, types.NewAttoFILFromFIL(75), getBalance(vestingAddr))
+
+		// the same funds cannot be withdrawn twice
+		res = send(beneficiary, "withdrawVested", 35)
+		assert.Equal(t, Errors[ErrNothingToWithdraw], res.ExecutionError)
+	})
+
+	t.Run("only the owner may revoke", func(t *testing.T) {
+		res := send(beneficiary, "revoke", 60)
+		assert.Equal(t, Errors[ErrNotOwner], res.ExecutionError)
+	})
+
+	t.Run("revoking returns the unvested funds to the owner", func(t *testing.T) {
+		before := getBalance(owner)
+
+		res := send(owner, "revoke", 60)
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, types.NewAttoFILFromFIL(50), types.NewAttoFILFromBytes(res.Receipt.Return[0]))
+		assert.Equal(t, before.Add(types.NewAttoFILFromFIL(50)), getBalance(owner))
+
+		res = send(owner, "revoke", 70)
+		assert.Equal(t, Errors[ErrRevoked], res.ExecutionError)
+
+		// the beneficiary keeps what vested before the schedule was revoked
+		res = send(beneficiary, "withdrawVested", 200)
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, types.NewAttoFILFromFIL(25), types.NewAttoFILFromBytes(res.Receipt.Return[0]))
+		assert.True(t, getBalance(vestingAddr).IsZero())
+	})
+}
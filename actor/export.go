@@ -82,6 +82,10 @@ func MakeTypedExport(actor exec.ExecutableActor, method string) exec.ExportedFun
 			return nil, 1, errors.RevertErrorWrap(err, "invalid params")
 		}
 
+		if err := abi.ValidateValues(params); err != nil {
+			return nil, 1, errors.RevertErrorWrap(err, "invalid params")
+		}
+
 		args := []reflect.Value{
 			reflect.ValueOf(actor),
 			reflect.ValueOf(ctx),
@@ -0,0 +1,158 @@
+package actor
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/exec"
+	vmerrors "github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// StateVersionField is the name of the field of a versioned state struct
+// holding its version.
+const StateVersionField = "StateVersion"
+
+// StateMigration converts the fields of a state, decoded generically from
+// CBOR and keyed by field name, into the fields of the next version of the
+// state, in place.
+type StateMigration func(fields map[string]interface{}) error
+
+// StateSchema describes the versions of a type of actor state, so that state
+// written by earlier versions of an actor decodes into the current Go struct
+// of the state.
+//
+// By convention the struct of a versioned state carries its version in a
+// StateVersion uint64 field tagged `refmt:",omitempty"`, so that state written
+// before the struct was versioned reads as version 0 and the encoding of
+// version 0 state does not change. Adding a field whose zero value suits the
+// existing state needs no new version. A change that breaks decoding existing
+// state, such as renaming, retyping or removing a field, bumps Version and
+// adds the migration from the previous version. Unlike an ActorUpgrade
+// migrating the whole state of an actor at once, migrations apply whenever
+// older state is read, and state is written back at the current version.
+type StateSchema struct {
+	// Version is the version of the current Go struct of the state.
+	Version uint64
+	// Migrations convert state of the version they are keyed by into state
+	// of the next version.
+	Migrations map[uint64]StateMigration
+}
+
+// Unmarshal decodes the state in raw, of any version up to the current one,
+// into to.
+func (s *StateSchema) Unmarshal(raw []byte, to interface{}) error {
+	var from interface{}
+	if err := cbor.DecodeInto(raw, &from); err != nil {
+		return err
+	}
+	migrated, err := s.Migrate(from)
+	if err != nil {
+		return err
+	}
+	return correctUnmarshaling(migrated, to)
+}
+
+// Migrate converts state decoded generically from CBOR, of any version up to
+// the current one, into state of the current version. The state given is not
+// modified. State that is not decoded from CBOR, such as a value set on a
+// HAMT node not yet stored, is of the current version and returned as is.
+func (s *StateSchema) Migrate(from interface{}) (interface{}, error) {
+	fields, ok := from.(map[string]interface{})
+	if !ok {
+		return from, nil
+	}
+	version, err := stateVersion(fields)
+	if err != nil {
+		return nil, err
+	}
+	if version > s.Version {
+		return nil, errors.Errorf("state version %d is newer than the current version %d", version, s.Version)
+	}
+	if version == s.Version {
+		return fields, nil
+	}
+
+	// Migrations change fields in place, copy them so that the state, which
+	// may be cached by a HAMT node, stays as stored.
+	migrated := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		migrated[k] = v
+	}
+	for ; version < s.Version; version++ {
+		migrate, ok := s.Migrations[version]
+		if !ok {
+			return nil, errors.Errorf("no migration from state version %d", version)
+		}
+		if err := migrate(migrated); err != nil {
+			return nil, errors.Wrapf(err, "failed to migrate state version %d", version)
+		}
+		migrated[StateVersionField] = version + 1
+	}
+	return migrated, nil
+}
+
+// stateVersion returns the version of the state fields, 0 if they have none.
+func stateVersion(fields map[string]interface{}) (uint64, error) {
+	v, ok := fields[StateVersionField]
+	if !ok {
+		return 0, nil
+	}
+	switch version := v.(type) {
+	case int:
+		return uint64(version), nil
+	case int64:
+		return uint64(version), nil
+	case uint64:
+		return version, nil
+	default:
+		return 0, errors.Errorf("state version is a %T", v)
+	}
+}
+
+// WithVersionedState is like WithState, but decodes state of any version of
+// the schema into st.
+func WithVersionedState(ctx exec.VMContext, st interface{}, schema *StateSchema, f func() (interface{}, error)) (interface{}, error) {
+	chunk, err := ctx.ReadStorage()
+	if err != nil {
+		return nil, vmerrors.FaultErrorWrap(err, "Could not read actor storage")
+	}
+
+	if err := schema.Unmarshal(chunk, st); err != nil {
+		return nil, vmerrors.FaultErrorWrap(err, "Could not unmarshall actor storage")
+	}
+
+	ret, err := f()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.WriteStorage(st); err != nil {
+		return nil, vmerrors.FaultErrorWrap(err, "Could not write actor storage")
+	}
+
+	return ret, nil
+}
+
+// LoadVersionedLookup is like LoadTypedLookup, but decodes values of any
+// version of the schema into the value type.
+func LoadVersionedLookup(ctx context.Context, storage exec.Storage, cid cid.Cid, valueType interface{}, schema *StateSchema) (exec.Lookup, error) {
+	l, err := LoadTypedLookup(ctx, storage, cid, valueType)
+	if err != nil {
+		return nil, err
+	}
+	l.(*lookup).schema = schema
+	return l, nil
+}
+
+// newMigratedUnmarshaled is newCorrectlyUnmarshaled for values of a schema.
+func newMigratedUnmarshaled(from interface{}, valueType reflect.Type, schema *StateSchema) (interface{}, error) {
+	migrated, err := schema.Migrate(from)
+	if err != nil {
+		return nil, err
+	}
+	return newCorrectlyUnmarshaled(migrated, valueType)
+}
@@ -0,0 +1,114 @@
+package actor_test
+
+import (
+	"context"
+	"testing"
+
+	cid "github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-ipfs-blockstore"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// testStateV0 is a state before it was versioned.
+type testStateV0 struct {
+	Amt  uint64
+	Memo string
+}
+
+// testStateV1 renamed Amt.
+type testStateV1 struct {
+	Amount       uint64
+	Memo         string
+	StateVersion uint64 `refmt:",omitempty"`
+}
+
+func init() {
+	cbor.RegisterCborType(testStateV0{})
+	cbor.RegisterCborType(testStateV1{})
+}
+
+var testStateSchema = &StateSchema{
+	Version: 1,
+	Migrations: map[uint64]StateMigration{
+		0: func(fields map[string]interface{}) error {
+			fields["Amount"] = fields["Amt"]
+			delete(fields, "Amt")
+			return nil
+		},
+	},
+}
+
+func TestStateSchema(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("decodes earlier versions into the current struct", func(t *testing.T) {
+		raw, err := MarshalStorage(testStateV0{Amt: 5, Memo: "old"})
+		require.NoError(t, err)
+
+		var st testStateV1
+		require.NoError(t, testStateSchema.Unmarshal(raw, &st))
+		assert.Equal(t, testStateV1{Amount: 5, Memo: "old", StateVersion: 1}, st)
+	})
+
+	t.Run("decodes the current version", func(t *testing.T) {
+		raw, err := MarshalStorage(testStateV1{Amount: 5, Memo: "new", StateVersion: 1})
+		require.NoError(t, err)
+
+		var st testStateV1
+		require.NoError(t, testStateSchema.Unmarshal(raw, &st))
+		assert.Equal(t, testStateV1{Amount: 5, Memo: "new", StateVersion: 1}, st)
+	})
+
+	t.Run("refuses newer versions", func(t *testing.T) {
+		raw, err := MarshalStorage(testStateV1{Amount: 5, StateVersion: 2})
+		require.NoError(t, err)
+
+		var st testStateV1
+		err = testStateSchema.Unmarshal(raw, &st)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "newer than the current version")
+	})
+
+	t.Run("fails without a migration", func(t *testing.T) {
+		raw, err := MarshalStorage(testStateV1{Amount: 5, StateVersion: 1})
+		require.NoError(t, err)
+
+		schema := &StateSchema{Version: 2, Migrations: testStateSchema.Migrations}
+		var st testStateV1
+		err = schema.Unmarshal(raw, &st)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no migration from state version 1")
+	})
+
+	t.Run("migrates the values of lookups", func(t *testing.T) {
+		ctx := context.Background()
+		vms := vm.NewStorageMap(blockstore.NewBlockstore(datastore.NewMapDatastore()))
+		storage := vms.NewStorage(address.TestAddress, &Actor{})
+
+		old, err := LoadLookup(ctx, storage, cid.Undef)
+		require.NoError(t, err)
+		require.NoError(t, old.Set(ctx, "foo", testStateV0{Amt: 7, Memo: "old"}))
+		c, err := old.Commit(ctx)
+		require.NoError(t, err)
+
+		lookup, err := LoadVersionedLookup(ctx, storage, c, &testStateV1{}, testStateSchema)
+		require.NoError(t, err)
+
+		value, err := lookup.Find(ctx, "foo")
+		require.NoError(t, err)
+		assert.Equal(t, &testStateV1{Amount: 7, Memo: "old", StateVersion: 1}, value)
+
+		kvs, err := lookup.Values(ctx)
+		require.NoError(t, err)
+		require.Len(t, kvs, 1)
+		assert.Equal(t, &testStateV1{Amount: 7, Memo: "old", StateVersion: 1}, kvs[0].Value)
+	})
+}
@@ -160,6 +160,8 @@ type lookup struct {
 	n *hamt.Node
 	s exec.Storage
 	t reflect.Type
+	// schema, if set, migrates values of earlier versions of t.
+	schema *StateSchema
 }
 
 var _ exec.Lookup = (*lookup)(nil)
@@ -175,7 +177,7 @@ func (l *lookup) Find(ctx context.Context, k string) (interface{}, error) {
 
 	// correct type of response
 	if l.t != nil {
-		value, err = newCorrectlyUnmarshaled(value, l.t)
+		value, err = l.unmarshalValue(value)
 		if err != nil {
 			return nil, err
 		}
@@ -218,7 +220,7 @@ func (l *lookup) Values(ctx context.Context) ([]*hamt.KV, error) {
 	// The values coming out of the hamt are not correctly unmarshaled. Correct that now.
 	if l.t != nil {
 		for _, kv := range kvs {
-			kv.Value, err = newCorrectlyUnmarshaled(kv.Value, l.t)
+			kv.Value, err = l.unmarshalValue(kv.Value)
 			if err != nil {
 				return nil, err
 			}
@@ -255,6 +257,15 @@ func (l *lookup) values(ctx context.Context, vs []*hamt.KV) ([]*hamt.KV, error)
 	return vs, nil
 }
 
+// unmarshalValue converts a value as it comes out of the hamt into the value
+// type of the lookup.
+func (l *lookup) unmarshalValue(value interface{}) (interface{}, error) {
+	if l.schema != nil {
+		return newMigratedUnmarshaled(value, l.t, l.schema)
+	}
+	return newCorrectlyUnmarshaled(value, l.t)
+}
+
 // newCorrectlyUnmarshaled creates a interface of the correct type, unmarshals into it, and returns the value
 func newCorrectlyUnmarshaled(from interface{}, valueType reflect.Type) (interface{}, error) {
 	to := reflect.New(valueType).Interface()
@@ -74,6 +74,14 @@ var FakeActorExports = exec.Exports{
 		Params: nil,
 		Return: nil,
 	},
+	"emitEvent": &exec.FunctionSignature{
+		Params: nil,
+		Return: nil,
+	},
+	"emitEventAndRevertError": &exec.FunctionSignature{
+		Params: nil,
+		Return: nil,
+	},
 }
 
 // InitializeState stores this actors
@@ -217,6 +225,22 @@ func (ma *FakeActor) BlockLimitTestMethod(ctx exec.VMContext) (uint8, error) {
 	return 0, nil
 }
 
+// EmitEvent emits an event with the topic "fake".
+func (ma *FakeActor) EmitEvent(ctx exec.VMContext) (uint8, error) {
+	if err := ctx.EmitEvent("fake", []byte("payload")); err != nil {
+		return errors.CodeError(err), err
+	}
+	return 0, nil
+}
+
+// EmitEventAndRevertError emits an event and returns a revert error.
+func (ma *FakeActor) EmitEventAndRevertError(ctx exec.VMContext) (uint8, error) {
+	if err := ctx.EmitEvent("fake", []byte("payload")); err != nil {
+		panic("Unexpected error emitting event")
+	}
+	return 1, errors.NewRevertError("boom")
+}
+
 // MustConvertParams encodes the given params and panics if it fails to do so.
 func MustConvertParams(params ...interface{}) []byte {
 	vals, err := abi.ToValues(params)
@@ -35,6 +35,16 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	InitAddress, err = NewActorAddress([]byte("init"))
+	if err != nil {
+		panic(err)
+	}
+
+	FaucetAddress, err = NewActorAddress([]byte("faucet"))
+	if err != nil {
+		panic(err)
+	}
 }
 
 var (
@@ -49,6 +59,10 @@ var (
 	StorageMarketAddress Address
 	// PaymentBrokerAddress is the hard-coded address of the filecoin payment broker.
 	PaymentBrokerAddress Address
+	// InitAddress is the hard-coded address of the filecoin init actor.
+	InitAddress Address
+	// FaucetAddress is the hard-coded address of the faucet of test networks.
+	FaucetAddress Address
 )
 
 var (
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Permission is a scope of the API a token gives access to.
+type Permission string
+
+const (
+	// PermRead allows reading the state of the node and the chain.
+	PermRead = Permission("read")
+	// PermWrite allows changing the state of the node without spending funds.
+	PermWrite = Permission("write")
+	// PermSign allows signing messages, and so spending funds.
+	PermSign = Permission("sign")
+	// PermAdmin allows managing the node, its config and its keys.
+	PermAdmin = Permission("admin")
+)
+
+// AllPermissions lists the permissions from the least to the most
+// privileged.
+var AllPermissions = []Permission{PermRead, PermWrite, PermSign, PermAdmin}
+
+// ErrInvalidToken is returned for tokens not signed with the node's secret.
+var ErrInvalidToken = errors.New("invalid token")
+
+// PermissionsUpTo returns the permissions up to and including perm, which
+// are those given by a token with the perm scope.
+func PermissionsUpTo(perm Permission) ([]Permission, error) {
+	for i, p := range AllPermissions {
+		if p == perm {
+			return append([]Permission{}, AllPermissions[:i+1]...), nil
+		}
+	}
+	return nil, errors.Errorf("unknown permission %s", perm)
+}
+
+// tokenHeader is the header of the tokens, which are JSON web tokens signed
+// with HMAC SHA-256.
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+type tokenPayload struct {
+	Perms []Permission `json:"perms"`
+}
+
+// NewSecret generates a random secret to sign tokens with.
+func NewSecret() ([]byte, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// CreateToken creates a token giving the permissions perms, signed with
+// secret.
+func CreateToken(secret []byte, perms []Permission) (string, error) {
+	payload, err := json.Marshal(&tokenPayload{Perms: perms})
+	if err != nil {
+		return "", err
+	}
+	signed := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + signToken(secret, signed), nil
+}
+
+// VerifyToken checks token was signed with secret and returns the
+// permissions it gives.
+func VerifyToken(secret []byte, token string) ([]Permission, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return nil, ErrInvalidToken
+	}
+	signed := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(signToken(secret, signed))) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var p tokenPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return p.Perms, nil
+}
+
+func signToken(secret []byte, signed string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed)) // nolint: errcheck
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// hasPermission returns whether perms include perm.
+func hasPermission(perms []Permission, perm Permission) bool {
+	for _, p := range perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
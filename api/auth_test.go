@@ -0,0 +1,71 @@
+package api_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/api"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestTokens(t *testing.T) {
+	tf.UnitTest(t)
+
+	secret, err := api.NewSecret()
+	require.NoError(t, err)
+
+	t.Run("a token gives the permissions it was created with", func(t *testing.T) {
+		perms := []api.Permission{api.PermRead, api.PermWrite}
+		token, err := api.CreateToken(secret, perms)
+		require.NoError(t, err)
+
+		got, err := api.VerifyToken(secret, token)
+		require.NoError(t, err)
+		assert.Equal(t, perms, got)
+	})
+
+	t.Run("a token signed with another secret is invalid", func(t *testing.T) {
+		other, err := api.NewSecret()
+		require.NoError(t, err)
+		token, err := api.CreateToken(other, []api.Permission{api.PermAdmin})
+		require.NoError(t, err)
+
+		_, err = api.VerifyToken(secret, token)
+		assert.Equal(t, api.ErrInvalidToken, err)
+	})
+
+	t.Run("a token with a changed payload is invalid", func(t *testing.T) {
+		readToken, err := api.CreateToken(secret, []api.Permission{api.PermRead})
+		require.NoError(t, err)
+		adminToken, err := api.CreateToken(secret, []api.Permission{api.PermAdmin})
+		require.NoError(t, err)
+
+		read := strings.Split(readToken, ".")
+		admin := strings.Split(adminToken, ".")
+		_, err = api.VerifyToken(secret, strings.Join([]string{read[0], admin[1], read[2]}, "."))
+		assert.Equal(t, api.ErrInvalidToken, err)
+	})
+
+	t.Run("malformed tokens are invalid", func(t *testing.T) {
+		_, err := api.VerifyToken(secret, "not a token")
+		assert.Equal(t, api.ErrInvalidToken, err)
+	})
+}
+
+func TestPermissionsUpTo(t *testing.T) {
+	tf.UnitTest(t)
+
+	perms, err := api.PermissionsUpTo(api.PermWrite)
+	require.NoError(t, err)
+	assert.Equal(t, []api.Permission{api.PermRead, api.PermWrite}, perms)
+
+	perms, err = api.PermissionsUpTo(api.PermAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, api.AllPermissions, perms)
+
+	_, err = api.PermissionsUpTo(api.Permission("root"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+// Package client implements a Go client of the JSON-RPC API of a filecoin
+// node.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/api"
+)
+
+// Client calls the commands of a node over its JSON-RPC API.
+//
+// Client is safe for concurrent use.
+type Client struct {
+	url        string
+	token      string
+	httpClient *http.Client
+
+	nextID uint64
+}
+
+// New creates a client of the API served at baseURL, e.g.
+// http://127.0.0.1:3453, authenticating with token. Tokens are created with
+// the auth create-token command of the node.
+func New(baseURL, token string) *Client {
+	return &Client{
+		url:        strings.TrimSuffix(baseURL, "/") + api.RPCPath,
+		token:      token,
+		httpClient: http.DefaultClient,
+	}
+}
+
+// Call runs the command named method, e.g. "wallet.balance", with the given
+// arguments and options, and decodes the JSON array of the values the command
+// emitted into result, unless it is nil. If the command fails, the error
+// returned is an *api.Error.
+func (c *Client) Call(ctx context.Context, method string, args []string, opts map[string]interface{}, result interface{}) error {
+	id, err := json.Marshal(atomic.AddUint64(&c.nextID, 1))
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(&api.Request{
+		JSONRPC: api.JSONRPCVersion,
+		ID:      id,
+		Method:  method,
+		Params:  api.Params{Arguments: args, Options: opts},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode request")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close() // nolint: errcheck
+
+	if httpResp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(httpResp.Body)
+		return fmt.Errorf("%s: %s", httpResp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var resp api.Response
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return errors.Wrap(err, "failed to decode response")
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return errors.Wrap(json.Unmarshal(resp.Result, result), "failed to decode result")
+}
@@ -0,0 +1,117 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-ipfs-cmdkit"
+	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/api"
+	"github.com/filecoin-project/go-filecoin/api/client"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+var testRootCmd = &cmds.Command{
+	Subcommands: map[string]*cmds.Command{
+		"text": {
+			Subcommands: map[string]*cmds.Command{
+				"echo": {
+					Arguments: []cmdkit.Argument{
+						cmdkit.StringArg("text", true, true, "Text to echo"),
+					},
+					Options: []cmdkit.Option{
+						cmdkit.UintOption("repeat", "Number of times to echo the text").WithDefault(uint(1)),
+					},
+					Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+						for i := uint(0); i < req.Options["repeat"].(uint); i++ {
+							if err := re.Emit(strings.Join(req.Arguments, " ")); err != nil {
+								return err
+							}
+						}
+						return nil
+					},
+				},
+			},
+		},
+		"cat": {
+			Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+				return re.Emit(strings.NewReader("some data"))
+			},
+		},
+		"fail": {
+			Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+				return errors.New("command failed")
+			},
+		},
+	},
+}
+
+func TestClientCall(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	secret := []byte("secret")
+	server := httptest.NewServer(api.NewHandler(testRootCmd, nil, secret, func(*cmds.Command) api.Permission {
+		return api.PermRead
+	}))
+	defer server.Close()
+	token, err := api.CreateToken(secret, []api.Permission{api.PermRead})
+	require.NoError(t, err)
+	c := client.New(server.URL, token)
+
+	t.Run("returns the values emitted", func(t *testing.T) {
+		var out []string
+		require.NoError(t, c.Call(ctx, "text.echo", []string{"hello", "world"}, map[string]interface{}{"repeat": 2}, &out))
+		assert.Equal(t, []string{"hello world", "hello world"}, out)
+	})
+
+	t.Run("fills in default options", func(t *testing.T) {
+		var out []string
+		require.NoError(t, c.Call(ctx, "text.echo", []string{"hello"}, nil, &out))
+		assert.Equal(t, []string{"hello"}, out)
+	})
+
+	t.Run("returns streamed data as bytes", func(t *testing.T) {
+		var out [][]byte
+		require.NoError(t, c.Call(ctx, "cat", nil, nil, &out))
+		require.Len(t, out, 1)
+		assert.Equal(t, "some data", string(out[0]))
+	})
+
+	t.Run("returns command errors", func(t *testing.T) {
+		err := c.Call(ctx, "fail", nil, nil, nil)
+		require.Error(t, err)
+		rpcErr, ok := err.(*api.Error)
+		require.True(t, ok)
+		assert.Equal(t, api.ErrCodeCommand, rpcErr.Code)
+		assert.Contains(t, rpcErr.Message, "command failed")
+	})
+
+	t.Run("rejects unknown methods", func(t *testing.T) {
+		err := c.Call(ctx, "text.shout", nil, nil, nil)
+		require.Error(t, err)
+		rpcErr, ok := err.(*api.Error)
+		require.True(t, ok)
+		assert.Equal(t, api.ErrCodeMethodNotFound, rpcErr.Code)
+	})
+
+	t.Run("rejects invalid params", func(t *testing.T) {
+		err := c.Call(ctx, "text.echo", nil, map[string]interface{}{"repeat": "many"}, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("rejects a token signed with another secret", func(t *testing.T) {
+		forged, err := api.CreateToken([]byte("guess"), []api.Permission{api.PermRead})
+		require.NoError(t, err)
+
+		err = client.New(server.URL, forged).Call(ctx, "text.echo", []string{"hello"}, nil, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+	})
+}
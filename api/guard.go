@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ipfs/go-ipfs-cmds"
+)
+
+// Guard protects an HTTP handler serving the commands of root under prefix,
+// one command per path like the go-ipfs-cmds handler does, with the tokens
+// of the JSON-RPC API: requests run only the commands their token allows.
+type Guard struct {
+	root   *cmds.Command
+	prefix string
+	secret []byte
+	permOf func(*cmds.Command) Permission
+	next   http.Handler
+}
+
+// NewGuard creates a guard letting requests through to next if their token
+// is signed with secret and has the permission permOf returns for the
+// command of the path. With an empty secret all requests are rejected.
+func NewGuard(root *cmds.Command, prefix string, secret []byte, permOf func(*cmds.Command) Permission, next http.Handler) *Guard {
+	return &Guard{
+		root:   root,
+		prefix: prefix,
+		secret: secret,
+		permOf: permOf,
+		next:   next,
+	}
+}
+
+// ServeHTTP serves the request with the guarded handler if its token allows
+// running the command of its path.
+func (g *Guard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// CORS preflight requests carry no credentials and run no command.
+	if r.Method == http.MethodOptions {
+		g.next.ServeHTTP(w, r)
+		return
+	}
+
+	perms, err := authenticate(g.secret, r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	cmd, name := g.command(r.URL.Path)
+	if perm := g.permOf(cmd); !hasPermission(perms, perm) {
+		http.Error(w, fmt.Sprintf("command %s requires the %s permission", name, perm), http.StatusForbidden)
+		return
+	}
+	g.next.ServeHTTP(w, r)
+}
+
+// command returns the deepest command of root the path leads to, which is
+// the one the guarded handler runs, and its name. Path elements past it are
+// taken as arguments of the command.
+func (g *Guard) command(path string) (*cmds.Command, string) {
+	cmd := g.root
+	var names []string
+	for _, elem := range strings.Split(strings.Trim(strings.TrimPrefix(path, g.prefix), "/"), "/") {
+		sub, ok := cmd.Subcommands[elem]
+		if !ok {
+			break
+		}
+		cmd = sub
+		names = append(names, elem)
+	}
+	return cmd, strings.Join(names, " ")
+}
@@ -0,0 +1,94 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/api"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestGuard(t *testing.T) {
+	tf.UnitTest(t)
+
+	lsCmd := &cmds.Command{}
+	root := &cmds.Command{
+		Subcommands: map[string]*cmds.Command{
+			"wallet": {
+				Subcommands: map[string]*cmds.Command{
+					"ls":     lsCmd,
+					"export": {},
+				},
+			},
+		},
+	}
+	permOf := func(cmd *cmds.Command) api.Permission {
+		if cmd == lsCmd {
+			return api.PermRead
+		}
+		return api.PermAdmin
+	}
+	secret := []byte("secret")
+	readToken, err := api.CreateToken(secret, []api.Permission{api.PermRead})
+	require.NoError(t, err)
+
+	served := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	})
+	serve := func(g http.Handler, method, path, token string) *httptest.ResponseRecorder {
+		served = false
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, req)
+		return rec
+	}
+	guard := api.NewGuard(root, "/api", secret, permOf, next)
+
+	t.Run("lets through the commands the token allows", func(t *testing.T) {
+		rec := serve(guard, http.MethodPost, "/api/wallet/ls", readToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, served)
+	})
+
+	t.Run("rejects unauthenticated requests", func(t *testing.T) {
+		rec := serve(guard, http.MethodPost, "/api/wallet/ls", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.False(t, served)
+	})
+
+	t.Run("rejects commands the token doesn't allow", func(t *testing.T) {
+		rec := serve(guard, http.MethodPost, "/api/wallet/export", readToken)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.False(t, served)
+
+		// trailing path elements are arguments of the command, not
+		// subcommands
+		rec = serve(guard, http.MethodPost, "/api/wallet/export/ls", readToken)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.False(t, served)
+	})
+
+	t.Run("lets through preflight requests", func(t *testing.T) {
+		rec := serve(guard, http.MethodOptions, "/api/wallet/export", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, served)
+	})
+
+	t.Run("rejects all requests without a secret", func(t *testing.T) {
+		unsigned, err := api.CreateToken(nil, []api.Permission{api.PermRead})
+		require.NoError(t, err)
+
+		rec := serve(api.NewGuard(root, "/api", nil, permOf, next), http.MethodPost, "/api/wallet/ls", unsigned)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.False(t, served)
+	})
+}
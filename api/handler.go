@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ipfs/go-ipfs-cmdkit"
+	"github.com/ipfs/go-ipfs-cmds"
+	logging "github.com/ipfs/go-log"
+	"github.com/pkg/errors"
+)
+
+var log = logging.Logger("api")
+
+// Handler serves the commands of a root command over JSON-RPC, running them
+// in a command environment.
+type Handler struct {
+	root   *cmds.Command
+	env    cmds.Environment
+	secret []byte
+	permOf func(*cmds.Command) Permission
+}
+
+// NewHandler creates a handler running the commands of root in env, for
+// requests authenticated with tokens signed with secret. permOf returns the
+// permission a token needs to run a command. With an empty secret all
+// requests are rejected.
+func NewHandler(root *cmds.Command, env cmds.Environment, secret []byte, permOf func(*cmds.Command) Permission) *Handler {
+	return &Handler{
+		root:   root,
+		env:    env,
+		secret: secret,
+		permOf: permOf,
+	}
+}
+
+// ServeHTTP serves a JSON-RPC request.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	perms, err := authenticate(h.secret, r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req Request
+	dec := json.NewDecoder(r.Body)
+	// Keep numbers as they were written, to parse them as the type of the
+	// option they are given for.
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil {
+		writeResponse(w, &Response{Error: &Error{Code: ErrCodeParse, Message: err.Error()}})
+		return
+	}
+
+	result, rpcErr := h.call(r.Context(), &req, perms)
+	writeResponse(w, &Response{ID: req.ID, Result: result, Error: rpcErr})
+}
+
+// authenticate returns the permissions given by the token of the request,
+// which must be signed with secret.
+func authenticate(secret []byte, r *http.Request) ([]Permission, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("API authentication is not configured")
+	}
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return nil, errors.New("missing bearer token")
+	}
+	return VerifyToken(secret, auth[len(prefix):])
+}
+
+// call runs the command of the request's method if perms allow it and
+// returns the JSON array of the values it emitted.
+func (h *Handler) call(ctx context.Context, req *Request, perms []Permission) (json.RawMessage, *Error) {
+	if req.JSONRPC != JSONRPCVersion || req.Method == "" {
+		return nil, &Error{Code: ErrCodeInvalidRequest, Message: "not a JSON-RPC 2.0 request"}
+	}
+
+	path := strings.Split(req.Method, ".")
+	cmd, err := h.root.Get(path)
+	if err != nil || cmd.Run == nil || cmd.NoRemote {
+		return nil, &Error{Code: ErrCodeMethodNotFound, Message: fmt.Sprintf("unknown method %s", req.Method)}
+	}
+	if perm := h.permOf(cmd); !hasPermission(perms, perm) {
+		return nil, &Error{Code: ErrCodePermission, Message: fmt.Sprintf("method %s requires the %s permission", req.Method, perm)}
+	}
+
+	opts := make(cmdkit.OptMap)
+	for name, value := range req.Params.Options {
+		// The command request parses option values given as strings, like
+		// those from the command line.
+		if s, ok := value.(string); ok {
+			opts[name] = s
+		} else {
+			opts[name] = fmt.Sprint(value)
+		}
+	}
+	creq, err := cmds.NewRequest(ctx, path, opts, req.Params.Arguments, nil, h.root)
+	if err != nil {
+		return nil, &Error{Code: ErrCodeInvalidParams, Message: err.Error()}
+	}
+	if err := creq.FillDefaults(); err != nil {
+		return nil, &Error{Code: ErrCodeInvalidParams, Message: err.Error()}
+	}
+
+	// The command is cancelled once the request is served, so it doesn't
+	// block emitting values nobody reads if the call fails.
+	re, res := cmds.NewChanResponsePair(creq)
+	go func() {
+		err := cmds.NewExecutor(h.root).Execute(creq, re, h.env)
+		re.CloseWithError(err) // nolint: errcheck
+	}()
+
+	values := []interface{}{}
+	for {
+		v, err := res.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &Error{Code: ErrCodeCommand, Message: err.Error()}
+		}
+		if r, ok := v.(io.Reader); ok {
+			data, err := readAll(r)
+			if err != nil {
+				return nil, &Error{Code: ErrCodeCommand, Message: err.Error()}
+			}
+			v = data
+		}
+		values = append(values, v)
+	}
+
+	result, err := json.Marshal(values)
+	if err != nil {
+		return nil, &Error{Code: ErrCodeCommand, Message: err.Error()}
+	}
+	return result, nil
+}
+
+// readAll reads out the data a command streams, closing r if it is a closer.
+func readAll(r io.Reader) ([]byte, error) {
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close() // nolint: errcheck
+	}
+	return ioutil.ReadAll(r)
+}
+
+func writeResponse(w http.ResponseWriter, resp *Response) {
+	resp.JSONRPC = JSONRPCVersion
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Warningf("failed to write JSON-RPC response: %s", err)
+	}
+}
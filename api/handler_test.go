@@ -0,0 +1,108 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/api"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestHandler(t *testing.T) {
+	tf.UnitTest(t)
+
+	pingCmd := &cmds.Command{
+		Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+			return re.Emit("pong")
+		},
+	}
+	root := &cmds.Command{
+		Subcommands: map[string]*cmds.Command{
+			"ping": pingCmd,
+			"stop": {
+				Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+					return re.Emit("stopped")
+				},
+			},
+		},
+	}
+	permOf := func(cmd *cmds.Command) api.Permission {
+		if cmd == pingCmd {
+			return api.PermRead
+		}
+		return api.PermAdmin
+	}
+	secret := []byte("secret")
+	newHandler := func() http.Handler {
+		return api.NewHandler(root, nil, secret, permOf)
+	}
+	readToken, err := api.CreateToken(secret, []api.Permission{api.PermRead})
+	require.NoError(t, err)
+
+	serve := func(h http.Handler, method, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, api.RPCPath, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("serves a call", func(t *testing.T) {
+		rec := serve(newHandler(), http.MethodPost, readToken, `{"jsonrpc":"2.0","id":"a","method":"ping"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp api.Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Nil(t, resp.Error)
+		assert.Equal(t, `"a"`, string(resp.ID))
+		assert.JSONEq(t, `["pong"]`, string(resp.Result))
+	})
+
+	t.Run("reports parse errors", func(t *testing.T) {
+		rec := serve(newHandler(), http.MethodPost, readToken, `{"jsonrpc":`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp api.Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, api.ErrCodeParse, resp.Error.Code)
+	})
+
+	t.Run("rejects other HTTP methods", func(t *testing.T) {
+		rec := serve(newHandler(), http.MethodGet, readToken, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+
+	t.Run("rejects unauthenticated requests", func(t *testing.T) {
+		rec := serve(newHandler(), http.MethodPost, "", `{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("rejects methods the token doesn't allow", func(t *testing.T) {
+		rec := serve(newHandler(), http.MethodPost, readToken, `{"jsonrpc":"2.0","id":1,"method":"stop"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp api.Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, api.ErrCodePermission, resp.Error.Code)
+		assert.Nil(t, resp.Result)
+	})
+
+	t.Run("rejects all requests without a secret", func(t *testing.T) {
+		unsigned, err := api.CreateToken(nil, []api.Permission{api.PermRead})
+		require.NoError(t, err)
+
+		rec := serve(api.NewHandler(root, nil, nil, permOf), http.MethodPost, unsigned, `{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
@@ -0,0 +1,75 @@
+// Package api serves the filecoin command set over a versioned HTTP JSON-RPC
+// 2.0 API, so that applications can integrate with a node without going
+// through the CLI. The package api/client implements a Go client for it.
+//
+// Each command is a method named after its path joined with dots, e.g.
+// "wallet.balance", taking the command's arguments and options as params:
+//
+//	{"jsonrpc": "2.0", "id": 1, "method": "wallet.balance",
+//	 "params": {"arguments": ["t1..."], "options": {}}}
+//
+// The result is the array of the values the command emitted, in their JSON
+// encoding. Data the command streams, like with client.cat, is emitted as a
+// base64 string.
+//
+// Requests must be authenticated with a token in an "Authorization: Bearer
+// <token>" header. Tokens are JSON web tokens signed with the secret of the
+// node, giving permissions to the scopes of commands they may run.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RPCPath is the path of the current version of the JSON-RPC API.
+const RPCPath = "/rpc/v0"
+
+// JSONRPCVersion is the version of the JSON-RPC protocol the API implements.
+const JSONRPCVersion = "2.0"
+
+// Error codes of the API, as defined by the JSON-RPC 2.0 specification, and
+// ErrCodeCommand for the errors returned by commands and ErrCodePermission
+// for calls the token doesn't allow.
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeCommand        = -32000
+	ErrCodePermission     = -32001
+)
+
+// Request is a JSON-RPC request calling a command.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  Params          `json:"params"`
+}
+
+// Params are the arguments and options of a command call. Options are given
+// by name, with the same values as on the command line.
+type Params struct {
+	Arguments []string               `json:"arguments,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+}
+
+// Response is a JSON-RPC response, holding either the values emitted by the
+// command or the error that made the call fail.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is the error of a failed JSON-RPC call.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
@@ -0,0 +1,66 @@
+package chain
+
+import (
+	"sync"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// badBlockCacheSize is the number of bad blocks the badBlockCache remembers.
+const badBlockCacheSize = 1 << 14
+
+// badBlockCache keeps track of blocks known to break the rules of consensus,
+// by the cids of their headers, so that the syncer never validates them
+// again nor any chain building on them. A block is bad if it is invalid on its
+// own or if one of its ancestors is. Like the badTipSetCache it is only
+// in-memory, so it is reset whenever the node is restarted. Once full it
+// forgets the blocks it learned of first.
+type badBlockCache struct {
+	mu    sync.Mutex
+	bad   map[cid.Cid]struct{}
+	order []cid.Cid
+}
+
+func newBadBlockCache() *badBlockCache {
+	return &badBlockCache{
+		bad: make(map[cid.Cid]struct{}),
+	}
+}
+
+// AddChain adds all blocks of the chain of tipsets to the badBlockCache.
+func (cache *badBlockCache) AddChain(chain []types.TipSet) {
+	for _, ts := range chain {
+		for _, blk := range ts.ToSlice() {
+			cache.Add(blk.Cid())
+		}
+	}
+}
+
+// Add adds a single block cid to the badBlockCache.
+func (cache *badBlockCache) Add(c cid.Cid) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if _, ok := cache.bad[c]; ok {
+		return
+	}
+	if len(cache.order) >= badBlockCacheSize {
+		delete(cache.bad, cache.order[0])
+		cache.order = cache.order[1:]
+	}
+	cache.bad[c] = struct{}{}
+	cache.order = append(cache.order, c)
+}
+
+// HasAny checks whether any of the block cids are in the badBlockCache.
+func (cache *badBlockCache) HasAny(cids types.SortedCidSet) bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for _, c := range cids.ToSlice() {
+		if _, ok := cache.bad[c]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package chain
+
+import (
+	"context"
+	"io"
+
+	"github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-car"
+	carutil "github.com/ipfs/go-car/util"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-hamt-ipld"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Archiver exports chains to CAR files and imports them back. A CAR file
+// written by Export is rooted at the blocks of the head tipset and holds the
+// blocks of the head and its ancestors, with their messages and receipts, and
+// optionally the state trees the blocks refer to.
+type Archiver struct {
+	// bs holds the state trees the blocks refer to.
+	bs bstore.Blockstore
+	// To compute the state of imported tipsets.
+	cst       *hamt.CborIpldStore
+	consensus consensus.Protocol
+	store     Store
+}
+
+// NewArchiver returns a new Archiver.
+func NewArchiver(bs bstore.Blockstore, cst *hamt.CborIpldStore, c consensus.Protocol, store Store) *Archiver {
+	return &Archiver{
+		bs:        bs,
+		cst:       cst,
+		consensus: c,
+		store:     store,
+	}
+}
+
+// Export writes the chain ending in head to out as a CAR file. The state
+// trees the blocks refer to are written too if includeState is set. progress
+// is called with the number of blocks of the chain written so far after
+// every block.
+func (a *Archiver) Export(ctx context.Context, head types.TipSet, includeState bool, out io.Writer, progress func(uint64)) error {
+	header, err := cbor.DumpObject(&car.CarHeader{
+		Roots:   head.ToSortedCidSet().ToSlice(),
+		Version: 1,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode car header")
+	}
+	if err := carutil.LdWrite(out, header); err != nil {
+		return err
+	}
+
+	written := make(map[cid.Cid]struct{})
+	var count uint64
+	for iterator := IterAncestors(ctx, a.store, head); !iterator.Complete(); err = iterator.Next() {
+		if err != nil {
+			return err
+		}
+		for _, blk := range iterator.Value().ToSlice() {
+			if err := writeNode(out, blk.ToNode(), written); err != nil {
+				return err
+			}
+			if includeState {
+				if err := a.writeDAG(ctx, out, blk.StateRoot, written); err != nil {
+					return errors.Wrapf(err, "failed to export state of block %s", blk.Cid())
+				}
+			}
+			count++
+			progress(count)
+		}
+	}
+	return nil
+}
+
+// writeNode writes the ipld block blk to out unless it was written already.
+func writeNode(out io.Writer, blk blocks.Block, written map[cid.Cid]struct{}) error {
+	if _, ok := written[blk.Cid()]; ok {
+		return nil
+	}
+	if err := carutil.LdWrite(out, blk.Cid().Bytes(), blk.RawData()); err != nil {
+		return err
+	}
+	written[blk.Cid()] = struct{}{}
+	return nil
+}
+
+// writeDAG writes the dag rooted at the ipld block with cid c to out, leaving
+// out the parts written already.
+func (a *Archiver) writeDAG(ctx context.Context, out io.Writer, c cid.Cid, written map[cid.Cid]struct{}) error {
+	if _, ok := written[c]; ok || !c.Defined() {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	blk, err := a.bs.Get(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get block %s", c)
+	}
+	if err := writeNode(out, blk, written); err != nil {
+		return err
+	}
+	if c.Type() != cid.DagCBOR {
+		return nil
+	}
+
+	node, err := cbor.DecodeBlock(blk)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode %s", c)
+	}
+	for _, link := range node.Links() {
+		if err := a.writeDAG(ctx, out, link.Cid, written); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import reads a CAR file written by Export from in and syncs the store to
+// the chain in it, validating and processing every tipset the way the syncer
+// does chains from the network. State trees in the file are not trusted; the
+// state is computed from the genesis state of the store. The chain must have
+// the genesis block of the store. progress is called with the number of
+// blocks read so far after every block. Import returns the head of the chain
+// in the file, which becomes the head of the store if it is heavier.
+func (a *Archiver) Import(ctx context.Context, in io.Reader, progress func(uint64)) (types.SortedCidSet, error) {
+	cr, err := car.NewCarReader(in)
+	if err != nil {
+		return types.SortedCidSet{}, errors.Wrap(err, "failed to read car header")
+	}
+	if len(cr.Header.Roots) == 0 {
+		return types.SortedCidSet{}, errors.New("car file has no roots")
+	}
+
+	// The blocks are only added to the store once validated, so they are
+	// kept apart until then.
+	// TODO: chains that do not fit in memory need an on disk staging area.
+	staged := bstore.NewBlockstore(datastore.NewMapDatastore())
+	var count uint64
+	for {
+		blk, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return types.SortedCidSet{}, errors.Wrap(err, "failed to read car block")
+		}
+		if err := staged.Put(blk); err != nil {
+			return types.SortedCidSet{}, err
+		}
+		count++
+		progress(count)
+	}
+
+	head := types.NewSortedCidSet(cr.Header.Roots...)
+	fetcher := &stagedFetcher{staged: staged}
+	if err := a.checkGenesis(ctx, fetcher, head); err != nil {
+		return types.SortedCidSet{}, err
+	}
+
+	syncer := NewDefaultSyncer(a.cst, a.consensus, a.store, fetcher)
+	if err := syncer.HandleNewTipset(ctx, head); err != nil {
+		return types.SortedCidSet{}, errors.Wrap(err, "chain in car file is invalid")
+	}
+	return head, nil
+}
+
+// checkGenesis returns an error if the chain ending in head does not have the
+// genesis block of the store.
+func (a *Archiver) checkGenesis(ctx context.Context, fetcher *stagedFetcher, head types.SortedCidSet) error {
+	key := head
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if a.store.HasTipSetAndState(ctx, key.String()) {
+			// the chain joins the chain of the store
+			return nil
+		}
+		blks, err := fetcher.getStaged(key.ToSlice())
+		if err != nil {
+			return err
+		}
+		if blks[0].Parents.Empty() {
+			return errors.Errorf("chain in car file has genesis %s, expected %s", blks[0].Cid(), a.store.GenesisCid())
+		}
+		key = blks[0].Parents
+	}
+}
+
+// stagedFetcher fetches blocks from a car file staged in a blockstore for the
+// syncer. The syncer puts the blocks into the store once they are validated.
+type stagedFetcher struct {
+	staged bstore.Blockstore
+}
+
+// GetBlocks returns the blocks with the given cids.
+func (f *stagedFetcher) GetBlocks(ctx context.Context, cids []cid.Cid) ([]*types.Block, error) {
+	return f.getStaged(cids)
+}
+
+func (f *stagedFetcher) getStaged(cids []cid.Cid) ([]*types.Block, error) {
+	var blks []*types.Block
+	for _, c := range cids {
+		raw, err := f.staged.Get(c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "block %s is not in the car file", c)
+		}
+		blk, err := types.DecodeBlock(raw.RawData())
+		if err != nil {
+			return nil, err
+		}
+		blks = append(blks, blk)
+	}
+	return blks, nil
+}
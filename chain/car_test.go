@@ -0,0 +1,80 @@
+package chain_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-hamt-ipld"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/proofs"
+	"github.com/filecoin-project/go-filecoin/repo"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestArchiverExportImport(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	ctx := context.Background()
+	syncer, chainStore, con, blockSource := initSyncTestWithPowerTable(t, &th.TestView{})
+	_ = requirePutBlocks(t, blockSource, link1.ToSlice()...)
+	_ = requirePutBlocks(t, blockSource, link2.ToSlice()...)
+	_ = requirePutBlocks(t, blockSource, link3.ToSlice()...)
+	cids4 := requirePutBlocks(t, blockSource, link4.ToSlice()...)
+	require.NoError(t, syncer.HandleNewTipset(ctx, cids4))
+
+	r := repo.NewInMemoryRepo()
+	bs := bstore.NewBlockstore(r.Datastore())
+	cst := hamt.NewCborStore()
+	_, err := initGenesis(cst, bs)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	var exported uint64
+	exporter := chain.NewArchiver(bs, cst, con, chainStore)
+	require.NoError(t, exporter.Export(ctx, link4, false, &buf, func(n uint64) { exported = n }))
+	// the blocks of link4 back to the genesis block
+	assert.Equal(t, uint64(9), exported)
+
+	t.Run("imports the chain into a fresh store", func(t *testing.T) {
+		_, freshStore, _, _ := initSyncTest(t, con, initGenesis, cst, bs, repo.NewInMemoryRepo())
+		importer := chain.NewArchiver(bs, cst, con, freshStore)
+
+		head, err := importer.Import(ctx, bytes.NewReader(buf.Bytes()), func(uint64) {})
+		require.NoError(t, err)
+		assert.Equal(t, link4.ToSortedCidSet(), head)
+
+		assertTsAdded(t, freshStore, link1)
+		assertTsAdded(t, freshStore, link2)
+		assertTsAdded(t, freshStore, link3)
+		assertTsAdded(t, freshStore, link4)
+		assertHead(t, freshStore, link4)
+	})
+
+	t.Run("rejects a chain with another genesis block", func(t *testing.T) {
+		otherGenesis := func(cst *hamt.CborIpldStore, bs bstore.Blockstore) (*types.Block, error) {
+			genesis, err := initGenesis(cst, bs)
+			if err != nil {
+				return nil, err
+			}
+			genesis.Nonce = 1
+			return genesis, nil
+		}
+		verifier := proofs.NewFakeVerifier(true, nil)
+		otherCon := consensus.NewExpected(cst, bs, th.NewTestProcessor(), &th.TestView{}, genCid, verifier)
+		_, otherStore, _, _ := initSyncTest(t, otherCon, otherGenesis, cst, bs, repo.NewInMemoryRepo())
+		importer := chain.NewArchiver(bs, cst, otherCon, otherStore)
+
+		_, err := importer.Import(ctx, bytes.NewReader(buf.Bytes()), func(uint64) {})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "chain in car file has genesis")
+		assertNoAdd(t, otherStore, link1.ToSortedCidSet())
+	})
+}
@@ -0,0 +1,149 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// CheckStore checks the integrity of the chain data stored in ds, the
+// datastore of a DefaultStore that is not running. It follows the chain from
+// the stored head back to genesis, expecting every block and the state root
+// of every tipset to be present, and looks for blocks that are not on that
+// chain.
+//
+// With repair set, a head that cannot be loaded is reset to the genesis
+// block, from which the node syncs the chain again, and blocks that are not
+// on the chain are deleted.
+func CheckStore(ctx context.Context, ds repo.Datastore, genesisCid cid.Cid, repair bool) ([]*repo.Problem, error) {
+	bs := bstore.NewBlockstore(ds)
+
+	onChain, broken, err := walkChain(ds, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	if broken != "" {
+		problem := &repo.Problem{Description: broken}
+		if repair {
+			if err := resetHead(ds, bs, genesisCid); err != nil {
+				return nil, err
+			}
+			problem.Description += "; reset the chain head to genesis"
+			problem.Repaired = true
+		}
+		// The blocks of a broken chain are not orphans, they are what is left
+		// of the chain and will be synced on top of again.
+		return []*repo.Problem{problem}, nil
+	}
+
+	keys, err := bs.AllKeysChan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list chain blocks")
+	}
+
+	var orphans []cid.Cid
+	for c := range keys {
+		if !onChain[c] {
+			orphans = append(orphans, c)
+		}
+	}
+	if len(orphans) == 0 {
+		return nil, nil
+	}
+
+	problem := &repo.Problem{Description: fmt.Sprintf("%d blocks are not on the chain to the head", len(orphans))}
+	if repair {
+		for _, c := range orphans {
+			if err := bs.DeleteBlock(c); err != nil {
+				return nil, errors.Wrapf(err, "failed to delete orphaned block %s", c)
+			}
+		}
+		problem.Description += "; deleted them"
+		problem.Repaired = true
+	}
+	return []*repo.Problem{problem}, nil
+}
+
+// walkChain follows the chain from the head stored in ds back to genesis and
+// returns the cids of its blocks. If the chain cannot be loaded it instead
+// returns a description of the first inconsistency found.
+func walkChain(ds repo.Datastore, bs bstore.Blockstore) (map[cid.Cid]bool, string, error) {
+	bb, err := ds.Get(headKey)
+	if err == datastore.ErrNotFound {
+		return nil, "the chain head is missing", nil
+	} else if err != nil {
+		return nil, "", errors.Wrap(err, "failed to read headKey")
+	}
+
+	var tsKey types.SortedCidSet
+	if err := json.Unmarshal(bb, &tsKey); err != nil {
+		return nil, "the chain head is truncated", nil
+	}
+
+	onChain := map[cid.Cid]bool{}
+	for !tsKey.Empty() {
+		ts := types.TipSet{}
+		for it := tsKey.Iter(); !it.Complete(); it.Next() {
+			blk, err := bs.Get(it.Value())
+			if err == bstore.ErrNotFound {
+				return nil, fmt.Sprintf("block %s of the chain is missing", it.Value()), nil
+			} else if err != nil {
+				return nil, "", errors.Wrapf(err, "failed to get block %s", it.Value())
+			}
+
+			decoded, err := types.DecodeBlock(blk.RawData())
+			if err != nil {
+				return nil, fmt.Sprintf("block %s of the chain is truncated", it.Value()), nil
+			}
+			if err := ts.AddBlock(decoded); err != nil {
+				return nil, fmt.Sprintf("block %s does not belong to tipset %s", it.Value(), tsKey), nil
+			}
+			onChain[it.Value()] = true
+		}
+
+		h, err := ts.Height()
+		if err != nil {
+			return nil, "", err
+		}
+		has, err := ds.Has(datastore.NewKey(makeKey(ts.String(), h)))
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to read tipset key %s", ts.String())
+		}
+		if !has {
+			return nil, fmt.Sprintf("the state root of tipset %s at height %d is missing", ts.String(), h), nil
+		}
+
+		tsKey, err = ts.Parents()
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return onChain, "", nil
+}
+
+// resetHead makes the genesis block the head stored in ds.
+func resetHead(ds repo.Datastore, bs bstore.Blockstore, genesisCid cid.Cid) error {
+	has, err := bs.Has(genesisCid)
+	if err != nil {
+		return errors.Wrap(err, "failed to read genesis block")
+	}
+	if !has {
+		return errors.New("cannot reset the chain head, the genesis block is missing")
+	}
+
+	val, err := json.Marshal(types.NewSortedCidSet(genesisCid))
+	if err != nil {
+		return err
+	}
+	return ds.Put(headKey, val)
+}
@@ -0,0 +1,74 @@
+package chain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-hamt-ipld"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/repo"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestCheckStore(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	ctx := context.Background()
+	initStoreTest(ctx, t)
+
+	newStoreWithHead := func() repo.Datastore {
+		ds := repo.NewInMemoryRepo().ChainDatastore()
+		chainStore := chain.NewDefaultStore(ds, hamt.NewCborStore(), genCid)
+		requirePutTestChain(t, chainStore)
+		assertSetHead(t, chainStore, genTS)
+		assertSetHead(t, chainStore, link2)
+		chainStore.Stop()
+		return ds
+	}
+
+	t.Run("reports and deletes blocks that are not on the chain", func(t *testing.T) {
+		ds := newStoreWithHead()
+
+		problems, err := chain.CheckStore(ctx, ds, genCid, false)
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.Contains(t, problems[0].Description, "3 blocks are not on the chain")
+		assert.False(t, problems[0].Repaired)
+
+		problems, err = chain.CheckStore(ctx, ds, genCid, true)
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.True(t, problems[0].Repaired)
+
+		problems, err = chain.CheckStore(ctx, ds, genCid, false)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+
+		rebootChain := chain.NewDefaultStore(ds, hamt.NewCborStore(), genCid)
+		require.NoError(t, rebootChain.Load(ctx))
+		assert.False(t, rebootChain.HasBlock(ctx, link3blk1.Cid()))
+		assert.True(t, rebootChain.HasBlock(ctx, link2blk1.Cid()))
+	})
+
+	t.Run("resets the head to genesis when a block of the chain is missing", func(t *testing.T) {
+		ds := newStoreWithHead()
+		require.NoError(t, bstore.NewBlockstore(ds).DeleteBlock(link1blk2.Cid()))
+
+		rebootChain := chain.NewDefaultStore(ds, hamt.NewCborStore(), genCid)
+		require.Error(t, rebootChain.Load(ctx))
+
+		problems, err := chain.CheckStore(ctx, ds, genCid, true)
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.Contains(t, problems[0].Description, link1blk2.Cid().String())
+		assert.True(t, problems[0].Repaired)
+
+		rebootChain = chain.NewDefaultStore(ds, hamt.NewCborStore(), genCid)
+		require.NoError(t, rebootChain.Load(ctx))
+		assert.Equal(t, genTS.ToSortedCidSet(), rebootChain.GetHead())
+	})
+}
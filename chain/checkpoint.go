@@ -0,0 +1,160 @@
+package chain
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ErrChainBeforeCheckpoint is returned when syncing a chain that does not
+// include the checkpoint of the syncer.
+var ErrChainBeforeCheckpoint = errors.New("input chain forks from the chain before the trusted checkpoint")
+
+// Checkpoint is a tipset trusted to be on the chain, along with the state root
+// resulting from applying it. A syncer with a checkpoint fetches the state at
+// the checkpoint instead of computing it from the genesis state.
+type Checkpoint struct {
+	TipSet    types.SortedCidSet
+	StateRoot cid.Cid
+}
+
+// ParseCheckpoint parses a checkpoint from the comma separated cids of the
+// blocks of its tipset and its state root.
+func ParseCheckpoint(tipSet, stateRoot string) (*Checkpoint, error) {
+	var cids []cid.Cid
+	for _, s := range strings.Split(tipSet, ",") {
+		c, err := cid.Decode(strings.TrimSpace(s))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid checkpoint block cid %q", s)
+		}
+		cids = append(cids, c)
+	}
+	root, err := cid.Decode(stateRoot)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid checkpoint state root %q", stateRoot)
+	}
+	return &Checkpoint{
+		TipSet:    types.NewSortedCidSet(cids...),
+		StateRoot: root,
+	}, nil
+}
+
+type stateFetcher interface {
+	// FetchState fetches the state tree with the given root into the state
+	// store of the syncer.
+	FetchState(context.Context, cid.Cid) error
+}
+
+// UseCheckpoint makes the syncer sync chains back to the checkpoint instead of
+// back to genesis. The first time the syncer reaches the checkpoint it adds
+// the headers of the checkpoint and its ancestors to the store and fetches the
+// state at the checkpoint and at its parent with the state fetcher, without
+// executing any of their messages. The syncer rejects chains that do not
+// include the checkpoint.
+func (syncer *DefaultSyncer) UseCheckpoint(cp *Checkpoint, f stateFetcher) {
+	syncer.mu.Lock()
+	defer syncer.mu.Unlock()
+
+	syncer.checkpoint = cp
+	syncer.stateFetcher = f
+}
+
+// checkpointHeight returns the height of the checkpoint tipset, fetching its
+// blocks if necessary.
+func (syncer *DefaultSyncer) checkpointHeight(ctx context.Context) (uint64, error) {
+	if syncer.checkpointTipSet == nil {
+		blks, err := syncer.getBlksMaybeFromNet(ctx, syncer.checkpoint.TipSet.ToSlice())
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to fetch checkpoint")
+		}
+		ts, err := syncer.consensus.NewValidTipSet(ctx, blks)
+		if err != nil {
+			return 0, errors.Wrap(err, "checkpoint is not a valid tipset")
+		}
+		syncer.checkpointTipSet = ts
+	}
+	return syncer.checkpointTipSet.Height()
+}
+
+// syncCheckpoint adds the checkpoint tipset and its ancestors back to a
+// tipset in the store to the store without processing them. The state root of
+// every ancestor is the one the blocks of its child commit to, so only the
+// state trees at the checkpoint and its parent are fetched. They are needed to
+// process the child of the checkpoint and to compare the weight of the
+// checkpoint with the head.
+//
+// Precondition: the caller must hold the syncer's lock.
+func (syncer *DefaultSyncer) syncCheckpoint(ctx context.Context) error {
+	if _, err := syncer.checkpointHeight(ctx); err != nil {
+		return err
+	}
+	checkpoint := syncer.checkpointTipSet
+	logSyncer.Infof("syncing headers back from checkpoint %s", checkpoint.String())
+
+	tsass := []*TipSetAndState{{TipSet: checkpoint, TipSetStateRoot: syncer.checkpoint.StateRoot}}
+	child := checkpoint
+	for {
+		parentCids, err := child.Parents()
+		if err != nil {
+			return err
+		}
+		childStateRoot := child.ToSlice()[0].StateRoot
+		if syncer.chainStore.HasTipSetAndState(ctx, parentCids.String()) {
+			parentTsas, err := syncer.chainStore.GetTipSetAndState(parentCids)
+			if err != nil {
+				return err
+			}
+			if !parentTsas.TipSetStateRoot.Equals(childStateRoot) {
+				return errors.Errorf("checkpoint chain commits to state %s of tipset %s, expected %s", childStateRoot, parentCids, parentTsas.TipSetStateRoot)
+			}
+			break
+		}
+		if parentCids.Empty() {
+			return errors.Errorf("checkpoint chain has genesis %s, expected %s", child.String(), syncer.chainStore.GenesisCid())
+		}
+
+		blks, err := syncer.getBlksMaybeFromNet(ctx, parentCids.ToSlice())
+		if err != nil {
+			return err
+		}
+		parent, err := syncer.consensus.NewValidTipSet(ctx, blks)
+		if err != nil {
+			return err
+		}
+		tsass = append(tsass, &TipSetAndState{TipSet: parent, TipSetStateRoot: childStateRoot})
+		child = parent
+
+		if len(tsass)%500 == 0 {
+			logSyncer.Infof("fetching headers before the checkpoint, %d tipsets fetched", len(tsass))
+		}
+	}
+
+	if err := syncer.stateFetcher.FetchState(ctx, syncer.checkpoint.StateRoot); err != nil {
+		return errors.Wrap(err, "failed to fetch checkpoint state")
+	}
+	if err := syncer.stateFetcher.FetchState(ctx, checkpoint.ToSlice()[0].StateRoot); err != nil {
+		return errors.Wrap(err, "failed to fetch checkpoint parent state")
+	}
+
+	for i := len(tsass) - 1; i >= 0; i-- {
+		if err := syncer.chainStore.PutTipSetAndState(ctx, tsass[i]); err != nil {
+			return err
+		}
+	}
+
+	logSyncer.Infof("added checkpoint %s and %d tipsets before it", checkpoint.String(), len(tsass)-1)
+
+	parentCids, err := checkpoint.Parents()
+	if err != nil {
+		return err
+	}
+	parentTsas, err := syncer.chainStore.GetTipSetAndState(parentCids)
+	if err != nil {
+		return err
+	}
+	return syncer.setHeadIfHeavier(ctx, parentTsas.TipSet, checkpoint)
+}
@@ -0,0 +1,94 @@
+package chain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// testStateFetcher records the state roots it is asked to fetch. The state
+// trees of the test chain are in the store already.
+type testStateFetcher struct {
+	fetched []cid.Cid
+}
+
+func (f *testStateFetcher) FetchState(ctx context.Context, root cid.Cid) error {
+	f.fetched = append(f.fetched, root)
+	return nil
+}
+
+func TestParseCheckpoint(t *testing.T) {
+	tf.UnitTest(t)
+
+	c1, c2 := cidGetter(), cidGetter()
+
+	cp, err := chain.ParseCheckpoint(c1.String()+", "+c2.String(), c1.String())
+	require.NoError(t, err)
+	assert.Equal(t, types.NewSortedCidSet(c1, c2), cp.TipSet)
+	assert.Equal(t, c1, cp.StateRoot)
+
+	_, err = chain.ParseCheckpoint("nope", c1.String())
+	assert.Error(t, err)
+	_, err = chain.ParseCheckpoint(c1.String(), "")
+	assert.Error(t, err)
+}
+
+func TestSyncFromCheckpoint(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	t.Run("syncs headers back to the checkpoint", func(t *testing.T) {
+		syncer, chainStore, _, blockSource := initSyncTestDefault(t)
+		ctx := context.Background()
+		states := &testStateFetcher{}
+		syncer.UseCheckpoint(&chain.Checkpoint{TipSet: link2.ToSortedCidSet(), StateRoot: link2State}, states)
+
+		_ = requirePutBlocks(t, blockSource, link1.ToSlice()...)
+		_ = requirePutBlocks(t, blockSource, link2.ToSlice()...)
+		_ = requirePutBlocks(t, blockSource, link3.ToSlice()...)
+		cids4 := requirePutBlocks(t, blockSource, link4.ToSlice()...)
+
+		require.NoError(t, syncer.HandleNewTipset(ctx, cids4))
+		assertTsAdded(t, chainStore, link1)
+		assertTsAdded(t, chainStore, link2)
+		assertTsAdded(t, chainStore, link3)
+		assertTsAdded(t, chainStore, link4)
+		assertHead(t, chainStore, link4)
+
+		// the states at the checkpoint and its parent
+		assert.Equal(t, []cid.Cid{link2State, link1State}, states.fetched)
+	})
+
+	t.Run("syncs to the checkpoint itself", func(t *testing.T) {
+		syncer, chainStore, _, blockSource := initSyncTestDefault(t)
+		ctx := context.Background()
+		syncer.UseCheckpoint(&chain.Checkpoint{TipSet: link2.ToSortedCidSet(), StateRoot: link2State}, &testStateFetcher{})
+
+		_ = requirePutBlocks(t, blockSource, link1.ToSlice()...)
+		cids2 := requirePutBlocks(t, blockSource, link2.ToSlice()...)
+
+		require.NoError(t, syncer.HandleNewTipset(ctx, cids2))
+		assertTsAdded(t, chainStore, link2)
+		assertHead(t, chainStore, link2)
+	})
+
+	t.Run("rejects chains without the checkpoint", func(t *testing.T) {
+		syncer, chainStore, _, blockSource := initSyncTestDefault(t)
+		ctx := context.Background()
+		syncer.UseCheckpoint(&chain.Checkpoint{TipSet: link2.ToSortedCidSet(), StateRoot: link2State}, &testStateFetcher{})
+
+		cids1 := requirePutBlocks(t, blockSource, link1.ToSlice()...)
+		_ = requirePutBlocks(t, blockSource, link2.ToSlice()...)
+
+		err := syncer.HandleNewTipset(ctx, cids1)
+		assert.Equal(t, chain.ErrChainBeforeCheckpoint, err)
+		assertNoAdd(t, chainStore, cids1)
+		assertHead(t, chainStore, genTS)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"runtime/debug"
 	"sync"
+	"time"
 
 	"github.com/cskr/pubsub"
 	"github.com/ipfs/go-cid"
@@ -18,6 +19,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/metrics"
 	"github.com/filecoin-project/go-filecoin/metrics/tracing"
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/state"
@@ -28,6 +30,8 @@ var logStore = logging.Logger("chain.store")
 
 var headKey = datastore.NewKey("/chain/heaviestTipSet")
 
+var putTipSetOp = metrics.NewSlowOp("chain/put_tipset_and_state", 100*time.Millisecond)
+
 // DefaultStore is a generic implementation of the Store interface.
 // It works(tm) for now.
 type DefaultStore struct {
@@ -210,6 +214,8 @@ func (store *DefaultStore) putBlk(ctx context.Context, block *types.Block) error
 
 // PutTipSetAndState persists the blocks of a tipset and the tipset index.
 func (store *DefaultStore) PutTipSetAndState(ctx context.Context, tsas *TipSetAndState) error {
+	defer putTipSetOp.Start().Stop()
+
 	// Persist blocks.
 	for _, blk := range tsas.TipSet {
 		if err := store.putBlk(ctx, blk); err != nil {
@@ -249,6 +255,12 @@ func (store *DefaultStore) GetTipSetAndStatesByParentsAndHeight(pTsKey string, h
 	return store.tipIndex.GetByParentsAndHeight(pTsKey, h)
 }
 
+// AllTipSetAndStates returns all tipsets and states tracked by the default
+// store's tipIndex, those of forks included.
+func (store *DefaultStore) AllTipSetAndStates() []*TipSetAndState {
+	return store.tipIndex.All()
+}
+
 // HasTipSetAndStatesWithParentsAndHeight returns true if the default store's tipindex
 // contains any tipset indexed by the provided parent ID.
 func (store *DefaultStore) HasTipSetAndStatesWithParentsAndHeight(pTsKey string, h uint64) bool {
@@ -317,28 +329,40 @@ func (store *DefaultStore) SetHead(ctx context.Context, ts types.TipSet) error {
 		logStore.Error(debug.Stack())
 	}
 
-	if err := store.setHeadPersistent(ctx, ts); err != nil {
+	oldHead, err := store.setHeadPersistent(ctx, ts)
+	if err != nil {
 		return err
 	}
 
 	// Publish an event that we have a new head.
 	store.HeadEvents().Pub(ts, NewHeadTopic)
 
+	// Publish how the chain changed. The head is set already, so failing to
+	// describe the change is only logged.
+	change, err := CollectHeadChange(ctx, store, oldHead, ts)
+	if err != nil {
+		logStore.Errorf("failed to collect head change from %s to %s: %s", oldHead.String(), ts.String(), err)
+		return nil
+	}
+	store.HeadEvents().Pub(change, HeadChangeTopic)
+
 	return nil
 }
 
-func (store *DefaultStore) setHeadPersistent(ctx context.Context, ts types.TipSet) error {
+// setHeadPersistent sets and persists the head, returning the previous one.
+func (store *DefaultStore) setHeadPersistent(ctx context.Context, ts types.TipSet) (types.TipSet, error) {
 	store.mu.Lock()
 	defer store.mu.Unlock()
 
 	// Ensure consistency by storing this new head on disk.
 	if errInner := store.writeHead(ctx, ts.ToSortedCidSet()); errInner != nil {
-		return errors.Wrap(errInner, "failed to write new Head to datastore")
+		return nil, errors.Wrap(errInner, "failed to write new Head to datastore")
 	}
 
+	oldHead := store.head
 	store.head = ts
 
-	return nil
+	return oldHead, nil
 }
 
 // writeHead writes the given cid set as head to disk.
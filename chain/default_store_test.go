@@ -343,6 +343,27 @@ func TestHeadEvents(t *testing.T) {
 	assertEmptyCh(t, chB)
 }
 
+// Head changes are propagated on HeadEvents.
+func TestHeadChangeEvents(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	ctx := context.Background()
+	initStoreTest(ctx, t)
+	chainStore := newChainStore()
+	requirePutTestChain(t, chainStore)
+
+	ch := chainStore.HeadEvents().Sub(chain.HeadChangeTopic)
+
+	assertSetHead(t, chainStore, genTS)
+	assertSetHead(t, chainStore, link2)
+	assertSetHead(t, chainStore, link1)
+
+	assert.Equal(t, &chain.HeadChange{Apply: []types.TipSet{genTS}}, <-ch)
+	assert.Equal(t, &chain.HeadChange{Apply: []types.TipSet{link1, link2}}, <-ch)
+	assert.Equal(t, &chain.HeadChange{Revert: []types.TipSet{link2}}, <-ch)
+	assertEmptyCh(t, ch)
+}
+
 /* Loading  */
 // Load does not error and gives the chain store access to all blocks and
 // tipset indexes along the heaviest chain.
@@ -2,6 +2,7 @@ package chain
 
 import (
 	"context"
+	"runtime"
 	"sync"
 	"time"
 
@@ -25,20 +26,97 @@ var blkWaitTime = 30 * time.Second
 var (
 	// ErrChainHasBadTipSet is returned when the syncer traverses a chain with a cached bad tipset.
 	ErrChainHasBadTipSet = errors.New("input chain contains a cached bad tipset")
+	// ErrChainHasBadBlock is returned when the syncer traverses a chain with a cached bad block.
+	ErrChainHasBadBlock = errors.New("input chain contains a cached bad block")
 	// ErrNewChainTooLong is returned when processing a fork that split off from the main chain too many blocks ago.
 	ErrNewChainTooLong = errors.New("input chain forked from best chain too far in the past")
 	// ErrUnexpectedStoreState indicates that the syncer's chain store is violating expected invariants.
 	ErrUnexpectedStoreState = errors.New("the chain store is in an unexpected state")
+	// ErrBadParentWeight is returned when a tipset claims a parent weight its parents cannot have.
+	ErrBadParentWeight = errors.New("input chain claims a parent weight its parents cannot have")
 )
 
 var logSyncer = logging.Logger("chain.syncer")
 
+// IsBadChainError is true of the errors HandleNewTipset returns when the chain
+// it was given breaks the rules of consensus, as opposed to when the syncer
+// fails to fetch or store it. The peers sending such chains are misbehaving.
+func IsBadChainError(err error) bool {
+	for err != nil {
+		if _, ok := err.(badChainError); ok {
+			return true
+		}
+		c, ok := err.(causer)
+		if !ok {
+			return false
+		}
+		err = c.Cause()
+	}
+	return false
+}
+
+type causer interface {
+	Cause() error
+}
+
+// stateTransitionFailures are the causes of the errors of running a state
+// transition that show the tipset breaks the rules of consensus. Running it
+// can fail for other reasons, reading the stores for one, which say nothing
+// about the tipset.
+var stateTransitionFailures = []error{
+	consensus.ErrBlockBeforeParents,
+	consensus.ErrInvalidBlockSignature,
+	consensus.ErrInvalidMessage,
+	consensus.ErrInvalidTicket,
+	consensus.ErrLosingTicket,
+	consensus.ErrReceiptsMismatch,
+	consensus.ErrStateRootMismatch,
+}
+
+// isBadHeaderError is true of the errors of validating headers that show
+// the headers break the rules of consensus. The headers of a block from the
+// future may be valid once the local clock catches up, so those errors are
+// not, nor are errors once ctx is done.
+func isBadHeaderError(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && errors.Cause(err) != consensus.ErrBlockFromFuture
+}
+
+// isBadStateTransitionError is true of the errors of running a state
+// transition that show the tipset breaks the rules of consensus, see
+// stateTransitionFailures.
+func isBadStateTransitionError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	cause := errors.Cause(err)
+	for _, failure := range stateTransitionFailures {
+		if cause == failure {
+			return true
+		}
+	}
+	return false
+}
+
+// badChainError marks an error rejecting a chain that breaks the rules of
+// consensus. Its cause is the error it marks.
+type badChainError struct {
+	err error
+}
+
+func (e badChainError) Error() string {
+	return e.err.Error()
+}
+
+func (e badChainError) Cause() error {
+	return e.err
+}
+
 type syncFetcher interface {
 	GetBlocks(context.Context, []cid.Cid) ([]*types.Block, error)
 }
 
 // DefaultSyncer updates its chain.Store according to the methods of its
-// consensus.Protocol.  It uses a bad tipset cache and a limit on new
+// consensus.Protocol.  It uses bad tipset and block caches and a limit on new
 // blocks to traverse during chain collection.  The DefaultSyncer can query the
 // network for blocks.  The DefaultSyncer maintains the following invariant on
 // its store: all tipsets that pass the syncer's validity checks are added to the
@@ -68,8 +146,23 @@ type DefaultSyncer struct {
 	stateStore *hamt.CborIpldStore
 	// badTipSetCache is used to filter out collections of invalid blocks.
 	badTipSets *badTipSetCache
+	// badBlocks is used to filter out chains containing invalid blocks.
+	badBlocks  *badBlockCache
 	consensus  consensus.Protocol
 	chainStore Store
+
+	// checkpoint is the trusted tipset the syncer syncs back to, if set.
+	checkpoint *Checkpoint
+	// checkpointTipSet caches the tipset of the checkpoint once fetched.
+	checkpointTipSet types.TipSet
+	// stateFetcher fetches the state at the checkpoint.
+	stateFetcher stateFetcher
+	// finality is the number of rounds below the head beyond which the
+	// syncer does not switch to forks.
+	finality uint64
+	// validationWorkers is the number of goroutines validating the headers
+	// of the fetched chain.
+	validationWorkers int
 }
 
 var _ Syncer = (*DefaultSyncer)(nil)
@@ -82,11 +175,33 @@ func NewDefaultSyncer(cst *hamt.CborIpldStore, c consensus.Protocol, s Store, f
 		badTipSets: &badTipSetCache{
 			bad: make(map[string]struct{}),
 		},
+		badBlocks:  newBadBlockCache(),
 		consensus:  c,
 		chainStore: s,
+		finality:   consensus.FinalityRounds,
+
+		validationWorkers: runtime.NumCPU(),
 	}
 }
 
+// SetFinality sets the number of rounds below the head beyond which the
+// syncer does not switch to forks.
+func (syncer *DefaultSyncer) SetFinality(rounds uint64) {
+	syncer.mu.Lock()
+	defer syncer.mu.Unlock()
+
+	syncer.finality = rounds
+}
+
+// SetValidationWorkers sets the number of goroutines validating the headers
+// of the fetched chain.
+func (syncer *DefaultSyncer) SetValidationWorkers(workers int) {
+	syncer.mu.Lock()
+	defer syncer.mu.Unlock()
+
+	syncer.validationWorkers = workers
+}
+
 // getBlksMaybeFromNet resolves cids of blocks.  It gets blocks through the
 // fetcher.  The fetcher wraps a bitswap session which wraps a bitswap exchange,
 // and the bitswap exchange wraps the node's shared blockstore.  So if blocks
@@ -107,19 +222,54 @@ func (syncer *DefaultSyncer) getBlksMaybeFromNet(ctx context.Context, blkCids []
 // parent tipset already synced into the store.  collectChain resolves cids
 // from the syncer's fetcher.  In production the fetcher wraps a bitswap
 // session.  collectChain errors if any set of cids in the chain resolves to
-// blocks that do not form a tipset, or if any tipset has already been recorded
-// as the head of an invalid chain.  collectChain is the entrypoint to the code
-// that interacts with the network. It does NOT add tipsets to the chainStore..
+// blocks that do not form a tipset or that have invalid headers, or if any
+// tipset has already been recorded as the head of an invalid chain or any
+// block as invalid.  The headers are validated by a pool of workers while the
+// rest of the chain is fetched.  collectChain adds the blocks it finds
+// invalid, and the blocks building on them, to the bad block cache.
+// collectChain is the entrypoint to the code that interacts with the network.
+// It does NOT add tipsets to the chainStore..
 func (syncer *DefaultSyncer) collectChain(ctx context.Context, tipsetCids types.SortedCidSet) (ts []types.TipSet, err error) {
 	ctx, span := trace.StartSpan(ctx, "DefaultSyncer.collectChain")
 	span.AddAttributes(trace.StringAttribute("tipset", tipsetCids.String()))
 	defer tracing.AddErrorEndSpan(ctx, span, &err)
 
-	var chain []types.TipSet
-	var count uint64
 	fetchedHead := tipsetCids
 	defer logSyncer.Infof("chain fetch from network complete %v", fetchedHead)
 
+	validator := newHeaderValidator(ctx, syncer.consensus, syncer.validationWorkers)
+	chain, err := syncer.fetchChain(ctx, tipsetCids, validator)
+	// Wait even if fetching failed so no worker outlives the call.
+	i, invalidErr := validator.Wait(chain)
+	if err != nil {
+		return nil, err
+	}
+	if invalidErr != nil {
+		if !isBadHeaderError(ctx, invalidErr) {
+			return nil, invalidErr
+		}
+		syncer.badTipSets.AddChain(chain[i:])
+		syncer.addInvalidBlocks(ctx, chain[i].ToSlice())
+		syncer.badBlocks.AddChain(chain[i+1:])
+		return nil, badChainError{invalidErr}
+	}
+	return chain, nil
+}
+
+// fetchChain walks the chain back from the tipset with the given cids for
+// collectChain, adding the tipsets it resolves to the header validator.
+//
+// As it goes, fetchChain checks that the parent weight each tipset claims is
+// a weight its parents can have given their headers, back to the tipset in
+// the store the chain joins. A peer can thus not make the syncer run the
+// state transitions of a chain, by far the costliest part of syncing it, by
+// announcing a head claiming more weight than the chain behind it has, and
+// the weight of a head is only worth as much as the length of the chain
+// behind it.
+func (syncer *DefaultSyncer) fetchChain(ctx context.Context, tipsetCids types.SortedCidSet, validator *headerValidator) ([]types.TipSet, error) {
+	var chain []types.TipSet
+	var count uint64
+
 	for {
 		var blks []*types.Block
 		// check the cache for bad tipsets before doing anything
@@ -127,6 +277,21 @@ func (syncer *DefaultSyncer) collectChain(ctx context.Context, tipsetCids types.
 
 		// Finish traversal if the tipset made is tracked in the store.
 		if syncer.chainStore.HasTipSetAndState(ctx, tsKey) {
+			if err := syncer.checkStoredParentWeight(tipsetCids, chain); err != nil {
+				return nil, err
+			}
+			return chain, nil
+		}
+
+		// Add the checkpoint to the store the first time it is reached
+		// instead of traversing the chain before it.
+		if syncer.checkpoint != nil && tipsetCids.Equals(syncer.checkpoint.TipSet) {
+			if err := syncer.syncCheckpoint(ctx); err != nil {
+				return nil, err
+			}
+			if err := syncer.checkStoredParentWeight(tipsetCids, chain); err != nil {
+				return nil, err
+			}
 			return chain, nil
 		}
 
@@ -135,18 +300,34 @@ func (syncer *DefaultSyncer) collectChain(ctx context.Context, tipsetCids types.
 		if syncer.badTipSets.Has(tsKey) {
 			return nil, ErrChainHasBadTipSet
 		}
+		if syncer.badBlocks.HasAny(tipsetCids) {
+			syncer.badBlocks.AddChain(chain)
+			return nil, badChainError{ErrChainHasBadBlock}
+		}
 
 		blks, err := syncer.getBlksMaybeFromNet(ctx, tipsetCids.ToSlice())
 		if err != nil {
 			return nil, err
 		}
 
-		ts, err := syncer.consensus.NewValidTipSet(ctx, blks)
+		// Only check that the blocks form a tipset, to find its parents,
+		// and leave the rest of the header checks to the validator.
+		ts, err := types.NewTipSet(blks...)
 		if err != nil {
 			syncer.badTipSets.Add(tsKey)
 			syncer.badTipSets.AddChain(chain)
-			return nil, err
+			syncer.addInvalidBlocks(ctx, blks)
+			syncer.badBlocks.AddChain(chain)
+			return nil, badChainError{err}
 		}
+		if len(chain) > 0 {
+			if err := syncer.checkParentWeight(ts, chain[0]); err != nil {
+				syncer.badTipSets.AddChain(chain)
+				syncer.badBlocks.AddChain(chain)
+				return nil, err
+			}
+		}
+		validator.Add(ts)
 
 		count++
 		if count%500 == 0 {
@@ -162,6 +343,54 @@ func (syncer *DefaultSyncer) collectChain(ctx context.Context, tipsetCids types.
 	}
 }
 
+// checkParentWeight checks that the parent weight child claims is a weight
+// parent can have given its headers. The errors it returns satisfy
+// IsBadChainError.
+func (syncer *DefaultSyncer) checkParentWeight(parent, child types.TipSet) error {
+	min, max, err := syncer.consensus.WeightRange(parent)
+	if err != nil {
+		return badChainError{err}
+	}
+	claimed, err := child.ParentWeight()
+	if err != nil {
+		return badChainError{err}
+	}
+	if claimed < min || claimed > max {
+		return badChainError{errors.Wrapf(ErrBadParentWeight, "tipset %s claims parent weight %d, its parents can weigh %d to %d", child.String(), claimed, min, max)}
+	}
+	return nil
+}
+
+// checkStoredParentWeight checks the parent weight the first tipset of chain
+// claims against its parents in the store, adding chain to the bad caches if
+// the claim is bad.
+func (syncer *DefaultSyncer) checkStoredParentWeight(parentCids types.SortedCidSet, chain []types.TipSet) error {
+	if len(chain) == 0 {
+		return nil
+	}
+	parent, err := syncer.chainStore.GetTipSetAndState(parentCids)
+	if err != nil {
+		return err
+	}
+	if err := syncer.checkParentWeight(parent.TipSet, chain[0]); err != nil {
+		syncer.badTipSets.AddChain(chain)
+		syncer.badBlocks.AddChain(chain)
+		return err
+	}
+	return nil
+}
+
+// addInvalidBlocks adds those of blks that are invalid on their own, rather
+// than for not forming a tipset with the others, to the bad block cache.
+// Blocks from the future are left out, they may be valid later.
+func (syncer *DefaultSyncer) addInvalidBlocks(ctx context.Context, blks []*types.Block) {
+	for _, blk := range blks {
+		if _, err := syncer.consensus.NewValidTipSet(ctx, []*types.Block{blk}); err != nil && isBadHeaderError(ctx, err) {
+			syncer.badBlocks.Add(blk.Cid())
+		}
+	}
+}
+
 // tipSetState returns the state resulting from applying the input tipset to
 // the chain.  Precondition: the tipset must be in the store
 func (syncer *DefaultSyncer) tipSetState(ctx context.Context, tsKey types.SortedCidSet) (state.Tree, error) {
@@ -218,6 +447,9 @@ func (syncer *DefaultSyncer) syncOne(ctx context.Context, parent, next types.Tip
 	// a new state to add to the store.
 	st, err = syncer.consensus.RunStateTransition(ctx, next, ancestors, st)
 	if err != nil {
+		if isBadStateTransitionError(ctx, err) {
+			return badChainError{err}
+		}
 		return err
 	}
 	root, err := st.Flush(ctx)
@@ -234,7 +466,15 @@ func (syncer *DefaultSyncer) syncOne(ctx context.Context, parent, next types.Tip
 	logSyncer.Debugf("Successfully updated store with %s", next.String())
 
 	// TipSet is validated and added to store, now check if it is the heaviest.
-	// If it is the heaviest update the chainStore.
+	return syncer.setHeadIfHeavier(ctx, parent, next)
+}
+
+// setHeadIfHeavier sets the head of the store to next if next is heavier
+// than the head. next and its parent must be in the store.
+//
+// Precondition: the caller must hold the syncer's lock.
+func (syncer *DefaultSyncer) setHeadIfHeavier(ctx context.Context, parent, next types.TipSet) error {
+	head := syncer.chainStore.GetHead()
 	nextParentSt, err := syncer.tipSetState(ctx, parent.ToSortedCidSet()) // call again to get a copy
 	if err != nil {
 		return err
@@ -270,6 +510,14 @@ func (syncer *DefaultSyncer) syncOne(ctx context.Context, parent, next types.Tip
 		}
 		newChain = append(newChain, next)
 		if IsReorg(headTipSetAndState.TipSet, newChain) {
+			depth, err := ReorgDepth(ctx, syncer.chainStore, headTipSetAndState.TipSet, newChain)
+			if err != nil {
+				return err
+			}
+			if depth > syncer.finality {
+				logSyncer.Warningf("not switching from %s to %s, reorg of %d rounds exceeds finality", headTipSetAndState.TipSet.String(), next.String(), depth)
+				return ErrNewChainTooLong
+			}
 			logSyncer.Infof("reorg occurring while switching from %s to %s", headTipSetAndState.TipSet.String(), next.String())
 		}
 		if err = syncer.chainStore.SetHead(ctx, next); err != nil {
@@ -333,7 +581,8 @@ func (syncer *DefaultSyncer) widen(ctx context.Context, ts types.TipSet) (types.
 // HandleNewTipset extends the Syncer's chain store with the given tipset if they
 // represent a valid extension. It limits the length of new chains it will
 // attempt to validate and caches invalid blocks it has encountered to
-// help prevent DOS.
+// help prevent DOS. The errors it returns for chains that break the rules of
+// consensus satisfy IsBadChainError.
 func (syncer *DefaultSyncer) HandleNewTipset(ctx context.Context, tipsetCids types.SortedCidSet) (err error) {
 	logSyncer.Debugf("Begin fetch and sync of chain with head %v", tipsetCids)
 	ctx, span := trace.StartSpan(ctx, "DefaultSyncer.HandleNewTipset")
@@ -358,6 +607,10 @@ func (syncer *DefaultSyncer) HandleNewTipset(ctx context.Context, tipsetCids typ
 	if err != nil {
 		return err
 	}
+	if len(chain) == 0 {
+		// the chain ends in the checkpoint
+		return nil
+	}
 	parentCids, err := chain[0].Parents()
 	if err != nil {
 		return err
@@ -368,6 +621,37 @@ func (syncer *DefaultSyncer) HandleNewTipset(ctx context.Context, tipsetCids typ
 	}
 	parent := parentTsas.TipSet
 
+	// Do not process forks that would reorg the chain beyond finality. The
+	// chain forks from the chain of the head at the parent or below it.
+	headTsas, err := syncer.chainStore.GetTipSetAndState(syncer.chainStore.GetHead())
+	if err != nil {
+		return err
+	}
+	headHeight, err := headTsas.TipSet.Height()
+	if err != nil {
+		return err
+	}
+	parentHeight, err := parent.Height()
+	if err != nil {
+		return err
+	}
+	if headHeight > parentHeight+syncer.finality {
+		syncer.badTipSets.AddChain(chain)
+		return ErrNewChainTooLong
+	}
+
+	// Chains must include the checkpoint, so they cannot join the chain in
+	// the store before it.
+	if syncer.checkpoint != nil {
+		checkpointHeight, err := syncer.checkpointHeight(ctx)
+		if err != nil {
+			return err
+		}
+		if parentHeight < checkpointHeight {
+			return ErrChainBeforeCheckpoint
+		}
+	}
+
 	// Try adding the tipsets of the chain to the store, checking for new
 	// heaviest tipsets.
 	for i, ts := range chain {
@@ -387,12 +671,19 @@ func (syncer *DefaultSyncer) HandleNewTipset(ctx context.Context, tipsetCids typ
 			}
 		}
 		if err = syncer.syncOne(ctx, parent, ts); err != nil {
-			// While `syncOne` can indeed fail for reasons other than consensus,
-			// adding to the badTipSets at this point is the simplest, since we
-			// have access to the chain. If syncOne fails for non-consensus reasons,
-			// there is no assumption that the running node's data is valid at all,
-			// so we don't really lose anything with this simplification.
-			syncer.badTipSets.AddChain(chain[i:])
+			// Only cache the chain as bad if ts breaks the rules of
+			// consensus. syncOne also fails for reasons that say nothing
+			// about the chain, a cancelled ctx or a failing store, and
+			// the chain may sync fine when it is handled again.
+			if IsBadChainError(err) {
+				syncer.badTipSets.AddChain(chain[i:])
+				// The block of a single block tipset is invalid on its
+				// own, and the tipsets after ts build on an invalid one.
+				if len(ts) == 1 {
+					syncer.badBlocks.AddChain(chain[i : i+1])
+				}
+				syncer.badBlocks.AddChain(chain[i+1:])
+			}
 			return err
 		}
 		if i%500 == 0 {
@@ -402,3 +693,17 @@ func (syncer *DefaultSyncer) HandleNewTipset(ctx context.Context, tipsetCids typ
 	}
 	return nil
 }
+
+// SetHead forces the head of the store to the tipset with the given cids,
+// which must be in the store, regardless of its weight and of finality.
+func (syncer *DefaultSyncer) SetHead(ctx context.Context, tipsetCids types.SortedCidSet) error {
+	syncer.mu.Lock()
+	defer syncer.mu.Unlock()
+
+	tsas, err := syncer.chainStore.GetTipSetAndState(tipsetCids)
+	if err != nil {
+		return errors.Wrapf(err, "tipset %s is not in the store", tipsetCids.String())
+	}
+	logSyncer.Warningf("forcing head from %s to %s", syncer.chainStore.GetHead().String(), tipsetCids.String())
+	return syncer.chainStore.SetHead(ctx, tsas.TipSet)
+}
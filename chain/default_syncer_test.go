@@ -3,11 +3,13 @@ package chain_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-hamt-ipld"
 	bstore "github.com/ipfs/go-ipfs-blockstore"
 	"github.com/libp2p/go-libp2p-peer"
+	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/address"
@@ -49,6 +51,11 @@ var (
 	minerAddress      address.Address
 	minerOwnerAddress address.Address
 	minerPeerID       peer.ID
+
+	// chainSigner mines the test chain. Tickets depend only on the parents
+	// and the key, so blocks meant to join the test chain's tipsets must be
+	// mined with it too.
+	chainSigner types.MockSigner
 )
 
 func init() {
@@ -65,6 +72,7 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+	chainSigner, _ = types.NewMockSignersAndKeyInfo(1)
 
 	// Set up the test chain
 	bs := bstore.NewBlockstore(repo.NewInMemoryRepo().Datastore())
@@ -86,12 +94,10 @@ func init() {
 // test chain's basic structure is always the same, but some tests want
 // mocked stateRoots or parent weight calculations from different consensus protocols.
 func requireSetTestChain(t *testing.T, con consensus.Protocol, mockStateRoots bool) {
-
-	var err error
 	// see powerTableForWidenTest
 	minerPower := uint64(25)
 	totalPower := uint64(100)
-	mockSigner, _ := types.NewMockSignersAndKeyInfo(1)
+	mockSigner := chainSigner
 	mockSignerPubKey := mockSigner.PubKeys[0]
 
 	fakeChildParams := th.FakeChildParams{
@@ -99,18 +105,16 @@ func requireSetTestChain(t *testing.T, con consensus.Protocol, mockStateRoots bo
 		GenesisCid:  genCid,
 		StateRoot:   genStateRoot,
 		Consensus:   con,
-		MinerAddr:   minerAddress,
+		MinerAddr:   mockSigner.Addresses[0],
 		MinerPubKey: mockSignerPubKey,
 		Signer:      mockSigner,
 	}
 
 	link1blk1 = th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	link1blk1.Proof, link1blk1.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, link1blk1, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, mockSigner)
 
 	link1blk2 = th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	link1blk2.Proof, link1blk2.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, link1blk2, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, mockSigner)
 
 	link1 = th.RequireNewTipSet(t, link1blk1, link1blk2)
 
@@ -123,17 +127,14 @@ func requireSetTestChain(t *testing.T, con consensus.Protocol, mockStateRoots bo
 	fakeChildParams.Parent = link1
 	fakeChildParams.StateRoot = link1State
 	link2blk1 = th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	link2blk1.Proof, link2blk1.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, link2blk1, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, mockSigner)
 
 	link2blk2 = th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	link2blk2.Proof, link2blk2.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, link2blk2, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, mockSigner)
 
 	fakeChildParams.Nonce = uint64(1)
 	link2blk3 = th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	link2blk3.Proof, link2blk3.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, link2blk3, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, mockSigner)
 
 	link2 = th.RequireNewTipSet(t, link2blk1, link2blk2, link2blk3)
 
@@ -146,8 +147,7 @@ func requireSetTestChain(t *testing.T, con consensus.Protocol, mockStateRoots bo
 	fakeChildParams.Parent = link2
 	fakeChildParams.StateRoot = link2State
 	link3blk1 = th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	link3blk1.Proof, link3blk1.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, link3blk1, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, mockSigner)
 
 	link3 = th.RequireNewTipSet(t, link3blk1)
 
@@ -161,13 +161,11 @@ func requireSetTestChain(t *testing.T, con consensus.Protocol, mockStateRoots bo
 	fakeChildParams.StateRoot = link3State
 	fakeChildParams.NullBlockCount = uint64(2)
 	link4blk1 = th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	link4blk1.Proof, link4blk1.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, link4blk1, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, mockSigner)
 
 	fakeChildParams.Nonce = uint64(1)
 	link4blk2 = th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	link4blk2.Proof, link4blk2.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, link4blk2, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, mockSigner)
 
 	link4 = th.RequireNewTipSet(t, link4blk1, link4blk2)
 
@@ -460,7 +458,7 @@ func TestSyncIgnoreLightFork(t *testing.T) {
 
 	forkblk1 := th.RequireMkFakeChild(t,
 		th.FakeChildParams{
-			MinerAddr:   minerAddress,
+			MinerAddr:   signer.Addresses[0],
 			Signer:      signer,
 			MinerPubKey: signerPubKey,
 			Parent:      forkbase,
@@ -503,7 +501,7 @@ func TestHeavierFork(t *testing.T) {
 		Parent:      forkbase,
 		GenesisCid:  genCid,
 		StateRoot:   genStateRoot,
-		MinerAddr:   minerAddress,
+		MinerAddr:   signer.Addresses[0],
 		Signer:      signer,
 		MinerPubKey: mockSignerPubKey,
 		Nonce:       uint64(1),
@@ -560,6 +558,55 @@ func TestHeavierFork(t *testing.T) {
 	assertHead(t, chainStore, forklink3)
 }
 
+// Syncer does not switch to a heavier fork deeper than finality, unless the
+// head is set by hand.
+func TestForkBeyondFinality(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	syncer, chainStore, _, blockSource := initSyncTestDefault(t)
+	ctx := context.Background()
+	syncer.SetFinality(1)
+
+	signer, ki := types.NewMockSignersAndKeyInfo(2)
+	fakeChildParams := th.FakeChildParams{
+		Parent:      link1,
+		GenesisCid:  genCid,
+		StateRoot:   genStateRoot,
+		MinerAddr:   signer.Addresses[0],
+		Signer:      signer,
+		MinerPubKey: ki[0].PublicKey(),
+		Nonce:       uint64(5),
+	}
+	forklink2 := th.RequireNewTipSet(t, th.RequireMkFakeChild(t, fakeChildParams))
+	fakeChildParams.Parent = forklink2
+	forklink3 := th.RequireNewTipSet(t, th.RequireMkFakeChild(t, fakeChildParams))
+	fakeChildParams.Parent = forklink3
+	forklink4 := th.RequireNewTipSet(t, th.RequireMkFakeChild(t, fakeChildParams))
+	fakeChildParams.Parent = forklink4
+	forklink5 := th.RequireNewTipSet(t, th.RequireMkFakeChild(t, fakeChildParams))
+
+	_ = requirePutBlocks(t, blockSource, link1.ToSlice()...)
+	_ = requirePutBlocks(t, blockSource, link2.ToSlice()...)
+	cids3 := requirePutBlocks(t, blockSource, link3.ToSlice()...)
+	_ = requirePutBlocks(t, blockSource, forklink2.ToSlice()...)
+	_ = requirePutBlocks(t, blockSource, forklink3.ToSlice()...)
+	_ = requirePutBlocks(t, blockSource, forklink4.ToSlice()...)
+	forkHead := requirePutBlocks(t, blockSource, forklink5.ToSlice()...)
+
+	require.NoError(t, syncer.HandleNewTipset(ctx, cids3))
+	assertHead(t, chainStore, link3)
+
+	// the fork splits off at link1, two rounds below the head
+	err := syncer.HandleNewTipset(ctx, forkHead)
+	assert.Equal(t, chain.ErrNewChainTooLong, err)
+	assertHead(t, chainStore, link3)
+
+	// a fork the node has validated can be chosen by hand
+	require.NoError(t, syncer.SetHead(ctx, link1.ToSortedCidSet()))
+	assertHead(t, chainStore, link1)
+	assert.Error(t, syncer.SetHead(ctx, forkHead))
+}
+
 // Syncer errors if blocks don't form a tipset
 func TestBlocksNotATipSet(t *testing.T) {
 	tf.BadUnitTestWithSideEffects(t)
@@ -572,7 +619,186 @@ func TestBlocksNotATipSet(t *testing.T) {
 	badCids := types.NewSortedCidSet(link1blk1.Cid(), link2blk1.Cid())
 	err := syncer.HandleNewTipset(ctx, badCids)
 	assert.Error(t, err)
+	assert.True(t, chain.IsBadChainError(err))
 	assertNoAdd(t, chainStore, badCids)
+
+	// The blocks are valid on their own.
+	require.NoError(t, syncer.HandleNewTipset(ctx, link2.ToSortedCidSet()))
+	assertTsAdded(t, chainStore, link2)
+}
+
+// Syncer caches the blocks it finds invalid and rejects chains containing them
+// without validating them again.
+func TestBadBlocksCached(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	syncer, chainStore, _, blockSource := initSyncTestDefault(t)
+	ctx := context.Background()
+
+	fakeChildParams := th.FakeChildParams{
+		Parent:      link1,
+		GenesisCid:  genCid,
+		StateRoot:   genStateRoot,
+		MinerAddr:   chainSigner.Addresses[0],
+		MinerPubKey: chainSigner.PubKeys[0],
+		Signer:      chainSigner,
+	}
+	unsigned := th.RequireMkFakeChild(t, fakeChildParams)
+	unsigned.BlockSig = nil
+	fakeChildParams.Nonce = uint64(1)
+	sibling := th.RequireMkFakeChild(t, fakeChildParams)
+	fakeChildParams.Parent = th.RequireNewTipSet(t, unsigned)
+	child := th.RequireMkFakeChild(t, fakeChildParams)
+
+	_ = requirePutBlocks(t, blockSource, link1.ToSlice()...)
+	_ = requirePutBlocks(t, blockSource, unsigned, sibling)
+	childCids := requirePutBlocks(t, blockSource, child)
+
+	err := syncer.HandleNewTipset(ctx, childCids)
+	assert.Equal(t, consensus.ErrUnsignedBlock, errors.Cause(err))
+	assert.True(t, chain.IsBadChainError(err))
+	assertNoAdd(t, chainStore, childCids)
+
+	// Another tipset with the unsigned block is rejected without validation.
+	err = syncer.HandleNewTipset(ctx, types.NewSortedCidSet(unsigned.Cid(), sibling.Cid()))
+	assert.Equal(t, chain.ErrChainHasBadBlock, errors.Cause(err))
+	assert.True(t, chain.IsBadChainError(err))
+
+	// The valid sibling is not cached.
+	siblingTs := th.RequireNewTipSet(t, sibling)
+	require.NoError(t, syncer.HandleNewTipset(ctx, siblingTs.ToSortedCidSet()))
+	assertTsAdded(t, chainStore, siblingTs)
+}
+
+// Syncer does not cache blocks from the future as bad, they may be valid once
+// the local clock catches up.
+func TestFutureBlocksNotCached(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	syncer, chainStore, _, blockSource := initSyncTestDefault(t)
+	ctx := context.Background()
+
+	fakeChildParams := th.FakeChildParams{
+		Parent:      link1,
+		GenesisCid:  genCid,
+		StateRoot:   genStateRoot,
+		MinerAddr:   chainSigner.Addresses[0],
+		MinerPubKey: chainSigner.PubKeys[0],
+		Signer:      chainSigner,
+	}
+	future := th.RequireMkFakeChild(t, fakeChildParams)
+	future.Timestamp = types.Uint64(time.Now().Add(time.Hour).Unix())
+	require.NoError(t, consensus.SignBlock(future, chainSigner.PubKeys[0], chainSigner))
+
+	_ = requirePutBlocks(t, blockSource, link1.ToSlice()...)
+	futureCids := requirePutBlocks(t, blockSource, future)
+
+	err := syncer.HandleNewTipset(ctx, futureCids)
+	assert.Equal(t, consensus.ErrBlockFromFuture, errors.Cause(err))
+	assert.False(t, chain.IsBadChainError(err))
+	assertNoAdd(t, chainStore, futureCids)
+
+	// The block is validated again rather than rejected from the caches.
+	err = syncer.HandleNewTipset(ctx, futureCids)
+	assert.Equal(t, consensus.ErrBlockFromFuture, errors.Cause(err))
+	assert.False(t, chain.IsBadChainError(err))
+}
+
+// Syncer rejects chains claiming more weight than their tipsets can have
+// before running their state transitions.
+func TestSyncBadParentWeight(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	fakeChildParams := th.FakeChildParams{
+		Parent:      link1,
+		GenesisCid:  genCid,
+		StateRoot:   genStateRoot,
+		MinerAddr:   chainSigner.Addresses[0],
+		MinerPubKey: chainSigner.PubKeys[0],
+		Signer:      chainSigner,
+	}
+
+	t.Run("rejects claims about fetched parents", func(t *testing.T) {
+		syncer, chainStore, _, blockSource := initSyncTestDefault(t)
+		ctx := context.Background()
+
+		heavy := th.RequireMkFakeChild(t, fakeChildParams)
+		heavy.ParentWeight += types.Uint64(1000000)
+
+		_ = requirePutBlocks(t, blockSource, link1.ToSlice()...)
+		heavyCids := requirePutBlocks(t, blockSource, heavy)
+
+		err := syncer.HandleNewTipset(ctx, heavyCids)
+		assert.Equal(t, chain.ErrBadParentWeight, errors.Cause(err))
+		assert.True(t, chain.IsBadChainError(err))
+		assertNoAdd(t, chainStore, heavyCids)
+
+		// The chain is rejected again without being fetched.
+		err = syncer.HandleNewTipset(ctx, heavyCids)
+		assert.Equal(t, chain.ErrChainHasBadTipSet, errors.Cause(err))
+	})
+
+	t.Run("rejects claims about parents in the store", func(t *testing.T) {
+		syncer, chainStore, _, blockSource := initSyncTestDefault(t)
+		ctx := context.Background()
+
+		cids1 := requirePutBlocks(t, blockSource, link1.ToSlice()...)
+		require.NoError(t, syncer.HandleNewTipset(ctx, cids1))
+
+		light := th.RequireMkFakeChild(t, fakeChildParams)
+		light.ParentWeight = 0
+		lightCids := requirePutBlocks(t, blockSource, light)
+
+		err := syncer.HandleNewTipset(ctx, lightCids)
+		assert.Equal(t, chain.ErrBadParentWeight, errors.Cause(err))
+		assertNoAdd(t, chainStore, lightCids)
+		assertHead(t, chainStore, link1)
+	})
+}
+
+// Syncer validates the headers of the whole chain before executing any of
+// it, with any number of workers.
+func TestInvalidHeadersMidChain(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	for _, workers := range []int{1, 4} {
+		syncer, chainStore, _, blockSource := initSyncTestDefault(t)
+		syncer.SetValidationWorkers(workers)
+		ctx := context.Background()
+
+		fakeChildParams := th.FakeChildParams{
+			Parent:      link1,
+			GenesisCid:  genCid,
+			StateRoot:   genStateRoot,
+			MinerAddr:   chainSigner.Addresses[0],
+			MinerPubKey: chainSigner.PubKeys[0],
+			Signer:      chainSigner,
+		}
+		valid := th.RequireMkFakeChild(t, fakeChildParams)
+		fakeChildParams.Parent = th.RequireNewTipSet(t, valid)
+		unsigned := th.RequireMkFakeChild(t, fakeChildParams)
+		unsigned.BlockSig = nil
+		fakeChildParams.Parent = th.RequireNewTipSet(t, unsigned)
+		head := th.RequireMkFakeChild(t, fakeChildParams)
+
+		_ = requirePutBlocks(t, blockSource, link1.ToSlice()...)
+		validCids := requirePutBlocks(t, blockSource, valid)
+		_ = requirePutBlocks(t, blockSource, unsigned)
+		headCids := requirePutBlocks(t, blockSource, head)
+
+		err := syncer.HandleNewTipset(ctx, headCids)
+		assert.Equal(t, consensus.ErrUnsignedBlock, errors.Cause(err))
+		assertNoAdd(t, chainStore, validCids)
+		assertNoAdd(t, chainStore, headCids)
+
+		// The head builds on the unsigned block.
+		err = syncer.HandleNewTipset(ctx, headCids)
+		assert.Equal(t, chain.ErrChainHasBadTipSet, errors.Cause(err))
+
+		// The chain below the unsigned block is fine.
+		require.NoError(t, syncer.HandleNewTipset(ctx, validCids))
+		assertTsAdded(t, chainStore, th.RequireNewTipSet(t, valid))
+	}
 }
 
 /* particularly tricky edge cases relating to subtle Expected Consensus requirements */
@@ -599,7 +825,7 @@ func TestLoadFork(t *testing.T) {
 	fakeChildParams := th.FakeChildParams{
 		Parent:      forkbase,
 		GenesisCid:  genCid,
-		MinerAddr:   minerAddress,
+		MinerAddr:   signer.Addresses[0],
 		Nonce:       uint64(1),
 		StateRoot:   genStateRoot,
 		Signer:      signer,
@@ -698,7 +924,7 @@ func TestSubsetParent(t *testing.T) {
 	fakeChildParams := th.FakeChildParams{
 		Parent:      forkbase,
 		GenesisCid:  genCid,
-		MinerAddr:   minerAddress,
+		MinerAddr:   signer.Addresses[0],
 		StateRoot:   genStateRoot,
 		Signer:      signer,
 		MinerPubKey: mockSignerPubKey,
@@ -738,7 +964,7 @@ func TestWidenChainAncestor(t *testing.T) {
 	mockSignerPubKey := ki[0].PublicKey()
 
 	fakeChildParams := th.FakeChildParams{
-		MinerAddr:   minerAddress,
+		MinerAddr:   signer.Addresses[0],
 		Parent:      link1,
 		GenesisCid:  genCid,
 		StateRoot:   genStateRoot,
@@ -789,6 +1015,10 @@ func (pt *powerTableForWidenTest) HasPower(ctx context.Context, st state.Tree, b
 	return true
 }
 
+func (pt *powerTableForWidenTest) WorkerAddr(ctx context.Context, st state.Tree, bs bstore.Blockstore, mAddr address.Address) (address.Address, error) {
+	return mAddr, nil
+}
+
 // Syncer finds a heaviest tipset by combining blocks from the ancestors of a
 // chain and blocks already in the store.
 //
@@ -820,46 +1050,42 @@ func TestHeaviestIsWidenedAncestor(t *testing.T) {
 
 	minerPower := uint64(25)
 	totalPower := uint64(100)
-	signer, ki := types.NewMockSignersAndKeyInfo(2)
-	mockSignerPubKey := ki[0].PublicKey()
+	signer := chainSigner
+	mockSignerPubKey := signer.PubKeys[0]
 
 	fakeChildParams := th.FakeChildParams{
-		Parent:     link1,
-		Consensus:  con,
-		GenesisCid: genCid,
-		StateRoot:  genStateRoot,
-		MinerAddr:  minerAddress,
-		Signer:     signer,
-		Nonce:      uint64(1),
+		Parent:      link1,
+		Consensus:   con,
+		GenesisCid:  genCid,
+		StateRoot:   genStateRoot,
+		MinerAddr:   signer.Addresses[0],
+		Signer:      signer,
+		MinerPubKey: mockSignerPubKey,
+		Nonce:       uint64(1),
 	}
 
 	var err error
 	forklink2blk1 := th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	forklink2blk1.Proof, forklink2blk1.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, signer)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, forklink2blk1, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, signer)
 
 	fakeChildParams.Nonce = uint64(52)
 	forklink2blk2 := th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	forklink2blk2.Proof, forklink2blk2.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, signer)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, forklink2blk2, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, signer)
 
 	fakeChildParams.Nonce = uint64(53)
 	forklink2blk3 := th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	forklink2blk3.Proof, forklink2blk3.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, signer)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, forklink2blk3, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, signer)
 
 	fakeChildParams.Nonce = uint64(54)
 	forklink2blk4 := th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	forklink2blk4.Proof, forklink2blk4.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, signer)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, forklink2blk4, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, signer)
 
 	forklink2 := th.RequireNewTipSet(t, forklink2blk1, forklink2blk2, forklink2blk3, forklink2blk4)
 
 	fakeChildParams.Nonce = uint64(0)
 	fakeChildParams.Parent = forklink2
 	forklink3blk1 := th.RequireMkFakeChildWithCon(t, fakeChildParams)
-	forklink3blk1.Proof, forklink3blk1.Ticket, err = th.MakeProofAndWinningTicket(mockSignerPubKey, minerPower, totalPower, signer)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, forklink3blk1, fakeChildParams.Parent, mockSignerPubKey, minerPower, totalPower, signer)
 
 	forklink3 := th.RequireNewTipSet(t, forklink3blk1)
 
@@ -900,10 +1126,6 @@ func TestTipSetWeightDeep(t *testing.T) {
 
 	ctx := context.Background()
 
-	mockSigner, ki := types.NewMockSignersAndKeyInfo(3)
-	signerPubKey1 := ki[0].PublicKey()
-	signerPubKey2 := ki[1].PublicKey()
-
 	// set up genesis block with power
 	genCfg := &gengen.GenesisCfg{
 		Keys: 4,
@@ -926,6 +1148,15 @@ func TestTipSetWeightDeep(t *testing.T) {
 	info, err := gengen.GenGen(ctx, genCfg, cst, bs, 0)
 	require.NoError(t, err)
 
+	// All miners are owned by the first key, whose account is their worker
+	// and so signs their blocks.
+	var keys []types.KeyInfo
+	for _, k := range info.Keys {
+		keys = append(keys, *k)
+	}
+	mockSigner := types.NewMockSigner(keys)
+	signerPubKey := info.Keys[0].PublicKey()
+
 	var calcGenBlk types.Block
 	require.NoError(t, cst.Get(ctx, info.GenesisCid, &calcGenBlk))
 
@@ -982,23 +1213,22 @@ func TestTipSetWeightDeep(t *testing.T) {
 	}
 
 	fakeChildParams := th.FakeChildParams{
-		Parent:     baseTS,
-		GenesisCid: calcGenBlk.Cid(),
-		StateRoot:  bootstrapStateRoot,
-		Signer:     mockSigner,
+		Parent:      baseTS,
+		GenesisCid:  calcGenBlk.Cid(),
+		StateRoot:   bootstrapStateRoot,
+		Signer:      mockSigner,
+		MinerPubKey: signerPubKey,
 
 		MinerAddr: info.Miners[1].Address,
 	}
 
 	f1b1 := th.RequireMkFakeChildCore(t, fakeChildParams, wFun)
-	f1b1.Proof, f1b1.Ticket, err = th.MakeProofAndWinningTicket(signerPubKey1, info.Miners[1].Power, 1000, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, f1b1, fakeChildParams.Parent, signerPubKey, info.Miners[1].Power, 1000, mockSigner)
 
 	fakeChildParams.Nonce = uint64(1)
 	fakeChildParams.MinerAddr = info.Miners[2].Address
 	f2b1 := th.RequireMkFakeChildCore(t, fakeChildParams, wFun)
-	f2b1.Proof, f2b1.Ticket, err = th.MakeProofAndWinningTicket(signerPubKey1, info.Miners[2].Power, 1000, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, f2b1, fakeChildParams.Parent, signerPubKey, info.Miners[2].Power, 1000, mockSigner)
 
 	tsShared := th.RequireNewTipSet(t, f1b1, f2b1)
 
@@ -1014,23 +1244,22 @@ func TestTipSetWeightDeep(t *testing.T) {
 
 	// fork 1 is heavier than the old head.
 	fakeChildParams = th.FakeChildParams{
-		Parent:     th.RequireNewTipSet(t, f1b1),
-		GenesisCid: calcGenBlk.Cid(),
-		StateRoot:  bootstrapStateRoot,
-		Signer:     mockSigner,
+		Parent:      th.RequireNewTipSet(t, f1b1),
+		GenesisCid:  calcGenBlk.Cid(),
+		StateRoot:   bootstrapStateRoot,
+		Signer:      mockSigner,
+		MinerPubKey: signerPubKey,
 
 		MinerAddr: info.Miners[1].Address,
 	}
 	f1b2a := th.RequireMkFakeChildCore(t, fakeChildParams, wFun)
-	f1b2a.Proof, f1b2a.Ticket, err = th.MakeProofAndWinningTicket(signerPubKey1, info.Miners[1].Power, 1000, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, f1b2a, fakeChildParams.Parent, signerPubKey, info.Miners[1].Power, 1000, mockSigner)
 
 	fakeChildParams.Nonce = uint64(1)
 
 	fakeChildParams.MinerAddr = info.Miners[2].Address
 	f1b2b := th.RequireMkFakeChildCore(t, fakeChildParams, wFun)
-	f1b2b.Proof, f1b2b.Ticket, err = th.MakeProofAndWinningTicket(signerPubKey2, info.Miners[2].Power, 1000, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, f1b2b, fakeChildParams.Parent, signerPubKey, info.Miners[2].Power, 1000, mockSigner)
 
 	f1 := th.RequireNewTipSet(t, f1b2a, f1b2b)
 	f1Cids := requirePutBlocks(t, blockSource, f1.ToSlice()...)
@@ -1045,16 +1274,16 @@ func TestTipSetWeightDeep(t *testing.T) {
 	// fork 2 has heavier weight because of addr3's power even though there
 	// are fewer blocks in the tipset than fork 1.
 	fakeChildParams = th.FakeChildParams{
-		Parent:     th.RequireNewTipSet(t, f2b1),
-		GenesisCid: calcGenBlk.Cid(),
-		Signer:     mockSigner,
+		Parent:      th.RequireNewTipSet(t, f2b1),
+		GenesisCid:  calcGenBlk.Cid(),
+		Signer:      mockSigner,
+		MinerPubKey: signerPubKey,
 
 		StateRoot: bootstrapStateRoot,
 		MinerAddr: info.Miners[3].Address,
 	}
 	f2b2 := th.RequireMkFakeChildCore(t, fakeChildParams, wFun)
-	f2b2.Proof, f2b2.Ticket, err = th.MakeProofAndWinningTicket(signerPubKey2, info.Miners[3].Power, 1000, mockSigner)
-	require.NoError(t, err)
+	th.RequireWinningBlock(t, f2b2, fakeChildParams.Parent, signerPubKey, info.Miners[3].Power, 1000, mockSigner)
 
 	f2 := th.RequireNewTipSet(t, f2b2)
 	f2Cids := requirePutBlocks(t, blockSource, f2.ToSlice()...)
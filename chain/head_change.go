@@ -0,0 +1,71 @@
+package chain
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// HeadChangeTopic is the topic used to publish head changes.
+const HeadChangeTopic = "head-change"
+
+// HeadChange describes how the chain changes when the head moves. Revert
+// lists the tipsets no longer on the chain from the old head down, and Apply
+// the tipsets new on the chain from the lowest up to the new head.
+type HeadChange struct {
+	Revert []types.TipSet
+	Apply  []types.TipSet
+}
+
+// CollectHeadChange walks the chains of the old and the new head back to
+// their common ancestor and returns the change of head between them. If the
+// old head is empty the change applies the new head only.
+func CollectHeadChange(ctx context.Context, store BlockProvider, oldHead, newHead types.TipSet) (*HeadChange, error) {
+	change := &HeadChange{}
+	if len(oldHead) == 0 {
+		change.Apply = []types.TipSet{newHead}
+		return change, nil
+	}
+
+	oldItr := IterAncestors(ctx, store, oldHead)
+	newItr := IterAncestors(ctx, store, newHead)
+	var apply []types.TipSet
+	for {
+		if oldItr.Complete() || newItr.Complete() {
+			return nil, errors.New("heads have no common ancestor")
+		}
+		if oldItr.Value().Equals(newItr.Value()) {
+			break
+		}
+		oldHeight, err := oldItr.Value().Height()
+		if err != nil {
+			return nil, err
+		}
+		newHeight, err := newItr.Value().Height()
+		if err != nil {
+			return nil, err
+		}
+
+		// Step back along the higher chain, or along both if they are at
+		// the same height.
+		if oldHeight >= newHeight {
+			change.Revert = append(change.Revert, oldItr.Value())
+			if err := oldItr.Next(); err != nil {
+				return nil, err
+			}
+		}
+		if newHeight >= oldHeight {
+			apply = append(apply, newItr.Value())
+			if err := newItr.Next(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i := len(apply) - 1; i >= 0; i-- {
+		change.Apply = append(change.Apply, apply[i])
+	}
+	return change, nil
+}
@@ -0,0 +1,55 @@
+package chain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestCollectHeadChange(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	store := th.NewFakeBlockProvider()
+
+	// root -> a1 -> a2 -> a3 and root -> a1 -> b2
+	root := store.NewBlock(0)
+	a1 := store.NewBlock(1, root)
+	a2 := store.NewBlock(2, a1)
+	a3 := store.NewBlock(3, a2)
+	b2 := store.NewBlock(4, a1)
+
+	t.Run("extending the chain applies the new tipsets", func(t *testing.T) {
+		change, err := chain.CollectHeadChange(ctx, store, requireTipset(t, a1), requireTipset(t, a3))
+		require.NoError(t, err)
+		assert.Empty(t, change.Revert)
+		assert.Equal(t, []types.TipSet{requireTipset(t, a2), requireTipset(t, a3)}, change.Apply)
+	})
+
+	t.Run("a reorg reverts the old tipsets and applies the new ones", func(t *testing.T) {
+		change, err := chain.CollectHeadChange(ctx, store, requireTipset(t, a3), requireTipset(t, b2))
+		require.NoError(t, err)
+		assert.Equal(t, []types.TipSet{requireTipset(t, a3), requireTipset(t, a2)}, change.Revert)
+		assert.Equal(t, []types.TipSet{requireTipset(t, b2)}, change.Apply)
+	})
+
+	t.Run("the first head is applied", func(t *testing.T) {
+		change, err := chain.CollectHeadChange(ctx, store, types.TipSet{}, requireTipset(t, a2))
+		require.NoError(t, err)
+		assert.Empty(t, change.Revert)
+		assert.Equal(t, []types.TipSet{requireTipset(t, a2)}, change.Apply)
+	})
+
+	t.Run("heads without common ancestor", func(t *testing.T) {
+		other := store.NewBlock(5)
+		_, err := chain.CollectHeadChange(ctx, store, requireTipset(t, a3), requireTipset(t, other))
+		assert.Error(t, err)
+	})
+}
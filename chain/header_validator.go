@@ -0,0 +1,66 @@
+package chain
+
+import (
+	"context"
+	"sync"
+
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// headerValidator checks the headers of the tipsets collectChain fetches,
+// with consensus.Protocol.NewValidTipSet, on a bounded pool of goroutines so
+// that the checks, signature verification foremost, run on all cores while
+// the syncer fetches the rest of the chain. Adding a tipset blocks while all
+// workers are busy, which keeps fetching from running far ahead of validation.
+type headerValidator struct {
+	ctx       context.Context
+	consensus consensus.Protocol
+	slots     chan struct{}
+	wg        sync.WaitGroup
+
+	mu     sync.Mutex
+	errors map[string]error
+}
+
+func newHeaderValidator(ctx context.Context, c consensus.Protocol, workers int) *headerValidator {
+	if workers < 1 {
+		workers = 1
+	}
+	return &headerValidator{
+		ctx:       ctx,
+		consensus: c,
+		slots:     make(chan struct{}, workers),
+		errors:    make(map[string]error),
+	}
+}
+
+// Add starts validating the headers of ts once a worker is free.
+func (v *headerValidator) Add(ts types.TipSet) {
+	v.slots <- struct{}{}
+	v.wg.Add(1)
+	go func() {
+		defer func() {
+			<-v.slots
+			v.wg.Done()
+		}()
+		if _, err := v.consensus.NewValidTipSet(v.ctx, ts.ToSlice()); err != nil {
+			v.mu.Lock()
+			v.errors[ts.String()] = err
+			v.mu.Unlock()
+		}
+	}()
+}
+
+// Wait waits for the validation of all added tipsets and returns the index in
+// chain of the first tipset with invalid headers and their error. The index is
+// -1 if all headers are valid.
+func (v *headerValidator) Wait(chain []types.TipSet) (int, error) {
+	v.wg.Wait()
+	for i, ts := range chain {
+		if err, ok := v.errors[ts.String()]; ok {
+			return i, err
+		}
+	}
+	return -1, nil
+}
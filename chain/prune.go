@@ -0,0 +1,173 @@
+package chain
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ErrPruneBelowFinality is returned when asked to prune the states of tipsets
+// within the finality window, which the syncer needs to switch to forks.
+var ErrPruneBelowFinality = errors.New("cannot prune the states of tipsets within finality")
+
+// pruneStore is the part of the DefaultStore the Pruner uses.
+type pruneStore interface {
+	GetHead() types.SortedCidSet
+	GetTipSetAndState(tsKey types.SortedCidSet) (*TipSetAndState, error)
+	AllTipSetAndStates() []*TipSetAndState
+}
+
+// PruneResult describes a run of the Pruner.
+type PruneResult struct {
+	// Height is the height below which tipsets have no state left.
+	Height uint64
+	// TipSets is the number of tipsets whose states were pruned.
+	TipSets uint64
+	// Blocks is the number of ipld blocks of state deleted.
+	Blocks uint64
+}
+
+// Pruner discards the states of tipsets far enough below the head, keeping
+// their headers, to bound the disk usage of long running nodes. The state
+// trees of successive tipsets share most of their nodes, so only the nodes
+// no longer reachable from a state that is kept are deleted. Forks are only
+// known to the store until the node restarts, so the states of old forks
+// the store no longer tracks are left in place.
+type Pruner struct {
+	// bs holds the state trees the tipsets refer to.
+	bs       bstore.Blockstore
+	store    pruneStore
+	finality uint64
+
+	// Serializes runs.
+	mu sync.Mutex
+}
+
+// NewPruner returns a new Pruner refusing to prune the states of the tipsets
+// within finality rounds of the head.
+func NewPruner(bs bstore.Blockstore, store pruneStore, finality uint64) *Pruner {
+	return &Pruner{
+		bs:       bs,
+		store:    store,
+		finality: finality,
+	}
+}
+
+// Prune deletes the states of the tipsets more than keep rounds below the
+// head. keep must be at least the finality of the Pruner.
+func (p *Pruner) Prune(ctx context.Context, keep uint64) (*PruneResult, error) {
+	if keep < p.finality {
+		return nil, errors.Wrapf(ErrPruneBelowFinality, "keeping %d rounds of states, finality is %d rounds", keep, p.finality)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	headTsas, err := p.store.GetTipSetAndState(p.store.GetHead())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get head")
+	}
+	headHeight, err := headTsas.TipSet.Height()
+	if err != nil {
+		return nil, err
+	}
+	if headHeight <= keep {
+		return &PruneResult{}, nil
+	}
+	result := &PruneResult{Height: headHeight - keep}
+
+	var kept, pruned []cid.Cid
+	for _, tsas := range p.store.AllTipSetAndStates() {
+		h, err := tsas.TipSet.Height()
+		if err != nil {
+			return nil, err
+		}
+		roots := tipSetStateRoots(tsas)
+		if h >= result.Height {
+			kept = append(kept, roots...)
+		} else {
+			pruned = append(pruned, roots...)
+			result.TipSets++
+		}
+	}
+
+	// Whatever is reachable from a kept node is kept, so the walk of the
+	// pruned states stops at kept nodes.
+	keptNodes := make(map[cid.Cid]struct{})
+	for _, root := range kept {
+		if err := p.walkState(ctx, root, keptNodes, nil); err != nil {
+			return nil, err
+		}
+	}
+	remove := make(map[cid.Cid]struct{})
+	for _, root := range pruned {
+		if err := p.walkState(ctx, root, remove, keptNodes); err != nil {
+			return nil, err
+		}
+	}
+
+	for c := range remove {
+		if err := p.bs.DeleteBlock(c); err != nil {
+			return nil, errors.Wrapf(err, "failed to delete state block %s", c)
+		}
+		result.Blocks++
+	}
+	logStore.Infof("pruned the states of %d tipsets below height %d, deleting %d blocks", result.TipSets, result.Height, result.Blocks)
+	return result, nil
+}
+
+// tipSetStateRoots returns the state root of the tipset and those its blocks
+// refer to, which differ for tipsets of several blocks.
+func tipSetStateRoots(tsas *TipSetAndState) []cid.Cid {
+	roots := []cid.Cid{tsas.TipSetStateRoot}
+	for _, blk := range tsas.TipSet.ToSlice() {
+		roots = append(roots, blk.StateRoot)
+	}
+	return roots
+}
+
+// walkState adds the cids of the nodes of the state dag rooted at c to seen,
+// leaving out the parts in skip. Nodes missing from the blockstore, such as
+// those pruned before and the code cids of builtin actors, are left out.
+func (p *Pruner) walkState(ctx context.Context, c cid.Cid, seen, skip map[cid.Cid]struct{}) error {
+	if !c.Defined() {
+		return nil
+	}
+	if _, ok := seen[c]; ok {
+		return nil
+	}
+	if _, ok := skip[c]; ok {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	blk, err := p.bs.Get(c)
+	if err == bstore.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to get state block %s", c)
+	}
+	seen[c] = struct{}{}
+	if c.Type() != cid.DagCBOR {
+		return nil
+	}
+
+	node, err := cbor.DecodeBlock(blk)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode %s", c)
+	}
+	for _, link := range node.Links() {
+		if err := p.walkState(ctx, link.Cid, seen, skip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package chain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-hamt-ipld"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/repo"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func requirePutStateNode(t *testing.T, bs bstore.Blockstore, obj interface{}) cid.Cid {
+	nd, err := cbor.WrapObject(obj, types.DefaultHashFunction, -1)
+	require.NoError(t, err)
+	require.NoError(t, bs.Put(nd))
+	return nd.Cid()
+}
+
+func TestPrune(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	r := repo.NewInMemoryRepo()
+	bs := bstore.NewBlockstore(r.Datastore())
+
+	// Every state links to a node shared by all of them and to a node of its
+	// own.
+	shared := requirePutStateNode(t, bs, map[string]interface{}{"shared": true})
+	var states, own []cid.Cid
+	var chn []types.TipSet
+	var parent *types.Block
+	for h := 0; h < 10; h++ {
+		ownNode := requirePutStateNode(t, bs, map[string]interface{}{"height": h})
+		root := requirePutStateNode(t, bs, map[string]interface{}{"shared": shared, "own": ownNode})
+		blk := types.NewBlockForTest(parent, 0)
+		blk.StateRoot = root
+		chn = append(chn, th.RequireNewTipSet(t, blk))
+		states = append(states, root)
+		own = append(own, ownNode)
+		parent = blk
+	}
+
+	store := chain.NewDefaultStore(r.ChainDatastore(), hamt.NewCborStore(), chn[0].ToSlice()[0].Cid())
+	for i, ts := range chn {
+		th.RequirePutTsas(ctx, t, store, &chain.TipSetAndState{TipSet: ts, TipSetStateRoot: states[i]})
+	}
+	require.NoError(t, store.SetHead(ctx, chn[9]))
+
+	pruner := chain.NewPruner(bs, store, 3)
+
+	t.Run("refuses to prune within finality", func(t *testing.T) {
+		_, err := pruner.Prune(ctx, 2)
+		assert.Equal(t, chain.ErrPruneBelowFinality, errors.Cause(err))
+	})
+
+	t.Run("prunes old states only", func(t *testing.T) {
+		res, err := pruner.Prune(ctx, 4)
+		require.NoError(t, err)
+		assert.Equal(t, &chain.PruneResult{Height: 5, TipSets: 5, Blocks: 10}, res)
+
+		for h := range chn {
+			hasRoot, err := bs.Has(states[h])
+			require.NoError(t, err)
+			hasOwn, err := bs.Has(own[h])
+			require.NoError(t, err)
+			assert.Equal(t, h >= 5, hasRoot)
+			assert.Equal(t, h >= 5, hasOwn)
+		}
+		hasShared, err := bs.Has(shared)
+		require.NoError(t, err)
+		assert.True(t, hasShared)
+
+		// The headers stay.
+		for _, ts := range chn {
+			assert.True(t, store.HasTipSetAndState(ctx, ts.String()))
+		}
+	})
+
+	t.Run("prunes again as the head moves", func(t *testing.T) {
+		res, err := pruner.Prune(ctx, 4)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), res.Blocks)
+
+		res, err = pruner.Prune(ctx, 3)
+		require.NoError(t, err)
+		assert.Equal(t, &chain.PruneResult{Height: 6, TipSets: 6, Blocks: 2}, res)
+	})
+
+	t.Run("does nothing on short chains", func(t *testing.T) {
+		res, err := pruner.Prune(ctx, 100)
+		require.NoError(t, err)
+		assert.Equal(t, &chain.PruneResult{}, res)
+	})
+}
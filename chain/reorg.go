@@ -1,6 +1,10 @@
 package chain
 
 import (
+	"context"
+
+	"github.com/pkg/errors"
+
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -22,3 +26,33 @@ func IsReorg(curHead types.TipSet, newChain []types.TipSet) bool {
 	}
 	return true
 }
+
+// ReorgDepth returns the number of rounds between curHead and the most recent
+// ancestor it has in common with newChain, which must include every tipset
+// from the new head back to genesis. It is the depth of the reorg choosing
+// the new head causes.
+func ReorgDepth(ctx context.Context, store BlockProvider, curHead types.TipSet, newChain []types.TipSet) (uint64, error) {
+	onNewChain := make(map[string]struct{})
+	for _, ts := range newChain {
+		onNewChain[ts.String()] = struct{}{}
+	}
+	headHeight, err := curHead.Height()
+	if err != nil {
+		return 0, err
+	}
+
+	for iterator := IterAncestors(ctx, store, curHead); !iterator.Complete(); err = iterator.Next() {
+		if err != nil {
+			return 0, err
+		}
+		if _, ok := onNewChain[iterator.Value().String()]; !ok {
+			continue
+		}
+		h, err := iterator.Value().Height()
+		if err != nil {
+			return 0, err
+		}
+		return headHeight - h, nil
+	}
+	return 0, errors.New("chains have no common ancestor")
+}
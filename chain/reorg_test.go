@@ -1,9 +1,11 @@
 package chain_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/chain"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
@@ -69,3 +71,31 @@ func TestIsReorg(t *testing.T) {
 		assert.False(t, chain.IsReorg(curHead, chn))
 	})
 }
+
+func TestReorgDepth(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	store := th.NewFakeBlockProvider()
+
+	// root -> a1 -> a2 -> a3 is the current chain, root -> a1 -> b2 the new one
+	root := store.NewBlock(0)
+	a1 := store.NewBlock(1, root)
+	a2 := store.NewBlock(2, a1)
+	a3 := store.NewBlock(3, a2)
+	b2 := store.NewBlock(4, a1)
+
+	newChain := []types.TipSet{requireTipset(t, root), requireTipset(t, a1), requireTipset(t, b2)}
+
+	depth, err := chain.ReorgDepth(ctx, store, requireTipset(t, a3), newChain)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), depth)
+
+	depth, err = chain.ReorgDepth(ctx, store, requireTipset(t, a2), newChain)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), depth)
+
+	other := store.NewBlock(5)
+	_, err = chain.ReorgDepth(ctx, store, requireTipset(t, a3), []types.TipSet{requireTipset(t, other)})
+	assert.Error(t, err)
+}
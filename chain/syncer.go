@@ -18,4 +18,8 @@ import (
 // after too many blocks.
 type Syncer interface {
 	HandleNewTipset(ctx context.Context, tipsetCids types.SortedCidSet) error
+	// SetHead forces the head of the store to a tipset already in the
+	// store, regardless of its weight and of finality. It is meant for
+	// manual recovery.
+	SetHead(ctx context.Context, tipsetCids types.SortedCidSet) error
 }
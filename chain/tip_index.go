@@ -95,6 +95,17 @@ func (ti *TipIndex) Has(tsKey string) bool {
 	return ok
 }
 
+// All returns all tipsets and states stored in the TipIndex.
+func (ti *TipIndex) All() []*TipSetAndState {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	ret := make([]*TipSetAndState, 0, len(ti.tsasByID))
+	for _, tsas := range ti.tsasByID {
+		ret = append(ret, tsas)
+	}
+	return ret
+}
+
 // GetByParentsAndHeight returns the all tipsets and states stored in the TipIndex
 // such that the parent ID of these tipsets equals the input.
 func (ti *TipIndex) GetByParentsAndHeight(pKey string, h uint64) ([]*TipSetAndState, error) {
@@ -1,24 +1,42 @@
 package commands
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
 	"reflect"
+	"sort"
 	"strings"
 
+	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/faucet"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/vesting"
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/types"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-cmdkit"
 	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
 )
 
+// ActorCallResult is the result of calling an actor method with actor call.
+// Return values are formatted as by formatActorReturn.
+type ActorCallResult struct {
+	Return  []interface{}  `json:"return"`
+	GasUsed types.GasUnits `json:"gasUsed"`
+}
+
 // ActorView represents a generic way to represent details about any actor to the user.
 type ActorView struct {
 	ActorType string          `json:"actorType"`
@@ -30,6 +48,20 @@ type ActorView struct {
 	Head      cid.Cid         `json:"head,omitempty"`
 }
 
+// ActorMethodsResult lists the methods an actor exports.
+type ActorMethodsResult struct {
+	ActorType string        `json:"actorType"`
+	Code      cid.Cid       `json:"code"`
+	Methods   []ActorMethod `json:"methods"`
+}
+
+// ActorMethod is the signature of a method an actor exports.
+type ActorMethod struct {
+	Name   string   `json:"name"`
+	Params []string `json:"params"`
+	Return []string `json:"return"`
+}
+
 // readableFunctionSignature is a representation of an actors function signature,
 // such that it can be shown to the user.
 type readableFunctionSignature struct {
@@ -46,7 +78,111 @@ var actorCmd = &cmds.Command{
 		Tagline: "Interact with actors. Actors are built-in smart contracts.",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"ls": actorLsCmd,
+		"call":       actorCallCmd,
+		"ls":         actorLsCmd,
+		"methods":    actorMethodsCmd,
+		"read-state": actorReadStateCmd,
+	},
+}
+
+var actorCallCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Call an actor method without sending a message",
+		ShortDescription: `
+Calls a method of an actor against the state of the head tipset, or of the
+tipset given by --tipset, and prints the return values and the gas the call
+used. Nothing is sent to the network and no state is changed.
+
+Params are parsed according to the method's signature. Amounts of FIL are
+given in FIL and byte values are hex encoded, as are byte return values.
+Bytes a method returns holding a CBOR encoded value, such as the channels
+returned by the payment broker's ls, are printed as JSON.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("actor", true, false, "Address of the actor to call"),
+		cmdkit.StringArg("method", true, false, "Name of the method to call"),
+		cmdkit.StringArg("params", false, true, "Parameters of the method"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to call the method from"),
+		cmdkit.StringOption("tipset", "Comma separated CIDs of the blocks of the tipset to call the method against"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		to, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid actor address")
+		}
+		method := req.Arguments[1]
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		baseKey := types.SortedCidSet{}
+		if tsOpt, ok := req.Options["tipset"].(string); ok && tsOpt != "" {
+			baseKey, err = parseTipSetKey(tsOpt)
+			if err != nil {
+				return err
+			}
+		}
+
+		sig, err := GetPorcelainAPI(env).ActorGetSignature(req.Context, to, method)
+		if err != nil {
+			return errors.Wrap(err, "could not get method signature")
+		}
+
+		act, err := GetPorcelainAPI(env).ActorGet(req.Context, to)
+		if err != nil {
+			return err
+		}
+
+		vals, err := abi.ParseValues(req.Arguments[2:], sig.Params)
+		if err != nil {
+			return err
+		}
+
+		ret, gasUsed, err := GetPorcelainAPI(env).MessageQueryAt(req.Context, fromAddr, to, baseKey, method, abi.FromValues(vals)...)
+		if err != nil {
+			return err
+		}
+
+		res := &ActorCallResult{GasUsed: gasUsed}
+		for i, t := range sig.Return {
+			if i >= len(ret) {
+				break
+			}
+			val, err := abi.Deserialize(ret[i], t)
+			if err != nil {
+				return errors.Wrap(err, "unable to deserialize return value")
+			}
+			r, err := formatActorReturn(act.Code, method, val)
+			if err != nil {
+				return err
+			}
+			res.Return = append(res.Return, r)
+		}
+
+		return re.Emit(res)
+	},
+	Type: ActorCallResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *ActorCallResult) error {
+			for _, r := range res.Return {
+				if s, ok := r.(string); ok {
+					fmt.Fprintln(w, s) // nolint: errcheck
+					continue
+				}
+				marshaled, err := appendJSON(r, nil)
+				if err != nil {
+					return err
+				}
+				w.Write(marshaled) // nolint: errcheck
+			}
+			_, err := fmt.Fprintf(w, "gas used: %d\n", res.GasUsed)
+			return err
+		}),
 	},
 }
 
@@ -77,6 +213,14 @@ var actorLsCmd = &cmds.Command{
 				output = makeActorView(result.Actor, result.Address, &miner.Actor{})
 			case result.Actor.Code.Equals(types.BootstrapMinerActorCodeCid):
 				output = makeActorView(result.Actor, result.Address, &miner.Actor{})
+			case result.Actor.Code.Equals(types.MultisigActorCodeCid):
+				output = makeActorView(result.Actor, result.Address, &multisig.Actor{})
+			case result.Actor.Code.Equals(types.VestingActorCodeCid):
+				output = makeActorView(result.Actor, result.Address, &vesting.Actor{})
+			case result.Actor.Code.Equals(types.InitActorCodeCid):
+				output = makeActorView(result.Actor, result.Address, &initactor.Actor{})
+			case result.Actor.Code.Equals(types.FaucetActorCodeCid):
+				output = makeActorView(result.Actor, result.Address, &faucet.Actor{})
 			default:
 				output = makeActorView(result.Actor, result.Address, nil)
 			}
@@ -88,17 +232,119 @@ var actorLsCmd = &cmds.Command{
 		return nil
 	},
 	Type: &ActorView{},
+}
+
+var actorMethodsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the methods an actor exports",
+		ShortDescription: `
+Prints the name, parameter types and return types of each method exported by
+a builtin actor, given either the address of an actor on chain or the CID of
+the code of a builtin actor. These are the methods that can be called with
+actor call or message send.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("actor", true, false, "Address of the actor or CID of its code"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		code, err := actorCode(req, env, req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		executable, ok := builtin.Actors[code]
+		if !ok {
+			return fmt.Errorf("no builtin actor has code %s", code)
+		}
+
+		res := &ActorMethodsResult{
+			ActorType: getActorType(executable),
+			Code:      code,
+			Methods:   []ActorMethod{},
+		}
+		for name, sig := range executable.Exports() {
+			rfs := makeReadable(sig)
+			res.Methods = append(res.Methods, ActorMethod{
+				Name:   name,
+				Params: rfs.Params,
+				Return: rfs.Return,
+			})
+		}
+		sort.Slice(res.Methods, func(i, j int) bool { return res.Methods[i].Name < res.Methods[j].Name })
+
+		return re.Emit(res)
+	},
+	Type: ActorMethodsResult{},
 	Encoders: cmds.EncoderMap{
-		cmds.JSON: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, a *ActorView) error {
-			marshaled, err := json.Marshal(a)
-			if err != nil {
-				return err
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *ActorMethodsResult) error {
+			fmt.Fprintf(w, "%s (%s)\n", res.ActorType, res.Code) // nolint: errcheck
+			for _, m := range res.Methods {
+				_, err := fmt.Fprintf(w, "%s(%s) (%s)\n", m.Name, strings.Join(m.Params, ", "), strings.Join(m.Return, ", "))
+				if err != nil {
+					return err
+				}
 			}
-			_, err = w.Write(marshaled)
+			return nil
+		}),
+	},
+}
+
+// actorCode returns the code CID given, or the code of the actor at the
+// address given.
+func actorCode(req *cmds.Request, env cmds.Environment, arg string) (cid.Cid, error) {
+	addr, err := address.NewFromString(arg)
+	if err != nil {
+		code, cidErr := cid.Decode(arg)
+		if cidErr != nil {
+			return cid.Undef, fmt.Errorf("%s is neither an address nor a CID", arg)
+		}
+		return code, nil
+	}
+
+	act, err := GetPorcelainAPI(env).ActorGet(req.Context, addr)
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "failed to get actor")
+	}
+	if act.Empty() {
+		return cid.Undef, fmt.Errorf("actor %s has no code", addr)
+	}
+	return act.Code, nil
+}
+
+var actorReadStateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Print the decoded state of an actor",
+		ShortDescription: `
+Loads the state of the actor at the given address from the head of the chain,
+decodes it and prints it as JSON. The state of miner, storage market, multisig,
+vesting and init actors is printed as stored. The state of the payment broker is
+printed as its payment channels, indexed by payer and channel id.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("address", true, false, "Address of the actor whose state to read"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		addr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		st, err := GetPorcelainAPI(env).ActorGetState(req.Context, addr)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(st)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeEncoder(func(req *cmds.Request, w io.Writer, st interface{}) error {
+			marshaled, err := json.MarshalIndent(st, "", "  ")
 			if err != nil {
 				return err
 			}
-			_, err = w.Write([]byte("\n"))
+			_, err = fmt.Fprintln(w, string(marshaled))
 			return err
 		}),
 	},
@@ -153,3 +399,10 @@ func getActorType(actType exec.ExecutableActor) string {
 
 	return strings.Title(prefixes[len(prefixes)-1]) + t.Name()
 }
+
+func formatActorCallValue(val *abi.Value) string {
+	if val.Type == abi.Bytes {
+		return hex.EncodeToString(val.Val.([]byte))
+	}
+	return val.String()
+}
@@ -8,9 +8,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/commands"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 func TestActorDaemon(t *testing.T) {
@@ -39,7 +41,7 @@ func TestActorDaemon(t *testing.T) {
 		// The order of actors is consistent, but only within builds of genesis.car.
 		// We just want to make sure the views have something valid in them.
 		for _, av := range avs {
-			assert.Contains(t, []string{"StoragemarketActor", "AccountActor", "PaymentbrokerActor", "MinerActor", "BootstrapMinerActor"}, av.ActorType)
+			assert.Contains(t, []string{"StoragemarketActor", "AccountActor", "PaymentbrokerActor", "MinerActor", "BootstrapMinerActor", "InitactorActor"}, av.ActorType)
 			if av.ActorType == "AccountActor" {
 				assert.Zero(t, len(av.Exports))
 			} else {
@@ -47,4 +49,98 @@ func TestActorDaemon(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("actor read-state prints the decoded state of an actor", func(t *testing.T) {
+		d := th.NewDaemon(t).Start()
+		defer d.ShutdownSuccess()
+
+		out := d.RunSuccess("actor", "read-state", address.StorageMarketAddress.String()).ReadStdout()
+		var market map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(out), &market))
+		assert.Contains(t, market, "TotalCommittedStorage")
+		assert.Contains(t, market, "ProofsMode")
+
+		out = d.RunSuccess("actor", "read-state", address.PaymentBrokerAddress.String()).ReadStdout()
+		var channels map[string]map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(out), &channels))
+		assert.Empty(t, channels)
+
+		d.RunFail("failed to get actor", "actor", "read-state", address.TestAddress.String())
+	})
+
+	t.Run("actor methods lists the methods an actor exports", func(t *testing.T) {
+		d := th.NewDaemon(t).Start()
+		defer d.ShutdownSuccess()
+
+		out := d.RunSuccess("actor", "methods", address.PaymentBrokerAddress.String(), "--enc", "json").ReadStdout()
+		var byAddr commands.ActorMethodsResult
+		require.NoError(t, json.Unmarshal([]byte(out), &byAddr))
+		assert.Equal(t, "PaymentbrokerActor", byAddr.ActorType)
+		assert.Equal(t, types.PaymentBrokerActorCodeCid, byAddr.Code)
+		assert.Contains(t, byAddr.Methods, commands.ActorMethod{
+			Name:   "cancel",
+			Params: []string{"*types.ChannelID"},
+			Return: []string{},
+		})
+
+		out = d.RunSuccess("actor", "methods", types.PaymentBrokerActorCodeCid.String(), "--enc", "json").ReadStdout()
+		var byCode commands.ActorMethodsResult
+		require.NoError(t, json.Unmarshal([]byte(out), &byCode))
+		assert.Equal(t, byAddr, byCode)
+
+		out = d.RunSuccess("actor", "methods", types.PaymentBrokerActorCodeCid.String()).ReadStdout()
+		assert.Contains(t, out, "cancel(*types.ChannelID) ()")
+
+		d.RunFail("failed to get actor", "actor", "methods", address.TestAddress.String())
+		d.RunFail("neither an address nor a CID", "actor", "methods", "foo")
+	})
+}
+
+func TestActorCall(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(t).Start()
+	defer d.ShutdownSuccess()
+
+	t.Run("calls a method without sending a message", func(t *testing.T) {
+		out := d.RunSuccess("actor", "call", address.StorageMarketAddress.String(), "getTotalStorage", "--enc=json")
+
+		var res commands.ActorCallResult
+		require.NoError(t, json.Unmarshal([]byte(out.ReadStdout()), &res))
+		assert.Len(t, res.Return, 1)
+		assert.NotZero(t, res.GasUsed)
+
+		pending := d.RunSuccess("mpool", "ls")
+		assert.Empty(t, pending.ReadStdoutTrimNewlines())
+	})
+
+	t.Run("parses params according to the signature", func(t *testing.T) {
+		out := d.RunSuccess("actor", "call", address.PaymentBrokerAddress.String(), "ls", d.GetDefaultAddress())
+		assert.Contains(t, out.ReadStdout(), "gas used:")
+
+		d.RunFail("expected 1 parameters", "actor", "call", address.PaymentBrokerAddress.String(), "ls")
+		d.RunFail("invalid", "actor", "call", address.PaymentBrokerAddress.String(), "ls", "notanaddress")
+	})
+
+	t.Run("calls against a chosen tipset", func(t *testing.T) {
+		head := d.GetChainHead()
+		tipset := head[0].Cid().String()
+		for _, blk := range head[1:] {
+			tipset += "," + blk.Cid().String()
+		}
+
+		out := d.RunSuccess("actor", "call", address.PaymentBrokerAddress.String(), "ls", d.GetDefaultAddress(), "--tipset", tipset)
+		assert.Contains(t, out.ReadStdout(), "gas used:")
+	})
+
+	t.Run("decodes the channels the payment broker returns", func(t *testing.T) {
+		out := d.RunSuccess("actor", "call", address.PaymentBrokerAddress.String(), "ls", d.GetDefaultAddress(), "--enc=json")
+
+		var res struct {
+			Return []map[string]interface{} `json:"return"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out.ReadStdout()), &res))
+		require.Len(t, res.Return, 1)
+		assert.Empty(t, res.Return[0])
+	})
 }
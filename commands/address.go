@@ -1,10 +1,13 @@
 package commands
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"strings"
 
 	"github.com/ipfs/go-ipfs-cmdkit"
 	"github.com/ipfs/go-ipfs-cmds"
@@ -12,7 +15,9 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/wallet"
 )
 
 var walletCmd = &cmds.Command{
@@ -20,9 +25,16 @@ var walletCmd = &cmds.Command{
 		Tagline: "Manage your filecoin wallets",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"balance": balanceCmd,
-		"import":  walletImportCmd,
-		"export":  walletExportCmd,
+		"balance":      balanceCmd,
+		"import":       walletImportCmd,
+		"export":       walletExportCmd,
+		"init":         walletInitCmd,
+		"lock":         walletLockCmd,
+		"new":          addrsNewCmd,
+		"restore":      walletRestoreCmd,
+		"session":      walletSessionCmd,
+		"transactions": walletTransactionsCmd,
+		"unlock":       walletUnlockCmd,
 	},
 }
 
@@ -48,8 +60,24 @@ type AddressLsResult struct {
 }
 
 var addrsNewCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Create a new address in the wallet",
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("type", "The type of key of the address, secp256k1 or bls").WithDefault("secp256k1"),
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
-		addr, err := GetPorcelainAPI(env).WalletNewAddress()
+		var protocol address.Protocol
+		switch req.Options["type"].(string) {
+		case "secp256k1":
+			protocol = address.SECP256K1
+		case "bls":
+			protocol = address.BLS
+		default:
+			return fmt.Errorf("unknown key type %q, expected secp256k1 or bls", req.Options["type"])
+		}
+
+		addr, err := GetPorcelainAPI(env).WalletNewAddress(protocol)
 		if err != nil {
 			return err
 		}
@@ -151,7 +179,66 @@ var balanceCmd = &cmds.Command{
 	Type: &types.AttoFIL{},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, b *types.AttoFIL) error {
-			return PrintString(w, b)
+			_, err := fmt.Fprintln(w, NewFormatter(req).FIL(b))
+			return err
+		}),
+	},
+}
+
+var walletTransactionsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the messages on chain sent from or to an address",
+		ShortDescription: `
+Lists the messages executed on chain that were sent from or to the address,
+the most recent first, with the height they were executed at and the exit code
+of their receipt.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("address", true, false, "Address to list the messages of"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		addr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		txs, err := GetPorcelainAPI(env).WalletTransactions(req.Context, addr)
+		if err != nil {
+			return err
+		}
+		return re.Emit(txs)
+	},
+	Type: []*msg.ChainMessage{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, txs *[]*msg.ChainMessage) error {
+			if len(*txs) == 0 {
+				fmt.Fprintln(w, "no transactions") // nolint: errcheck
+				return nil
+			}
+
+			f := NewFormatter(req)
+			table := f.NewTable(w, "Height", "Message", "From", "To", "Value", "Method", "Exit")
+			for _, tx := range *txs {
+				c, err := tx.Message.Cid()
+				if err != nil {
+					return err
+				}
+				method := tx.Message.Method
+				if method == "" {
+					method = "-"
+				}
+				table.Row(
+					fmt.Sprintf("%d", uint64(tx.Block.Height)),
+					c.String(),
+					tx.Message.From.String(),
+					tx.Message.To.String(),
+					f.FIL(tx.Message.Value),
+					method,
+					fmt.Sprintf("%d", tx.Receipt.ExitCode),
+				)
+			}
+			return table.Flush()
 		}),
 	},
 }
@@ -161,7 +248,110 @@ type WalletSerializeResult struct {
 	KeyInfo []*types.KeyInfo
 }
 
+// WalletInitResult is the result of running the wallet init command.
+type WalletInitResult struct {
+	Mnemonic string
+	// UnderivedAddresses are the addresses in the wallet before it was
+	// initialized, which the mnemonic does not recover.
+	UnderivedAddresses []string
+}
+
+var walletInitCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Derive new addresses from a new mnemonic",
+		ShortDescription: `
+Generates a new mnemonic and makes the wallet derive its new secp256k1
+addresses from it, along the BIP44 path m/44'/461'/0'/0/<index>. Write the
+mnemonic down: it recovers all the derived addresses with 'wallet restore'.
+The keys of addresses created before are not derived from the mnemonic and
+must still be backed up with 'wallet export'.
+`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		mnemonic, underived, err := GetPorcelainAPI(env).WalletInitHD()
+		if err != nil {
+			return err
+		}
+
+		res := &WalletInitResult{Mnemonic: mnemonic}
+		for _, addr := range underived {
+			res.UnderivedAddresses = append(res.UnderivedAddresses, addr.String())
+		}
+		return re.Emit(res)
+	},
+	Type: &WalletInitResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *WalletInitResult) error {
+			if _, err := fmt.Fprintf(w, "%s\n", res.Mnemonic); err != nil {
+				return err
+			}
+			if len(res.UnderivedAddresses) == 0 {
+				return nil
+			}
+			if _, err := fmt.Fprintln(w, "\nThese addresses are not recovered by the mnemonic, back them up with 'wallet export':"); err != nil {
+				return err
+			}
+			for _, addr := range res.UnderivedAddresses {
+				if _, err := fmt.Fprintln(w, addr); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+var walletRestoreCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Recover the addresses derived from a mnemonic",
+		ShortDescription: `
+Makes the wallet derive its addresses from the given mnemonic, and adds the
+derived addresses up to the last one with an actor on chain. The wallet must
+not derive its addresses from a mnemonic already.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("mnemonic", true, true, "The words of the mnemonic"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		mnemonic := strings.Join(req.Arguments, " ")
+		addrs, err := GetPorcelainAPI(env).WalletRestore(req.Context, mnemonic)
+		if err != nil {
+			return err
+		}
+
+		var alr AddressLsResult
+		for _, addr := range addrs {
+			alr.Addresses = append(alr.Addresses, addr.String())
+		}
+		return re.Emit(&alr)
+	},
+	Type: &AddressLsResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, addrs *AddressLsResult) error {
+			for _, addr := range addrs.Addresses {
+				if _, err := fmt.Fprintln(w, addr); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
 var walletImportCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Import keys into the wallet",
+		ShortDescription: `
+Imports the keys in the given file. The file either holds the JSON output of
+'wallet export --enc=json', or keys in the standard key format written by
+'wallet export --key', one per line.
+
+A key in the standard key format is the hex encoding of the JSON object
+{"version":1,"type":"<type>","privateKey":"<hex private key>"}, where type is
+secp256k1 or bls. The format is stable across node versions.
+`,
+	},
 	Arguments: []cmdkit.Argument{
 		cmdkit.FileArg("walletFile", true, false, "File containing wallet data to import").EnableStdin(),
 	},
@@ -176,11 +366,14 @@ var walletImportCmd = &cmds.Command{
 			return fmt.Errorf("given file was not a files.File")
 		}
 
-		var wir *WalletSerializeResult
-		if err := json.NewDecoder(fi).Decode(&wir); err != nil {
+		data, err := ioutil.ReadAll(fi)
+		if err != nil {
+			return err
+		}
+		keyInfos, err := parseWalletFile(data)
+		if err != nil {
 			return err
 		}
-		keyInfos := wir.KeyInfo
 
 		if len(keyInfos) == 0 {
 			return fmt.Errorf("no keys in wallet file")
@@ -213,9 +406,20 @@ var walletImportCmd = &cmds.Command{
 }
 
 var walletExportCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Export the keys of wallet addresses",
+		ShortDescription: `
+Prints the private keys of the given addresses. With --key, the keys are
+printed in the standard key format, one per line, which 'wallet import'
+accepts on any node version. See 'wallet import --help' for the format.
+`,
+	},
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("addresses", true, true, "Addresses of keys to export").EnableStdin(),
 	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("key", "Print the keys in the standard key format"),
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		addrs := make([]address.Address, len(req.Arguments))
 		for i, arg := range req.Arguments {
@@ -239,6 +443,18 @@ var walletExportCmd = &cmds.Command{
 	Type: &WalletSerializeResult{},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, klr *WalletSerializeResult) error {
+			if key, _ := req.Options["key"].(bool); key {
+				for _, k := range klr.KeyInfo {
+					encoded, err := wallet.EncodeKey(k)
+					if err != nil {
+						return err
+					}
+					if _, err := fmt.Fprintln(w, encoded); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
 			for _, k := range klr.KeyInfo {
 				a, err := k.Address()
 				if err != nil {
@@ -254,3 +470,28 @@ var walletExportCmd = &cmds.Command{
 		}),
 	},
 }
+
+// parseWalletFile returns the keys of a file holding either a JSON encoded
+// WalletSerializeResult or keys in the standard key format, one per line.
+func parseWalletFile(data []byte) ([]*types.KeyInfo, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var wir WalletSerializeResult
+		if err := json.Unmarshal(trimmed, &wir); err != nil {
+			return nil, err
+		}
+		return wir.KeyInfo, nil
+	}
+
+	var keyInfos []*types.KeyInfo
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		ki, err := wallet.DecodeKey(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key on line %d", i+1)
+		}
+		keyInfos = append(keyInfos, ki)
+	}
+	return keyInfos, nil
+}
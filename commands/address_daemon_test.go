@@ -1,6 +1,7 @@
 package commands_test
 
 import (
+	"io/ioutil"
 	"os"
 	"strings"
 	"testing"
@@ -41,19 +42,44 @@ func TestWalletBalance(t *testing.T) {
 	addr := d.CreateAddress()
 
 	t.Log("[success] not found, zero")
-	balance := d.RunSuccess("wallet", "balance", addr)
+	balance := d.RunSuccess("wallet", "balance", "--no-humanize", addr)
 	assert.Equal(t, "0", balance.ReadStdoutTrimNewlines())
 
 	t.Log("[success] balance 9999900000")
-	balance = d.RunSuccess("wallet", "balance", address.NetworkAddress.String())
+	balance = d.RunSuccess("wallet", "balance", "--no-humanize", address.NetworkAddress.String())
 	assert.Equal(t, "9999900000", balance.ReadStdoutTrimNewlines())
 
 	t.Log("[success] newly generated one")
 	addrNew := d.RunSuccess("address new")
-	balance = d.RunSuccess("wallet", "balance", addrNew.ReadStdoutTrimNewlines())
+	balance = d.RunSuccess("wallet", "balance", "--no-humanize", addrNew.ReadStdoutTrimNewlines())
 	assert.Equal(t, "0", balance.ReadStdoutTrimNewlines())
 }
 
+func TestWalletTransactions(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := makeTestDaemonWithMinerAndStart(t)
+	defer d.ShutdownSuccess()
+
+	txs := d.RunSuccess("wallet", "transactions", fixtures.TestAddresses[1])
+	assert.Equal(t, "no transactions", txs.ReadStdoutTrimNewlines())
+
+	msg := d.RunSuccess(
+		"message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--gas-price", "1", "--gas-limit", "300",
+		"--value=10",
+		fixtures.TestAddresses[1],
+	)
+	msgCid := msg.ReadStdoutTrimNewlines()
+	d.RunSuccess("mining once")
+
+	for _, addr := range fixtures.TestAddresses[:2] {
+		txs = d.RunSuccess("wallet", "transactions", addr)
+		assert.Contains(t, txs.ReadStdout(), msgCid)
+	}
+}
+
 func TestAddrLookupAndUpdate(t *testing.T) {
 	tf.IntegrationTest(t)
 
@@ -118,7 +144,7 @@ func TestWalletLoadFromFile(t *testing.T) {
 	}
 
 	// assert default amount of funds were allocated to address during genesis
-	wb := d.RunSuccess("wallet", "balance", fixtures.TestAddresses[0]).ReadStdoutTrimNewlines()
+	wb := d.RunSuccess("wallet", "balance", "--no-humanize", fixtures.TestAddresses[0]).ReadStdoutTrimNewlines()
 	assert.Contains(t, wb, "10000")
 }
 
@@ -146,6 +172,33 @@ func TestWalletExportImportRoundTrip(t *testing.T) {
 
 }
 
+func TestWalletExportImportKeyFormatRoundTrip(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(t).Start()
+	defer d.ShutdownSuccess()
+
+	dw := d.RunSuccess("address", "ls").ReadStdoutTrimNewlines()
+
+	key := d.RunSuccess("wallet", "export", dw, "--key").ReadStdoutTrimNewlines()
+	assert.NotContains(t, key, "\n")
+
+	wf, err := ioutil.TempFile("", "walletKeyTest")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.Remove(wf.Name()))
+	}()
+	_, err = wf.WriteString(key + "\n")
+	require.NoError(t, err)
+	require.NoError(t, wf.Close())
+
+	other := th.NewDaemon(t).Start()
+	defer other.ShutdownSuccess()
+
+	imported := other.RunSuccess("wallet", "import", wf.Name()).ReadStdoutTrimNewlines()
+	assert.Equal(t, dw, imported)
+}
+
 func TestWalletExportPrivateKeyConsistentDisplay(t *testing.T) {
 	tf.IntegrationTest(t)
 
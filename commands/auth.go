@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-ipfs-cmdkit"
+	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/api"
+)
+
+var authCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage the authentication to the JSON-RPC API",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"create-token": authCreateTokenCmd,
+	},
+}
+
+var authCreateTokenCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Create a token to authenticate to the JSON-RPC API with",
+		ShortDescription: `
+Creates a token giving access to the commands of the given permission scope
+over the JSON-RPC API of the node. Scopes from the least to the most privileged
+are read, write, sign and admin, and a token for a scope also gives access to
+the less privileged ones. A read token can inspect the node and the chain,
+write can change the state of the node without spending funds, sign can send
+messages and so move funds, and admin can run any command.
+
+Tokens authenticate the requests to the HTTP API of the CLI too. The CLI signs
+itself an admin token with the secret of the local repo, unless a token is
+given in the FIL_API_TOKEN environment variable.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("perm", "Permission scope of the token: read, write, sign or admin"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		perm, _ := req.Options["perm"].(string)
+		if perm == "" {
+			return errors.New("the --perm option is required")
+		}
+		perms, err := api.PermissionsUpTo(api.Permission(perm))
+		if err != nil {
+			return err
+		}
+
+		encoded, err := GetPorcelainAPI(env).ConfigGet("api.authSecret")
+		if err != nil {
+			return err
+		}
+		secret, err := hex.DecodeString(encoded.(string))
+		if err != nil {
+			return errors.Wrap(err, "invalid API auth secret")
+		}
+		if len(secret) == 0 {
+			return errors.New("the API auth secret is not set, set api.authSecret in the config")
+		}
+
+		token, err := api.CreateToken(secret, perms)
+		if err != nil {
+			return err
+		}
+		return re.Emit(token)
+	},
+	Type: string(""),
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, token string) error {
+			_, err := fmt.Fprintln(w, token)
+			return err
+		}),
+	},
+}
+
+// commandPermissions is the permission a token needs to run each command
+// over the JSON-RPC API. Commands not listed require api.PermAdmin.
+var commandPermissions = map[*cmds.Command]api.Permission{
+	actorCallCmd:              api.PermRead,
+	actorLsCmd:                api.PermRead,
+	actorMethodsCmd:           api.PermRead,
+	actorReadStateCmd:         api.PermRead,
+	addrsLookupCmd:            api.PermRead,
+	addrsLsCmd:                api.PermRead,
+	balanceCmd:                api.PermRead,
+	bootstrapLsCmd:            api.PermRead,
+	chainHeadCmd:              api.PermRead,
+	chainLatencyCmd:           api.PermRead,
+	chainLsCmd:                api.PermRead,
+	clientCatCmd:              api.PermRead,
+	clientListAsksCmd:         api.PermRead,
+	clientQueryStorageDealCmd: api.PermRead,
+	dagGetCmd:                 api.PermRead,
+	dealsShowCmd:              api.PermRead,
+	defaultAddressCmd:         api.PermRead,
+	findPeerDhtCmd:            api.PermRead,
+	findProvidersDhtCmd:       api.PermRead,
+	historyCmd:                api.PermRead,
+	idCmd:                     api.PermRead,
+	lsCmd:                     api.PermRead,
+	minerAskLsCmd:             api.PermRead,
+	minerDealsLsCmd:           api.PermRead,
+	minerInfoCmd:              api.PermRead,
+	minerOwnerCmd:             api.PermRead,
+	minerPaymentsReportCmd:    api.PermRead,
+	minerPowerCmd:             api.PermRead,
+	minerSectorsExpiringCmd:   api.PermRead,
+	minerWorkerCmd:            api.PermRead,
+	mpoolEstimateGasCmd:       api.PermRead,
+	mpoolGasEstimateCmd:       api.PermRead,
+	mpoolLsCmd:                api.PermRead,
+	mpoolPendingCmd:           api.PermRead,
+	mpoolShowCmd:              api.PermRead,
+	msgReplayCmd:              api.PermRead,
+	msgStatusCmd:              api.PermRead,
+	msgWaitCmd:                api.PermRead,
+	outboxLsCmd:               api.PermRead,
+	paymentsCmd:               api.PermRead,
+	pingCmd:                   api.PermRead,
+	protocolCmd:               api.PermRead,
+	queryDhtCmd:               api.PermRead,
+	showBlockCmd:              api.PermRead,
+	stateDiffCmd:              api.PermRead,
+	statsBandwidthCmd:         api.PermRead,
+	statsBitswapCmd:           api.PermRead,
+	swarmBandwidthCmd:         api.PermRead,
+	swarmBansCmd:              api.PermRead,
+	swarmFindPeerCmd:          api.PermRead,
+	swarmPeersCmd:             api.PermRead,
+	voucherDecodeCmd:          api.PermRead,
+	voucherEncodeCmd:          api.PermRead,
+	voucherInspectCmd:         api.PermRead,
+	vouchersCmd:               api.PermRead,
+	walletTransactionsCmd:     api.PermRead,
+
+	addrsNewCmd:         api.PermWrite,
+	clientImportDataCmd: api.PermWrite,
+	miningOnceCmd:       api.PermWrite,
+	miningStartCmd:      api.PermWrite,
+	miningStopCmd:       api.PermWrite,
+	mpoolRemoveCmd:      api.PermWrite,
+	outboxClearCmd:      api.PermWrite,
+	swarmBansClearCmd:   api.PermWrite,
+	swarmConnectCmd:     api.PermWrite,
+	swarmDisconnectCmd:  api.PermWrite,
+	vouchersImportCmd:   api.PermWrite,
+
+	assignTargetCmd:             api.PermSign,
+	cancelCmd:                   api.PermSign,
+	clientProposeStorageDealCmd: api.PermSign,
+	clientRetrievePieceCmd:      api.PermSign,
+	closeCmd:                    api.PermSign,
+	createChannelCmd:            api.PermSign,
+	extendCmd:                   api.PermSign,
+	faucetTapCmd:                api.PermSign,
+	minerAskRmCmd:               api.PermSign,
+	minerChangeWorkerCmd:        api.PermSign,
+	minerCreateCmd:              api.PermSign,
+	minerPledgeAddCmd:           api.PermSign,
+	minerPledgeCmd:              api.PermSign,
+	minerPledgeWithdrawCmd:      api.PermSign,
+	minerSetPriceCmd:            api.PermSign,
+	minerUpdatePeerIDCmd:        api.PermSign,
+	msgSendCmd:                  api.PermSign,
+	multisigApproveCmd:          api.PermSign,
+	multisigCancelCmd:           api.PermSign,
+	multisigCreateCmd:           api.PermSign,
+	multisigProposeCmd:          api.PermSign,
+	reclaimCmd:                  api.PermSign,
+	redeemCmd:                   api.PermSign,
+	settleCmd:                   api.PermSign,
+	signAssignmentCmd:           api.PermSign,
+	vestingCreateCmd:            api.PermSign,
+	vestingRevokeCmd:            api.PermSign,
+	vestingWithdrawCmd:          api.PermSign,
+	voucherCmd:                  api.PermSign,
+}
+
+// commandPermission returns the permission a token needs to run cmd over the
+// JSON-RPC API.
+func commandPermission(cmd *cmds.Command) api.Permission {
+	if perm, ok := commandPermissions[cmd]; ok {
+		return perm
+	}
+	return api.PermAdmin
+}
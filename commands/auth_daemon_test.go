@@ -0,0 +1,110 @@
+package commands_test
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/multiformats/go-multiaddr-net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/api"
+	"github.com/filecoin-project/go-filecoin/api/client"
+	"github.com/filecoin-project/go-filecoin/fixtures"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestAuthCreateToken(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	ctx := context.Background()
+	d := th.NewDaemon(t).Start()
+	defer d.ShutdownSuccess()
+
+	token := d.RunSuccess("auth", "create-token", "--perm", "write").ReadStdoutTrimNewlines()
+
+	secret, err := hex.DecodeString(d.Config().API.AuthSecret)
+	require.NoError(t, err)
+	perms, err := api.VerifyToken(secret, token)
+	require.NoError(t, err)
+	assert.Equal(t, []api.Permission{api.PermRead, api.PermWrite}, perms)
+
+	d.RunFail("unknown permission", "auth", "create-token", "--perm", "root")
+
+	maddr, err := ma.NewMultiaddr(d.CmdAddr())
+	require.NoError(t, err)
+	_, host, err := manet.DialArgs(maddr)
+	require.NoError(t, err)
+	c := client.New(fmt.Sprintf("http://%s", host), token)
+
+	t.Run("the token runs commands of its scope", func(t *testing.T) {
+		var out []map[string]interface{}
+		require.NoError(t, c.Call(ctx, "id", nil, nil, &out))
+		require.Len(t, out, 1)
+		assert.Contains(t, out[0], "ID")
+	})
+
+	t.Run("the token doesn't run commands of higher scopes", func(t *testing.T) {
+		err := c.Call(ctx, "config", []string{"api"}, nil, nil)
+		require.Error(t, err)
+		rpcErr, ok := err.(*api.Error)
+		require.True(t, ok)
+		assert.Equal(t, api.ErrCodePermission, rpcErr.Code)
+	})
+}
+
+func TestAuthReadTokenCannotSendMessages(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	ctx := context.Background()
+	d := th.NewDaemon(t, th.KeyFile(fixtures.KeyFilePaths()[0]), th.DefaultAddress(fixtures.TestAddresses[0])).Start()
+	defer d.ShutdownSuccess()
+
+	readToken := d.RunSuccess("auth", "create-token", "--perm", "read").ReadStdoutTrimNewlines()
+
+	maddr, err := ma.NewMultiaddr(d.CmdAddr())
+	require.NoError(t, err)
+	_, host, err := manet.DialArgs(maddr)
+	require.NoError(t, err)
+
+	sendArgs := []string{fixtures.TestAddresses[1]}
+	sendOpts := map[string]interface{}{"value": "1", "from": fixtures.TestAddresses[0], "gas-price": "0", "gas-limit": "300"}
+
+	t.Run("through the JSON-RPC API", func(t *testing.T) {
+		c := client.New(fmt.Sprintf("http://%s", host), readToken)
+		err := c.Call(ctx, "message.send", sendArgs, sendOpts, nil)
+		require.Error(t, err)
+		rpcErr, ok := err.(*api.Error)
+		require.True(t, ok)
+		assert.Equal(t, api.ErrCodePermission, rpcErr.Code)
+	})
+
+	t.Run("through the HTTP API of the CLI", func(t *testing.T) {
+		query := url.Values{"arg": sendArgs}
+		for name, value := range sendOpts {
+			query.Set(name, value.(string))
+		}
+		sendURL := fmt.Sprintf("http://%s/api/message/send?%s", host, query.Encode())
+
+		for token, status := range map[string]int{"": http.StatusUnauthorized, readToken: http.StatusForbidden} {
+			req, err := http.NewRequest(http.MethodPost, sendURL, nil)
+			require.NoError(t, err)
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+			require.NoError(t, err)
+			resp.Body.Close() // nolint: errcheck
+			assert.Equal(t, status, resp.StatusCode)
+		}
+	})
+
+	// nothing reached the message pool
+	assert.Equal(t, "", d.RunSuccess("mpool", "ls").ReadStdoutTrimNewlines())
+}
@@ -6,6 +6,7 @@ import (
 
 	cmdkit "github.com/ipfs/go-ipfs-cmdkit"
 	cmds "github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
 )
 
 // BootstrapLsResult is the result of the bootstrap listing command.
@@ -18,7 +19,85 @@ var bootstrapCmd = &cmds.Command{
 		Tagline: "Interact with bootstrap addresses",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"ls": bootstrapLsCmd,
+		"add": bootstrapAddCmd,
+		"ls":  bootstrapLsCmd,
+		"rm":  bootstrapRmCmd,
+	},
+}
+
+var bootstrapAddCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Add peers to the bootstrap peers",
+		ShortDescription: `
+Adds peer addresses to the bootstrap peers in the config. When it has fewer
+connections than bootstrap.minPeerThreshold, the node connects to random
+bootstrap peers, right away and then every bootstrap.period. Addresses must
+end with the ID of the peer:
+
+go-filecoin bootstrap add /ip4/104.131.131.82/tcp/6000/ipfs/QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("address", true, true, "Address of a bootstrap peer").EnableStdin(),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		added, err := GetPorcelainAPI(env).BootstrapAddPeers(req.Arguments)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&BootstrapLsResult{added})
+	},
+	Type: &BootstrapLsResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, br *BootstrapLsResult) error {
+			for _, peer := range br.Peers {
+				if _, err := fmt.Fprintf(w, "added %s\n", peer); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+var bootstrapRmCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Remove peers from the bootstrap peers",
+		ShortDescription: `
+Removes peer addresses from the bootstrap peers in the config, or all of them
+with --all. Connections open to removed peers are kept.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("address", false, true, "Address of a bootstrap peer").EnableStdin(),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("all", "Remove all the bootstrap peers"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		all, _ := req.Options["all"].(bool)
+		if all == (len(req.Arguments) > 0) {
+			return errors.New("either addresses or --all must be given")
+		}
+
+		removed, err := GetPorcelainAPI(env).BootstrapRemovePeers(req.Arguments, all)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&BootstrapLsResult{removed})
+	},
+	Type: &BootstrapLsResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, br *BootstrapLsResult) error {
+			for _, peer := range br.Peers {
+				if _, err := fmt.Fprintf(w, "removed %s\n", peer); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
 	},
 }
 
@@ -19,3 +19,22 @@ func TestBootstrapList(t *testing.T) {
 
 	assert.Equal(t, "&{[]}\n", bs.ReadStdout())
 }
+
+func TestBootstrapAddRm(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(t).Start()
+	defer d.ShutdownSuccess()
+
+	peer := "/ip4/127.0.0.1/tcp/6000/ipfs/QmcZf59bWwK5XFi76CZX8cbJ4BhTzzA3gU1ZjYZcYW3dwt"
+
+	added := d.RunSuccess("bootstrap", "add", peer)
+	assert.Equal(t, "added "+peer+"\n", added.ReadStdout())
+	assert.Equal(t, "&{["+peer+"]}\n", d.RunSuccess("bootstrap", "ls").ReadStdout())
+
+	d.RunFail("not a bootstrap peer", "bootstrap", "rm", "/ip4/127.0.0.1/tcp/6001/ipfs/QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ")
+
+	removed := d.RunSuccess("bootstrap", "rm", "--all")
+	assert.Equal(t, "removed "+peer+"\n", removed.ReadStdout())
+	assert.Equal(t, "&{[]}\n", d.RunSuccess("bootstrap", "ls").ReadStdout())
+}
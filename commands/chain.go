@@ -2,15 +2,21 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"strconv"
 	"strings"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-cmdkit"
 	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/ipfs/go-ipfs-files"
+	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/metrics"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -19,28 +25,167 @@ var chainCmd = &cmds.Command{
 		Tagline: "Inspect the filecoin blockchain",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"head": chainHeadCmd,
-		"ls":   chainLsCmd,
+		"export":   chainExportCmd,
+		"head":     chainHeadCmd,
+		"import":   chainImportCmd,
+		"latency":  chainLatencyCmd,
+		"ls":       chainLsCmd,
+		"prune":    chainPruneCmd,
+		"set-head": chainSetHeadCmd,
 	},
 }
 
+// ChainHeadEvent is the output of chain head. Without --watch it is the head,
+// encoded to JSON as the list of the cids of its blocks. With --watch it is a
+// tipset the head moves through: first the head when watching starts, with
+// type current, then the tipsets every head change reverts and applies.
+type ChainHeadEvent struct {
+	Type   string    `json:"type"`
+	TipSet []cid.Cid `json:"tipset"`
+	Height uint64    `json:"height"`
+}
+
+// chainHeadEventFields lets ChainHeadEvent use the default json encoding.
+type chainHeadEventFields ChainHeadEvent
+
+// MarshalJSON implements json.Marshaler.
+func (e ChainHeadEvent) MarshalJSON() ([]byte, error) {
+	if e.Type == "" {
+		return json.Marshal(e.TipSet)
+	}
+	return json.Marshal(chainHeadEventFields(e))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *ChainHeadEvent) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '[' {
+		*e = ChainHeadEvent{}
+		return json.Unmarshal(data, &e.TipSet)
+	}
+	return json.Unmarshal(data, (*chainHeadEventFields)(e))
+}
+
+func newChainHeadEvent(eventType string, ts types.TipSet) (*ChainHeadEvent, error) {
+	h, err := ts.Height()
+	if err != nil {
+		return nil, err
+	}
+	return &ChainHeadEvent{Type: eventType, TipSet: ts.ToSortedCidSet().ToSlice(), Height: h}, nil
+}
+
 var chainHeadCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Get heaviest tipset CIDs",
+		ShortDescription: `
+Prints the CIDs of the blocks of the heaviest tipset. With --watch the command
+keeps running and prints the head when it starts and then every tipset the
+head changes revert and apply, in order, as type, height and block CIDs.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("watch", "Stream head changes"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		head, err := GetPorcelainAPI(env).ChainHead()
 		if err != nil {
 			return err
 		}
-		return re.Emit(head.ToSortedCidSet())
+		if watch, _ := req.Options["watch"].(bool); !watch {
+			return re.Emit(&ChainHeadEvent{TipSet: head.ToSortedCidSet().ToSlice()})
+		}
+
+		current, err := newChainHeadEvent("current", *head)
+		if err != nil {
+			return err
+		}
+		if err := re.Emit(current); err != nil {
+			return err
+		}
+		return GetPorcelainAPI(env).ChainSubscribeHeadChanges(req.Context, func(change *chain.HeadChange) error {
+			for _, ts := range change.Revert {
+				event, err := newChainHeadEvent("revert", ts)
+				if err != nil {
+					return err
+				}
+				if err := re.Emit(event); err != nil {
+					return err
+				}
+			}
+			for _, ts := range change.Apply {
+				event, err := newChainHeadEvent("apply", ts)
+				if err != nil {
+					return err
+				}
+				if err := re.Emit(event); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	},
+	Type: ChainHeadEvent{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *ChainHeadEvent) error {
+			if res.Type != "" {
+				cids := make([]string, len(res.TipSet))
+				for i, c := range res.TipSet {
+					cids[i] = c.String()
+				}
+				_, err := fmt.Fprintf(w, "%s\t%d\t%s\n", res.Type, res.Height, strings.Join(cids, " "))
+				return err
+			}
+			for _, r := range res.TipSet {
+				_, err := fmt.Fprintln(w, r.String())
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+var chainSetHeadCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Force the head of the chain to a tipset (for operators recovering a node)",
+		ShortDescription: `
+Sets the head of the chain to the tipset with the given block CIDs regardless
+of its weight and of finality. The tipset must have been validated by the node
+already. The node does not switch to forks deeper than finality on its own, so
+this is the way to recover a node that followed the wrong chain. Requires
+--force since picking the wrong tipset leaves the node off the network's chain.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("cids", true, true, "CIDs of the blocks of the tipset"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("force", "Confirm setting the head"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		if force, _ := req.Options["force"].(bool); !force {
+			return errors.New("setting the head overrides consensus, pass --force to do it")
+		}
+
+		var cids []cid.Cid
+		for _, arg := range req.Arguments {
+			c, err := cid.Decode(arg)
+			if err != nil {
+				return err
+			}
+			cids = append(cids, c)
+		}
+		tipsetCids := types.NewSortedCidSet(cids...)
+		if err := GetPorcelainAPI(env).ChainSetHead(req.Context, tipsetCids); err != nil {
+			return err
+		}
+		return re.Emit(tipsetCids)
 	},
 	Type: []cid.Cid{},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res []cid.Cid) error {
 			for _, r := range res {
-				_, err := fmt.Fprintln(w, r.String())
-				if err != nil {
+				if _, err := fmt.Fprintln(w, r.String()); err != nil {
 					return err
 				}
 			}
@@ -49,6 +194,83 @@ var chainHeadCmd = &cmds.Command{
 	},
 }
 
+var chainLatencyCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show how late blocks arrive from the network",
+		ShortDescription: `
+Summarizes the latency of the latest blocks received from the network, the
+time from the timestamp their miners stamped them with to their arrival, and
+shows how many fell in each latency bucket. Latencies growing across nodes
+point to propagation problems in the network. The latencies of all blocks
+are also exported to the metrics endpoint as block_propagation_latency.
+`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		return re.Emit(GetPorcelainAPI(env).ChainBlockLatency())
+	},
+	Type: metrics.BlockLatencyReport{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, report *metrics.BlockLatencyReport) error {
+			f := NewFormatter(req)
+			fmt.Fprintf(w, "blocks: %d\n", report.Count) // nolint: errcheck
+			if report.Count == 0 {
+				return nil
+			}
+			fmt.Fprintf(w, "mean: %s median: %s p90: %s p99: %s max: %s\n", f.Duration(report.Mean), f.Duration(report.Median), f.Duration(report.P90), f.Duration(report.P99), f.Duration(report.Max)) // nolint: errcheck
+			for _, bucket := range report.Buckets {
+				upTo := "inf"
+				if bucket.UpTo != 0 {
+					upTo = f.Duration(bucket.UpTo)
+				}
+				fmt.Fprintf(w, "<= %s %d\n", upTo, bucket.Count) // nolint: errcheck
+			}
+			return nil
+		}),
+	},
+}
+
+var chainPruneCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Discard the states of old tipsets",
+		ShortDescription: `
+Deletes the state trees of the tipsets more than --keep rounds below the head,
+keeping their headers, to free disk space. --keep defaults to
+pruning.keepStates, which also has the node prune periodically, and cannot be
+less than the sync finality, so the node can still switch to any fork it would
+otherwise. Old states can no longer be queried or exported once pruned.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.Uint64Option("keep", "Number of rounds below the head whose tipsets keep their states"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		keep, ok := req.Options["keep"].(uint64)
+		if !ok {
+			keepStates, err := GetPorcelainAPI(env).ConfigGet("pruning.keepStates")
+			if err != nil {
+				return err
+			}
+			keep, _ = keepStates.(uint64)
+		}
+		if keep == 0 {
+			return errors.New("pass --keep or set pruning.keepStates")
+		}
+
+		res, err := GetPorcelainAPI(env).ChainPrune(req.Context, keep)
+		if err != nil {
+			return err
+		}
+		return re.Emit(res)
+	},
+	Type: chain.PruneResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *chain.PruneResult) error {
+			_, err := fmt.Fprintf(w, "pruned the states of %d tipsets below height %d, deleting %d blocks\n", res.TipSets, res.Height, res.Blocks)
+			return err
+		}),
+	},
+}
+
 var chainLsCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline:          "List blocks in the blockchain",
@@ -108,3 +330,124 @@ var chainLsCmd = &cmds.Command{
 		}),
 	},
 }
+
+// chainArchiveProgressInterval is the number of blocks between the progress
+// reports of chain export and import.
+const chainArchiveProgressInterval = 100
+
+// ChainArchiveProgress is the progress of a chain export or import. The last
+// one a command emits has Done set, and for imports the head of the chain
+// imported.
+type ChainArchiveProgress struct {
+	Blocks uint64             `json:"blocks"`
+	Done   bool               `json:"done"`
+	Head   types.SortedCidSet `json:"head"`
+}
+
+var chainArchiveProgressEncoders = cmds.EncoderMap{
+	cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *ChainArchiveProgress) error {
+		if !res.Done {
+			_, err := fmt.Fprintf(w, "%d blocks\n", res.Blocks)
+			return err
+		}
+		if res.Head.Empty() {
+			_, err := fmt.Fprintf(w, "done, %d blocks\n", res.Blocks)
+			return err
+		}
+		_, err := fmt.Fprintf(w, "done, %d blocks, head %s\n", res.Blocks, res.Head.String())
+		return err
+	}),
+}
+
+// emitChainArchiveProgress returns a progress callback for the archiver that
+// emits the progress every chainArchiveProgressInterval blocks.
+func emitChainArchiveProgress(re cmds.ResponseEmitter) func(uint64) {
+	return func(blocks uint64) {
+		if blocks%chainArchiveProgressInterval == 0 {
+			re.Emit(&ChainArchiveProgress{Blocks: blocks}) // nolint: errcheck
+		}
+	}
+}
+
+var chainExportCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Export the blockchain to a CAR file",
+		ShortDescription: `
+Writes the blocks from the head to genesis, with their messages and receipts,
+to a CAR file at the given path, which is relative to the working directory of
+the daemon. With --state the state trees the blocks refer to are written too.
+The file can be imported into another node with the chain import command.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("file", true, false, "Path of the CAR file to write"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("state", "Include the state trees in the export"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		includeState, _ := req.Options["state"].(bool)
+
+		out, err := os.Create(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		defer out.Close() // nolint: errcheck
+
+		var blocks uint64
+		progress := emitChainArchiveProgress(re)
+		err = GetPorcelainAPI(env).ChainExport(req.Context, out, includeState, func(n uint64) {
+			blocks = n
+			progress(n)
+		})
+		if err != nil {
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		return re.Emit(&ChainArchiveProgress{Blocks: blocks, Done: true})
+	},
+	Type:     ChainArchiveProgress{},
+	Encoders: chainArchiveProgressEncoders,
+}
+
+var chainImportCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Import the blockchain from a CAR file",
+		ShortDescription: `
+Reads a CAR file written by the chain export command and adds the chain in it
+to the node. Every tipset is validated and processed as if it came from the
+network, so the state trees in the file are not trusted. The chain must have
+the genesis block of the node. The head of the node moves to the head of the
+chain imported if it is heavier.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.FileArg("file", true, false, "Path to the CAR file to import").EnableStdin(),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		iter := req.Files.Entries()
+		if !iter.Next() {
+			return fmt.Errorf("no file given: %s", iter.Err())
+		}
+
+		fi, ok := iter.Node().(files.File)
+		if !ok {
+			return fmt.Errorf("given file was not a files.File")
+		}
+
+		var blocks uint64
+		progress := emitChainArchiveProgress(re)
+		head, err := GetPorcelainAPI(env).ChainImport(req.Context, fi, func(n uint64) {
+			blocks = n
+			progress(n)
+		})
+		if err != nil {
+			return err
+		}
+		return re.Emit(&ChainArchiveProgress{Blocks: blocks, Done: true, Head: head})
+	},
+	Type:     ChainArchiveProgress{},
+	Encoders: chainArchiveProgressEncoders,
+}
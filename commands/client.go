@@ -3,12 +3,15 @@ package commands
 import (
 	"fmt"
 	"io"
+	"os"
 	"strconv"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-cmdkit"
 	"github.com/ipfs/go-ipfs-cmds"
 	"github.com/ipfs/go-ipfs-files"
+	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/porcelain"
@@ -37,38 +40,150 @@ var clientCatCmd = &cmds.Command{
 Prints data from the storage market specified with a given CID to stdout. The
 only argument should be the CID to return. The data will be returned in whatever
 format was provided with the data initially.
+`,
+		LongDescription: `
+Prints data from the storage market specified with a given CID to stdout. The
+only argument should be the CID to return. The data will be returned in whatever
+format was provided with the data initially.
+
+Data stored in the local node is read out directly. Otherwise the piece is
+retrieved from the miner given with --miner, or if not given from the miner of
+a deal for the piece made by this node that has been posted to the chain. The
+number of bytes read so far is reported on stderr.
 `,
 	},
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("cid", true, false, "CID of data to read"),
 	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("miner", "Address of the miner to retrieve the data from if it is not stored locally"),
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		c, err := cid.Decode(req.Arguments[0])
 		if err != nil {
 			return err
 		}
 
-		dr, err := GetPorcelainAPI(env).DAGCat(req.Context, c)
+		local, err := GetPorcelainAPI(env).DAGHasLocal(req.Context, c)
 		if err != nil {
 			return err
 		}
+		if local {
+			size, err := GetPorcelainAPI(env).DAGGetFileSize(req.Context, c)
+			if err != nil {
+				return err
+			}
+			dr, err := GetPorcelainAPI(env).DAGCat(req.Context, c)
+			if err != nil {
+				return err
+			}
+			re.SetLength(size)
+			return re.Emit(dr)
+		}
+
+		var minerAddr address.Address
+		if o, ok := req.Options["miner"].(string); ok && o != "" {
+			if minerAddr, err = address.NewFromString(o); err != nil {
+				return errors.Wrap(err, "invalid miner address")
+			}
+		} else {
+			minerAddr, err = GetPorcelainAPI(env).DealFindPieceMiner(c)
+			if err != nil {
+				return errors.Wrap(err, "data is not stored locally and no miner was given to retrieve it from")
+			}
+		}
 
-		return re.Emit(dr)
+		mpid, err := GetPorcelainAPI(env).MinerGetPeerID(req.Context, minerAddr)
+		if err != nil {
+			return err
+		}
+		readCloser, err := GetRetrievalAPI(env).RetrievePiece(req.Context, c, mpid, minerAddr)
+		if err != nil {
+			return err
+		}
+		return re.Emit(readCloser)
+	},
+	PostRun: cmds.PostRunMap{
+		cmds.CLI: func(res cmds.Response, re cmds.ResponseEmitter) error {
+			v, err := res.Next()
+			if err != nil {
+				return err
+			}
+			r, ok := v.(io.Reader)
+			if !ok {
+				return fmt.Errorf("unexpected type %T", v)
+			}
+			return re.Emit(&progressReader{r: r, total: res.Length(), w: os.Stderr})
+		},
 	},
 }
 
+// catProgressInterval is the number of bytes between the progress reports of
+// client cat.
+const catProgressInterval = 1 << 20
+
+// progressReader reports on w the number of bytes read from r every
+// catProgressInterval bytes and once r is exhausted. total is the number of
+// bytes r holds, or 0 if unknown.
+type progressReader struct {
+	r     io.Reader
+	total uint64
+	w     io.Writer
+
+	read     uint64
+	reported uint64
+	done     bool
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.read += uint64(n)
+	if err == io.EOF && !pr.done {
+		pr.done = true
+		fmt.Fprintf(pr.w, "\r%s\n", pr.status()) // nolint: errcheck
+	} else if pr.read-pr.reported >= catProgressInterval {
+		pr.reported = pr.read
+		fmt.Fprintf(pr.w, "\r%s", pr.status()) // nolint: errcheck
+	}
+	return n, err
+}
+
+func (pr *progressReader) status() string {
+	if pr.total == 0 {
+		return fmt.Sprintf("%d bytes", pr.read)
+	}
+	return fmt.Sprintf("%d / %d bytes", pr.read, pr.total)
+}
+
+// ClientImportResult is the result of importing data into the node.
+type ClientImportResult struct {
+	Cid cid.Cid
+	// Size is the size in bytes of the imported data, and PieceSize the size
+	// it takes in a sector once bit-padded.
+	Size      uint64
+	PieceSize uint64
+}
+
 var clientImportDataCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Import data into the local node",
 		ShortDescription: `
 Imports data previously exported with the client cat command into the storage
 market. This command takes only one argument, the path of the file to import.
-See the go-filecoin client cat command for more details.
+The file is chunked into a UnixFS DAG stored in the node, whose root CID can be
+given to 'client propose-storage-deal'. See the go-filecoin client cat command
+for more details.
+
+With --size, the size of the data and the size of the piece it makes once
+padded for sealing are printed after the CID. The piece must fit in a sector.
 `,
 	},
 	Arguments: []cmdkit.Argument{
 		cmdkit.FileArg("file", true, false, "Path to file to import").EnableStdin(),
 	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("size", "Also print the size of the data and of its padded piece"),
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		iter := req.Files.Entries()
 		if !iter.Next() {
@@ -85,12 +200,28 @@ See the go-filecoin client cat command for more details.
 			return err
 		}
 
-		return re.Emit(out.Cid())
+		size, err := GetPorcelainAPI(env).DAGGetFileSize(req.Context, out.Cid())
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&ClientImportResult{
+			Cid:       out.Cid(),
+			Size:      size,
+			PieceSize: types.PaddedPieceSize(size),
+		})
 	},
-	Type: cid.Cid{},
+	Type: ClientImportResult{},
 	Encoders: cmds.EncoderMap{
-		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
-			return PrintString(w, c)
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *ClientImportResult) error {
+			if err := PrintString(w, res.Cid); err != nil {
+				return err
+			}
+			if size, _ := req.Options["size"].(bool); !size {
+				return nil
+			}
+			_, err := fmt.Fprintf(w, "Size:\t\t%d bytes\nPiece size:\t%d bytes\n", res.Size, res.PieceSize)
+			return err
 		}),
 	},
 }
@@ -202,18 +333,38 @@ var clientListAsksCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "List all asks in the storage market",
 		ShortDescription: `
-Lists all asks in the storage market. This command takes no arguments. Results
-will be returned as a space separated table with miner, id, price and expiration
-respectively.
+Lists all asks in the storage market, ordered by increasing price. This command
+takes no arguments. Results will be returned as a space separated table with
+miner, id, price and expiration respectively. With --check-reachability, each
+miner is pinged over the network and the table gets a last column telling
+whether the miner is reachable or unreachable.
 `,
 	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("check-reachability", "Ping the miners and tell whether they are reachable"),
+		cmdkit.StringOption("ping-timeout", "How long to wait for a miner to answer a ping").WithDefault("5s"),
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		asksCh := GetPorcelainAPI(env).ClientListAsks(req.Context)
 
+		var asks []porcelain.Ask
 		for a := range asksCh {
 			if a.Error != nil {
 				return a.Error
 			}
+			asks = append(asks, a)
+		}
+		porcelain.SortAsks(asks)
+
+		if check, _ := req.Options["check-reachability"].(bool); check {
+			timeout, err := time.ParseDuration(req.Options["ping-timeout"].(string))
+			if err != nil {
+				return errors.Wrap(err, "invalid ping timeout")
+			}
+			GetPorcelainAPI(env).ClientCheckReachability(req.Context, asks, timeout)
+		}
+
+		for _, a := range asks {
 			if err := re.Emit(a); err != nil {
 				return err
 			}
@@ -223,8 +374,15 @@ respectively.
 	Type: porcelain.Ask{},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ask *porcelain.Ask) error {
-			fmt.Fprintf(w, "%s %.3d %s %s\n", ask.Miner, ask.ID, ask.Price, ask.Expiry) // nolint: errcheck
-			return nil
+			reachability := ""
+			if ask.Reachable != nil {
+				reachability = " unreachable"
+				if *ask.Reachable {
+					reachability = " reachable"
+				}
+			}
+			_, err := fmt.Fprintf(w, "%s %.3d %s %s%s\n", ask.Miner, ask.ID, NewFormatter(req).FIL(ask.Price), ask.Expiry, reachability)
+			return err
 		}),
 	},
 }
@@ -254,20 +412,16 @@ var paymentsCmd = &cmds.Command{
 	Type: []*types.PaymentVoucher{},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, vouchers []*types.PaymentVoucher) error {
-			if _, err := fmt.Println("Channel\tAmount\tValidAt\tEncoded Voucher"); err != nil {
-				return err
-			}
+			f := NewFormatter(req)
+			table := f.NewTable(w, "Channel", "Amount", "ValidAt", "Encoded Voucher")
 			for _, voucher := range vouchers {
 				encodedVoucher, err := voucher.Encode()
 				if err != nil {
 					return err
 				}
-				_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", voucher.Channel.String(), voucher.Amount.String(), voucher.ValidAt.String(), encodedVoucher)
-				if err != nil {
-					return err
-				}
+				table.Row(voucher.Channel.String(), f.FIL(&voucher.Amount), voucher.ValidAt.String(), encodedVoucher)
 			}
-			return nil
+			return table.Flush()
 		}),
 	},
 }
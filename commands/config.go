@@ -69,6 +69,14 @@ $ go-filecoin config bootstrap
 	"minPeerThreshold": 0,
 	"period": "5m"
 }
+
+Values are validated before being saved, e.g. addresses must be multiaddrs and
+periods durations like "1m30s". Most changes take effect when the daemon is
+next started, but some are applied to the running daemon right away:
+
+	api.address          the API moves to the new address
+	mining               the message wait of the running mining worker
+	mpool                the limits of the message pool
 `,
 	},
 	Arguments: []cmdkit.Argument{
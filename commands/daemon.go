@@ -2,11 +2,14 @@ package commands
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"net/http"
 	_ "net/http/pprof" // nolint: golint
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -18,7 +21,9 @@ import (
 	"github.com/multiformats/go-multiaddr-net"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/api"
 	"github.com/filecoin-project/go-filecoin/config"
+	"github.com/filecoin-project/go-filecoin/metrics"
 	"github.com/filecoin-project/go-filecoin/mining"
 	"github.com/filecoin-project/go-filecoin/node"
 	"github.com/filecoin-project/go-filecoin/paths"
@@ -39,6 +44,9 @@ var daemonCmd = &cmds.Command{
 		cmdkit.BoolOption(ELStdout),
 		cmdkit.BoolOption(IsRelay, "advertise and allow filecoin network traffic to be relayed through this node"),
 		cmdkit.StringOption(BlockTime, "time a node waits before trying to mine the next block").WithDefault(mining.DefaultBlockTime.String()),
+		cmdkit.BoolOption(RepairRepo, "repair the problems the repo integrity check finds before starting"),
+		cmdkit.StringOption(CheckpointTipSet, "comma separated block cids of a trusted tipset to sync the chain back to instead of genesis"),
+		cmdkit.StringOption(CheckpointStateRoot, "state root resulting from applying the checkpoint tipset"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		return daemonRun(req, re, env)
@@ -46,9 +54,45 @@ var daemonCmd = &cmds.Command{
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.Encoders[cmds.Text],
 	},
+	Subcommands: map[string]*cmds.Command{
+		"perf-report": daemonPerfReportCmd,
+	},
+}
+
+var daemonPerfReportCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Report how long slow-prone operations of the running daemon take",
+		ShortDescription: `
+Reports aggregated durations of operations that are sensitive to hardware and
+configuration, such as state tree loads, datastore writes and proof
+verification. Each operation taking longer than its threshold is also logged
+as a warning when it happens. Results will be returned as a space separated
+table with operation, count, slow count, threshold, mean and max duration
+respectively.
+`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		for _, report := range metrics.SlowOpReports() {
+			if err := re.Emit(report); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: metrics.SlowOpReport{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, report *metrics.SlowOpReport) error {
+			f := NewFormatter(req)
+			_, err := fmt.Fprintf(w, "%s %d %d %s %s %s\n", report.Name, report.Count, report.SlowCount, f.Duration(report.Threshold), f.Duration(report.Mean), f.Duration(report.Max))
+			return err
+		}),
+	},
 }
 
 func daemonRun(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+	// Let log tail stream the messages logged.
+	metrics.InstallLogBus()
+
 	// third precedence is config file.
 	rep, err := getRepo(req)
 	if err != nil {
@@ -73,6 +117,24 @@ func daemonRun(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment)
 		rep.Config().Swarm.PublicRelayAddress = publicRelayAddress
 	}
 
+	if checkpointTipSet, ok := req.Options[CheckpointTipSet].(string); ok && checkpointTipSet != "" {
+		rep.Config().Sync.CheckpointTipSet = checkpointTipSet
+		rep.Config().Sync.CheckpointStateRoot, _ = req.Options[CheckpointStateRoot].(string)
+	}
+
+	repair, _ := req.Options[RepairRepo].(bool)
+	problems, err := node.CheckRepo(req.Context, rep, repair)
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		if problem.Repaired {
+			re.Emit(fmt.Sprintf("Repaired repo: %s\n", problem.Description)) // nolint: errcheck
+		} else {
+			re.Emit(fmt.Sprintf("Repo problem: %s (run with --%s to repair)\n", problem.Description, RepairRepo)) // nolint: errcheck
+		}
+	}
+
 	opts, err := node.OptionsFromRepo(rep)
 	if err != nil {
 		return err
@@ -149,10 +211,67 @@ func runAPIAndWait(ctx context.Context, nd *node.Node, config *config.Config, re
 	cfg.SetAllowedMethods(config.API.AccessControlAllowMethods...)
 	cfg.SetAllowCredentials(config.API.AccessControlAllowCredentials)
 
+	authSecret, err := hex.DecodeString(config.API.AuthSecret)
+	if err != nil {
+		return errors.Wrap(err, "invalid API auth secret")
+	}
+
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(sigCh)
 
-	maddr, err := ma.NewMultiaddr(config.API.Address)
+	handler := http.NewServeMux()
+	handler.Handle("/debug/pprof/", http.DefaultServeMux)
+	cmdHandler := http.Handler(cmdhttp.NewHandler(servenv, rootCmdDaemon, cfg))
+	if len(authSecret) > 0 {
+		// the CLI authenticates with the tokens of the JSON-RPC API too, so
+		// that neither API lets a token run commands beyond its scope
+		cmdHandler = api.NewGuard(rootCmdDaemon, APIPrefix, authSecret, commandPermission, cmdHandler)
+		handler.Handle(api.RPCPath, api.NewHandler(rootCmdDaemon, servenv, authSecret, commandPermission))
+	}
+	handler.Handle(APIPrefix+"/", cmdHandler)
+
+	apiserv := &apiServer{
+		server: &http.Server{
+			Handler: handler,
+		},
+		repo: nd.Repo,
+	}
+	if err := apiserv.listen(config.API.Address); err != nil {
+		return err
+	}
+	config.API.Address = apiserv.address()
+
+	// move the API to the new address when api.address is set
+	nd.PorcelainAPI.ConfigOnChange("api.address", apiserv.applyConfig)
+
+	signal := <-sigCh
+	fmt.Printf("Got %s, shutting down...\n", signal)
+
+	// allow 5 seconds for clean shutdown. Ideally it would never take this long.
+	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	if err := apiserv.server.Shutdown(ctx); err != nil {
+		fmt.Println("failed to shut down api server:", err)
+	}
+
+	return nil
+}
+
+// apiServer serves the API of the daemon on a listener that can be moved to
+// another address while the daemon runs.
+type apiServer struct {
+	server *http.Server
+	repo   repo.Repo
+
+	lk  sync.Mutex
+	lis manet.Listener
+}
+
+// listen serves the API on addr, stops listening on the previous address and
+// writes the resolved address to the repo.
+func (s *apiServer) listen(addr string) error {
+	maddr, err := ma.NewMultiaddr(addr)
 	if err != nil {
 		return err
 	}
@@ -160,42 +279,57 @@ func runAPIAndWait(ctx context.Context, nd *node.Node, config *config.Config, re
 	// For the case when /ip4/127.0.0.1/tcp/0 is passed,
 	// we want to fetch the new multiaddr from the listener, as it may (should)
 	// have resolved to some other value. i.e. resolve port zero to real value.
-	apiLis, err := manet.Listen(maddr)
+	lis, err := manet.Listen(maddr)
 	if err != nil {
 		return err
 	}
-	config.API.Address = apiLis.Multiaddr().String()
-
-	handler := http.NewServeMux()
-	handler.Handle("/debug/pprof/", http.DefaultServeMux)
-	handler.Handle(APIPrefix+"/", cmdhttp.NewHandler(servenv, rootCmdDaemon, cfg))
-
-	apiserv := http.Server{
-		Handler: handler,
-	}
 
-	go func() {
-		err := apiserv.Serve(manet.NetListener(apiLis))
-		if err != nil && err != http.ErrServerClosed {
-			panic(err)
+	s.lk.Lock()
+	prev := s.lis
+	s.lis = lis
+	s.lk.Unlock()
+
+	go s.serve(lis)
+	if prev != nil {
+		// requests in flight on the previous listener, like the one moving
+		// the API, are still served to completion
+		if err := prev.Close(); err != nil {
+			return errors.Wrap(err, "failed to close the previous API listener")
 		}
-	}()
+	}
 
 	// write our api address to file
-	if err := nd.Repo.SetAPIAddr(config.API.Address); err != nil {
+	if err := s.repo.SetAPIAddr(lis.Multiaddr().String()); err != nil {
 		return errors.Wrap(err, "Could not save API address to repo")
 	}
+	return nil
+}
 
-	signal := <-sigCh
-	fmt.Printf("Got %s, shutting down...\n", signal)
-
-	// allow 5 seconds for clean shutdown. Ideally it would never take this long.
-	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
-	defer cancel()
+func (s *apiServer) serve(lis manet.Listener) {
+	err := s.server.Serve(manet.NetListener(lis))
+	if err == nil || err == http.ErrServerClosed {
+		return
+	}
 
-	if err := apiserv.Shutdown(ctx); err != nil {
-		fmt.Println("failed to shut down api server:", err)
+	s.lk.Lock()
+	replaced := lis != s.lis
+	s.lk.Unlock()
+	if !replaced {
+		panic(err)
 	}
+}
 
-	return nil
+// address returns the address the API is served on.
+func (s *apiServer) address() string {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	return s.lis.Multiaddr().String()
+}
+
+// applyConfig moves the API to the address of cfg, if it changed.
+func (s *apiServer) applyConfig(cfg *config.Config) error {
+	if cfg.API.Address == s.address() {
+		return nil
+	}
+	return s.listen(cfg.API.Address)
 }
@@ -124,3 +124,14 @@ func TestDaemonOverHttp(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, http.StatusNotFound, res.StatusCode)
 }
+
+func TestDaemonPerfReport(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(t).Start()
+	defer d.ShutdownSuccess()
+
+	out := d.RunSuccess("daemon", "perf-report").ReadStdout()
+	assert.Contains(t, out, "chain/put_tipset_and_state")
+	assert.Contains(t, out, "state/load_tree")
+}
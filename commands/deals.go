@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	cmdkit "github.com/ipfs/go-ipfs-cmdkit"
+	cmds "github.com/ipfs/go-ipfs-cmds"
+
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+)
+
+var dealsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Inspect storage deals",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"show": dealsShowCmd,
+	},
+}
+
+var dealsShowCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the details of a storage deal",
+		ShortDescription: `
+Shows the state of a storage deal this node made, as a client or as a miner,
+given the CID of the deal's proposal.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("dealCid", true, false, "CID of the deal proposal"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		dealCid, err := cid.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		deal := GetPorcelainAPI(env).DealGet(dealCid)
+		if deal == nil {
+			return fmt.Errorf("no deal with proposal CID %s", dealCid.String())
+		}
+
+		return re.Emit(deal)
+	},
+	Type: storagedeal.Deal{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, deal *storagedeal.Deal) error {
+			f := NewFormatter(req)
+			fmt.Fprintf(w, "Proposal: %s\n", deal.Response.ProposalCid) // nolint: errcheck
+			fmt.Fprintf(w, "State:    %s\n", deal.Response.State)       // nolint: errcheck
+			if deal.Response.Message != "" {
+				fmt.Fprintf(w, "Message:  %s\n", deal.Response.Message) // nolint: errcheck
+			}
+			fmt.Fprintf(w, "Miner:    %s\n", deal.Miner)                          // nolint: errcheck
+			fmt.Fprintf(w, "Client:   %s\n", deal.Proposal.Payment.Payer)         // nolint: errcheck
+			fmt.Fprintf(w, "Piece:    %s\n", deal.Proposal.PieceRef)              // nolint: errcheck
+			fmt.Fprintf(w, "Size:     %s\n", f.Size(deal.Proposal.Size.Uint64())) // nolint: errcheck
+			fmt.Fprintf(w, "Duration: %d\n", deal.Proposal.Duration)              // nolint: errcheck
+			fmt.Fprintf(w, "Final:    %t\n", deal.Response.State.IsFinal())       // nolint: errcheck
+			return nil
+		}),
+	},
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs-cmds"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// cborReturnTypes are the types of the CBOR encoded values actor methods
+// return as bytes, by actor code and method name.
+var cborReturnTypes = map[cid.Cid]map[string]reflect.Type{
+	types.PaymentBrokerActorCodeCid: {
+		"history": reflect.TypeOf([]*paymentbroker.Redemption{}),
+		"ls":      reflect.TypeOf(map[string]*paymentbroker.PaymentChannel{}),
+		"voucher": reflect.TypeOf(types.PaymentVoucher{}),
+	},
+	types.MinerActorCodeCid: {
+		"getAsk": reflect.TypeOf(miner.Ask{}),
+	},
+	types.BootstrapMinerActorCodeCid: {
+		"getAsk": reflect.TypeOf(miner.Ask{}),
+	},
+}
+
+// formatActorReturn formats a value returned by the method of an actor with
+// the given code. Bytes holding a CBOR encoded value are decoded, so that
+// they encode to readable JSON, other values are formatted as by
+// formatActorCallValue.
+func formatActorReturn(code cid.Cid, method string, val *abi.Value) (interface{}, error) {
+	if t, ok := cborReturnTypes[code][method]; ok && val.Type == abi.Bytes {
+		decoded := reflect.New(t)
+		if err := cbor.DecodeInto(val.Val.([]byte), decoded.Interface()); err != nil {
+			return nil, errors.Wrapf(err, "unable to decode return value of %s", method)
+		}
+		return decoded.Elem().Interface(), nil
+	}
+	return formatActorCallValue(val), nil
+}
+
+// applyDefaultEncoders gives every command under cmd without a JSON encoder
+// of its own jsonEncoder, so that --enc=json prints the results of all
+// commands the same way. Commands without a text encoder print JSON with
+// --enc=text too.
+func applyDefaultEncoders(cmd *cmds.Command) {
+	if cmd.Run != nil {
+		if cmd.Encoders == nil {
+			cmd.Encoders = cmds.EncoderMap{}
+		}
+		if _, ok := cmd.Encoders[cmds.JSON]; !ok {
+			cmd.Encoders[cmds.JSON] = jsonEncoder
+		}
+	}
+	for _, sub := range cmd.Subcommands {
+		applyDefaultEncoders(sub)
+	}
+}
+
+// jsonEncoder writes each result as JSON on a line of its own, so that the
+// results of commands emitting several can be read one at a time.
+var jsonEncoder = cmds.MakeEncoder(func(req *cmds.Request, w io.Writer, v interface{}) error {
+	marshaled, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(marshaled, '\n'))
+	return err
+})
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/address"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestFormatActorReturn(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("decodes the channels of the payment broker", func(t *testing.T) {
+		channels := map[string]*paymentbroker.PaymentChannel{
+			types.NewChannelID(1).KeyString(): {
+				Target:         address.TestAddress,
+				Amount:         types.NewAttoFILFromFIL(10),
+				AmountRedeemed: types.NewAttoFILFromFIL(1),
+				AgreedEol:      types.NewBlockHeight(100),
+				Eol:            types.NewBlockHeight(100),
+			},
+		}
+		raw, err := actor.MarshalStorage(channels)
+		require.NoError(t, err)
+
+		r, err := formatActorReturn(types.PaymentBrokerActorCodeCid, "ls", &abi.Value{Type: abi.Bytes, Val: raw})
+		require.NoError(t, err)
+		require.IsType(t, map[string]*paymentbroker.PaymentChannel{}, r)
+		decoded := r.(map[string]*paymentbroker.PaymentChannel)
+		require.Len(t, decoded, 1)
+		assert.Equal(t, types.NewAttoFILFromFIL(10), decoded[types.NewChannelID(1).KeyString()].Amount)
+
+		marshaled, err := json.Marshal(r)
+		require.NoError(t, err)
+		assert.Contains(t, string(marshaled), address.TestAddress.String())
+	})
+
+	t.Run("decodes asks of miners", func(t *testing.T) {
+		ask := miner.Ask{Price: types.NewAttoFILFromFIL(2), Expiry: types.NewBlockHeight(10), ID: big.NewInt(1)}
+		raw, err := actor.MarshalStorage(ask)
+		require.NoError(t, err)
+
+		r, err := formatActorReturn(types.MinerActorCodeCid, "getAsk", &abi.Value{Type: abi.Bytes, Val: raw})
+		require.NoError(t, err)
+		assert.Equal(t, ask, r)
+	})
+
+	t.Run("formats other values", func(t *testing.T) {
+		r, err := formatActorReturn(types.MinerActorCodeCid, "getKey", &abi.Value{Type: abi.Bytes, Val: []byte{0xab}})
+		require.NoError(t, err)
+		assert.Equal(t, "ab", r)
+
+		r, err = formatActorReturn(types.PaymentBrokerActorCodeCid, "ls", &abi.Value{Type: abi.Integer, Val: big.NewInt(3)})
+		require.NoError(t, err)
+		assert.Equal(t, "3", r)
+	})
+
+	t.Run("fails on bytes that are not the CBOR of the return type", func(t *testing.T) {
+		_, err := formatActorReturn(types.PaymentBrokerActorCodeCid, "ls", &abi.Value{Type: abi.Bytes, Val: []byte{0xff}})
+		assert.Error(t, err)
+	})
+}
+
+func TestJSONEncoder(t *testing.T) {
+	tf.UnitTest(t)
+
+	var buf bytes.Buffer
+	enc := jsonEncoder(nil)(&buf)
+	require.NoError(t, enc.Encode(map[string]int{"a": 1}))
+	require.NoError(t, enc.Encode("b"))
+	assert.Equal(t, "{\"a\":1}\n\"b\"\n", buf.String())
+}
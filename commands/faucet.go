@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"io"
+
+	"github.com/ipfs/go-cid"
+	cmdkit "github.com/ipfs/go-ipfs-cmdkit"
+	cmds "github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+)
+
+var faucetCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Fund your wallets on test networks",
+		ShortDescription: `
+Test networks may have a faucet, which sends a fixed amount of FIL to each
+address that asks for it, at most once per period of blocks.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"tap": faucetTapCmd,
+	},
+}
+
+var faucetTapCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Ask the faucet of the network to fund an address",
+		ShortDescription: `Issues a new message to the network asking the faucet to fund <address>, or the
+default wallet address if omitted. The faucet sends nothing to an address it
+funded less than a period ago. The message may be sent with --gas-price 0, so
+that new wallets without funds can tap the faucet.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("address", false, false, "Address to fund"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		addr := address.Undef
+		if len(req.Arguments) > 0 {
+			var err error
+			addr, err = address.NewFromString(req.Arguments[0])
+			if err != nil {
+				return errors.Wrap(err, "invalid address")
+			}
+		}
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		c, err := GetPorcelainAPI(env).FaucetTap(req.Context, fromAddr, addr, gasPrice, gasLimit)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
+}
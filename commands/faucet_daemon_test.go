@@ -0,0 +1,17 @@
+package commands_test
+
+import (
+	"testing"
+
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestFaucetTapWithoutFaucet(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(t).Start()
+	defer d.ShutdownSuccess()
+
+	d.RunFail("this network has no faucet", "faucet", "tap", "--gas-price", "0", "--gas-limit", "300")
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cmds "github.com/ipfs/go-ipfs-cmds"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Formatter formats the values Text encoders print. By default it formats
+// them for people to read: FIL amounts have grouped digits and a unit, sizes
+// and durations are rounded to a readable unit and tables are aligned. With
+// --no-humanize it prints the raw values instead, for scripts to parse.
+type Formatter struct {
+	raw bool
+}
+
+// NewFormatter returns a formatter honouring the --no-humanize option of req.
+func NewFormatter(req *cmds.Request) *Formatter {
+	raw, _ := req.Options[NoHumanize].(bool)
+	return &Formatter{raw: raw}
+}
+
+// FIL formats an amount of FIL.
+func (f *Formatter) FIL(amt *types.AttoFIL) string {
+	if amt == nil {
+		amt = types.NewZeroAttoFIL()
+	}
+	if f.raw {
+		return amt.String()
+	}
+
+	whole, frac := amt.String(), ""
+	if i := strings.IndexByte(whole, '.'); i >= 0 {
+		whole, frac = whole[:i], whole[i:]
+	}
+	return groupDigits(whole) + frac + " FIL"
+}
+
+var sizeUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// Size formats a number of bytes.
+func (f *Formatter) Size(bytes uint64) string {
+	if f.raw {
+		return fmt.Sprintf("%d", bytes)
+	}
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	size, unit := float64(bytes), 0
+	for size >= 1024 && unit < len(sizeUnits)-1 {
+		size /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %s", size, sizeUnits[unit])
+}
+
+// Duration formats a duration. Durations of a second or more are rounded
+// to the second, shorter ones to the microsecond.
+func (f *Formatter) Duration(d time.Duration) string {
+	if f.raw {
+		return d.String()
+	}
+	if d >= time.Second || d <= -time.Second {
+		return d.Round(time.Second).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
+
+// NewTable returns a table writing rows to w under the given header. The
+// header is left out if it is empty.
+func (f *Formatter) NewTable(w io.Writer, header ...string) *Table {
+	t := &Table{sw: NewSilentWriter(w)}
+	if !f.raw {
+		t.tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		t.sw = NewSilentWriter(t.tw)
+	}
+	if len(header) > 0 {
+		t.Row(header...)
+	}
+	return t
+}
+
+// Table writes rows of columns. Humanized tables are aligned with spaces
+// once flushed, raw tables are tab separated.
+type Table struct {
+	tw *tabwriter.Writer
+	sw *SilentWriter
+}
+
+// Row adds a row to the table.
+func (t *Table) Row(cols ...string) {
+	t.sw.Println(strings.Join(cols, "\t"))
+}
+
+// Flush writes out the table and returns the first error encountered while
+// writing it.
+func (t *Table) Flush() error {
+	if err := t.sw.Error(); err != nil {
+		return err
+	}
+	if t.tw != nil {
+		return t.tw.Flush()
+	}
+	return nil
+}
+
+// groupDigits separates the thousands of a string of decimal digits with
+// commas.
+func groupDigits(digits string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= 3 {
+		return sign + digits
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	first := len(digits) % 3
+	if first == 0 {
+		first = 3
+	}
+	b.WriteString(digits[:first])
+	for i := first; i < len(digits); i += 3 {
+		b.WriteByte(',')
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-ipfs-cmdkit"
+	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestFormatter(t *testing.T) {
+	tf.UnitTest(t)
+
+	human := NewFormatter(&cmds.Request{Options: cmdkit.OptMap{}})
+	raw := NewFormatter(&cmds.Request{Options: cmdkit.OptMap{NoHumanize: true}})
+
+	t.Run("FIL", func(t *testing.T) {
+		amt, ok := types.NewAttoFILFromFILString("9999900000.5")
+		require.True(t, ok)
+
+		assert.Equal(t, "9,999,900,000.5 FIL", human.FIL(amt))
+		assert.Equal(t, "9999900000.5", raw.FIL(amt))
+		assert.Equal(t, "0 FIL", human.FIL(types.NewZeroAttoFIL()))
+		assert.Equal(t, "100 FIL", human.FIL(types.NewAttoFILFromFIL(100)))
+		assert.Equal(t, "1,000 FIL", human.FIL(types.NewAttoFILFromFIL(1000)))
+	})
+
+	t.Run("Size", func(t *testing.T) {
+		assert.Equal(t, "512 B", human.Size(512))
+		assert.Equal(t, "1.0 KiB", human.Size(1024))
+		assert.Equal(t, "256.0 MiB", human.Size(256<<20))
+		assert.Equal(t, "268435456", raw.Size(256<<20))
+	})
+
+	t.Run("Duration", func(t *testing.T) {
+		assert.Equal(t, "2m0s", human.Duration(120*time.Second))
+		assert.Equal(t, "2s", human.Duration(1600*time.Millisecond))
+		assert.Equal(t, "1.235ms", human.Duration(1234567*time.Nanosecond))
+		assert.Equal(t, "1.234567ms", raw.Duration(1234567*time.Nanosecond))
+	})
+
+	t.Run("tables", func(t *testing.T) {
+		var out bytes.Buffer
+		table := human.NewTable(&out, "Channel", "Amount")
+		table.Row("7", "1 FIL")
+		table.Row("12", "100 FIL")
+		require.NoError(t, table.Flush())
+		assert.Equal(t, "Channel  Amount\n7        1 FIL\n12       100 FIL\n", out.String())
+
+		out.Reset()
+		table = raw.NewTable(&out, "Channel", "Amount")
+		table.Row("7", "1")
+		require.NoError(t, table.Flush())
+		assert.Equal(t, "Channel\tAmount\n7\t1\n", out.String())
+	})
+}
@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	cmdkit "github.com/ipfs/go-ipfs-cmdkit"
+	cmds "github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/gengen/util"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var genesisCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Create genesis blocks for new networks",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"create": genesisCreateCmd,
+	},
+}
+
+// GenesisCreateResult describes the genesis block created by genesis create.
+type GenesisCreateResult struct {
+	GenesisCid cid.Cid                    `json:"genesisCid"`
+	CarFile    string                     `json:"carFile"`
+	KeyFiles   []string                   `json:"keyFiles"`
+	Miners     []gengen.RenderedMinerInfo `json:"miners"`
+}
+
+var genesisCreateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Create a genesis block from a spec file",
+		ShortDescription: `
+Creates a genesis block with the accounts, miners and network parameters of the
+given spec file, and writes it as a CAR file for 'daemon --genesisfile'.
+`,
+		LongDescription: `
+Creates a genesis block with the accounts, miners and network parameters of the
+given JSON spec file, and writes it as a CAR file to be passed to
+'go-filecoin init --genesisfile' by every node of the network.
+
+The spec file has the following fields, all optional:
+
+  keys            the number of keys to generate
+  preAlloc        the balances in FIL of the generated keys, in order
+  accounts        existing accounts to fund, as {"address": ..., "balance": ...}
+  miners          miners to create, as {"owner": <key index>, "power": <sectors>,
+                  "peerId": ...}, with the given number of committed sectors
+  networkBalance  the balance in FIL of the network account paying block rewards
+  timestamp       the unix time in seconds of the genesis block
+
+For example:
+
+  {
+    "keys": 1,
+    "preAlloc": ["1000000"],
+    "accounts": [{"address": "fcq...", "balance": "5000"}],
+    "miners": [{"owner": 0, "power": 10}]
+  }
+
+The generated keys are written to <keypath>/<index>.key, to be imported with
+'go-filecoin wallet import'. The genesis block is the same for the same spec
+file and seed.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("spec", true, false, "Path of the JSON spec file of the genesis block"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("out-car", "Path of the CAR file to write").WithDefault("genesis.car"),
+		cmdkit.StringOption("keypath", "Directory to write the generated keys to").WithDefault("."),
+		cmdkit.IntOption("seed", "Seed of the key generation, defaults to the current unix time"),
+		cmdkit.BoolOption("test-proofs-mode", "Make sealing, PoSt, etc. compatible with test environments"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		cfg, err := readGenesisSpec(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		cfg.ProofsMode = types.LiveProofsMode
+		if testProofs, _ := req.Options["test-proofs-mode"].(bool); testProofs {
+			cfg.ProofsMode = types.TestProofsMode
+		}
+
+		seed := time.Now().Unix()
+		if s, ok := req.Options["seed"].(int); ok {
+			seed = int64(s)
+		}
+
+		carPath, _ := req.Options["out-car"].(string)
+		out, err := os.Create(carPath)
+		if err != nil {
+			return errors.Wrap(err, "failed to create the CAR file")
+		}
+		defer out.Close() // nolint: errcheck
+
+		info, err := gengen.GenGenesisCar(cfg, out, seed)
+		if err != nil {
+			return errors.Wrap(err, "failed to create the genesis block")
+		}
+
+		keyPath, _ := req.Options["keypath"].(string)
+		var keyFiles []string
+		for i, ki := range info.Keys {
+			keyFile := filepath.Join(keyPath, fmt.Sprintf("%d.key", i))
+			if err := writeGenesisKey(keyFile, ki); err != nil {
+				return errors.Wrapf(err, "failed to write key %d", i)
+			}
+			keyFiles = append(keyFiles, keyFile)
+		}
+
+		return re.Emit(&GenesisCreateResult{
+			GenesisCid: info.GenesisCid,
+			CarFile:    carPath,
+			KeyFiles:   keyFiles,
+			Miners:     info.Miners,
+		})
+	},
+	Type: GenesisCreateResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *GenesisCreateResult) error {
+			if _, err := fmt.Fprintf(w, "created genesis block %s in %s\n", res.GenesisCid, res.CarFile); err != nil {
+				return err
+			}
+			for _, m := range res.Miners {
+				if _, err := fmt.Fprintf(w, "created miner %s, owned by key %d, power = %d\n", m.Address, m.Owner, m.Power); err != nil {
+					return err
+				}
+			}
+			for _, keyFile := range res.KeyFiles {
+				if _, err := fmt.Fprintf(w, "wrote key %s, import it with 'go-filecoin wallet import %s'\n", keyFile, keyFile); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+func readGenesisSpec(path string) (*gengen.GenesisCfg, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the spec file")
+	}
+	defer f.Close() // nolint: errcheck
+
+	var cfg gengen.GenesisCfg
+	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the spec file")
+	}
+	return &cfg, nil
+}
+
+// writeGenesisKey writes ki to path in the format of 'wallet export'.
+func writeGenesisKey(path string, ki *types.KeyInfo) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close() // nolint: errcheck
+
+	return json.NewEncoder(f).Encode(&WalletSerializeResult{KeyInfo: []*types.KeyInfo{ki}})
+}
@@ -0,0 +1,49 @@
+package commands_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestGenesisCreate(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	dir, err := ioutil.TempDir("", "genesis")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	addr := address.NewForTestGetter()()
+	spec := fmt.Sprintf(`{
+	"keys": 1,
+	"preAlloc": ["1000"],
+	"accounts": [{"address": %q, "balance": "42"}],
+	"miners": [{"owner": 0, "power": 2}]
+}`, addr)
+	specPath := filepath.Join(dir, "spec.json")
+	require.NoError(t, ioutil.WriteFile(specPath, []byte(spec), 0644))
+	carPath := filepath.Join(dir, "genesis.car")
+
+	out, err := exec.Command(th.MustGetFilecoinBinary(), "genesis", "create", specPath,
+		"--out-car", carPath, "--keypath", dir, "--seed", "1", "--test-proofs-mode").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Contains(t, string(out), "created genesis block")
+	assert.Contains(t, string(out), "owned by key 0, power = 2")
+	assert.FileExists(t, filepath.Join(dir, "0.key"))
+
+	d := th.NewDaemon(t, th.GenesisFile(carPath)).Start()
+	defer d.ShutdownSuccess()
+
+	balance := d.RunSuccess("wallet", "balance", "--no-humanize", addr.String())
+	assert.Equal(t, "42", balance.ReadStdoutTrimNewlines())
+}
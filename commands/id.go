@@ -20,6 +20,10 @@ type IDDetails struct {
 	AgentVersion    string
 	ProtocolVersion string
 	PublicKey       []byte // raw bytes
+	// Reachability is whether peers can dial the node: public, private
+	// (behind a NAT, dialed through relays) or unknown.
+	Reachability  string
+	PublicAddress ma.Multiaddr
 }
 
 var idCmd = &cmds.Command{
@@ -34,9 +38,13 @@ var idCmd = &cmds.Command{
 		addrs := GetPorcelainAPI(env).NetworkGetPeerAddresses()
 		hostID := GetPorcelainAPI(env).NetworkGetPeerID()
 
+		reachability, publicAddr := GetPorcelainAPI(env).NetworkGetReachability()
+
 		details := IDDetails{
-			Addresses: make([]ma.Multiaddr, len(addrs)),
-			ID:        hostID,
+			Addresses:     make([]ma.Multiaddr, len(addrs)),
+			ID:            hostID,
+			Reachability:  reachability,
+			PublicAddress: publicAddr,
 		}
 
 		for i, addr := range addrs {
@@ -82,6 +90,7 @@ func idFormatSubstitute(format string, val *IDDetails) string {
 	output = strings.Replace(output, "<pver>", val.ProtocolVersion, -1)
 	output = strings.Replace(output, "<pubkey>", base64.StdEncoding.EncodeToString(val.PublicKey), -1)
 	output = strings.Replace(output, "<addrs>", strings.Join(addrStrings, "\n"), -1)
+	output = strings.Replace(output, "<reach>", val.Reachability, -1)
 	output = strings.Replace(output, "\\n", "\n", -1)
 	output = strings.Replace(output, "\\t", "\t", -1)
 	return output
@@ -112,6 +121,12 @@ func (idd IDDetails) MarshalJSON() ([]byte, error) {
 		// This is what the built-in JSON encoder does to []byte too.
 		v["PublicKey"] = base64.StdEncoding.EncodeToString(idd.PublicKey)
 	}
+	if idd.Reachability != "" {
+		v["Reachability"] = idd.Reachability
+	}
+	if idd.PublicAddress != nil {
+		v["PublicAddress"] = idd.PublicAddress.String()
+	}
 	return json.Marshal(v)
 }
 
@@ -153,6 +168,19 @@ func (idd *IDDetails) UnmarshalJSON(data []byte) error {
 	if err := decode(v, "PublicKey", &idd.PublicKey); err != nil {
 		return err
 	}
+	if err := decode(v, "Reachability", &idd.Reachability); err != nil {
+		return err
+	}
+
+	var publicAddr string
+	if err := decode(v, "PublicAddress", &publicAddr); err != nil {
+		return err
+	}
+	if publicAddr != "" {
+		if idd.PublicAddress, err = ma.NewMultiaddr(publicAddr); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
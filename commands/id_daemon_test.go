@@ -41,6 +41,17 @@ func TestIdFormat(t *testing.T) {
 	assert.NotContains(t, idContent, "ID")
 }
 
+func TestIdReachability(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(t).Start()
+	defer d.ShutdownSuccess()
+
+	// with no peer to dial it back, the node can't tell its reachability
+	assert.Contains(t, d.RunSuccess("id").ReadStdout(), `"Reachability": "unknown"`)
+	assert.Equal(t, "unknown", d.RunSuccess("id", "--format=<reach>").ReadStdout())
+}
+
 func TestPersistId(t *testing.T) {
 	tf.IntegrationTest(t)
 
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -15,8 +16,10 @@ import (
 	cmdkit "github.com/ipfs/go-ipfs-cmdkit"
 	cmds "github.com/ipfs/go-ipfs-cmds"
 	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/api"
 	"github.com/filecoin-project/go-filecoin/config"
 	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/fixtures"
@@ -85,6 +88,12 @@ var initCmd = &cmds.Command{
 func getConfigFromOptions(options cmdkit.OptMap) (*config.Config, error) {
 	newConfig := config.NewDefaultConfig()
 
+	secret, err := api.NewSecret()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate API auth secret")
+	}
+	newConfig.API.AuthSecret = hex.EncodeToString(secret)
+
 	if dir, ok := options[OptionSectorDir].(string); ok {
 		newConfig.SectorBase.RootDir = dir
 	}
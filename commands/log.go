@@ -1,15 +1,21 @@
 package commands
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/ipfs/go-ipfs-cmdkit"
 	"github.com/ipfs/go-ipfs-cmds"
 	logging "github.com/ipfs/go-log"
-	writer "github.com/ipfs/go-log/writer"
+	"github.com/pkg/errors"
 	oldlogging "github.com/whyrusleeping/go-logging"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/metrics"
+	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 )
 
 var loglogger = logging.Logger("commands/log")
@@ -24,30 +30,49 @@ output of a running daemon.
 	},
 
 	Subcommands: map[string]*cmds.Command{
-		"level": logLevelCmd,
-		"ls":    logLsCmd,
-		"tail":  logTailCmd,
+		"level":     logLevelCmd,
+		"ls":        logLsCmd,
+		"subscribe": logSubscribeCmd,
+		"tail":      logTailCmd,
 	},
 }
 
 var logTailCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
-		Tagline: "Read the event log.",
+		Tagline: "Stream the messages the daemon logs.",
 		ShortDescription: `
-Outputs event log messages (not other log messages) as they are generated.
+Outputs the messages the daemon logs from now on, at --level or more severe and
+of the --system subsystem if given, e.g. mining, sync, sectorbuilder or deals.
+Only the messages logged at the current log level of their subsystem are
+output, see the log level command. Each line has the time, level, subsystem,
+message and source file, and each record has these fields with --enc=json.
 `,
 	},
-
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("level", "Least severe level of the messages to output, one of: debug, info, notice, warning, error, critical").WithDefault("debug"),
+		cmdkit.StringOption("system", "Only output the messages of this subsystem"),
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
-		r, w := io.Pipe()
-		go func() {
-			defer w.Close() // nolint: errcheck
-			<-req.Context.Done()
-		}()
-
-		writer.WriterGroup.AddWriter(w)
+		level, err := oldlogging.LogLevel(req.Options["level"].(string))
+		if err != nil {
+			return err
+		}
+		filter := metrics.LogFilter{Level: level}
+		filter.System, _ = req.Options["system"].(string)
 
-		return re.Emit(r)
+		for record := range metrics.SubscribeLogs(req.Context, filter) {
+			if err := re.Emit(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: metrics.LogRecord{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, record *metrics.LogRecord) error {
+			_, err := fmt.Fprintf(w, "%s %s %s: %s %s\n", record.Timestamp.Format(time.RFC3339Nano), record.Level, record.System, record.Message, record.File)
+			return err
+		}),
 	},
 }
 
@@ -123,6 +148,45 @@ subsystems of a running daemon.
 	},
 }
 
+var logSubscribeCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Stream the events actors emit.",
+		ShortDescription: `
+Outputs the events actors emit in the blocks added to the chain from now on,
+e.g. when a payment channel is redeemed. Each line has the block height, the
+emitting actor, the topic, the hex encoded payload and the message cid.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("actor", "Only output events emitted by the actor with this address"),
+		cmdkit.StringOption("topic", "Only output events with this topic"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		var filter msg.EventFilter
+		if o, ok := req.Options["actor"].(string); ok {
+			addr, err := address.NewFromString(o)
+			if err != nil {
+				return errors.Wrap(err, "invalid actor address")
+			}
+			filter.Emitter = addr
+		}
+		if topic, ok := req.Options["topic"].(string); ok {
+			filter.Topic = topic
+		}
+
+		return GetPorcelainAPI(env).MessageSubscribeEvents(req.Context, filter, func(event *msg.ChainEvent) error {
+			return re.Emit(event)
+		})
+	},
+	Type: msg.ChainEvent{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, event *msg.ChainEvent) error {
+			_, err := fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", event.Height, event.Event.Emitter, event.Event.Topic, hex.EncodeToString(event.Event.Payload), event.Message)
+			return err
+		}),
+	},
+}
+
 func getLogLevel(level string) (int, error) {
 	var lvl int
 	switch level {
@@ -2,8 +2,10 @@ package commands
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"syscall"
@@ -16,7 +18,9 @@ import (
 	"github.com/multiformats/go-multiaddr-net"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/api"
 	"github.com/filecoin-project/go-filecoin/paths"
+	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -81,6 +85,21 @@ const (
 	// IsRelay when set causes the the daemon to provide libp2p relay
 	// services allowing other filecoin nodes behind NATs to talk directly.
 	IsRelay = "is-relay"
+
+	// RepairRepo tells the daemon to repair the problems the repo integrity
+	// check finds at startup.
+	RepairRepo = "repair-repo"
+
+	// CheckpointTipSet sets the trusted tipset the daemon syncs the chain
+	// back to instead of genesis.
+	CheckpointTipSet = "checkpoint-tipset"
+
+	// CheckpointStateRoot sets the state root of the checkpoint tipset.
+	CheckpointStateRoot = "checkpoint-state-root"
+
+	// NoHumanize makes the text output of commands print raw values instead
+	// of formatting them for people to read.
+	NoHumanize = "no-humanize"
 )
 
 // command object for the local cli
@@ -93,6 +112,7 @@ START RUNNING FILECOIN
   go-filecoin config <key> [<value>] - Get and set filecoin config values
   go-filecoin daemon                 - Start a long-running daemon process
   go-filecoin wallet                 - Manage your filecoin wallets
+  go-filecoin faucet                 - Fund your wallets on test networks
   go-filecoin address                - Interact with addresses
 
 STORE AND RETRIEVE DATA
@@ -126,9 +146,13 @@ MESSAGE COMMANDS
   go-filecoin mpool                  - Manage the message pool
 
 TOOL COMMANDS
+  go-filecoin auth                   - Manage the authentication to the JSON-RPC API
+  go-filecoin genesis                - Create genesis blocks for new networks
   go-filecoin inspect                - Show info about the go-filecoin node
   go-filecoin log                    - Interact with the daemon event log output
+  go-filecoin proofs                 - Manage the proof parameters and benchmark the proofs
   go-filecoin protocol               - Show protocol parameter details
+  go-filecoin repo                   - Check the integrity of the repo
   go-filecoin version                - Show go-filecoin version information
 `,
 	},
@@ -136,6 +160,7 @@ TOOL COMMANDS
 		cmdkit.StringOption(OptionAPI, "set the api port to use"),
 		cmdkit.StringOption(OptionRepoDir, "set the repo directory, defaults to ~/.filecoin/repo"),
 		cmds.OptionEncodingType,
+		cmdkit.BoolOption(NoHumanize, "Print raw values, such as amounts in FIL and sizes in bytes, in text output."),
 		cmdkit.BoolOption("help", "Show the full command help text."),
 		cmdkit.BoolOption("h", "Show a short version of the command help text."),
 	},
@@ -150,7 +175,10 @@ var rootCmdDaemon = &cmds.Command{
 // all top level commands, not available to daemon
 var rootSubcmdsLocal = map[string]*cmds.Command{
 	"daemon":  daemonCmd,
+	"genesis": genesisCmd,
 	"init":    initCmd,
+	"proofs":  proofsCmd,
+	"repo":    repoCmd,
 	"version": versionCmd,
 }
 
@@ -158,13 +186,16 @@ var rootSubcmdsLocal = map[string]*cmds.Command{
 var rootSubcmdsDaemon = map[string]*cmds.Command{
 	"actor":            actorCmd,
 	"address":          addrsCmd,
+	"auth":             authCmd,
 	"bitswap":          bitswapCmd,
 	"bootstrap":        bootstrapCmd,
 	"chain":            chainCmd,
 	"config":           configCmd,
 	"client":           clientCmd,
 	"dag":              dagCmd,
+	"deals":            dealsCmd,
 	"dht":              dhtCmd,
+	"faucet":           faucetCmd,
 	"id":               idCmd,
 	"inspect":          inspectCmd,
 	"log":              logCmd,
@@ -172,14 +203,17 @@ var rootSubcmdsDaemon = map[string]*cmds.Command{
 	"miner":            minerCmd,
 	"mining":           miningCmd,
 	"mpool":            mpoolCmd,
+	"multisig":         multisigCmd,
 	"outbox":           outboxCmd,
 	"paych":            paymentChannelCmd,
 	"ping":             pingCmd,
 	"protocol":         protocolCmd,
 	"retrieval-client": retrievalClientCmd,
 	"show":             showCmd,
+	"state":            stateCmd,
 	"stats":            statsCmd,
 	"swarm":            swarmCmd,
+	"vesting":          vestingCmd,
 	"wallet":           walletCmd,
 }
 
@@ -192,6 +226,14 @@ func init() {
 		rootCmd.Subcommands[k] = v
 		rootCmdDaemon.Subcommands[k] = v
 	}
+
+	// The daemon command itself only runs locally, but its subcommands
+	// query the running daemon.
+	rootCmdDaemon.Subcommands["daemon"] = &cmds.Command{
+		Subcommands: daemonCmd.Subcommands,
+	}
+
+	applyDefaultEncoders(rootCmd)
 }
 
 // Run processes the arguments and stdin
@@ -211,8 +253,9 @@ func buildEnv(ctx context.Context, req *cmds.Request) (cmds.Environment, error)
 }
 
 type executor struct {
-	api  string
-	exec cmds.Executor
+	api   string
+	token string
+	exec  cmds.Executor
 }
 
 func (e *executor) Execute(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
@@ -220,7 +263,10 @@ func (e *executor) Execute(req *cmds.Request, re cmds.ResponseEmitter, env cmds.
 		return e.exec.Execute(req, re, env)
 	}
 
-	client := cmdhttp.NewClient(e.api, cmdhttp.ClientWithAPIPrefix(APIPrefix))
+	client := cmdhttp.NewClient(e.api,
+		cmdhttp.ClientWithAPIPrefix(APIPrefix),
+		cmdhttp.ClientWithHTTPClient(&http.Client{Transport: &tokenTransport{token: e.token}}),
+	)
 
 	res, err := client.Send(req)
 	if err != nil {
@@ -230,6 +276,12 @@ func (e *executor) Execute(req *cmds.Request, re cmds.ResponseEmitter, env cmds.
 		return cmdkit.Errorf(cmdkit.ErrFatal, err.Error())
 	}
 
+	// let the command process the result on the client side, e.g. to report
+	// progress, before it is emitted
+	if postRun := req.Command.PostRun[cmds.CLI]; postRun != nil {
+		return re.CloseWithError(postRun(res, re))
+	}
+
 	// copy received result into cli emitter
 	err = cmds.Copy(re, res)
 	if err != nil {
@@ -240,13 +292,17 @@ func (e *executor) Execute(req *cmds.Request, re cmds.ResponseEmitter, env cmds.
 
 func makeExecutor(req *cmds.Request, env interface{}) (cmds.Executor, error) {
 	isDaemonRequired := requiresDaemon(req)
-	var api string
+	var api, token string
 	if isDaemonRequired {
 		var err error
 		api, err = getAPIAddress(req)
 		if err != nil {
 			return nil, err
 		}
+		token, err = getAPIToken(req)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if api == "" && isDaemonRequired {
@@ -254,11 +310,63 @@ func makeExecutor(req *cmds.Request, env interface{}) (cmds.Executor, error) {
 	}
 
 	return &executor{
-		api:  api,
-		exec: cmds.NewExecutor(rootCmd),
+		api:   api,
+		token: token,
+		exec:  cmds.NewExecutor(rootCmd),
 	}, nil
 }
 
+// getAPIToken returns the token the CLI authenticates to the daemon with:
+// the one in the FIL_API_TOKEN env var, else an admin token signed with the
+// auth secret of the local repo. Without either, requests carry no token,
+// which daemons without an auth secret accept.
+func getAPIToken(req *cmds.Request) (string, error) {
+	if envtoken := os.Getenv("FIL_API_TOKEN"); envtoken != "" {
+		return envtoken, nil
+	}
+
+	repoDir, _ := req.Options[OptionRepoDir].(string)
+	repoDir, err := paths.GetRepoPath(repoDir)
+	if err != nil {
+		return "", err
+	}
+	cfg, err := repo.ConfigFromRepoPath(repoDir)
+	if err != nil {
+		// the daemon may run on another host, leave it to reject the
+		// request if it needs a token
+		return "", nil
+	}
+	secret, err := hex.DecodeString(cfg.API.AuthSecret)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid API auth secret")
+	}
+	if len(secret) == 0 {
+		return "", nil
+	}
+	return api.CreateToken(secret, api.AllPermissions)
+}
+
+// tokenTransport authenticates the requests of the CLI to the daemon with a
+// token.
+type tokenTransport struct {
+	token string
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if t.token == "" {
+		return http.DefaultTransport.RoundTrip(r)
+	}
+	// a RoundTripper must not modify the request it is given
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Header = make(http.Header, len(r.Header)+1)
+	for k, v := range r.Header {
+		r2.Header[k] = v
+	}
+	r2.Header.Set("Authorization", "Bearer "+t.token)
+	return http.DefaultTransport.RoundTrip(r2)
+}
+
 func getAPIAddress(req *cmds.Request) (string, error) {
 	var rawAddr string
 	var err error
@@ -299,10 +407,15 @@ func getAPIAddress(req *cmds.Request) (string, error) {
 }
 
 func requiresDaemon(req *cmds.Request) bool {
-	for _, cmd := range rootSubcmdsLocal {
+	for name, cmd := range rootSubcmdsLocal {
 		if req.Command == cmd {
 			return false
 		}
+		// The subcommands of local commands run locally too, except those of
+		// the daemon command.
+		if name != "daemon" && len(req.Path) > 1 && req.Path[0] == name {
+			return false
+		}
 	}
 	return true
 }
@@ -325,19 +438,27 @@ func isConnectionRefused(err error) bool {
 	return syscallErr.Err == syscall.ECONNREFUSED
 }
 
-var priceOption = cmdkit.StringOption("gas-price", "Price (FIL e.g. 0.00013) to pay for each GasUnits consumed mining this message")
+var priceOption = cmdkit.StringOption("gas-price", "Price (FIL e.g. 0.00013) to pay for each GasUnits consumed mining this message, by default a price suggested to get it mined within a few blocks")
 var limitOption = cmdkit.Uint64Option("gas-limit", "Maximum number of GasUnits this message is allowed to consume")
 var previewOption = cmdkit.BoolOption("preview", "Preview the Gas cost of this command without actually executing it")
 
-func parseGasOptions(req *cmds.Request) (types.AttoFIL, types.GasUnits, bool, error) {
-	priceOption := req.Options["gas-price"]
-	if priceOption == nil {
-		return types.AttoFIL{}, types.NewGasUnits(0), false, errors.New("price option is required")
-	}
-
-	price, ok := types.NewAttoFILFromFILString(priceOption.(string))
-	if !ok {
-		return types.AttoFIL{}, types.NewGasUnits(0), false, errors.New("invalid gas price (specify FIL as a decimal number)")
+// parseGasOptions parses the gas price, gas limit and preview options. Without
+// a gas price, the price the gas oracle suggests for inclusion within
+// msg.DefaultGasPriceTarget blocks is used.
+func parseGasOptions(req *cmds.Request, env cmds.Environment) (types.AttoFIL, types.GasUnits, bool, error) {
+	var price types.AttoFIL
+	if priceOption := req.Options["gas-price"]; priceOption != nil {
+		parsed, ok := types.NewAttoFILFromFILString(priceOption.(string))
+		if !ok {
+			return types.AttoFIL{}, types.NewGasUnits(0), false, errors.New("invalid gas price (specify FIL as a decimal number)")
+		}
+		price = *parsed
+	} else {
+		suggested, err := GetPorcelainAPI(env).MessageGasPrice(req.Context, msg.DefaultGasPriceTarget)
+		if err != nil {
+			return types.AttoFIL{}, types.NewGasUnits(0), false, errors.Wrap(err, "failed to suggest a gas price")
+		}
+		price = suggested
 	}
 
 	limitOption := req.Options["gas-limit"]
@@ -353,5 +474,5 @@ func parseGasOptions(req *cmds.Request) (types.AttoFIL, types.GasUnits, bool, er
 
 	preview, _ := req.Options["preview"].(bool)
 
-	return *price, types.NewGasUnits(gasLimitInt), preview, nil
+	return price, types.NewGasUnits(gasLimitInt), preview, nil
 }
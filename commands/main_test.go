@@ -20,4 +20,8 @@ func TestRequiresDaemon(t *testing.T) {
 
 	assert.True(t, requiresDaemon(reqWithDaemon))
 	assert.False(t, requiresDaemon(reqWithoutDaemon))
+
+	reqLocalSubcommand, err := cmds.NewRequest(context.Background(), []string{"proofs", "fetch-params"}, nil, []string{}, nil, rootCmd)
+	assert.NoError(t, err)
+	assert.False(t, requiresDaemon(reqLocalSubcommand))
 }
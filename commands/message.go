@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-cmdkit"
@@ -18,6 +19,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/plumbing/bcf"
 	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
 )
 
 var msgCmd = &cmds.Command{
@@ -25,6 +27,7 @@ var msgCmd = &cmds.Command{
 		Tagline: "Send and monitor messages",
 	},
 	Subcommands: map[string]*cmds.Command{
+		"replay": msgReplayCmd,
 		"send":   msgSendCmd,
 		"status": msgStatusCmd,
 		"wait":   msgWaitCmd,
@@ -36,23 +39,38 @@ type MessageSendResult struct {
 	Cid     cid.Cid
 	GasUsed types.GasUnits
 	Preview bool
+	// Receipt is the receipt of the message, if the command waited for it.
+	Receipt *types.MessageReceipt `json:",omitempty"`
 }
 
 var msgSendCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Send a message", // This feels too generic...
+		ShortDescription: `
+Constructs a message transferring value to the target actor and invoking
+method on it, signs it with the from address and sends it to the network.
+Params are parsed according to the method's signature. Without a method the
+message is a plain value transfer.
+
+The nonce of the message is the next nonce of the from actor, unless given
+with --nonce, in which case it must follow the nonces of the messages from
+the actor waiting in the outbox. With --wait, the command waits for the
+message to be executed in a block and prints its receipt too.
+`,
 	},
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("target", true, false, "Address of the actor to send the message to"),
 		cmdkit.StringArg("method", false, false, "The method to invoke on the target actor"),
+		cmdkit.StringArg("params", false, true, "Parameters of the method"),
 	},
 	Options: []cmdkit.Option{
 		cmdkit.StringOption("value", "Value to send with message in FIL"),
 		cmdkit.StringOption("from", "Address to send message from"),
+		cmdkit.Uint64Option("nonce", "Nonce of the message, instead of the next nonce of the from actor"),
+		cmdkit.BoolOption("wait", "Wait for the message to be executed and print its receipt"),
 		priceOption,
 		limitOption,
 		previewOption,
-		// TODO: (per dignifiedquire) add an option to set the nonce and method explicitly
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		target, err := address.NewFromString(req.Arguments[0])
@@ -79,14 +97,26 @@ var msgSendCmd = &cmds.Command{
 			}
 		}
 
-		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
 		if err != nil {
 			return err
 		}
 
-		method, ok := req.Options["method"].(string)
-		if !ok {
-			method = ""
+		method := ""
+		var params []interface{}
+		if len(req.Arguments) > 1 {
+			method = req.Arguments[1]
+
+			sig, err := GetPorcelainAPI(env).ActorGetSignature(req.Context, target, method)
+			if err != nil {
+				return errors.Wrap(err, "could not get method signature")
+			}
+
+			vals, err := abi.ParseValues(req.Arguments[2:], sig.Params)
+			if err != nil {
+				return err
+			}
+			params = abi.FromValues(vals)
 		}
 
 		if preview {
@@ -95,6 +125,7 @@ var msgSendCmd = &cmds.Command{
 				fromAddr,
 				target,
 				method,
+				params...,
 			)
 			if err != nil {
 				return err
@@ -106,24 +137,56 @@ var msgSendCmd = &cmds.Command{
 			})
 		}
 
-		c, err := GetPorcelainAPI(env).MessageSendWithDefaultAddress(
-			req.Context,
-			fromAddr,
-			target,
-			val,
-			gasPrice,
-			gasLimit,
-			method,
-		)
+		var c cid.Cid
+		if nonce, ok := req.Options["nonce"].(uint64); ok {
+			if fromAddr.Empty() {
+				if fromAddr, err = GetPorcelainAPI(env).WalletDefaultAddress(); err != nil {
+					return err
+				}
+			}
+			c, err = GetPorcelainAPI(env).MessageSendWithNonce(
+				req.Context,
+				fromAddr,
+				target,
+				val,
+				gasPrice,
+				gasLimit,
+				nonce,
+				method,
+				params...,
+			)
+		} else {
+			c, err = GetPorcelainAPI(env).MessageSendWithDefaultAddress(
+				req.Context,
+				fromAddr,
+				target,
+				val,
+				gasPrice,
+				gasLimit,
+				method,
+				params...,
+			)
+		}
 		if err != nil {
 			return err
 		}
 
-		return re.Emit(&MessageSendResult{
+		res := &MessageSendResult{
 			Cid:     c,
 			GasUsed: types.NewGasUnits(0),
 			Preview: false,
-		})
+		}
+		if wait, _ := req.Options["wait"].(bool); wait {
+			err = GetPorcelainAPI(env).MessageWait(req.Context, c, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+				res.Receipt = receipt
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return re.Emit(res)
 	},
 	Type: &MessageSendResult{},
 	Encoders: cmds.EncoderMap{
@@ -133,16 +196,32 @@ var msgSendCmd = &cmds.Command{
 				_, err := w.Write([]byte(output))
 				return err
 			}
-			return PrintString(w, res.Cid)
+			if err := PrintString(w, res.Cid); err != nil {
+				return err
+			}
+			if res.Receipt == nil {
+				return nil
+			}
+			marshaled, err := appendJSON(res.Receipt, []byte{})
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(marshaled)
+			return err
 		}),
 	},
 }
 
 // WaitResult is the result of a message wait call.
 type WaitResult struct {
-	Message   *types.SignedMessage
-	Receipt   *types.MessageReceipt
+	Message *types.SignedMessage
+	Receipt *types.MessageReceipt
+	// TipSet is the tipset the message was executed in.
+	TipSet    types.SortedCidSet
 	Signature *exec.FunctionSignature
+	// Return is the value the message returned, formatted as by
+	// formatActorReturn, if it succeeded and returned one.
+	Return interface{} `json:",omitempty"`
 }
 
 var msgWaitCmd = &cmds.Command{
@@ -156,6 +235,7 @@ var msgWaitCmd = &cmds.Command{
 		cmdkit.BoolOption("message", "Print the whole message").WithDefault(true),
 		cmdkit.BoolOption("receipt", "Print the whole message receipt").WithDefault(true),
 		cmdkit.BoolOption("return", "Print the return value from the receipt").WithDefault(false),
+		cmdkit.UintOption("confidence", "Number of tipsets to wait for on top of the tipset executing the message (default: mpool.waitConfidence)"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		msgCid, err := cid.Parse(req.Arguments[0])
@@ -163,22 +243,40 @@ var msgWaitCmd = &cmds.Command{
 			return errors.Wrap(err, "invalid cid "+req.Arguments[0])
 		}
 
+		var confidence uint64
+		if o, ok := req.Options["confidence"].(uint); ok {
+			confidence = uint64(o)
+		} else {
+			cfg, err := GetPorcelainAPI(env).ConfigGet("mpool.waitConfidence")
+			if err != nil {
+				return err
+			}
+			confidence = cfg.(uint64)
+		}
+
 		fmt.Printf("waiting for: %s\n", req.Arguments[0])
 
 		found := false
-		err = GetPorcelainAPI(env).MessageWait(req.Context, msgCid, func(blk *types.Block, msg *types.SignedMessage, receipt *types.MessageReceipt) error {
-			found = true
-			sig, err := GetPorcelainAPI(env).ActorGetSignature(req.Context, msg.To, msg.Method)
+		err = GetPorcelainAPI(env).MessageWaitConfirmed(req.Context, msgCid, confidence, func(chainMsg *msg.ChainMessage) error {
+			sig, err := GetPorcelainAPI(env).ActorGetSignature(req.Context, chainMsg.Message.To, chainMsg.Message.Method)
 			if err != nil && err != bcf.ErrNoMethod && err != bcf.ErrNoActorImpl {
 				return errors.Wrap(err, "Couldn't get signature for message")
 			}
 
 			res := WaitResult{
-				Message: msg,
-				Receipt: receipt,
+				Message: chainMsg.Message,
+				Receipt: chainMsg.Receipt,
+				TipSet:  chainMsg.TipSet.ToSortedCidSet(),
 				// Signature is required to decode the output.
 				Signature: sig,
 			}
+			if sig != nil && len(sig.Return) > 0 && chainMsg.Receipt.ExitCode == 0 && len(chainMsg.Receipt.Return) > 0 {
+				res.Return, err = waitReturn(req, env, chainMsg, sig)
+				if err != nil {
+					return err
+				}
+			}
+			found = true
 			re.Emit(&res) // nolint: errcheck
 
 			return nil
@@ -212,13 +310,19 @@ var msgWaitCmd = &cmds.Command{
 				}
 			}
 
-			if returnOpt && res.Receipt != nil && res.Signature != nil {
-				val, err := abi.Deserialize(res.Receipt.Return[0], res.Signature.Return[0])
-				if err != nil {
-					return errors.Wrap(err, "unable to deserialize return value")
-				}
+			if !receiptOpt && res.Receipt != nil && res.Receipt.ExitCode != 0 {
+				marshaled = append(marshaled, []byte(formatReceiptStatus(res.Receipt)+"\n")...)
+			}
 
-				marshaled = append(marshaled, []byte(val.Val.(fmt.Stringer).String())...)
+			if returnOpt && res.Return != nil {
+				if s, ok := res.Return.(string); ok {
+					marshaled = append(marshaled, []byte(s)...)
+				} else {
+					marshaled, err = appendJSON(res.Return, marshaled)
+					if err != nil {
+						return err
+					}
+				}
 			}
 
 			_, err = w.Write(marshaled)
@@ -227,6 +331,20 @@ var msgWaitCmd = &cmds.Command{
 	},
 }
 
+// waitReturn decodes the first value the message returned.
+func waitReturn(req *cmds.Request, env cmds.Environment, chainMsg *msg.ChainMessage, sig *exec.FunctionSignature) (interface{}, error) {
+	val, err := abi.Deserialize(chainMsg.Receipt.Return[0], sig.Return[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to deserialize return value")
+	}
+
+	act, err := GetPorcelainAPI(env).ActorGet(req.Context, chainMsg.Message.To)
+	if err != nil {
+		return nil, err
+	}
+	return formatActorReturn(act.Code, chainMsg.Message.Method, val)
+}
+
 // MessageStatusResult is the status of a message on chain or in the message queue/pool
 type MessageStatusResult struct {
 	InPool    bool // Whether the message is found in the mpool
@@ -293,7 +411,10 @@ var msgStatusCmd = &cmds.Command{
 			}
 			if res.OnChain {
 				msg = res.ChainMsg.Message
-				sw.Printf("On chain at height %d, receipt %v\n", res.ChainMsg.Block.Height, res.ChainMsg.Receipt)
+				sw.Printf("On chain at height %d\n", res.ChainMsg.Block.Height)
+				if res.ChainMsg.Receipt != nil {
+					sw.Println(formatReceiptStatus(res.ChainMsg.Receipt))
+				}
 			}
 			if msg != nil {
 				sw.Println(msg.String())
@@ -303,6 +424,18 @@ var msgStatusCmd = &cmds.Command{
 	},
 }
 
+// formatReceiptStatus describes the exit code of a receipt and the error the
+// message failed with, if any.
+func formatReceiptStatus(receipt *types.MessageReceipt) string {
+	if receipt.ExitCode == 0 {
+		return "Exit code: 0"
+	}
+	if receipt.Error == "" {
+		return fmt.Sprintf("Exit code: %d", receipt.ExitCode)
+	}
+	return fmt.Sprintf("Exit code: %d (%s)", receipt.ExitCode, receipt.Error)
+}
+
 func appendJSON(val interface{}, out []byte) ([]byte, error) {
 	m, err := json.MarshalIndent(val, "", "\t")
 	if err != nil {
@@ -312,3 +445,86 @@ func appendJSON(val interface{}, out []byte) ([]byte, error) {
 	out = append(out, byte('\n'))
 	return out, nil
 }
+
+var msgReplayCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Re-execute a message that is on chain",
+		ShortDescription: `
+Re-executes a message that is on chain against the state of the parent of the
+tipset it was executed in and prints its receipt. Nothing is stored. With
+--trace it also prints every message sent, gas charged and chunk of actor
+storage read or written while executing it.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("cid", true, false, "CID of the message to replay"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("trace", "Print what the VM did while executing the message"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		msgCid, err := cid.Parse(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid cid "+req.Arguments[0])
+		}
+
+		replay, err := GetPorcelainAPI(env).MessageReplay(req.Context, msgCid)
+		if err != nil {
+			return err
+		}
+
+		if trace, _ := req.Options["trace"].(bool); !trace {
+			replay.Trace = nil
+		}
+
+		return re.Emit(replay)
+	},
+	Type: msg.Replay{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, replay *msg.Replay) error {
+			f := NewFormatter(req)
+			sw := NewSilentWriter(w)
+
+			sw.Printf("Block:     %s\n", replay.Block)
+			sw.Printf("Exit code: %d\n", replay.Receipt.ExitCode)
+			sw.Printf("Gas:       %s\n", f.FIL(replay.Receipt.GasAttoFIL))
+			if replay.ExecutionError != "" {
+				sw.Printf("Error:     %s\n", replay.ExecutionError)
+			}
+
+			if len(replay.Trace) > 0 {
+				sw.Println("Trace:")
+			}
+			for _, event := range replay.Trace {
+				sw.Printf("%s%s\n", strings.Repeat("  ", event.Depth+1), formatTraceEvent(f, event))
+			}
+
+			return sw.Error()
+		}),
+	},
+}
+
+func formatTraceEvent(f *Formatter, event *vm.TraceEvent) string {
+	var out string
+	switch event.Kind {
+	case vm.TraceSend:
+		msg := event.Message
+		method := msg.Method
+		if method == "" {
+			method = "<transfer>"
+		}
+		out = fmt.Sprintf("send %s -> %s %s value %s", msg.From, msg.To, method, f.FIL(msg.Value))
+	case vm.TraceReturn:
+		out = fmt.Sprintf("return %d", event.ExitCode)
+	case vm.TraceCharge:
+		out = fmt.Sprintf("charge %d", event.Gas)
+	case vm.TraceStorageGet, vm.TraceStoragePut:
+		out = fmt.Sprintf("%s %s (%s)", event.Kind, event.Chunk, f.Size(uint64(event.Size)))
+	default:
+		out = string(event.Kind)
+	}
+	if event.Error != "" {
+		out += ": " + event.Error
+	}
+	return out
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/commands"
 	"github.com/filecoin-project/go-filecoin/fixtures"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
@@ -70,6 +71,53 @@ func TestMessageSend(t *testing.T) {
 	)
 }
 
+func TestMessageSendNonceAndWait(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := makeTestDaemonWithMinerAndStart(t)
+	defer d.ShutdownSuccess()
+
+	t.Run("waits for the receipt", func(t *testing.T) {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out := d.RunSuccess(
+				"message", "send",
+				"--from", fixtures.TestAddresses[0],
+				"--gas-price", "1", "--gas-limit", "300",
+				"--value=10", "--wait", "--enc=json",
+				fixtures.TestAddresses[1],
+			).ReadStdoutTrimNewlines()
+
+			var res commands.MessageSendResult
+			require.NoError(t, json.Unmarshal([]byte(out), &res))
+			require.NotNil(t, res.Receipt)
+			assert.Equal(t, uint8(0), res.Receipt.ExitCode)
+		}()
+
+		d.RunSuccess("mpool", "ls", "--wait-for-count=1")
+		d.RunSuccess("mining", "once")
+		wg.Wait()
+	})
+
+	t.Run("a nonce not following the outbox is refused", func(t *testing.T) {
+		d.RunSuccess(
+			"message", "send",
+			"--from", fixtures.TestAddresses[0],
+			"--gas-price", "1", "--gas-limit", "300",
+			fixtures.TestAddresses[1],
+		)
+		d.RunFail("Invalid nonce",
+			"message", "send",
+			"--from", fixtures.TestAddresses[0],
+			"--gas-price", "1", "--gas-limit", "300",
+			"--nonce", "1000",
+			fixtures.TestAddresses[1],
+		)
+	})
+}
+
 func TestMessageWait(t *testing.T) {
 	tf.IntegrationTest(t)
 
@@ -179,6 +227,7 @@ func TestMessageStatus(t *testing.T) {
 		assert.NotContains(t, status, "In mpool")
 		assert.Contains(t, status, "On chain")
 		assert.Contains(t, status, "1234") // the "value"
+		assert.Contains(t, status, "Exit code: 0")
 
 		status = d.RunSuccess("message", "status", "QmPVkJMTeRC6iBByPWdrRkD3BE5UXsj5HPzb4kPqL186mS").ReadStdout()
 		assert.NotContains(t, status, "In outbox")
@@ -186,3 +235,37 @@ func TestMessageStatus(t *testing.T) {
 		assert.NotContains(t, status, "On chain")
 	})
 }
+
+func TestMessageReplay(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := makeTestDaemonWithMinerAndStart(t)
+	defer d.ShutdownSuccess()
+
+	msgcid := d.RunSuccess(
+		"message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--gas-price", "1", "--gas-limit", "300",
+		"--value=10",
+		fixtures.TestAddresses[1],
+	).ReadStdoutTrimNewlines()
+
+	t.Run("fails for messages that are not on chain", func(t *testing.T) {
+		d.RunFail("not found on chain", "message", "replay", msgcid)
+	})
+
+	d.RunSuccess("mining", "once")
+
+	t.Run("prints the receipt", func(t *testing.T) {
+		out := d.RunSuccess("message", "replay", msgcid).ReadStdout()
+		assert.Contains(t, out, "Exit code: 0")
+		assert.NotContains(t, out, "Trace:")
+	})
+
+	t.Run("prints the trace", func(t *testing.T) {
+		out := d.RunSuccess("message", "replay", "--trace", msgcid).ReadStdout()
+		assert.Contains(t, out, "Trace:")
+		assert.Contains(t, out, "send "+fixtures.TestAddresses[0]+" -> "+fixtures.TestAddresses[1]+" <transfer>")
+		assert.Contains(t, out, "return 0")
+	})
+}
@@ -12,8 +12,10 @@ import (
 	"github.com/libp2p/go-libp2p-peer"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -22,12 +24,19 @@ var minerCmd = &cmds.Command{
 		Tagline: "Manage a single miner actor",
 	},
 	Subcommands: map[string]*cmds.Command{
+		"ask":           minerAskCmd,
+		"change-worker": minerChangeWorkerCmd,
 		"create":        minerCreateCmd,
+		"deals":         minerDealsCmd,
+		"info":          minerInfoCmd,
 		"owner":         minerOwnerCmd,
+		"payments":      minerPaymentsCmd,
 		"pledge":        minerPledgeCmd,
 		"power":         minerPowerCmd,
+		"sectors":       minerSectorsCmd,
 		"set-price":     minerSetPriceCmd,
 		"update-peerid": minerUpdatePeerIDCmd,
+		"worker":        minerWorkerCmd,
 	},
 }
 
@@ -61,6 +70,120 @@ var minerPledgeCmd = &cmds.Command{
 		str := fmt.Sprintf("%d", pledgeSectors) // nolint: govet
 		return re.Emit(str)
 	},
+	Subcommands: map[string]*cmds.Command{
+		"add":      minerPledgeAddCmd,
+		"withdraw": minerPledgeWithdrawCmd,
+	},
+}
+
+var minerPledgeAddCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Pledge <sectors> more sectors, locking up <collateral> more FIL",
+		ShortDescription: `Issues a new message to the network to increase the pledge of the node's miner,
+or of the given miner. The miner's collateral, including the <collateral> sent,
+must be greater than 0.001 FIL per pledged sector.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("sectors", true, false, "The number of sectors to add to the pledge"),
+		cmdkit.StringArg("collateral", true, false, "The amount of collateral in FIL to be sent"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("miner", "The address of the miner whose pledge to increase"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		sectors, err := strconv.ParseUint(req.Arguments[0], 10, 64)
+		if err != nil {
+			return ErrInvalidPledge
+		}
+
+		collateral, ok := types.NewAttoFILFromFILString(req.Arguments[1])
+		if !ok {
+			return ErrInvalidCollateral
+		}
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		minerAddr, err := optionalAddr(req.Options["miner"])
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		c, err := GetPorcelainAPI(env).MinerAddPledge(req.Context, fromAddr, minerAddr, gasPrice, gasLimit, sectors, collateral)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
+}
+
+var minerPledgeWithdrawCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Withdraw <sectors> sectors from the pledge, releasing their collateral",
+		ShortDescription: `Issues a new message to the network to decrease the pledge of the node's miner,
+or of the given miner. Collateral no longer needed is returned to the miner's
+owner. The pledge cannot drop below the number of sectors the miner has committed.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("sectors", true, false, "The number of sectors to remove from the pledge"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("miner", "The address of the miner whose pledge to decrease"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		sectors, err := strconv.ParseUint(req.Arguments[0], 10, 64)
+		if err != nil {
+			return ErrInvalidPledge
+		}
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		minerAddr, err := optionalAddr(req.Options["miner"])
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		c, err := GetPorcelainAPI(env).MinerWithdrawPledge(req.Context, fromAddr, minerAddr, gasPrice, gasLimit, sectors)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
 }
 
 // MinerCreateResult is the type returned when creating a miner.
@@ -84,6 +207,7 @@ Collateral must be greater than 0.001 FIL per pledged sector.`,
 	Options: []cmdkit.Option{
 		cmdkit.StringOption("from", "Address to send from"),
 		cmdkit.StringOption("peerid", "Base58-encoded libp2p peer ID that the miner will operate"),
+		cmdkit.StringOption("worker", "Address whose key will sign the miner's blocks and PoSts (defaults to the sending address)"),
 		priceOption,
 		limitOption,
 		previewOption,
@@ -96,6 +220,11 @@ Collateral must be greater than 0.001 FIL per pledged sector.`,
 			return err
 		}
 
+		workerAddr, err := optionalAddr(req.Options["worker"])
+		if err != nil {
+			return err
+		}
+
 		var pid peer.ID
 		peerid := req.Options["peerid"]
 		if peerid != nil {
@@ -118,7 +247,7 @@ Collateral must be greater than 0.001 FIL per pledged sector.`,
 			return ErrInvalidCollateral
 		}
 
-		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
 		if err != nil {
 			return err
 		}
@@ -144,6 +273,7 @@ Collateral must be greater than 0.001 FIL per pledged sector.`,
 		addr, err := GetPorcelainAPI(env).MinerCreate(
 			req.Context,
 			fromAddr,
+			workerAddr,
 			gasPrice,
 			gasLimit,
 			pledge,
@@ -221,7 +351,7 @@ This command waits for the ask to be mined.`,
 			return fmt.Errorf("expiry must be a valid integer")
 		}
 
-		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
 		if err != nil {
 			return err
 		}
@@ -321,7 +451,7 @@ var minerUpdatePeerIDCmd = &cmds.Command{
 			return err
 		}
 
-		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
 		if err != nil {
 			return err
 		}
@@ -378,6 +508,373 @@ var minerUpdatePeerIDCmd = &cmds.Command{
 	},
 }
 
+var minerAskCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage the asks of a miner",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls": minerAskLsCmd,
+		"rm": minerAskRmCmd,
+	},
+}
+
+var minerDealsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage the storage deals of a miner",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls": minerDealsLsCmd,
+	},
+}
+
+var minerDealsLsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the storage deals this node has made as a miner",
+		ShortDescription: `
+Lists the storage deals made with the node's miner, or with the given miner.
+Results will be returned as a space separated table with proposal cid, state,
+piece cid and client payer address respectively.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("miner", "The address of the miner whose deals to list"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := optionalAddr(req.Options["miner"])
+		if err != nil {
+			return err
+		}
+
+		deals, err := GetPorcelainAPI(env).MinerListDeals(minerAddr)
+		if err != nil {
+			return err
+		}
+
+		for _, deal := range deals {
+			if err := re.Emit(deal); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: storagedeal.Deal{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, deal *storagedeal.Deal) error {
+			fmt.Fprintf(w, "%s %s %s %s\n", deal.Response.ProposalCid, deal.Response.State, deal.Proposal.PieceRef, deal.Proposal.Payment.Payer) // nolint: errcheck
+			return nil
+		}),
+	},
+}
+
+var minerPaymentsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Account for the payments of a miner's storage deals",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"report": minerPaymentsReportCmd,
+	},
+}
+
+var minerPaymentsReportCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Reconcile the payments of a miner's active storage deals",
+		ShortDescription: `
+Reports, for each active storage deal of the node's miner or of the given
+miner, the total price of the deal, the largest voucher received for the deal's
+payment channel and the amount redeemed from the channel on chain, followed by
+the totals over all deals. A redeemed amount of "-" means the channel is no
+longer on chain.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("miner", "The address of the miner whose payments to report"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := optionalAddr(req.Options["miner"])
+		if err != nil {
+			return err
+		}
+
+		report, err := GetPorcelainAPI(env).MinerPaymentsReport(req.Context, minerAddr)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(report)
+	},
+	Type: porcelain.MinerPaymentsReportResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, report *porcelain.MinerPaymentsReportResult) error {
+			f := NewFormatter(req)
+			table := f.NewTable(w, "Deal", "State", "Payer", "Channel", "Expected", "Received", "Redeemed")
+			for _, deal := range report.Deals {
+				channel, redeemed := "-", "-"
+				if deal.Channel != nil {
+					channel = deal.Channel.String()
+				}
+				if deal.Redeemed != nil {
+					redeemed = f.FIL(deal.Redeemed)
+				}
+				table.Row(deal.ProposalCid.String(), deal.State.String(), deal.Payer.String(), channel, f.FIL(deal.Expected), f.FIL(deal.Received), redeemed)
+			}
+			table.Row("Total", "", "", "", f.FIL(report.Expected), f.FIL(report.Received), f.FIL(report.Redeemed))
+			return table.Flush()
+		}),
+	},
+}
+
+var minerSectorsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Inspect a miner's committed sectors",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"expiring": minerSectorsExpiringCmd,
+	},
+}
+
+var minerSectorsExpiringCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the sectors whose storage deals end soon",
+		ShortDescription: `
+Lists the committed sectors of the node's miner or of the given miner in which
+all storage deals end within --within blocks of the chain head, ordered by the
+height at which their last deal ends. Sectors whose deals already ended are
+marked expired. A miner with mining.removeExpiredSectors set removes expired
+sectors on its own, reducing its power.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("miner", "The address of the miner whose sectors to list"),
+		cmdkit.Uint64Option("within", "number of blocks from the chain head within which the deals end").WithDefault(uint64(miner.ProvingPeriodBlocks)),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := optionalAddr(req.Options["miner"])
+		if err != nil {
+			return err
+		}
+
+		height, err := GetPorcelainAPI(env).ChainBlockHeight()
+		if err != nil {
+			return err
+		}
+		until := height.Add(types.NewBlockHeight(req.Options["within"].(uint64)))
+
+		expirations, err := GetPorcelainAPI(env).MinerGetSectorExpirations(req.Context, minerAddr)
+		if err != nil {
+			return err
+		}
+
+		for _, expiration := range expirations {
+			if expiration.Expiry.GreaterThan(until) {
+				break
+			}
+			res := &ExpiringSectorResult{
+				SectorExpiration: *expiration,
+				Expired:          expiration.Expiry.LessEqual(height),
+			}
+			if err := re.Emit(res); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: ExpiringSectorResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *ExpiringSectorResult) error {
+			status := "active"
+			if res.Expired {
+				status = "expired"
+			}
+			_, err := fmt.Fprintf(w, "%d\t%s\t%s\t%d deals\t%s\n", res.SectorID, res.CommitHeight, res.Expiry, len(res.Deals), status)
+			return err
+		}),
+	},
+}
+
+// ExpiringSectorResult is a sector listed by miner sectors expiring.
+type ExpiringSectorResult struct {
+	porcelain.SectorExpiration
+	// Expired is true if the deals in the sector already ended.
+	Expired bool `json:"expired"`
+}
+
+var minerAskLsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the unexpired asks of a miner",
+		ShortDescription: `
+Lists the unexpired asks of the given miner, or of the node's miner if none is
+given. Results will be returned as a space separated table with miner, id,
+price and expiration respectively.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("miner", "The address of the miner whose asks to list"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := optionalAddr(req.Options["miner"])
+		if err != nil {
+			return err
+		}
+
+		asks, err := GetPorcelainAPI(env).MinerListAsks(req.Context, minerAddr)
+		if err != nil {
+			return err
+		}
+
+		for _, ask := range asks {
+			if err := re.Emit(ask); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: porcelain.Ask{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ask *porcelain.Ask) error {
+			_, err := fmt.Fprintf(w, "%s %.3d %s %s\n", ask.Miner, ask.ID, NewFormatter(req).FIL(ask.Price), ask.Expiry)
+			return err
+		}),
+	},
+}
+
+var minerAskRmCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "Remove an ask before it expires",
+		ShortDescription: `Issues a new message to the network to retire the ask with id <ask> of the node's miner, or of the given miner.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("ask", true, false, "ID of the ask to remove"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("miner", "The address of the miner whose ask to remove"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		askID, err := strconv.ParseUint(req.Arguments[0], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid ask id")
+		}
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		minerAddr, err := optionalAddr(req.Options["miner"])
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		c, err := GetPorcelainAPI(env).MinerRemoveAsk(req.Context, fromAddr, minerAddr, gasPrice, gasLimit, askID)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
+}
+
+var minerChangeWorkerCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Change the worker address of a miner",
+		ShortDescription: `Issues a new message to the network to replace the miner's worker key with the
+key of <worker>. The worker key signs the miner's blocks and PoSts. Only the
+miner's owner may change its worker.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("worker", true, false, "Wallet address whose key will become the miner's worker key"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("miner", "The address of the miner whose worker to change"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		workerAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		minerAddr, err := optionalAddr(req.Options["miner"])
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		c, err := GetPorcelainAPI(env).MinerChangeWorker(
+			req.Context,
+			fromAddr,
+			minerAddr,
+			gasPrice,
+			gasLimit,
+			workerAddr,
+		)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
+}
+
+var minerWorkerCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "Show the worker address of <miner>",
+		ShortDescription: `Given <miner> miner address, output the address whose key signs the miner's blocks and PoSts.`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := optionalAddr(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		workerAddr, err := GetPorcelainAPI(env).MinerGetWorkerAddress(req.Context, minerAddr)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&workerAddr)
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", true, false, "The address of the miner"),
+	},
+	Type: address.Address{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, a *address.Address) error {
+			return PrintString(w, a)
+		}),
+	},
+}
+
 var minerOwnerCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline:          "Show the actor address of <miner>",
@@ -416,6 +913,49 @@ var minerOwnerCmd = &cmds.Command{
 	},
 }
 
+var minerInfoCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the owner, pledge and power of a miner",
+		ShortDescription: `
+Queries the miner actor and the storage market for the miner's owner, worker,
+peer ID, pledge, collateral, committed sectors, and power versus the total
+power of the storage market. Power is counted in sectors.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", true, false, "The address of the miner"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		info, err := GetPorcelainAPI(env).MinerGetInfo(req.Context, minerAddr)
+		if err != nil {
+			return err
+		}
+		return re.Emit(info)
+	},
+	Type: porcelain.MinerInfo{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, info *porcelain.MinerInfo) error {
+			share := "0.00%"
+			if info.TotalPower.Sign() > 0 {
+				ratio := new(big.Rat).SetFrac(new(big.Int).Mul(info.Power, big.NewInt(100)), info.TotalPower)
+				share = ratio.FloatString(2) + "%"
+			}
+
+			_, err := fmt.Fprintf(w, "Owner:\t\t%s\nWorker:\t\t%s\nPeer ID:\t%s\nSector size:\t%d bytes\n"+
+				"Pledge:\t\t%s sectors (%s bytes)\nCollateral:\t%s\nCommitted:\t%d sectors\nPower:\t\t%s / %s sectors (%s)\n",
+				info.Owner, info.Worker, info.PeerID.Pretty(), info.SectorSize,
+				info.PledgeSectors, info.PledgeBytes, NewFormatter(req).FIL(info.Collateral), info.CommittedSectors,
+				info.Power, info.TotalPower, share)
+			return err
+		}),
+	},
+}
+
 var minerPowerCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Get the power of a miner versus the total storage market power",
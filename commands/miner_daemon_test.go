@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-peer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,6 +23,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/fixtures"
 	"github.com/filecoin-project/go-filecoin/gengen/util"
+	"github.com/filecoin-project/go-filecoin/porcelain"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -296,6 +298,32 @@ func TestMinerSetPrice(t *testing.T) {
 	assert.Equal(t, `"62"`, configuredPrice.ReadStdoutTrimNewlines())
 }
 
+func TestMinerAskLsAndRm(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d1 := th.NewDaemon(t,
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+		th.DefaultAddress(fixtures.TestAddresses[0])).Start()
+	defer d1.ShutdownSuccess()
+
+	d1.RunSuccess("mining", "start")
+
+	d1.MinerSetPrice(fixtures.TestMiners[0], fixtures.TestAddresses[0], "62", "100")
+
+	// miner, id, price and expiry
+	ask := strings.Fields(d1.RunSuccess("miner", "ask", "ls", "--no-humanize").ReadStdoutTrimNewlines())
+	require.Len(t, ask, 4)
+	assert.Equal(t, []string{fixtures.TestMiners[0], "000", "62"}, ask[:3])
+
+	rmOut := d1.RunSuccess("miner", "ask", "rm", "0", "--gas-price", "1", "--gas-limit", "300")
+	rmCid, err := cid.Decode(rmOut.ReadStdoutTrimNewlines())
+	require.NoError(t, err)
+	d1.WaitForMessageRequireSuccess(rmCid)
+
+	assert.Equal(t, "", d1.RunSuccess("miner", "ask", "ls").ReadStdoutTrimNewlines())
+}
+
 func TestMinerCreateSuccess(t *testing.T) {
 	tf.IntegrationTest(t)
 
@@ -446,6 +474,50 @@ func TestMinerPower(t *testing.T) {
 	assert.Equal(t, "3 / 6", power)
 }
 
+func TestMinerInfo(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	fi, err := ioutil.TempFile("", "gengentest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = gengen.GenGenesisCar(testConfig, fi, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = fi.Close()
+
+	d := th.NewDaemon(t, th.GenesisFile(fi.Name())).Start()
+	defer d.ShutdownSuccess()
+
+	actorLsOutput := d.RunSuccess("actor", "ls")
+
+	scanner := bufio.NewScanner(strings.NewReader(actorLsOutput.ReadStdout()))
+	var addressStruct struct{ Address string }
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "MinerActor") {
+			err = json.Unmarshal([]byte(line), &addressStruct)
+			assert.NoError(t, err)
+			break
+		}
+	}
+
+	ownerOutput := d.RunSuccess("miner", "owner", addressStruct.Address).ReadStdoutTrimNewlines()
+
+	var info porcelain.MinerInfo
+	infoOutput := d.RunSuccess("miner", "info", addressStruct.Address, "--enc=json").ReadStdoutTrimNewlines()
+	require.NoError(t, json.Unmarshal([]byte(infoOutput), &info))
+	assert.Equal(t, ownerOutput, info.Owner.String())
+	assert.Equal(t, big.NewInt(3), info.Power)
+	assert.Equal(t, big.NewInt(6), info.TotalPower)
+
+	textOutput := d.RunSuccess("miner", "info", addressStruct.Address).ReadStdout()
+	assert.Contains(t, textOutput, "3 / 6 sectors (50.00%)")
+}
+
 var testConfig = &gengen.GenesisCfg{
 	Keys: 4,
 	PreAlloc: []string{
@@ -26,12 +26,12 @@ func TestMiningGenBlock(t *testing.T) {
 
 	addr := fixtures.TestAddresses[0]
 
-	s := d.RunSuccess("wallet", "balance", addr)
+	s := d.RunSuccess("wallet", "balance", "--no-humanize", addr)
 	beforeBalance := parseInt(t, s.ReadStdout())
 
 	d.RunSuccess("mining", "once")
 
-	s = d.RunSuccess("wallet", "balance", addr)
+	s = d.RunSuccess("wallet", "balance", "--no-humanize", addr)
 	afterBalance := parseInt(t, s.ReadStdout())
 	sum := new(big.Int)
 
@@ -11,6 +11,10 @@ import (
 	"github.com/ipfs/go-ipfs-cmds"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -19,20 +23,40 @@ var mpoolCmd = &cmds.Command{
 		Tagline: "Manage the message pool",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"ls":   mpoolLsCmd,
-		"show": mpoolShowCmd,
-		"rm":   mpoolRemoveCmd,
+		"ls":           mpoolLsCmd,
+		"pending":      mpoolPendingCmd,
+		"show":         mpoolShowCmd,
+		"rm":           mpoolRemoveCmd,
+		"estimate-gas": mpoolEstimateGasCmd,
+		"gas-estimate": mpoolGasEstimateCmd,
 	},
 }
 
 var mpoolLsCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "View the pool of outstanding messages",
+		ShortDescription: `
+Lists the messages in the pool waiting to be mined. With --from, lists the
+queue of messages from an address instead, ordered by nonce. Messages after a
+gap in the nonces of their sender are held in the pool until the gap is
+filled, and a message with the nonce of a pending message replaces it if its
+gas price is higher by at least the configured mpool.replaceByFeeBump percent.
+Messages are dropped after mpool.messageTimeout tipsets without being mined.
+`,
 	},
 	Options: []cmdkit.Option{
 		cmdkit.UintOption("wait-for-count", "Block until this number of messages are in the pool").WithDefault(0),
+		cmdkit.StringOption("from", "List the queue of messages from this address"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		if from, ok := req.Options["from"].(string); ok {
+			fromAddr, err := address.NewFromString(from)
+			if err != nil {
+				return errors.Wrap(err, "invalid from address")
+			}
+			return re.Emit(GetPorcelainAPI(env).MessagePoolPendingFrom(fromAddr))
+		}
+
 		messageCount, _ := req.Options["wait-for-count"].(uint)
 
 		pending, err := GetPorcelainAPI(env).MessagePoolWait(req.Context, messageCount)
@@ -57,6 +81,66 @@ var mpoolLsCmd = &cmds.Command{
 	},
 }
 
+var mpoolPendingCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the pending messages",
+		ShortDescription: `
+Lists the messages in the pool waiting to be mined. With --local, lists the
+messages sent from this node instead, along with what became of them: pending,
+mined, failed (on chain or expired before reaching it), or replaced by another
+message with the same nonce. Pending messages sent from this node are
+rebroadcast until they reach the chain or expire.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("local", "List the messages sent from this node and their status"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		if local, _ := req.Options["local"].(bool); local {
+			for _, entry := range GetPorcelainAPI(env).MessageJournalLs(false) {
+				if err := re.Emit(entry); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for _, msg := range GetPorcelainAPI(env).MessagePoolPending() {
+			c, err := msg.Cid()
+			if err != nil {
+				return err
+			}
+			if err := re.Emit(&core.JournalEntry{Cid: c, Msg: msg, Status: core.MessagePending}); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: core.JournalEntry{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, entry *core.JournalEntry) error {
+			sw := NewSilentWriter(w)
+			if local, _ := req.Options["local"].(bool); !local {
+				sw.Println(entry.Cid.String())
+				return sw.Error()
+			}
+
+			sw.Printf("%s %s, from: %s, nonce: %d, sent at height: %d, broadcasts: %d", entry.Cid, entry.Status, entry.Msg.From, entry.Msg.Nonce, entry.SentAt, entry.Broadcasts)
+			if entry.MinedAt != 0 {
+				sw.Printf(", mined at height: %d", entry.MinedAt)
+			}
+			if entry.ReplacedBy.Defined() {
+				sw.Printf(", replaced by: %s", entry.ReplacedBy)
+			}
+			if entry.Error != "" {
+				sw.Printf(", error: %s", entry.Error)
+			}
+			sw.Println()
+			return sw.Error()
+		}),
+	},
+}
+
 var mpoolShowCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Show content of an outstanding message",
@@ -79,6 +163,7 @@ var mpoolShowCmd = &cmds.Command{
 	Type: &types.SignedMessage{},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, msg *types.SignedMessage) error {
+			f := NewFormatter(req)
 			_, err := fmt.Fprintf(w, `Message Details
 To:        %s
 From:      %s
@@ -93,10 +178,10 @@ Signature: %s
 				msg.To,
 				msg.From,
 				strconv.FormatUint(uint64(msg.Nonce), 10),
-				msg.Value,
+				f.FIL(msg.Value),
 				msg.Method,
 				base64.StdEncoding.EncodeToString(msg.Params),
-				msg.GasPrice.String(),
+				f.FIL(&msg.GasPrice),
 				strconv.FormatUint(uint64(msg.GasLimit), 10),
 				base64.StdEncoding.EncodeToString(msg.Signature),
 			)
@@ -123,3 +208,103 @@ var mpoolRemoveCmd = &cmds.Command{
 		return nil
 	},
 }
+
+var mpoolEstimateGasCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Estimate the gas a message would use",
+		ShortDescription: `
+Estimates the gas a message calling method on the target actor would use by
+applying it to the state of the head of the chain, priced according to the
+gas table of the current protocol version. The estimate includes the cost of
+the message itself. Nothing is sent to the network.
+
+Params are parsed according to the method's signature.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("target", true, false, "Address of the actor to send the message to"),
+		cmdkit.StringArg("method", false, false, "The method to invoke on the target actor"),
+		cmdkit.StringArg("params", false, true, "Parameters of the method"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send the message from"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		target, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid target address")
+		}
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		method := ""
+		var params []interface{}
+		if len(req.Arguments) > 1 {
+			method = req.Arguments[1]
+
+			sig, err := GetPorcelainAPI(env).ActorGetSignature(req.Context, target, method)
+			if err != nil {
+				return errors.Wrap(err, "could not get method signature")
+			}
+
+			vals, err := abi.ParseValues(req.Arguments[2:], sig.Params)
+			if err != nil {
+				return err
+			}
+			params = abi.FromValues(vals)
+		}
+
+		usedGas, err := GetPorcelainAPI(env).MessagePreview(req.Context, fromAddr, target, method, params...)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&usedGas)
+	},
+	Type: types.GasUnits(0),
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, usedGas *types.GasUnits) error {
+			_, err := fmt.Fprintln(w, strconv.FormatUint(uint64(*usedGas), 10))
+			return err
+		}),
+	},
+}
+
+var mpoolGasEstimateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Suggest gas prices to get messages mined in time",
+		ShortDescription: `
+Suggests gas prices to get a message mined within the next block, 3 blocks and
+10 blocks, from the lowest gas prices of the messages in recent blocks. A price
+at or above the lowest price of most recent blocks is likely to get a message
+into the next block; lower prices take more blocks. Messages sent without
+--gas-price use the price suggested for 3 blocks.
+`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		estimate, err := GetPorcelainAPI(env).MessageGasEstimate(req.Context)
+		if err != nil {
+			return err
+		}
+		return re.Emit(estimate)
+	},
+	Type: msg.GasPriceEstimate{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, estimate *msg.GasPriceEstimate) error {
+			f := NewFormatter(req)
+			sw := NewSilentWriter(w)
+			for _, suggestion := range estimate.Suggestions {
+				target := fmt.Sprintf("within %d blocks", suggestion.Blocks)
+				if suggestion.Blocks == 1 {
+					target = "next block"
+				}
+				sw.Printf("%s: %s\n", target, f.FIL(&suggestion.Price))
+			}
+			sw.Printf("(from %d messages in %d blocks)\n", estimate.Messages, estimate.Blocks)
+			return sw.Error()
+		}),
+	},
+}
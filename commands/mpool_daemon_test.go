@@ -8,6 +8,7 @@ import (
 	"github.com/ipfs/go-cid"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/fixtures"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
@@ -76,6 +77,22 @@ func TestMpoolLs(t *testing.T) {
 
 		assert.True(t, complete)
 	})
+
+	t.Run("return the messages from an address", func(t *testing.T) {
+		d := th.NewDaemon(t, th.KeyFile(fixtures.KeyFilePaths()[0])).Start()
+		defer d.ShutdownSuccess()
+
+		first := sendMessage(d, fixtures.TestAddresses[0], fixtures.TestAddresses[2]).ReadStdoutTrimNewlines()
+		second := sendMessage(d, fixtures.TestAddresses[0], fixtures.TestAddresses[2]).ReadStdoutTrimNewlines()
+
+		out := d.RunSuccess("mpool", "ls", "--from", fixtures.TestAddresses[0]).ReadStdoutTrimNewlines()
+		assert.Equal(t, first+"\n"+second, out)
+
+		out = d.RunSuccess("mpool", "ls", "--from", fixtures.TestAddresses[1]).ReadStdoutTrimNewlines()
+		assert.Equal(t, "", out)
+
+		d.RunFail("invalid from address", "mpool", "ls", "--from", "notanaddress")
+	})
 }
 
 func TestMpoolShow(t *testing.T) {
@@ -111,6 +128,34 @@ func TestMpoolShow(t *testing.T) {
 	})
 }
 
+func TestMpoolPending(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(t, th.KeyFile(fixtures.KeyFilePaths()[0])).Start()
+	defer d.ShutdownSuccess()
+
+	msgCid := d.RunSuccess("message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--gas-price", "1", "--gas-limit", "300",
+		"--value=10", fixtures.TestAddresses[2],
+	).ReadStdoutTrimNewlines()
+
+	t.Run("lists the pool", func(t *testing.T) {
+		out := d.RunSuccess("mpool", "pending").ReadStdoutTrimNewlines()
+		assert.Equal(t, msgCid, out)
+	})
+
+	t.Run("lists local messages with their status", func(t *testing.T) {
+		// Removing the message from the pool leaves the journal unchanged.
+		d.RunSuccess("mpool", "rm", msgCid)
+
+		out := d.RunSuccess("mpool", "pending", "--local").ReadStdoutTrimNewlines()
+		assert.Contains(t, out, msgCid+" pending")
+		assert.Contains(t, out, "from: "+fixtures.TestAddresses[0])
+		assert.Contains(t, out, "broadcasts: 1")
+	})
+}
+
 func TestMpoolRm(t *testing.T) {
 	tf.IntegrationTest(t)
 
@@ -131,3 +176,50 @@ func TestMpoolRm(t *testing.T) {
 		assert.Equal(t, "", out)
 	})
 }
+
+func TestMpoolEstimateGas(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(t, th.KeyFile(fixtures.KeyFilePaths()[0])).Start()
+	defer d.ShutdownSuccess()
+
+	t.Run("estimates a method call", func(t *testing.T) {
+		out := d.RunSuccess("mpool", "estimate-gas",
+			"--from", fixtures.TestAddresses[0],
+			address.PaymentBrokerAddress.String(), "ls", fixtures.TestAddresses[0],
+		).ReadStdoutTrimNewlines()
+		assert.Equal(t, "100", out)
+	})
+
+	t.Run("rejects params that do not match the signature", func(t *testing.T) {
+		d.RunFail("expected 1 parameters", "mpool", "estimate-gas",
+			"--from", fixtures.TestAddresses[0],
+			address.PaymentBrokerAddress.String(), "ls",
+		)
+	})
+}
+
+func TestMpoolGasEstimate(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(t, th.KeyFile(fixtures.KeyFilePaths()[0])).Start()
+	defer d.ShutdownSuccess()
+
+	t.Run("suggests prices for each target", func(t *testing.T) {
+		out := d.RunSuccess("mpool", "gas-estimate").ReadStdoutTrimNewlines()
+		assert.Contains(t, out, "next block: 0")
+		assert.Contains(t, out, "within 3 blocks: 0")
+		assert.Contains(t, out, "within 10 blocks: 0")
+	})
+
+	t.Run("prices messages sent without a gas price", func(t *testing.T) {
+		msgCid := d.RunSuccess("message", "send",
+			"--from", fixtures.TestAddresses[0],
+			"--gas-limit", "300",
+			"--value=10", fixtures.TestAddresses[2],
+		).ReadStdoutTrimNewlines()
+
+		out := d.RunSuccess("mpool", "show", msgCid).ReadStdoutTrimNewlines()
+		assert.Contains(t, out, "Gas price: 0")
+	})
+}
@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ipfs/go-cid"
+	cmdkit "github.com/ipfs/go-ipfs-cmdkit"
+	cmds "github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var multisigCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage multisig accounts",
+		ShortDescription: `
+A multisig account holds funds on behalf of a set of signers. Any signer may
+propose a transaction, which is only sent once the required number of signers
+have approved it.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"create":  multisigCreateCmd,
+		"propose": multisigProposeCmd,
+		"approve": multisigApproveCmd,
+		"cancel":  multisigCancelCmd,
+	},
+}
+
+var multisigCreateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Create a multisig account requiring <required> of <signers> to approve transactions",
+		ShortDescription: `Issues a new message to the network to create the multisig, then waits for the
+message to be mined as this is required to return the address of the new multisig.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("required", true, false, "The number of signers that must approve each transaction"),
+		cmdkit.StringArg("signers", true, true, "Addresses of the signers"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("value", "Amount of FIL to fund the multisig with").WithDefault("0"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		required, err := strconv.ParseUint(req.Arguments[0], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid number of required signers")
+		}
+
+		var signers []address.Address
+		for _, s := range req.Arguments[1:] {
+			signer, err := address.NewFromString(s)
+			if err != nil {
+				return errors.Wrapf(err, "invalid signer %s", s)
+			}
+			signers = append(signers, signer)
+		}
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		value, ok := types.NewAttoFILFromFILString(req.Options["value"].(string))
+		if !ok {
+			return ErrInvalidAmount
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		addr, err := GetPorcelainAPI(env).MultisigCreate(req.Context, fromAddr, gasPrice, gasLimit, signers, required, value)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(addr)
+	},
+	Type: address.Address{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, a address.Address) error {
+			return PrintString(w, a)
+		}),
+	},
+}
+
+var multisigProposeCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Propose that a multisig sends <value> FIL to <to>",
+		ShortDescription: `Issues a new message to the network proposing a multisig transaction. The
+proposal counts as the sender's approval. With --method the transaction also
+calls that method of <to> with the given params, parsed according to the
+method's signature. The id of the transaction is the return value of the
+message, see 'message wait --return'.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("multisig", true, false, "Address of the multisig"),
+		cmdkit.StringArg("to", true, false, "Address to send to"),
+		cmdkit.StringArg("value", true, false, "Amount of FIL to send"),
+		cmdkit.StringArg("params", false, true, "Parameters of the method"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("method", "Method of <to> to call"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		multisigAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid multisig address")
+		}
+
+		to, err := address.NewFromString(req.Arguments[1])
+		if err != nil {
+			return errors.Wrap(err, "invalid to address")
+		}
+
+		value, ok := types.NewAttoFILFromFILString(req.Arguments[2])
+		if !ok {
+			return ErrInvalidAmount
+		}
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		method, _ := req.Options["method"].(string)
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		c, err := GetPorcelainAPI(env).MultisigPropose(req.Context, fromAddr, multisigAddr, gasPrice, gasLimit, to, value, method, req.Arguments[3:])
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
+}
+
+var multisigApproveCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Approve a pending multisig transaction",
+		ShortDescription: `Issues a new message to the network approving the transaction. The
+transaction is sent once it has the required number of approvals.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("multisig", true, false, "Address of the multisig"),
+		cmdkit.StringArg("txid", true, false, "Id of the transaction"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		multisigAddr, txID, err := parseMultisigTxArgs(req)
+		if err != nil {
+			return err
+		}
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		c, err := GetPorcelainAPI(env).MultisigApprove(req.Context, fromAddr, multisigAddr, gasPrice, gasLimit, txID)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
+}
+
+var multisigCancelCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Cancel a pending multisig transaction",
+		ShortDescription: `Issues a new message to the network canceling the transaction. Only the
+signer who proposed a transaction may cancel it.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("multisig", true, false, "Address of the multisig"),
+		cmdkit.StringArg("txid", true, false, "Id of the transaction"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		multisigAddr, txID, err := parseMultisigTxArgs(req)
+		if err != nil {
+			return err
+		}
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		c, err := GetPorcelainAPI(env).MultisigCancel(req.Context, fromAddr, multisigAddr, gasPrice, gasLimit, txID)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
+}
+
+// parseMultisigTxArgs parses the <multisig> <txid> arguments shared by
+// approve and cancel.
+func parseMultisigTxArgs(req *cmds.Request) (address.Address, uint64, error) {
+	multisigAddr, err := address.NewFromString(req.Arguments[0])
+	if err != nil {
+		return address.Undef, 0, errors.Wrap(err, "invalid multisig address")
+	}
+
+	txID, err := strconv.ParseUint(req.Arguments[1], 10, 64)
+	if err != nil {
+		return address.Undef, 0, fmt.Errorf("invalid transaction id %s", req.Arguments[1])
+	}
+
+	return multisigAddr, txID, nil
+}
@@ -0,0 +1,88 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/fixtures"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestMultisig(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(
+		t,
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[1]),
+		th.DefaultAddress(fixtures.TestAddresses[0]),
+	).Start()
+	defer d.ShutdownSuccess()
+
+	signer1 := fixtures.TestAddresses[0]
+	signer2 := fixtures.TestAddresses[1]
+	target := fixtures.TestAddresses[2]
+
+	var multisigAddr address.Address
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		out := d.RunSuccess("multisig", "create", "2", signer1, signer2, "--value", "100", "--gas-price", "1", "--gas-limit", "300")
+		var err error
+		multisigAddr, err = address.NewFromString(out.ReadStdoutTrimNewlines())
+		assert.NoError(t, err)
+	}()
+	d.MineAndPropagate(time.Second)
+	wg.Wait()
+	require.NotEqual(t, address.Undef, multisigAddr)
+
+	sendAndMine := func(args ...string) [][]byte {
+		args = append(args, "--gas-price", "1", "--gas-limit", "300")
+		msgCid, err := cid.Parse(d.RunSuccess(args...).ReadStdoutTrimNewlines())
+		require.NoError(t, err)
+		d.RunSuccess("mining", "once")
+		return d.WaitForMessageRequireSuccess(msgCid).Return
+	}
+
+	targetBalance := func() string {
+		return strings.TrimSpace(d.RunSuccess("wallet", "balance", "--no-humanize", target).ReadStdout())
+	}
+	before := targetBalance()
+
+	ret := sendAndMine("multisig", "propose", multisigAddr.String(), target, "10", "--from", signer1)
+	txID := big.NewInt(0).SetBytes(ret[0]).String()
+
+	// one approval is not enough
+	assert.Equal(t, before, targetBalance())
+
+	sendAndMine("multisig", "approve", multisigAddr.String(), txID, "--from", signer2)
+	assert.NotEqual(t, before, targetBalance())
+
+	// a canceled transaction cannot be approved
+	ret = sendAndMine("multisig", "propose", multisigAddr.String(), target, "10", "--from", signer1)
+	txID = big.NewInt(0).SetBytes(ret[0]).String()
+	sendAndMine("multisig", "cancel", multisigAddr.String(), txID, "--from", signer1)
+
+	msgCid, err := cid.Parse(d.RunSuccess("multisig", "approve", multisigAddr.String(), txID, "--from", signer2, "--gas-price", "1", "--gas-limit", "300").ReadStdoutTrimNewlines())
+	require.NoError(t, err)
+	d.RunSuccess("mining", "once")
+
+	out := d.RunSuccess("message", "wait", msgCid.String(), "--receipt=true", "--message=false")
+	var receipt types.MessageReceipt
+	require.NoError(t, json.Unmarshal([]byte(out.ReadStdoutTrimNewlines()), &receipt))
+	assert.Equal(t, uint8(multisig.ErrUnknownTransaction), receipt.ExitCode)
+}
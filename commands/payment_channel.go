@@ -1,9 +1,14 @@
 package commands
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
@@ -11,6 +16,7 @@ import (
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-cmdkit"
 	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
 )
 
 var paymentChannelCmd = &cmds.Command{
@@ -18,14 +24,19 @@ var paymentChannelCmd = &cmds.Command{
 		Tagline: "Payment channel operations",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"cancel":  cancelCmd,
-		"close":   closeCmd,
-		"create":  createChannelCmd,
-		"extend":  extendCmd,
-		"ls":      lsCmd,
-		"reclaim": reclaimCmd,
-		"redeem":  redeemCmd,
-		"voucher": voucherCmd,
+		"assign-target":   assignTargetCmd,
+		"cancel":          cancelCmd,
+		"close":           closeCmd,
+		"create":          createChannelCmd,
+		"extend":          extendCmd,
+		"history":         historyCmd,
+		"ls":              lsCmd,
+		"reclaim":         reclaimCmd,
+		"redeem":          redeemCmd,
+		"settle":          settleCmd,
+		"sign-assignment": signAssignmentCmd,
+		"voucher":         voucherCmd,
+		"vouchers":        vouchersCmd,
 	},
 }
 
@@ -36,11 +47,21 @@ type CreateChannelResult struct {
 	Preview bool
 }
 
+// signatureSchemes maps the names of the voucher signature schemes accepted by
+// paych create to the schemes.
+var signatureSchemes = map[string]paymentbroker.SignatureScheme{
+	"secp256k1": paymentbroker.SchemeSecp256k1,
+	"bls":       paymentbroker.SchemeBLS,
+}
+
 var createChannelCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Create a new payment channel",
 		ShortDescription: `Issues a new message to the network to create a payment channeld. Then waits for the
-message to be mined to get the channelID.`,
+message to be mined to get the channelID.
+
+The vouchers of the channel must be signed with the signature scheme given by
+--scheme, secp256k1 or bls. It defaults to the scheme of the sending address.`,
 	},
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("target", true, false, "Address of account that will redeem funds"),
@@ -49,6 +70,7 @@ message to be mined to get the channelID.`,
 	},
 	Options: []cmdkit.Option{
 		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("scheme", "Signature scheme of the channel's vouchers (secp256k1 or bls)"),
 		priceOption,
 		limitOption,
 		previewOption,
@@ -74,7 +96,16 @@ message to be mined to get the channelID.`,
 			return ErrInvalidBlockHeight
 		}
 
-		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		params := []interface{}{target, eol}
+		if name, ok := req.Options["scheme"].(string); ok {
+			scheme, ok := signatureSchemes[name]
+			if !ok {
+				return fmt.Errorf("unknown signature scheme %q", name)
+			}
+			params = append(params, big.NewInt(int64(scheme)))
+		}
+
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
 		if err != nil {
 			return err
 		}
@@ -85,7 +116,7 @@ message to be mined to get the channelID.`,
 				fromAddr,
 				address.PaymentBrokerAddress,
 				"createChannel",
-				target, eol,
+				params...,
 			)
 			if err != nil {
 				return err
@@ -105,8 +136,7 @@ message to be mined to get the channelID.`,
 			gasPrice,
 			gasLimit,
 			"createChannel",
-			target,
-			eol,
+			params...,
 		)
 		if err != nil {
 			return err
@@ -167,13 +197,74 @@ var lsCmd = &cmds.Command{
 				return nil
 			}
 
-			for chid, pc := range *pcs {
-				_, err := fmt.Fprintf(w, "%s: target: %v, amt: %v, amt redeemed: %v, eol: %v\n", chid, pc.Target.String(), pc.Amount, pc.AmountRedeemed, pc.Eol)
-				if err != nil {
-					return err
+			chids := make([]string, 0, len(*pcs))
+			for chid := range *pcs {
+				chids = append(chids, chid)
+			}
+			// channel ids are decimal, so shorter ids are smaller
+			sort.Slice(chids, func(i, j int) bool {
+				if len(chids[i]) != len(chids[j]) {
+					return len(chids[i]) < len(chids[j])
 				}
+				return chids[i] < chids[j]
+			})
+
+			f := NewFormatter(req)
+			table := f.NewTable(w, "Channel", "Target", "Amount", "Redeemed", "Eol")
+			for _, chid := range chids {
+				pc := (*pcs)[chid]
+				table.Row(chid, pc.Target.String(), f.FIL(pc.Amount), f.FIL(pc.AmountRedeemed), pc.Eol.String())
 			}
-			return nil
+			return table.Flush()
+		}),
+	},
+}
+
+var historyCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the recent redemptions of a payment channel",
+		ShortDescription: `
+Shows the most recent vouchers redeemed against the payment channel, the oldest
+first: the amount of the voucher, the amount it paid, the height it was
+redeemed at and the address that redeemed it. Channel ids are only unique per
+payer, so the payer of the channel is needed too.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("payer", true, false, "Address of the payer of the channel"),
+		cmdkit.StringArg("channel", true, false, "Id of the channel"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		payer, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		channel, ok := types.NewChannelIDFromString(req.Arguments[1], 10)
+		if !ok {
+			return fmt.Errorf("invalid channel id")
+		}
+
+		history, err := GetPorcelainAPI(env).PaymentChannelHistory(req.Context, payer, channel)
+		if err != nil {
+			return err
+		}
+		return re.Emit(history)
+	},
+	Type: []*paymentbroker.Redemption{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, history *[]*paymentbroker.Redemption) error {
+			if len(*history) == 0 {
+				fmt.Fprintln(w, "no redemptions") // nolint: errcheck
+				return nil
+			}
+
+			f := NewFormatter(req)
+			table := f.NewTable(w, "Height", "Amount", "Paid", "Redeemer")
+			for _, r := range *history {
+				table.Row(r.Height.String(), f.FIL(r.Amount), f.FIL(r.Paid), r.Redeemer.String())
+			}
+			return table.Flush()
 		}),
 	},
 }
@@ -217,6 +308,11 @@ var voucherCmd = &cmds.Command{
 			return err
 		}
 
+		// keep the voucher around so it can be exported later
+		if err := GetPorcelainAPI(env).VoucherPut(voucher); err != nil {
+			return err
+		}
+
 		v, err := voucher.Encode()
 		if err != nil {
 			return err
@@ -230,6 +326,233 @@ var voucherCmd = &cmds.Command{
 			return nil
 		}),
 	},
+	Subcommands: map[string]*cmds.Command{
+		"decode":  voucherDecodeCmd,
+		"encode":  voucherEncodeCmd,
+		"inspect": voucherInspectCmd,
+	},
+}
+
+var voucherEncodeCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Encode a voucher to the current voucher format",
+		ShortDescription: `
+Encodes a voucher given as JSON, as printed by voucher decode, or as a string in
+any voucher format this node knows, to the current voucher format. Use it to
+bring vouchers in the legacy base58 format up to date.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("voucher", true, false, "Voucher as JSON or as an encoded string"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		var voucher *types.PaymentVoucher
+		arg := strings.TrimSpace(req.Arguments[0])
+		if strings.HasPrefix(arg, "{") {
+			voucher = &types.PaymentVoucher{}
+			if err := json.Unmarshal([]byte(arg), voucher); err != nil {
+				return errors.Wrap(err, "invalid voucher JSON")
+			}
+		} else {
+			var err error
+			voucher, err = types.DecodeVoucher(arg)
+			if err != nil {
+				return err
+			}
+		}
+
+		encoded, err := voucher.Encode()
+		if err != nil {
+			return err
+		}
+		return re.Emit(encoded)
+	},
+	Type: string(""),
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, voucher string) error {
+			_, err := fmt.Fprintln(w, voucher)
+			return err
+		}),
+	},
+}
+
+var voucherDecodeCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Decode an encoded voucher to JSON",
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("voucher", true, false, "Encoded voucher"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		voucher, err := types.DecodeVoucher(strings.TrimSpace(req.Arguments[0]))
+		if err != nil {
+			return err
+		}
+		return re.Emit(voucher)
+	},
+	Type: types.PaymentVoucher{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, voucher *types.PaymentVoucher) error {
+			marshaled, err := json.MarshalIndent(voucher, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(w, string(marshaled))
+			return err
+		}),
+	},
+}
+
+// VoucherInspectResult describes an encoded voucher.
+type VoucherInspectResult struct {
+	// Version is the format version the voucher was encoded in, 0 for the
+	// legacy format.
+	Version uint64
+	Voucher *types.PaymentVoucher
+	// SignatureValid is whether the voucher is signed by its payer.
+	SignatureValid bool
+}
+
+var voucherInspectCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the content of an encoded voucher",
+		ShortDescription: `
+Decodes a voucher, checking its checksum, and shows its format version, its
+fields and whether it is signed by its payer.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("voucher", true, false, "Encoded voucher"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		voucher, version, err := types.DecodeVoucherWithVersion(strings.TrimSpace(req.Arguments[0]))
+		if err != nil {
+			return err
+		}
+
+		valid := paymentbroker.VerifyVoucherSignature(
+			paymentbroker.SchemeForAddress(voucher.Payer),
+			voucher.Payer,
+			&voucher.Channel,
+			&voucher.Amount,
+			&voucher.ValidAt,
+			voucher.Condition,
+			voucher.Signature,
+		)
+		return re.Emit(&VoucherInspectResult{Version: version, Voucher: voucher, SignatureValid: valid})
+	},
+	Type: VoucherInspectResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *VoucherInspectResult) error {
+			f := NewFormatter(req)
+			table := f.NewTable(w)
+			v := res.Voucher
+			table.Row("Format version:", fmt.Sprintf("%d", res.Version))
+			table.Row("Channel:", v.Channel.String())
+			table.Row("Payer:", v.Payer.String())
+			table.Row("Target:", v.Target.String())
+			table.Row("Amount:", f.FIL(&v.Amount))
+			table.Row("Valid at:", v.ValidAt.String())
+			condition := "none"
+			if v.Condition != nil {
+				condition = fmt.Sprintf("%s on %s", v.Condition.Method, v.Condition.To.String())
+			}
+			table.Row("Condition:", condition)
+			signature := "invalid"
+			if res.SignatureValid {
+				signature = "valid"
+			}
+			table.Row("Signature:", signature)
+			return table.Flush()
+		}),
+	},
+}
+
+var vouchersCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Transfer payment vouchers out of band",
+		ShortDescription: `Export signed vouchers to a compact string that can be delivered to the channel
+target by other means (e.g. email or HTTP), and import them on the target's node.`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"export": vouchersExportCmd,
+		"import": vouchersImportCmd,
+	},
+}
+
+var vouchersExportCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "Export all vouchers created for a payment channel",
+		ShortDescription: `Encodes every voucher this node has created for the channel into a single string.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("channel", true, false, "Channel id of channel whose vouchers to export"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("payer", "Address of the channel payer (defaults to the default wallet address)"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		payerAddr, err := optionalAddr(req.Options["payer"])
+		if err != nil {
+			return err
+		}
+
+		channel, ok := types.NewChannelIDFromString(req.Arguments[0], 10)
+		if !ok {
+			return fmt.Errorf("invalid channel id")
+		}
+
+		encoded, err := GetPorcelainAPI(env).PaymentChannelVoucherExport(req.Context, payerAddr, channel)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(encoded)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, vouchers string) error {
+			fmt.Fprintln(w, vouchers) // nolint: errcheck
+			return nil
+		}),
+	},
+}
+
+var vouchersImportCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Import exported vouchers",
+		ShortDescription: `Validates exported vouchers against the current state of their payment channels
+and stores them. No voucher is imported if any of them is invalid.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("vouchers", true, false, "Vouchers as output by 'paych vouchers export'").EnableStdin(),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address for which to query channel state"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		vouchers, err := GetPorcelainAPI(env).PaymentChannelVoucherImport(req.Context, fromAddr, strings.TrimSpace(req.Arguments[0]))
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(vouchers)
+	},
+	Type: []*types.PaymentVoucher{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, vouchers *[]*types.PaymentVoucher) error {
+			f := NewFormatter(req)
+			table := f.NewTable(w, "Channel", "Payer", "Target", "Amount", "ValidAt")
+			for _, v := range *vouchers {
+				table.Row(v.Channel.String(), v.Payer.String(), v.Target.String(), f.FIL(&v.Amount), v.ValidAt.String())
+			}
+			return table.Flush()
+		}),
+	},
 }
 
 // RedeemResult type returned from Redeem
@@ -258,7 +581,7 @@ var redeemCmd = &cmds.Command{
 			return err
 		}
 
-		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
 		if err != nil {
 			return err
 		}
@@ -351,7 +674,7 @@ var reclaimCmd = &cmds.Command{
 			return fmt.Errorf("invalid channel id")
 		}
 
-		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
 		if err != nil {
 			return err
 		}
@@ -433,7 +756,7 @@ var closeCmd = &cmds.Command{
 			return err
 		}
 
-		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
 		if err != nil {
 			return err
 		}
@@ -538,7 +861,7 @@ var extendCmd = &cmds.Command{
 			return ErrInvalidBlockHeight
 		}
 
-		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
 		if err != nil {
 			return err
 		}
@@ -625,7 +948,7 @@ var cancelCmd = &cmds.Command{
 			return fmt.Errorf("invalid channel id")
 		}
 
-		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
 		if err != nil {
 			return err
 		}
@@ -680,3 +1003,280 @@ var cancelCmd = &cmds.Command{
 		}),
 	},
 }
+
+// SettleResult type returned from Settle
+type SettleResult struct {
+	Cid     cid.Cid
+	GasUsed types.GasUnits
+	Preview bool
+}
+
+var settleCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Start the settlement of a payment channel as its target",
+		ShortDescription: `
+Lowers the eol of the channel so that it can be reclaimed after the cancel
+delay, as cancel does for the payer. Redeem the last voucher of the channel
+before settling it, as redeeming resets the eol.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("payer", true, false, "address of the payer of the channel"),
+		cmdkit.StringArg("channel", true, false, "id of channel to settle"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "address of the channel target"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		payer, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		channel, ok := types.NewChannelIDFromString(req.Arguments[1], 10)
+		if !ok {
+			return fmt.Errorf("invalid channel id")
+		}
+
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		if preview {
+			usedGas, err := GetPorcelainAPI(env).MessagePreview(
+				req.Context,
+				fromAddr,
+				address.PaymentBrokerAddress,
+				"settle",
+				payer,
+				channel,
+			)
+			if err != nil {
+				return err
+			}
+			return re.Emit(&SettleResult{
+				Cid:     cid.Cid{},
+				GasUsed: usedGas,
+				Preview: true,
+			})
+		}
+
+		c, err := GetPorcelainAPI(env).MessageSendWithDefaultAddress(
+			req.Context,
+			fromAddr,
+			address.PaymentBrokerAddress,
+			types.NewAttoFILFromFIL(0),
+			gasPrice,
+			gasLimit,
+			"settle",
+			payer,
+			channel,
+		)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&SettleResult{
+			Cid:     c,
+			GasUsed: types.NewGasUnits(0),
+			Preview: false,
+		})
+	},
+	Type: &SettleResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *SettleResult) error {
+			if res.Preview {
+				output := strconv.FormatUint(uint64(res.GasUsed), 10)
+				_, err := w.Write([]byte(output))
+				return err
+			}
+			return PrintString(w, res.Cid)
+		}),
+	},
+}
+
+var signAssignmentCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Sign the assignment of a payment channel to a new target",
+		ShortDescription: `
+Prints the hex encoded signature of the payer or the current target of the
+channel on its assignment to the new target. Both signatures are needed to
+assign the channel with assign-target.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("payer", true, false, "address of the payer of the channel"),
+		cmdkit.StringArg("channel", true, false, "id of the channel to assign"),
+		cmdkit.StringArg("new-target", true, false, "address of the new target of the channel"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "address of the payer or the target of the channel"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		payer, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		channel, ok := types.NewChannelIDFromString(req.Arguments[1], 10)
+		if !ok {
+			return fmt.Errorf("invalid channel id")
+		}
+
+		newTarget, err := address.NewFromString(req.Arguments[2])
+		if err != nil {
+			return err
+		}
+
+		sig, err := GetPorcelainAPI(env).PaymentChannelSignAssignment(req.Context, fromAddr, payer, channel, newTarget)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(hex.EncodeToString(sig))
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, sig string) error {
+			fmt.Fprintln(w, sig) // nolint: errcheck
+			return nil
+		}),
+	},
+}
+
+// AssignTargetResult type returned from AssignTarget
+type AssignTargetResult struct {
+	Cid     cid.Cid
+	GasUsed types.GasUnits
+	Preview bool
+}
+
+var assignTargetCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Assign a payment channel to a new target",
+		ShortDescription: `
+Moves the channel, along with its unredeemed balance, to the new target. The
+payer and the current target of the channel both sign the assignment with
+sign-assignment. Vouchers of the channel remain valid for the new target.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("payer", true, false, "address of the payer of the channel"),
+		cmdkit.StringArg("channel", true, false, "id of the channel to assign"),
+		cmdkit.StringArg("new-target", true, false, "address of the new target of the channel"),
+		cmdkit.StringArg("payer-signature", true, false, "hex encoded signature of the payer"),
+		cmdkit.StringArg("target-signature", true, false, "hex encoded signature of the current target"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "address to send the message from"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		payer, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		channel, ok := types.NewChannelIDFromString(req.Arguments[1], 10)
+		if !ok {
+			return fmt.Errorf("invalid channel id")
+		}
+
+		newTarget, err := address.NewFromString(req.Arguments[2])
+		if err != nil {
+			return err
+		}
+
+		payerSig, err := hex.DecodeString(req.Arguments[3])
+		if err != nil {
+			return errors.Wrap(err, "invalid payer signature")
+		}
+
+		targetSig, err := hex.DecodeString(req.Arguments[4])
+		if err != nil {
+			return errors.Wrap(err, "invalid target signature")
+		}
+
+		gasPrice, gasLimit, preview, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		if preview {
+			usedGas, err := GetPorcelainAPI(env).MessagePreview(
+				req.Context,
+				fromAddr,
+				address.PaymentBrokerAddress,
+				"assignTarget",
+				payer,
+				channel,
+				newTarget,
+				payerSig,
+				targetSig,
+			)
+			if err != nil {
+				return err
+			}
+			return re.Emit(&AssignTargetResult{
+				Cid:     cid.Cid{},
+				GasUsed: usedGas,
+				Preview: true,
+			})
+		}
+
+		c, err := GetPorcelainAPI(env).MessageSendWithDefaultAddress(
+			req.Context,
+			fromAddr,
+			address.PaymentBrokerAddress,
+			types.NewAttoFILFromFIL(0),
+			gasPrice,
+			gasLimit,
+			"assignTarget",
+			payer,
+			channel,
+			newTarget,
+			payerSig,
+			targetSig,
+		)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&AssignTargetResult{
+			Cid:     c,
+			GasUsed: types.NewGasUnits(0),
+			Preview: false,
+		})
+	},
+	Type: &AssignTargetResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *AssignTargetResult) error {
+			if res.Preview {
+				output := strconv.FormatUint(uint64(res.GasUsed), 10)
+				_, err := w.Write([]byte(output))
+				return err
+			}
+			return PrintString(w, res.Cid)
+		}),
+	},
+}
@@ -176,6 +176,62 @@ func TestPaymentChannelVoucherSuccess(t *testing.T) {
 	assert.Equal(t, voucherAmount, &voucher.Amount)
 }
 
+func TestPaymentChannelVouchersExportImport(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(30*time.Second))
+	defer cancel()
+
+	// Get basic testing environment
+	ctx, env := fastesting.NewTestEnvironment(ctx, t, fast.EnvironmentOpts{})
+
+	// Teardown after test ends
+	defer func() {
+		err := env.Teardown(ctx)
+		require.NoError(t, err)
+	}()
+
+	// Start test
+	rsrc := requireNewPaychResource(ctx, t, env)
+
+	channelExpiry := types.NewBlockHeight(20)
+	channelAmount := types.NewAttoFILFromFIL(1000)
+
+	chanid, _ := rsrc.requirePaymentChannel(ctx, t, channelAmount, channelExpiry)
+
+	voucherValidAt := types.NewBlockHeight(0)
+	for _, amt := range []uint64{10, 20} {
+		_, err := rsrc.payer.PaychVoucher(ctx, chanid, types.NewAttoFILFromFIL(amt), fast.AOFromAddr(rsrc.payerAddr), fast.AOValidAt(voucherValidAt))
+		require.NoError(t, err)
+	}
+
+	exported, err := rsrc.payer.PaychVouchersExport(ctx, chanid, fast.AOPayer(rsrc.payerAddr))
+	require.NoError(t, err)
+
+	imported, err := rsrc.target.PaychVouchersImport(ctx, exported, fast.AOFromAddr(rsrc.targetAddr))
+	require.NoError(t, err)
+	require.Len(t, imported, 2)
+
+	for _, voucher := range imported {
+		assert.Equal(t, *chanid, voucher.Channel)
+		assert.Equal(t, rsrc.payerAddr, voucher.Payer)
+		assert.Equal(t, rsrc.targetAddr, voucher.Target)
+	}
+
+	// An imported voucher can be redeemed by the target.
+	voucherStr, err := imported[1].Encode()
+	require.NoError(t, err)
+
+	mcid, err := rsrc.target.PaychRedeem(ctx, voucherStr, fast.AOFromAddr(rsrc.targetAddr), fast.AOPrice(big.NewFloat(1)), fast.AOLimit(300))
+	require.NoError(t, err)
+
+	series.CtxMiningOnce(ctx)
+
+	resp, err := rsrc.target.MessageWait(ctx, mcid)
+	require.NoError(t, err)
+	assert.Equal(t, 0, int(resp.Receipt.ExitCode))
+}
+
 func TestPaymentChannelRedeemSuccess(t *testing.T) {
 	tf.IntegrationTest(t)
 
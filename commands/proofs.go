@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	cmdkit "github.com/ipfs/go-ipfs-cmdkit"
+	cmds "github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/proofs/bench"
+	"github.com/filecoin-project/go-filecoin/proofs/params"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var proofsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage the proof parameters and benchmark the proofs",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"bench":        proofsBenchCmd,
+		"fetch-params": proofsFetchParamsCmd,
+	},
+}
+
+var proofsFetchParamsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Download the Groth parameters needed to seal sectors",
+		ShortDescription: `
+Downloads the parameter files listed in the parameter manifest into the
+parameter cache, verifying them against the checksums of the manifest. Files
+already in the cache are verified and only downloaded again if they do not
+match. With --verify-only, the cache is checked without downloading anything.
+`,
+		LongDescription: `
+Downloads the parameter files listed in the parameter manifest into the
+parameter cache, verifying them against the checksums of the manifest. Files
+already in the cache are verified and only downloaded again if they do not
+match. With --verify-only, the cache is checked without downloading anything.
+
+The cache is the directory named by FILECOIN_PARAMETER_CACHE, by default
+/tmp/filecoin-proof-parameters. The manifest is the parameters.json shipped
+next to the go-filecoin binary, or proofs/misc/parameters.json in a source
+tree, unless --manifest names another.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.Uint64Option("sector-size", "size in bytes of the sectors to fetch parameters for, all sizes if omitted"),
+		cmdkit.StringOption("manifest", "path of the parameter manifest"),
+		cmdkit.StringOption("gateway", "IPFS gateway to download parameters from").WithDefault(params.DefaultGateway),
+		cmdkit.BoolOption("verify-only", "verify the cached parameters without downloading any"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		manifestPath, _ := req.Options["manifest"].(string)
+		if manifestPath == "" {
+			var err error
+			manifestPath, err = params.FindManifest()
+			if err != nil {
+				return err
+			}
+		}
+		manifest, err := params.LoadManifest(manifestPath)
+		if err != nil {
+			return err
+		}
+
+		sectorSize, _ := req.Options["sector-size"].(uint64)
+		pm := params.NewManager(manifest, params.CacheDir())
+		pm.Gateway = req.Options["gateway"].(string)
+		if len(pm.Names(sectorSize)) == 0 {
+			return fmt.Errorf("the manifest lists no parameters for sectors of %d bytes", sectorSize)
+		}
+
+		if verifyOnly, _ := req.Options["verify-only"].(bool); verifyOnly {
+			var failed int
+			for _, name := range pm.Names(sectorSize) {
+				status := "verified"
+				if err := pm.Verify(name); err != nil {
+					status = err.Error()
+					failed++
+				}
+				if err := re.Emit(&params.Progress{Name: name, Status: status}); err != nil {
+					return err
+				}
+			}
+			if failed > 0 {
+				return errors.Errorf("%d parameter files are missing or corrupt, run fetch-params to download them", failed)
+			}
+			return nil
+		}
+
+		var emitErr error
+		err = pm.Fetch(req.Context, sectorSize, func(p params.Progress) {
+			if emitErr == nil {
+				emitErr = re.Emit(&p)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return emitErr
+	},
+	Type: params.Progress{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, p *params.Progress) error {
+			if p.Status == "downloading" {
+				if p.Total > 0 {
+					_, err := fmt.Fprintf(w, "%s: downloaded %d of %d bytes (%d%%)\n", p.Name, p.Done, p.Total, p.Done*100/p.Total)
+					return err
+				}
+				_, err := fmt.Fprintf(w, "%s: downloaded %d bytes\n", p.Name, p.Done)
+				return err
+			}
+			_, err := fmt.Fprintf(w, "%s: %s\n", p.Name, p.Status)
+			return err
+		}),
+	},
+}
+
+var proofsBenchCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Measure how fast this machine seals sectors and generates PoSts",
+		ShortDescription: `
+Adds a sector's worth of random data, seals it and generates a proof of
+spacetime for it, as many times as --rounds, in a temporary directory. Reports
+the wall time, CPU time and throughput of each phase and the peak memory used,
+to size hardware before committing storage. The parameters for the sector size
+must be in the parameter cache, see fetch-params.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.Uint64Option("sector-size", "size in bytes of the sectors to seal").WithDefault(types.OneKiBSectorSize.Uint64()),
+		cmdkit.IntOption("rounds", "number of sectors to seal").WithDefault(1),
+		cmdkit.StringOption("dir", "directory to create the temporary sector directories in"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		dir, _ := req.Options["dir"].(string)
+		report, err := bench.Run(req.Context, req.Options["sector-size"].(uint64), req.Options["rounds"].(int), dir)
+		if err != nil {
+			return err
+		}
+		return re.Emit(report)
+	},
+	Type: bench.Report{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, report *bench.Report) error {
+			f := NewFormatter(req)
+			sw := NewSilentWriter(w)
+			sw.Printf("Sector size: %s\n", f.Size(report.SectorSize))
+			sw.Printf("Rounds:      %d\n", report.Rounds)
+			sw.Printf("Peak memory: %s\n\n", f.Size(report.MaxRSS))
+			if err := sw.Error(); err != nil {
+				return err
+			}
+
+			table := f.NewTable(w, "PHASE", "WALL TIME", "PER ROUND", "CPU TIME", "THROUGHPUT")
+			for _, p := range report.Phases {
+				table.Row(
+					p.Name,
+					f.Duration(p.WallTime),
+					f.Duration(p.WallTime/time.Duration(report.Rounds)),
+					f.Duration(p.CPUTime),
+					f.Size(uint64(p.Throughput()))+"/s",
+				)
+			}
+			return table.Flush()
+		}),
+	},
+}
@@ -1,8 +1,8 @@
 package commands
 
 import (
-	"fmt"
 	"io"
+	"time"
 
 	cmdkit "github.com/ipfs/go-ipfs-cmdkit"
 	cmds "github.com/ipfs/go-ipfs-cmds"
@@ -24,17 +24,13 @@ var protocolCmd = &cmds.Command{
 	Type: porcelain.ProtocolParams{},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, pp *porcelain.ProtocolParams) error {
-			_, err := fmt.Fprintf(w, "Auto-Seal Interval: %d seconds\nSector Sizes:\n", pp.AutoSealInterval)
-			if err != nil {
-				return err
-			}
+			f := NewFormatter(req)
+			sw := NewSilentWriter(w)
+			sw.Printf("Auto-Seal Interval: %s\nSector Sizes:\n", f.Duration(time.Duration(pp.AutoSealInterval)*time.Second))
 			for _, sectorSize := range pp.SectorSizes {
-				_, err := fmt.Fprintf(w, "\t%d bytes\n", sectorSize)
-				if err != nil {
-					return err
-				}
+				sw.Printf("\t%s\n", f.Size(sectorSize))
 			}
-			return nil
+			return sw.Error()
 		}),
 	},
 }
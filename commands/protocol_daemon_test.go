@@ -18,5 +18,5 @@ func TestProtocol(t *testing.T) {
 	protocol := d.RunSuccess("protocol")
 
 	protocolContent := protocol.ReadStdout()
-	assert.Contains(t, protocolContent, "Auto-Seal Interval: 120 seconds")
+	assert.Contains(t, protocolContent, "Auto-Seal Interval: 2m0s")
 }
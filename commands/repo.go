@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	cmdkit "github.com/ipfs/go-ipfs-cmdkit"
+	cmds "github.com/ipfs/go-ipfs-cmds"
+
+	"github.com/filecoin-project/go-filecoin/node"
+	"github.com/filecoin-project/go-filecoin/repo"
+)
+
+var repoCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage the filecoin repo",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"fsck": repoFsckCmd,
+	},
+}
+
+// RepoFsckResult lists the problems found by repo fsck.
+type RepoFsckResult struct {
+	Problems []*repo.Problem `json:"problems"`
+}
+
+var repoFsckCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Check the integrity of the repo",
+		ShortDescription: `
+Checks the chain store for missing blocks and state roots, and for blocks that
+are not on the chain, and checks the storage miner's sector metadata against
+the sealed sector files. The daemon must not be running.
+`,
+		LongDescription: `
+Checks the chain store for missing blocks and state roots, and for blocks that
+are not on the chain, and checks the storage miner's sector metadata against
+the sealed sector files. The daemon must not be running.
+
+With --repair, blocks that are not on the chain are deleted, a chain that
+cannot be loaded is reset to the genesis block so that the node syncs it again,
+and sector metadata that cannot be decoded is moved aside.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("repair", "Repair the problems that can be repaired"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		rep, err := getRepo(req)
+		if err != nil {
+			return err
+		}
+		// The only error Close can return is that the repo has already been closed
+		defer rep.Close() // nolint: errcheck
+
+		repair, _ := req.Options["repair"].(bool)
+		problems, err := node.CheckRepo(req.Context, rep, repair)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&RepoFsckResult{Problems: problems})
+	},
+	Type: RepoFsckResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *RepoFsckResult) error {
+			if len(res.Problems) == 0 {
+				_, err := fmt.Fprintln(w, "no problems found")
+				return err
+			}
+			for _, problem := range res.Problems {
+				status := "problem"
+				if problem.Repaired {
+					status = "repaired"
+				}
+				if _, err := fmt.Fprintf(w, "%s: %s\n", status, problem.Description); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
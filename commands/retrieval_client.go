@@ -1,11 +1,15 @@
 package commands
 
 import (
+	"io"
+
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-cmdkit"
 	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 var retrievalClientCmd = &cmds.Command{
@@ -20,11 +24,24 @@ var retrievalClientCmd = &cmds.Command{
 var clientRetrievePieceCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Read out piece data stored by a miner on the network",
+		ShortDescription: `
+Reads out the piece with the given CID from the given miner. The piece is
+retrieved for free unless --channel is given, in which case each chunk of the
+piece is paid for with a voucher from that payment channel of --payer (the
+default address if not given). The retrieval fails without paying anything if
+the miner charges more than --max-price per chunk.
+`,
 	},
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("miner", true, false, "Retrieval miner actor address"),
 		cmdkit.StringArg("cid", true, false, "Content identifier of piece to read"),
 	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("path", "Path of a single file to read from within the piece's DAG, instead of the whole piece"),
+		cmdkit.StringOption("channel", "ID of the payment channel to pay for the piece from"),
+		cmdkit.StringOption("payer", "Address of the payer of the payment channel"),
+		cmdkit.StringOption("max-price", "Maximum price (FIL e.g. 0.00013) to pay for each chunk of the piece").WithDefault("0"),
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		minerAddr, err := address.NewFromString(req.Arguments[0])
 		if err != nil {
@@ -41,7 +58,43 @@ var clientRetrievePieceCmd = &cmds.Command{
 			return err
 		}
 
-		readCloser, err := GetRetrievalAPI(env).RetrievePiece(req.Context, pieceCID, mpid, minerAddr)
+		path, _ := req.Options["path"].(string)
+		channelOption, _ := req.Options["channel"].(string)
+
+		var readCloser io.ReadCloser
+		if channelOption != "" {
+			if path != "" {
+				return errors.New("paid retrieval of a path is not supported")
+			}
+
+			channel, ok := types.NewChannelIDFromString(channelOption, 10)
+			if !ok {
+				return errors.New("invalid channel id")
+			}
+
+			var payer address.Address
+			payer, err = optionalAddr(req.Options["payer"])
+			if err != nil {
+				return err
+			}
+			if payer.Empty() {
+				payer, err = GetPorcelainAPI(env).WalletDefaultAddress()
+				if err != nil {
+					return err
+				}
+			}
+
+			maxPrice, ok := types.NewAttoFILFromFILString(req.Options["max-price"].(string))
+			if !ok {
+				return ErrInvalidPrice
+			}
+
+			readCloser, err = GetRetrievalAPI(env).RetrievePaidPiece(req.Context, pieceCID, mpid, minerAddr, payer, channel, maxPrice)
+		} else if path != "" {
+			readCloser, err = GetRetrievalAPI(env).RetrievePiecePath(req.Context, pieceCID, path, mpid, minerAddr)
+		} else {
+			readCloser, err = GetRetrievalAPI(env).RetrievePiece(req.Context, pieceCID, mpid, minerAddr)
+		}
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs-cmdkit"
+	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var stateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Inspect the state of the chain",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"diff": stateDiffCmd,
+	},
+}
+
+var stateDiffCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the actors that differ between the states of two tipsets",
+		ShortDescription: `
+Compares the states resulting from two tipsets and prints every actor whose
+code, head, nonce or balance differs. Tipsets are given as the comma separated
+CIDs of their blocks and must be in the node's chain store. Results will be
+returned as address, field, value in the first state and value in the second
+state, tab separated, with - for actors missing from a state.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("from", true, false, "Comma separated block CIDs of the first tipset"),
+		cmdkit.StringArg("to", true, false, "Comma separated block CIDs of the second tipset"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("actor", "Only show the changes of the actor with this address"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		from, err := parseTipSetKey(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		to, err := parseTipSetKey(req.Arguments[1])
+		if err != nil {
+			return err
+		}
+
+		var filter address.Address
+		if o, ok := req.Options["actor"].(string); ok && o != "" {
+			if filter, err = address.NewFromString(o); err != nil {
+				return err
+			}
+		}
+
+		diffs, err := GetPorcelainAPI(env).StateDiff(req.Context, from, to)
+		if err != nil {
+			return err
+		}
+		for _, diff := range diffs {
+			if !filter.Empty() && diff.Address != filter {
+				continue
+			}
+			if err := re.Emit(diff); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: state.ActorDiff{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, diff *state.ActorDiff) error {
+			f := NewFormatter(req)
+			fields := []struct {
+				name  string
+				value func(*actor.Actor) string
+			}{
+				{"code", func(a *actor.Actor) string { return a.Code.String() }},
+				{"head", func(a *actor.Actor) string { return a.Head.String() }},
+				{"nonce", func(a *actor.Actor) string { return fmt.Sprintf("%d", a.Nonce) }},
+				{"balance", func(a *actor.Actor) string { return f.FIL(a.Balance) }},
+			}
+			for _, field := range fields {
+				before, after := "-", "-"
+				if diff.Before != nil {
+					before = field.value(diff.Before)
+				}
+				if diff.After != nil {
+					after = field.value(diff.After)
+				}
+				if before == after {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", diff.Address, field.name, before, after); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+// parseTipSetKey parses a comma separated list of block CIDs.
+func parseTipSetKey(s string) (types.SortedCidSet, error) {
+	var cids []cid.Cid
+	for _, c := range strings.Split(s, ",") {
+		decoded, err := cid.Decode(strings.TrimSpace(c))
+		if err != nil {
+			return types.SortedCidSet{}, errors.Wrapf(err, "invalid block cid %s", c)
+		}
+		cids = append(cids, decoded)
+	}
+	return types.NewSortedCidSet(cids...), nil
+}
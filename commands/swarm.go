@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/ipfs/go-ipfs-cmdkit"
 	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/libp2p/go-libp2p-peer"
 	ma "github.com/multiformats/go-multiaddr"
 
 	"github.com/filecoin-project/go-filecoin/net"
@@ -23,8 +25,12 @@ libp2p peers on the internet.
 `,
 	},
 	Subcommands: map[string]*cmds.Command{
-		"connect": swarmConnectCmd,
-		"peers":   swarmPeersCmd,
+		"bandwidth":  swarmBandwidthCmd,
+		"bans":       swarmBansCmd,
+		"connect":    swarmConnectCmd,
+		"disconnect": swarmDisconnectCmd,
+		"findpeer":   swarmFindPeerCmd,
+		"peers":      swarmPeersCmd,
 	},
 }
 
@@ -39,6 +45,8 @@ var swarmPeersCmd = &cmds.Command{
 		cmdkit.BoolOption("verbose", "v", "Display all extra information"),
 		cmdkit.BoolOption("streams", "Also list information about open streams for each peer"),
 		cmdkit.BoolOption("latency", "Also list information about latency to each peer"),
+		cmdkit.BoolOption("agent", "Also list the agent version of each peer"),
+		cmdkit.BoolOption("direction", "Also list whether each connection is inbound or outbound"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		verbose, _ := req.Options["verbose"].(bool)
@@ -54,6 +62,10 @@ var swarmPeersCmd = &cmds.Command{
 	},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ci *net.SwarmConnInfos) error {
+			verbose, _ := req.Options["verbose"].(bool)
+			agent, _ := req.Options["agent"].(bool)
+			direction, _ := req.Options["direction"].(bool)
+
 			pipfs := ma.ProtocolWithCode(ma.P_IPFS).Name
 			for _, info := range ci.Peers {
 				ids := fmt.Sprintf("/%s/%s", pipfs, info.Peer)
@@ -65,6 +77,12 @@ var swarmPeersCmd = &cmds.Command{
 				if info.Latency != "" {
 					fmt.Fprintf(w, " %s", info.Latency) // nolint: errcheck
 				}
+				if verbose || direction {
+					fmt.Fprintf(w, " %s", info.Direction) // nolint: errcheck
+				}
+				if (verbose || agent) && info.Agent != "" {
+					fmt.Fprintf(w, " %s", info.Agent) // nolint: errcheck
+				}
 				fmt.Fprintln(w) // nolint: errcheck
 
 				for _, s := range info.Streams {
@@ -122,3 +140,163 @@ go-filecoin swarm connect /ip4/104.131.131.82/tcp/4001/ipfs/QmaCpDMGvV2BGHeYERUE
 		}),
 	},
 }
+
+var swarmDisconnectCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Close the connections to the given peers.",
+		ShortDescription: `
+'go-filecoin swarm disconnect' closes the connections to peers, given by peer
+ID or by address:
+
+go-filecoin swarm disconnect QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("peer", true, true, "Peer ID or address of the peer to disconnect from.").EnableStdin(),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		results, err := GetPorcelainAPI(env).NetworkDisconnect(req.Arguments)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			if err := re.Emit(result); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+	Type: net.ConnectionResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, result net.ConnectionResult) error {
+			if result.Err != nil {
+				fmt.Fprintf(w, "disconnect %s failed: %s\n", result.PeerID.Pretty(), result.Err) // nolint: errcheck
+			} else {
+				fmt.Fprintf(w, "disconnect %s success\n", result.PeerID.Pretty()) // nolint: errcheck
+			}
+			return nil
+		}),
+	},
+}
+
+var swarmBandwidthCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the bandwidth used by each protocol.",
+		ShortDescription: `
+'go-filecoin swarm bandwidth' lists, for each protocol the node spoke since it
+started, the total bytes received and sent and the current rates in bytes per
+second.
+`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		for _, bw := range GetPorcelainAPI(env).NetworkGetBandwidthByProtocol() {
+			if err := re.Emit(bw); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: net.ProtocolBandwidth{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, bw net.ProtocolBandwidth) error {
+			_, err := fmt.Fprintf(w, "%s in: %d B (%.0f B/s) out: %d B (%.0f B/s)\n", bw.Protocol, bw.TotalIn, bw.RateIn, bw.TotalOut, bw.RateOut)
+			return err
+		}),
+	},
+}
+
+var swarmFindPeerCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Find the addresses of a peer and whether it is connected.",
+		ShortDescription: `
+'go-filecoin swarm findpeer' looks up the addresses of a peer through the
+router and reports whether the node has a connection open to it.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("peerID", true, false, "The ID of the peer to search for."),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		peerID, err := peer.IDB58Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		out, err := GetPorcelainAPI(env).NetworkLookupPeer(req.Context, peerID)
+		if err != nil {
+			return err
+		}
+		return re.Emit(out)
+	},
+	Type: net.PeerConnectivity{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, pc *net.PeerConnectivity) error {
+			status := "not connected"
+			if pc.Connected {
+				status = "connected"
+			}
+			fmt.Fprintf(w, "%s %s\n", pc.Peer, status) // nolint: errcheck
+			for _, addr := range pc.Addrs {
+				fmt.Fprintf(w, "  %s\n", addr) // nolint: errcheck
+			}
+			return nil
+		}),
+	},
+}
+
+var swarmBansCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the peers banned for sending invalid chains.",
+		ShortDescription: `
+'go-filecoin swarm bans' lists the peers the node banned for repeatedly sending
+it chains that break the rules of consensus, and when their bans expire. The
+node refuses connections from banned peers. Use 'go-filecoin swarm bans clear'
+to lift bans.
+`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		for _, ban := range GetPorcelainAPI(env).NetworkBans() {
+			if err := re.Emit(ban); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: net.PeerBan{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ban net.PeerBan) error {
+			_, err := fmt.Fprintf(w, "%s until %s\n", ban.Peer, ban.Until.Format(time.RFC3339))
+			return err
+		}),
+	},
+	Subcommands: map[string]*cmds.Command{
+		"clear": swarmBansClearCmd,
+	},
+}
+
+var swarmBansClearCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Lift the bans of peers.",
+		ShortDescription: `
+'go-filecoin swarm bans clear' lifts the bans of the given peers, or of all
+peers if none are given.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("peer", false, true, "Peer ID of the peer to unban."),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		peers := make([]peer.ID, len(req.Arguments))
+		for i, arg := range req.Arguments {
+			pid, err := peer.IDB58Decode(arg)
+			if err != nil {
+				return err
+			}
+			peers[i] = pid
+		}
+		GetPorcelainAPI(env).NetworkClearBans(peers...)
+		return nil
+	},
+}
@@ -3,6 +3,8 @@ package commands_test
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 )
@@ -29,3 +31,44 @@ func TestSwarmConnectPeersInvalid(t *testing.T) {
 		"swarm connect /ip4/hello",
 	)
 }
+
+func TestSwarmPeersDisconnect(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d1 := th.NewDaemon(t, th.SwarmAddr("/ip4/0.0.0.0/tcp/6000")).Start()
+	defer d1.ShutdownSuccess()
+
+	d2 := th.NewDaemon(t, th.SwarmAddr("/ip4/0.0.0.0/tcp/6001")).Start()
+	defer d2.ShutdownSuccess()
+
+	d1.ConnectSuccess(d2)
+	d2ID := d2.GetID()
+
+	peers := d1.RunSuccess("swarm", "peers", "--direction").ReadStdout()
+	assert.Contains(t, peers, d2ID+" outbound")
+	peers = d2.RunSuccess("swarm", "peers", "--direction").ReadStdout()
+	assert.Contains(t, peers, " inbound")
+
+	out := d1.RunSuccess("swarm", "disconnect", d2ID).ReadStdout()
+	assert.Equal(t, "disconnect "+d2ID+" success\n", out)
+
+	out = d1.RunSuccess("swarm", "disconnect", d2ID).ReadStdout()
+	assert.Equal(t, "disconnect "+d2ID+" failed: not connected\n", out)
+}
+
+func TestSwarmBans(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d1 := th.NewDaemon(t, th.SwarmAddr("/ip4/0.0.0.0/tcp/6000")).Start()
+	defer d1.ShutdownSuccess()
+
+	d2 := th.NewDaemon(t, th.SwarmAddr("/ip4/0.0.0.0/tcp/6001")).Start()
+	defer d2.ShutdownSuccess()
+
+	d1.ConnectSuccess(d2)
+
+	assert.Empty(t, d1.RunSuccess("swarm", "bans").ReadStdout())
+	d1.RunSuccess("swarm", "bans", "clear", d2.GetID())
+	d1.RunSuccess("swarm", "bans", "clear")
+	d1.RunFail("", "swarm", "bans", "clear", "notapeer")
+}
@@ -6,29 +6,102 @@ import (
 
 	cmdkit "github.com/ipfs/go-ipfs-cmdkit"
 	cmds "github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/flags"
+	"github.com/filecoin-project/go-filecoin/paths"
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/update"
 )
 
 type versionInfo struct {
 	// Commit, is the git sha that was used to build this version of go-filecoin.
 	Commit string
+	// Update, is the result of checking the release manifest, if requested.
+	Update *update.Result `json:",omitempty"`
 }
 
 var versionCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Show go-filecoin version information",
+		ShortDescription: `
+With --check-update, also fetches the release manifest configured in the
+repo's update.manifestUrl, reporting whether a newer release exists and
+warning about network upgrades this build does not support. Checking must be
+enabled by setting update.checkEnabled to true in the repo's config.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("check-update", "Check the release manifest for updates"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
-		return re.Emit(&versionInfo{
+		info := &versionInfo{
 			Commit: flags.Commit,
-		})
+		}
+
+		if checkUpdate, _ := req.Options["check-update"].(bool); checkUpdate {
+			res, err := checkForUpdate(req)
+			if err != nil {
+				return err
+			}
+			info.Update = res
+		}
+
+		return re.Emit(info)
 	},
 	Type: versionInfo{},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, vo *versionInfo) error {
 			_, err := fmt.Fprintf(w, "commit: %s\n", vo.Commit)
-			return err
+			if err != nil || vo.Update == nil {
+				return err
+			}
+
+			if vo.Update.UpdateAvailable {
+				_, err = fmt.Fprintf(w, "update available: %s\n", vo.Update.LatestVersion)
+			} else {
+				_, err = fmt.Fprintln(w, "up to date")
+			}
+			if err != nil {
+				return err
+			}
+			for _, warning := range vo.Update.Warnings {
+				if _, err := fmt.Fprintf(w, "warning: %s\n", warning); err != nil {
+					return err
+				}
+			}
+			return nil
 		}),
 	},
 }
+
+// checkForUpdate compares this build with the release manifest configured in
+// the local repo. The version command runs without a daemon, so the config is
+// read straight from the repo.
+func checkForUpdate(req *cmds.Request) (*update.Result, error) {
+	repoDir, _ := req.Options[OptionRepoDir].(string)
+	repoDir, err := paths.GetRepoPath(repoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := repo.ConfigFromRepoPath(repoDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read repo config")
+	}
+
+	if !cfg.Update.CheckEnabled {
+		return nil, errors.New("update checks are disabled, set update.checkEnabled to true in the config to enable them")
+	}
+	if cfg.Update.ManifestURL == "" {
+		return nil, errors.New("no release manifest configured, set update.manifestUrl in the config")
+	}
+
+	manifest, err := update.FetchManifest(req.Context, cfg.Update.ManifestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return update.Check(manifest, flags.Commit, consensus.NetworkByName(cfg.Net)), nil
+}
@@ -2,13 +2,17 @@ package commands_test
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os/exec"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/filecoin-project/go-filecoin/consensus"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 func TestVersion(t *testing.T) {
@@ -28,3 +32,25 @@ func TestVersion(t *testing.T) {
 	version := string(verOut)
 	assert.Exactly(t, version, fmt.Sprintf("commit: %s", commit))
 }
+
+func TestVersionCheckUpdate(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		latest := consensus.DefaultProtocolVersions.VersionAt(types.NewBlockHeight(1000))
+		fmt.Fprintf(w, `{"latestVersion":"0.9.0","latestCommit":"newer","upgrades":[{"network":"","height":1000,"protocolVersion":%d}]}`, latest+1) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	d := th.NewDaemon(t).Start()
+	defer d.ShutdownSuccess()
+
+	d.RunFail("update checks are disabled", "version", "--check-update")
+
+	d.RunSuccess("config", "update.checkEnabled", "true")
+	d.RunSuccess("config", "update.manifestUrl", server.URL)
+
+	out := d.RunSuccess("version", "--check-update").ReadStdout()
+	assert.Contains(t, out, "update available: 0.9.0")
+	assert.Contains(t, out, "warning: network upgrade at height 1000")
+}
@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	cmdkit "github.com/ipfs/go-ipfs-cmdkit"
+	cmds "github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var vestingCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage funds that vest over time",
+		ShortDescription: `
+A vesting account locks funds and releases them to a beneficiary by block
+height. Nothing is released before the cliff. From the cliff on, the funds are
+released linearly over the blocks from start to end.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"create":   vestingCreateCmd,
+		"withdraw": vestingWithdrawCmd,
+		"revoke":   vestingRevokeCmd,
+	},
+}
+
+var vestingCreateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Lock --value FIL to be released to <beneficiary> between <start> and <end>",
+		ShortDescription: `Issues a new message to the network to create the vesting account, then waits
+for the message to be mined as this is required to return its address. Use a
+<cliff> equal to <start> for a linear schedule and equal to <end> to release
+everything at once. The sender may revoke the funds that have not vested.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("beneficiary", true, false, "Address the funds are released to"),
+		cmdkit.StringArg("start", true, false, "Block height at which the funds start vesting"),
+		cmdkit.StringArg("cliff", true, false, "Block height before which nothing is released"),
+		cmdkit.StringArg("end", true, false, "Block height at which all funds have vested"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("value", "Amount of FIL to lock").WithDefault("0"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		beneficiary, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid beneficiary address")
+		}
+
+		var heights [3]*types.BlockHeight
+		for i, arg := range req.Arguments[1:4] {
+			height, ok := types.NewBlockHeightFromString(arg, 10)
+			if !ok {
+				return fmt.Errorf("invalid block height %s", arg)
+			}
+			heights[i] = height
+		}
+
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		value, ok := types.NewAttoFILFromFILString(req.Options["value"].(string))
+		if !ok {
+			return ErrInvalidAmount
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		addr, err := GetPorcelainAPI(env).VestingCreate(req.Context, fromAddr, gasPrice, gasLimit, beneficiary, heights[0], heights[1], heights[2], value)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(addr)
+	},
+	Type: address.Address{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, a address.Address) error {
+			return PrintString(w, a)
+		}),
+	},
+}
+
+var vestingWithdrawCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Withdraw the funds of a vesting account that have vested",
+		ShortDescription: `Issues a new message to the network sending the vested funds that have not
+been withdrawn yet to the beneficiary, who must be the sender.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("vesting", true, false, "Address of the vesting account"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		vestingAddr, fromAddr, err := parseVestingArgs(req)
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		c, err := GetPorcelainAPI(env).VestingWithdraw(req.Context, fromAddr, vestingAddr, gasPrice, gasLimit)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
+}
+
+var vestingRevokeCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Revoke the funds of a vesting account that have not vested",
+		ShortDescription: `Issues a new message to the network stopping the schedule and returning the
+funds that have not vested to the creator of the account, who must be the
+sender. The beneficiary may still withdraw what vested before.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("vesting", true, false, "Address of the vesting account"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		vestingAddr, fromAddr, err := parseVestingArgs(req)
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req, env)
+		if err != nil {
+			return err
+		}
+
+		c, err := GetPorcelainAPI(env).VestingRevoke(req.Context, fromAddr, vestingAddr, gasPrice, gasLimit)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
+}
+
+// parseVestingArgs parses the <vesting> argument and --from option shared by
+// withdraw and revoke.
+func parseVestingArgs(req *cmds.Request) (address.Address, address.Address, error) {
+	vestingAddr, err := address.NewFromString(req.Arguments[0])
+	if err != nil {
+		return address.Undef, address.Undef, errors.Wrap(err, "invalid vesting address")
+	}
+
+	fromAddr, err := optionalAddr(req.Options["from"])
+	if err != nil {
+		return address.Undef, address.Undef, err
+	}
+
+	return vestingAddr, fromAddr, nil
+}
@@ -0,0 +1,74 @@
+package commands_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/fixtures"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestVesting(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(
+		t,
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[1]),
+		th.DefaultAddress(fixtures.TestAddresses[0]),
+	).Start()
+	defer d.ShutdownSuccess()
+
+	owner := fixtures.TestAddresses[0]
+	beneficiary := fixtures.TestAddresses[1]
+
+	createVesting := func(end string) address.Address {
+		var vestingAddr address.Address
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out := d.RunSuccess("vesting", "create", beneficiary, "0", "0", end, "--value", "100", "--from", owner, "--gas-price", "1", "--gas-limit", "300")
+			var err error
+			vestingAddr, err = address.NewFromString(out.ReadStdoutTrimNewlines())
+			assert.NoError(t, err)
+		}()
+		d.MineAndPropagate(time.Second)
+		wg.Wait()
+		require.NotEqual(t, address.Undef, vestingAddr)
+		return vestingAddr
+	}
+
+	sendAndMine := func(args ...string) {
+		args = append(args, "--gas-price", "1", "--gas-limit", "300")
+		msgCid, err := cid.Parse(d.RunSuccess(args...).ReadStdoutTrimNewlines())
+		require.NoError(t, err)
+		d.RunSuccess("mining", "once")
+		d.WaitForMessageRequireSuccess(msgCid)
+	}
+
+	balance := func(addr string) string {
+		return strings.TrimSpace(d.RunSuccess("wallet", "balance", "--no-humanize", addr).ReadStdout())
+	}
+
+	// a schedule ending at height 1 has fully vested by the time it is mined
+	vested := createVesting("1")
+	before := balance(beneficiary)
+	sendAndMine("vesting", "withdraw", vested.String(), "--from", beneficiary)
+	assert.NotEqual(t, before, balance(beneficiary))
+	assert.Equal(t, "0", balance(vested.String()))
+
+	// revoking a schedule that has barely started returns most of its funds
+	unvested := createVesting("1000000")
+	sendAndMine("vesting", "revoke", unvested.String(), "--from", owner)
+	assert.NotEqual(t, "100", balance(unvested.String()))
+}
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ipfs/go-ipfs-cmdkit"
+	"github.com/ipfs/go-ipfs-cmds"
+
+	"github.com/filecoin-project/go-filecoin/address"
+)
+
+var walletLockCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Lock the wallet",
+		ShortDescription: `
+Locks the wallet so that its keys can neither sign nor be exported until it is
+unlocked with its passphrase. If the wallet keys are not encrypted yet, a
+passphrase must be given to encrypt them with.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("passphrase", false, false, "Passphrase to encrypt the wallet keys with, if they are not encrypted yet"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		api := GetPorcelainAPI(env)
+		if len(req.Arguments) > 0 {
+			return api.WalletEncrypt([]byte(req.Arguments[0]))
+		}
+		return api.WalletLock()
+	},
+}
+
+var walletUnlockCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Unlock the wallet with its passphrase",
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("passphrase", true, false, "Passphrase of the wallet").EnableStdin(),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("timeout", "Lock the wallet again after this duration, 0 to keep it unlocked").WithDefault("5m"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		timeout, err := time.ParseDuration(req.Options["timeout"].(string))
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %s", err)
+		}
+		return GetPorcelainAPI(env).WalletUnlock([]byte(req.Arguments[0]), timeout)
+	},
+}
+
+// WalletSessionResult is the result of starting a signing session.
+type WalletSessionResult struct {
+	Token string
+}
+
+var walletSessionCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage the keys usable to sign while the wallet is locked",
+		ShortDescription: `
+A session lets the keys of some addresses, such as the one a miner signs its
+blocks with, sign while the wallet is locked. Starting a session requires the
+wallet to be unlocked and returns a token. The session survives restarts of
+the daemon when it is resumed with the token, either with 'wallet session
+resume' or by setting the FIL_WALLET_SESSION environment variable of the
+daemon to the token.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"start":  walletSessionStartCmd,
+		"resume": walletSessionResumeCmd,
+		"end":    walletSessionEndCmd,
+	},
+}
+
+var walletSessionStartCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Start a session for the given addresses, ending the previous one",
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("addresses", true, true, "Addresses whose keys sign during the session"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		addrs := make([]address.Address, len(req.Arguments))
+		for i, arg := range req.Arguments {
+			addr, err := address.NewFromString(arg)
+			if err != nil {
+				return err
+			}
+			addrs[i] = addr
+		}
+
+		token, err := GetPorcelainAPI(env).WalletStartSession(addrs)
+		if err != nil {
+			return err
+		}
+		return re.Emit(&WalletSessionResult{Token: hex.EncodeToString(token)})
+	},
+	Type: &WalletSessionResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *WalletSessionResult) error {
+			_, err := fmt.Fprintln(w, res.Token)
+			return err
+		}),
+	},
+}
+
+var walletSessionResumeCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Resume the session started with the given token",
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("token", true, false, "Token of the session").EnableStdin(),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		token, err := hex.DecodeString(req.Arguments[0])
+		if err != nil {
+			return fmt.Errorf("invalid token: %s", err)
+		}
+		return GetPorcelainAPI(env).WalletResumeSession(token)
+	},
+}
+
+var walletSessionEndCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "End the session",
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		return GetPorcelainAPI(env).WalletEndSession()
+	},
+}
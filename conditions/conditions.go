@@ -0,0 +1,57 @@
+// Package conditions builds the predicates payment vouchers can be
+// conditioned on. Each predicate targets a method of a builtin actor that
+// succeeds only when the condition holds, and is evaluated by the payment
+// broker when the voucher is redeemed, with parameters supplied by the
+// redeemer appended to those of the predicate.
+package conditions
+
+import (
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+const (
+	// PieceInclusionMethod is the miner actor method checking that a piece is
+	// included in a committed sector.
+	PieceInclusionMethod = "verifyPieceInclusion"
+	// PieceStorageMethod is the miner actor method checking that a piece is
+	// included in a committed sector the miner still proves it stores.
+	PieceStorageMethod = "verifyPieceStorage"
+)
+
+// PieceInclusion returns a condition that holds once the piece commP is
+// included in a sector committed by miner, as long as the miner keeps up with
+// its PoSts. The redeemer supplies the sector id and the inclusion proof, see
+// PieceInclusionParams.
+func PieceInclusion(miner address.Address, commP types.CommP) *types.Predicate {
+	return &types.Predicate{
+		To:     miner,
+		Method: PieceInclusionMethod,
+		Params: []interface{}{commP[:]},
+	}
+}
+
+// PieceInclusionParams returns the parameters the redeemer of a voucher
+// conditioned on PieceInclusion supplies.
+func PieceInclusionParams(sectorID uint64, proof []byte) []interface{} {
+	return []interface{}{sectorID, proof}
+}
+
+// PieceStorage returns a condition that holds while miner stores the piece
+// commP in sector sectorID: the sector is committed and the miner is not late
+// proving its storage. Conditioning the vouchers of a storage deal paid over
+// time on it pays the miner only for continued storage. The redeemer supplies
+// the inclusion proof of the piece, see PieceStorageParams.
+func PieceStorage(miner address.Address, commP types.CommP, sectorID uint64) *types.Predicate {
+	return &types.Predicate{
+		To:     miner,
+		Method: PieceStorageMethod,
+		Params: []interface{}{commP[:], sectorID},
+	}
+}
+
+// PieceStorageParams returns the parameters the redeemer of a voucher
+// conditioned on PieceStorage supplies.
+func PieceStorageParams(proof []byte) []interface{} {
+	return []interface{}{proof}
+}
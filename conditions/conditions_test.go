@@ -0,0 +1,51 @@
+package conditions_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/conditions"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// requireMatchesSignature checks that the params of condition followed by
+// the redeemer supplied params match the signature of the condition method.
+func requireMatchesSignature(t *testing.T, condition *types.Predicate, supplied []interface{}) {
+	sig, ok := (&miner.Actor{}).Exports()[condition.Method]
+	require.True(t, ok, "miner actor does not export %s", condition.Method)
+
+	vals, err := abi.ToValues(append(condition.Params, supplied...))
+	require.NoError(t, err)
+	require.Len(t, vals, len(sig.Params))
+	for i, v := range vals {
+		assert.Equal(t, sig.Params[i], v.Type)
+	}
+}
+
+func TestPieceInclusion(t *testing.T) {
+	tf.UnitTest(t)
+
+	commP := types.CommP{1, 2, 3}
+	condition := conditions.PieceInclusion(address.TestAddress, commP)
+	assert.Equal(t, address.TestAddress, condition.To)
+	assert.Equal(t, commP[:], condition.Params[0])
+
+	requireMatchesSignature(t, condition, conditions.PieceInclusionParams(7, []byte("proof")))
+}
+
+func TestPieceStorage(t *testing.T) {
+	tf.UnitTest(t)
+
+	commP := types.CommP{1, 2, 3}
+	condition := conditions.PieceStorage(address.TestAddress, commP, 7)
+	assert.Equal(t, address.TestAddress, condition.To)
+	assert.Equal(t, []interface{}{commP[:], uint64(7)}, condition.Params)
+
+	requireMatchesSignature(t, condition, conditions.PieceStorageParams([]byte("proof")))
+}
@@ -8,7 +8,9 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
@@ -25,8 +27,13 @@ type Config struct {
 	Mpool         *MessagePoolConfig   `json:"mpool"`
 	Net           string               `json:"net"`
 	Observability *ObservabilityConfig `json:"observability"`
+	Paych         *PaychConfig         `json:"paych"`
+	Pruning       *PruningConfig       `json:"pruning"`
 	SectorBase    *SectorBaseConfig    `json:"sectorbase"`
 	Swarm         *SwarmConfig         `json:"swarm"`
+	Sync          *SyncConfig          `json:"sync"`
+	TimeSync      *TimeSyncConfig      `json:"timesync"`
+	Update        *UpdateConfig        `json:"update"`
 	Wallet        *WalletConfig        `json:"wallet"`
 }
 
@@ -36,6 +43,10 @@ type APIConfig struct {
 	AccessControlAllowOrigin      []string `json:"accessControlAllowOrigin"`
 	AccessControlAllowCredentials bool     `json:"accessControlAllowCredentials"`
 	AccessControlAllowMethods     []string `json:"accessControlAllowMethods"`
+	// AuthSecret is the hex encoded secret the tokens authenticating the
+	// requests to the JSON-RPC API are signed with. The JSON-RPC API is
+	// disabled while it is empty.
+	AuthSecret string `json:"authSecret,omitempty"`
 }
 
 func newDefaultAPIConfig() *APIConfig {
@@ -64,7 +75,17 @@ type DatastoreConfig struct {
 // the given key and value are valid. Validators will only be run if a property
 // being set matches the name given in this map.
 var Validators = map[string]func(string, string) error{
-	"heartbeat.nickname": validateLettersOnly,
+	"api.address":                          validateMultiaddr,
+	"bootstrap.period":                     validateDuration,
+	"heartbeat.beatPeriod":                 validateDuration,
+	"heartbeat.nickname":                   validateLettersOnly,
+	"heartbeat.reconnectPeriod":            validateDuration,
+	"mining.dealPolicy.hookTimeout":        validateDuration,
+	"observability.metrics.reportInterval": validateDuration,
+	"swarm.address":                        validateMultiaddr,
+	"sync.chainWeight":                     validateChainWeight,
+	"timesync.checkPeriod":                 validateDuration,
+	"timesync.maxDrift":                    validateDuration,
 }
 
 func newDefaultDatastoreConfig() *DatastoreConfig {
@@ -78,6 +99,9 @@ func newDefaultDatastoreConfig() *DatastoreConfig {
 type SwarmConfig struct {
 	Address            string `json:"address"`
 	PublicRelayAddress string `json:"public_relay_address,omitempty"`
+	// EnableNATPortMap makes the node ask the router for a port mapping with
+	// UPnP or NAT-PMP, so that it can be dialed from behind a NAT.
+	EnableNATPortMap bool `json:"enableNatPortMap,omitempty"`
 }
 
 func newDefaultSwarmConfig() *SwarmConfig {
@@ -107,19 +131,103 @@ type MiningConfig struct {
 	MinerAddress            address.Address `json:"minerAddress"`
 	AutoSealIntervalSeconds uint            `json:"autoSealIntervalSeconds"`
 	StoragePrice            *types.AttoFIL  `json:"storagePrice"`
+	// RetrievalPrice is the price the miner charges for each chunk of a
+	// piece served over the paid retrieval protocol. When it is not zero
+	// the miner refuses retrievals over the free protocol.
+	RetrievalPrice *types.AttoFIL `json:"retrievalPrice"`
+	// MinBlockMessages is the number of pending messages below which the
+	// miner waits for more messages before generating a block.
+	MinBlockMessages uint `json:"minBlockMessages"`
+	// MaxMessageWaitMilliseconds bounds how long the miner waits for
+	// MinBlockMessages to arrive. Zero disables waiting.
+	MaxMessageWaitMilliseconds uint `json:"maxMessageWaitMilliseconds"`
+	// RemoveExpiredSectors makes the miner remove the sectors in which all
+	// deals ended, reducing its power and deleting their replicas, rather
+	// than keep proving them.
+	RemoveExpiredSectors bool `json:"removeExpiredSectors"`
+	// DealPolicy holds the rules the miner accepts storage deals by.
+	DealPolicy *DealPolicyConfig `json:"dealPolicy"`
+}
+
+// DealPolicyConfig holds the rules a miner applies to the storage deals
+// proposed to it, on top of requiring that they pay its storage price.
+type DealPolicyConfig struct {
+	// MinPrice is the lowest price per byte per block the miner accepts.
+	MinPrice *types.AttoFIL `json:"minPrice"`
+	// MaxPieceSize is the size in bytes of the largest piece the miner
+	// accepts. Zero leaves the sector size as the only limit.
+	MaxPieceSize uint64 `json:"maxPieceSize"`
+	// ClientWhitelist, when not empty, lists the only clients the miner
+	// accepts deals from.
+	ClientWhitelist []address.Address `json:"clientWhitelist"`
+	// ClientBlacklist lists clients the miner rejects deals from.
+	ClientBlacklist []address.Address `json:"clientBlacklist"`
+	// MaxOpenDeals is the number of accepted deals not yet posted to the
+	// chain above which the miner rejects new deals. Zero means no limit.
+	MaxOpenDeals uint `json:"maxOpenDeals"`
+	// Hook is the path of an executable deciding on deals that pass the other
+	// rules. It gets the proposal as JSON on its standard input and accepts
+	// the deal by exiting with status zero. Its output is the reason for a
+	// rejection. An empty path disables the hook.
+	Hook string `json:"hook"`
+	// HookTimeout bounds how long the hook may run before the deal is
+	// rejected. Golang duration units are accepted.
+	HookTimeout string `json:"hookTimeout"`
 }
 
 func newDefaultMiningConfig() *MiningConfig {
 	return &MiningConfig{
-		MinerAddress:            address.Undef,
-		AutoSealIntervalSeconds: 120,
-		StoragePrice:            types.NewZeroAttoFIL(),
+		MinerAddress:               address.Undef,
+		AutoSealIntervalSeconds:    120,
+		StoragePrice:               types.NewZeroAttoFIL(),
+		RetrievalPrice:             types.NewZeroAttoFIL(),
+		MinBlockMessages:           0,
+		MaxMessageWaitMilliseconds: 0,
+		RemoveExpiredSectors:       false,
+		DealPolicy:                 newDefaultDealPolicyConfig(),
+	}
+}
+
+func newDefaultDealPolicyConfig() *DealPolicyConfig {
+	return &DealPolicyConfig{
+		MinPrice:        types.NewZeroAttoFIL(),
+		MaxPieceSize:    0,
+		ClientWhitelist: []address.Address{},
+		ClientBlacklist: []address.Address{},
+		MaxOpenDeals:    0,
+		Hook:            "",
+		HookTimeout:     "30s",
+	}
+}
+
+// TimeSyncConfig holds all configuration options related to checking the
+// local clock against an NTP server.
+type TimeSyncConfig struct {
+	// NTPServer is the NTP server the clock is checked against. An empty
+	// server disables the check.
+	NTPServer string `json:"ntpServer"`
+	// CheckPeriod represents how frequently the clock is checked.
+	// Golang duration units are accepted.
+	CheckPeriod string `json:"checkPeriod"`
+	// MaxDrift is how far the clock may drift before the node refuses to mine.
+	// Golang duration units are accepted.
+	MaxDrift string `json:"maxDrift"`
+}
+
+func newDefaultTimeSyncConfig() *TimeSyncConfig {
+	return &TimeSyncConfig{
+		NTPServer:   "pool.ntp.org",
+		CheckPeriod: "10m",
+		MaxDrift:    "5s",
 	}
 }
 
 // WalletConfig holds all configuration options related to the wallet.
 type WalletConfig struct {
 	DefaultAddress address.Address `json:"defaultAddress,omitempty"`
+	// ExternalSigners lists the unix socket paths of external signer processes
+	// holding the keys of some of the wallet addresses.
+	ExternalSigners []string `json:"externalSigners,omitempty"`
 }
 
 func newDefaultWalletConfig() *WalletConfig {
@@ -207,12 +315,71 @@ type MessagePoolConfig struct {
 	MaxPoolSize int `json:"maxPoolSize"`
 	// MaxNonceGap is the maximum nonce of a message past the last received on chain
 	MaxNonceGap types.Uint64 `json:"maxNonceGap"`
+	// WaitConfidence is the number of tipsets that must be on top of the
+	// tipset executing a message before waiting for the message returns.
+	WaitConfidence uint64 `json:"waitConfidence"`
+	// MaxMessageSize is the maximum size in bytes of a message received from
+	// the network.
+	MaxMessageSize int `json:"maxMessageSize"`
+	// PeerMessageRate is the number of messages per second a peer may send
+	// on average before its messages are rejected.
+	PeerMessageRate float64 `json:"peerMessageRate"`
+	// PeerMessageBurst is the number of messages a peer may send at once
+	// above its rate.
+	PeerMessageBurst int `json:"peerMessageBurst"`
+	// MessageTimeout is the number of tipsets after which messages that
+	// have not been mined are dropped from the pool.
+	MessageTimeout uint64 `json:"messageTimeout"`
+	// ReplaceByFeeBump is the percentage by which the gas price of a message
+	// must exceed that of the pending message with the same sender and nonce
+	// to replace it.
+	ReplaceByFeeBump uint64 `json:"replaceByFeeBump"`
 }
 
 func newDefaultMessagePoolConfig() *MessagePoolConfig {
 	return &MessagePoolConfig{
-		MaxPoolSize: 10000,
-		MaxNonceGap: 100,
+		MaxPoolSize:      10000,
+		MaxNonceGap:      100,
+		WaitConfidence:   0,
+		MaxMessageSize:   32 << 10,
+		PeerMessageRate:  10,
+		PeerMessageBurst: 100,
+		MessageTimeout:   6,
+		ReplaceByFeeBump: 10,
+	}
+}
+
+// PaychConfig holds all configuration options related to payment channels.
+type PaychConfig struct {
+	// Watch makes the node dispute the cancellation of the payment channels
+	// it is the target of by redeeming their latest stored voucher, so that
+	// payees need not be around when payers cancel channels.
+	Watch bool `json:"watch"`
+}
+
+func newDefaultPaychConfig() *PaychConfig {
+	return &PaychConfig{
+		Watch: false,
+	}
+}
+
+// PruningConfig holds all configuration options related to discarding the
+// states of old tipsets.
+type PruningConfig struct {
+	// KeepStates is the number of rounds below the head whose tipsets keep
+	// their states. The states of older tipsets are discarded, keeping only
+	// their headers, to bound disk usage. It must be at least the sync
+	// finality. Zero disables pruning.
+	KeepStates uint64 `json:"keepStates"`
+	// Period is how often the node prunes old states.
+	// Golang duration units are accepted.
+	Period string `json:"period"`
+}
+
+func newDefaultPruningConfig() *PruningConfig {
+	return &PruningConfig{
+		KeepStates: 0,
+		Period:     "1h",
 	}
 }
 
@@ -222,11 +389,75 @@ type SectorBaseConfig struct {
 	// RootDir is the path to the root directory holding sector data.
 	// If empty the default of <homedir>/sectors is implied.
 	RootDir string `json:"rootdir"`
+
+	// SealedPaths are directories, usually on separate disks, sealed sectors
+	// are moved to in proportion to their weights, skipping those without
+	// room for them. If empty, sealed sectors stay under RootDir.
+	SealedPaths []SealedPathConfig `json:"sealedPaths"`
+}
+
+// SealedPathConfig is a directory sealed sectors are distributed to.
+type SealedPathConfig struct {
+	Path string `json:"path"`
+	// Weight is the share of the sealed sectors the path gets relative to the
+	// other paths. A weight of zero counts as one.
+	Weight uint64 `json:"weight"`
 }
 
 func newDefaultSectorbaseConfig() *SectorBaseConfig {
 	return &SectorBaseConfig{
-		RootDir: "",
+		RootDir:     "",
+		SealedPaths: []SealedPathConfig{},
+	}
+}
+
+// SyncConfig holds all configuration options related to syncing the chain.
+type SyncConfig struct {
+	// CheckpointTipSet is the comma separated cids of the blocks of a tipset
+	// trusted to be on the chain. When set, the node syncs the chain back to
+	// this tipset and fetches the state at it instead of executing all the
+	// messages before it.
+	CheckpointTipSet string `json:"checkpointTipSet"`
+	// CheckpointStateRoot is the state root resulting from applying the
+	// checkpoint tipset.
+	CheckpointStateRoot string `json:"checkpointStateRoot"`
+	// Finality is the number of rounds below the head beyond which the node
+	// does not switch to forks. Zero means the default of the consensus
+	// protocol.
+	Finality uint64 `json:"finality"`
+	// ChainWeight is the function weighing the chains to choose the heaviest:
+	// "power" weighs them by the power of their miners as specified by
+	// expected consensus, "height" by their height, for test networks.
+	ChainWeight string `json:"chainWeight"`
+	// ValidationWorkers is the number of block headers the node validates
+	// concurrently while fetching the chain. Zero means one per CPU.
+	ValidationWorkers uint `json:"validationWorkers"`
+}
+
+func newDefaultSyncConfig() *SyncConfig {
+	return &SyncConfig{
+		CheckpointTipSet:    "",
+		CheckpointStateRoot: "",
+		Finality:            0,
+		ChainWeight:         "power",
+		ValidationWorkers:   0,
+	}
+}
+
+// UpdateConfig holds all configuration options related to checking for new
+// releases. Checks are off by default; when enabled, the node only downloads
+// the release manifest and sends nothing about itself.
+type UpdateConfig struct {
+	// CheckEnabled allows `version --check-update` to fetch the manifest.
+	CheckEnabled bool `json:"checkEnabled"`
+	// ManifestURL is the URL of the release manifest.
+	ManifestURL string `json:"manifestUrl"`
+}
+
+func newDefaultUpdateConfig() *UpdateConfig {
+	return &UpdateConfig{
+		CheckEnabled: false,
+		ManifestURL:  "",
 	}
 }
 
@@ -238,13 +469,18 @@ func NewDefaultConfig() *Config {
 		Bootstrap:     newDefaultBootstrapConfig(),
 		Datastore:     newDefaultDatastoreConfig(),
 		Swarm:         newDefaultSwarmConfig(),
+		Sync:          newDefaultSyncConfig(),
 		Mining:        newDefaultMiningConfig(),
 		Wallet:        newDefaultWalletConfig(),
 		Heartbeat:     newDefaultHeartbeatConfig(),
 		Net:           "",
 		Mpool:         newDefaultMessagePoolConfig(),
 		SectorBase:    newDefaultSectorbaseConfig(),
+		TimeSync:      newDefaultTimeSyncConfig(),
 		Observability: newDefaultObservabilityConfig(),
+		Paych:         newDefaultPaychConfig(),
+		Pruning:       newDefaultPruningConfig(),
+		Update:        newDefaultUpdateConfig(),
 	}
 }
 
@@ -377,3 +613,38 @@ func validateLettersOnly(key string, value string) error {
 	}
 	return nil
 }
+
+// validateMultiaddr validates that a given value is a multiaddr string.
+func validateMultiaddr(key string, value string) error {
+	var addr string
+	if err := json.Unmarshal([]byte(value), &addr); err != nil {
+		return errors.Errorf(`"%s" must be a multiaddr string`, key)
+	}
+	if _, err := ma.NewMultiaddr(addr); err != nil {
+		return errors.Wrapf(err, `"%s" must be a multiaddr`, key)
+	}
+	return nil
+}
+
+// validateDuration validates that a given value is a duration string, e.g.
+// "1m30s", as parsed by time.ParseDuration.
+func validateDuration(key string, value string) error {
+	var duration string
+	if err := json.Unmarshal([]byte(value), &duration); err != nil {
+		return errors.Errorf(`"%s" must be a duration string`, key)
+	}
+	if _, err := time.ParseDuration(duration); err != nil {
+		return errors.Wrapf(err, `"%s" must be a duration`, key)
+	}
+	return nil
+}
+
+// validateChainWeight validates that a given value names a chain weight
+// function.
+func validateChainWeight(key string, value string) error {
+	var weight string
+	if err := json.Unmarshal([]byte(value), &weight); err != nil || (weight != "power" && weight != "height") {
+		return errors.Errorf(`"%s" must be "power" or "height"`, key)
+	}
+	return nil
+}
@@ -75,11 +75,30 @@ func TestWriteFile(t *testing.T) {
 	"mining": {
 		"minerAddress": "empty",
 		"autoSealIntervalSeconds": 120,
-		"storagePrice": "0"
+		"storagePrice": "0",
+		"retrievalPrice": "0",
+		"minBlockMessages": 0,
+		"maxMessageWaitMilliseconds": 0,
+		"removeExpiredSectors": false,
+		"dealPolicy": {
+			"minPrice": "0",
+			"maxPieceSize": 0,
+			"clientWhitelist": [],
+			"clientBlacklist": [],
+			"maxOpenDeals": 0,
+			"hook": "",
+			"hookTimeout": "30s"
+		}
 	},
 	"mpool": {
 		"maxPoolSize": 10000,
-		"maxNonceGap": "100"
+		"maxNonceGap": "100",
+		"waitConfidence": 0,
+		"maxMessageSize": 32768,
+		"peerMessageRate": 10,
+		"peerMessageBurst": 100,
+		"messageTimeout": 6,
+		"replaceByFeeBump": 10
 	},
 	"net": "",
 	"observability": {
@@ -94,12 +113,36 @@ func TestWriteFile(t *testing.T) {
 			"jaegerEndpoint": "http://localhost:14268/api/traces"
 		}
 	},
+	"paych": {
+		"watch": false
+	},
+	"pruning": {
+		"keepStates": 0,
+		"period": "1h"
+	},
 	"sectorbase": {
-		"rootdir": ""
+		"rootdir": "",
+		"sealedPaths": []
 	},
 	"swarm": {
 		"address": "/ip4/0.0.0.0/tcp/6000"
 	},
+	"sync": {
+		"checkpointTipSet": "",
+		"checkpointStateRoot": "",
+		"finality": 0,
+		"chainWeight": "power",
+		"validationWorkers": 0
+	},
+	"timesync": {
+		"ntpServer": "pool.ntp.org",
+		"checkPeriod": "10m",
+		"maxDrift": "5s"
+	},
+	"update": {
+		"checkEnabled": false,
+		"manifestUrl": ""
+	},
 	"wallet": {
 		"defaultAddress": "empty"
 	}
@@ -124,6 +167,40 @@ func TestSetRejectsInvalidNicks(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestSetValidatesAddressesAndDurations(t *testing.T) {
+	tf.UnitTest(t)
+
+	cfg := NewDefaultConfig()
+
+	assert.NoError(t, cfg.Set("api.address", "/ip4/127.0.0.1/tcp/3453"))
+	assert.NoError(t, cfg.Set("swarm.address", `"/ip4/0.0.0.0/tcp/6001"`))
+	assert.NoError(t, cfg.Set("timesync.maxDrift", "10s"))
+	assert.NoError(t, cfg.Set("heartbeat", `{"beatPeriod": "5s", "reconnectPeriod": "1m"}`))
+
+	err := cfg.Set("api.address", ":1234")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"api.address" must be a multiaddr`)
+	assert.Error(t, cfg.Set("swarm", `{"address": "localhost:6000"}`))
+	assert.EqualError(t, cfg.Set("bootstrap.period", `5`), `"bootstrap.period" must be a duration string`)
+	assert.Error(t, cfg.Set("observability.metrics.reportInterval", "often"))
+	assert.Error(t, cfg.Set("heartbeat", `{"beatPeriod": "3 seconds"}`))
+
+	assert.Equal(t, "/ip4/127.0.0.1/tcp/3453", cfg.API.Address)
+	assert.Equal(t, "5s", cfg.Heartbeat.BeatPeriod)
+	assert.Equal(t, "1m", cfg.Bootstrap.Period)
+}
+
+func TestSetValidatesChainWeight(t *testing.T) {
+	tf.UnitTest(t)
+
+	cfg := NewDefaultConfig()
+	assert.Equal(t, "power", cfg.Sync.ChainWeight)
+
+	assert.NoError(t, cfg.Set("sync.chainWeight", "height"))
+	assert.EqualError(t, cfg.Set("sync.chainWeight", "longest"), `"sync.chainWeight" must be "power" or "height"`)
+	assert.Equal(t, "height", cfg.Sync.ChainWeight)
+}
+
 func TestConfigRoundtrip(t *testing.T) {
 	tf.UnitTest(t)
 
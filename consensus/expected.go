@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-hamt-ipld"
@@ -27,6 +28,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm"
+	vmerrors "github.com/filecoin-project/go-filecoin/vm/errors"
 )
 
 var (
@@ -49,9 +51,35 @@ var (
 	ErrInvalidBase = errors.New("block does not connect to a known good chain")
 	// ErrUnorderedTipSets is returned when weight and minticket are the same between two tipsets.
 	ErrUnorderedTipSets = errors.New("trying to order two identical tipsets")
+	// ErrBlockFromFuture is returned when a block's timestamp is further ahead of the local clock than AllowedClockDrift.
+	ErrBlockFromFuture = errors.New("block timestamp is too far in the future")
+	// ErrBlockBeforeParents is returned when a block's timestamp precedes that of one of its parents.
+	ErrBlockBeforeParents = errors.New("block timestamp precedes its parents")
+	// ErrBlockOutsideEpoch is returned when a block's timestamp is further than AllowedClockDrift from the epoch of its height.
+	ErrBlockOutsideEpoch = errors.New("block timestamp is outside the epoch of its height")
+	// ErrWrongProtocolVersion is returned when a block's protocol version is not the one the network runs at its height.
+	ErrWrongProtocolVersion = errors.New("block protocol version does not match the network's")
+	// ErrUnsignedBlock is returned when a block other than the genesis block carries no signature.
+	ErrUnsignedBlock = errors.New("block is not signed")
+	// ErrInvalidBlockSignature is returned when a block is not signed by the worker of its miner.
+	ErrInvalidBlockSignature = errors.New("block signature is not by the miner's worker")
+	// ErrInvalidTicket is returned when a block's ticket is not drawn from its parents' tickets by the worker of its miner.
+	ErrInvalidTicket = errors.New("block ticket is not drawn from its parents' tickets by the miner's worker")
+	// ErrLosingTicket is returned when a block's ticket does not win its miner the right to mine it.
+	ErrLosingTicket = errors.New("block ticket is not a winning ticket")
+	// ErrInvalidMessage is returned when a block contains a message that cannot be applied to its parent state.
+	ErrInvalidMessage = errors.New("block contains a message that cannot be applied")
+	// ErrReceiptsMismatch is returned when a block's receipts do not match the computed result.
+	ErrReceiptsMismatch = errors.New("block receipts do not match computed result")
 )
 
-// TicketSigner is an interface for a test signer that can create tickets.
+// AllowedClockDrift is how far ahead of the local clock a block's timestamp
+// may be before the block is rejected. It allows for honest miners' clocks
+// being slightly off from ours.
+const AllowedClockDrift = 10 * time.Second
+
+// TicketSigner is an interface for a signer that can create tickets and sign
+// blocks.
 type TicketSigner interface {
 	GetAddressForPubKey(pk []byte) (address.Address, error)
 	SignBytes(data []byte, signerAddr address.Address) (types.Signature, error)
@@ -71,6 +99,11 @@ const ECPrM uint64 = 100
 // to process all state transitions.
 const AncestorRoundsNeeded = miner.ProvingPeriodBlocks + miner.GracePeriodBlocks
 
+// FinalityRounds is the default number of rounds after which a tipset is
+// final. Nodes do not switch to chains that fork from their chain more than
+// this many rounds below their head.
+const FinalityRounds uint64 = 500
+
 // A Processor processes all the messages in a block or tip set.
 type Processor interface {
 	// ProcessBlock processes all messages in a block.
@@ -100,20 +133,44 @@ type Expected struct {
 	genesisCid cid.Cid
 
 	verifier proofs.Verifier
+
+	// weigher weighs the chains to choose the heaviest
+	weigher Weigher
+
+	// protocolVersions are the protocol versions blocks must carry at their
+	// heights
+	protocolVersions ProtocolVersionTable
+
+	// epochClock, if set, binds the timestamps of blocks to the epochs of
+	// their heights
+	epochClock *EpochClock
 }
 
 // Ensure Expected satisfies the Protocol interface at compile time.
 var _ Protocol = (*Expected)(nil)
 
 // NewExpected is the constructor for the Expected consenus.Protocol module.
+// Chains are weighed by the power of their miners. Blocks must carry no
+// protocol version.
 func NewExpected(cs *hamt.CborIpldStore, bs blockstore.Blockstore, processor Processor, pt PowerTableView, gCid cid.Cid, verifier proofs.Verifier) Protocol {
+	return NewExpectedWithWeigher(cs, bs, processor, pt, gCid, verifier, NewPowerWeigher(bs, pt, gCid), nil, nil)
+}
+
+// NewExpectedWithWeigher is the constructor for the Expected consensus.Protocol
+// module weighing chains with weigher. Blocks must carry the protocol version
+// of versions at their height and, if clock is not nil, be timestamped within
+// the epoch of their height.
+func NewExpectedWithWeigher(cs *hamt.CborIpldStore, bs blockstore.Blockstore, processor Processor, pt PowerTableView, gCid cid.Cid, verifier proofs.Verifier, weigher Weigher, versions ProtocolVersionTable, clock *EpochClock) Protocol {
 	return &Expected{
-		cstore:       cs,
-		bstore:       bs,
-		processor:    processor,
-		PwrTableView: pt,
-		genesisCid:   gCid,
-		verifier:     verifier,
+		cstore:           cs,
+		bstore:           bs,
+		processor:        processor,
+		PwrTableView:     pt,
+		genesisCid:       gCid,
+		verifier:         verifier,
+		weigher:          weigher,
+		protocolVersions: versions,
+		epochClock:       clock,
 	}
 }
 
@@ -134,55 +191,124 @@ func (c *Expected) NewValidTipSet(ctx context.Context, blks []*types.Block) (typ
 // cryptographically valid. This means checking that all of its fields are
 // properly filled out and its signatures are correct. Checking the validity of
 // state changes must be done separately and only once the state of the
-// previous block has been validated. TODO: not yet signature checking
+// previous block has been validated. The block's signature can only be checked
+// against the state the block was mined on, see validateMining, so here it is
+// only required to be present.
 func (c *Expected) validateBlockStructure(ctx context.Context, b *types.Block) error {
-	// TODO: validate signature on block
 	if !b.StateRoot.Defined() {
 		return fmt.Errorf("block has nil StateRoot")
 	}
 
+	if err := ValidateProtocolVersion(b, c.protocolVersions); err != nil {
+		return err
+	}
+
+	if err := ValidateSignaturePresent(b); err != nil {
+		return err
+	}
+
+	// A message without a valid signature by its sender invalidates the
+	// whole block, rather than failing on its own when the block is applied.
+	for _, msg := range b.Messages {
+		if !msg.VerifySignature() {
+			return errors.Errorf("block contains message with invalid signature from %s", msg.From)
+		}
+	}
+
+	if err := ValidateEpochTime(b, c.epochClock); err != nil {
+		return err
+	}
+
+	return ValidateTimestamp(b, nil, time.Now())
+}
+
+// ValidateProtocolVersion checks that the block carries the protocol version
+// versions has active at its height, so that blocks of networks with other
+// upgrade schedules are rejected. The genesis block, the only block without
+// parents, carries no version.
+func ValidateProtocolVersion(b *types.Block, versions ProtocolVersionTable) error {
+	if b.Parents.Empty() {
+		return nil
+	}
+	expected := versions.VersionAt(types.NewBlockHeight(uint64(b.Height)))
+	if uint64(b.ProtocolVersion) != expected {
+		return errors.Wrapf(ErrWrongProtocolVersion, "block %s has protocol version %d, expected %d at height %d", b.Cid(), b.ProtocolVersion, expected, b.Height)
+	}
 	return nil
 }
 
-// Weight returns the EC weight of this TipSet in uint64 encoded fixed point
-// representation.
-func (c *Expected) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (uint64, error) {
-	ctx = log.Start(ctx, "Expected.Weight")
-	log.LogKV(ctx, "Weight", ts.String())
-	if len(ts) == 1 && ts.ToSlice()[0].Cid().Equals(c.genesisCid) {
-		return uint64(0), nil
+// ValidateSignaturePresent checks that the block carries a signature. The
+// genesis block, the only block without parents, carries none.
+func ValidateSignaturePresent(b *types.Block) error {
+	if b.Parents.Empty() || len(b.BlockSig) > 0 {
+		return nil
 	}
-	// Compute parent weight.
-	parentW, err := ts.ParentWeight()
-	if err != nil {
-		return uint64(0), err
+	return errors.Wrapf(ErrUnsignedBlock, "block %s at height %d", b.Cid(), b.Height)
+}
+
+// ValidateTimestamp checks that the block's timestamp is no further ahead of
+// now than AllowedClockDrift and, if parents are given, that it does not
+// precede any of the parents' timestamps.
+func ValidateTimestamp(b *types.Block, parents types.TipSet, now time.Time) error {
+	latest := now.Add(AllowedClockDrift)
+	if time.Unix(int64(b.Timestamp), 0).After(latest) {
+		return errors.Wrapf(ErrBlockFromFuture, "block %s has timestamp %d, latest allowed is %d", b.Cid(), b.Timestamp, latest.Unix())
 	}
 
-	w, err := types.FixedToBig(parentW)
-	if err != nil {
-		return uint64(0), err
+	if len(parents) == 0 {
+		return nil
 	}
-	// Each block in the tipset adds ECV + ECPrm * miner_power to parent weight.
-	totalBytes, err := c.PwrTableView.Total(ctx, pSt, c.bstore)
+	parentTime, err := parents.MaxTimestamp()
 	if err != nil {
-		return uint64(0), err
+		return err
 	}
-	floatTotalBytes := new(big.Float).SetInt64(int64(totalBytes))
-	floatECV := new(big.Float).SetInt64(int64(ECV))
-	floatECPrM := new(big.Float).SetInt64(int64(ECPrM))
-	for _, blk := range ts.ToSlice() {
-		minerBytes, err := c.PwrTableView.Miner(ctx, pSt, c.bstore, blk.Miner)
-		if err != nil {
-			return uint64(0), err
-		}
-		floatOwnBytes := new(big.Float).SetInt64(int64(minerBytes))
-		wBlk := new(big.Float)
-		wBlk.Quo(floatOwnBytes, floatTotalBytes)
-		wBlk.Mul(wBlk, floatECPrM) // Power addition
-		wBlk.Add(wBlk, floatECV)   // Constant addition
-		w.Add(w, wBlk)
+	if b.Timestamp < parentTime {
+		return errors.Wrapf(ErrBlockBeforeParents, "block %s has timestamp %d, parents have %d", b.Cid(), b.Timestamp, parentTime)
+	}
+	return nil
+}
+
+// EpochClock maps block heights to the epochs they are mined in, counted in
+// BlockTime steps from the timestamp of the genesis block.
+type EpochClock struct {
+	GenesisTime uint64
+	BlockTime   time.Duration
+}
+
+// EpochStart returns the time the epoch of height h starts at.
+func (ec *EpochClock) EpochStart(h uint64) time.Time {
+	return time.Unix(int64(ec.GenesisTime), 0).Add(time.Duration(h) * ec.BlockTime)
+}
+
+// ValidateEpochTime checks that the block's timestamp falls within the epoch
+// of its height, widened by AllowedClockDrift on both sides, so that blocks
+// can neither be mined ahead of their epoch nor backdated to a past one. A
+// nil clock leaves block heights unbound to time. The genesis block, the only
+// block without parents, sets the clock.
+func ValidateEpochTime(b *types.Block, clock *EpochClock) error {
+	if clock == nil || b.Parents.Empty() {
+		return nil
 	}
-	return types.BigToFixed(w)
+	start := clock.EpochStart(uint64(b.Height))
+	earliest := start.Add(-AllowedClockDrift)
+	latest := start.Add(clock.BlockTime + AllowedClockDrift)
+	ts := time.Unix(int64(b.Timestamp), 0)
+	if ts.Before(earliest) || !ts.Before(latest) {
+		return errors.Wrapf(ErrBlockOutsideEpoch, "block %s at height %d has timestamp %d, allowed are [%d, %d)", b.Cid(), b.Height, b.Timestamp, earliest.Unix(), latest.Unix())
+	}
+	return nil
+}
+
+// Weight returns the weight of this TipSet given by the weigher of the
+// protocol.
+func (c *Expected) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (uint64, error) {
+	return c.weigher.Weight(ctx, ts, pSt)
+}
+
+// WeightRange returns the least and the most weight the weigher can give ts
+// given only its headers.
+func (c *Expected) WeightRange(ts types.TipSet) (uint64, uint64, error) {
+	return c.weigher.WeightRange(ts)
 }
 
 // IsHeavier returns true if tipset a is heavier than tipset b, and false
@@ -247,6 +373,13 @@ func (c *Expected) RunStateTransition(ctx context.Context, ts types.TipSet, ance
 		return nil, err
 	}
 
+	now := time.Now()
+	for _, blk := range ts.ToSlice() {
+		if err := ValidateTimestamp(blk, ancestors[0], now); err != nil {
+			return nil, err
+		}
+	}
+
 	sl := ts.ToSlice()
 	one := sl[0]
 	for _, blk := range sl[1:] {
@@ -272,16 +405,34 @@ func (c *Expected) RunStateTransition(ctx context.Context, ts types.TipSet, ance
 	return st, nil
 }
 
-// validateMining checks validity of the block ticket, proof, and miner address.
+// validateMining checks validity of the block signature, ticket, proof, and miner address.
 //    Returns an error if:
 //    	* any tipset's block was mined by an invalid miner address.
+//      * the block is not signed by the worker of its miner
+//      * the block ticket is not drawn from the parents' tickets by that worker
 //      * the block proof is invalid for the challenge
 //      * the block ticket fails the power check, i.e. is not a winning ticket
 //    Returns nil if all the above checks pass.
 // See https://github.com/filecoin-project/specs/blob/master/mining.md#chain-validation
 func (c *Expected) validateMining(ctx context.Context, st state.Tree, ts types.TipSet, parentTs types.TipSet) error {
 	for _, blk := range ts.ToSlice() {
-		// TODO: Also need to validate BlockSig
+		validSig, err := IsValidBlockSignature(ctx, c.bstore, c.PwrTableView, st, blk)
+		if err != nil {
+			return errors.Wrap(err, "can't check block signature")
+		}
+
+		if !validSig {
+			return errors.Wrapf(ErrInvalidBlockSignature, "block %s from miner %s", blk.Cid(), blk.Miner)
+		}
+
+		validTicket, err := IsValidTicket(ctx, c.bstore, c.PwrTableView, st, parentTs, blk)
+		if err != nil {
+			return errors.Wrap(err, "can't check ticket")
+		}
+
+		if !validTicket {
+			return errors.Wrapf(ErrInvalidTicket, "block %s from miner %s", blk.Cid(), blk.Miner)
+		}
 
 		// TODO: Once we've picked a delay function (see #2119), we need to
 		// verify its proof here. The proof will likely be written to a field on
@@ -294,7 +445,7 @@ func (c *Expected) validateMining(ctx context.Context, st state.Tree, ts types.T
 		}
 
 		if !result {
-			return errors.New("not a winning ticket")
+			return errors.Wrapf(ErrLosingTicket, "block %s from miner %s", blk.Cid(), blk.Miner)
 		}
 	}
 	return nil
@@ -320,6 +471,84 @@ func IsWinningTicket(ctx context.Context, bs blockstore.Blockstore, ptv PowerTab
 	return CompareTicketPower(ticket, minerPower, totalPower), nil
 }
 
+// IsValidBlockSignature returns true if blk is signed by the worker of its
+// miner in st, errors out if the worker can't be found.
+func IsValidBlockSignature(ctx context.Context, bs blockstore.Blockstore, ptv PowerTableView, st state.Tree, blk *types.Block) (bool, error) {
+	if len(blk.BlockSig) == 0 {
+		return false, nil
+	}
+
+	worker, err := ptv.WorkerAddr(ctx, st, bs, blk.Miner)
+	if err != nil {
+		return false, errors.Wrap(err, "Couldn't get worker address")
+	}
+
+	return types.IsValidSignature(blk.SignatureData(), worker, blk.BlockSig), nil
+}
+
+// IsValidTicket returns true if blk's ticket is the ticket the worker of its
+// miner in st draws for a block on parents at blk's height, errors out if the
+// worker can't be found.
+func IsValidTicket(ctx context.Context, bs blockstore.Blockstore, ptv PowerTableView, st state.Tree, parents types.TipSet, blk *types.Block) (bool, error) {
+	parentHeight, err := parents.Height()
+	if err != nil {
+		return false, errors.Wrap(err, "Couldn't get parent height")
+	}
+	if uint64(blk.Height) <= parentHeight {
+		return false, nil
+	}
+
+	seed, err := TicketSeed(parents, uint64(blk.Height)-parentHeight-1)
+	if err != nil {
+		return false, err
+	}
+
+	worker, err := ptv.WorkerAddr(ctx, st, bs, blk.Miner)
+	if err != nil {
+		return false, errors.Wrap(err, "Couldn't get worker address")
+	}
+
+	return types.IsValidSignature(seed, worker, blk.Ticket), nil
+}
+
+// TicketValidator checks block tickets and signatures against the state the
+// blocks were mined on, for validating blocks ahead of syncing them.
+type TicketValidator struct {
+	cstore *hamt.CborIpldStore
+	bstore blockstore.Blockstore
+	ptv    PowerTableView
+}
+
+// NewTicketValidator returns a TicketValidator reading the states from cs and
+// bs, and the miners' power from ptv.
+func NewTicketValidator(cs *hamt.CborIpldStore, bs blockstore.Blockstore, ptv PowerTableView) *TicketValidator {
+	return &TicketValidator{
+		cstore: cs,
+		bstore: bs,
+		ptv:    ptv,
+	}
+}
+
+// IsWinningTicket returns true if ticket is a winning ticket for miner in the
+// state with root stateRoot.
+func (tv *TicketValidator) IsWinningTicket(ctx context.Context, stateRoot cid.Cid, ticket types.Signature, miner address.Address) (bool, error) {
+	st, err := state.LoadStateTree(ctx, tv.cstore, stateRoot, builtin.Actors)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to load state")
+	}
+	return IsWinningTicket(ctx, tv.bstore, tv.ptv, st, ticket, miner)
+}
+
+// IsValidBlockSignature returns true if blk is signed by the worker of its
+// miner in the state with root stateRoot.
+func (tv *TicketValidator) IsValidBlockSignature(ctx context.Context, stateRoot cid.Cid, blk *types.Block) (bool, error) {
+	st, err := state.LoadStateTree(ctx, tv.cstore, stateRoot, builtin.Actors)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to load state")
+	}
+	return IsValidBlockSignature(ctx, tv.bstore, tv.ptv, st, blk)
+}
+
 // CompareTicketPower abstracts the actual comparison logic so it can be used by some test
 // helpers
 func CompareTicketPower(ticket types.Signature, minerPower uint64, totalPower uint64) bool {
@@ -331,22 +560,31 @@ func CompareTicketPower(ticket types.Signature, minerPower uint64, totalPower ui
 	return lhs.Cmp(rhs) < 0
 }
 
-// CreateChallengeSeed creates/recreates the block challenge for purposes of validation.
+// TicketSeed returns the data a miner's worker signs to draw its ticket for a
+// block on parents after nullBlkCount null blocks: the hash of the smallest
+// parent ticket and nullBlkCount, so that the tickets form a chain and every
+// round after the parents draws a fresh ticket.
 //   TODO -- in general this won't work with only the base tipset.
 //     We'll potentially need some chain manager utils, similar to
 //     the State function, to sample further back in the chain.
-func CreateChallengeSeed(parents types.TipSet, nullBlkCount uint64) (types.PoStChallengeSeed, error) {
+func TicketSeed(parents types.TipSet, nullBlkCount uint64) ([]byte, error) {
 	smallest, err := parents.MinTicket()
 	if err != nil {
-		return types.PoStChallengeSeed{}, err
+		return nil, err
 	}
 
 	buf := make([]byte, binary.MaxVarintLen64)
 	n := binary.PutUvarint(buf, nullBlkCount)
-	buf = append(smallest, buf[:n]...)
+	buf = append(append([]byte{}, smallest...), buf[:n]...)
 
 	h := sha256.Sum256(buf)
-	return h, nil
+	return h[:], nil
+}
+
+// CreateChallengeSeed creates/recreates the PoSt challenge of a block from
+// its ticket, for purposes of validation.
+func CreateChallengeSeed(ticket types.Signature) types.PoStChallengeSeed {
+	return sha256.Sum256(ticket)
 }
 
 // runMessages applies the messages of all blocks within the input
@@ -374,12 +612,15 @@ func (c *Expected) runMessages(ctx context.Context, st state.Tree, vms vm.Storag
 		}
 
 		receipts, err := c.processor.ProcessBlock(ctx, cpySt, vms, blk, ancestors)
+		if vmerrors.IsApplyErrorPermanent(err) || vmerrors.IsApplyErrorTemporary(err) {
+			return nil, errors.Wrapf(ErrInvalidMessage, "block %s: %s", blk.Cid(), err)
+		}
 		if err != nil {
 			return nil, errors.Wrap(err, "error validating block state")
 		}
 		// TODO: check that receipts actually match
 		if len(receipts) != len(blk.MessageReceipts) {
-			return nil, fmt.Errorf("found invalid message receipts: %v %v", receipts, blk.MessageReceipts)
+			return nil, errors.Wrapf(ErrReceiptsMismatch, "found %d receipts, block %s has %d", len(receipts), blk.Cid(), len(blk.MessageReceipts))
 		}
 
 		outCid, err := cpySt.Flush(ctx)
@@ -404,20 +645,41 @@ func (c *Expected) runMessages(ctx context.Context, st state.Tree, vms vm.Storag
 	return st, nil
 }
 
-// CreateTicket computes a valid ticket.
-// 	params:  proof  []byte, the proof to sign
+// CreateTicket computes a valid ticket, the signature of the TicketSeed of
+// parents and nullBlkCount. Signatures are deterministic, so the ticket acts
+// as the output of a verifiable random function of the parents' tickets that
+// the miner can't grind.
+// 	params:  parents types.TipSet, the tipset to mine on
+// 			 nullBlkCount uint64, the number of null blocks since the parents
 // 			 signerPubKey []byte, the public key for the signer. Must exist in the signer
 //      	 signer, implements TicketSigner interface. Must have signerPubKey in its keyinfo.
 //  returns:  types.Signature ( []byte ), error
-func CreateTicket(proof types.PoStProof, signerPubKey []byte, signer TicketSigner) (types.Signature, error) {
-
-	var ticket types.Signature
+func CreateTicket(parents types.TipSet, nullBlkCount uint64, signerPubKey []byte, signer TicketSigner) (types.Signature, error) {
+	seed, err := TicketSeed(parents, nullBlkCount)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not compute ticket seed")
+	}
 
 	signerAddr, err := signer.GetAddressForPubKey(signerPubKey)
 	if err != nil {
-		return ticket, errors.Wrap(err, "could not get address for signerPubKey")
+		return nil, errors.Wrap(err, "could not get address for signerPubKey")
 	}
-	buf := append(proof[:], signerAddr.Bytes()...)
 	// Don't hash it here; it gets hashed in walletutil.Sign
-	return signer.SignBytes(buf[:], signerAddr)
+	return signer.SignBytes(seed, signerAddr)
+}
+
+// SignBlock signs blk with the key of the worker whose public key is
+// signerPubKey, setting its BlockSig. The block must not be changed after.
+func SignBlock(blk *types.Block, signerPubKey []byte, signer TicketSigner) error {
+	signerAddr, err := signer.GetAddressForPubKey(signerPubKey)
+	if err != nil {
+		return errors.Wrap(err, "could not get address for signerPubKey")
+	}
+
+	sig, err := signer.SignBytes(blk.SignatureData(), signerAddr)
+	if err != nil {
+		return errors.Wrap(err, "could not sign block")
+	}
+	blk.BlockSig = sig
+	return nil
 }
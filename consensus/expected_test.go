@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/address"
@@ -68,6 +69,50 @@ func TestExpected_NewValidTipSet(t *testing.T) {
 		assert.NotNil(t, tipSet)
 	})
 
+	t.Run("NewValidTipSet returns nil + error when a message signature is invalid", func(t *testing.T) {
+		genesisBlock, err := consensus.DefaultGenesis(cistore, bstore)
+		require.NoError(t, err)
+
+		exp := consensus.NewExpected(cistore, bstore, consensus.NewDefaultProcessor(), ptv, genesisBlock.Cid(), verifier)
+
+		pTipSet, err := exp.NewValidTipSet(ctx, []*types.Block{genesisBlock})
+		require.NoError(t, err)
+
+		stateTree, err := state.LoadStateTree(ctx, cistore, genesisBlock.StateRoot, builtin.Actors)
+		require.NoError(t, err)
+
+		blocks := requireMakeBlocks(ctx, t, pTipSet, stateTree, vm.NewStorageMap(bstore))
+
+		ki := types.MustGenerateKeyInfo(1, types.GenerateKeyInfoSeed())
+		mockSigner := types.NewMockSigner(ki)
+		blocks[0].Messages = types.NewSignedMsgs(2, mockSigner)
+		blocks[0].Messages[1].Signature = blocks[0].Messages[0].Signature
+
+		tipSet, err := exp.NewValidTipSet(ctx, blocks)
+		assert.Error(t, err)
+		assert.Nil(t, tipSet)
+	})
+
+	t.Run("NewValidTipSet returns nil + error when a block is unsigned", func(t *testing.T) {
+		genesisBlock, err := consensus.DefaultGenesis(cistore, bstore)
+		require.NoError(t, err)
+
+		exp := consensus.NewExpected(cistore, bstore, consensus.NewDefaultProcessor(), ptv, genesisBlock.Cid(), verifier)
+
+		pTipSet, err := exp.NewValidTipSet(ctx, []*types.Block{genesisBlock})
+		require.NoError(t, err)
+
+		stateTree, err := state.LoadStateTree(ctx, cistore, genesisBlock.StateRoot, builtin.Actors)
+		require.NoError(t, err)
+
+		blocks := requireMakeBlocks(ctx, t, pTipSet, stateTree, vm.NewStorageMap(bstore))
+		blocks[0].BlockSig = nil
+
+		tipSet, err := exp.NewValidTipSet(ctx, blocks)
+		assert.Equal(t, consensus.ErrUnsignedBlock, errors.Cause(err))
+		assert.Nil(t, tipSet)
+	})
+
 	t.Run("NewValidTipSet returns nil + error when invalid blocks", func(t *testing.T) {
 
 		parentBlock := types.NewBlockForTest(nil, 0)
@@ -95,7 +140,7 @@ func TestExpected_NewValidTipSet(t *testing.T) {
 }
 
 // requireMakeBlocks sets up 3 blocks with 3 owner actors and 3 miner actors and puts them in the state tree.
-// the owner actors have associated mockSigners for signing blocks (not implemented yet) and tickets.
+// the owner actors have associated mockSigners for signing blocks and tickets, and are the workers of the miners.
 func requireMakeBlocks(ctx context.Context, t *testing.T, pTipSet types.TipSet, tree state.Tree, vms vm.StorageMap) []*types.Block {
 	// make  a set of owner keypairs so they can sign blocks
 	mockSigner, kis := types.NewMockSignersAndKeyInfo(3)
@@ -148,7 +193,7 @@ func TestExpected_RunStateTransition_validateMining(t *testing.T) {
 		minerPower := uint64(1)
 		totalPower := uint64(1)
 
-		ptv := testhelpers.NewTestPowerTableView(minerPower, totalPower)
+		ptv := &stateWorkerPowerTableView{testhelpers.NewTestPowerTableView(minerPower, totalPower)}
 		exp := consensus.NewExpected(cistore, bstore, testhelpers.NewTestProcessor(), ptv, genesisBlock.Cid(), verifier)
 
 		pTipSet, err := exp.NewValidTipSet(ctx, []*types.Block{genesisBlock})
@@ -188,6 +233,131 @@ func TestExpected_RunStateTransition_validateMining(t *testing.T) {
 		_, err = exp.RunStateTransition(ctx, tipSet, []types.TipSet{pTipSet}, stateTree)
 		assert.EqualError(t, err, "can't check for winning ticket: Couldn't get minerPower: something went wrong with the miner power")
 	})
+
+	t.Run("returns nil + mining error when a block is not signed by the miner's worker", func(t *testing.T) {
+		ptv := &stateWorkerPowerTableView{testhelpers.NewTestPowerTableView(1, 1)}
+		exp := consensus.NewExpected(cistore, bstore, testhelpers.NewTestProcessor(), ptv, genesisBlock.Cid(), verifier)
+
+		pTipSet, err := exp.NewValidTipSet(ctx, []*types.Block{genesisBlock})
+		require.NoError(t, err)
+
+		stateTree, err := state.LoadStateTree(ctx, cistore, genesisBlock.StateRoot, builtin.Actors)
+		require.NoError(t, err)
+
+		blocks := requireMakeBlocks(ctx, t, pTipSet, stateTree, vm.NewStorageMap(bstore))
+		blocks[1].BlockSig = blocks[0].BlockSig
+
+		tipSet, err := exp.NewValidTipSet(ctx, blocks)
+		require.NoError(t, err)
+
+		_, err = exp.RunStateTransition(ctx, tipSet, []types.TipSet{pTipSet}, stateTree)
+		assert.Equal(t, consensus.ErrInvalidBlockSignature, errors.Cause(err))
+	})
+}
+
+func TestValidateTimestamp(t *testing.T) {
+	tf.UnitTest(t)
+
+	now := time.Unix(1000000, 0)
+	parent := &types.Block{StateRoot: types.SomeCid(), Timestamp: types.Uint64(now.Unix() - 30)}
+	parents, err := types.NewTipSet(parent)
+	require.NoError(t, err)
+
+	t.Run("accepts a timestamp between the parents and now", func(t *testing.T) {
+		blk := &types.Block{Timestamp: types.Uint64(now.Unix())}
+		assert.NoError(t, consensus.ValidateTimestamp(blk, parents, now))
+	})
+
+	t.Run("accepts a timestamp within the allowed drift", func(t *testing.T) {
+		blk := &types.Block{Timestamp: types.Uint64(now.Add(consensus.AllowedClockDrift).Unix())}
+		assert.NoError(t, consensus.ValidateTimestamp(blk, parents, now))
+	})
+
+	t.Run("rejects a timestamp too far in the future", func(t *testing.T) {
+		blk := &types.Block{Timestamp: types.Uint64(now.Add(consensus.AllowedClockDrift).Unix() + 1)}
+		err := consensus.ValidateTimestamp(blk, parents, now)
+		assert.Equal(t, consensus.ErrBlockFromFuture, errors.Cause(err))
+	})
+
+	t.Run("rejects a timestamp before the parents", func(t *testing.T) {
+		blk := &types.Block{Timestamp: parent.Timestamp - 1}
+		err := consensus.ValidateTimestamp(blk, parents, now)
+		assert.Equal(t, consensus.ErrBlockBeforeParents, errors.Cause(err))
+	})
+
+	t.Run("skips the parent check without parents", func(t *testing.T) {
+		blk := &types.Block{Timestamp: parent.Timestamp - 1}
+		assert.NoError(t, consensus.ValidateTimestamp(blk, nil, now))
+	})
+}
+
+func TestValidateEpochTime(t *testing.T) {
+	tf.UnitTest(t)
+
+	clock := &consensus.EpochClock{GenesisTime: 1000000, BlockTime: 30 * time.Second}
+	drift := uint64(consensus.AllowedClockDrift / time.Second)
+	parents := types.NewSortedCidSet(types.SomeCid())
+	// Height 10 is the epoch [1000300, 1000330).
+	newBlock := func(timestamp uint64) *types.Block {
+		return &types.Block{Parents: parents, Height: 10, Timestamp: types.Uint64(timestamp)}
+	}
+
+	t.Run("accepts a timestamp within the epoch", func(t *testing.T) {
+		assert.NoError(t, consensus.ValidateEpochTime(newBlock(1000300), clock))
+		assert.NoError(t, consensus.ValidateEpochTime(newBlock(1000329), clock))
+	})
+
+	t.Run("accepts a timestamp up to the drift before the epoch", func(t *testing.T) {
+		assert.NoError(t, consensus.ValidateEpochTime(newBlock(1000300-drift), clock))
+
+		err := consensus.ValidateEpochTime(newBlock(1000300-drift-1), clock)
+		assert.Equal(t, consensus.ErrBlockOutsideEpoch, errors.Cause(err))
+	})
+
+	t.Run("accepts a timestamp up to the drift after the epoch", func(t *testing.T) {
+		assert.NoError(t, consensus.ValidateEpochTime(newBlock(1000330+drift-1), clock))
+
+		err := consensus.ValidateEpochTime(newBlock(1000330+drift), clock)
+		assert.Equal(t, consensus.ErrBlockOutsideEpoch, errors.Cause(err))
+	})
+
+	t.Run("skips the genesis block and networks without a clock", func(t *testing.T) {
+		assert.NoError(t, consensus.ValidateEpochTime(&types.Block{Timestamp: 5}, clock))
+		assert.NoError(t, consensus.ValidateEpochTime(newBlock(5), nil))
+	})
+}
+
+func TestValidateProtocolVersion(t *testing.T) {
+	tf.UnitTest(t)
+
+	versions := consensus.ProtocolVersionTable{
+		{Version: 1, Height: types.NewBlockHeight(0)},
+		{Version: 2, Height: types.NewBlockHeight(100)},
+	}
+	genesis := types.NewBlockForTest(nil, 0)
+
+	t.Run("accepts the version active at the block's height", func(t *testing.T) {
+		blk := types.NewBlockForTest(genesis, 1)
+		blk.Height = 99
+		blk.ProtocolVersion = 1
+		assert.NoError(t, consensus.ValidateProtocolVersion(blk, versions))
+
+		blk.Height = 100
+		blk.ProtocolVersion = 2
+		assert.NoError(t, consensus.ValidateProtocolVersion(blk, versions))
+	})
+
+	t.Run("rejects other versions", func(t *testing.T) {
+		blk := types.NewBlockForTest(genesis, 1)
+		blk.Height = 100
+		blk.ProtocolVersion = 1
+		err := consensus.ValidateProtocolVersion(blk, versions)
+		assert.Equal(t, consensus.ErrWrongProtocolVersion, errors.Cause(err))
+	})
+
+	t.Run("skips the genesis block", func(t *testing.T) {
+		assert.NoError(t, consensus.ValidateProtocolVersion(genesis, versions))
+	})
 }
 
 func TestIsWinningTicket(t *testing.T) {
@@ -285,13 +455,13 @@ func TestCompareTicketPower(t *testing.T) {
 	}
 }
 
-func TestCreateChallenge(t *testing.T) {
+func TestTicketSeed(t *testing.T) {
 	tf.UnitTest(t)
 
 	cases := []struct {
 		parentTickets  [][]byte
 		nullBlockCount uint64
-		challenge      string
+		seed           string
 	}{
 		// From https://www.di-mgt.com.au/sha_testvectors.html
 		{[][]byte{[]byte("ac"), []byte("ab"), []byte("xx")},
@@ -308,7 +478,7 @@ func TestCreateChallenge(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		decoded, err := hex.DecodeString(c.challenge)
+		decoded, err := hex.DecodeString(c.seed)
 		assert.NoError(t, err)
 
 		parents := types.TipSet{}
@@ -317,10 +487,70 @@ func TestCreateChallenge(t *testing.T) {
 			err = parents.AddBlock(&b)
 			assert.NoError(t, err)
 		}
-		r, err := consensus.CreateChallengeSeed(parents, c.nullBlockCount)
+		r, err := consensus.TicketSeed(parents, c.nullBlockCount)
 		assert.NoError(t, err)
-		assert.Equal(t, decoded, r[:])
+		assert.Equal(t, decoded, r)
+	}
+}
+
+func TestCreateChallengeSeed(t *testing.T) {
+	tf.UnitTest(t)
+
+	// From https://www.di-mgt.com.au/sha_testvectors.html
+	decoded, err := hex.DecodeString("ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad")
+	require.NoError(t, err)
+
+	r := consensus.CreateChallengeSeed(types.Signature("abc"))
+	assert.Equal(t, decoded, r[:])
+}
+
+func TestIsValidTicket(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	// The test power table view makes every miner its own worker.
+	ptv := testhelpers.NewTestPowerTableView(1, 1)
+	signer, kis := types.NewMockSignersAndKeyInfo(2)
+	pubKey := kis[0].PublicKey()
+
+	parents := testhelpers.RequireNewTipSet(t, &types.Block{Height: 5, Ticket: types.Signature{0xbb}})
+	newChild := func(nullBlkCount uint64) *types.Block {
+		ticket, err := consensus.CreateTicket(parents, nullBlkCount, pubKey, signer)
+		require.NoError(t, err)
+		return &types.Block{Miner: signer.Addresses[0], Height: types.Uint64(6 + nullBlkCount), Ticket: ticket}
 	}
+
+	t.Run("accepts the ticket drawn by the worker for the block's round", func(t *testing.T) {
+		for _, nullBlkCount := range []uint64{0, 3} {
+			valid, err := consensus.IsValidTicket(ctx, nil, ptv, nil, parents, newChild(nullBlkCount))
+			require.NoError(t, err)
+			assert.True(t, valid)
+		}
+	})
+
+	t.Run("rejects a ticket drawn for another round", func(t *testing.T) {
+		blk := newChild(0)
+		blk.Height++
+		valid, err := consensus.IsValidTicket(ctx, nil, ptv, nil, parents, blk)
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("rejects a ticket drawn by another key", func(t *testing.T) {
+		blk := newChild(0)
+		blk.Miner = signer.Addresses[1]
+		valid, err := consensus.IsValidTicket(ctx, nil, ptv, nil, parents, blk)
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("rejects a block not higher than its parents", func(t *testing.T) {
+		blk := newChild(0)
+		blk.Height = 5
+		valid, err := consensus.IsValidTicket(ctx, nil, ptv, nil, parents, blk)
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
 }
 
 func setupCborBlockstoreProofs() (*hamt.CborIpldStore, blockstore.Blockstore, proofs.Verifier) {
@@ -351,6 +581,10 @@ func (tv *FailingTestPowerTableView) HasPower(ctx context.Context, st state.Tree
 	return true
 }
 
+func (tv *FailingTestPowerTableView) WorkerAddr(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (address.Address, error) {
+	return (&consensus.MarketView{}).WorkerAddr(ctx, st, bstore, mAddr)
+}
+
 type FailingMinerTestPowerTableView struct{ minerPower, totalPower uint64 }
 
 func NewFailingMinerTestPowerTableView(minerPower int64, totalPower int64) *FailingMinerTestPowerTableView {
@@ -368,3 +602,17 @@ func (tv *FailingMinerTestPowerTableView) Miner(ctx context.Context, st state.Tr
 func (tv *FailingMinerTestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
 	return true
 }
+
+func (tv *FailingMinerTestPowerTableView) WorkerAddr(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (address.Address, error) {
+	return (&consensus.MarketView{}).WorkerAddr(ctx, st, bstore, mAddr)
+}
+
+// stateWorkerPowerTableView is a TestPowerTableView reading the workers of
+// the miners from their actors in the state.
+type stateWorkerPowerTableView struct {
+	*testhelpers.TestPowerTableView
+}
+
+func (tv *stateWorkerPowerTableView) WorkerAddr(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (address.Address, error) {
+	return (&consensus.MarketView{}).WorkerAddr(ctx, st, bstore, mAddr)
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/faucet"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
@@ -42,6 +44,12 @@ type Config struct {
 	actors     map[address.Address]*actor.Actor
 	miners     map[address.Address]*miner.State
 	proofsMode types.ProofsMode
+	faucet     *faucetConfig
+}
+
+type faucetConfig struct {
+	balance *types.AttoFIL
+	state   *faucet.State
 }
 
 // GenOption is a configuration option for the GenesisInitFunction.
@@ -58,7 +66,11 @@ func ActorAccount(addr address.Address, amt *types.AttoFIL) GenOption {
 // MinerActor returns a config option that sets up an miner actor account.
 func MinerActor(addr address.Address, owner address.Address, key []byte, pledge uint64, pid peer.ID, coll *types.AttoFIL) GenOption {
 	return func(gc *Config) error {
-		gc.miners[addr] = miner.NewState(owner, key, big.NewInt(int64(pledge)), pid, coll)
+		worker, err := address.NewSecp256k1Address(key)
+		if err != nil {
+			return err
+		}
+		gc.miners[addr] = miner.NewState(owner, worker, key, big.NewInt(int64(pledge)), pid, coll)
 		return nil
 	}
 }
@@ -89,6 +101,18 @@ func ProofsMode(proofsMode types.ProofsMode) GenOption {
 	}
 }
 
+// Faucet returns a config option that sets up a faucet holding balance and
+// dispensing amount per address every period blocks, for test networks.
+func Faucet(balance, amount *types.AttoFIL, period *types.BlockHeight) GenOption {
+	return func(gc *Config) error {
+		gc.faucet = &faucetConfig{
+			balance: balance,
+			state:   faucet.NewState(amount, period),
+		}
+		return nil
+	}
+}
+
 // NewEmptyConfig inits and returns an empty config
 func NewEmptyConfig() *Config {
 	return &Config{
@@ -155,6 +179,11 @@ func MakeGenesisFunc(opts ...GenOption) GenesisInitFunc {
 		if err := SetupDefaultActors(ctx, st, storageMap, genCfg.proofsMode); err != nil {
 			return nil, err
 		}
+		if genCfg.faucet != nil {
+			if err := SetupFaucet(ctx, st, storageMap, genCfg.faucet.balance, genCfg.faucet.state); err != nil {
+				return nil, err
+			}
+		}
 		// Now add any other actors configured.
 		for addr, a := range genCfg.actors {
 			if err := st.SetActor(ctx, addr, a); err != nil {
@@ -224,5 +253,25 @@ func SetupDefaultActors(ctx context.Context, st state.Tree, storageMap vm.Storag
 
 	pbAct.Balance = types.NewAttoFILFromFIL(0)
 
-	return st.SetActor(ctx, address.PaymentBrokerAddress, pbAct)
+	if err := st.SetActor(ctx, address.PaymentBrokerAddress, pbAct); err != nil {
+		return err
+	}
+
+	initAct := initactor.NewActor()
+	if err := (&initactor.Actor{}).InitializeState(storageMap.NewStorage(address.InitAddress, initAct), nil); err != nil {
+		return err
+	}
+
+	return st.SetActor(ctx, address.InitAddress, initAct)
+}
+
+// SetupFaucet inits the faucet actor of test networks, holding balance and
+// dispensing funds as set in faucetState.
+func SetupFaucet(ctx context.Context, st state.Tree, storageMap vm.StorageMap, balance *types.AttoFIL, faucetState *faucet.State) error {
+	faucetAct := faucet.NewActor(balance)
+	if err := (&faucet.Actor{}).InitializeState(storageMap.NewStorage(address.FaucetAddress, faucetAct), faucetState); err != nil {
+		return err
+	}
+
+	return st.SetActor(ctx, address.FaucetAddress, faucetAct)
 }
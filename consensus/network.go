@@ -0,0 +1,49 @@
+package consensus
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Network describes a filecoin network. Nodes only talk to the nodes of their
+// own network, so that nodes of different devnets do not sync or gossip with
+// each other even when they share a genesis block.
+type Network struct {
+	// Name identifies the network to peers in the hello handshake.
+	Name string
+
+	// ProtocolVersions are the protocol versions the network runs, by the
+	// block heights they activate at. Blocks must carry the version active
+	// at their height.
+	ProtocolVersions ProtocolVersionTable
+
+	// BlockTime is the duration of the network's epochs, counted from the
+	// timestamp of its genesis block. When set, blocks must be timestamped
+	// within the epoch of their height. Networks whose heights do not keep
+	// pace with time, like local networks mined on demand, leave it zero.
+	BlockTime time.Duration
+}
+
+// KnownNetworks are the networks this node can join, by name. Add a version
+// to the protocol versions of a network to schedule an upgrade of it.
+var KnownNetworks = map[string]*Network{
+	"devnet-test":    {Name: "devnet-test", ProtocolVersions: DefaultProtocolVersions},
+	"devnet-nightly": {Name: "devnet-nightly", ProtocolVersions: DefaultProtocolVersions},
+	"devnet-user":    {Name: "devnet-user", ProtocolVersions: DefaultProtocolVersions},
+}
+
+// NetworkByName returns the known network with the given name. Other names,
+// such as those of local networks, run the DefaultProtocolVersions.
+func NetworkByName(name string) *Network {
+	if network, ok := KnownNetworks[name]; ok {
+		return network
+	}
+	return &Network{Name: name, ProtocolVersions: DefaultProtocolVersions}
+}
+
+// VersionAt returns the protocol version the network runs at the given block
+// height.
+func (n *Network) VersionAt(bh *types.BlockHeight) uint64 {
+	return n.ProtocolVersions.VersionAt(bh)
+}
@@ -0,0 +1,23 @@
+package consensus_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/go-filecoin/consensus"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestNetworkByName(t *testing.T) {
+	tf.UnitTest(t)
+
+	user := consensus.NetworkByName("devnet-user")
+	assert.Equal(t, consensus.KnownNetworks["devnet-user"], user)
+
+	local := consensus.NetworkByName("")
+	assert.Equal(t, "", local.Name)
+	assert.Equal(t, consensus.DefaultProtocolVersions, local.ProtocolVersions)
+	assert.Equal(t, consensus.DefaultProtocolVersions.VersionAt(types.NewBlockHeight(0)), local.VersionAt(types.NewBlockHeight(0)))
+}
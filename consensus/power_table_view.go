@@ -26,6 +26,10 @@ type PowerTableView interface {
 	// HasPower returns true if the input address is associated with a
 	// miner that has storage power in the network.
 	HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool
+
+	// WorkerAddr returns the address of the worker of the miner of the
+	// input address, whose key signs the miner's blocks.
+	WorkerAddr(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (address.Address, error)
 }
 
 // MarketView is the power table view used for running expected consensus in
@@ -90,3 +94,19 @@ func (v *MarketView) HasPower(ctx context.Context, st state.Tree, bstore blockst
 
 	return numBytes > 0
 }
+
+// WorkerAddr returns the address of the miner's worker, as recorded by its
+// miner actor.
+func (v *MarketView) WorkerAddr(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (address.Address, error) {
+	vms := vm.NewStorageMap(bstore)
+	rets, ec, err := CallQueryMethod(ctx, st, vms, mAddr, "getWorker", []byte{}, address.Undef, nil)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	if ec != 0 {
+		return address.Undef, errors.Errorf("non-zero return code from query message: %d", ec)
+	}
+
+	return address.NewFromBytes(rets[0])
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/metrics"
 	"github.com/filecoin-project/go-filecoin/metrics/tracing"
 	"github.com/filecoin-project/go-filecoin/state"
@@ -56,6 +57,8 @@ type ProcessTipSetResponse struct {
 type DefaultProcessor struct {
 	signedMessageValidator SignedMessageValidator
 	blockRewarder          BlockRewarder
+	protocolVersions       ProtocolVersionTable
+	actorUpgrades          []ActorUpgrade
 }
 
 var _ Processor = (*DefaultProcessor)(nil)
@@ -65,6 +68,8 @@ func NewDefaultProcessor() *DefaultProcessor {
 	return &DefaultProcessor{
 		signedMessageValidator: NewDefaultMessageValidator(),
 		blockRewarder:          NewDefaultBlockRewarder(),
+		protocolVersions:       DefaultProtocolVersions,
+		actorUpgrades:          DefaultActorUpgrades,
 	}
 }
 
@@ -73,6 +78,19 @@ func NewConfiguredProcessor(validator SignedMessageValidator, rewarder BlockRewa
 	return &DefaultProcessor{
 		signedMessageValidator: validator,
 		blockRewarder:          rewarder,
+		protocolVersions:       DefaultProtocolVersions,
+		actorUpgrades:          DefaultActorUpgrades,
+	}
+}
+
+// NewUpgradingProcessor creates a default processor with custom validation
+// and rewards that upgrades actors according to the given protocol versions.
+func NewUpgradingProcessor(validator SignedMessageValidator, rewarder BlockRewarder, versions ProtocolVersionTable, upgrades []ActorUpgrade) *DefaultProcessor {
+	return &DefaultProcessor{
+		signedMessageValidator: validator,
+		blockRewarder:          rewarder,
+		protocolVersions:       versions,
+		actorUpgrades:          upgrades,
 	}
 }
 
@@ -277,6 +295,12 @@ func (p *DefaultProcessor) ProcessTipSet(ctx context.Context, st state.Tree, vms
 //   - everything else: successfully applied (include, keep changes)
 //
 func (p *DefaultProcessor) ApplyMessage(ctx context.Context, st state.Tree, vms vm.StorageMap, msg *types.SignedMessage, minerOwnerAddr address.Address, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet) (result *ApplicationResult, err error) {
+	return p.applyMessage(ctx, st, vms, msg, minerOwnerAddr, bh, gasTracker, ancestors, nil)
+}
+
+// applyMessage is ApplyMessage, notifying the optional tracer of the
+// operations the VM performs.
+func (p *DefaultProcessor) applyMessage(ctx context.Context, st state.Tree, vms vm.StorageMap, msg *types.SignedMessage, minerOwnerAddr address.Address, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet, tracer vm.Tracer) (result *ApplicationResult, err error) {
 	msgCid, err := msg.Cid()
 	if err != nil {
 		return nil, errors.FaultErrorWrap(err, "could not get message cid")
@@ -292,7 +316,7 @@ func (p *DefaultProcessor) ApplyMessage(ctx context.Context, st state.Tree, vms
 
 	cachedStateTree := state.NewCachedStateTree(st)
 
-	r, err := p.attemptApplyMessage(ctx, cachedStateTree, vms, msg, bh, gasTracker, ancestors)
+	r, err := p.attemptApplyMessage(ctx, cachedStateTree, vms, msg, bh, gasTracker, ancestors, tracer)
 	if err == nil {
 		err = cachedStateTree.Commit(ctx)
 		if err != nil {
@@ -361,10 +385,19 @@ var (
 // CallQueryMethod calls a method on an actor in the given state tree. It does
 // not make any changes to the state/blockchain and is useful for interrogating
 // actor state. Block height bh is optional; some methods will ignore it.
+// CallQueryMethod does not report the gas the call used, so it charges gas
+// by the default protocol versions.
 func CallQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to address.Address, method string, params []byte, from address.Address, optBh *types.BlockHeight) ([][]byte, uint8, error) {
+	ret, retCode, _, err := CallQueryMethodWithGas(ctx, st, vms, DefaultProtocolVersions, to, method, params, from, optBh)
+	return ret, retCode, err
+}
+
+// CallQueryMethodWithGas is like CallQueryMethod but also returns the amount of
+// gas the call used, charged by the gas table versions has active at optBh.
+func CallQueryMethodWithGas(ctx context.Context, st state.Tree, vms vm.StorageMap, versions ProtocolVersionTable, to address.Address, method string, params []byte, from address.Address, optBh *types.BlockHeight) ([][]byte, uint8, types.GasUnits, error) {
 	toActor, err := st.GetActor(ctx, to)
 	if err != nil {
-		return nil, 1, errors.ApplyErrorPermanentWrapf(err, "failed to get To actor")
+		return nil, 1, types.NewGasUnits(0), errors.ApplyErrorPermanentWrapf(err, "failed to get To actor")
 	}
 
 	// not committing or flushing storage structures guarantees changes won't make it to stored state tree or datastore
@@ -380,7 +413,7 @@ func CallQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to a
 	}
 
 	// Set the gas limit to the max because this message send should always succeed; it doesn't cost gas.
-	gasTracker := vm.NewGasTracker()
+	gasTracker := vm.NewGasTrackerWithTable(gasTableAt(versions, optBh))
 	gasTracker.MsgGasLimit = types.BlockGasLimit
 
 	vmCtxParams := vm.NewContextParams{
@@ -394,12 +427,13 @@ func CallQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to a
 
 	vmCtx := vm.NewVMContext(vmCtxParams)
 	ret, retCode, err := vm.Send(ctx, vmCtx)
-	return ret, retCode, err
+	return ret, retCode, vmCtx.GasUnits(), err
 }
 
 // PreviewQueryMethod estimates the amount of gas that will be used by a method
-// call. It accepts all the same arguments as CallQueryMethod.
-func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to address.Address, method string, params []byte, from address.Address, optBh *types.BlockHeight) (types.GasUnits, error) {
+// call, by the gas table versions has active at optBh. It accepts all the
+// same arguments as CallQueryMethodWithGas.
+func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, versions ProtocolVersionTable, to address.Address, method string, params []byte, from address.Address, optBh *types.BlockHeight) (types.GasUnits, error) {
 	toActor, err := st.GetActor(ctx, to)
 	if err != nil {
 		return types.NewGasUnits(0), errors.ApplyErrorPermanentWrapf(err, "failed to get To actor")
@@ -418,7 +452,7 @@ func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, t
 	}
 
 	// Set the gas limit to the max because this message send should always succeed; it doesn't cost gas.
-	gasTracker := vm.NewGasTracker()
+	gasTracker := vm.NewGasTrackerWithTable(gasTableAt(versions, optBh))
 	gasTracker.MsgGasLimit = types.BlockGasLimit
 
 	vmCtxParams := vm.NewContextParams{
@@ -430,6 +464,11 @@ func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, t
 		BlockHeight: optBh,
 	}
 	vmCtx := vm.NewVMContext(vmCtxParams)
+	// Charge for the message itself so the estimate covers all the gas
+	// applying it would use.
+	if err := vmCtx.Charge(vm.MessageGas(gasTracker.Table(), msg)); err != nil {
+		return vmCtx.GasUnits(), err
+	}
 	_, _, err = vm.Send(ctx, vmCtx)
 
 	return vmCtx.GasUnits(), err
@@ -440,7 +479,7 @@ func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, t
 // should deal with trying to apply the message to the state tree whereas
 // ApplyMessage should deal with any side effects and how it should be presented
 // to the caller. attemptApplyMessage should only be called from ApplyMessage.
-func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.CachedTree, store vm.StorageMap, msg *types.SignedMessage, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet) (*types.MessageReceipt, error) {
+func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.CachedTree, store vm.StorageMap, msg *types.SignedMessage, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet, tracer vm.Tracer) (*types.MessageReceipt, error) {
 	gasTracker.ResetForNewMessage(msg.MeteredMessage)
 	if err := blockGasLimitError(gasTracker); err != nil {
 		return &types.MessageReceipt{
@@ -494,10 +533,18 @@ func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.Ca
 		GasTracker:  gasTracker,
 		BlockHeight: bh,
 		Ancestors:   ancestors,
+		Tracer:      tracer,
 	}
 	vmCtx := vm.NewVMContext(vmCtxParams)
 
-	ret, exitCode, vmErr := vm.Send(ctx, vmCtx)
+	var ret [][]byte
+	var exitCode uint8
+	var vmErr error
+	if err := vmCtx.Charge(vm.MessageGas(gasTracker.Table(), &msg.Message)); err != nil {
+		exitCode, vmErr = exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	} else {
+		ret, exitCode, vmErr = vm.Send(ctx, vmCtx)
+	}
 	if errors.IsFault(vmErr) {
 		return nil, vmErr
 	}
@@ -508,6 +555,10 @@ func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.Ca
 	receipt := &types.MessageReceipt{
 		ExitCode:   exitCode,
 		GasAttoFIL: gasCharge,
+		Events:     vmCtx.Events(),
+	}
+	if vmErr != nil {
+		receipt.Error = vmErr.Error()
 	}
 
 	receipt.Return = append(receipt.Return, ret...)
@@ -528,7 +579,8 @@ type ApplyMessagesResponse struct {
 	TemporaryErrors []error
 }
 
-// ApplyMessagesAndPayRewards begins by paying the block mining reward to the miner's owner. It then applies messages to a state tree.
+// ApplyMessagesAndPayRewards begins by upgrading the actors due for an upgrade at the block height
+// and paying the block mining reward to the miner's owner. It then applies messages to a state tree.
 // It returns an ApplyMessagesResponse which wraps the results of message application,
 // groupings of messages with permanent failures, temporary failures, and
 // successes, and the permanent and temporary errors raised during application.
@@ -538,12 +590,16 @@ func (p *DefaultProcessor) ApplyMessagesAndPayRewards(ctx context.Context, st st
 	var emptyRet ApplyMessagesResponse
 	var ret ApplyMessagesResponse
 
+	if err := UpgradeActors(ctx, st, vms, p.protocolVersions, p.actorUpgrades, bh); err != nil {
+		return ApplyMessagesResponse{}, err
+	}
+
 	// transfer block reward to miner's owner from network address.
 	if err := p.blockRewarder.BlockReward(ctx, st, minerOwnerAddr); err != nil {
 		return ApplyMessagesResponse{}, err
 	}
 
-	gasTracker := vm.NewGasTracker()
+	gasTracker := vm.NewGasTrackerWithTable(gasTableAt(p.protocolVersions, bh))
 
 	// process all messages
 	for _, smsg := range messages {
@@ -620,6 +676,18 @@ func rewardTransfer(ctx context.Context, fromAddr, toAddr address.Address, value
 	return vm.Transfer(fromActor, toActor, value)
 }
 
+// gasTableAt returns the gas table of the protocol version the network runs
+// at the given block height, or at the latest version if bh is nil.
+func gasTableAt(versions ProtocolVersionTable, bh *types.BlockHeight) *exec.GasTable {
+	if bh == nil {
+		if len(versions) == 0 {
+			return vm.GasTableAt(0)
+		}
+		return vm.GasTableAt(versions[len(versions)-1].Version)
+	}
+	return vm.GasTableAt(versions.VersionAt(bh))
+}
+
 func blockGasLimitError(gasTracker *vm.GasTracker) error {
 	if gasTracker.GasAboveBlockLimit() {
 		return errGasAboveBlockLimit
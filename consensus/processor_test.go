@@ -410,6 +410,7 @@ func TestProcessBlockParamsLengthError(t *testing.T) {
 
 	assert.Empty(t, rct.Receipt.Return)
 	assert.Contains(t, rct.ExecutionError.Error(), "invalid params: expected 0 parameters, but got 1")
+	assert.Equal(t, rct.ExecutionError.Error(), rct.Receipt.Error)
 }
 
 func TestProcessBlockParamsError(t *testing.T) {
@@ -672,14 +673,15 @@ func TestReentrantTransferDoesntAllowMultiSpending(t *testing.T) {
 		addr2: act2,
 	})
 
-	// addr1 will attempt to double spend to addr2 by sending a reentrant message that spends twice
+	// addr1 will attempt to double spend to addr2 by sending a reentrant message that spends twice,
+	// but the reentrant message is refused
 	params, err := abi.ToEncodedValues(addr1, addr2)
 	assert.NoError(t, err)
 	msg := types.NewMessage(addr0, addr1, 0, types.ZeroAttoFIL, "attemptMultiSpend1", params)
 	_, err = th.ApplyTestMessage(st, th.VMStorage(), msg, types.NewBlockHeight(0))
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "second callSendTokens")
-	assert.Contains(t, err.Error(), "not enough balance")
+	assert.Contains(t, err.Error(), "first callSendTokens")
+	assert.Contains(t, err.Error(), "actor may not be reentered")
 
 	// addr1 will attempt to double spend to addr2 by sending a reentrant message that spends and then spending directly
 	params, err = abi.ToEncodedValues(addr1, addr2)
@@ -687,8 +689,8 @@ func TestReentrantTransferDoesntAllowMultiSpending(t *testing.T) {
 	msg = types.NewMessage(addr0, addr1, 0, types.ZeroAttoFIL, "attemptMultiSpend2", params)
 	_, err = th.ApplyTestMessage(st, th.VMStorage(), msg, types.NewBlockHeight(0))
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed sendTokens")
-	assert.Contains(t, err.Error(), "not enough balance")
+	assert.Contains(t, err.Error(), "first callSendTokens")
+	assert.Contains(t, err.Error(), "actor may not be reentered")
 }
 
 func TestSendToNonexistentAddressThenSpendFromIt(t *testing.T) {
@@ -778,6 +780,38 @@ func TestApplyQueryMessageWillNotAlterState(t *testing.T) {
 	assert.True(t, preCid.Equals(postCid))
 }
 
+func TestPreviewQueryMethodChargesByVersions(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	ctx := context.Background()
+	vms := th.VMStorage()
+
+	// Install the fake actor so we can execute it.
+	fakeActorCodeCid := types.NewCidForTestGetter()()
+	builtin.Actors[fakeActorCodeCid] = &actor.FakeActor{}
+	defer delete(builtin.Actors, fakeActorCodeCid)
+
+	addresses, st, _ := setupActorsForGasTest(t, vms, fakeActorCodeCid, 1000)
+	addr0 := addresses[0]
+	addr1 := addresses[1]
+
+	v1Gas, err := PreviewQueryMethod(ctx, st, vms, DefaultProtocolVersions, addr1, "hasReturnValue", nil, addr0, types.NewBlockHeight(10))
+	require.NoError(t, err)
+
+	// A network upgrading to version 2, with its own gas table, at height 5.
+	versions := ProtocolVersionTable{
+		{Version: 1, Height: types.NewBlockHeight(0)},
+		{Version: 2, Height: types.NewBlockHeight(5)},
+	}
+	v2Gas, err := PreviewQueryMethod(ctx, st, vms, versions, addr1, "hasReturnValue", nil, addr0, types.NewBlockHeight(10))
+	require.NoError(t, err)
+	assert.True(t, v2Gas > v1Gas)
+
+	beforeUpgradeGas, err := PreviewQueryMethod(ctx, st, vms, versions, addr1, "hasReturnValue", nil, addr0, types.NewBlockHeight(4))
+	require.NoError(t, err)
+	assert.Equal(t, v1Gas, beforeUpgradeGas)
+}
+
 func TestApplyMessageChargesGas(t *testing.T) {
 	tf.BadUnitTestWithSideEffects(t)
 
@@ -30,6 +30,9 @@ type Protocol interface {
 	NewValidTipSet(ctx context.Context, blks []*types.Block) (types.TipSet, error)
 	// Weight returns the weight given to the input ts by this consensus protocol.
 	Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (uint64, error)
+	// WeightRange returns the least and the most weight the input ts can
+	// have given only its headers.
+	WeightRange(ts types.TipSet) (uint64, uint64, error)
 	// IsHeaver returns 1 if tipset a is heavier than tipset b and -1 if
 	// tipset b is heavier than tipset a.
 	IsHeavier(ctx context.Context, a, b types.TipSet, aSt, bSt state.Tree) (bool, error)
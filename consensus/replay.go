@@ -0,0 +1,69 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// ReplayMessage re-applies the message with cid msgCid of tipset ts to st,
+// the state of the parent of ts, and returns the result of applying it. The
+// messages ts applies before it are applied first, the way ProcessTipSet
+// applies them, so the message sees the state it saw when the tipset was
+// processed. The tracer is notified of the operations the VM performs while
+// it applies the message.
+func (p *DefaultProcessor) ReplayMessage(ctx context.Context, st state.Tree, vms vm.StorageMap, ts types.TipSet, ancestors []types.TipSet, msgCid cid.Cid, tracer vm.Tracer) (*ApplicationResult, error) {
+	h, err := ts.Height()
+	if err != nil {
+		return nil, errors.FaultErrorWrap(err, "processing empty tipset")
+	}
+	bh := types.NewBlockHeight(h)
+	msgFilter := make(map[string]struct{})
+
+	tips := ts.ToSlice()
+	types.SortBlocks(tips)
+
+	for _, blk := range tips {
+		minerOwnerAddr, err := minerOwnerAddress(ctx, st, vms, blk.Miner)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := UpgradeActors(ctx, st, vms, p.protocolVersions, p.actorUpgrades, bh); err != nil {
+			return nil, err
+		}
+		if err := p.blockRewarder.BlockReward(ctx, st, minerOwnerAddr); err != nil {
+			return nil, err
+		}
+
+		gasTracker := vm.NewGasTrackerWithTable(gasTableAt(p.protocolVersions, bh))
+		for _, msg := range blk.Messages {
+			mCid, err := msg.Cid()
+			if err != nil {
+				return nil, errors.FaultErrorWrap(err, "error getting message cid")
+			}
+			if _, ok := msgFilter[mCid.String()]; ok {
+				continue
+			}
+			msgFilter[mCid.String()] = struct{}{}
+
+			if mCid.Equals(msgCid) {
+				return p.applyMessage(ctx, st, vms, msg, minerOwnerAddr, bh, gasTracker, ancestors, tracer)
+			}
+
+			// Messages that were not applied leave the state as it was, so
+			// only faults matter here.
+			if _, err := p.ApplyMessage(ctx, st, vms, msg, minerOwnerAddr, bh, gasTracker, ancestors); errors.IsFault(err) {
+				return nil, err
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("message %s is not in tipset %s", msgCid, ts.String())
+}
@@ -33,6 +33,12 @@ func (tv *TestView) HasPower(ctx context.Context, st state.Tree, bstore blocksto
 	return true
 }
 
+// WorkerAddr returns the miner address itself, as test miners are their own
+// workers.
+func (tv *TestView) WorkerAddr(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (address.Address, error) {
+	return mAddr, nil
+}
+
 // RequireNewTipSet instantiates and returns a new tipset of the given blocks
 // and requires that the setup validation succeed.
 func RequireNewTipSet(require *require.Assertions, blks ...*types.Block) types.TipSet {
@@ -72,6 +78,12 @@ func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstor
 	return true
 }
 
+// WorkerAddr returns the miner address itself, as test miners are their own
+// workers.
+func (tv *TestPowerTableView) WorkerAddr(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (address.Address, error) {
+	return mAddr, nil
+}
+
 // TestSignedMessageValidator is a validator that doesn't validate to simplify message creation in tests.
 type TestSignedMessageValidator struct{}
 
@@ -104,5 +116,7 @@ func NewTestProcessor() *DefaultProcessor {
 	return &DefaultProcessor{
 		signedMessageValidator: &TestSignedMessageValidator{},
 		blockRewarder:          &TestBlockRewarder{},
+		protocolVersions:       DefaultProtocolVersions,
+		actorUpgrades:          DefaultActorUpgrades,
 	}
 }
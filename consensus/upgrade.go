@@ -0,0 +1,101 @@
+package consensus
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// ProtocolVersion is a version of the network protocol and the block height
+// from which the network runs it.
+type ProtocolVersion struct {
+	Version uint64
+	Height  *types.BlockHeight
+}
+
+// ProtocolVersionTable lists the versions of the network protocol in order
+// of their activation heights.
+type ProtocolVersionTable []ProtocolVersion
+
+// VersionAt returns the protocol version the network runs at the given
+// block height, or 0 if the table has no version active yet.
+func (t ProtocolVersionTable) VersionAt(bh *types.BlockHeight) uint64 {
+	var version uint64
+	for _, pv := range t {
+		if bh.LessThan(pv.Height) {
+			break
+		}
+		version = pv.Version
+	}
+	return version
+}
+
+// DefaultProtocolVersions is the protocol version table of the network.
+// Add an entry here to schedule a network upgrade.
+var DefaultProtocolVersions = ProtocolVersionTable{
+	{Version: 1, Height: types.NewBlockHeight(0)},
+}
+
+// ActorUpgrade swaps the code of the actor at Address from From to To once
+// the network runs Version. If the new code is an exec.MigratableActor its
+// MigrateState converts the actor's state first.
+type ActorUpgrade struct {
+	Version uint64
+	Address address.Address
+	From    cid.Cid
+	To      cid.Cid
+}
+
+// DefaultActorUpgrades are the actor upgrades of the network.
+var DefaultActorUpgrades = []ActorUpgrade{}
+
+// UpgradeActors applies the upgrades that are active at the given block
+// height to the state tree. An upgrade only applies to an actor still
+// running its From code, so applying the same upgrades again is a no-op
+// and upgrades missed at their activation height, e.g. because of null
+// blocks, are applied at the next height.
+func UpgradeActors(ctx context.Context, st state.Tree, vms vm.StorageMap, versions ProtocolVersionTable, upgrades []ActorUpgrade, bh *types.BlockHeight) error {
+	version := versions.VersionAt(bh)
+
+	for _, upgrade := range upgrades {
+		if upgrade.Version > version {
+			continue
+		}
+
+		act, err := st.GetActor(ctx, upgrade.Address)
+		if err != nil {
+			if state.IsActorNotFoundError(err) {
+				continue
+			}
+			return errors.FaultErrorWrapf(err, "could not get actor %s to upgrade", upgrade.Address)
+		}
+		if !act.Code.Equals(upgrade.From) {
+			continue
+		}
+
+		code, err := st.GetBuiltinActorCode(upgrade.To)
+		if err != nil {
+			return errors.FaultErrorWrapf(err, "could not get code to upgrade actor %s to", upgrade.Address)
+		}
+
+		if migratable, ok := code.(exec.MigratableActor); ok {
+			if err := migratable.MigrateState(vms.NewStorage(upgrade.Address, act), upgrade.From); err != nil {
+				return errors.FaultErrorWrapf(err, "could not migrate state of actor %s", upgrade.Address)
+			}
+		}
+
+		act.Code = upgrade.To
+		if err := st.SetActor(ctx, upgrade.Address, act); err != nil {
+			return errors.FaultErrorWrapf(err, "could not set upgraded actor %s", upgrade.Address)
+		}
+	}
+
+	return nil
+}
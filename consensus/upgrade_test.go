@@ -0,0 +1,136 @@
+package consensus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-hamt-ipld"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	. "github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// migratingFakeActor is a second version of the fake actor that sets the
+// Changed bit of the fake actor's state when migrating it.
+type migratingFakeActor struct {
+	actor.FakeActor
+	migrations int
+}
+
+func (ma *migratingFakeActor) MigrateState(storage exec.Storage, from cid.Cid) error {
+	ma.migrations++
+
+	chunk, err := storage.Get(storage.Head())
+	if err != nil {
+		return err
+	}
+
+	var st actor.FakeActorStorage
+	if err := cbor.DecodeInto(chunk, &st); err != nil {
+		return err
+	}
+	st.Changed = true
+
+	head, err := storage.Put(&st)
+	if err != nil {
+		return err
+	}
+	return storage.Commit(head, storage.Head())
+}
+
+func TestProtocolVersionTable(t *testing.T) {
+	tf.UnitTest(t)
+
+	versions := ProtocolVersionTable{
+		{Version: 1, Height: types.NewBlockHeight(10)},
+		{Version: 2, Height: types.NewBlockHeight(100)},
+	}
+
+	assert.Equal(t, uint64(0), versions.VersionAt(types.NewBlockHeight(9)))
+	assert.Equal(t, uint64(1), versions.VersionAt(types.NewBlockHeight(10)))
+	assert.Equal(t, uint64(1), versions.VersionAt(types.NewBlockHeight(99)))
+	assert.Equal(t, uint64(2), versions.VersionAt(types.NewBlockHeight(100)))
+	assert.Equal(t, uint64(2), versions.VersionAt(types.NewBlockHeight(1000)))
+}
+
+func TestUpgradeActors(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	cst := hamt.NewCborStore()
+	vms := th.VMStorage()
+
+	v1 := types.NewVersionedActorCodeObj("fakeactor", 1).Cid()
+	v2 := types.NewVersionedActorCodeObj("fakeactor", 2).Cid()
+	require.False(t, v1.Equals(v2))
+
+	v2Code := &migratingFakeActor{}
+	st := state.NewEmptyStateTreeWithActors(cst, map[cid.Cid]exec.ExecutableActor{
+		v1: &actor.FakeActor{},
+		v2: v2Code,
+	})
+
+	addrGetter := address.NewForTestGetter()
+	addr := addrGetter()
+	require.NoError(t, st.SetActor(ctx, addr, th.RequireNewFakeActor(t, vms, addr, v1)))
+
+	versions := ProtocolVersionTable{
+		{Version: 1, Height: types.NewBlockHeight(0)},
+		{Version: 2, Height: types.NewBlockHeight(100)},
+	}
+	upgrades := []ActorUpgrade{{Version: 2, Address: addr, From: v1, To: v2}}
+
+	requireFakeState := func() (*actor.Actor, actor.FakeActorStorage) {
+		act, err := st.GetActor(ctx, addr)
+		require.NoError(t, err)
+
+		chunk, err := vms.NewStorage(addr, act).Get(act.Head)
+		require.NoError(t, err)
+
+		var fakeState actor.FakeActorStorage
+		require.NoError(t, cbor.DecodeInto(chunk, &fakeState))
+		return act, fakeState
+	}
+
+	t.Run("actors are not upgraded before the upgrade's version", func(t *testing.T) {
+		require.NoError(t, UpgradeActors(ctx, st, vms, versions, upgrades, types.NewBlockHeight(99)))
+
+		act, fakeState := requireFakeState()
+		assert.Equal(t, v1, act.Code)
+		assert.False(t, fakeState.Changed)
+		assert.Equal(t, 0, v2Code.migrations)
+	})
+
+	t.Run("actors are upgraded and migrated once the version is active", func(t *testing.T) {
+		// height 100 may have been a null block
+		require.NoError(t, UpgradeActors(ctx, st, vms, versions, upgrades, types.NewBlockHeight(101)))
+
+		act, fakeState := requireFakeState()
+		assert.Equal(t, v2, act.Code)
+		assert.True(t, fakeState.Changed)
+		assert.Equal(t, 1, v2Code.migrations)
+	})
+
+	t.Run("upgrades are applied only once", func(t *testing.T) {
+		require.NoError(t, UpgradeActors(ctx, st, vms, versions, upgrades, types.NewBlockHeight(102)))
+
+		act, _ := requireFakeState()
+		assert.Equal(t, v2, act.Code)
+		assert.Equal(t, 1, v2Code.migrations)
+	})
+
+	t.Run("upgrades of missing actors are skipped", func(t *testing.T) {
+		missing := []ActorUpgrade{{Version: 1, Address: addrGetter(), From: v1, To: v2}}
+		assert.NoError(t, UpgradeActors(ctx, st, vms, versions, missing, types.NewBlockHeight(0)))
+	})
+}
@@ -49,7 +49,7 @@ func (v *defaultMessageValidator) Validate(ctx context.Context, msg *types.Signe
 		return errSelfSend
 	}
 
-	if msg.GasPrice.LessEqual(types.ZeroAttoFIL) {
+	if msg.GasPrice.LessEqual(types.ZeroAttoFIL) && !isFaucetTap(msg) {
 		return errGasPriceZero
 	}
 
@@ -88,6 +88,12 @@ func (v *defaultMessageValidator) Validate(ctx context.Context, msg *types.Signe
 	return nil
 }
 
+// isFaucetTap returns whether msg asks the faucet for funds. These messages
+// may be free, so that new wallets without funds can ask for them.
+func isFaucetTap(msg *types.SignedMessage) bool {
+	return msg.To == address.FaucetAddress && msg.Method == "tap" && msg.Value.IsZero()
+}
+
 // Check's whether the maximum gas charge + message value is within the actor's balance.
 // Note that this is an imperfect test, since nested messages invoked by this one may transfer
 // more value from the actor's balance.
@@ -130,6 +136,13 @@ func (v *IngestionValidator) Validate(ctx context.Context, msg *types.SignedMess
 		}
 	}
 
+	// free faucet taps are only accepted on networks with a faucet
+	if isFaucetTap(msg) && !msg.GasPrice.IsPositive() {
+		if _, err := v.api.ActorFromLatestState(ctx, address.FaucetAddress); err != nil {
+			return errors.NewRevertError("free faucet tap on a network without faucet")
+		}
+	}
+
 	// check that message nonce is not too high
 	if msg.Nonce > fromActor.Nonce && msg.Nonce-fromActor.Nonce > v.cfg.MaxNonceGap {
 		return errors.NewRevertErrorf("message nonce (%d) is too much greater than actor nonce (%d)", msg.Nonce, fromActor.Nonce)
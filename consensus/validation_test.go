@@ -90,6 +90,18 @@ func TestMessageValidator(t *testing.T) {
 		msg := newMessage(t, alice, bob, 101, 5, 1, 0)
 		assert.Errorf(t, validator.Validate(ctx, msg, actor), "too high")
 	})
+
+	t.Run("zero gas price fails unless tapping the faucet", func(t *testing.T) {
+		msg := newMessage(t, alice, bob, 100, 0, 0, 0)
+		assert.Error(t, validator.Validate(ctx, msg, actor))
+
+		tap := newTapMessage(t, alice, 100, 0)
+		assert.NoError(t, validator.Validate(ctx, tap, actor))
+
+		emptyActor := &actor.Actor{}
+		tap = newTapMessage(t, alice, 0, 0)
+		assert.NoError(t, validator.Validate(ctx, tap, emptyActor))
+	})
 }
 
 func TestOutboundMessageValidator(t *testing.T) {
@@ -165,6 +177,20 @@ func newMessage(t *testing.T, from, to address.Address, nonce uint64, valueAF in
 	return signed
 }
 
+func newTapMessage(t *testing.T, from address.Address, nonce uint64, gasPrice int64) *types.SignedMessage {
+	msg := types.NewMessage(
+		from,
+		address.FaucetAddress,
+		nonce,
+		types.NewZeroAttoFIL(),
+		"tap",
+		actor.MustConvertParams(from),
+	)
+	signed, err := types.NewSignedMessage(*msg, signer, types.NewGasPrice(gasPrice), types.NewGasUnits(300))
+	require.NoError(t, err)
+	return signed
+}
+
 func attoFil(v int) *types.AttoFIL {
 	val, _ := types.NewAttoFILFromString(fmt.Sprintf("%d", v), 10)
 	return val
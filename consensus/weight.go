@@ -0,0 +1,160 @@
+package consensus
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs-blockstore"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Names of the chain weighers, as set in the sync.chainWeight config key.
+const (
+	// PowerWeight weighs chains by the power of their miners, as specified
+	// by expected consensus.
+	PowerWeight = "power"
+	// HeightWeight weighs chains by their height.
+	HeightWeight = "height"
+)
+
+// Weigher computes the weight of tipsets, by which the heaviest chain is
+// chosen. Weights are uint64 encoded fixed point numbers.
+type Weigher interface {
+	// Weight returns the weight of ts, whose parents' state is pSt.
+	Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (uint64, error)
+	// WeightRange returns the least and the most weight ts can have given
+	// only its headers, so that the weights chains claim can be checked
+	// before their state is computed.
+	WeightRange(ts types.TipSet) (min uint64, max uint64, err error)
+}
+
+// NewWeigher returns the weigher with the given name, or the power weigher if
+// name is empty.
+func NewWeigher(name string, bs blockstore.Blockstore, pt PowerTableView, gCid cid.Cid) (Weigher, error) {
+	switch name {
+	case "", PowerWeight:
+		return NewPowerWeigher(bs, pt, gCid), nil
+	case HeightWeight:
+		return &HeightWeigher{}, nil
+	default:
+		return nil, errors.Errorf("unknown chain weight %q", name)
+	}
+}
+
+// PowerWeigher is the expected consensus weigher: each block adds ECV plus
+// ECPrM times the power ratio of its miner to the weight of its parents.
+type PowerWeigher struct {
+	bstore     blockstore.Blockstore
+	ptv        PowerTableView
+	genesisCid cid.Cid
+}
+
+// NewPowerWeigher returns a PowerWeigher reading the power table with pt.
+func NewPowerWeigher(bs blockstore.Blockstore, pt PowerTableView, gCid cid.Cid) *PowerWeigher {
+	return &PowerWeigher{
+		bstore:     bs,
+		ptv:        pt,
+		genesisCid: gCid,
+	}
+}
+
+// Weight returns the EC weight of this TipSet in uint64 encoded fixed point
+// representation.
+func (w *PowerWeigher) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (uint64, error) {
+	ctx = log.Start(ctx, "Expected.Weight")
+	log.LogKV(ctx, "Weight", ts.String())
+	if len(ts) == 1 && ts.ToSlice()[0].Cid().Equals(w.genesisCid) {
+		return uint64(0), nil
+	}
+	// Compute parent weight.
+	parentW, err := ts.ParentWeight()
+	if err != nil {
+		return uint64(0), err
+	}
+
+	weight, err := types.FixedToBig(parentW)
+	if err != nil {
+		return uint64(0), err
+	}
+	// Each block in the tipset adds ECV + ECPrm * miner_power to parent weight.
+	totalBytes, err := w.ptv.Total(ctx, pSt, w.bstore)
+	if err != nil {
+		return uint64(0), err
+	}
+	floatTotalBytes := new(big.Float).SetInt64(int64(totalBytes))
+	floatECV := new(big.Float).SetInt64(int64(ECV))
+	floatECPrM := new(big.Float).SetInt64(int64(ECPrM))
+	for _, blk := range ts.ToSlice() {
+		minerBytes, err := w.ptv.Miner(ctx, pSt, w.bstore, blk.Miner)
+		if err != nil {
+			return uint64(0), err
+		}
+		floatOwnBytes := new(big.Float).SetInt64(int64(minerBytes))
+		wBlk := new(big.Float)
+		wBlk.Quo(floatOwnBytes, floatTotalBytes)
+		wBlk.Mul(wBlk, floatECPrM) // Power addition
+		wBlk.Add(wBlk, floatECV)   // Constant addition
+		weight.Add(weight, wBlk)
+	}
+	return types.BigToFixed(weight)
+}
+
+// WeightRange returns the weight of ts if its miners had none, and if they
+// had all, of the power.
+func (w *PowerWeigher) WeightRange(ts types.TipSet) (uint64, uint64, error) {
+	if len(ts) == 1 && ts.ToSlice()[0].Cid().Equals(w.genesisCid) {
+		return uint64(0), uint64(0), nil
+	}
+	parentW, err := ts.ParentWeight()
+	if err != nil {
+		return uint64(0), uint64(0), err
+	}
+	weight, err := types.FixedToBig(parentW)
+	if err != nil {
+		return uint64(0), uint64(0), err
+	}
+
+	blocks := new(big.Float).SetInt64(int64(len(ts)))
+	min := new(big.Float).Mul(blocks, new(big.Float).SetInt64(int64(ECV)))
+	min.Add(min, weight)
+	max := new(big.Float).Mul(blocks, new(big.Float).SetInt64(int64(ECV+ECPrM)))
+	max.Add(max, weight)
+
+	minW, err := types.BigToFixed(min)
+	if err != nil {
+		return uint64(0), uint64(0), err
+	}
+	maxW, err := types.BigToFixed(max)
+	if err != nil {
+		return uint64(0), uint64(0), err
+	}
+	return minW, maxW, nil
+}
+
+// HeightWeigher weighs tipsets by their height, regardless of the power of
+// their miners. It suits test networks whose miners have no power, and the
+// longest chain wins.
+type HeightWeigher struct{}
+
+// Weight returns the height of ts in uint64 encoded fixed point
+// representation.
+func (w *HeightWeigher) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (uint64, error) {
+	h, err := ts.Height()
+	if err != nil {
+		return uint64(0), err
+	}
+	return types.BigToFixed(new(big.Float).SetUint64(h))
+}
+
+// WeightRange returns the weight of ts, which only depends on its height.
+func (w *HeightWeigher) WeightRange(ts types.TipSet) (uint64, uint64, error) {
+	weight, err := w.Weight(context.Background(), ts, nil)
+	if err != nil {
+		return uint64(0), uint64(0), err
+	}
+	return weight, weight, nil
+}
@@ -0,0 +1,72 @@
+package consensus_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestNewWeigher(t *testing.T) {
+	tf.UnitTest(t)
+
+	_, bstore, _ := setupCborBlockstoreProofs()
+	ptv := testhelpers.NewTestPowerTableView(1, 5)
+
+	for _, name := range []string{"", consensus.PowerWeight} {
+		w, err := consensus.NewWeigher(name, bstore, ptv, types.SomeCid())
+		require.NoError(t, err)
+		assert.IsType(t, &consensus.PowerWeigher{}, w)
+	}
+
+	w, err := consensus.NewWeigher(consensus.HeightWeight, bstore, ptv, types.SomeCid())
+	require.NoError(t, err)
+	assert.IsType(t, &consensus.HeightWeigher{}, w)
+
+	_, err = consensus.NewWeigher("longest", bstore, ptv, types.SomeCid())
+	assert.Error(t, err)
+}
+
+func TestHeightWeigher(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	genesis := types.NewBlockForTest(nil, 0)
+	child := types.NewBlockForTest(genesis, 1)
+	child.Height = 3
+
+	w := &consensus.HeightWeigher{}
+	genesisWeight, err := w.Weight(ctx, types.RequireNewTipSet(t, genesis), nil)
+	require.NoError(t, err)
+	childWeight, err := w.Weight(ctx, types.RequireNewTipSet(t, child), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(0), genesisWeight)
+	expected, err := types.BigToFixed(big.NewFloat(3))
+	require.NoError(t, err)
+	assert.Equal(t, expected, childWeight)
+}
+
+func TestExpectedWithWeigher(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	cst, bstore, verifier := setupCborBlockstoreProofs()
+	ptv := testhelpers.NewTestPowerTableView(1, 5)
+	exp := consensus.NewExpectedWithWeigher(cst, bstore, consensus.NewDefaultProcessor(), ptv, types.SomeCid(), verifier, &consensus.HeightWeigher{}, nil, nil)
+
+	genesis := types.NewBlockForTest(nil, 0)
+	short := types.NewBlockForTest(genesis, 1)
+	long := types.NewBlockForTest(short, 2)
+
+	heavier, err := exp.IsHeavier(ctx, types.RequireNewTipSet(t, long), types.RequireNewTipSet(t, short), nil, nil)
+	require.NoError(t, err)
+	assert.True(t, heavier)
+}
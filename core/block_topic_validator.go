@@ -0,0 +1,194 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-peer"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// invalidBlockPenalty is the score a peer loses for each invalid block it
+// sends. Blocks are much rarer than messages, so that few invalid blocks are
+// enough to have the peer ignored.
+const invalidBlockPenalty = 25
+
+var (
+	// ErrBlockWithoutTicket is returned for blocks without a ticket.
+	ErrBlockWithoutTicket = errors.New("block has no ticket")
+	// ErrBlockLosingTicket is returned for blocks whose ticket does not win against the parents' state.
+	ErrBlockLosingTicket = errors.New("block ticket is not a winning ticket")
+	// ErrBlockBadSignature is returned for blocks not signed by their miner's worker in the parents' state.
+	ErrBlockBadSignature = errors.New("block is not signed by its miner's worker")
+	// ErrBlockBadHeight is returned for blocks not higher than their parents.
+	ErrBlockBadHeight = errors.New("block is not higher than its parents")
+	// ErrBlockBadParentWeight is returned for blocks claiming a parent weight lower than their parents'.
+	ErrBlockBadParentWeight = errors.New("block parent weight is lower than its parents'")
+)
+
+// blockTopicChain is the chain store the validator reads the known tipsets
+// from.
+type blockTopicChain interface {
+	GetTipSetAndState(tsKey types.SortedCidSet) (*chain.TipSetAndState, error)
+	HasTipSetAndState(ctx context.Context, tsKey string) bool
+}
+
+// blockTicketValidator checks the tickets and signatures of blocks against the
+// state they were mined on.
+type blockTicketValidator interface {
+	IsWinningTicket(ctx context.Context, stateRoot cid.Cid, ticket types.Signature, miner address.Address) (bool, error)
+	IsValidBlockSignature(ctx context.Context, stateRoot cid.Cid, blk *types.Block) (bool, error)
+}
+
+// BlockTopicValidator validates blocks received from peers over the block
+// pubsub topic, so that junk blocks are not propagated to other peers before
+// the syncer gets to reject them. It checks what can be checked cheaply: that
+// the block is signed, the signatures of the messages, the timestamp and, when
+// the parents are known locally, the height, parent weight, ticket and block
+// signature against the parents. Blocks building on unknown parents are let
+// through to the syncer, which fetches and validates the whole chain. Peers
+// are scored by the validity of the blocks they sent, and the blocks of peers
+// with too low a score are rejected unchecked.
+//
+// Blocks carry no proof of spacetime that could be verified without the
+// sector set, so it is not checked.
+//
+// BlockTopicValidator is safe for concurrent access.
+type BlockTopicValidator struct {
+	chain    blockTopicChain
+	tickets  blockTicketValidator
+	versions consensus.ProtocolVersionTable
+
+	lk     sync.Mutex
+	scores map[peer.ID]int
+}
+
+// NewBlockTopicValidator creates a topic validator checking blocks against
+// the tipsets of chainStore and the protocol versions of the network.
+func NewBlockTopicValidator(chainStore blockTopicChain, tickets blockTicketValidator, versions consensus.ProtocolVersionTable) *BlockTopicValidator {
+	return &BlockTopicValidator{
+		chain:    chainStore,
+		tickets:  tickets,
+		versions: versions,
+		scores:   make(map[peer.ID]int),
+	}
+}
+
+// Validate checks the encoded block data received from peer from. It returns
+// an error if the block must be rejected.
+func (bv *BlockTopicValidator) Validate(ctx context.Context, from peer.ID, data []byte) error {
+	if bv.Score(from) < minPeerScore {
+		return ErrPeerScoreTooLow
+	}
+
+	blk, err := types.DecodeBlock(data)
+	if err != nil {
+		bv.adjust(from, -invalidBlockPenalty)
+		return errors.Wrap(err, "failed to decode block")
+	}
+
+	invalid, err := bv.validate(ctx, blk, time.Now())
+	if err != nil {
+		// Only penalize the peer for the block being invalid, not for
+		// failures to read the state to check it against.
+		if invalid {
+			bv.adjust(from, -invalidBlockPenalty)
+		}
+		return err
+	}
+
+	bv.adjust(from, 1)
+	return nil
+}
+
+// Score returns the current score of peer p.
+func (bv *BlockTopicValidator) Score(p peer.ID) int {
+	bv.lk.Lock()
+	defer bv.lk.Unlock()
+
+	return bv.scores[p]
+}
+
+// validate checks blk, returning whether an error is due to the block being
+// invalid.
+func (bv *BlockTopicValidator) validate(ctx context.Context, blk *types.Block, now time.Time) (bool, error) {
+	if len(blk.Ticket) == 0 {
+		return true, ErrBlockWithoutTicket
+	}
+	if err := consensus.ValidateProtocolVersion(blk, bv.versions); err != nil {
+		return true, err
+	}
+	if err := consensus.ValidateSignaturePresent(blk); err != nil {
+		return true, err
+	}
+	for _, msg := range blk.Messages {
+		if !msg.VerifySignature() {
+			return true, errors.Errorf("block contains message with invalid signature from %s", msg.From)
+		}
+	}
+	if err := consensus.ValidateTimestamp(blk, nil, now); err != nil {
+		return true, err
+	}
+
+	if blk.Parents.Empty() || !bv.chain.HasTipSetAndState(ctx, blk.Parents.String()) {
+		return false, nil
+	}
+	parents, err := bv.chain.GetTipSetAndState(blk.Parents)
+	if err != nil {
+		return false, err
+	}
+
+	if err := consensus.ValidateTimestamp(blk, parents.TipSet, now); err != nil {
+		return true, err
+	}
+	parentHeight, err := parents.TipSet.Height()
+	if err != nil {
+		return false, err
+	}
+	if uint64(blk.Height) <= parentHeight {
+		return true, errors.Wrapf(ErrBlockBadHeight, "block %s has height %d, parents have %d", blk.Cid(), blk.Height, parentHeight)
+	}
+	parentWeight, err := parents.TipSet.ParentWeight()
+	if err != nil {
+		return false, err
+	}
+	if uint64(blk.ParentWeight) < parentWeight {
+		return true, errors.Wrapf(ErrBlockBadParentWeight, "block %s has parent weight %d, parents have %d", blk.Cid(), blk.ParentWeight, parentWeight)
+	}
+
+	won, err := bv.tickets.IsWinningTicket(ctx, parents.TipSetStateRoot, blk.Ticket, blk.Miner)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check the block ticket")
+	}
+	if !won {
+		return true, ErrBlockLosingTicket
+	}
+
+	signed, err := bv.tickets.IsValidBlockSignature(ctx, parents.TipSetStateRoot, blk)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check the block signature")
+	}
+	if !signed {
+		return true, ErrBlockBadSignature
+	}
+	return false, nil
+}
+
+// adjust adds delta to the score of peer p, up to maxPeerScore.
+func (bv *BlockTopicValidator) adjust(p peer.ID, delta int) {
+	bv.lk.Lock()
+	defer bv.lk.Unlock()
+
+	score := bv.scores[p] + delta
+	if score > maxPeerScore {
+		score = maxPeerScore
+	}
+	bv.scores[p] = score
+}
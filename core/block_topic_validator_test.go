@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-peer"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+type fakeBlockTopicChain struct {
+	tipSets map[string]*chain.TipSetAndState
+}
+
+func (c *fakeBlockTopicChain) GetTipSetAndState(tsKey types.SortedCidSet) (*chain.TipSetAndState, error) {
+	tsas, ok := c.tipSets[tsKey.String()]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return tsas, nil
+}
+
+func (c *fakeBlockTopicChain) HasTipSetAndState(ctx context.Context, tsKey string) bool {
+	_, ok := c.tipSets[tsKey]
+	return ok
+}
+
+type fakeBlockTicketValidator struct {
+	won    bool
+	forged bool
+	err    error
+}
+
+func (v *fakeBlockTicketValidator) IsWinningTicket(ctx context.Context, stateRoot cid.Cid, ticket types.Signature, miner address.Address) (bool, error) {
+	return v.won, v.err
+}
+
+func (v *fakeBlockTicketValidator) IsValidBlockSignature(ctx context.Context, stateRoot cid.Cid, blk *types.Block) (bool, error) {
+	return !v.forged, v.err
+}
+
+func TestBlockTopicValidator(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	parent := types.NewBlockForTest(nil, 0)
+	parent.Height = 5
+	parent.ParentWeight = 10
+	parent.Timestamp = types.Uint64(time.Now().Unix() - 30)
+	parents := &fakeBlockTopicChain{tipSets: map[string]*chain.TipSetAndState{
+		parent.Cid().String(): {TipSet: types.RequireNewTipSet(t, parent)},
+	}}
+
+	newBlock := func() *types.Block {
+		blk := types.NewBlockForTest(parent, 1)
+		blk.Ticket = types.Signature{1, 2, 3}
+		blk.BlockSig = types.Signature{4, 5, 6}
+		blk.ParentWeight = 12
+		blk.Timestamp = types.Uint64(time.Now().Unix())
+		return blk
+	}
+	encode := func(blk *types.Block) []byte {
+		return blk.ToNode().RawData()
+	}
+
+	t.Run("accepts valid blocks and rewards the peer", func(t *testing.T) {
+		bv := NewBlockTopicValidator(parents, &fakeBlockTicketValidator{won: true}, nil)
+		assert.NoError(t, bv.Validate(ctx, peer.ID("a"), encode(newBlock())))
+		assert.Equal(t, 1, bv.Score(peer.ID("a")))
+	})
+
+	t.Run("accepts blocks with unknown parents unchecked", func(t *testing.T) {
+		bv := NewBlockTopicValidator(&fakeBlockTopicChain{}, &fakeBlockTicketValidator{}, nil)
+		assert.NoError(t, bv.Validate(ctx, peer.ID("a"), encode(newBlock())))
+	})
+
+	t.Run("rejects and penalizes invalid blocks", func(t *testing.T) {
+		bv := NewBlockTopicValidator(parents, &fakeBlockTicketValidator{won: true}, nil)
+
+		assert.Error(t, bv.Validate(ctx, peer.ID("a"), []byte("garbage")))
+
+		noTicket := newBlock()
+		noTicket.Ticket = nil
+		assert.Equal(t, ErrBlockWithoutTicket, bv.Validate(ctx, peer.ID("a"), encode(noTicket)))
+
+		unsigned := newBlock()
+		unsigned.BlockSig = nil
+		assert.Equal(t, consensus.ErrUnsignedBlock, errors.Cause(bv.Validate(ctx, peer.ID("a"), encode(unsigned))))
+
+		future := newBlock()
+		future.Timestamp = types.Uint64(time.Now().Add(time.Hour).Unix())
+		assert.Equal(t, consensus.ErrBlockFromFuture, errors.Cause(bv.Validate(ctx, peer.ID("a"), encode(future))))
+
+		low := newBlock()
+		low.Height = parent.Height
+		assert.Equal(t, ErrBlockBadHeight, errors.Cause(bv.Validate(ctx, peer.ID("a"), encode(low))))
+
+		light := newBlock()
+		light.ParentWeight = parent.ParentWeight - 1
+		assert.Equal(t, ErrBlockBadParentWeight, errors.Cause(bv.Validate(ctx, peer.ID("a"), encode(light))))
+
+		assert.Equal(t, -6*invalidBlockPenalty, bv.Score(peer.ID("a")))
+		assert.Equal(t, ErrPeerScoreTooLow, bv.Validate(ctx, peer.ID("a"), encode(newBlock())))
+	})
+
+	t.Run("rejects blocks of other protocol versions", func(t *testing.T) {
+		versions := consensus.ProtocolVersionTable{{Version: 1, Height: types.NewBlockHeight(0)}}
+		bv := NewBlockTopicValidator(parents, &fakeBlockTicketValidator{won: true}, versions)
+
+		assert.Equal(t, consensus.ErrWrongProtocolVersion, errors.Cause(bv.Validate(ctx, peer.ID("a"), encode(newBlock()))))
+		assert.Equal(t, -invalidBlockPenalty, bv.Score(peer.ID("a")))
+
+		versioned := newBlock()
+		versioned.ProtocolVersion = 1
+		assert.NoError(t, bv.Validate(ctx, peer.ID("b"), encode(versioned)))
+	})
+
+	t.Run("penalizes losing tickets but not failures to check them", func(t *testing.T) {
+		bv := NewBlockTopicValidator(parents, &fakeBlockTicketValidator{won: false}, nil)
+		assert.Equal(t, ErrBlockLosingTicket, bv.Validate(ctx, peer.ID("a"), encode(newBlock())))
+		assert.Equal(t, -invalidBlockPenalty, bv.Score(peer.ID("a")))
+
+		bv = NewBlockTopicValidator(parents, &fakeBlockTicketValidator{err: errors.New("state unavailable")}, nil)
+		assert.Error(t, bv.Validate(ctx, peer.ID("a"), encode(newBlock())))
+		assert.Equal(t, 0, bv.Score(peer.ID("a")))
+	})
+
+	t.Run("rejects and penalizes blocks not signed by the miner's worker", func(t *testing.T) {
+		bv := NewBlockTopicValidator(parents, &fakeBlockTicketValidator{won: true, forged: true}, nil)
+		assert.Equal(t, ErrBlockBadSignature, bv.Validate(ctx, peer.ID("a"), encode(newBlock())))
+		assert.Equal(t, -invalidBlockPenalty, bv.Score(peer.ID("a")))
+	})
+}
@@ -0,0 +1,253 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// MessageStatus is the status of a message in the MessageJournal.
+type MessageStatus string
+
+const (
+	// MessagePending is the status of messages sent but not yet on chain.
+	MessagePending = MessageStatus("pending")
+	// MessageMined is the status of messages on chain that executed
+	// successfully.
+	MessageMined = MessageStatus("mined")
+	// MessageFailed is the status of messages on chain that failed to execute
+	// and of messages that expired before reaching the chain.
+	MessageFailed = MessageStatus("failed")
+	// MessageReplaced is the status of messages whose nonce another message
+	// from the same sender took on chain.
+	MessageReplaced = MessageStatus("replaced")
+)
+
+// MessageJournalSize is the number of messages the MessageJournal remembers.
+// Beyond it, the journal forgets the oldest messages no longer pending.
+const MessageJournalSize = 1000
+
+// JournalEntry is a message in the MessageJournal and what became of it.
+type JournalEntry struct {
+	Cid cid.Cid
+	Msg *types.SignedMessage
+	// Status is the current status of the message.
+	Status MessageStatus
+	// SentAt is the block height at which the message was sent.
+	SentAt uint64
+	// BroadcastAt is the block height at which the message was last
+	// broadcast, either when sent or rebroadcast.
+	BroadcastAt uint64
+	// Broadcasts is the number of times the message was broadcast.
+	Broadcasts uint64
+	// MinedAt is the height of the block including the message or the
+	// message replacing it.
+	MinedAt uint64
+	// ReplacedBy is the cid of the message that took the nonce of a replaced
+	// message.
+	ReplacedBy cid.Cid
+	// Error describes why the message failed.
+	Error string
+}
+
+// MessageJournal keeps track of the messages sent from this node, from the
+// time they are sent until they appear on chain or expire, and remembers what
+// became of them for a while after. Unlike the outbox, which only holds the
+// messages still to be mined, the journal follows messages through re-orgs:
+// a message whose block leaves the chain is pending again.
+// MessageJournal is safe for concurrent access.
+type MessageJournal struct {
+	lk      sync.RWMutex
+	entries map[cid.Cid]*JournalEntry
+	// The cids of the messages in the order they were sent.
+	order []cid.Cid
+}
+
+// NewMessageJournal returns a new, empty journal.
+func NewMessageJournal() *MessageJournal {
+	return &MessageJournal{
+		entries: make(map[cid.Cid]*JournalEntry),
+	}
+}
+
+// Add records msg as pending, sent and broadcast at height.
+func (j *MessageJournal) Add(msg *types.SignedMessage, height uint64) error {
+	c, err := msg.Cid()
+	if err != nil {
+		return errors.Wrap(err, "failed to get message cid")
+	}
+
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	if _, ok := j.entries[c]; ok {
+		return nil
+	}
+	j.entries[c] = &JournalEntry{
+		Cid:         c,
+		Msg:         msg,
+		Status:      MessagePending,
+		SentAt:      height,
+		BroadcastAt: height,
+		Broadcasts:  1,
+	}
+	j.order = append(j.order, c)
+	j.forget()
+	return nil
+}
+
+// forget drops the oldest messages no longer pending until the journal fits
+// in MessageJournalSize.
+func (j *MessageJournal) forget() {
+	excess := len(j.order) - MessageJournalSize
+	if excess <= 0 {
+		return
+	}
+	kept := j.order[:0]
+	for _, c := range j.order {
+		if excess > 0 && j.entries[c].Status != MessagePending {
+			delete(j.entries, c)
+			excess--
+			continue
+		}
+		kept = append(kept, c)
+	}
+	j.order = kept
+}
+
+// Get returns a copy of the entry of the message with cid c.
+func (j *MessageJournal) Get(c cid.Cid) (*JournalEntry, bool) {
+	j.lk.RLock()
+	defer j.lk.RUnlock()
+
+	entry, ok := j.entries[c]
+	if !ok {
+		return nil, false
+	}
+	out := *entry
+	return &out, true
+}
+
+// Entries returns copies of the entries of the journal in the order the
+// messages were sent.
+func (j *MessageJournal) Entries() []*JournalEntry {
+	return j.list(func(*JournalEntry) bool { return true })
+}
+
+// Pending returns copies of the entries of the pending messages in the order
+// they were sent.
+func (j *MessageJournal) Pending() []*JournalEntry {
+	return j.list(func(entry *JournalEntry) bool { return entry.Status == MessagePending })
+}
+
+func (j *MessageJournal) list(include func(*JournalEntry) bool) []*JournalEntry {
+	j.lk.RLock()
+	defer j.lk.RUnlock()
+
+	var out []*JournalEntry
+	for _, c := range j.order {
+		if entry := j.entries[c]; include(entry) {
+			copied := *entry
+			out = append(out, &copied)
+		}
+	}
+	return out
+}
+
+// Rebroadcast records that the pending message with cid c was broadcast
+// again at height.
+func (j *MessageJournal) Rebroadcast(c cid.Cid, height uint64) {
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	if entry, ok := j.entries[c]; ok && entry.Status == MessagePending {
+		entry.BroadcastAt = height
+		entry.Broadcasts++
+	}
+}
+
+// OnNewHeadTipset updates the status of the messages in the journal from the
+// blocks between the old head and the new head. Messages pending for more
+// than maxAgeRounds at the new head fail as expired.
+func (j *MessageJournal) OnNewHeadTipset(ctx context.Context, store chain.BlockProvider, oldHead, newHead types.TipSet, maxAgeRounds uint64) error {
+	oldBlocks, newBlocks, err := CollectBlocksToCommonAncestor(ctx, store, oldHead, newHead)
+	if err != nil {
+		return err
+	}
+	height, err := newHead.Height()
+	if err != nil {
+		return err
+	}
+
+	j.lk.Lock()
+	defer j.lk.Unlock()
+
+	// Messages of blocks that left the chain are pending again, unless the
+	// new chain includes them too.
+	for _, block := range oldBlocks {
+		for _, minedMsg := range block.Messages {
+			c, err := minedMsg.Cid()
+			if err != nil {
+				return err
+			}
+			for _, entry := range j.entries {
+				if entry.Cid.Equals(c) || entry.ReplacedBy.Equals(c) {
+					entry.Status = MessagePending
+					entry.MinedAt = 0
+					entry.ReplacedBy = cid.Undef
+					entry.Error = ""
+				}
+			}
+		}
+	}
+
+	// Process the new blocks in increasing height order.
+	reverse(newBlocks)
+	for _, block := range newBlocks {
+		for i, minedMsg := range block.Messages {
+			c, err := minedMsg.Cid()
+			if err != nil {
+				return err
+			}
+			var receipt *types.MessageReceipt
+			if i < len(block.MessageReceipts) {
+				receipt = block.MessageReceipts[i]
+			}
+
+			for _, entry := range j.entries {
+				// Expired messages may still make it to the chain.
+				expired := entry.Status == MessageFailed && entry.MinedAt == 0
+				if (entry.Status != MessagePending && !expired) || entry.Msg.From != minedMsg.From || entry.Msg.Nonce != minedMsg.Nonce {
+					continue
+				}
+				entry.MinedAt = uint64(block.Height)
+				entry.Error = ""
+				switch {
+				case !entry.Cid.Equals(c):
+					entry.Status = MessageReplaced
+					entry.ReplacedBy = c
+				case receipt != nil && receipt.ExitCode != 0:
+					entry.Status = MessageFailed
+					entry.Error = fmt.Sprintf("exit code %d", receipt.ExitCode)
+				default:
+					entry.Status = MessageMined
+				}
+			}
+		}
+	}
+
+	for _, entry := range j.entries {
+		if entry.Status == MessagePending && height > entry.SentAt+maxAgeRounds {
+			entry.Status = MessageFailed
+			entry.Error = fmt.Sprintf("expired un-mined after %d rounds", maxAgeRounds)
+		}
+	}
+	j.forget()
+	return nil
+}
@@ -0,0 +1,152 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/core"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestMessageJournal(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	keys := types.MustGenerateKeyInfo(2, types.GenerateKeyInfoSeed())
+	mm := types.NewMessageMaker(t, keys)
+
+	alice := mm.Addresses()[0]
+	bob := mm.Addresses()[1]
+
+	requireAdd := func(j *core.MessageJournal, msg *types.SignedMessage, height uint64) *types.SignedMessage {
+		require.NoError(t, j.Add(msg, height))
+		return msg
+	}
+	requireEntry := func(j *core.MessageJournal, msg *types.SignedMessage) *core.JournalEntry {
+		c, err := msg.Cid()
+		require.NoError(t, err)
+		entry, ok := j.Get(c)
+		require.True(t, ok)
+		return entry
+	}
+
+	t.Run("records sent messages as pending", func(t *testing.T) {
+		j := core.NewMessageJournal()
+		msgs := []*types.SignedMessage{
+			requireAdd(j, mm.NewSignedMessage(alice, 1), 100),
+			requireAdd(j, mm.NewSignedMessage(bob, 1), 101),
+		}
+
+		pending := j.Pending()
+		require.Len(t, pending, 2)
+		for i, entry := range pending {
+			assert.Equal(t, msgs[i], entry.Msg)
+			assert.Equal(t, core.MessagePending, entry.Status)
+			assert.Equal(t, uint64(100+i), entry.SentAt)
+			assert.Equal(t, uint64(1), entry.Broadcasts)
+		}
+
+		j.Rebroadcast(pending[0].Cid, 105)
+		entry := requireEntry(j, msgs[0])
+		assert.Equal(t, uint64(105), entry.BroadcastAt)
+		assert.Equal(t, uint64(2), entry.Broadcasts)
+	})
+
+	t.Run("follows mined and replaced messages", func(t *testing.T) {
+		blocks := th.NewFakeBlockProvider()
+		j := core.NewMessageJournal()
+		mined := requireAdd(j, mm.NewSignedMessage(alice, 1), 100)
+		next := requireAdd(j, mm.NewSignedMessage(alice, 2), 100)
+		replaced := requireAdd(j, mm.NewSignedMessage(bob, 1), 100)
+		replacement := mm.NewSignedMessage(bob, 1)
+
+		root := blocks.NewBlock(0)
+		root.Height = 100
+		b1 := blocks.NewBlockWithMessages(1, []*types.SignedMessage{mined, replacement}, root)
+		require.NoError(t, j.OnNewHeadTipset(ctx, blocks, requireTipset(t, root), requireTipset(t, b1), 10))
+
+		entry := requireEntry(j, mined)
+		assert.Equal(t, core.MessageMined, entry.Status)
+		assert.Equal(t, uint64(101), entry.MinedAt)
+
+		entry = requireEntry(j, replaced)
+		assert.Equal(t, core.MessageReplaced, entry.Status)
+		replacementCid, err := replacement.Cid()
+		require.NoError(t, err)
+		assert.Equal(t, replacementCid, entry.ReplacedBy)
+
+		assert.Equal(t, core.MessagePending, requireEntry(j, next).Status)
+		assert.Len(t, j.Pending(), 1)
+		assert.Len(t, j.Entries(), 3)
+	})
+
+	t.Run("returns messages to pending on re-orgs", func(t *testing.T) {
+		blocks := th.NewFakeBlockProvider()
+		j := core.NewMessageJournal()
+		msg := requireAdd(j, mm.NewSignedMessage(alice, 1), 100)
+
+		root := blocks.NewBlock(0)
+		root.Height = 100
+		a1 := blocks.NewBlockWithMessages(1, []*types.SignedMessage{msg}, root)
+		require.NoError(t, j.OnNewHeadTipset(ctx, blocks, requireTipset(t, root), requireTipset(t, a1), 10))
+		assert.Equal(t, core.MessageMined, requireEntry(j, msg).Status)
+
+		b1 := blocks.NewBlock(2, root)
+		b2 := blocks.NewBlock(3, b1)
+		require.NoError(t, j.OnNewHeadTipset(ctx, blocks, requireTipset(t, a1), requireTipset(t, b2), 10))
+		entry := requireEntry(j, msg)
+		assert.Equal(t, core.MessagePending, entry.Status)
+		assert.Equal(t, uint64(0), entry.MinedAt)
+	})
+
+	t.Run("fails messages failing on chain", func(t *testing.T) {
+		blocks := th.NewFakeBlockProvider()
+		j := core.NewMessageJournal()
+		msg := requireAdd(j, mm.NewSignedMessage(alice, 1), 100)
+
+		root := blocks.NewBlock(0)
+		root.Height = 100
+		b1 := blocks.NewBlockWithMessages(1, []*types.SignedMessage{msg}, root)
+		b1.MessageReceipts = []*types.MessageReceipt{{ExitCode: 1}}
+		require.NoError(t, j.OnNewHeadTipset(ctx, blocks, requireTipset(t, root), requireTipset(t, b1), 10))
+
+		entry := requireEntry(j, msg)
+		assert.Equal(t, core.MessageFailed, entry.Status)
+		assert.Contains(t, entry.Error, "exit code 1")
+	})
+
+	t.Run("expires old messages", func(t *testing.T) {
+		blocks := th.NewFakeBlockProvider()
+		j := core.NewMessageJournal()
+		msg := requireAdd(j, mm.NewSignedMessage(alice, 1), 100)
+
+		root := blocks.NewBlock(0)
+		root.Height = 100
+
+		// Skip exactly 10 rounds since the message was sent
+		b1 := blocks.NewBlock(1, root)
+		b1.Height = 110
+		require.NoError(t, j.OnNewHeadTipset(ctx, blocks, requireTipset(t, root), requireTipset(t, b1), 10))
+		assert.Equal(t, core.MessagePending, requireEntry(j, msg).Status)
+
+		b2 := blocks.NewBlock(2, b1) // Height 111
+		require.NoError(t, j.OnNewHeadTipset(ctx, blocks, requireTipset(t, b1), requireTipset(t, b2), 10))
+		entry := requireEntry(j, msg)
+		assert.Equal(t, core.MessageFailed, entry.Status)
+		assert.Contains(t, entry.Error, "expired")
+		assert.Empty(t, j.Pending())
+
+		// Expired messages may still be mined.
+		b3 := blocks.NewBlockWithMessages(3, []*types.SignedMessage{msg}, b2)
+		require.NoError(t, j.OnNewHeadTipset(ctx, blocks, requireTipset(t, b2), requireTipset(t, b3), 10))
+		entry = requireEntry(j, msg)
+		assert.Equal(t, core.MessageMined, entry.Status)
+		assert.Equal(t, "", entry.Error)
+	})
+}
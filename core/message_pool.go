@@ -2,11 +2,16 @@ package core
 
 import (
 	"context"
+	"math/big"
+	"sort"
 	"sync"
 
 	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/config"
@@ -16,17 +21,59 @@ import (
 
 var mpSize = metrics.NewInt64Gauge("message_pool_size", "The size of the message pool")
 
-// MessageTimeOut is the number of tipsets we should receive before timing out messages
-const MessageTimeOut = 6
+// messagePoolPrefix is the datastore prefix of the persisted pending messages.
+const messagePoolPrefix = "mpool"
+
+// minerActorCodes are the codes of the miner actors.
+var minerActorCodes = []cid.Cid{types.MinerActorCodeCid, types.BootstrapMinerActorCodeCid}
+
+// criticalMethods lists, by target actor and method, the messages the safety
+// of the network depends on. When the pool is full it evicts ordinary messages
+// to make room for these. An undefined actor address matches any actor whose
+// code, in the latest state, is one of codes.
+var criticalMethods = []struct {
+	to     address.Address
+	codes  []cid.Cid
+	method string
+}{
+	{address.Undef, minerActorCodes, "submitPoSt"},
+	{address.Undef, minerActorCodes, "slashStorageFault"},
+	{address.PaymentBrokerAddress, nil, "reclaim"},
+}
+
+// isCriticalMessage returns true if msg is one the pool must try hardest to
+// keep, see criticalMethods.
+func (pool *MessagePool) isCriticalMessage(ctx context.Context, msg *types.SignedMessage) bool {
+	for _, cm := range criticalMethods {
+		if msg.Method != cm.method || (!cm.to.Empty() && msg.To != cm.to) {
+			continue
+		}
+		if len(cm.codes) == 0 {
+			return true
+		}
+		target, err := pool.api.ActorFromLatestState(ctx, msg.To)
+		if err != nil {
+			continue
+		}
+		for _, code := range cm.codes {
+			if target.Code.Equals(code) {
+				return true
+			}
+		}
+	}
+	return false
+}
 
 type timedmessage struct {
-	message *types.SignedMessage
-	addedAt uint64
+	message  *types.SignedMessage
+	addedAt  uint64
+	critical bool
 }
 
 // MessagePoolAPI defines an interface to api resources the message pool needs.
 type MessagePoolAPI interface {
 	BlockHeight() (uint64, error)
+	ActorFromLatestState(ctx context.Context, addr address.Address) (*actor.Actor, error)
 }
 
 // MessagePoolValidator defines a validator that ensures a message can go through the pool.
@@ -34,30 +81,30 @@ type MessagePoolValidator interface {
 	Validate(ctx context.Context, msg *types.SignedMessage) error
 }
 
-type addressNonce struct {
-	addr  address.Address
-	nonce uint64
-}
-
-func newAddressNonce(msg *types.SignedMessage) addressNonce {
-	return addressNonce{addr: msg.From, nonce: uint64(msg.Nonce)}
-}
-
-// MessagePool keeps an unordered, de-duplicated set of Messages and supports removal by CID.
+// MessagePool keeps a de-duplicated set of Messages and supports removal by CID.
 // By 'de-duplicated' we mean that insertion of a message by cid that already
 // exists is a nop. We use a MessagePool to store all messages received by this node
 // via network or directly created via user command that have yet to be included
-// in a block. Messages are removed as they are processed.
+// in a block. Messages are removed as they are processed, or once they have
+// been pending for the configured number of tipsets. Once full, the pool
+// only accepts critical messages, evicting ordinary ones to fit them.
+//
+// The pool keeps a queue of pending messages per sender, ordered by nonce.
+// Messages may arrive out of nonce order, those after a gap in their
+// sender's queue are held until the gap is filled, as they cannot be mined
+// before. A message with the sender and nonce of a pending message replaces
+// it if it pays a high enough gas price, see ReplacementGasPrice.
 //
 // MessagePool is safe for concurrent access.
 type MessagePool struct {
 	lk sync.RWMutex
 
-	api           MessagePoolAPI
-	cfg           *config.MessagePoolConfig
-	validator     MessagePoolValidator
-	pending       map[cid.Cid]*timedmessage // all pending messages
-	addressNonces map[addressNonce]bool     // set of address nonce pairs used to efficiently validate duplicate nonces
+	api       MessagePoolAPI
+	cfg       *config.MessagePoolConfig
+	validator MessagePoolValidator
+	pending   map[cid.Cid]*timedmessage              // all pending messages
+	senders   map[address.Address]map[uint64]cid.Cid // cids of the pending messages by sender and nonce
+	ds        datastore.Datastore                    // persists the pending messages, if set
 }
 
 // Add adds a message to the pool.
@@ -73,6 +120,8 @@ func (pool *MessagePool) Add(ctx context.Context, msg *types.SignedMessage) (cid
 // An error coming out of addTimedMessage probably means the message failed to validate,
 // but it could indicate a more serious problem with the system.
 func (pool *MessagePool) addTimedMessage(ctx context.Context, msg *timedmessage) (cid.Cid, error) {
+	msg.critical = pool.isCriticalMessage(ctx, msg.message)
+
 	pool.lk.Lock()
 	defer pool.lk.Unlock()
 
@@ -87,12 +136,29 @@ func (pool *MessagePool) addTimedMessage(ctx context.Context, msg *timedmessage)
 		return c, nil
 	}
 
-	if err = pool.validateMessage(ctx, msg.message); err != nil {
+	replaced, err := pool.validateMessage(ctx, msg)
+	if err != nil {
 		return cid.Undef, errors.Wrap(err, "validation error adding message to pool")
 	}
 
+	if replaced.Defined() {
+		log.Infof("replacing message %s with %s", replaced, c)
+		pool.remove(replaced)
+	} else if len(pool.pending) >= pool.cfg.MaxPoolSize {
+		// validation ensured msg is critical and there is a message to evict
+		evicted, _ := pool.evictionCandidate()
+		log.Infof("evicting message %s from full pool for critical message %s", evicted, c)
+		pool.remove(evicted)
+	}
+
 	pool.pending[c] = msg
-	pool.addressNonces[newAddressNonce(msg.message)] = true
+	nonces, ok := pool.senders[msg.message.From]
+	if !ok {
+		nonces = make(map[uint64]cid.Cid)
+		pool.senders[msg.message.From] = nonces
+	}
+	nonces[uint64(msg.message.Nonce)] = c
+	pool.persist(c, msg.message)
 	mpSize.Set(ctx, int64(len(pool.pending)))
 	return c, nil
 }
@@ -109,6 +175,46 @@ func (pool *MessagePool) Pending() []*types.SignedMessage {
 	return out
 }
 
+// PendingFrom returns the queue of pending messages from addr, ordered by
+// nonce.
+func (pool *MessagePool) PendingFrom(addr address.Address) []*types.SignedMessage {
+	pool.lk.RLock()
+	defer pool.lk.RUnlock()
+
+	nonces := pool.senders[addr]
+	out := make([]*types.SignedMessage, 0, len(nonces))
+	for _, c := range nonces {
+		out = append(out, pool.pending[c].message)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Nonce < out[j].Nonce })
+
+	return out
+}
+
+// ReplacementGasPrice returns the least gas price a message must pay to
+// replace the pending message with its sender and nonce, and false if there
+// is no such message.
+func (pool *MessagePool) ReplacementGasPrice(from address.Address, nonce uint64) (*types.AttoFIL, bool) {
+	pool.lk.RLock()
+	defer pool.lk.RUnlock()
+
+	c, ok := pool.senders[from][nonce]
+	if !ok {
+		return nil, false
+	}
+	return pool.replacementGasPrice(pool.pending[c].message), true
+}
+
+// replacementGasPrice returns the least gas price replacing msg costs: its
+// gas price raised by the configured bump, and by at least one attoFIL.
+func (pool *MessagePool) replacementGasPrice(msg *types.SignedMessage) *types.AttoFIL {
+	bump := msg.GasPrice.DivBigInt(big.NewInt(100)).MulBigInt(new(big.Int).SetUint64(pool.cfg.ReplaceByFeeBump))
+	if !bump.IsPositive() {
+		bump = types.NewAttoFIL(big.NewInt(1))
+	}
+	return msg.GasPrice.Add(bump)
+}
+
 // Get retrieves a message from the pool by CID.
 func (pool *MessagePool) Get(c cid.Cid) (*types.SignedMessage, bool) {
 	pool.lk.RLock()
@@ -127,22 +233,132 @@ func (pool *MessagePool) Remove(c cid.Cid) {
 	pool.lk.Lock()
 	defer pool.lk.Unlock()
 
+	pool.remove(c)
+	mpSize.Set(context.TODO(), int64(len(pool.pending)))
+}
+
+// remove removes the message by CID from the pending pool. The caller must
+// hold the lock.
+func (pool *MessagePool) remove(c cid.Cid) {
 	msg, ok := pool.pending[c]
 	if ok {
-		delete(pool.addressNonces, newAddressNonce(msg.message))
+		nonces := pool.senders[msg.message.From]
+		delete(nonces, uint64(msg.message.Nonce))
+		if len(nonces) == 0 {
+			delete(pool.senders, msg.message.From)
+		}
 		delete(pool.pending, c)
+		pool.unpersist(c)
 	}
-	mpSize.Set(context.TODO(), int64(len(pool.pending)))
+}
+
+// Load adds the messages persisted to ds by a previous run to the pool and
+// persists the pending messages to ds from then on. The messages are
+// validated again against the current state, those no longer valid are
+// dropped.
+func (pool *MessagePool) Load(ctx context.Context, ds datastore.Datastore) error {
+	results, err := ds.Query(query.Query{Prefix: "/" + messagePoolPrefix})
+	if err != nil {
+		return errors.Wrap(err, "failed to query persisted messages")
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		return errors.Wrap(err, "failed to read persisted messages")
+	}
+
+	pool.lk.Lock()
+	pool.ds = ds
+	pool.lk.Unlock()
+
+	for _, entry := range entries {
+		msg := &types.SignedMessage{}
+		if err := msg.Unmarshal(entry.Value); err != nil {
+			log.Warningf("dropping undecodable persisted message %s: %s", entry.Key, err)
+			pool.deleteEntry(datastore.NewKey(entry.Key))
+			continue
+		}
+		if _, err := pool.Add(ctx, msg); err != nil {
+			log.Infof("dropping persisted message %s: %s", entry.Key, err)
+			pool.deleteEntry(datastore.NewKey(entry.Key))
+		}
+	}
+	return nil
+}
+
+// persist writes the message with cid c to the pool's datastore, if any.
+// Failures are logged only, the message stays in the pool. The caller must
+// hold the lock.
+func (pool *MessagePool) persist(c cid.Cid, msg *types.SignedMessage) {
+	if pool.ds == nil {
+		return
+	}
+	data, err := msg.Marshal()
+	if err == nil {
+		err = pool.ds.Put(messagePoolKey(c), data)
+	}
+	if err != nil {
+		log.Errorf("failed to persist message %s: %s", c, err)
+	}
+}
+
+// unpersist deletes the message with cid c from the pool's datastore, if
+// any. The caller must hold the lock.
+func (pool *MessagePool) unpersist(c cid.Cid) {
+	if pool.ds == nil {
+		return
+	}
+	pool.deleteEntry(messagePoolKey(c))
+}
+
+func (pool *MessagePool) deleteEntry(key datastore.Key) {
+	if err := pool.ds.Delete(key); err != nil && err != datastore.ErrNotFound {
+		log.Errorf("failed to delete persisted message %s: %s", key, err)
+	}
+}
+
+func messagePoolKey(c cid.Cid) datastore.Key {
+	return datastore.KeyWithNamespaces([]string{messagePoolPrefix, c.String()})
+}
+
+// evictionCandidate picks the message to drop to make room for a critical
+// message: the ordinary message with the lowest gas price, preferring the
+// most recently added. Only the highest nonce message of each sender is
+// considered so that the messages left behind can still be mined. It returns
+// false if there is no such message. The caller must hold the lock.
+func (pool *MessagePool) evictionCandidate() (cid.Cid, bool) {
+	var candidate *timedmessage
+	var candidateCid cid.Cid
+	for _, nonces := range pool.senders {
+		var tail uint64
+		for nonce := range nonces {
+			if nonce > tail {
+				tail = nonce
+			}
+		}
+		c := nonces[tail]
+		msg := pool.pending[c]
+		if msg.critical {
+			continue
+		}
+		if candidate == nil ||
+			msg.message.GasPrice.LessThan(&candidate.message.GasPrice) ||
+			(msg.message.GasPrice.Equal(&candidate.message.GasPrice) && msg.addedAt > candidate.addedAt) {
+			candidate = msg
+			candidateCid = c
+		}
+	}
+
+	return candidateCid, candidate != nil
 }
 
 // NewMessagePool constructs a new MessagePool.
 func NewMessagePool(api MessagePoolAPI, cfg *config.MessagePoolConfig, validator MessagePoolValidator) *MessagePool {
 	return &MessagePool{
-		api:           api,
-		cfg:           cfg,
-		validator:     validator,
-		pending:       make(map[cid.Cid]*timedmessage),
-		addressNonces: make(map[addressNonce]bool),
+		api:       api,
+		cfg:       cfg,
+		validator: validator,
+		pending:   make(map[cid.Cid]*timedmessage),
+		senders:   make(map[address.Address]map[uint64]cid.Cid),
 	}
 }
 
@@ -188,7 +404,7 @@ func (pool *MessagePool) UpdateMessagePool(ctx context.Context, store chain.Bloc
 	return pool.timeoutMessages(ctx, store, newHead)
 }
 
-// timeoutMessages removes all messages from the pool that arrived more than MessageTimeout tip sets ago.
+// timeoutMessages removes all messages from the pool that arrived more than the configured number of tip sets ago.
 // Note that we measure the timeout in the number of tip sets we have received rather than a fixed block
 // height. This prevents us from prematurely timing messages that arrive during long chains of null blocks.
 // Also when blocks fill, the rate of message processing will correspond more closely to rate of tip
@@ -203,7 +419,7 @@ func (pool *MessagePool) timeoutMessages(ctx context.Context, store chain.BlockP
 	}
 
 	// walk back MessageTimeout tip sets to arrive at the lowest viable block height
-	for i := 0; minimumHeight > 0 && i < MessageTimeOut; i++ {
+	for i := uint64(0); minimumHeight > 0 && i < pool.cfg.MessageTimeout; i++ {
 		lowestTipSet, err = chain.GetParentTipSet(ctx, store, lowestTipSet)
 		if err != nil {
 			return err
@@ -239,30 +455,42 @@ func (pool *MessagePool) messagesToTimeOut(minimumHeight uint64) []cid.Cid {
 // LargestNonce returns the largest nonce used by a message from address in the pool.
 // If no messages from address are found, found will be false.
 func (pool *MessagePool) LargestNonce(address address.Address) (largest uint64, found bool) {
-	for _, m := range pool.Pending() {
-		if m.From == address {
-			found = true
-			if uint64(m.Nonce) > largest {
-				largest = uint64(m.Nonce)
-			}
+	pool.lk.RLock()
+	defer pool.lk.RUnlock()
+
+	for nonce := range pool.senders[address] {
+		found = true
+		if nonce > largest {
+			largest = nonce
 		}
 	}
 	return
 }
 
 // validateMessage validates that too many messages aren't added to the pool and the ones that are
-// have a high probability of making it through processing.
-func (pool *MessagePool) validateMessage(ctx context.Context, message *types.SignedMessage) error {
-	if len(pool.pending) >= pool.cfg.MaxPoolSize {
-		return errors.Errorf("message pool is full (%d messages)", pool.cfg.MaxPoolSize)
-	}
-
-	// check that message with this nonce does not already exist
-	_, found := pool.addressNonces[newAddressNonce(message)]
+// have a high probability of making it through processing. It returns the cid of the pending
+// message the message replaces, if any.
+func (pool *MessagePool) validateMessage(ctx context.Context, msg *timedmessage) (cid.Cid, error) {
+	message := msg.message
+	// a message with the nonce of a pending message replaces it if it pays enough more for gas
+	replaced, found := pool.senders[message.From][uint64(message.Nonce)]
 	if found {
-		return errors.Errorf("message pool contains message with same actor and nonce but different cid")
+		minPrice := pool.replacementGasPrice(pool.pending[replaced].message)
+		if message.GasPrice.LessThan(minPrice) {
+			return cid.Undef, errors.Errorf("message pool contains message with same actor and nonce but different cid, replacing it requires a gas price of at least %s", minPrice)
+		}
+	} else if len(pool.pending) >= pool.cfg.MaxPoolSize {
+		if !msg.critical {
+			return cid.Undef, errors.Errorf("message pool is full (%d messages)", pool.cfg.MaxPoolSize)
+		}
+		if _, ok := pool.evictionCandidate(); !ok {
+			return cid.Undef, errors.Errorf("message pool is full of critical messages (%d messages)", pool.cfg.MaxPoolSize)
+		}
 	}
 
 	// check that the message is likely to succeed in processing
-	return pool.validator.Validate(ctx, message)
+	if err := pool.validator.Validate(ctx, message); err != nil {
+		return cid.Undef, err
+	}
+	return replaced, nil
 }
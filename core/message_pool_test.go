@@ -7,11 +7,13 @@ import (
 	"testing"
 
 	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-hamt-ipld"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/config"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
@@ -88,6 +90,88 @@ func TestMessagePoolValidate(t *testing.T) {
 		assert.Len(t, pool.Pending(), maxMessagePoolSize)
 	})
 
+	t.Run("message pool evicts an ordinary message for a critical message", func(t *testing.T) {
+		ctx := context.Background()
+		mpoolCfg := config.NewDefaultConfig().Mpool
+		mpoolCfg.MaxPoolSize = 3
+		api := th.NewTestMessagePoolAPI(0)
+		api.Actors[mustMethodTarget(t, "submitPoSt")] = &actor.Actor{Code: types.MinerActorCodeCid}
+		pool := NewMessagePool(api, mpoolCfg, th.NewMockMessagePoolValidator())
+
+		// the cheapest message is not evicted because its sender has a later message
+		cheapest := mustSignGasPrice(t, mockSigner.Addresses[0], 0, "transfer", 0)
+		expensive := mustSignGasPrice(t, mockSigner.Addresses[0], 1, "transfer", 2)
+		cheap := mustSignGasPrice(t, mockSigner.Addresses[1], 0, "transfer", 1)
+		MustAdd(pool, cheapest, expensive, cheap)
+
+		// ordinary messages are still rejected
+		_, err := pool.Add(ctx, mustSignGasPrice(t, mockSigner.Addresses[2], 0, "transfer", 5))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "message pool is full")
+
+		post := mustSignGasPrice(t, mockSigner.Addresses[2], 0, "submitPoSt", 0)
+		_, err = pool.Add(ctx, post)
+		require.NoError(t, err)
+
+		assert.Len(t, pool.Pending(), 3)
+		_, found := pool.Get(mustCid(t, cheap))
+		assert.False(t, found)
+		_, found = pool.Get(mustCid(t, cheapest))
+		assert.True(t, found)
+		_, found = pool.Get(mustCid(t, post))
+		assert.True(t, found)
+	})
+
+	t.Run("message pool rejects critical messages when only critical messages remain", func(t *testing.T) {
+		ctx := context.Background()
+		mpoolCfg := config.NewDefaultConfig().Mpool
+		mpoolCfg.MaxPoolSize = 2
+		api := th.NewTestMessagePoolAPI(0)
+		api.Actors[mustMethodTarget(t, "submitPoSt")] = &actor.Actor{Code: types.MinerActorCodeCid}
+		pool := NewMessagePool(api, mpoolCfg, th.NewMockMessagePoolValidator())
+
+		ordinary := mustSignGasPrice(t, mockSigner.Addresses[0], 0, "transfer", 0)
+		reclaim := mustSignGasPrice(t, mockSigner.Addresses[0], 1, "reclaim", 0)
+		reclaim = mustResignMessage(mockSigner, reclaim, func(m *types.Message) {
+			m.To = address.PaymentBrokerAddress
+		})
+		MustAdd(pool, ordinary, reclaim)
+
+		// ordinary is not its sender's last message, so evicting it would
+		// leave reclaim unminable
+		_, err := pool.Add(ctx, mustSignGasPrice(t, mockSigner.Addresses[1], 0, "submitPoSt", 0))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "full of critical messages")
+	})
+
+	t.Run("message pool only treats miner methods sent to miners as critical", func(t *testing.T) {
+		ctx := context.Background()
+		mpoolCfg := config.NewDefaultConfig().Mpool
+		mpoolCfg.MaxPoolSize = 1
+		api := th.NewTestMessagePoolAPI(0)
+		api.Actors[mustMethodTarget(t, "submitPoSt")] = &actor.Actor{Code: types.AccountActorCodeCid}
+		api.Actors[mustMethodTarget(t, "slashStorageFault")] = &actor.Actor{Code: types.BootstrapMinerActorCodeCid}
+		pool := NewMessagePool(api, mpoolCfg, th.NewMockMessagePoolValidator())
+		MustAdd(pool, mustSignGasPrice(t, mockSigner.Addresses[0], 0, "transfer", 0))
+
+		// submitPoSt to an account actor, or to no actor, is ordinary
+		_, err := pool.Add(ctx, mustSignGasPrice(t, mockSigner.Addresses[1], 0, "submitPoSt", 0))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "message pool is full")
+		notMiner := mustResignMessage(mockSigner, mustSignGasPrice(t, mockSigner.Addresses[1], 0, "submitPoSt", 0), func(m *types.Message) {
+			m.To = mustMethodTarget(t, "nobody")
+		})
+		_, err = pool.Add(ctx, notMiner)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "message pool is full")
+
+		slash := mustSignGasPrice(t, mockSigner.Addresses[1], 0, "slashStorageFault", 0)
+		_, err = pool.Add(ctx, slash)
+		require.NoError(t, err)
+		_, found := pool.Get(mustCid(t, slash))
+		assert.True(t, found)
+	})
+
 	t.Run("validates no two messages are added with same nonce", func(t *testing.T) {
 		ctx := context.Background()
 		pool := NewMessagePool(th.NewTestMessagePoolAPI(0), config.NewDefaultConfig().Mpool, th.NewMockMessagePoolValidator())
@@ -116,6 +200,81 @@ func TestMessagePoolValidate(t *testing.T) {
 	})
 }
 
+func TestMessagePoolReplace(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	from := mockSigner.Addresses[0]
+
+	t.Run("replaces a message paying enough more for gas", func(t *testing.T) {
+		pool := NewMessagePool(th.NewTestMessagePoolAPI(0), config.NewDefaultConfig().Mpool, th.NewMockMessagePoolValidator())
+
+		original := mustSignGasPrice(t, from, 0, "transfer", 100)
+		MustAdd(pool, original)
+
+		minPrice, ok := pool.ReplacementGasPrice(from, 0)
+		require.True(t, ok)
+		assert.Equal(t, types.NewGasPrice(110), *minPrice)
+
+		_, err := pool.Add(ctx, mustSignGasPrice(t, from, 0, "transfer", 109))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "message with same actor and nonce")
+		assertPoolEquals(t, pool, original)
+
+		replacement := mustSignGasPrice(t, from, 0, "transfer", 110)
+		MustAdd(pool, replacement)
+		assertPoolEquals(t, pool, replacement)
+
+		largest, found := pool.LargestNonce(from)
+		assert.True(t, found)
+		assert.Equal(t, uint64(0), largest)
+	})
+
+	t.Run("replacing a message costs at least one attoFIL more", func(t *testing.T) {
+		pool := NewMessagePool(th.NewTestMessagePoolAPI(0), config.NewDefaultConfig().Mpool, th.NewMockMessagePoolValidator())
+
+		MustAdd(pool, mustSignGasPrice(t, from, 0, "transfer", 0))
+
+		minPrice, ok := pool.ReplacementGasPrice(from, 0)
+		require.True(t, ok)
+		assert.Equal(t, types.NewGasPrice(1), *minPrice)
+
+		_, ok = pool.ReplacementGasPrice(from, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("replaces messages when full", func(t *testing.T) {
+		mpoolCfg := config.NewDefaultConfig().Mpool
+		mpoolCfg.MaxPoolSize = 1
+		pool := NewMessagePool(th.NewTestMessagePoolAPI(0), mpoolCfg, th.NewMockMessagePoolValidator())
+
+		MustAdd(pool, mustSignGasPrice(t, from, 0, "transfer", 10))
+		replacement := mustSignGasPrice(t, from, 0, "transfer", 20)
+		MustAdd(pool, replacement)
+		assertPoolEquals(t, pool, replacement)
+	})
+}
+
+func TestMessagePoolPendingFrom(t *testing.T) {
+	tf.UnitTest(t)
+
+	pool := NewMessagePool(th.NewTestMessagePoolAPI(0), config.NewDefaultConfig().Mpool, th.NewMockMessagePoolValidator())
+
+	from := mockSigner.Addresses[0]
+	m3 := mustSignGasPrice(t, from, 3, "transfer", 0)
+	m0 := mustSignGasPrice(t, from, 0, "transfer", 0)
+	m1 := mustSignGasPrice(t, from, 1, "transfer", 0)
+	other := mustSignGasPrice(t, mockSigner.Addresses[1], 2, "transfer", 0)
+	MustAdd(pool, m3, other, m0, m1)
+
+	assert.Equal(t, []*types.SignedMessage{m0, m1, m3}, pool.PendingFrom(from))
+	assert.Equal(t, []*types.SignedMessage{other}, pool.PendingFrom(mockSigner.Addresses[1]))
+	assert.Empty(t, pool.PendingFrom(mockSigner.Addresses[2]))
+
+	pool.Remove(mustCid(t, m1))
+	assert.Equal(t, []*types.SignedMessage{m0, m3}, pool.PendingFrom(from))
+}
+
 func TestMessagePoolDedup(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -472,14 +631,16 @@ func TestUpdateMessagePool(t *testing.T) {
 		var err error
 		store := hamt.NewCborStore()
 		api := th.NewTestMessagePoolAPI(0)
-		p := NewMessagePool(api, config.NewDefaultConfig().Mpool, th.NewMockMessagePoolValidator())
+		mpoolCfg := config.NewDefaultConfig().Mpool
+		p := NewMessagePool(api, mpoolCfg, th.NewMockMessagePoolValidator())
 
-		m := types.NewSignedMsgs(MessageTimeOut, mockSigner)
+		timeout := int(mpoolCfg.MessageTimeout)
+		m := types.NewSignedMsgs(timeout, mockSigner)
 
 		head := headOf(NewChainWithMessages(store, types.TipSet{}, msgsSet{msgs{}}))
 
-		// Add a message at each block height until MessageTimeOut is reached
-		for i := 0; i < MessageTimeOut; i++ {
+		// Add a message at each block height until the timeout is reached
+		for i := 0; i < timeout; i++ {
 			// api.Height determines block time at which message is added
 			api.Height, err = head.Height()
 			require.NoError(t, err)
@@ -509,18 +670,47 @@ func TestUpdateMessagePool(t *testing.T) {
 		assertPoolEquals(t, p, m[5:]...)
 	})
 
+	t.Run("Times out messages after the configured number of tipsets", func(t *testing.T) {
+		var err error
+		store := hamt.NewCborStore()
+		api := th.NewTestMessagePoolAPI(0)
+		mpoolCfg := config.NewDefaultConfig().Mpool
+		mpoolCfg.MessageTimeout = 2
+		p := NewMessagePool(api, mpoolCfg, th.NewMockMessagePoolValidator())
+
+		m := types.NewSignedMsgs(1, mockSigner)
+
+		head := headOf(NewChainWithMessages(store, types.TipSet{}, msgsSet{msgs{}}))
+		api.Height, err = head.Height()
+		require.NoError(t, err)
+		MustAdd(p, m[0])
+
+		for i := 0; i < 2; i++ {
+			next := headOf(NewChainWithMessages(store, head, msgsSet{msgs{}}))
+			assert.NoError(t, p.UpdateMessagePool(ctx, &storeBlockProvider{store}, head, next))
+			assertPoolEquals(t, p, m[0])
+			head = next
+		}
+
+		next := headOf(NewChainWithMessages(store, head, msgsSet{msgs{}}))
+		assert.NoError(t, p.UpdateMessagePool(ctx, &storeBlockProvider{store}, head, next))
+		assertPoolEquals(t, p)
+	})
+
 	t.Run("Message timeout is unaffected by null tipsets", func(t *testing.T) {
 		var err error
 		store := hamt.NewCborStore()
 		blockTimer := th.NewTestMessagePoolAPI(0)
-		p := NewMessagePool(blockTimer, config.NewDefaultConfig().Mpool, th.NewMockMessagePoolValidator())
+		mpoolCfg := config.NewDefaultConfig().Mpool
+		p := NewMessagePool(blockTimer, mpoolCfg, th.NewMockMessagePoolValidator())
 
-		m := types.NewSignedMsgs(MessageTimeOut, mockSigner)
+		timeout := int(mpoolCfg.MessageTimeout)
+		m := types.NewSignedMsgs(timeout, mockSigner)
 
 		head := headOf(NewChainWithMessages(store, types.TipSet{}, msgsSet{msgs{}}))
 
-		// Add a message at each block height until MessageTimeOut is reached
-		for i := 0; i < MessageTimeOut; i++ {
+		// Add a message at each block height until the timeout is reached
+		for i := 0; i < timeout; i++ {
 			// blockTimer.Height determines block time at which message is added
 			blockTimer.Height, err = head.Height()
 			require.NoError(t, err)
@@ -604,6 +794,43 @@ func TestLargestNonce(t *testing.T) {
 	})
 }
 
+func TestMessagePoolPersistence(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	t.Run("reloads the pending messages", func(t *testing.T) {
+		ds := datastore.NewMapDatastore()
+		p := NewMessagePool(th.NewTestMessagePoolAPI(0), config.NewDefaultConfig().Mpool, th.NewMockMessagePoolValidator())
+		require.NoError(t, p.Load(ctx, ds))
+
+		m := types.NewSignedMsgs(3, mockSigner)
+		MustAdd(p, m...)
+		p.Remove(mustCid(t, m[1]))
+
+		restarted := NewMessagePool(th.NewTestMessagePoolAPI(0), config.NewDefaultConfig().Mpool, th.NewMockMessagePoolValidator())
+		require.NoError(t, restarted.Load(ctx, ds))
+		assertPoolEquals(t, restarted, m[0], m[2])
+	})
+
+	t.Run("drops messages no longer valid", func(t *testing.T) {
+		ds := datastore.NewMapDatastore()
+		p := NewMessagePool(th.NewTestMessagePoolAPI(0), config.NewDefaultConfig().Mpool, th.NewMockMessagePoolValidator())
+		require.NoError(t, p.Load(ctx, ds))
+		MustAdd(p, types.NewSignedMsgs(2, mockSigner)...)
+
+		invalid := th.NewMockMessagePoolValidator()
+		invalid.Valid = false
+		restarted := NewMessagePool(th.NewTestMessagePoolAPI(0), config.NewDefaultConfig().Mpool, invalid)
+		require.NoError(t, restarted.Load(ctx, ds))
+		assert.Len(t, restarted.Pending(), 0)
+
+		again := NewMessagePool(th.NewTestMessagePoolAPI(0), config.NewDefaultConfig().Mpool, th.NewMockMessagePoolValidator())
+		require.NoError(t, again.Load(ctx, ds))
+		assert.Len(t, again.Pending(), 0)
+	})
+}
+
 type storeBlockProvider struct {
 	store *hamt.CborIpldStore
 }
@@ -636,3 +863,24 @@ func mustResignMessage(signer types.Signer, message *types.SignedMessage, f func
 func signMessage(signer types.Signer, message types.Message) (*types.SignedMessage, error) {
 	return types.NewSignedMessage(message, signer, types.NewGasPrice(0), types.NewGasUnits(0))
 }
+
+func mustSignGasPrice(t *testing.T, from address.Address, nonce uint64, method string, gasPrice int64) *types.SignedMessage {
+	msg := types.NewMessage(from, mustMethodTarget(t, method), nonce, types.NewZeroAttoFIL(), method, nil)
+	smsg, err := types.NewSignedMessage(*msg, mockSigner, types.NewGasPrice(gasPrice), types.NewGasUnits(0))
+	require.NoError(t, err)
+	return smsg
+}
+
+// mustMethodTarget returns the address mustSignGasPrice sends messages calling
+// method to.
+func mustMethodTarget(t *testing.T, method string) address.Address {
+	to, err := address.NewActorAddress([]byte(method))
+	require.NoError(t, err)
+	return to
+}
+
+func mustCid(t *testing.T, smsg *types.SignedMessage) cid.Cid {
+	c, err := smsg.Cid()
+	require.NoError(t, err)
+	return c
+}
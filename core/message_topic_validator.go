@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-peer"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+
+	"github.com/filecoin-project/go-filecoin/config"
+	"github.com/filecoin-project/go-filecoin/types"
+	vmerrors "github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// Peers gain a point of score for each valid message they send, up to
+// maxPeerScore, and lose points for invalid or rate limited messages. Messages
+// from peers whose score falls below minPeerScore are rejected unchecked.
+const (
+	maxPeerScore          = 100
+	minPeerScore          = -100
+	invalidMessagePenalty = 10
+	rateLimitPenalty      = 1
+)
+
+var (
+	// ErrMessageTooLarge is returned for messages larger than the configured maximum size.
+	ErrMessageTooLarge = errors.New("message too large")
+	// ErrPeerRateLimited is returned for messages from a peer sending faster than its rate limit.
+	ErrPeerRateLimited = errors.New("peer exceeded its message rate limit")
+	// ErrPeerScoreTooLow is returned for messages from a peer that sent too many invalid messages.
+	ErrPeerScoreTooLow = errors.New("peer score too low")
+)
+
+type peerRecord struct {
+	limiter *rate.Limiter
+	score   int
+}
+
+// MessageTopicValidator validates messages received from peers over the
+// message pubsub topic, so that invalid messages are neither added to the pool
+// nor propagated. Besides validating the message itself, it limits the rate
+// at which each peer may send messages and scores peers by the validity of
+// the messages they sent.
+//
+// MessageTopicValidator is safe for concurrent access.
+type MessageTopicValidator struct {
+	validator MessagePoolValidator
+	cfg       *config.MessagePoolConfig
+
+	lk    sync.Mutex
+	peers map[peer.ID]*peerRecord
+}
+
+// NewMessageTopicValidator creates a topic validator checking messages
+// against the given message pool validator.
+func NewMessageTopicValidator(validator MessagePoolValidator, cfg *config.MessagePoolConfig) *MessageTopicValidator {
+	return &MessageTopicValidator{
+		validator: validator,
+		cfg:       cfg,
+		peers:     make(map[peer.ID]*peerRecord),
+	}
+}
+
+// Validate checks the encoded message data received from peer from. It
+// returns an error if the message must be rejected.
+func (mv *MessageTopicValidator) Validate(ctx context.Context, from peer.ID, data []byte) error {
+	if err := mv.admit(from); err != nil {
+		return err
+	}
+
+	if len(data) > mv.cfg.MaxMessageSize {
+		mv.penalize(from, invalidMessagePenalty)
+		return ErrMessageTooLarge
+	}
+
+	msg := &types.SignedMessage{}
+	if err := msg.Unmarshal(data); err != nil {
+		mv.penalize(from, invalidMessagePenalty)
+		return errors.Wrap(err, "failed to decode message")
+	}
+
+	if err := mv.validator.Validate(ctx, msg); err != nil {
+		// Only penalize the peer for the message being invalid, not for
+		// failures to read the state to check it against.
+		if vmerrors.ShouldRevert(err) {
+			mv.penalize(from, invalidMessagePenalty)
+		}
+		return err
+	}
+
+	mv.reward(from)
+	return nil
+}
+
+// Score returns the current score of peer p.
+func (mv *MessageTopicValidator) Score(p peer.ID) int {
+	mv.lk.Lock()
+	defer mv.lk.Unlock()
+
+	if rec, ok := mv.peers[p]; ok {
+		return rec.score
+	}
+	return 0
+}
+
+// admit rejects the messages from peers with a too low score or sending
+// faster than their rate limit.
+func (mv *MessageTopicValidator) admit(p peer.ID) error {
+	mv.lk.Lock()
+	defer mv.lk.Unlock()
+
+	rec := mv.record(p)
+	if rec.score < minPeerScore {
+		return ErrPeerScoreTooLow
+	}
+	if !rec.limiter.Allow() {
+		rec.score -= rateLimitPenalty
+		return ErrPeerRateLimited
+	}
+	return nil
+}
+
+func (mv *MessageTopicValidator) penalize(p peer.ID, penalty int) {
+	mv.lk.Lock()
+	defer mv.lk.Unlock()
+
+	mv.record(p).score -= penalty
+}
+
+func (mv *MessageTopicValidator) reward(p peer.ID) {
+	mv.lk.Lock()
+	defer mv.lk.Unlock()
+
+	rec := mv.record(p)
+	if rec.score < maxPeerScore {
+		rec.score++
+	}
+}
+
+// record returns the record of peer p, creating it if needed. It must be
+// called with the lock held.
+func (mv *MessageTopicValidator) record(p peer.ID) *peerRecord {
+	rec, ok := mv.peers[p]
+	if !ok {
+		rec = &peerRecord{
+			limiter: rate.NewLimiter(rate.Limit(mv.cfg.PeerMessageRate), mv.cfg.PeerMessageBurst),
+		}
+		mv.peers[p] = rec
+	}
+	return rec
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-peer"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/config"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+	vmerrors "github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+type fakeMessagePoolValidator struct {
+	err error
+}
+
+func (v *fakeMessagePoolValidator) Validate(ctx context.Context, msg *types.SignedMessage) error {
+	return v.err
+}
+
+func TestMessageTopicValidator(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	data, err := newSignedMessage().Marshal()
+	require.NoError(t, err)
+
+	newValidator := func(err error) *MessageTopicValidator {
+		cfg := config.NewDefaultConfig().Mpool
+		return NewMessageTopicValidator(&fakeMessagePoolValidator{err: err}, cfg)
+	}
+
+	t.Run("accepts valid messages and rewards the peer", func(t *testing.T) {
+		mv := newValidator(nil)
+		assert.NoError(t, mv.Validate(ctx, peer.ID("a"), data))
+		assert.Equal(t, 1, mv.Score(peer.ID("a")))
+	})
+
+	t.Run("rejects oversized and undecodable messages", func(t *testing.T) {
+		mv := newValidator(nil)
+		mv.cfg.MaxMessageSize = len(data) - 1
+		assert.Equal(t, ErrMessageTooLarge, mv.Validate(ctx, peer.ID("a"), data))
+		assert.Error(t, mv.Validate(ctx, peer.ID("a"), []byte("garbage")))
+		assert.Equal(t, -2*invalidMessagePenalty, mv.Score(peer.ID("a")))
+	})
+
+	t.Run("penalizes invalid messages only", func(t *testing.T) {
+		mv := newValidator(vmerrors.NewRevertError("invalid"))
+		assert.Error(t, mv.Validate(ctx, peer.ID("a"), data))
+		assert.Equal(t, -invalidMessagePenalty, mv.Score(peer.ID("a")))
+
+		mv = newValidator(errors.New("state unavailable"))
+		assert.Error(t, mv.Validate(ctx, peer.ID("a"), data))
+		assert.Equal(t, 0, mv.Score(peer.ID("a")))
+	})
+
+	t.Run("limits the rate of each peer", func(t *testing.T) {
+		mv := newValidator(nil)
+		mv.cfg.PeerMessageRate = 0
+		mv.cfg.PeerMessageBurst = 2
+
+		assert.NoError(t, mv.Validate(ctx, peer.ID("a"), data))
+		assert.NoError(t, mv.Validate(ctx, peer.ID("a"), data))
+		assert.Equal(t, ErrPeerRateLimited, mv.Validate(ctx, peer.ID("a"), data))
+		assert.NoError(t, mv.Validate(ctx, peer.ID("b"), data))
+	})
+
+	t.Run("rejects peers with a too low score", func(t *testing.T) {
+		mv := newValidator(vmerrors.NewRevertError("invalid"))
+		for mv.Score(peer.ID("a")) >= minPeerScore {
+			assert.Error(t, mv.Validate(ctx, peer.ID("a"), data))
+		}
+		mv.validator = &fakeMessagePoolValidator{}
+		assert.Equal(t, ErrPeerScoreTooLow, mv.Validate(ctx, peer.ID("a"), data))
+		assert.NoError(t, mv.Validate(ctx, peer.ID("b"), data))
+	})
+}
@@ -0,0 +1,86 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	secp256k1 "github.com/ipsn/go-secp256k1"
+)
+
+// HardenedKeyStart is the index of the first hardened child key. Hardened
+// keys are derived from their parent's private key rather than public key.
+const HardenedKeyStart uint32 = 1 << 31
+
+// ErrInvalidChildKey is returned when a child index yields an invalid key.
+// BIP32 wallets skip such indexes, which are astronomically unlikely.
+var ErrInvalidChildKey = errors.New("child index yields an invalid key")
+
+var masterKeySalt = []byte("Bitcoin seed")
+
+// ExtendedKey is a secp256k1 private key together with the chain code to
+// derive its children from, as defined by BIP32.
+type ExtendedKey struct {
+	PrivateKey []byte
+	ChainCode  []byte
+}
+
+// NewMasterKey returns the BIP32 master key of the given seed.
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	mac := hmac.New(sha512.New, masterKeySalt)
+	mac.Write(seed) // nolint: errcheck
+	sum := mac.Sum(nil)
+
+	k := new(big.Int).SetBytes(sum[:32])
+	if k.Sign() == 0 || k.Cmp(secp256k1.S256().Params().N) >= 0 {
+		return nil, ErrInvalidChildKey
+	}
+	return &ExtendedKey{PrivateKey: sum[:32], ChainCode: sum[32:]}, nil
+}
+
+// Child returns the child key of k with the given index, hardened if the
+// index is at least HardenedKeyStart.
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	var data []byte
+	if index >= HardenedKeyStart {
+		data = append([]byte{0}, k.PrivateKey...)
+	} else {
+		data = CompressedPublicKey(k.PrivateKey)
+	}
+	var idx [4]byte
+	binary.BigEndian.PutUint32(idx[:], index)
+	data = append(data, idx[:]...)
+
+	mac := hmac.New(sha512.New, k.ChainCode)
+	mac.Write(data) // nolint: errcheck
+	sum := mac.Sum(nil)
+
+	n := secp256k1.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, ErrInvalidChildKey
+	}
+	child := il.Add(il, new(big.Int).SetBytes(k.PrivateKey))
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, ErrInvalidChildKey
+	}
+
+	privkey := make([]byte, PrivateKeyBytes)
+	blob := child.Bytes()
+	copy(privkey[PrivateKeyBytes-len(blob):], blob)
+	return &ExtendedKey{PrivateKey: privkey, ChainCode: sum[32:]}, nil
+}
+
+// CompressedPublicKey returns the public key for this private key in the
+// 33 bytes compressed form.
+func CompressedPublicKey(sk []byte) []byte {
+	x, y := secp256k1.S256().ScalarBaseMult(sk)
+	pk := make([]byte, 33)
+	pk[0] = 2 + byte(y.Bit(0))
+	blob := x.Bytes()
+	copy(pk[33-len(blob):], blob)
+	return pk
+}
@@ -53,6 +53,14 @@ type ExecutableActor interface {
 	InitializeState(storage Storage, initializerData interface{}) error
 }
 
+// MigratableActor is implemented by actor code that replaces an earlier
+// version of an actor in a network upgrade and takes over its state.
+type MigratableActor interface {
+	// MigrateState converts the state in storage, written by the actor
+	// code from, into the state of this code.
+	MigrateState(storage Storage, from cid.Cid) error
+}
+
 // ExportedFunc is the signature an exported method of an actor is expected to have.
 type ExportedFunc func(ctx VMContext) ([]byte, uint8, error)
 
@@ -60,11 +68,41 @@ type ExportedFunc func(ctx VMContext) ([]byte, uint8, error)
 // TODO: convert signatures into non go types, but rather low level agreed up types
 type FunctionSignature struct {
 	// Params is a list of the types of the parameters the function expects.
+	// Trailing parameters may be declared abi.Optional, in which case callers
+	// may leave them out.
 	Params []abi.Type
 	// Return is the type of the return value of the function.
 	Return []abi.Type
 }
 
+// GasTable lists the gas the VM charges for the operations of a message.
+// Tables are versioned with the network protocol, see vm.GasTables.
+type GasTable struct {
+	// MessageBase is charged once for every message applied to the state.
+	MessageBase types.GasUnits
+	// ParamByte is charged per byte of the params of a message.
+	ParamByte types.GasUnits
+	// SignatureVerification is charged for every signature checked, be it
+	// the signature of a message or one an actor checks.
+	SignatureVerification types.GasUnits
+	// MethodCall is charged by actors for every method they run.
+	MethodCall types.GasUnits
+	// Send is charged for every message an actor sends.
+	Send types.GasUnits
+	// StorageGet and StorageGetByte are charged for every chunk read from
+	// actor storage, the latter per byte of the chunk.
+	StorageGet     types.GasUnits
+	StorageGetByte types.GasUnits
+	// StoragePut and StoragePutByte are charged for every chunk put into
+	// actor storage, the latter per byte of the chunk.
+	StoragePut     types.GasUnits
+	StoragePutByte types.GasUnits
+	// Event and EventByte are charged for every event an actor emits, the
+	// latter per byte of its payload.
+	Event     types.GasUnits
+	EventByte types.GasUnits
+}
+
 // VMContext defines the ABI interface exposed to actors.
 type VMContext interface {
 	Message() *types.Message
@@ -74,7 +112,10 @@ type VMContext interface {
 	BlockHeight() *types.BlockHeight
 	IsFromAccountActor() bool
 	Charge(cost types.GasUnits) error
+	GasTable() *GasTable
 	SampleChainRandomness(sampleHeight *types.BlockHeight) ([]byte, error)
+	Rand(epoch *types.BlockHeight) ([]byte, error)
+	EmitEvent(topic string, payload []byte) error
 
 	CreateNewActor(addr address.Address, code cid.Cid, initalizationParams interface{}) error
 
@@ -90,6 +131,20 @@ type Storage interface {
 	Get(cid.Cid) ([]byte, error)
 	Commit(cid.Cid, cid.Cid) error
 	Head() cid.Cid
+	// Begin starts a transaction over the changes made to the storage until
+	// the transaction ends. The VM runs every method in a transaction and
+	// rolls it back when the method returns a revert error.
+	Begin() Transaction
+}
+
+// Transaction groups changes made to a storage so that they can be
+// discarded together. Transactions may nest; rolling back an outer
+// transaction discards the changes of the inner ones too.
+type Transaction interface {
+	// Commit keeps the changes made since the transaction began.
+	Commit()
+	// Rollback discards the changes made since the transaction began.
+	Rollback()
 }
 
 // Lookup defines an internal interface for actor storage.
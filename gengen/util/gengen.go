@@ -11,6 +11,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/faucet"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/crypto"
@@ -46,6 +47,29 @@ type Miner struct {
 	Power uint64
 }
 
+// Account is an existing account to be funded at the start of the network
+type Account struct {
+	// Address is the address of the account
+	Address string
+
+	// Balance is the string value of whole filecoin allocated to the account
+	Balance string
+}
+
+// Faucet is the faucet of a test network
+type Faucet struct {
+	// Balance is the string value of whole filecoin held by the faucet
+	Balance string
+
+	// Amount is the string value of whole filecoin the faucet sends each
+	// address per period
+	Amount string
+
+	// Period is the number of blocks after which an address may be funded
+	// again
+	Period uint64
+}
+
 // GenesisCfg is
 type GenesisCfg struct {
 	// Keys is an array of names of keys. A random key will be generated
@@ -56,11 +80,28 @@ type GenesisCfg struct {
 	// that will be preallocated to each account
 	PreAlloc []string
 
+	// Accounts is a list of existing accounts, such as those of the wallets
+	// of the network's users, that will be allocated filecoin
+	Accounts []Account
+
 	// Miners is a list of miners that should be set up at the start of the network
 	Miners []Miner
 
 	// ProofsMode affects sealing, sector packing, PoSt, etc. in the proofs library
 	ProofsMode types.ProofsMode
+
+	// NetworkBalance is the string value of whole filecoin held by the
+	// network account, out of which block rewards are paid. It defaults to
+	// 10000000000.
+	NetworkBalance string
+
+	// Timestamp is the unix time in seconds set in the genesis block. It
+	// defaults to zero.
+	Timestamp uint64
+
+	// Faucet, if set, sets up a faucet dispensing filecoin to the users of
+	// the network
+	Faucet *Faucet
 }
 
 // RenderedGenInfo contains information about a genesis block creation
@@ -106,7 +147,15 @@ func GenGen(ctx context.Context, cfg *GenesisCfg, cst *hamt.CborIpldStore, bs bl
 		return nil, err
 	}
 
-	if err := setupPrealloc(st, keys, cfg.PreAlloc); err != nil {
+	if err := setupPrealloc(st, keys, cfg.PreAlloc, cfg.NetworkBalance); err != nil {
+		return nil, err
+	}
+
+	if err := setupAccounts(st, cfg.Accounts); err != nil {
+		return nil, err
+	}
+
+	if err := setupFaucet(ctx, st, storageMap, cfg.Faucet); err != nil {
 		return nil, err
 	}
 
@@ -143,6 +192,7 @@ func GenGen(ctx context.Context, cfg *GenesisCfg, cst *hamt.CborIpldStore, bs bl
 
 	geneblk := &types.Block{
 		StateRoot: stateRoot,
+		Timestamp: types.Uint64(cfg.Timestamp),
 	}
 
 	c, err := cst.Put(ctx, geneblk)
@@ -176,7 +226,7 @@ func genKeys(cfgkeys int, pnrg io.Reader) ([]*types.KeyInfo, error) {
 	return keys, nil
 }
 
-func setupPrealloc(st state.Tree, keys []*types.KeyInfo, prealloc []string) error {
+func setupPrealloc(st state.Tree, keys []*types.KeyInfo, prealloc []string, networkBalance string) error {
 
 	if len(keys) < len(prealloc) {
 		return fmt.Errorf("keys do not match prealloc")
@@ -203,7 +253,16 @@ func setupPrealloc(st state.Tree, keys []*types.KeyInfo, prealloc []string) erro
 		}
 	}
 
-	netact, err := account.NewActor(types.NewAttoFILFromFIL(10000000000))
+	netbal := uint64(10000000000)
+	if networkBalance != "" {
+		v, err := strconv.ParseUint(networkBalance, 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid network balance")
+		}
+		netbal = v
+	}
+
+	netact, err := account.NewActor(types.NewAttoFILFromFIL(netbal))
 	if err != nil {
 		return err
 	}
@@ -211,6 +270,51 @@ func setupPrealloc(st state.Tree, keys []*types.KeyInfo, prealloc []string) erro
 	return st.SetActor(context.Background(), address.NetworkAddress, netact)
 }
 
+func setupAccounts(st state.Tree, accounts []Account) error {
+	for _, a := range accounts {
+		addr, err := address.NewFromString(a.Address)
+		if err != nil {
+			return errors.Wrapf(err, "invalid account address %s", a.Address)
+		}
+
+		valint, err := strconv.ParseUint(a.Balance, 10, 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid balance of account %s", a.Address)
+		}
+
+		if _, err := st.GetActor(context.Background(), addr); err == nil {
+			return errors.Errorf("account %s is allocated twice", a.Address)
+		}
+
+		act, err := account.NewActor(types.NewAttoFILFromFIL(valint))
+		if err != nil {
+			return err
+		}
+		if err := st.SetActor(context.Background(), addr, act); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setupFaucet(ctx context.Context, st state.Tree, sm vm.StorageMap, f *Faucet) error {
+	if f == nil {
+		return nil
+	}
+
+	balance, err := strconv.ParseUint(f.Balance, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "invalid faucet balance")
+	}
+	amount, err := strconv.ParseUint(f.Amount, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "invalid faucet amount")
+	}
+
+	faucetState := faucet.NewState(types.NewAttoFILFromFIL(amount), types.NewBlockHeight(f.Period))
+	return consensus.SetupFaucet(ctx, st, sm, types.NewAttoFILFromFIL(balance), faucetState)
+}
+
 func setupMiners(st state.Tree, sm vm.StorageMap, keys []*types.KeyInfo, miners []Miner, pnrg io.Reader) ([]RenderedMinerInfo, error) {
 	var minfos []RenderedMinerInfo
 	ctx := context.Background()
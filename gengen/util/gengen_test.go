@@ -11,11 +11,16 @@ import (
 	"github.com/ipfs/go-ipfs-blockstore"
 	"github.com/ipfs/go-ipfs-exchange-offline"
 
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/address"
 	. "github.com/filecoin-project/go-filecoin/gengen/util"
+	"github.com/filecoin-project/go-filecoin/state"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var testConfig = &GenesisCfg{
@@ -75,3 +80,74 @@ func TestGenGenDeterministicBetweenBuilds(t *testing.T) {
 		}
 	}
 }
+
+func TestGenGenAccountsAndNetworkParameters(t *testing.T) {
+	tf.UnitTest(t)
+
+	mds := ds.NewMapDatastore()
+	bstore := blockstore.NewBlockstore(mds)
+	cst := &hamt.CborIpldStore{Blocks: bserv.New(bstore, offline.Exchange(bstore))}
+	ctx := context.Background()
+
+	addr := address.NewForTestGetter()()
+	cfg := &GenesisCfg{
+		Keys:           1,
+		PreAlloc:       []string{"10"},
+		Accounts:       []Account{{Address: addr.String(), Balance: "42"}},
+		NetworkBalance: "1000",
+		Timestamp:      1234,
+	}
+
+	info, err := GenGen(ctx, cfg, cst, bstore, 0)
+	require.NoError(t, err)
+
+	var genesis types.Block
+	require.NoError(t, cst.Get(ctx, info.GenesisCid, &genesis))
+	assert.Equal(t, types.Uint64(1234), genesis.Timestamp)
+
+	st, err := state.LoadStateTree(ctx, cst, genesis.StateRoot, builtin.Actors)
+	require.NoError(t, err)
+	act, err := st.GetActor(ctx, addr)
+	require.NoError(t, err)
+	assert.True(t, types.NewAttoFILFromFIL(42).Equal(act.Balance))
+	netact, err := st.GetActor(ctx, address.NetworkAddress)
+	require.NoError(t, err)
+	assert.True(t, types.NewAttoFILFromFIL(1000).Equal(netact.Balance))
+
+	cfg.Accounts = []Account{{Address: "not an address", Balance: "42"}}
+	_, err = GenGen(ctx, cfg, cst, bstore, 0)
+	assert.Error(t, err)
+
+	cfg.Accounts = []Account{{Address: address.NetworkAddress.String(), Balance: "42"}}
+	_, err = GenGen(ctx, cfg, cst, bstore, 0)
+	assert.Error(t, err)
+}
+
+func TestGenGenFaucet(t *testing.T) {
+	tf.UnitTest(t)
+
+	mds := ds.NewMapDatastore()
+	bstore := blockstore.NewBlockstore(mds)
+	cst := &hamt.CborIpldStore{Blocks: bserv.New(bstore, offline.Exchange(bstore))}
+	ctx := context.Background()
+
+	cfg := &GenesisCfg{
+		Faucet: &Faucet{Balance: "1000", Amount: "10", Period: 100},
+	}
+
+	info, err := GenGen(ctx, cfg, cst, bstore, 0)
+	require.NoError(t, err)
+
+	var genesis types.Block
+	require.NoError(t, cst.Get(ctx, info.GenesisCid, &genesis))
+	st, err := state.LoadStateTree(ctx, cst, genesis.StateRoot, builtin.Actors)
+	require.NoError(t, err)
+	act, err := st.GetActor(ctx, address.FaucetAddress)
+	require.NoError(t, err)
+	assert.Equal(t, types.FaucetActorCodeCid, act.Code)
+	assert.True(t, types.NewAttoFILFromFIL(1000).Equal(act.Balance))
+
+	cfg.Faucet = &Faucet{Balance: "1000", Amount: "10", Period: 0}
+	_, err = GenGen(ctx, cfg, cst, bstore, 0)
+	assert.Error(t, err)
+}
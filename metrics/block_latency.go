@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// blockLatencyBoundsMs are the upper bounds, in milliseconds, of the buckets
+// of the block latency histograms.
+var blockLatencyBoundsMs = []float64{250, 500, 1000, 2000, 4000, 8000, 15000, 30000, 60000}
+
+var blockLatencyMs = stats.Float64("block_propagation_latency", "Time from the timestamp of a block to its arrival from the network", stats.UnitMilliseconds)
+
+func init() {
+	if err := view.Register(&view.View{
+		Name:        "block_propagation_latency",
+		Measure:     blockLatencyMs,
+		Description: blockLatencyMs.Description(),
+		Aggregation: view.Distribution(blockLatencyBoundsMs...),
+	}); err != nil {
+		// a panic here indicates a developer error, see NewTimer.
+		panic(err)
+	}
+}
+
+// DefaultBlockLatencyWindow is the number of most recent blocks a
+// BlockLatencyTracker reports on.
+const DefaultBlockLatencyWindow = 1000
+
+// BlockLatencyTracker records how long after their epoch time blocks arrive
+// from the network. The epoch time of a block is its timestamp, the time its
+// miner mined it at. Latencies growing across the network point to systemic
+// propagation problems, such as overloaded relays or slow validation.
+// Latencies are exported to the metrics endpoint and the most recent ones
+// kept in a rolling window for reports.
+type BlockLatencyTracker struct {
+	now func() time.Time
+
+	lk     sync.Mutex
+	window []time.Duration
+	next   int
+	full   bool
+}
+
+// NewBlockLatencyTracker returns a BlockLatencyTracker keeping the latencies
+// of the last window blocks.
+func NewBlockLatencyTracker(window int) *BlockLatencyTracker {
+	return &BlockLatencyTracker{
+		now:    time.Now,
+		window: make([]time.Duration, window),
+	}
+}
+
+// Record records the arrival of blk now. Blocks stamped ahead of the local
+// clock count as arriving without latency.
+func (t *BlockLatencyTracker) Record(ctx context.Context, blk *types.Block) time.Duration {
+	latency := t.now().Sub(time.Unix(int64(blk.Timestamp), 0))
+	if latency < 0 {
+		latency = 0
+	}
+	stats.Record(ctx, blockLatencyMs.M(float64(latency)/float64(time.Millisecond)))
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.window[t.next] = latency
+	t.next = (t.next + 1) % len(t.window)
+	t.full = t.full || t.next == 0
+	return latency
+}
+
+// LatencyBucket counts the blocks that arrived within a latency.
+type LatencyBucket struct {
+	// UpTo is the upper bound of the bucket, zero for the last bucket that
+	// has none.
+	UpTo  time.Duration
+	Count uint64
+}
+
+// BlockLatencyReport summarizes the latencies of the blocks in the window of
+// a BlockLatencyTracker.
+type BlockLatencyReport struct {
+	Count   uint64
+	Mean    time.Duration
+	Median  time.Duration
+	P90     time.Duration
+	P99     time.Duration
+	Max     time.Duration
+	Buckets []LatencyBucket
+}
+
+// Report summarizes the latencies of the blocks in the window.
+func (t *BlockLatencyTracker) Report() *BlockLatencyReport {
+	t.lk.Lock()
+	n := t.next
+	if t.full {
+		n = len(t.window)
+	}
+	latencies := make([]time.Duration, n)
+	copy(latencies, t.window[:n])
+	t.lk.Unlock()
+
+	report := &BlockLatencyReport{
+		Count:   uint64(len(latencies)),
+		Buckets: make([]LatencyBucket, len(blockLatencyBoundsMs)+1),
+	}
+	for i, bound := range blockLatencyBoundsMs {
+		report.Buckets[i].UpTo = time.Duration(bound) * time.Millisecond
+	}
+	if len(latencies) == 0 {
+		return report
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+		i := sort.Search(len(blockLatencyBoundsMs), func(i int) bool {
+			return report.Buckets[i].UpTo >= latency
+		})
+		report.Buckets[i].Count++
+	}
+	quantile := func(q float64) time.Duration {
+		return latencies[int(q*float64(len(latencies)-1))]
+	}
+	report.Mean = total / time.Duration(len(latencies))
+	report.Median = quantile(0.5)
+	report.P90 = quantile(0.9)
+	report.P99 = quantile(0.99)
+	report.Max = latencies[len(latencies)-1]
+	return report
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestBlockLatencyTracker(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	arrive := func(tracker *BlockLatencyTracker, latency time.Duration) time.Duration {
+		tracker.now = func() time.Time { return now.Add(latency) }
+		return tracker.Record(ctx, &types.Block{Timestamp: types.Uint64(now.Unix())})
+	}
+
+	t.Run("reports nothing before blocks arrive", func(t *testing.T) {
+		report := NewBlockLatencyTracker(10).Report()
+		assert.Equal(t, uint64(0), report.Count)
+		assert.Len(t, report.Buckets, len(blockLatencyBoundsMs)+1)
+	})
+
+	t.Run("summarizes latencies", func(t *testing.T) {
+		tracker := NewBlockLatencyTracker(10)
+		for _, latency := range []time.Duration{100 * time.Millisecond, time.Second, 3 * time.Second, 2 * time.Minute} {
+			assert.Equal(t, latency, arrive(tracker, latency))
+		}
+
+		report := tracker.Report()
+		assert.Equal(t, uint64(4), report.Count)
+		assert.Equal(t, time.Second, report.Median)
+		assert.Equal(t, 3*time.Second, report.P90)
+		assert.Equal(t, 2*time.Minute, report.Max)
+		assert.Equal(t, (100*time.Millisecond+time.Second+3*time.Second+2*time.Minute)/4, report.Mean)
+
+		assert.Equal(t, LatencyBucket{UpTo: 250 * time.Millisecond, Count: 1}, report.Buckets[0])
+		assert.Equal(t, LatencyBucket{UpTo: time.Second, Count: 1}, report.Buckets[2])
+		assert.Equal(t, LatencyBucket{UpTo: 4 * time.Second, Count: 1}, report.Buckets[4])
+		assert.Equal(t, LatencyBucket{Count: 1}, report.Buckets[len(report.Buckets)-1])
+	})
+
+	t.Run("keeps the latest blocks", func(t *testing.T) {
+		tracker := NewBlockLatencyTracker(2)
+		arrive(tracker, time.Minute)
+		arrive(tracker, time.Second)
+		arrive(tracker, 2*time.Second)
+
+		report := tracker.Report()
+		assert.Equal(t, uint64(2), report.Count)
+		assert.Equal(t, 2*time.Second, report.Max)
+	})
+
+	t.Run("counts blocks from the future as on time", func(t *testing.T) {
+		tracker := NewBlockLatencyTracker(2)
+		assert.Equal(t, time.Duration(0), arrive(tracker, -time.Second))
+	})
+}
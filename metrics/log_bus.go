@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	logging "github.com/ipfs/go-log"
+	oldlogging "github.com/whyrusleeping/go-logging"
+)
+
+// logSubscriptionBuffer is the number of records buffered for a subscriber
+// of the log bus. Records logged while the buffer is full are dropped for
+// that subscriber rather than blocking the logging.
+const logSubscriptionBuffer = 256
+
+// LogFilter selects log records, those of System, or of all subsystems if
+// empty, at Level or more severe.
+type LogFilter struct {
+	System string
+	Level  oldlogging.Level
+}
+
+func (f LogFilter) matches(level oldlogging.Level, system string) bool {
+	return level <= f.Level && (f.System == "" || f.System == system)
+}
+
+type logSubscription struct {
+	filter LogFilter
+	ch     chan *LogRecord
+}
+
+// logBus is a go-logging backend publishing the records of the messages
+// logged to its subscribers, so that they can be streamed out of the daemon.
+type logBus struct {
+	lk   sync.Mutex
+	subs map[*logSubscription]struct{}
+}
+
+func newLogBus() *logBus {
+	return &logBus{
+		subs: make(map[*logSubscription]struct{}),
+	}
+}
+
+// logs is the log bus of the process.
+var logs = newLogBus()
+
+// InstallLogBus makes the messages logged go to the log bus of the process in
+// addition to stderr, keeping the log levels of the subsystems.
+func InstallLogBus() {
+	logs.install()
+}
+
+// SubscribeLogs returns a channel receiving the records matching filter
+// logged from now on, until ctx is done. Records are only published once
+// InstallLogBus has been called.
+func SubscribeLogs(ctx context.Context, filter LogFilter) <-chan *LogRecord {
+	return logs.subscribe(ctx, filter)
+}
+
+func (b *logBus) install() {
+	levels := map[string]oldlogging.Level{"": oldlogging.GetLevel("")}
+	for _, system := range logging.GetSubsystems() {
+		levels[system] = oldlogging.GetLevel(system)
+	}
+
+	oldlogging.SetBackend(oldlogging.NewLogBackend(os.Stderr, "", 0), b)
+
+	for system, level := range levels {
+		oldlogging.SetLevel(level, system)
+	}
+}
+
+// Log implements go-logging Backend.
+func (b *logBus) Log(level oldlogging.Level, calldepth int, r *oldlogging.Record) error {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	var record *LogRecord
+	for sub := range b.subs {
+		if !sub.filter.matches(level, r.Module) {
+			continue
+		}
+		if record == nil {
+			record = newLogRecord(calldepth+1, r)
+		}
+		select {
+		case sub.ch <- record:
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *logBus) subscribe(ctx context.Context, filter LogFilter) <-chan *LogRecord {
+	sub := &logSubscription{
+		filter: filter,
+		ch:     make(chan *LogRecord, logSubscriptionBuffer),
+	}
+
+	b.lk.Lock()
+	b.subs[sub] = struct{}{}
+	b.lk.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.lk.Lock()
+		defer b.lk.Unlock()
+		delete(b.subs, sub)
+		close(sub.ch)
+	}()
+	return sub.ch
+}
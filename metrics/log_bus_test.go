@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	oldlogging "github.com/whyrusleeping/go-logging"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestLogBus(t *testing.T) {
+	tf.UnitTest(t)
+
+	log := func(bus *logBus, level oldlogging.Level, system, msg string) {
+		require.NoError(t, bus.Log(level, 0, &oldlogging.Record{
+			Time:   time.Now(),
+			Module: system,
+			Level:  level,
+			Args:   []interface{}{msg},
+		}))
+	}
+
+	t.Run("subscribers receive the records matching their filter", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		bus := newLogBus()
+		all := bus.subscribe(ctx, LogFilter{Level: oldlogging.DEBUG})
+		miningWarnings := bus.subscribe(ctx, LogFilter{System: "mining", Level: oldlogging.WARNING})
+
+		log(bus, oldlogging.INFO, "mining", "mined a block")
+		log(bus, oldlogging.ERROR, "sync", "invalid block")
+		log(bus, oldlogging.WARNING, "mining", "late block")
+
+		for _, msg := range []string{"mined a block", "invalid block", "late block"} {
+			record := <-all
+			assert.Equal(t, msg, record.Message)
+		}
+
+		record := <-miningWarnings
+		assert.Equal(t, "late block", record.Message)
+		assert.Equal(t, "mining", record.System)
+		assert.Equal(t, "WARNING", record.Level)
+		assert.Len(t, miningWarnings, 0)
+	})
+
+	t.Run("the subscription ends with its context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		bus := newLogBus()
+		records := bus.subscribe(ctx, LogFilter{Level: oldlogging.DEBUG})
+		cancel()
+
+		_, ok := <-records
+		assert.False(t, ok)
+		log(bus, oldlogging.INFO, "mining", "mined a block")
+	})
+
+	t.Run("records are dropped for subscribers not keeping up", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		bus := newLogBus()
+		records := bus.subscribe(ctx, LogFilter{Level: oldlogging.DEBUG})
+		for i := 0; i < logSubscriptionBuffer+10; i++ {
+			log(bus, oldlogging.INFO, "mining", "mined a block")
+		}
+		assert.Len(t, records, logSubscriptionBuffer)
+	})
+}
@@ -15,7 +15,8 @@ import (
 type JSONFormatter struct {
 }
 
-type logRecord struct {
+// LogRecord is the structured form of a logged message.
+type LogRecord struct {
 	Timestamp time.Time `json:"timestamp"`
 	Level     string    `json:"level"`
 	System    string    `json:"system"`
@@ -25,6 +26,13 @@ type logRecord struct {
 
 // Format implements go-logging Formatter
 func (jf *JSONFormatter) Format(calldepth int, r *oldlogging.Record, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return encoder.Encode(newLogRecord(calldepth+1, r))
+}
+
+// newLogRecord returns the structured form of r, logged calldepth frames
+// above the caller.
+func newLogRecord(calldepth int, r *oldlogging.Record) *LogRecord {
 	var fileLine string
 	if calldepth > 0 {
 		_, file, line, ok := runtime.Caller(calldepth + 1)
@@ -34,13 +42,11 @@ func (jf *JSONFormatter) Format(calldepth int, r *oldlogging.Record, w io.Writer
 			fileLine = fmt.Sprintf("%s:%d", filepath.Base(file), line)
 		}
 	}
-	lr := &logRecord{
+	return &LogRecord{
 		Timestamp: r.Time,
 		Level:     r.Level.String(),
 		System:    r.Module,
 		Message:   r.Message(),
 		File:      fileLine,
 	}
-	encoder := json.NewEncoder(w)
-	return encoder.Encode(lr)
 }
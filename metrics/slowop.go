@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// slowOps holds every SlowOp created so they can be reported on together.
+var slowOps = struct {
+	lk  sync.Mutex
+	ops map[string]*SlowOp
+}{ops: make(map[string]*SlowOp)}
+
+// SlowOp keeps statistics on the duration of a kind of operation and logs a
+// warning every time one takes longer than its threshold, so operators can
+// pinpoint hardware or configuration bottlenecks.
+type SlowOp struct {
+	name      string
+	threshold time.Duration
+
+	lk        sync.Mutex
+	count     uint64
+	slowCount uint64
+	total     time.Duration
+	max       time.Duration
+}
+
+// NewSlowOp creates a SlowOp named name that considers operations taking
+// longer than threshold slow. Names must be unique.
+func NewSlowOp(name string, threshold time.Duration) *SlowOp {
+	slowOps.lk.Lock()
+	defer slowOps.lk.Unlock()
+
+	if _, ok := slowOps.ops[name]; ok {
+		// a panic here indicates a developer error, see NewTimer.
+		panic(fmt.Sprintf("slow op %s already registered", name))
+	}
+
+	op := &SlowOp{
+		name:      name,
+		threshold: threshold,
+	}
+	slowOps.ops[name] = op
+	return op
+}
+
+// Start starts timing an operation. Call Stop on the returned SlowOpStopwatch
+// when the operation is done, e.g. `defer op.Start().Stop()`.
+func (op *SlowOp) Start() *SlowOpStopwatch {
+	return &SlowOpStopwatch{
+		op:    op,
+		start: time.Now(),
+	}
+}
+
+// Observe records an operation that took d.
+func (op *SlowOp) Observe(d time.Duration) {
+	op.lk.Lock()
+	op.count++
+	op.total += d
+	if d > op.max {
+		op.max = d
+	}
+	slow := d > op.threshold
+	if slow {
+		op.slowCount++
+	}
+	op.lk.Unlock()
+
+	if slow {
+		log.Warningf("slow operation %s took %s (threshold %s)", op.name, d, op.threshold)
+	}
+}
+
+// Report returns the statistics gathered so far.
+func (op *SlowOp) Report() SlowOpReport {
+	op.lk.Lock()
+	defer op.lk.Unlock()
+
+	report := SlowOpReport{
+		Name:      op.name,
+		Threshold: op.threshold,
+		Count:     op.count,
+		SlowCount: op.slowCount,
+		Max:       op.max,
+	}
+	if op.count > 0 {
+		report.Mean = op.total / time.Duration(op.count)
+	}
+	return report
+}
+
+// SlowOpStopwatch times a single operation of a SlowOp.
+type SlowOpStopwatch struct {
+	op    *SlowOp
+	start time.Time
+}
+
+// Stop records the time since Start was called and returns it.
+func (sw *SlowOpStopwatch) Stop() time.Duration {
+	d := time.Since(sw.start)
+	sw.op.Observe(d)
+	return d
+}
+
+// SlowOpReport aggregates the durations of a kind of operation.
+type SlowOpReport struct {
+	Name      string
+	Threshold time.Duration
+	// Count is the number of operations recorded.
+	Count uint64
+	// SlowCount is the number of those that took longer than Threshold.
+	SlowCount uint64
+	Mean      time.Duration
+	Max       time.Duration
+}
+
+// SlowOpReports returns a report for every SlowOp, sorted by name.
+func SlowOpReports() []SlowOpReport {
+	slowOps.lk.Lock()
+	ops := make([]*SlowOp, 0, len(slowOps.ops))
+	for _, op := range slowOps.ops {
+		ops = append(ops, op)
+	}
+	slowOps.lk.Unlock()
+
+	reports := make([]SlowOpReport, 0, len(ops))
+	for _, op := range ops {
+		reports = append(reports, op.Report())
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Name < reports[j].Name
+	})
+	return reports
+}
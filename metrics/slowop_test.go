@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+// unregisterSlowOp cleans up after a test, since slow ops are registered
+// globally.
+func unregisterSlowOp(name string) {
+	slowOps.lk.Lock()
+	defer slowOps.lk.Unlock()
+	delete(slowOps.ops, name)
+}
+
+func TestSlowOpReport(t *testing.T) {
+	tf.UnitTest(t)
+
+	op := NewSlowOp("test/report", 50*time.Millisecond)
+	defer unregisterSlowOp("test/report")
+
+	assert.Equal(t, SlowOpReport{Name: "test/report", Threshold: 50 * time.Millisecond}, op.Report())
+
+	op.Observe(10 * time.Millisecond)
+	op.Observe(30 * time.Millisecond)
+	op.Observe(80 * time.Millisecond)
+
+	report := op.Report()
+	assert.Equal(t, uint64(3), report.Count)
+	assert.Equal(t, uint64(1), report.SlowCount)
+	assert.Equal(t, 40*time.Millisecond, report.Mean)
+	assert.Equal(t, 80*time.Millisecond, report.Max)
+}
+
+func TestSlowOpStopwatch(t *testing.T) {
+	tf.UnitTest(t)
+
+	op := NewSlowOp("test/stopwatch", time.Hour)
+	defer unregisterSlowOp("test/stopwatch")
+
+	sw := op.Start()
+	time.Sleep(time.Millisecond)
+	d := sw.Stop()
+
+	report := op.Report()
+	assert.True(t, d >= time.Millisecond)
+	assert.Equal(t, uint64(1), report.Count)
+	assert.Equal(t, uint64(0), report.SlowCount)
+	assert.Equal(t, d, report.Max)
+}
+
+func TestSlowOpReports(t *testing.T) {
+	tf.UnitTest(t)
+
+	NewSlowOp("test/b", time.Second)
+	defer unregisterSlowOp("test/b")
+	NewSlowOp("test/a", time.Second)
+	defer unregisterSlowOp("test/a")
+
+	var names []string
+	for _, report := range SlowOpReports() {
+		names = append(names, report.Name)
+	}
+	require.Contains(t, names, "test/a")
+	require.Contains(t, names, "test/b")
+
+	// reports are sorted by name
+	for i := 1; i < len(names); i++ {
+		assert.True(t, names[i-1] < names[i])
+	}
+
+	assert.Panics(t, func() { NewSlowOp("test/a", time.Second) })
+}
@@ -10,10 +10,18 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/metrics"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm"
 )
 
+// messageWaitPollInterval is how often the message source is polled while
+// waiting for messages before generating a block.
+var messageWaitPollInterval = 50 * time.Millisecond
+
+var extraMessagesCt = metrics.NewInt64Counter("mining_wait_extra_messages", "Number of messages captured by waiting for the message pool before generating a block")
+
 // Generate returns a new block created from the messages in the pool.
 func (w *DefaultWorker) Generate(ctx context.Context,
 	baseTipSet types.TipSet,
@@ -47,12 +55,23 @@ func (w *DefaultWorker) Generate(ctx context.Context,
 
 	blockHeight := baseHeight + nullBlockCount + 1
 
+	// Blocks may not precede their parents, so a clock running behind the
+	// parents' miners' stamps the block with the parents' time instead.
+	parentTime, err := baseTipSet.MaxTimestamp()
+	if err != nil {
+		return nil, errors.Wrap(err, "get base tip set timestamp")
+	}
+	timestamp := types.Uint64(time.Now().Unix())
+	if timestamp < parentTime {
+		timestamp = parentTime
+	}
+
 	ancestors, err := w.getAncestors(ctx, baseTipSet, types.NewBlockHeight(blockHeight))
 	if err != nil {
 		return nil, errors.Wrap(err, "get base tip set ancestors")
 	}
 
-	pending := w.messageSource.Pending()
+	pending := w.awaitMessages(ctx)
 	mq := NewMessageQueue(pending)
 	messages := mq.Drain()
 
@@ -86,6 +105,12 @@ func (w *DefaultWorker) Generate(ctx context.Context,
 		Proof:           proof,
 		StateRoot:       newStateTreeCid,
 		Ticket:          ticket,
+		Timestamp:       timestamp,
+		ProtocolVersion: types.Uint64(w.protocolVersions.VersionAt(types.NewBlockHeight(blockHeight))),
+	}
+
+	if err := consensus.SignBlock(next, w.minerPubKey, w.workerSigner); err != nil {
+		return nil, errors.Wrap(err, "sign block")
 	}
 
 	for i, msg := range res.PermanentFailures {
@@ -111,3 +136,39 @@ func (w *DefaultWorker) Generate(ctx context.Context,
 
 	return next, nil
 }
+
+// awaitMessages returns the pending messages from the message source. If
+// fewer than the configured minimum are pending it polls the source until
+// enough arrive, the configured maximum wait elapses, or ctx is canceled.
+func (w *DefaultWorker) awaitMessages(ctx context.Context) []*types.SignedMessage {
+	minMessages, maxWait := w.messageWait()
+	pending := w.messageSource.Pending()
+	if maxWait <= 0 || len(pending) >= minMessages {
+		return pending
+	}
+
+	initial := len(pending)
+	defer func() {
+		if extra := len(pending) - initial; extra > 0 {
+			extraMessagesCt.Inc(ctx, int64(extra))
+		}
+	}()
+
+	deadline := time.NewTimer(maxWait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(messageWaitPollInterval)
+	defer ticker.Stop()
+
+	for len(pending) < minMessages {
+		select {
+		case <-ctx.Done():
+			return pending
+		case <-deadline.C:
+			pending = w.messageSource.Pending()
+			return pending
+		case <-ticker.C:
+			pending = w.messageSource.Pending()
+		}
+	}
+	return pending
+}
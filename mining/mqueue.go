@@ -11,11 +11,11 @@ import (
 
 // MessageQueue is a priority queue of messages from different actors. Messages are ordered
 // by decreasing gas price, subject to the constraint that messages from a single actor are
-// always in increasing nonce order.
+// always in increasing nonce order. Messages after a gap in the nonces of their actor are
+// left out, as they can never be mined before the gap is filled.
 // All messages for a queue are inserted at construction, after which messages may only
 // be popped.
 // Potential improvements include:
-// - deprioritising messages after a gap in nonce value, which can never be mined (see Ethereum)
 // - attempting to pack messages into a fixed gas limit (i.e. 0/1 knapsack subject to nonce ordering),
 //   see https://en.wikipedia.org/wiki/Knapsack_problem
 type MessageQueue struct {
@@ -31,12 +31,17 @@ func NewMessageQueue(msgs []*types.SignedMessage) MessageQueue {
 		bySender[m.From] = append(bySender[m.From], m)
 	}
 
-	// Order each sender queue by nonce and initialize heap structure.
+	// Order each sender queue by nonce, cut it at the first gap and
+	// initialize heap structure.
 	addrHeap := make(queueHeap, len(bySender))
 	heapIdx := 0
 	for _, nq := range bySender {
 		sort.Slice(nq, func(i, j int) bool { return nq[i].Nonce < nq[j].Nonce })
-		addrHeap[heapIdx] = nq
+		end := 1
+		for end < len(nq) && nq[end].Nonce == nq[end-1].Nonce+1 {
+			end++
+		}
+		addrHeap[heapIdx] = nq[:end]
 		heapIdx++
 	}
 	heap.Init(&addrHeap)
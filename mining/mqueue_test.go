@@ -99,4 +99,20 @@ func TestMessageQueueOrder(t *testing.T) {
 		assert.Equal(t, expected, actual)
 		assert.True(t, q.Empty())
 	})
+
+	t.Run("leaves out messages after a nonce gap", func(t *testing.T) {
+		msgs := []*types.SignedMessage{
+			sign(a0, to, 3, 0, 1),
+			sign(a0, to, 5, 0, 1),
+			sign(a0, to, 4, 0, 1),
+			sign(a0, to, 7, 0, 1),
+			sign(a1, to, 2, 0, 2),
+		}
+		expected := []*types.SignedMessage{msgs[4], msgs[0], msgs[2], msgs[1]}
+
+		q := NewMessageQueue(msgs)
+		actual := q.Drain()
+		assert.Equal(t, expected, actual)
+		assert.True(t, q.Empty())
+	})
 }
@@ -43,13 +43,17 @@ import (
 )
 
 // Scheduler is the mining interface consumers use. When you Start() the
-// scheduler it returns two channels (inCh, outCh) and a sync.WaitGroup:
-//   - inCh: the caller sends Inputs to mine on to this channel.
+// scheduler it returns a channel and a sync.WaitGroup:
 //   - outCh: the scheduler sends Outputs to the caller on this channel.
 //   - doneWg: signals that the scheduler and any goroutines it launched
 //             have stopped. (Context cancelation happens async, so you
 //             need some way to know when it has actually stopped.)
 //
+// There is no input channel: the scheduler polls for the heaviest tipset
+// itself once each collection period has passed. Producers of new tipsets
+// therefore never block on the scheduler, and tipsets that are superseded
+// before the poll are never mined on.
+//
 // Once Start()ed, the Scheduler can be stopped by canceling its miningCtx,
 // which will signal on doneWg when it's actually done. Canceling miningCtx
 // cancels any run in progress and shuts the scheduler down.
@@ -75,15 +79,12 @@ type timingScheduler struct {
 const MineDelayConversionFactor = 30
 
 // Start is the main entrypoint for the timingScheduler. Call it to start
-// mining. It returns two channels: an input channel for tipsets and an output
-// channel for newly mined blocks. It also returns a waitgroup that will signal
-// that all mining runs and auxiliary goroutines have completed. Each tipset
-// that is received on the input channel is procssesed by the scheduler.
-// Depending on the tipset height and the time the input is received, a new
-// input may cancel the context of the previous mining run, or be ignorned.
-// Any successfully mined blocks are sent into the output channel. Closing the
-// input channel does not cause the scheduler to stop; cancel the miningCtx to
-// stop all mining and shut down the scheduler.
+// mining. It returns an output channel for newly mined blocks and a waitgroup
+// that will signal that all mining runs and auxiliary goroutines have
+// completed. After each collection period the scheduler polls for the
+// heaviest tipset and mines on it synchronously. Any successfully mined blocks
+// are sent into the output channel. Cancel the miningCtx to stop all mining
+// and shut down the scheduler.
 func (s *timingScheduler) Start(miningCtx context.Context) (<-chan Output, *sync.WaitGroup) {
 	// we buffer 1 to make sure we do not get blocked when shutting down
 	outCh := make(chan Output, 1)
@@ -141,3 +141,9 @@ func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore b
 func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
 	return true
 }
+
+// WorkerAddr returns the miner address itself, as test miners are their own
+// workers.
+func (tv *TestPowerTableView) WorkerAddr(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (address.Address, error) {
+	return mAddr, nil
+}
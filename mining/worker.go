@@ -6,6 +6,7 @@ package mining
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -92,6 +93,17 @@ type DefaultWorker struct {
 	blockstore    blockstore.Blockstore
 	cstore        *hamt.CborIpldStore
 	blockTime     time.Duration
+
+	// message wait heuristic, see SetMessageWait
+	messageWaitLk  sync.Mutex
+	minMessages    int
+	maxMessageWait time.Duration
+
+	// clockCheck reports whether the local clock is fit to mine with, see SetClockCheck
+	clockCheck func() error
+
+	// protocolVersions are the protocol versions of the network, see SetProtocolVersions
+	protocolVersions consensus.ProtocolVersionTable
 }
 
 // NewDefaultWorker instantiates a new Worker.
@@ -164,6 +176,38 @@ func NewDefaultWorkerWithDeps(messageSource MessageSource,
 	}
 }
 
+// SetMessageWait configures the worker to delay block generation by up to
+// maxWait while fewer than minMessages messages are pending. This lets a miner
+// that wins right after a head change pick up messages that arrive late. A
+// zero maxWait disables waiting. It is safe to call while the worker mines.
+func (w *DefaultWorker) SetMessageWait(minMessages int, maxWait time.Duration) {
+	w.messageWaitLk.Lock()
+	defer w.messageWaitLk.Unlock()
+	w.minMessages = minMessages
+	w.maxMessageWait = maxWait
+}
+
+// messageWait returns the message wait heuristic set with SetMessageWait.
+func (w *DefaultWorker) messageWait() (int, time.Duration) {
+	w.messageWaitLk.Lock()
+	defer w.messageWaitLk.Unlock()
+	return w.minMessages, w.maxMessageWait
+}
+
+// SetClockCheck configures the worker to call check before each mining run
+// and to skip the run if it returns an error. Blocks stamped by a drifting
+// clock would be rejected by the network, so mining with one wastes work.
+func (w *DefaultWorker) SetClockCheck(check func() error) {
+	w.clockCheck = check
+}
+
+// SetProtocolVersions configures the worker to stamp blocks with the protocol
+// version versions has active at their height. Without versions blocks carry
+// no protocol version.
+func (w *DefaultWorker) SetProtocolVersions(versions consensus.ProtocolVersionTable) {
+	w.protocolVersions = versions
+}
+
 // DoSomeWorkFunc is a dummy function that mimics doing something time-consuming
 // in the mining loop such as computing proofs. Pass a function that calls Sleep()
 // is a good idea for now.
@@ -181,6 +225,13 @@ func (w *DefaultWorker) Mine(ctx context.Context, base types.TipSet, nullBlkCoun
 		return false
 	}
 
+	if w.clockCheck != nil {
+		if err := w.clockCheck(); err != nil {
+			log.Errorf("Worker.Mine refusing to mine: %s", err)
+			return false
+		}
+	}
+
 	st, err := w.getStateTree(ctx, base)
 	if err != nil {
 		log.Errorf("Worker.Mine couldn't get state tree for tipset: %s", err.Error())
@@ -194,15 +245,15 @@ func (w *DefaultWorker) Mine(ctx context.Context, base types.TipSet, nullBlkCoun
 		return false
 	}
 
-	challenge, err := consensus.CreateChallengeSeed(base, uint64(nullBlkCount))
+	ticket, err := consensus.CreateTicket(base, uint64(nullBlkCount), w.minerPubKey, w.workerSigner)
 	if err != nil {
+		log.Errorf("failed to create ticket: %s", err)
 		outCh <- Output{Err: err}
 		return false
 	}
-	prCh := createProof(challenge, w.createPoSTFunc)
+	prCh := createProof(consensus.CreateChallengeSeed(ticket), w.createPoSTFunc)
 
 	var proof types.PoStProof
-	var ticket []byte
 	select {
 	case <-ctx.Done():
 		log.Infof("Mining run on base %s with %d null blocks canceled.", base.String(), nullBlkCount)
@@ -212,12 +263,7 @@ func (w *DefaultWorker) Mine(ctx context.Context, base types.TipSet, nullBlkCoun
 			log.Errorf("Worker.Mine got zero value from channel prChRead")
 			return false
 		}
-		proof := append(types.PoStProof{}, prChRead[:]...)
-		ticket, err = consensus.CreateTicket(proof, w.minerPubKey, w.workerSigner)
-		if err != nil {
-			log.Errorf("failed to create ticket: %s", err)
-			return false
-		}
+		proof = append(types.PoStProof{}, prChRead[:]...)
 	}
 
 	// TODO: Test the interplay of isWinningTicket() and createPoSTFunc()
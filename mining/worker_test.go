@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
@@ -94,6 +95,18 @@ func Test_Mine(t *testing.T) {
 		assert.False(t, doSomeWorkCalled)
 		cancel()
 	})
+
+	t.Run("Refuses to mine when the clock check fails", func(t *testing.T) {
+		doSomeWorkCalled = false
+		ctx, cancel := context.WithCancel(context.Background())
+		worker := mining.NewDefaultWorkerWithDeps(pool, getStateTree, getWeightTest, getAncestors, th.NewTestProcessor(),
+			mining.NewTestPowerTableView(1), bs, cst, minerAddr, minerOwnerAddr, blockSignerAddr, mockSigner, th.BlockTimeTest, CreatePoSTFunc)
+		worker.SetClockCheck(func() error { return errors.New("clock drift") })
+		outCh := make(chan mining.Output)
+		assert.False(t, worker.Mine(ctx, tipSet, 0, outCh))
+		assert.False(t, doSomeWorkCalled)
+		cancel()
+	})
 }
 
 func sharedSetupInitial() (*hamt.CborIpldStore, *core.MessagePool, cid.Cid) {
@@ -354,6 +367,9 @@ func TestGenerateSetsBasicFields(t *testing.T) {
 	assert.Equal(t, h+1, blk.Height)
 	assert.Equal(t, minerAddr, blk.Miner)
 
+	workerAddr := mockSigner.Addresses[len(mockSigner.Addresses)-1]
+	assert.True(t, types.IsValidSignature(blk.SignatureData(), workerAddr, blk.BlockSig))
+
 	blk, err = worker.Generate(ctx, baseTipSet, nil, types.PoStProof{}, 1)
 	assert.NoError(t, err)
 
@@ -362,6 +378,45 @@ func TestGenerateSetsBasicFields(t *testing.T) {
 	assert.Equal(t, minerAddr, blk.Miner)
 }
 
+func TestGenerateSetsTimestamp(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	mockSigner, blockSignerAddr := setupSigner()
+	newCid := types.NewCidForTestGetter()
+
+	st, pool, addrs, cst, bs := sharedSetup(t, mockSigner)
+
+	getStateTree := func(c context.Context, ts types.TipSet) (state.Tree, error) {
+		return st, nil
+	}
+	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
+		return nil, nil
+	}
+	worker := mining.NewDefaultWorkerWithDeps(pool, getStateTree, getWeightTest, getAncestors, consensus.NewDefaultProcessor(),
+		&th.TestView{}, bs, cst, addrs[4], addrs[3], blockSignerAddr, mockSigner, th.BlockTimeTest, func() {})
+
+	t.Run("uses the local clock", func(t *testing.T) {
+		before := types.Uint64(time.Now().Unix())
+		baseTipSet := th.RequireNewTipSet(t, &types.Block{Height: 100, StateRoot: newCid(), Timestamp: before - 30})
+
+		blk, err := worker.Generate(ctx, baseTipSet, nil, types.PoStProof{}, 0)
+		require.NoError(t, err)
+		assert.True(t, blk.Timestamp >= before)
+		assert.True(t, blk.Timestamp <= types.Uint64(time.Now().Unix()))
+	})
+
+	t.Run("does not precede the parents", func(t *testing.T) {
+		// The parents were stamped by a clock running ahead of ours.
+		parentTime := types.Uint64(time.Now().Add(time.Minute).Unix())
+		baseTipSet := th.RequireNewTipSet(t, &types.Block{Height: 100, StateRoot: newCid(), Timestamp: parentTime})
+
+		blk, err := worker.Generate(ctx, baseTipSet, nil, types.PoStProof{}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, parentTime, blk.Timestamp)
+	})
+}
+
 func TestGenerateWithoutMessages(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -395,6 +450,71 @@ func TestGenerateWithoutMessages(t *testing.T) {
 	assert.Len(t, blk.Messages, 0)
 }
 
+func TestGenerateWaitsForMessages(t *testing.T) {
+	tf.UnitTest(t)
+
+	CreatePoSTFunc := func() {}
+
+	ctx := context.Background()
+	mockSigner, blockSignerAddr := setupSigner()
+	newCid := types.NewCidForTestGetter()
+
+	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
+		return nil, nil
+	}
+	baseBlock := types.Block{
+		Parents:   types.NewSortedCidSet(newCid()),
+		Height:    types.Uint64(100),
+		StateRoot: newCid(),
+		Proof:     types.PoStProof{},
+	}
+
+	t.Run("captures messages that arrive while waiting", func(t *testing.T) {
+		st, pool, addrs, cst, bs := sharedSetup(t, mockSigner)
+		getStateTree := func(c context.Context, ts types.TipSet) (state.Tree, error) {
+			return st, nil
+		}
+		worker := mining.NewDefaultWorkerWithDeps(pool, getStateTree, getWeightTest, getAncestors, consensus.NewDefaultProcessor(),
+			&th.TestView{}, bs, cst, addrs[4], addrs[3], blockSignerAddr, mockSigner, th.BlockTimeTest, CreatePoSTFunc)
+		worker.SetMessageWait(1, 10*time.Second)
+
+		msg := types.NewMessage(addrs[0], addrs[1], 0, nil, "", nil)
+		smsg, err := types.NewSignedMessage(*msg, &mockSigner, types.NewGasPrice(1), types.NewGasUnits(0))
+		require.NoError(t, err)
+
+		addErr := make(chan error, 1)
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			_, err := pool.Add(ctx, smsg)
+			addErr <- err
+		}()
+
+		blk, err := worker.Generate(ctx, th.RequireNewTipSet(t, &baseBlock), nil, types.PoStProof{}, 0)
+		require.NoError(t, err)
+		require.NoError(t, <-addErr)
+
+		assert.Len(t, blk.Messages, 1)
+	})
+
+	t.Run("gives up after the maximum wait", func(t *testing.T) {
+		st, pool, addrs, cst, bs := sharedSetup(t, mockSigner)
+		getStateTree := func(c context.Context, ts types.TipSet) (state.Tree, error) {
+			return st, nil
+		}
+		worker := mining.NewDefaultWorkerWithDeps(pool, getStateTree, getWeightTest, getAncestors, consensus.NewDefaultProcessor(),
+			&th.TestView{}, bs, cst, addrs[4], addrs[3], blockSignerAddr, mockSigner, th.BlockTimeTest, CreatePoSTFunc)
+		maxWait := 200 * time.Millisecond
+		worker.SetMessageWait(1, maxWait)
+
+		start := time.Now()
+		blk, err := worker.Generate(ctx, th.RequireNewTipSet(t, &baseBlock), nil, types.PoStProof{}, 0)
+		require.NoError(t, err)
+
+		assert.True(t, time.Since(start) >= maxWait)
+		assert.Len(t, blk.Messages, 0)
+	})
+}
+
 // If something goes wrong while generating a new block, even as late as when flushing it,
 // no block should be returned, and the message pool should not be pruned.
 func TestGenerateError(t *testing.T) {
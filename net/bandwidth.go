@@ -0,0 +1,71 @@
+package net
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-metrics"
+	"github.com/libp2p/go-libp2p-peer"
+	"github.com/libp2p/go-libp2p-protocol"
+)
+
+// ProtocolBandwidth is the bandwidth used by the streams of a protocol.
+type ProtocolBandwidth struct {
+	Protocol string
+	metrics.Stats
+}
+
+// BandwidthTracker is the bandwidth reporter of the host. On top of the
+// totals, it accounts for the bandwidth used by each protocol the host spoke.
+type BandwidthTracker struct {
+	*metrics.BandwidthCounter
+
+	lk        sync.Mutex
+	protocols map[protocol.ID]struct{}
+}
+
+// NewBandwidthTracker returns a new BandwidthTracker.
+func NewBandwidthTracker() *BandwidthTracker {
+	return &BandwidthTracker{
+		BandwidthCounter: metrics.NewBandwidthCounter(),
+		protocols:        make(map[protocol.ID]struct{}),
+	}
+}
+
+// LogSentMessageStream implements metrics.Reporter.
+func (t *BandwidthTracker) LogSentMessageStream(size int64, proto protocol.ID, p peer.ID) {
+	t.addProtocol(proto)
+	t.BandwidthCounter.LogSentMessageStream(size, proto, p)
+}
+
+// LogRecvMessageStream implements metrics.Reporter.
+func (t *BandwidthTracker) LogRecvMessageStream(size int64, proto protocol.ID, p peer.ID) {
+	t.addProtocol(proto)
+	t.BandwidthCounter.LogRecvMessageStream(size, proto, p)
+}
+
+// ByProtocol returns the bandwidth used by each protocol, sorted by protocol.
+func (t *BandwidthTracker) ByProtocol() []ProtocolBandwidth {
+	t.lk.Lock()
+	protocols := make([]string, 0, len(t.protocols))
+	for proto := range t.protocols {
+		protocols = append(protocols, string(proto))
+	}
+	t.lk.Unlock()
+	sort.Strings(protocols)
+
+	out := make([]ProtocolBandwidth, len(protocols))
+	for i, proto := range protocols {
+		out[i] = ProtocolBandwidth{
+			Protocol: proto,
+			Stats:    t.GetBandwidthForProtocol(protocol.ID(proto)),
+		}
+	}
+	return out
+}
+
+func (t *BandwidthTracker) addProtocol(proto protocol.ID) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.protocols[proto] = struct{}{}
+}
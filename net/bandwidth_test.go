@@ -0,0 +1,30 @@
+package net
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestBandwidthTrackerByProtocol(t *testing.T) {
+	tf.UnitTest(t)
+
+	pid, err := peer.IDB58Decode("QmcZf59bWwK5XFi76CZX8cbJ4BhTzzA3gU1ZjYZcYW3dwt")
+	require.NoError(t, err)
+
+	tracker := NewBandwidthTracker()
+	assert.Empty(t, tracker.ByProtocol())
+
+	tracker.LogSentMessageStream(100, "/fil/storage/mk/1.0.0", pid)
+	tracker.LogRecvMessageStream(50, "/fil/hello/1.0.0", pid)
+	tracker.LogRecvMessageStream(50, "/fil/storage/mk/1.0.0", pid)
+
+	byProtocol := tracker.ByProtocol()
+	require.Len(t, byProtocol, 2)
+	assert.Equal(t, "/fil/hello/1.0.0", byProtocol[0].Protocol)
+	assert.Equal(t, "/fil/storage/mk/1.0.0", byProtocol[1].Protocol)
+}
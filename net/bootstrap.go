@@ -30,6 +30,9 @@ var filecoinDHTBootstrapConfig = dht.BootstrapConfig{
 // or call Stop().
 type Bootstrapper struct {
 	// Config
+	// lk protects MinPeerThreshold, bootstrapPeers and dhtBootStarted, as
+	// the peers can be changed with SetBootstrapPeers while bootstrapping.
+	lk sync.Mutex
 	// MinPeerThreshold is the number of connections it attempts to maintain.
 	MinPeerThreshold int
 	// Peers to connect to if we fall below the threshold.
@@ -98,11 +101,30 @@ func (b *Bootstrapper) Stop() {
 	}
 }
 
+// SetBootstrapPeers replaces the bootstrap peers and the number of connections
+// to maintain. If the Bootstrapper is started it bootstraps right away, so
+// that a node short of peers connects to new bootstrap peers without waiting
+// for the next period.
+func (b *Bootstrapper) SetBootstrapPeers(bootstrapPeers []pstore.PeerInfo, minPeer int) {
+	b.lk.Lock()
+	b.bootstrapPeers = bootstrapPeers
+	b.MinPeerThreshold = minPeer
+	b.lk.Unlock()
+
+	if b.ctx != nil && b.ctx.Err() == nil {
+		go b.Bootstrap(b.d.Peers())
+	}
+}
+
 // bootstrap does the actual work. If the number of connected peers
 // has fallen below b.MinPeerThreshold it will attempt to connect to
 // a random subset of its bootstrap peers.
 func (b *Bootstrapper) bootstrap(currentPeers []peer.ID) {
-	peersNeeded := b.MinPeerThreshold - len(currentPeers)
+	b.lk.Lock()
+	minPeerThreshold, bootstrapPeers := b.MinPeerThreshold, b.bootstrapPeers
+	b.lk.Unlock()
+
+	peersNeeded := minPeerThreshold - len(currentPeers)
 	if peersNeeded < 1 {
 		return
 	}
@@ -113,8 +135,11 @@ func (b *Bootstrapper) bootstrap(currentPeers []peer.ID) {
 		wg.Wait()
 		// After connecting to bootstrap peers, bootstrap the DHT.
 		// DHT Bootstrap is a persistent process so only do this once.
-		if !b.dhtBootStarted {
-			b.dhtBootStarted = true
+		b.lk.Lock()
+		startDHTBoot := !b.dhtBootStarted
+		b.dhtBootStarted = true
+		b.lk.Unlock()
+		if startDHTBoot {
 			err := b.bootstrapIpfsRouting()
 			if err != nil {
 				logBootstrap.Warningf("got error trying to bootstrap Routing: %s. Peer discovery may suffer.", err.Error())
@@ -124,8 +149,8 @@ func (b *Bootstrapper) bootstrap(currentPeers []peer.ID) {
 	}()
 
 	peersAttempted := 0
-	for _, i := range rand.Perm(len(b.bootstrapPeers)) {
-		pinfo := b.bootstrapPeers[i]
+	for _, i := range rand.Perm(len(bootstrapPeers)) {
+		pinfo := bootstrapPeers[i]
 		// Don't try to connect to an already connected peer.
 		if hasPID(currentPeers, pinfo.ID) {
 			continue
@@ -143,7 +168,7 @@ func (b *Bootstrapper) bootstrap(currentPeers []peer.ID) {
 			return
 		}
 	}
-	logBootstrap.Warningf("not enough bootstrap nodes to maintain %d connections (current connections: %d)", b.MinPeerThreshold, len(currentPeers))
+	logBootstrap.Warningf("not enough bootstrap nodes to maintain %d connections (current connections: %d)", minPeerThreshold, len(currentPeers))
 }
 
 func hasPID(pids []peer.ID, pid peer.ID) bool {
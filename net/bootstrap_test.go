@@ -133,3 +133,34 @@ func TestBootstrapperBootstrap(t *testing.T) {
 		lk.Unlock()
 	})
 }
+
+func TestBootstrapperSetBootstrapPeers(t *testing.T) {
+	tf.UnitTest(t)
+
+	var lk sync.Mutex
+	var connected []peer.ID
+	recordingConnect := func(_ context.Context, pi pstore.PeerInfo) error {
+		lk.Lock()
+		defer lk.Unlock()
+		connected = append(connected, pi.ID)
+		return nil
+	}
+
+	fakeHost := &th.FakeHost{ConnectImpl: recordingConnect}
+	fakeDialer := &th.FakeDialer{PeersImpl: nopPeers}
+	fakeRouter := offroute.NewOfflineRouter(repo.NewInMemoryRepo().Datastore(), blankValidator{})
+
+	b := NewBootstrapper([]pstore.PeerInfo{}, fakeHost, fakeDialer, fakeRouter, 1, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b.Start(ctx)
+
+	// the new peer is connected to right away rather than after the period
+	newPeer := th.RequireRandomPeerID(t)
+	b.SetBootstrapPeers([]pstore.PeerInfo{{ID: newPeer}}, 1)
+	time.Sleep(20 * time.Millisecond)
+
+	lk.Lock()
+	defer lk.Unlock()
+	assert.Equal(t, []peer.ID{newPeer}, connected)
+}
@@ -0,0 +1,381 @@
+package net
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs-blockstore"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	ipld "github.com/ipfs/go-ipld-format"
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-host"
+	inet "github.com/libp2p/go-libp2p-net"
+	"github.com/libp2p/go-libp2p-peer"
+	"github.com/pkg/errors"
+
+	cbu "github.com/filecoin-project/go-filecoin/cborutil"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func init() {
+	cbor.RegisterCborType(ChainExchangeRequest{})
+	cbor.RegisterCborType(ChainExchangeResponse{})
+	cbor.RegisterCborType(ExchangeBlock{})
+}
+
+// chainExchangeProtocol is the libp2p protocol identifier of the chain
+// exchange protocol, which transfers ranges of tipsets and state trees in a
+// single round trip.
+const chainExchangeProtocol = "/fil/chain/xchg/1.0.0"
+
+// ChainExchangeTipSets is the number of tipsets requested at once, from the
+// requested tipset back.
+const ChainExchangeTipSets = 100
+
+// maxExchangeTipSets bounds the tipsets served for a single request.
+const maxExchangeTipSets = 500
+
+// defaultExchangePeerTimeout is how long a peer is given to serve a request
+// before the next peer is tried.
+const defaultExchangePeerTimeout = 15 * time.Second
+
+// maxExchangePeers is the number of peers tracked to fetch from.
+const maxExchangePeers = 16
+
+var logExchange = logging.Logger("net.chainexchange")
+
+// ChainExchangeStatus tells whether a chain exchange request is served.
+type ChainExchangeStatus int
+
+const (
+	// ExchangeOK means the blocks follow the response.
+	ExchangeOK = ChainExchangeStatus(iota)
+	// ExchangeNotFound means the peer doesn't have the requested data.
+	ExchangeNotFound
+	// ExchangeBadRequest means the request is malformed.
+	ExchangeBadRequest
+)
+
+// ChainExchangeRequest asks a peer for a range of tipsets or a state tree.
+type ChainExchangeRequest struct {
+	// Head is the first tipset to send, followed by its ancestors up to
+	// Length tipsets.
+	Head   []cid.Cid
+	Length uint64
+	// StateRoot, if defined, asks for the whole state tree under it rather
+	// than tipsets.
+	StateRoot cid.Cid
+}
+
+// ChainExchangeResponse tells whether the request is served. If it is, the
+// blocks follow as ExchangeBlock messages until the stream is closed.
+type ChainExchangeResponse struct {
+	Status       ChainExchangeStatus
+	ErrorMessage string
+}
+
+// ExchangeBlock is a raw block sent over the chain exchange protocol.
+type ExchangeBlock struct {
+	Cid  cid.Cid
+	Data []byte
+}
+
+// ChainExchangeServer serves the tipsets and state trees of the local
+// blockstore to peers.
+type ChainExchangeServer struct {
+	bs blockstore.Blockstore
+}
+
+// NewChainExchangeServer creates a ChainExchangeServer and registers it to
+// the host.
+func NewChainExchangeServer(h host.Host, bs blockstore.Blockstore) *ChainExchangeServer {
+	server := &ChainExchangeServer{bs: bs}
+	h.SetStreamHandler(chainExchangeProtocol, server.handleStream)
+	return server
+}
+
+func (server *ChainExchangeServer) handleStream(s inet.Stream) {
+	defer s.Close() // nolint: errcheck
+
+	var req ChainExchangeRequest
+	if err := cbu.NewMsgReader(s).ReadMsg(&req); err != nil {
+		logExchange.Debugf("bad chain exchange request from peer %s: %s", s.Conn().RemotePeer(), err)
+		return
+	}
+
+	w := cbu.NewMsgWriter(s)
+	var err error
+	if req.StateRoot.Defined() {
+		err = server.sendState(w, req.StateRoot)
+	} else {
+		err = server.sendTipSets(w, req.Head, req.Length)
+	}
+	if err != nil {
+		logExchange.Debugf("failed to serve chain exchange request from peer %s: %s", s.Conn().RemotePeer(), err)
+	}
+}
+
+// sendTipSets sends the blocks of head and its ancestors, up to length
+// tipsets or the first one missing locally.
+func (server *ChainExchangeServer) sendTipSets(w *cbu.MsgWriter, head []cid.Cid, length uint64) error {
+	if len(head) == 0 || length == 0 {
+		return w.WriteMsg(&ChainExchangeResponse{Status: ExchangeBadRequest, ErrorMessage: "no tipset requested"})
+	}
+	if length > maxExchangeTipSets {
+		length = maxExchangeTipSets
+	}
+
+	var blks []blocks.Block
+	for _, c := range head {
+		blk, err := server.bs.Get(c)
+		if err == blockstore.ErrNotFound {
+			return w.WriteMsg(&ChainExchangeResponse{Status: ExchangeNotFound, ErrorMessage: "tipset not found"})
+		}
+		if err != nil {
+			return err
+		}
+		blks = append(blks, blk)
+	}
+
+	if err := w.WriteMsg(&ChainExchangeResponse{Status: ExchangeOK}); err != nil {
+		return err
+	}
+
+	for i := uint64(0); i < length; i++ {
+		for _, blk := range blks {
+			if err := w.WriteMsg(&ExchangeBlock{Cid: blk.Cid(), Data: blk.RawData()}); err != nil {
+				return err
+			}
+		}
+
+		decoded, err := types.DecodeBlock(blks[0].RawData())
+		if err != nil {
+			return err
+		}
+		if decoded.Parents.Empty() {
+			return nil
+		}
+
+		blks = blks[:0]
+		for _, c := range decoded.Parents.ToSlice() {
+			blk, err := server.bs.Get(c)
+			if err != nil {
+				// the peer fetches the rest elsewhere
+				return nil
+			}
+			blks = append(blks, blk)
+		}
+	}
+	return nil
+}
+
+// sendState sends all the blocks of the state tree under root.
+func (server *ChainExchangeServer) sendState(w *cbu.MsgWriter, root cid.Cid) error {
+	has, err := server.bs.Has(root)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return w.WriteMsg(&ChainExchangeResponse{Status: ExchangeNotFound, ErrorMessage: "state not found"})
+	}
+
+	if err := w.WriteMsg(&ChainExchangeResponse{Status: ExchangeOK}); err != nil {
+		return err
+	}
+
+	seen := cid.NewSet()
+	queue := []cid.Cid{root}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if !seen.Visit(c) {
+			continue
+		}
+
+		blk, err := server.bs.Get(c)
+		if err != nil {
+			// the peer fetches the rest elsewhere
+			return nil
+		}
+		if err := w.WriteMsg(&ExchangeBlock{Cid: c, Data: blk.RawData()}); err != nil {
+			return err
+		}
+
+		nd, err := ipld.Decode(blk)
+		if err != nil {
+			return err
+		}
+		for _, link := range nd.Links() {
+			queue = append(queue, link.Cid)
+		}
+	}
+	return nil
+}
+
+// ChainExchangeFetcher fetches the chain for the syncer. Rather than fetching
+// tipsets one by one, it asks peers for ranges of tipsets and for whole state
+// trees over the chain exchange protocol, each in a single round trip, which
+// makes syncing much faster on high latency links. Peers are tried in turn,
+// each with a timeout, and the blocks still missing are fetched with bitswap.
+type ChainExchangeFetcher struct {
+	host     host.Host
+	bs       blockstore.Blockstore
+	fallback *Fetcher
+
+	// PeerTimeout is how long a peer is given to serve a request.
+	PeerTimeout time.Duration
+
+	lk    sync.Mutex
+	peers []peer.ID
+}
+
+// NewChainExchangeFetcher returns a ChainExchangeFetcher storing the fetched
+// blocks to bs, and falling back to fallback.
+func NewChainExchangeFetcher(h host.Host, bs blockstore.Blockstore, fallback *Fetcher) *ChainExchangeFetcher {
+	return &ChainExchangeFetcher{
+		host:        h,
+		bs:          bs,
+		fallback:    fallback,
+		PeerTimeout: defaultExchangePeerTimeout,
+	}
+}
+
+// AddPeer makes the fetcher ask p for the chain, before the peers added
+// earlier. Peers are added as they tell about their heads.
+func (f *ChainExchangeFetcher) AddPeer(p peer.ID) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+
+	peers := []peer.ID{p}
+	for _, other := range f.peers {
+		if other != p && len(peers) < maxExchangePeers {
+			peers = append(peers, other)
+		}
+	}
+	f.peers = peers
+}
+
+// GetBlocks returns the blocks with the given cids. Blocks missing locally
+// are fetched with their ancestors from the peers, and otherwise with
+// bitswap.
+func (f *ChainExchangeFetcher) GetBlocks(ctx context.Context, cids []cid.Cid) ([]*types.Block, error) {
+	if !f.hasAll(cids) {
+		f.exchange(ctx, &ChainExchangeRequest{Head: cids, Length: ChainExchangeTipSets}, func() bool {
+			return f.hasAll(cids)
+		})
+	}
+	return f.fallback.GetBlocks(ctx, cids)
+}
+
+// FetchState fetches the state tree with the given root from the peers, and
+// otherwise with bitswap, adding it to the blockstore.
+func (f *ChainExchangeFetcher) FetchState(ctx context.Context, root cid.Cid) error {
+	if !f.hasAll([]cid.Cid{root}) {
+		f.exchange(ctx, &ChainExchangeRequest{StateRoot: root}, func() bool {
+			// the blocks the peer missed are fetched with bitswap
+			return true
+		})
+	}
+	// resolves the blocks fetched locally, and those missing with bitswap
+	return f.fallback.FetchState(ctx, root)
+}
+
+// exchange sends req to the peers in turn, until one serves it and done
+// returns true or the peers are exhausted.
+func (f *ChainExchangeFetcher) exchange(ctx context.Context, req *ChainExchangeRequest, done func() bool) {
+	f.lk.Lock()
+	peers := append([]peer.ID{}, f.peers...)
+	f.lk.Unlock()
+
+	for _, p := range peers {
+		if ctx.Err() != nil {
+			return
+		}
+		count, err := f.exchangeWith(ctx, p, req)
+		if err != nil {
+			logExchange.Debugf("chain exchange with peer %s failed after %d blocks: %s", p, count, err)
+			continue
+		}
+		if count > 0 && done() {
+			return
+		}
+	}
+}
+
+// exchangeWith sends req to p and stores the blocks it sends, returning
+// their number.
+func (f *ChainExchangeFetcher) exchangeWith(ctx context.Context, p peer.ID, req *ChainExchangeRequest) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.PeerTimeout)
+	defer cancel()
+
+	s, err := f.host.NewStream(ctx, p, chainExchangeProtocol)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close() // nolint: errcheck
+
+	// unblock the reads when the timeout elapses
+	go func() {
+		<-ctx.Done()
+		s.Reset() // nolint: errcheck
+	}()
+
+	if err := cbu.NewMsgWriter(s).WriteMsg(req); err != nil {
+		return 0, err
+	}
+
+	r := cbu.NewMsgReader(s)
+	var resp ChainExchangeResponse
+	if err := r.ReadMsg(&resp); err != nil {
+		return 0, err
+	}
+	if resp.Status != ExchangeOK {
+		return 0, errors.Errorf("request refused: %s", resp.ErrorMessage)
+	}
+
+	count := 0
+	for {
+		var eb ExchangeBlock
+		err := r.ReadMsg(&eb)
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		blk, err := verifiedBlock(&eb)
+		if err != nil {
+			return count, err
+		}
+		if err := f.bs.Put(blk); err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+func (f *ChainExchangeFetcher) hasAll(cids []cid.Cid) bool {
+	for _, c := range cids {
+		if has, err := f.bs.Has(c); err != nil || !has {
+			return false
+		}
+	}
+	return true
+}
+
+// verifiedBlock returns the block of eb, checking its data hashes to its cid.
+func verifiedBlock(eb *ExchangeBlock) (blocks.Block, error) {
+	c, err := eb.Cid.Prefix().Sum(eb.Data)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Equals(eb.Cid) {
+		return nil, errors.Errorf("data of block %s does not match its cid", eb.Cid)
+	}
+	return blocks.NewBlockWithCid(eb.Data, eb.Cid)
+}
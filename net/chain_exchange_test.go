@@ -0,0 +1,108 @@
+package net_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bserv "github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dss "github.com/ipfs/go-datastore/sync"
+	"github.com/ipfs/go-hamt-ipld"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	"github.com/ipfs/go-ipfs-exchange-offline"
+	"github.com/libp2p/go-libp2p-host"
+	"github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/net"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func newChainExchangePeers(ctx context.Context, t *testing.T) (host.Host, bstore.Blockstore, *net.ChainExchangeFetcher, bstore.Blockstore) {
+	mn, err := mocknet.WithNPeers(ctx, 2)
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+	require.NoError(t, mn.ConnectAllButSelf())
+
+	serverBS := bstore.NewBlockstore(dss.MutexWrap(datastore.NewMapDatastore()))
+	net.NewChainExchangeServer(mn.Hosts()[0], serverBS)
+
+	clientBS := bstore.NewBlockstore(dss.MutexWrap(datastore.NewMapDatastore()))
+	fallback := net.NewFetcher(ctx, bserv.New(clientBS, offline.Exchange(clientBS)))
+	fetcher := net.NewChainExchangeFetcher(mn.Hosts()[1], clientBS, fallback)
+
+	return mn.Hosts()[0], serverBS, fetcher, clientBS
+}
+
+func TestChainExchangeFetchesTipSetRanges(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server, serverBS, fetcher, clientBS := newChainExchangePeers(ctx, t)
+
+	genesis := types.NewBlockForTest(nil, 0)
+	block1 := types.NewBlockForTest(genesis, 1)
+	block2 := types.NewBlockForTest(block1, 2)
+	for _, blk := range []*types.Block{genesis, block1, block2} {
+		requireBlockStorePut(t, serverBS, blk.ToNode())
+	}
+
+	fetcher.AddPeer(server.ID())
+	fetched, err := fetcher.GetBlocks(ctx, []cid.Cid{block2.Cid()})
+	require.NoError(t, err)
+	require.Len(t, fetched, 1)
+	assert.True(t, block2.Equals(fetched[0]))
+
+	// the ancestors came along in the same request
+	for _, blk := range []*types.Block{genesis, block1} {
+		has, err := clientBS.Has(blk.Cid())
+		require.NoError(t, err)
+		assert.True(t, has)
+	}
+}
+
+func TestChainExchangeFetchesState(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server, serverBS, fetcher, clientBS := newChainExchangePeers(ctx, t)
+
+	cst := hamt.CborIpldStore{Blocks: bserv.New(serverBS, offline.Exchange(serverBS))}
+	leaf, err := cst.Put(ctx, map[string]string{"actor": "state"})
+	require.NoError(t, err)
+	root, err := cst.Put(ctx, map[string]cid.Cid{"leaf": leaf})
+	require.NoError(t, err)
+
+	fetcher.AddPeer(server.ID())
+	require.NoError(t, fetcher.FetchState(ctx, root))
+
+	has, err := clientBS.Has(leaf)
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestChainExchangeFallsBackWithoutPeers(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server, _, fetcher, _ := newChainExchangePeers(ctx, t)
+
+	// the server doesn't have the block, and neither has bitswap
+	fetcher.AddPeer(server.ID())
+	block := types.NewBlockForTest(nil, 0)
+
+	getCtx, getCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer getCancel()
+	_, err := fetcher.GetBlocks(getCtx, []cid.Cid{block.Cid()})
+	assert.Error(t, err)
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/ipfs/go-block-format"
 	bserv "github.com/ipfs/go-blockservice"
 	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/types"
@@ -17,13 +19,16 @@ import (
 type Fetcher struct {
 	// session is a bitswap session that enables efficient transfer.
 	session *bserv.Session
+	// dagService fetches whole dags, such as state trees.
+	dagService ipld.DAGService
 }
 
 // NewFetcher returns a Fetcher wired up to the input BlockService and a newly
 // initialized persistent session of the block service.
 func NewFetcher(ctx context.Context, bsrv bserv.BlockService) *Fetcher {
 	return &Fetcher{
-		session: bserv.NewSession(ctx, bsrv),
+		session:    bserv.NewSession(ctx, bsrv),
+		dagService: dag.NewDAGService(bsrv),
 	}
 }
 
@@ -55,3 +60,9 @@ func (f *Fetcher) GetBlocks(ctx context.Context, cids []cid.Cid) ([]*types.Block
 	}
 	return blocks, nil
 }
+
+// FetchState fetches the state tree with the given root from the network,
+// adding it to the blockstore of the Fetcher's block service.
+func (f *Fetcher) FetchState(ctx context.Context, root cid.Cid) error {
+	return dag.FetchGraph(ctx, root, f.dagService)
+}
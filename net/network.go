@@ -6,8 +6,10 @@ import (
 	"sort"
 	"sync"
 
+	"github.com/libp2p/go-libp2p-autonat"
 	"github.com/libp2p/go-libp2p-host"
 	"github.com/libp2p/go-libp2p-metrics"
+	inet "github.com/libp2p/go-libp2p-net"
 	"github.com/libp2p/go-libp2p-peer"
 	"github.com/libp2p/go-libp2p-peerstore"
 	"github.com/libp2p/go-libp2p-swarm"
@@ -19,11 +21,13 @@ import (
 
 // SwarmConnInfo represents details about a single swarm connection.
 type SwarmConnInfo struct {
-	Addr    string
-	Peer    string
-	Latency string
-	Muxer   string
-	Streams []SwarmStreamInfo
+	Addr      string
+	Peer      string
+	Agent     string
+	Direction string
+	Latency   string
+	Muxer     string
+	Streams   []SwarmStreamInfo
 }
 
 // SwarmStreamInfo represents details about a single swarm stream.
@@ -62,12 +66,14 @@ func (ci SwarmConnInfos) Swap(i, j int) {
 
 // Network is a unified interface for dealing with libp2p
 type Network struct {
-	host host.Host
+	host      host.Host
+	bandwidth *BandwidthTracker
+	nat       autonat.AutoNAT
 	*pubsub.Subscriber
 	*pubsub.Publisher
-	metrics.Reporter
 	*Router
 	*Pinger
+	*PeerBans
 }
 
 // New returns a new Network
@@ -76,14 +82,18 @@ func New(
 	publisher *pubsub.Publisher,
 	subscriber *pubsub.Subscriber,
 	router *Router,
-	reporter metrics.Reporter,
+	bandwidth *BandwidthTracker,
 	pinger *Pinger,
+	bans *PeerBans,
+	nat autonat.AutoNAT,
 ) *Network {
 	return &Network{
 		host:       host,
+		bandwidth:  bandwidth,
+		nat:        nat,
 		Pinger:     pinger,
+		PeerBans:   bans,
 		Publisher:  publisher,
-		Reporter:   reporter,
 		Router:     router,
 		Subscriber: subscriber,
 	}
@@ -101,7 +111,41 @@ func (network *Network) GetPeerID() peer.ID {
 
 // GetBandwidthStats gets stats on the current bandwidth usage of the network
 func (network *Network) GetBandwidthStats() metrics.Stats {
-	return network.Reporter.GetBandwidthTotals()
+	return network.bandwidth.GetBandwidthTotals()
+}
+
+// GetBandwidthByProtocol gets stats on the bandwidth used by each protocol
+func (network *Network) GetBandwidthByProtocol() []ProtocolBandwidth {
+	return network.bandwidth.ByProtocol()
+}
+
+// Reachability values report whether the node can be dialed by its peers.
+const (
+	ReachabilityUnknown = "unknown"
+	ReachabilityPublic  = "public"
+	ReachabilityPrivate = "private"
+)
+
+// GetReachability reports whether the node can be dialed by its peers, as
+// detected by AutoNAT, and the address it is dialed at if so. Nodes that
+// aren't are dialed through relays, and announce relay addresses.
+func (network *Network) GetReachability() (string, ma.Multiaddr) {
+	if network.nat == nil {
+		return ReachabilityUnknown, nil
+	}
+
+	switch network.nat.Status() {
+	case autonat.NATStatusPublic:
+		addr, err := network.nat.PublicAddr()
+		if err != nil {
+			return ReachabilityPublic, nil
+		}
+		return ReachabilityPublic, addr
+	case autonat.NATStatusPrivate:
+		return ReachabilityPrivate, nil
+	default:
+		return ReachabilityUnknown, nil
+	}
 }
 
 // ConnectionResult represents the result of an attempted connection from the
@@ -162,8 +206,13 @@ func (network *Network) Peers(ctx context.Context, verbose, latency, streams boo
 		addr := c.RemoteMultiaddr()
 
 		ci := SwarmConnInfo{
-			Addr: addr.String(),
-			Peer: pid.Pretty(),
+			Addr:      addr.String(),
+			Peer:      pid.Pretty(),
+			Direction: directionString(c.Stat().Direction),
+		}
+
+		if agent, err := network.host.Peerstore().Get(pid, "AgentVersion"); err == nil {
+			ci.Agent, _ = agent.(string)
 		}
 
 		if verbose || latency {
@@ -188,3 +237,68 @@ func (network *Network) Peers(ctx context.Context, verbose, latency, streams boo
 	sort.Sort(&out)
 	return &out, nil
 }
+
+// Disconnect closes the connections to the given peers, given by peer ID or
+// by address.
+func (network *Network) Disconnect(peers []string) ([]ConnectionResult, error) {
+	pids := make([]peer.ID, len(peers))
+	for i, p := range peers {
+		pid, err := peer.IDB58Decode(p)
+		if err != nil {
+			pis, err := PeerAddrsToPeerInfos([]string{p})
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s is neither a peer ID nor a peer address", p)
+			}
+			pid = pis[0].ID
+		}
+		pids[i] = pid
+	}
+
+	results := make([]ConnectionResult, len(pids))
+	for i, pid := range pids {
+		var err error
+		if network.host.Network().Connectedness(pid) != inet.Connected {
+			err = errors.New("not connected")
+		} else {
+			err = network.host.Network().ClosePeer(pid)
+		}
+		results[i] = ConnectionResult{PeerID: pid, Err: err}
+	}
+	return results, nil
+}
+
+// PeerConnectivity describes how a peer can be reached.
+type PeerConnectivity struct {
+	Peer      string
+	Addrs     []string
+	Connected bool
+}
+
+// LookupPeer finds the addresses of a peer with the router and reports
+// whether the node is connected to it.
+func (network *Network) LookupPeer(ctx context.Context, pid peer.ID) (*PeerConnectivity, error) {
+	pi, err := network.FindPeer(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &PeerConnectivity{
+		Peer:      pid.Pretty(),
+		Connected: network.host.Network().Connectedness(pid) == inet.Connected,
+	}
+	for _, addr := range pi.Addrs {
+		out.Addrs = append(out.Addrs, addr.String())
+	}
+	return out, nil
+}
+
+func directionString(dir inet.Direction) string {
+	switch dir {
+	case inet.DirInbound:
+		return "inbound"
+	case inet.DirOutbound:
+		return "outbound"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,128 @@
+package net
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-host"
+	inet "github.com/libp2p/go-libp2p-net"
+	"github.com/libp2p/go-libp2p-peer"
+)
+
+var logPeerBans = logging.Logger("net.peerbans")
+
+// InvalidChainsBeforeBan is the number of invalid chains a peer may send
+// before it is banned.
+const InvalidChainsBeforeBan = 3
+
+// BanDuration is how long a peer stays banned.
+const BanDuration = time.Hour
+
+// PeerBan describes a banned peer.
+type PeerBan struct {
+	Peer  string
+	Until time.Time
+}
+
+// PeerBans bans the peers that repeatedly send the node chains breaking the
+// rules of consensus. The node drops its connections to banned peers and
+// refuses new ones until the ban expires. Bans are only kept in memory.
+type PeerBans struct {
+	host host.Host
+	now  func() time.Time
+
+	lk      sync.Mutex
+	strikes map[peer.ID]int
+	bans    map[peer.ID]time.Time
+}
+
+// NewPeerBans returns a new PeerBans closing the connections of banned peers
+// to the host.
+func NewPeerBans(h host.Host) *PeerBans {
+	pb := &PeerBans{
+		host:    h,
+		now:     time.Now,
+		strikes: make(map[peer.ID]int),
+		bans:    make(map[peer.ID]time.Time),
+	}
+	h.Network().Notify(&inet.NotifyBundle{
+		ConnectedF: func(_ inet.Network, c inet.Conn) {
+			if pb.IsBanned(c.RemotePeer()) {
+				logPeerBans.Debugf("closing connection of banned peer %s", c.RemotePeer())
+				go c.Close() // nolint: errcheck
+			}
+		},
+	})
+	return pb
+}
+
+// ReportInvalidChain records that p sent an invalid chain and bans p once it
+// has sent InvalidChainsBeforeBan of them. It returns whether p is banned.
+func (pb *PeerBans) ReportInvalidChain(p peer.ID) bool {
+	pb.lk.Lock()
+	pb.strikes[p]++
+	banned := pb.strikes[p] >= InvalidChainsBeforeBan
+	if banned {
+		delete(pb.strikes, p)
+		pb.bans[p] = pb.now().Add(BanDuration)
+	}
+	pb.lk.Unlock()
+
+	if banned {
+		logPeerBans.Warningf("banning peer %s for sending %d invalid chains", p, InvalidChainsBeforeBan)
+		if err := pb.host.Network().ClosePeer(p); err != nil {
+			logPeerBans.Warningf("failed to disconnect banned peer %s: %s", p, err)
+		}
+	}
+	return banned
+}
+
+// IsBanned returns whether p is banned.
+func (pb *PeerBans) IsBanned(p peer.ID) bool {
+	pb.lk.Lock()
+	defer pb.lk.Unlock()
+	until, ok := pb.bans[p]
+	if !ok {
+		return false
+	}
+	if !pb.now().Before(until) {
+		delete(pb.bans, p)
+		return false
+	}
+	return true
+}
+
+// Bans lists the banned peers, sorted by peer ID.
+func (pb *PeerBans) Bans() []PeerBan {
+	pb.lk.Lock()
+	defer pb.lk.Unlock()
+	now := pb.now()
+	out := []PeerBan{}
+	for p, until := range pb.bans {
+		if !now.Before(until) {
+			delete(pb.bans, p)
+			continue
+		}
+		out = append(out, PeerBan{Peer: p.Pretty(), Until: until})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Peer < out[j].Peer })
+	return out
+}
+
+// ClearBans lifts the bans of the given peers, or of all peers if none are
+// given, and forgets the invalid chains they sent.
+func (pb *PeerBans) ClearBans(peers ...peer.ID) {
+	pb.lk.Lock()
+	defer pb.lk.Unlock()
+	if len(peers) == 0 {
+		pb.strikes = make(map[peer.ID]int)
+		pb.bans = make(map[peer.ID]time.Time)
+		return
+	}
+	for _, p := range peers {
+		delete(pb.strikes, p)
+		delete(pb.bans, p)
+	}
+}
@@ -0,0 +1,70 @@
+package net
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	inet "github.com/libp2p/go-libp2p-net"
+	"github.com/libp2p/go-libp2p-peer"
+	"github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestPeerBans(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mn, err := mocknet.WithNPeers(ctx, 2)
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+	require.NoError(t, mn.ConnectAllButSelf())
+	h, other := mn.Hosts()[0], mn.Hosts()[1]
+
+	now := time.Unix(1000, 0)
+	pb := NewPeerBans(h)
+	pb.now = func() time.Time { return now }
+
+	t.Run("bans peers after repeated invalid chains", func(t *testing.T) {
+		for i := 1; i < InvalidChainsBeforeBan; i++ {
+			assert.False(t, pb.ReportInvalidChain(other.ID()))
+		}
+		assert.False(t, pb.IsBanned(other.ID()))
+		assert.Equal(t, inet.Connected, h.Network().Connectedness(other.ID()))
+
+		assert.True(t, pb.ReportInvalidChain(other.ID()))
+		assert.True(t, pb.IsBanned(other.ID()))
+		assert.NotEqual(t, inet.Connected, h.Network().Connectedness(other.ID()))
+		assert.Equal(t, []PeerBan{{Peer: other.ID().Pretty(), Until: now.Add(BanDuration)}}, pb.Bans())
+	})
+
+	t.Run("bans expire", func(t *testing.T) {
+		now = now.Add(BanDuration)
+		assert.False(t, pb.IsBanned(other.ID()))
+		assert.Empty(t, pb.Bans())
+	})
+
+	t.Run("clears bans", func(t *testing.T) {
+		somePeer := peer.ID("somepeer")
+		for i := 0; i < InvalidChainsBeforeBan; i++ {
+			pb.ReportInvalidChain(other.ID())
+			pb.ReportInvalidChain(somePeer)
+		}
+		require.Len(t, pb.Bans(), 2)
+
+		pb.ClearBans(somePeer)
+		assert.False(t, pb.IsBanned(somePeer))
+		assert.True(t, pb.IsBanned(other.ID()))
+
+		pb.ClearBans()
+		assert.Empty(t, pb.Bans())
+
+		// Strikes are forgotten too.
+		assert.False(t, pb.ReportInvalidChain(somePeer))
+	})
+}
@@ -3,10 +3,15 @@ package node
 import (
 	"context"
 
+	"github.com/libp2p/go-libp2p-peer"
+	libp2pps "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/pkg/errors"
 	"go.opencensus.io/trace"
 
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/core"
 	"github.com/filecoin-project/go-filecoin/metrics/tracing"
+	"github.com/filecoin-project/go-filecoin/net"
 	"github.com/filecoin-project/go-filecoin/net/pubsub"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -40,7 +45,11 @@ func (node *Node) AddNewBlock(ctx context.Context, b *types.Block) (err error) {
 
 func (node *Node) processBlock(ctx context.Context, pubSubMsg pubsub.Message) (err error) {
 	// ignore messages from ourself
-	if pubSubMsg.GetFrom() == node.Host().ID() {
+	from := pubSubMsg.GetFrom()
+	if from == node.Host().ID() {
+		return nil
+	}
+	if node.PeerBans.IsBanned(from) {
 		return nil
 	}
 
@@ -53,13 +62,38 @@ func (node *Node) processBlock(ctx context.Context, pubSubMsg pubsub.Message) (e
 	}
 	span.AddAttributes(trace.StringAttribute("block", blk.Cid().String()))
 
-	log.Infof("Received new block from network cid: %s", blk.Cid().String())
+	latency := node.BlockLatency.Record(ctx, blk)
+	log.Infof("Received new block from network cid: %s, %s after its timestamp", blk.Cid().String(), latency)
 	log.Debugf("Received new block from network: %s", blk)
 
 	err = node.Syncer.HandleNewTipset(ctx, types.NewSortedCidSet(blk.Cid()))
 	if err != nil {
+		if chain.IsBadChainError(err) {
+			node.PeerBans.ReportInvalidChain(from)
+		}
 		return errors.Wrap(err, "processing block from network")
 	}
 
 	return nil
 }
+
+// blockTopicValidator adapts a block topic validator to libp2p pubsub. Blocks
+// published by this node were mined or validated by it and pass unchecked,
+// blocks from banned peers are rejected.
+func blockTopicValidator(self peer.ID, bans *net.PeerBans, v *core.BlockTopicValidator) libp2pps.Validator {
+	return func(ctx context.Context, pubSubMsg *libp2pps.Message) bool {
+		from := pubSubMsg.GetFrom()
+		if from == self {
+			return true
+		}
+		if bans.IsBanned(from) {
+			log.Debugf("Rejected block from banned peer %s", from)
+			return false
+		}
+		if err := v.Validate(ctx, from, pubSubMsg.GetData()); err != nil {
+			log.Debugf("Rejected block from peer %s: %s", from, err)
+			return false
+		}
+		return true
+	}
+}
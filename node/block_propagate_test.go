@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/protocol/storage"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/testhelpers"
@@ -38,10 +39,6 @@ func TestBlockPropsManyNodes(t *testing.T) {
 	numNodes := 4
 	minerAddr, nodes := makeNodes(t, numNodes)
 
-	// Now add 10 null blocks and 1 tipset.
-	signer, ki := types.NewMockSignersAndKeyInfo(1)
-	mockSignerPubKey := ki[0].PublicKey()
-
 	StartNodes(t, nodes)
 	defer StopNodes(nodes)
 
@@ -56,20 +53,19 @@ func TestBlockPropsManyNodes(t *testing.T) {
 	require.NoError(t, err)
 	baseTS := headTipSetAndState.TipSet
 	require.NotNil(t, baseTS)
-	proof := testhelpers.MakeRandomPoSTProofForTest()
 
-	ticket, err := signer.CreateTicket(proof, mockSignerPubKey)
-	require.NoError(t, err)
+	// Now add 10 null blocks and 1 tipset.
+	proof := testhelpers.MakeRandomPoSTProofForTest()
 
 	nextBlk := &types.Block{
 		Miner:        minerAddr,
 		Parents:      baseTS.ToSortedCidSet(),
 		Height:       types.Uint64(1),
-		ParentWeight: types.Uint64(10000),
+		ParentWeight: types.Uint64(0),
 		StateRoot:    baseTS.ToSlice()[0].StateRoot,
 		Proof:        proof,
-		Ticket:       ticket,
 	}
+	mineWithMinerKey(ctx, t, minerNode, baseTS, nextBlk)
 
 	// Wait for network connection notifications to propagate
 	time.Sleep(time.Millisecond * 300)
@@ -113,6 +109,7 @@ func TestChainSync(t *testing.T) {
 	nextBlk1 := testhelpers.NewValidTestBlockFromTipSet(baseTS, stateRoot, 1, minerAddr, mockSignerPubKey, signer)
 	nextBlk2 := testhelpers.NewValidTestBlockFromTipSet(baseTS, stateRoot, 2, minerAddr, mockSignerPubKey, signer)
 	nextBlk3 := testhelpers.NewValidTestBlockFromTipSet(baseTS, stateRoot, 3, minerAddr, mockSignerPubKey, signer)
+	mineWithMinerKey(ctx, t, nodes[0], baseTS, nextBlk1, nextBlk2, nextBlk3)
 
 	assert.NoError(t, nodes[0].AddNewBlock(ctx, nextBlk1))
 	assert.NoError(t, nodes[0].AddNewBlock(ctx, nextBlk2))
@@ -144,6 +141,22 @@ func (r *ZeroRewarder) GasReward(ctx context.Context, st state.Tree, minerAddr a
 }
 
 // makeNodes makes at least two nodes, a miner and a client; numNodes is the total wanted
+// mineWithMinerKey draws the tickets of blks, children of parents, and signs
+// them with the worker key of the miner nd mines for, which makeNodes gave to
+// nd.
+func mineWithMinerKey(ctx context.Context, t *testing.T, nd *Node, parents types.TipSet, blks ...*types.Block) {
+	t.Helper()
+	parentHeight, err := parents.Height()
+	require.NoError(t, err)
+	for _, blk := range blks {
+		minerPubKey, err := nd.PorcelainAPI.MinerGetKey(ctx, blk.Miner)
+		require.NoError(t, err)
+		blk.Ticket, err = consensus.CreateTicket(parents, uint64(blk.Height)-parentHeight-1, minerPubKey, nd.Wallet)
+		require.NoError(t, err)
+		require.NoError(t, consensus.SignBlock(blk, minerPubKey, nd.Wallet))
+	}
+}
+
 func makeNodes(t *testing.T, numNodes int) (address.Address, []*Node) {
 	seed := MakeChainSeed(t, TestGenCfg)
 	configOpts := []ConfigOpt{RewarderConfigOption(&ZeroRewarder{})}
@@ -0,0 +1,48 @@
+package node
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/paths"
+	"github.com/filecoin-project/go-filecoin/protocol/storage"
+	"github.com/filecoin-project/go-filecoin/repo"
+)
+
+// CheckRepo checks the integrity of the chain store and of the storage
+// miner's sector metadata in r, which must not be in use by a running node.
+// With repair set it repairs what it can, see chain.CheckStore and
+// storage.CheckSectorMetadata.
+func CheckRepo(ctx context.Context, r repo.Repo, repair bool) ([]*repo.Problem, error) {
+	genCid, err := readGenesisCid(r.Datastore())
+	if err != nil {
+		return nil, err
+	}
+
+	problems, err := chain.CheckStore(ctx, r.ChainDatastore(), genCid, repair)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check chain store")
+	}
+
+	repoPath, err := r.Path()
+	if err != nil {
+		return nil, err
+	}
+	sectorDir, err := paths.GetSectorPath(r.Config().SectorBase.RootDir, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	sealedDir, err := paths.SealedDir(sectorDir)
+	if err != nil {
+		return nil, err
+	}
+
+	sectorProblems, err := storage.CheckSectorMetadata(r.DealsDatastore(), sealedDir, repair)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check sector metadata")
+	}
+
+	return append(problems, sectorProblems...), nil
+}
@@ -17,12 +17,17 @@ func OptionsFromRepo(r repo.Repo) ([]ConfigOpt, error) {
 	}
 
 	cfg := r.Config()
+	libp2pOpts := []libp2p.Option{
+		libp2p.ListenAddrStrings(cfg.Swarm.Address),
+		libp2p.Identity(sk),
+	}
+	if cfg.Swarm.EnableNATPortMap {
+		libp2pOpts = append(libp2pOpts, libp2p.NATPortMap())
+	}
+
 	cfgopts := []ConfigOpt{
 		// Libp2pOptions can only be called once, so add all options here.
-		Libp2pOptions(
-			libp2p.ListenAddrStrings(cfg.Swarm.Address),
-			libp2p.Identity(sk),
-		),
+		Libp2pOptions(libp2pOpts...),
 	}
 
 	dsopt := func(c *Config) error {
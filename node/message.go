@@ -3,6 +3,10 @@ package node
 import (
 	"context"
 
+	"github.com/libp2p/go-libp2p-peer"
+	libp2pps "github.com/libp2p/go-libp2p-pubsub"
+
+	"github.com/filecoin-project/go-filecoin/core"
 	"github.com/filecoin-project/go-filecoin/net/pubsub"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -24,3 +28,20 @@ func (node *Node) processMessage(ctx context.Context, pubSubMsg pubsub.Message)
 	_, err = node.MsgPool.Add(ctx, unmarshaled)
 	return err
 }
+
+// messageTopicValidator adapts a message topic validator to libp2p pubsub.
+// Messages published by this node were validated when they were sent and
+// pass unchecked.
+func messageTopicValidator(self peer.ID, v *core.MessageTopicValidator) libp2pps.Validator {
+	return func(ctx context.Context, pubSubMsg *libp2pps.Message) bool {
+		from := pubSubMsg.GetFrom()
+		if from == self {
+			return true
+		}
+		if err := v.Validate(ctx, from, pubSubMsg.GetData()); err != nil {
+			log.Debugf("Rejected message from peer %s: %s", from, err)
+			return false
+		}
+		return true
+	}
+}
@@ -2,9 +2,11 @@ package node
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,18 +24,20 @@ import (
 	logging "github.com/ipfs/go-log"
 	"github.com/ipfs/go-merkledag"
 	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-autonat"
 	autonatsvc "github.com/libp2p/go-libp2p-autonat-svc"
 	circuit "github.com/libp2p/go-libp2p-circuit"
 	"github.com/libp2p/go-libp2p-host"
 	"github.com/libp2p/go-libp2p-kad-dht"
 	"github.com/libp2p/go-libp2p-kad-dht/opts"
-	p2pmetrics "github.com/libp2p/go-libp2p-metrics"
 	libp2ppeer "github.com/libp2p/go-libp2p-peer"
+	pstore "github.com/libp2p/go-libp2p-peerstore"
 	dhtprotocol "github.com/libp2p/go-libp2p-protocol"
 	libp2pps "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p-routing"
 	rhost "github.com/libp2p/go-libp2p/p2p/host/routed"
 	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+	"github.com/mitchellh/go-homedir"
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/pkg/errors"
 
@@ -50,14 +54,17 @@ import (
 	"github.com/filecoin-project/go-filecoin/net"
 	"github.com/filecoin-project/go-filecoin/net/pubsub"
 	"github.com/filecoin-project/go-filecoin/paths"
+	"github.com/filecoin-project/go-filecoin/paymentchannel"
 	"github.com/filecoin-project/go-filecoin/plumbing"
 	"github.com/filecoin-project/go-filecoin/plumbing/bcf"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
 	"github.com/filecoin-project/go-filecoin/plumbing/dag"
 	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/plumbing/strgdls"
+	"github.com/filecoin-project/go-filecoin/plumbing/vchrs"
 	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/proofs"
+	"github.com/filecoin-project/go-filecoin/proofs/params"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
 	"github.com/filecoin-project/go-filecoin/protocol/block"
 	"github.com/filecoin-project/go-filecoin/protocol/hello"
@@ -66,7 +73,9 @@ import (
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/sampling"
 	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/timesync"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
 	"github.com/filecoin-project/go-filecoin/wallet"
 )
 
@@ -110,6 +119,12 @@ type Node struct {
 	MsgPool *core.MessagePool
 	// Messages sent and not yet mined.
 	Outbox *core.MessageQueue
+	// What became of the messages sent.
+	MessageJournal *core.MessageJournal
+	// Broadcasts again the messages sent and not yet mined.
+	rebroadcaster *msg.Rebroadcaster
+	// Indexes of the messages on chain.
+	msgIndexer *msg.Indexer
 
 	Wallet *wallet.Wallet
 
@@ -130,16 +145,33 @@ type Node struct {
 	miningDoneWg *sync.WaitGroup
 
 	// Storage Market Interfaces
-	StorageMiner *storage.Miner
+	StorageMiner  *storage.Miner
+	StorageClient *storage.Client
 
 	// Retrieval Interfaces
 	RetrievalMiner *retrieval.Miner
 
+	// PaychWatcher disputes the cancellation of the payment channels the
+	// node is the target of, if configured to.
+	PaychWatcher *paymentchannel.Watcher
+
 	// Network Fields
 	BlockSub     pubsub.Subscription
 	MessageSub   pubsub.Subscription
 	HelloSvc     *hello.Handler
 	Bootstrapper *net.Bootstrapper
+	// PeerBans bans the peers sending invalid chains.
+	PeerBans *net.PeerBans
+	// BlockLatency records how late blocks arrive from the network.
+	BlockLatency *metrics.BlockLatencyTracker
+
+	// TimeSync checks the local clock against an NTP server
+	TimeSync *timesync.Checker
+
+	// Pruner discards the states of old tipsets.
+	Pruner *chain.Pruner
+	// prunePeriod is how often the node prunes old states, if configured to.
+	prunePeriod time.Duration
 
 	// Data Storage Fields
 
@@ -152,12 +184,18 @@ type Node struct {
 
 	// Fetcher is the interface for fetching data from nodes.
 	Fetcher *net.Fetcher
+	// ChainFetcher fetches the chain for the syncer from the peers that told
+	// about their heads.
+	ChainFetcher *net.ChainExchangeFetcher
 
 	// Exchange is the interface for fetching data from other nodes.
 	Exchange exchange.Interface
 
 	// Blockstore is the un-networked blocks interface
 	Blockstore bstore.Blockstore
+	// VMBlockstore is the Blockstore with a cache of the actor state read
+	// while applying messages.
+	VMBlockstore *vm.CachedBlockstore
 
 	// Blockservice is a higher level interface for fetching data
 	blockservice bserv.BlockService
@@ -184,6 +222,9 @@ type Config struct {
 	Rewarder    consensus.BlockRewarder
 	Repo        repo.Repo
 	IsRelay     bool
+	// Libp2pHost, if set, is the host the node uses instead of building its
+	// own from Libp2pOpts.
+	Libp2pHost host.Host
 }
 
 // ConfigOpt is a configuration option for a filecoin node.
@@ -205,6 +246,15 @@ func IsRelay() ConfigOpt {
 	}
 }
 
+// Libp2pHost makes the node use the given libp2p host, such as a host of a
+// mock network in tests, instead of building its own.
+func Libp2pHost(h host.Host) ConfigOpt {
+	return func(c *Config) error {
+		c.Libp2pHost = h
+		return nil
+	}
+}
+
 // BlockTime sets the blockTime.
 func BlockTime(blockTime time.Duration) ConfigOpt {
 	return func(c *Config) error {
@@ -337,8 +387,9 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 
 	var peerHost host.Host
 	var router routing.IpfsRouting
+	var nat autonat.AutoNAT
 
-	bandwidthTracker := p2pmetrics.NewBandwidthCounter()
+	bandwidthTracker := net.NewBandwidthTracker()
 	nc.Libp2pOpts = append(nc.Libp2pOpts, libp2p.BandwidthReporter(bandwidthTracker))
 
 	if !nc.OfflineMode {
@@ -358,10 +409,21 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 		}
 
 		var err error
-		peerHost, err = nc.buildHost(ctx, makeDHT)
-		if err != nil {
-			return nil, err
+		if nc.Libp2pHost != nil {
+			peerHost = nc.Libp2pHost
+			if _, err := makeDHT(peerHost); err != nil {
+				return nil, err
+			}
+		} else {
+			peerHost, err = nc.buildHost(ctx, makeDHT)
+			if err != nil {
+				return nil, err
+			}
 		}
+
+		// AutoNAT asks peers running the AutoNAT service, like relays, to
+		// dial back the node to find out whether it is reachable.
+		nat = autonat.NewAutoNAT(ctx, peerHost, nil)
 	} else {
 		router = offroute.NewOfflineRouter(nc.Repo.Datastore(), validator)
 		peerHost = rhost.Wrap(noopLibP2PHost{}, router)
@@ -370,12 +432,17 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 	// set up pinger
 	pingService := ping.NewPingService(peerHost)
 
+	// set up the bans of peers sending invalid chains
+	peerBans := net.NewPeerBans(peerHost)
+
 	// set up bitswap
 	nwork := bsnet.NewFromIpfsHost(peerHost, router)
 	//nwork := bsnet.NewFromIpfsHost(innerHost, router)
 	bswap := bitswap.New(ctx, nwork, bs)
 	bservice := bserv.New(bs, bswap)
 	fetcher := net.NewFetcher(ctx, bservice)
+	chainFetcher := net.NewChainExchangeFetcher(peerHost, bs, fetcher)
+	net.NewChainExchangeServer(peerHost, bs)
 
 	cstOffline := hamt.CborIpldStore{Blocks: bserv.New(bs, offline.Exchange(bs))}
 	genCid, err := readGenesisCid(nc.Repo.Datastore())
@@ -387,52 +454,120 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 	chainStore := chain.NewDefaultStore(nc.Repo.ChainDatastore(), &cstOffline, genCid)
 	powerTable := &consensus.MarketView{}
 
-	// set up processor
-	var processor consensus.Processor
-	if nc.Rewarder == nil {
-		processor = consensus.NewDefaultProcessor()
-	} else {
-		processor = consensus.NewConfiguredProcessor(consensus.NewDefaultMessageValidator(), nc.Rewarder)
+	// set up processor, running the protocol versions of the network
+	network := consensus.NetworkByName(nc.Repo.Config().Net)
+	rewarder := nc.Rewarder
+	if rewarder == nil {
+		rewarder = consensus.NewDefaultBlockRewarder()
 	}
+	processor := consensus.NewUpgradingProcessor(consensus.NewDefaultMessageValidator(), rewarder, network.ProtocolVersions, consensus.DefaultActorUpgrades)
+
+	// Message application reads the same nodes of actor state for each
+	// message, whether validating or mining blocks.
+	vmBlockstore := vm.NewCachedBlockstore(bs, vm.DefaultBlockCacheSize)
 
 	// set up consensus
+	weigher, err := consensus.NewWeigher(nc.Repo.Config().Sync.ChainWeight, bs, powerTable, genCid)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to set up chain weight")
+	}
+	var epochClock *consensus.EpochClock
+	if network.BlockTime > 0 {
+		genesis, err := chainStore.GetBlock(ctx, genCid)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load the genesis block")
+		}
+		epochClock = &consensus.EpochClock{GenesisTime: uint64(genesis.Timestamp), BlockTime: network.BlockTime}
+	}
 	var nodeConsensus consensus.Protocol
 	if nc.Verifier == nil {
-		nodeConsensus = consensus.NewExpected(&cstOffline, bs, processor, powerTable, genCid, &proofs.RustVerifier{})
+		nodeConsensus = consensus.NewExpectedWithWeigher(&cstOffline, vmBlockstore, processor, powerTable, genCid, &proofs.RustVerifier{}, weigher, network.ProtocolVersions, epochClock)
 	} else {
-		nodeConsensus = consensus.NewExpected(&cstOffline, bs, processor, powerTable, genCid, nc.Verifier)
+		nodeConsensus = consensus.NewExpectedWithWeigher(&cstOffline, vmBlockstore, processor, powerTable, genCid, nc.Verifier, weigher, network.ProtocolVersions, epochClock)
 	}
 
 	// only the syncer gets the storage which is online connected
-	chainSyncer := chain.NewDefaultSyncer(&cstOffline, nodeConsensus, chainStore, fetcher)
-	msgPool := core.NewMessagePool(chainStore, nc.Repo.Config().Mpool, consensus.NewIngestionValidator(chainStore, nc.Repo.Config().Mpool))
+	chainSyncer := chain.NewDefaultSyncer(&cstOffline, nodeConsensus, chainStore, chainFetcher)
+	if syncCfg := nc.Repo.Config().Sync; syncCfg.CheckpointTipSet != "" {
+		checkpoint, err := chain.ParseCheckpoint(syncCfg.CheckpointTipSet, syncCfg.CheckpointStateRoot)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to set up sync checkpoint")
+		}
+		chainSyncer.UseCheckpoint(checkpoint, chainFetcher)
+	}
+	finality := consensus.FinalityRounds
+	if syncFinality := nc.Repo.Config().Sync.Finality; syncFinality != 0 {
+		finality = syncFinality
+		chainSyncer.SetFinality(finality)
+	}
+	if workers := nc.Repo.Config().Sync.ValidationWorkers; workers != 0 {
+		chainSyncer.SetValidationWorkers(int(workers))
+	}
+	pruner := chain.NewPruner(bs, chainStore, finality)
+	blockLatency := metrics.NewBlockLatencyTracker(metrics.DefaultBlockLatencyWindow)
+	ingestionValidator := consensus.NewIngestionValidator(chainStore, nc.Repo.Config().Mpool)
+	msgPool := core.NewMessagePool(chainStore, nc.Repo.Config().Mpool, ingestionValidator)
 	outbox := core.NewMessageQueue()
+	msgJournal := core.NewMessageJournal()
 
 	// Set up libp2p pubsub
 	fsub, err := libp2pps.NewFloodSub(ctx, peerHost)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to set up pubsub")
 	}
+	msgTopicValidator := core.NewMessageTopicValidator(ingestionValidator, nc.Repo.Config().Mpool)
+	if err := fsub.RegisterTopicValidator(msg.Topic, messageTopicValidator(peerHost.ID(), msgTopicValidator)); err != nil {
+		return nil, errors.Wrap(err, "failed to register message topic validator")
+	}
+	blkTopicValidator := core.NewBlockTopicValidator(chainStore, consensus.NewTicketValidator(&cstOffline, bs, powerTable), network.ProtocolVersions)
+	if err := fsub.RegisterTopicValidator(BlockTopic, blockTopicValidator(peerHost.ID(), peerBans, blkTopicValidator)); err != nil {
+		return nil, errors.Wrap(err, "failed to register block topic validator")
+	}
 	backend, err := wallet.NewDSBackend(nc.Repo.WalletDatastore())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to set up wallet backend")
 	}
-	fcWallet := wallet.New(backend)
+	backends := []wallet.Backend{backend}
+	for _, path := range nc.Repo.Config().Wallet.ExternalSigners {
+		external, err := wallet.NewExternalBackend(wallet.NewSocketTransport(path))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to set up external signer %s", path)
+		}
+		backends = append(backends, external)
+	}
+	fcWallet := wallet.New(backends...)
+
+	// index the messages on chain so that they are found without a search
+	// of the chain
+	msgIndexer := msg.NewIndexer(chainStore, nc.Repo.ChainDatastore())
+	msgWaiter := msg.NewWaiter(chainStore, bs, &cstOffline, nc.Repo.Config().Mpool.WaitConfidence)
+	msgWaiter.UseIndex(msgIndexer)
 
 	PorcelainAPI := porcelain.New(plumbing.New(&plumbing.APIDeps{
-		Bitswap:      bswap,
-		Chain:        bcf.NewBlockChainFacade(chainStore, &cstOffline),
-		Config:       cfg.NewConfig(nc.Repo),
-		DAG:          dag.NewDAG(merkledag.NewDAGService(bservice)),
-		Deals:        strgdls.New(nc.Repo.DealsDatastore()),
-		MsgPool:      msgPool,
-		MsgPreviewer: msg.NewPreviewer(fcWallet, chainStore, &cstOffline, bs),
-		MsgQueryer:   msg.NewQueryer(nc.Repo, fcWallet, chainStore, &cstOffline, bs),
-		MsgSender:    msg.NewSender(fcWallet, chainStore, &cstOffline, chainStore, outbox, msgPool, consensus.NewOutboundMessageValidator(), fsub.Publish),
-		MsgWaiter:    msg.NewWaiter(chainStore, bs, &cstOffline),
-		Network:      net.New(peerHost, pubsub.NewPublisher(fsub), pubsub.NewSubscriber(fsub), net.NewRouter(router), bandwidthTracker, net.NewPinger(peerHost, pingService)),
-		Outbox:       outbox,
-		Wallet:       fcWallet,
+		Bitswap:       bswap,
+		BlockLatency:  blockLatency,
+		Chain:         bcf.NewBlockChainFacade(chainStore, &cstOffline),
+		ChainArchiver: chain.NewArchiver(bs, &cstOffline, nodeConsensus, chainStore),
+		ChainPruner:   pruner,
+		Config:        cfg.NewConfig(nc.Repo),
+		DAG:           dag.NewDAG(merkledag.NewDAGService(bservice)),
+		Deals:         strgdls.New(nc.Repo.DealsDatastore()),
+		GasOracle:     msg.NewGasOracle(chainStore),
+		LocalDAG:      dag.NewDAG(merkledag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))),
+		MsgIndexer:    msgIndexer,
+		MsgJournal:    msgJournal,
+		MsgPool:       msgPool,
+		MsgPreviewer:  msg.NewPreviewer(fcWallet, chainStore, &cstOffline, bs, network.ProtocolVersions),
+		MsgQueryer:    msg.NewQueryer(nc.Repo, fcWallet, chainStore, &cstOffline, bs, network.ProtocolVersions),
+		MsgReplayer:   msg.NewReplayer(chainStore, &cstOffline, bs),
+		MsgSender:     msg.NewSender(fcWallet, chainStore, &cstOffline, chainStore, outbox, msgJournal, msgPool, consensus.NewOutboundMessageValidator(), fsub.Publish),
+		MsgSubscriber: msg.NewSubscriber(chainStore, bs, &cstOffline),
+		MsgWaiter:     msgWaiter,
+		Network:       net.New(peerHost, pubsub.NewPublisher(fsub), pubsub.NewSubscriber(fsub), net.NewRouter(router), bandwidthTracker, net.NewPinger(peerHost, pingService), peerBans, nat),
+		Outbox:        outbox,
+		Syncer:        chainSyncer,
+		Vouchers:      vchrs.New(nc.Repo.DealsDatastore()),
+		Wallet:        fcWallet,
 	}))
 
 	nd := &Node{
@@ -445,16 +580,19 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 		PowerTable:   powerTable,
 		PorcelainAPI: PorcelainAPI,
 		Fetcher:      fetcher,
+		ChainFetcher: chainFetcher,
 		Exchange:     bswap,
 		host:         peerHost,
 		MsgPool:      msgPool,
 		Outbox:       outbox,
 		OfflineMode:  nc.OfflineMode,
+		PeerBans:     peerBans,
 		PeerHost:     peerHost,
 		Repo:         nc.Repo,
 		Wallet:       fcWallet,
 		blockTime:    nc.BlockTime,
 		Router:       router,
+		msgIndexer:   msgIndexer,
 	}
 
 	// set up mining worker funcs
@@ -462,6 +600,9 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 	nd.GetStateTreeFunc = nd.getStateTree
 	nd.GetWeightFunc = nd.getWeight
 
+	// apply mining config changes to the running worker
+	PorcelainAPI.ConfigOnChange("mining", nd.applyMiningConfig)
+
 	// Bootstrapping network peers.
 	periodStr := nd.Repo.Config().Bootstrap.Period
 	period, err := time.ParseDuration(periodStr)
@@ -477,6 +618,39 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 	}
 	minPeerThreshold := nd.Repo.Config().Bootstrap.MinPeerThreshold
 	nd.Bootstrapper = net.NewBootstrapper(bpi, nd.Host(), nd.Host().Network(), nd.Router, minPeerThreshold, period)
+	PorcelainAPI.ConfigOnChange("bootstrap", nd.applyBootstrapConfig)
+
+	// Clock drift checking.
+	tsCfg := nd.Repo.Config().TimeSync
+	checkPeriod, err := time.ParseDuration(tsCfg.CheckPeriod)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse time sync check period %s", tsCfg.CheckPeriod)
+	}
+	maxDrift, err := time.ParseDuration(tsCfg.MaxDrift)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse time sync max drift %s", tsCfg.MaxDrift)
+	}
+	nd.TimeSync = timesync.NewChecker(tsCfg.NTPServer, checkPeriod, maxDrift)
+
+	// Pruning of old states.
+	pruneCfg := nd.Repo.Config().Pruning
+	if pruneCfg.KeepStates != 0 {
+		if pruneCfg.KeepStates < finality {
+			return nil, errors.Wrapf(chain.ErrPruneBelowFinality, "pruning.keepStates is %d rounds, finality is %d rounds", pruneCfg.KeepStates, finality)
+		}
+		nd.prunePeriod, err = time.ParseDuration(pruneCfg.Period)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't parse pruning period %s", pruneCfg.Period)
+		}
+		if nd.prunePeriod <= 0 {
+			return nil, errors.Errorf("pruning period must be positive, got %s", pruneCfg.Period)
+		}
+	}
+	nd.Pruner = pruner
+	nd.BlockLatency = blockLatency
+	nd.MessageJournal = msgJournal
+	nd.VMBlockstore = vmBlockstore
+	nd.rebroadcaster = msg.NewRebroadcaster(msgJournal, fsub.Publish)
 
 	return nd, nil
 }
@@ -496,6 +670,22 @@ func (node *Node) Start(ctx context.Context) error {
 		return err
 	}
 
+	// resume the signing session of a locked wallet
+	if token := os.Getenv("FIL_WALLET_SESSION"); len(token) > 0 {
+		tb, err := hex.DecodeString(token)
+		if err != nil {
+			return errors.Wrap(err, "invalid FIL_WALLET_SESSION token")
+		}
+		if err := wallet.ResumeSession(node.Wallet, tb); err != nil {
+			return errors.Wrap(err, "failed to resume wallet session")
+		}
+	}
+
+	// reload the messages pending when the node last stopped
+	if err := node.MsgPool.Load(ctx, node.Repo.Datastore()); err != nil {
+		return errors.Wrap(err, "failed to load message pool")
+	}
+
 	// Only set these up if there is a miner configured.
 	if _, err := node.miningAddress(); err == nil {
 		if err := node.setupMining(ctx); err != nil {
@@ -506,19 +696,26 @@ func (node *Node) Start(ctx context.Context) error {
 
 	// Start up 'hello' handshake service
 	syncCallBack := func(pid libp2ppeer.ID, cids []cid.Cid, height uint64) {
+		if node.PeerBans.IsBanned(pid) {
+			return
+		}
+		node.ChainFetcher.AddPeer(pid)
 		cidSet := types.NewSortedCidSet(cids...)
 		err := node.Syncer.HandleNewTipset(context.Background(), cidSet)
 		if err != nil {
 			log.Infof("error handling blocks: %s", cidSet.String())
+			if chain.IsBadChainError(err) {
+				node.PeerBans.ReportInvalidChain(pid)
+			}
 		}
 	}
-	node.HelloSvc = hello.New(node.Host(), node.ChainReader.GenesisCid(), syncCallBack, node.PorcelainAPI.ChainHead, node.Repo.Config().Net, flags.Commit)
+	node.HelloSvc = hello.New(node.Host(), node.ChainReader.GenesisCid(), syncCallBack, node.PorcelainAPI.ChainHead, consensus.NetworkByName(node.Repo.Config().Net), flags.Commit)
 
 	err = node.setupProtocols()
 	if err != nil {
 		return errors.Wrap(err, "failed to set up protocols:")
 	}
-	node.RetrievalMiner = retrieval.NewMiner(node)
+	node.RetrievalMiner = retrieval.NewMiner(node, node.PorcelainAPI)
 
 	// subscribe to block notifications
 	blkSub, err := node.PorcelainAPI.PubSubSubscribe(BlockTopic)
@@ -537,6 +734,10 @@ func (node *Node) Start(ctx context.Context) error {
 	cctx, cancel := context.WithCancel(context.Background())
 	node.cancelSubscriptionsCtx = cancel
 
+	if err := node.msgIndexer.Start(cctx); err != nil {
+		return errors.Wrap(err, "failed to start message indexer")
+	}
+
 	go node.handleSubscription(cctx, node.processBlock, "processBlock", node.BlockSub, "BlockSub")
 	go node.handleSubscription(cctx, node.processMessage, "processMessage", node.MessageSub, "MessageSub")
 
@@ -548,10 +749,24 @@ func (node *Node) Start(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to get chain head")
 	}
+	if node.Repo.Config().Paych.Watch {
+		node.PaychWatcher = paymentchannel.NewWatcher(node.PorcelainAPI, node.ChainReadStore())
+		if err := node.PaychWatcher.WatchCancelled(ctx); err != nil {
+			log.Errorf("failed to watch cancelled payment channels: %s", err)
+		}
+	}
 	go node.handleNewHeaviestTipSet(cctx, *head, outboxPolicy)
 
+	if keep := node.Repo.Config().Pruning.KeepStates; keep != 0 {
+		go node.pruneStates(cctx, keep)
+	}
+
 	if !node.OfflineMode {
 		node.Bootstrapper.Start(context.Background())
+
+		if node.TimeSync.Server != "" {
+			node.TimeSync.Start(context.Background())
+		}
 	}
 
 	if err := node.setupHeartbeatServices(ctx); err != nil {
@@ -619,6 +834,25 @@ func (node *Node) setupMining(ctx context.Context) error {
 	return nil
 }
 
+// warnOnStalePeerID logs a warning if the peer ID registered on the miner
+// actor is not this node's, and returns whether it did. Clients dial the
+// registered peer ID to make deals, so a miner whose libp2p identity changed
+// is unreachable until it updates it.
+func (node *Node) warnOnStalePeerID(ctx context.Context, minerAddr address.Address) bool {
+	pid, err := node.PorcelainAPI.MinerGetPeerID(ctx, minerAddr)
+	if err != nil {
+		log.Warningf("could not get peer ID of miner %s: %s", minerAddr, err)
+		return false
+	}
+
+	if pid == node.Host().ID() {
+		return false
+	}
+	log.Warningf("miner %s is registered with peer ID %s but this node is %s; clients will not find it until `go-filecoin miner update-peerid %s %s` is run",
+		minerAddr, pid.Pretty(), node.Host().ID().Pretty(), minerAddr, node.Host().ID().Pretty())
+	return true
+}
+
 func (node *Node) setIsMining(isMining bool) {
 	node.mining.Lock()
 	defer node.mining.Unlock()
@@ -674,14 +908,28 @@ func (node *Node) handleNewHeaviestTipSet(ctx context.Context, head types.TipSet
 			if err := outboxPolicy.OnNewHeadTipset(ctx, head, newHead); err != nil {
 				log.Error("updating outbound message queue for new tipset", err)
 			}
+			if err := node.MessageJournal.OnNewHeadTipset(ctx, node.ChainReadStore(), head, newHead, core.OutboxMaxAgeRounds); err != nil {
+				log.Error("updating message journal for new tipset", err)
+			}
+			if _, err := node.rebroadcaster.OnNewHeadTipset(ctx, newHead); err != nil {
+				log.Error("rebroadcasting pending messages", err)
+			}
 			if err := node.MsgPool.UpdateMessagePool(ctx, node.ChainReadStore(), head, newHead); err != nil {
 				log.Error("updating message pool for new tipset", err)
 			}
+			if node.PaychWatcher != nil {
+				if err := node.PaychWatcher.OnNewHeadTipset(ctx, head, newHead); err != nil {
+					log.Error("watching payment channels for new tipset", err)
+				}
+			}
 			head = newHead
 
 			if node.StorageMiner != nil {
 				node.StorageMiner.OnNewHeaviestTipSet(newHead)
 			}
+			if node.StorageClient != nil {
+				node.StorageClient.OnNewHeaviestTipSet(newHead)
+			}
 			node.HeaviestTipSetHandled()
 		case <-ctx.Done():
 			return
@@ -729,10 +977,28 @@ func (node *Node) Stop(ctx context.Context) {
 	}
 
 	node.Bootstrapper.Stop()
+	node.TimeSync.Stop()
 
 	fmt.Println("stopping filecoin :(")
 }
 
+// pruneStates discards the states of the tipsets more than keep rounds below
+// the head every prune period, until ctx is done.
+func (node *Node) pruneStates(ctx context.Context, keep uint64) {
+	ticker := time.NewTicker(node.prunePeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := node.Pruner.Prune(ctx, keep); err != nil {
+				log.Errorf("failed to prune old states: %s", err)
+			}
+		}
+	}
+}
+
 type newBlockFunc func(context.Context, *types.Block)
 
 func (node *Node) addNewlyMinedBlock(ctx context.Context, b *types.Block) {
@@ -796,6 +1062,8 @@ func (node *Node) StartMining(ctx context.Context) error {
 		return errors.Wrapf(err, "failed to get mining owner address for miner %s", minerAddr)
 	}
 
+	node.warnOnStalePeerID(ctx, minerAddr)
+
 	_, mineDelay := node.MiningTimes()
 
 	if node.MiningWorker == nil {
@@ -824,9 +1092,12 @@ func (node *Node) StartMining(ctx context.Context) error {
 		return errors.Wrap(err, "failed to initialize storage miner")
 	}
 	node.StorageMiner = storageMiner
+	if err := node.StorageMiner.ResumeDeals(); err != nil {
+		log.Errorf("failed to resume storage deals: %s", err)
+	}
 
-	// loop, turning sealing-results into commitSector messages to be included
-	// in the chain
+	// loop, turning sealing-results into preCommitSector and proveCommitSector
+	// messages to be included in the chain
 	go func() {
 		for {
 			select {
@@ -834,34 +1105,7 @@ func (node *Node) StartMining(ctx context.Context) error {
 				if result.SealingErr != nil {
 					log.Errorf("failed to seal sector with id %d: %s", result.SectorID, result.SealingErr.Error())
 				} else if result.SealingResult != nil {
-
-					// TODO: determine these algorithmically by simulating call and querying historical prices
-					gasPrice := types.NewGasPrice(1)
-					gasUnits := types.NewGasUnits(300)
-
-					val := result.SealingResult
-					// This call can fail due to, e.g. nonce collisions. Our miners existence depends on this.
-					// We should deal with this, but MessageSendWithRetry is problematic.
-					msgCid, err := node.PorcelainAPI.MessageSend(
-						node.miningCtx,
-						minerOwnerAddr,
-						minerAddr,
-						nil,
-						gasPrice,
-						gasUnits,
-						"commitSector",
-						val.SectorID,
-						val.CommD[:],
-						val.CommR[:],
-						val.CommRStar[:],
-						val.Proof[:],
-					)
-					if err != nil {
-						log.Errorf("failed to send commitSector message from %s to %s for sector with id %d: %s", minerOwnerAddr, minerAddr, val.SectorID, err)
-						continue
-					}
-
-					node.StorageMiner.OnCommitmentSent(val, msgCid, nil)
+					go node.commitSealedSector(node.miningCtx, minerOwnerAddr, minerAddr, result.SealingResult)
 				}
 			case <-node.miningCtx.Done():
 				return
@@ -878,7 +1122,12 @@ func (node *Node) StartMining(ctx context.Context) error {
 					return
 				case <-time.After(time.Duration(node.Repo.Config().Mining.AutoSealIntervalSeconds) * time.Second):
 					log.Info("auto-seal has been triggered")
-					if err := node.SectorBuilder().SealAllStagedSectors(node.miningCtx); err != nil {
+					ticket, err := node.sampleSealTicket(node.miningCtx)
+					if err != nil {
+						log.Errorf("failed to sample seal ticket: %s", err)
+						continue
+					}
+					if err := node.SectorBuilder().SealAllStagedSectors(node.miningCtx, ticket); err != nil {
 						log.Errorf("scheduler received error from node.SectorBuilder.SealAllStagedSectors (%s) - exiting", err.Error())
 						return
 					}
@@ -920,6 +1169,33 @@ func (node *Node) getLastUsedSectorID(ctx context.Context, minerAddr address.Add
 	return lastUsedSectorID, nil
 }
 
+// warnMissingProofParams logs the parameter files for sectors of sectorSize
+// missing from the parameter cache, which sealing would otherwise only report
+// by failing.
+func warnMissingProofParams(sectorSize uint64) {
+	manifestPath, err := params.FindManifest()
+	if err != nil {
+		log.Debugf("not checking proof parameters: %s", err)
+		return
+	}
+	manifest, err := params.LoadManifest(manifestPath)
+	if err != nil {
+		log.Warningf("could not check proof parameters: %s", err)
+		return
+	}
+
+	pm := params.NewManager(manifest, params.CacheDir())
+	missing, err := pm.Missing(sectorSize)
+	if err != nil {
+		log.Warningf("could not check proof parameters: %s", err)
+		return
+	}
+	if len(missing) > 0 {
+		log.Warningf("proof parameters %s are missing from %s, sealing will fail until `go-filecoin proofs fetch-params --sector-size %d` is run",
+			strings.Join(missing, ", "), pm.Dir, sectorSize)
+	}
+}
+
 func initSectorBuilderForNode(ctx context.Context, node *Node, proofsMode types.ProofsMode) (sectorbuilder.SectorBuilder, error) {
 	minerAddr, err := node.miningAddress()
 	if err != nil {
@@ -937,6 +1213,7 @@ func initSectorBuilderForNode(ctx context.Context, node *Node, proofsMode types.
 	} else {
 		sectorClass = types.NewLiveSectorClass()
 	}
+	warnMissingProofParams(sectorClass.SectorSize().Uint64())
 
 	// TODO: Currently, weconfigure the RustSectorBuilder to store its
 	// metadata in the staging directory, it should be in its own directory.
@@ -960,14 +1237,25 @@ func initSectorBuilderForNode(ctx context.Context, node *Node, proofsMode types.
 	if err != nil {
 		return nil, err
 	}
+	var sealedPaths []sectorbuilder.SealedSectorPath
+	for _, p := range node.Repo.Config().SectorBase.SealedPaths {
+		path, err := homedir.Expand(p.Path)
+		if err != nil {
+			return nil, err
+		}
+		sealedPaths = append(sealedPaths, sectorbuilder.SealedSectorPath{Path: path, Weight: p.Weight})
+	}
+
 	cfg := sectorbuilder.RustSectorBuilderConfig{
-		BlockService:     node.blockservice,
-		LastUsedSectorID: lastUsedSectorID,
-		MetadataDir:      stagingDir,
-		MinerAddr:        minerAddr,
-		SealedSectorDir:  sealedDir,
-		StagedSectorDir:  stagingDir,
-		SectorClass:      sectorClass,
+		BlockService:          node.blockservice,
+		LastUsedSectorID:      lastUsedSectorID,
+		MetadataDir:           stagingDir,
+		MinerAddr:             minerAddr,
+		SealedSectorDir:       sealedDir,
+		StagedSectorDir:       stagingDir,
+		SectorClass:           sectorClass,
+		SealedSectorPaths:     sealedPaths,
+		SealedSectorLocations: node.Repo.Datastore(),
 	}
 
 	sb, err := sectorbuilder.NewRustSectorBuilder(cfg)
@@ -1064,6 +1352,7 @@ func (node *Node) setupProtocols() error {
 	// set up storage client and api
 	smc := storage.NewClient(node.blockTime, node.host, node.PorcelainAPI)
 	smcAPI := storage.NewAPI(smc)
+	node.StorageClient = smc
 	node.StorageAPI = &smcAPI
 	return nil
 }
@@ -1071,7 +1360,8 @@ func (node *Node) setupProtocols() error {
 // CreateMiningWorker creates a mining.Worker for the node using the configured
 // getStateTree, getWeight, and getAncestors functions for the node
 func (node *Node) CreateMiningWorker(ctx context.Context) (mining.Worker, error) {
-	processor := consensus.NewDefaultProcessor()
+	network := consensus.NetworkByName(node.Repo.Config().Net)
+	processor := consensus.NewUpgradingProcessor(consensus.NewDefaultMessageValidator(), consensus.NewDefaultBlockRewarder(), network.ProtocolVersions, consensus.DefaultActorUpgrades)
 
 	minerAddr, err := node.miningAddress()
 	if err != nil {
@@ -1088,10 +1378,45 @@ func (node *Node) CreateMiningWorker(ctx context.Context) (mining.Worker, error)
 		log.Errorf("could not get owner address of miner actor")
 		return nil, err
 	}
-	return mining.NewDefaultWorker(
+	worker := mining.NewDefaultWorker(
 		node.MsgPool, node.getStateTree, node.getWeight, node.getAncestors, processor, node.PowerTable,
-		node.Blockstore, node.CborStore(), minerAddr, minerOwnerAddr, minerPubKey,
-		node.Wallet, node.blockTime), nil
+		node.VMBlockstore, node.CborStore(), minerAddr, minerOwnerAddr, minerPubKey,
+		node.Wallet, node.blockTime)
+
+	miningCfg := node.Repo.Config().Mining
+	worker.SetMessageWait(int(miningCfg.MinBlockMessages), time.Duration(miningCfg.MaxMessageWaitMilliseconds)*time.Millisecond)
+	worker.SetClockCheck(node.TimeSync.Err)
+	worker.SetProtocolVersions(network.ProtocolVersions)
+
+	return worker, nil
+}
+
+// applyMiningConfig applies the message wait heuristic of cfg to the mining
+// worker, if the node is mining. Workers created later read it from the repo.
+func (node *Node) applyMiningConfig(cfg *config.Config) error {
+	worker, ok := node.MiningWorker.(*mining.DefaultWorker)
+	if !ok {
+		return nil
+	}
+	worker.SetMessageWait(int(cfg.Mining.MinBlockMessages), time.Duration(cfg.Mining.MaxMessageWaitMilliseconds)*time.Millisecond)
+	return nil
+}
+
+// applyBootstrapConfig gives the bootstrap peers and threshold of cfg to the
+// bootstrapper. Addresses that don't parse are skipped, as they fail the next
+// start of the node anyway. A new period is applied at the next start.
+func (node *Node) applyBootstrapConfig(cfg *config.Config) error {
+	var bpi []pstore.PeerInfo
+	for _, addr := range cfg.Bootstrap.Addresses {
+		pis, err := net.PeerAddrsToPeerInfos([]string{addr})
+		if err != nil {
+			log.Warningf("ignoring invalid bootstrap address %s: %s", addr, err)
+			continue
+		}
+		bpi = append(bpi, pis...)
+	}
+	node.Bootstrapper.SetBootstrapPeers(bpi, cfg.Bootstrap.MinPeerThreshold)
+	return nil
 }
 
 // getStateFromKey returns the state tree based on tipset fetched with provided key tsKey
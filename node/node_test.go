@@ -161,11 +161,11 @@ func TestNodeStartMining(t *testing.T) {
 		Chain:        bcf.NewBlockChainFacade(minerNode.ChainReader, minerNode.CborStore()),
 		Config:       pbConfig.NewConfig(minerNode.Repo),
 		MsgPool:      nil,
-		MsgPreviewer: msg.NewPreviewer(minerNode.Wallet, minerNode.ChainReader, minerNode.CborStore(), minerNode.Blockstore),
-		MsgQueryer:   msg.NewQueryer(minerNode.Repo, minerNode.Wallet, minerNode.ChainReader, minerNode.CborStore(), minerNode.Blockstore),
-		MsgSender:    msg.NewSender(minerNode.Wallet, nil, minerNode.CborStore(), nil, minerNode.Outbox, minerNode.MsgPool, validator, minerNode.PorcelainAPI.PubSubPublish),
-		MsgWaiter:    msg.NewWaiter(minerNode.ChainReader, minerNode.Blockstore, minerNode.CborStore()),
-		Network:      net.New(minerNode.Host(), nil, nil, nil, nil, nil),
+		MsgPreviewer: msg.NewPreviewer(minerNode.Wallet, minerNode.ChainReader, minerNode.CborStore(), minerNode.Blockstore, consensus.DefaultProtocolVersions),
+		MsgQueryer:   msg.NewQueryer(minerNode.Repo, minerNode.Wallet, minerNode.ChainReader, minerNode.CborStore(), minerNode.Blockstore, consensus.DefaultProtocolVersions),
+		MsgSender:    msg.NewSender(minerNode.Wallet, nil, minerNode.CborStore(), nil, minerNode.Outbox, minerNode.MessageJournal, minerNode.MsgPool, validator, minerNode.PorcelainAPI.PubSubPublish),
+		MsgWaiter:    msg.NewWaiter(minerNode.ChainReader, minerNode.Blockstore, minerNode.CborStore(), 0),
+		Network:      net.New(minerNode.Host(), nil, nil, nil, nil, nil, nil, nil),
 		Wallet:       wallet.New(walletBackend),
 		Deals:        strgdls.New(minerNode.Repo.DealsDatastore()),
 	})
@@ -1,10 +1,12 @@
 package node
 
 import (
+	"context"
 	"testing"
 
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMakePrivateKey(t *testing.T) {
@@ -25,3 +27,29 @@ func TestMakePrivateKey(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, goodKey)
 }
+
+func TestWarnOnStalePeerID(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	seed := MakeChainSeed(t, TestGenCfg)
+
+	// The genesis miner is registered with the peer ID of PeerKeys[0].
+	t.Run("warns if the miner is registered with another peer ID", func(t *testing.T) {
+		nd := MakeNodeWithChainSeed(t, seed, []ConfigOpt{}, PeerKeyOpt(PeerKeys[1]))
+		minerAddr, _ := seed.GiveMiner(t, nd, 0)
+		require.NoError(t, nd.Start(ctx))
+		defer nd.Stop(ctx)
+
+		assert.True(t, nd.warnOnStalePeerID(ctx, minerAddr))
+	})
+
+	t.Run("does not warn if the miner is registered with this node's peer ID", func(t *testing.T) {
+		nd := MakeNodeWithChainSeed(t, seed, []ConfigOpt{}, PeerKeyOpt(PeerKeys[0]))
+		minerAddr, _ := seed.GiveMiner(t, nd, 0)
+		require.NoError(t, nd.Start(ctx))
+		defer nd.Stop(ctx)
+
+		assert.False(t, nd.warnOnStalePeerID(ctx, minerAddr))
+	})
+}
@@ -0,0 +1,131 @@
+package node
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
+	"github.com/filecoin-project/go-filecoin/types"
+	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// sampleSealTicket draws a seal ticket from the chain at the current head.
+func (node *Node) sampleSealTicket(ctx context.Context) (sectorbuilder.SealTicket, error) {
+	height, err := node.PorcelainAPI.ChainBlockHeight()
+	if err != nil {
+		return sectorbuilder.SealTicket{}, errors.Wrap(err, "failed to get chain height")
+	}
+
+	ticket, err := node.PorcelainAPI.ChainSampleRandomness(ctx, height)
+	if err != nil {
+		return sectorbuilder.SealTicket{}, errors.Wrapf(err, "failed to sample chain randomness at height %s", height)
+	}
+
+	return sectorbuilder.SealTicket{
+		BlockHeight: height.AsBigInt().Uint64(),
+		TicketBytes: ticket,
+	}, nil
+}
+
+// commitSealedSector drives the two-phase commitment of a sealed sector. It
+// pre-commits the sector's commitments along with its seal ticket, waits for
+// the pre-commitment to land on chain and for the prove-commit delay to
+// elapse, and then submits the proof. The storage miner is notified of the
+// outcome either way.
+func (node *Node) commitSealedSector(ctx context.Context, minerOwnerAddr, minerAddr address.Address, val *sectorbuilder.SealedSectorMetadata) {
+	msgCid, err := node.proveCommitSealedSector(ctx, minerOwnerAddr, minerAddr, val)
+	if err != nil {
+		log.Errorf("failed to commit sector with id %d for miner %s: %s", val.SectorID, minerAddr, err)
+	}
+	node.StorageMiner.OnCommitmentSent(val, msgCid, err)
+}
+
+func (node *Node) proveCommitSealedSector(ctx context.Context, minerOwnerAddr, minerAddr address.Address, val *sectorbuilder.SealedSectorMetadata) (msgCid cid.Cid, err error) {
+	// TODO: determine these algorithmically by simulating call and querying historical prices
+	gasPrice := types.NewGasPrice(1)
+	gasUnits := types.NewGasUnits(300)
+
+	// This call can fail due to, e.g. nonce collisions. Our miners existence depends on this.
+	// We should deal with this, but MessageSendWithRetry is problematic.
+	preCommitCid, err := node.PorcelainAPI.MessageSend(
+		ctx,
+		minerOwnerAddr,
+		minerAddr,
+		nil,
+		gasPrice,
+		gasUnits,
+		"preCommitSector",
+		val.SectorID,
+		val.CommD[:],
+		val.CommR[:],
+		val.CommRStar[:],
+		types.NewBlockHeight(val.Ticket.BlockHeight),
+	)
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "failed to send preCommitSector message")
+	}
+
+	var preCommitHeight *types.BlockHeight
+	err = node.PorcelainAPI.MessageWait(ctx, preCommitCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+		if receipt.ExitCode != uint8(0) {
+			return vmErrors.VMExitCodeToError(receipt.ExitCode, miner.Errors)
+		}
+		preCommitHeight = types.NewBlockHeight(uint64(blk.Height))
+		return nil
+	})
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "preCommitSector message failed")
+	}
+
+	// The actor only accepts the proof strictly after the delay has elapsed,
+	// so wait until the next block to be mined is past it.
+	proveHeight := preCommitHeight.Add(types.NewBlockHeight(miner.ProveCommitDelayBlocks))
+	if err := node.waitForHeight(ctx, proveHeight); err != nil {
+		return cid.Undef, err
+	}
+
+	msgCid, err = node.PorcelainAPI.MessageSend(
+		ctx,
+		minerOwnerAddr,
+		minerAddr,
+		nil,
+		gasPrice,
+		gasUnits,
+		"proveCommitSector",
+		val.SectorID,
+		val.Proof[:],
+	)
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "failed to send proveCommitSector message")
+	}
+
+	return msgCid, nil
+}
+
+// waitForHeight blocks until the heaviest chain reaches the given height or
+// the context is cancelled.
+func (node *Node) waitForHeight(ctx context.Context, height *types.BlockHeight) error {
+	ch := node.ChainReader.HeadEvents().Sub(chain.NewHeadTopic)
+	defer node.ChainReader.HeadEvents().Unsub(ch, chain.NewHeadTopic)
+
+	for {
+		current, err := node.PorcelainAPI.ChainBlockHeight()
+		if err != nil {
+			return errors.Wrap(err, "failed to get chain height")
+		}
+		if current.GreaterEqual(height) {
+			return nil
+		}
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// Package paymentchannel holds the services a node runs for the payment
+// channels it is party to.
+package paymentchannel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var log = logging.Logger("paymentchannel")
+
+// redeemGasLimit is the gas limit of the redeem messages the Watcher sends.
+const redeemGasLimit = 300
+
+// RedeemRetryRounds is the number of rounds the Watcher waits for a redeem
+// message it sent to take effect before it sends it again.
+const RedeemRetryRounds = 10
+
+// watcherPorcelain is the part of the porcelain API the Watcher uses.
+type watcherPorcelain interface {
+	MessageGasPrice(ctx context.Context, target uint64) (types.AttoFIL, error)
+	MessageSend(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+	PaymentChannelLs(ctx context.Context, fromAddr address.Address, payerAddr address.Address) (map[string]*paymentbroker.PaymentChannel, error)
+	VouchersLs() ([]*types.PaymentVoucher, error)
+	WalletAddresses() []address.Address
+}
+
+// watchedChannel is a cancelled channel the Watcher may have to redeem.
+type watchedChannel struct {
+	payer   address.Address
+	channel *types.ChannelID
+	// The voucher of the last redeem message sent, the message and the
+	// height it was sent at, if any.
+	sent    *types.PaymentVoucher
+	sentCid cid.Cid
+	sentAt  uint64
+	// The signatures of the vouchers whose redeem messages failed, which
+	// are not redeemed again.
+	failed map[string]bool
+}
+
+// Watcher protects the payments of the channels the node is the target of
+// while its owner is away. A payer cancelling a channel gets back the funds
+// not redeemed when the channel expires, CancelDelayBlockTime rounds after
+// the cancellation, unless the target redeems its latest voucher before then,
+// which disputes the cancellation. The Watcher follows the chain for cancel
+// messages to the channels of the vouchers in the node's voucher store whose
+// target is in its wallet and redeems the latest of these vouchers in time.
+//
+// Only vouchers valid by the time they are redeemed can be. Vouchers with
+// conditions are left out, as the Watcher has no redeemer parameters to
+// satisfy them with: those of storage deal payments are redeemed by the
+// storage miner itself. A voucher whose redeem message fails is not redeemed
+// again.
+type Watcher struct {
+	porcelain watcherPorcelain
+	store     chain.BlockProvider
+
+	lk sync.Mutex
+	// Cancelled channels keyed by payer and channel id.
+	watched map[string]*watchedChannel
+}
+
+// NewWatcher returns a new Watcher.
+func NewWatcher(porcelain watcherPorcelain, store chain.BlockProvider) *Watcher {
+	return &Watcher{
+		porcelain: porcelain,
+		store:     store,
+		watched:   make(map[string]*watchedChannel),
+	}
+}
+
+func channelKey(payer address.Address, channel *types.ChannelID) string {
+	return payer.String() + "/" + channel.KeyString()
+}
+
+// WatchCancelled starts watching the channels of the stored vouchers that
+// are cancelled already, such as those cancelled while the node was offline.
+func (w *Watcher) WatchCancelled(ctx context.Context) error {
+	vouchers, err := w.porcelain.VouchersLs()
+	if err != nil {
+		return errors.Wrap(err, "failed to list vouchers")
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	payers := make(map[address.Address]map[string]*paymentbroker.PaymentChannel)
+	for _, voucher := range vouchers {
+		if !w.isLocal(voucher.Target) {
+			continue
+		}
+		channels, ok := payers[voucher.Payer]
+		if !ok {
+			channels, err = w.porcelain.PaymentChannelLs(ctx, address.Undef, voucher.Payer)
+			if err != nil {
+				return errors.Wrapf(err, "failed to list channels of %s", voucher.Payer)
+			}
+			payers[voucher.Payer] = channels
+		}
+		channel, ok := channels[voucher.Channel.KeyString()]
+		if ok && channel.Eol.LessThan(channel.AgreedEol) {
+			w.watch(voucher.Payer, &voucher.Channel)
+		}
+	}
+	return nil
+}
+
+// OnNewHeadTipset starts watching the channels cancelled in the blocks
+// between the old head and the new head and redeems the vouchers of the
+// watched channels that need to be.
+func (w *Watcher) OnNewHeadTipset(ctx context.Context, oldHead, newHead types.TipSet) error {
+	_, newBlocks, err := core.CollectBlocksToCommonAncestor(ctx, w.store, oldHead, newHead)
+	if err != nil {
+		return err
+	}
+	height, err := newHead.Height()
+	if err != nil {
+		return err
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	for _, block := range newBlocks {
+		for i, msg := range block.Messages {
+			if msg.To != address.PaymentBrokerAddress {
+				continue
+			}
+			failed := i < len(block.MessageReceipts) && block.MessageReceipts[i].ExitCode != 0
+			if msg.Method == "redeem" && failed {
+				w.redeemFailed(msg, block.MessageReceipts[i])
+			}
+			if msg.Method != "cancel" || failed {
+				continue
+			}
+			vals, err := abi.DecodeValues(msg.Params, []abi.Type{abi.ChannelID})
+			if err != nil {
+				log.Warningf("failed to decode the params of cancel message %s: %s", msg, err)
+				continue
+			}
+			w.watch(msg.From, vals[0].Val.(*types.ChannelID))
+		}
+	}
+
+	for key, watched := range w.watched {
+		done, err := w.redeem(ctx, watched, height)
+		if err != nil {
+			log.Errorf("failed to redeem channel %s of %s: %s", watched.channel, watched.payer, err)
+		}
+		if done {
+			delete(w.watched, key)
+		}
+	}
+	return nil
+}
+
+// watch starts watching the channel, if not already.
+func (w *Watcher) watch(payer address.Address, channel *types.ChannelID) {
+	key := channelKey(payer, channel)
+	if _, ok := w.watched[key]; ok {
+		return
+	}
+	log.Infof("watching cancelled channel %s of %s", channel, payer)
+	w.watched[key] = &watchedChannel{payer: payer, channel: channel, failed: make(map[string]bool)}
+}
+
+// redeemFailed records the failure of msg, if it is the last redeem message
+// sent for a watched channel, so that its voucher is not redeemed again.
+func (w *Watcher) redeemFailed(msg *types.SignedMessage, receipt *types.MessageReceipt) {
+	msgCid, err := msg.Cid()
+	if err != nil {
+		log.Warningf("failed to compute the CID of redeem message %s: %s", msg, err)
+		return
+	}
+	for _, watched := range w.watched {
+		if watched.sent == nil || !watched.sentCid.Equals(msgCid) {
+			continue
+		}
+		log.Warningf("redeem message %s for channel %s of %s failed with exit code %d: %s", msgCid, watched.channel, watched.payer, receipt.ExitCode, receipt.Error)
+		watched.failed[string(watched.sent.Signature)] = true
+		watched.sent = nil
+	}
+}
+
+// redeem redeems the latest valid voucher of the watched channel if the
+// channel is still cancelled and the voucher pays more than was redeemed. It
+// returns whether the channel needs no more watching.
+func (w *Watcher) redeem(ctx context.Context, watched *watchedChannel, height uint64) (bool, error) {
+	channels, err := w.porcelain.PaymentChannelLs(ctx, address.Undef, watched.payer)
+	if err != nil {
+		return false, err
+	}
+	channel, ok := channels[watched.channel.KeyString()]
+	if !ok {
+		// Closed or reclaimed.
+		return true, nil
+	}
+	if !w.isLocal(channel.Target) {
+		return true, nil
+	}
+	if !channel.Eol.LessThan(channel.AgreedEol) {
+		// A redemption disputed the cancellation.
+		return true, nil
+	}
+	h := types.NewBlockHeight(height)
+	if h.GreaterEqual(channel.Eol) {
+		log.Warningf("channel %s of %s expired before its cancellation could be disputed", watched.channel, watched.payer)
+		return true, nil
+	}
+
+	vouchers, err := w.porcelain.VouchersLs()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list vouchers")
+	}
+	var latest *types.PaymentVoucher
+	pending := false
+	for _, voucher := range vouchers {
+		if voucher.Payer != watched.payer || !voucher.Channel.Equal(watched.channel) || !voucher.Amount.GreaterThan(channel.AmountRedeemed) {
+			continue
+		}
+		if voucher.Condition != nil || watched.failed[string(voucher.Signature)] {
+			continue
+		}
+		if voucher.ValidAt.GreaterThan(h) {
+			// Valid before the channel expires, redeem it then.
+			pending = pending || voucher.ValidAt.LessThan(channel.Eol)
+			continue
+		}
+		if latest == nil || voucher.Amount.GreaterThan(&latest.Amount) {
+			latest = voucher
+		}
+	}
+	if latest == nil {
+		return !pending, nil
+	}
+	if watched.sent != nil && watched.sent.Amount.Equal(&latest.Amount) && height < watched.sentAt+RedeemRetryRounds {
+		// Wait for the redeem message sent to be mined.
+		return false, nil
+	}
+
+	gasPrice, err := w.porcelain.MessageGasPrice(ctx, 1)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get a gas price")
+	}
+	msgCid, err := w.porcelain.MessageSend(
+		ctx,
+		channel.Target,
+		address.PaymentBrokerAddress,
+		types.ZeroAttoFIL,
+		gasPrice,
+		types.NewGasUnits(redeemGasLimit),
+		"redeem",
+		latest.Payer,
+		&latest.Channel,
+		&latest.Amount,
+		&latest.ValidAt,
+		latest.Condition,
+		[]byte(latest.Signature),
+		[]interface{}{},
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to send redeem message")
+	}
+	log.Infof("disputing the cancellation of channel %s of %s with a voucher for %s in message %s", watched.channel, watched.payer, latest.Amount.String(), msgCid)
+	watched.sent = latest
+	watched.sentCid = msgCid
+	watched.sentAt = height
+	return false, nil
+}
+
+func (w *Watcher) isLocal(addr address.Address) bool {
+	for _, a := range w.porcelain.WalletAddresses() {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
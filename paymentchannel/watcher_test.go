@@ -0,0 +1,202 @@
+package paymentchannel
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/address"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+type sentMessage struct {
+	from   address.Address
+	method string
+	params []interface{}
+	msg    *types.SignedMessage
+}
+
+type fakeWatcherPorcelain struct {
+	channels map[string]*paymentbroker.PaymentChannel
+	vouchers []*types.PaymentVoucher
+	wallet   []address.Address
+	sent     []sentMessage
+}
+
+func (f *fakeWatcherPorcelain) MessageGasPrice(ctx context.Context, target uint64) (types.AttoFIL, error) {
+	return types.NewGasPrice(1), nil
+}
+
+func (f *fakeWatcherPorcelain) MessageSend(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	msg := types.NewMessage(from, to, uint64(len(f.sent)), value, method, nil)
+	smsg := &types.SignedMessage{MeteredMessage: *types.NewMeteredMessage(*msg, gasPrice, gasLimit)}
+	f.sent = append(f.sent, sentMessage{from, method, params, smsg})
+	return smsg.Cid()
+}
+
+func (f *fakeWatcherPorcelain) PaymentChannelLs(ctx context.Context, fromAddr address.Address, payerAddr address.Address) (map[string]*paymentbroker.PaymentChannel, error) {
+	return f.channels, nil
+}
+
+func (f *fakeWatcherPorcelain) VouchersLs() ([]*types.PaymentVoucher, error) {
+	return f.vouchers, nil
+}
+
+func (f *fakeWatcherPorcelain) WalletAddresses() []address.Address {
+	return f.wallet
+}
+
+func TestWatcher(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	addrGetter := address.NewForTestGetter()
+	payer, target := addrGetter(), addrGetter()
+	chid := types.NewChannelID(7)
+
+	voucher := func(amount uint64, validAt uint64) *types.PaymentVoucher {
+		return &types.PaymentVoucher{
+			Channel:   *chid,
+			Payer:     payer,
+			Target:    target,
+			Amount:    *types.NewAttoFILFromFIL(amount),
+			ValidAt:   *types.NewBlockHeight(validAt),
+			Signature: []byte(fmt.Sprintf("signature for %d", amount)),
+		}
+	}
+	cancelMsg := func(t *testing.T) *types.SignedMessage {
+		params, err := abi.ToEncodedValues(chid)
+		require.NoError(t, err)
+		msg := types.NewMessage(payer, address.PaymentBrokerAddress, 0, types.ZeroAttoFIL, "cancel", params)
+		return &types.SignedMessage{MeteredMessage: *types.NewMeteredMessage(*msg, types.NewGasPrice(0), types.NewGasUnits(0))}
+	}
+	setup := func(wallet ...address.Address) (*fakeWatcherPorcelain, *paymentbroker.PaymentChannel) {
+		channel := &paymentbroker.PaymentChannel{
+			Target:         target,
+			Amount:         types.NewAttoFILFromFIL(100),
+			AmountRedeemed: types.NewAttoFILFromFIL(5),
+			AgreedEol:      types.NewBlockHeight(100000),
+			Eol:            types.NewBlockHeight(paymentbroker.CancelDelayBlockTime + 1),
+		}
+		return &fakeWatcherPorcelain{
+			channels: map[string]*paymentbroker.PaymentChannel{chid.KeyString(): channel},
+			vouchers: []*types.PaymentVoucher{voucher(10, 0), voucher(20, 0), voucher(30, 1000)},
+			wallet:   wallet,
+		}, channel
+	}
+	requireRedeemed := func(t *testing.T, sent sentMessage, amount uint64) {
+		assert.Equal(t, target, sent.from)
+		assert.Equal(t, "redeem", sent.method)
+		assert.Equal(t, types.NewAttoFILFromFIL(amount), sent.params[2])
+	}
+
+	t.Run("disputes cancellations with the latest valid voucher", func(t *testing.T) {
+		porcelain, channel := setup(target)
+		blocks := th.NewFakeBlockProvider()
+		w := NewWatcher(porcelain, blocks)
+
+		root := blocks.NewBlock(0)
+		b1 := blocks.NewBlockWithMessages(1, []*types.SignedMessage{cancelMsg(t)}, root)
+		require.NoError(t, w.OnNewHeadTipset(ctx, th.RequireNewTipSet(t, root), th.RequireNewTipSet(t, b1)))
+		require.Len(t, porcelain.sent, 1)
+		requireRedeemed(t, porcelain.sent[0], 20)
+
+		// The redeem message gets time to be mined.
+		parent := b1
+		for i := uint64(2); i <= RedeemRetryRounds; i++ {
+			next := blocks.NewBlock(i, parent)
+			require.NoError(t, w.OnNewHeadTipset(ctx, th.RequireNewTipSet(t, parent), th.RequireNewTipSet(t, next)))
+			parent = next
+		}
+		assert.Len(t, porcelain.sent, 1)
+
+		// But is sent again if it is not.
+		next := blocks.NewBlock(RedeemRetryRounds+1, parent)
+		require.NoError(t, w.OnNewHeadTipset(ctx, th.RequireNewTipSet(t, parent), th.RequireNewTipSet(t, next)))
+		require.Len(t, porcelain.sent, 2)
+		requireRedeemed(t, porcelain.sent[1], 20)
+
+		// The redemption resets the eol and ends the watch.
+		channel.Eol = channel.AgreedEol
+		parent = next
+		next = blocks.NewBlock(RedeemRetryRounds+2, parent)
+		require.NoError(t, w.OnNewHeadTipset(ctx, th.RequireNewTipSet(t, parent), th.RequireNewTipSet(t, next)))
+		assert.Len(t, porcelain.sent, 2)
+		assert.Empty(t, w.watched)
+	})
+
+	t.Run("does not redeem a voucher again once its redemption failed", func(t *testing.T) {
+		porcelain, _ := setup(target)
+		// Without the voucher valid later, which is kept for then.
+		porcelain.vouchers = porcelain.vouchers[:2]
+		blocks := th.NewFakeBlockProvider()
+		w := NewWatcher(porcelain, blocks)
+
+		root := blocks.NewBlock(0)
+		b1 := blocks.NewBlockWithMessages(1, []*types.SignedMessage{cancelMsg(t)}, root)
+		require.NoError(t, w.OnNewHeadTipset(ctx, th.RequireNewTipSet(t, root), th.RequireNewTipSet(t, b1)))
+		require.Len(t, porcelain.sent, 1)
+		requireRedeemed(t, porcelain.sent[0], 20)
+
+		// The redemption fails, the watcher falls back on the previous voucher.
+		failure := []*types.MessageReceipt{{ExitCode: 1, Error: "condition failed"}}
+		b2 := blocks.NewBlockWithReceipts(2, []*types.SignedMessage{porcelain.sent[0].msg}, failure, b1)
+		require.NoError(t, w.OnNewHeadTipset(ctx, th.RequireNewTipSet(t, b1), th.RequireNewTipSet(t, b2)))
+		require.Len(t, porcelain.sent, 2)
+		requireRedeemed(t, porcelain.sent[1], 10)
+
+		// Which fails too, leaving nothing to redeem.
+		b3 := blocks.NewBlockWithReceipts(3, []*types.SignedMessage{porcelain.sent[1].msg}, failure, b2)
+		require.NoError(t, w.OnNewHeadTipset(ctx, th.RequireNewTipSet(t, b2), th.RequireNewTipSet(t, b3)))
+		assert.Len(t, porcelain.sent, 2)
+		assert.Empty(t, w.watched)
+	})
+
+	t.Run("leaves out vouchers with conditions", func(t *testing.T) {
+		porcelain, _ := setup(target)
+		conditional := voucher(25, 0)
+		conditional.Condition = &types.Predicate{To: addrGetter(), Method: "verifyPieceInclusion"}
+		porcelain.vouchers = append(porcelain.vouchers, conditional)
+		blocks := th.NewFakeBlockProvider()
+		w := NewWatcher(porcelain, blocks)
+
+		root := blocks.NewBlock(0)
+		b1 := blocks.NewBlockWithMessages(1, []*types.SignedMessage{cancelMsg(t)}, root)
+		require.NoError(t, w.OnNewHeadTipset(ctx, th.RequireNewTipSet(t, root), th.RequireNewTipSet(t, b1)))
+		require.Len(t, porcelain.sent, 1)
+		requireRedeemed(t, porcelain.sent[0], 20)
+	})
+
+	t.Run("leaves the channels of others", func(t *testing.T) {
+		porcelain, _ := setup()
+		blocks := th.NewFakeBlockProvider()
+		w := NewWatcher(porcelain, blocks)
+
+		root := blocks.NewBlock(0)
+		b1 := blocks.NewBlockWithMessages(1, []*types.SignedMessage{cancelMsg(t)}, root)
+		require.NoError(t, w.OnNewHeadTipset(ctx, th.RequireNewTipSet(t, root), th.RequireNewTipSet(t, b1)))
+		assert.Empty(t, porcelain.sent)
+		assert.Empty(t, w.watched)
+	})
+
+	t.Run("watches channels cancelled before it started", func(t *testing.T) {
+		porcelain, _ := setup(target)
+		blocks := th.NewFakeBlockProvider()
+		w := NewWatcher(porcelain, blocks)
+		require.NoError(t, w.WatchCancelled(ctx))
+
+		root := blocks.NewBlock(0)
+		b1 := blocks.NewBlock(1, root)
+		require.NoError(t, w.OnNewHeadTipset(ctx, th.RequireNewTipSet(t, root), th.RequireNewTipSet(t, b1)))
+		require.Len(t, porcelain.sent, 1)
+		requireRedeemed(t, porcelain.sent[0], 20)
+	})
+}
@@ -19,8 +19,10 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/config"
 	"github.com/filecoin-project/go-filecoin/core"
 	"github.com/filecoin-project/go-filecoin/exec"
+	fcmetrics "github.com/filecoin-project/go-filecoin/metrics"
 	"github.com/filecoin-project/go-filecoin/net"
 	"github.com/filecoin-project/go-filecoin/net/pubsub"
 	"github.com/filecoin-project/go-filecoin/plumbing/bcf"
@@ -28,6 +30,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/plumbing/dag"
 	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/plumbing/strgdls"
+	"github.com/filecoin-project/go-filecoin/plumbing/vchrs"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -42,36 +45,58 @@ import (
 type API struct {
 	logger logging.EventLogger
 
-	bitswap      exchange.Interface
-	chain        *bcf.BlockChainFacade
-	config       *cfg.Config
-	dag          *dag.DAG
-	msgPool      *core.MessagePool
-	msgPreviewer *msg.Previewer
-	msgQueryer   *msg.Queryer
-	outbox       *core.MessageQueue
-	msgSender    *msg.Sender
-	msgWaiter    *msg.Waiter
-	network      *net.Network
-	storagedeals *strgdls.Store
-	wallet       *wallet.Wallet
+	bitswap       exchange.Interface
+	blockLatency  *fcmetrics.BlockLatencyTracker
+	chain         *bcf.BlockChainFacade
+	chainArchiver *chain.Archiver
+	chainPruner   *chain.Pruner
+	config        *cfg.Config
+	dag           *dag.DAG
+	gasOracle     *msg.GasOracle
+	localDAG      *dag.DAG
+	msgIndexer    *msg.Indexer
+	msgJournal    *core.MessageJournal
+	msgPool       *core.MessagePool
+	msgPreviewer  *msg.Previewer
+	msgQueryer    *msg.Queryer
+	msgReplayer   *msg.Replayer
+	outbox        *core.MessageQueue
+	msgSender     *msg.Sender
+	msgSubscriber *msg.Subscriber
+	msgWaiter     *msg.Waiter
+	network       *net.Network
+	storagedeals  *strgdls.Store
+	syncer        chain.Syncer
+	vouchers      *vchrs.Store
+	wallet        *wallet.Wallet
 }
 
 // APIDeps contains all the API's dependencies
 type APIDeps struct {
-	Bitswap      exchange.Interface
-	Chain        *bcf.BlockChainFacade
-	Config       *cfg.Config
-	DAG          *dag.DAG
-	Deals        *strgdls.Store
-	MsgPool      *core.MessagePool
-	MsgPreviewer *msg.Previewer
-	MsgQueryer   *msg.Queryer
-	MsgSender    *msg.Sender
-	MsgWaiter    *msg.Waiter
-	Network      *net.Network
-	Outbox       *core.MessageQueue
-	Wallet       *wallet.Wallet
+	Bitswap       exchange.Interface
+	BlockLatency  *fcmetrics.BlockLatencyTracker
+	Chain         *bcf.BlockChainFacade
+	ChainArchiver *chain.Archiver
+	ChainPruner   *chain.Pruner
+	Config        *cfg.Config
+	DAG           *dag.DAG
+	Deals         *strgdls.Store
+	GasOracle     *msg.GasOracle
+	LocalDAG      *dag.DAG
+	MsgIndexer    *msg.Indexer
+	MsgJournal    *core.MessageJournal
+	MsgPool       *core.MessagePool
+	MsgPreviewer  *msg.Previewer
+	MsgQueryer    *msg.Queryer
+	MsgReplayer   *msg.Replayer
+	MsgSender     *msg.Sender
+	MsgSubscriber *msg.Subscriber
+	MsgWaiter     *msg.Waiter
+	Network       *net.Network
+	Outbox        *core.MessageQueue
+	Syncer        chain.Syncer
+	Vouchers      *vchrs.Store
+	Wallet        *wallet.Wallet
 }
 
 // New constructs a new instance of the API.
@@ -79,19 +104,30 @@ func New(deps *APIDeps) *API {
 	return &API{
 		logger: logging.Logger("porcelain"),
 
-		bitswap:      deps.Bitswap,
-		chain:        deps.Chain,
-		config:       deps.Config,
-		dag:          deps.DAG,
-		msgPool:      deps.MsgPool,
-		msgPreviewer: deps.MsgPreviewer,
-		msgQueryer:   deps.MsgQueryer,
-		msgSender:    deps.MsgSender,
-		msgWaiter:    deps.MsgWaiter,
-		network:      deps.Network,
-		outbox:       deps.Outbox,
-		storagedeals: deps.Deals,
-		wallet:       deps.Wallet,
+		bitswap:       deps.Bitswap,
+		blockLatency:  deps.BlockLatency,
+		chain:         deps.Chain,
+		chainArchiver: deps.ChainArchiver,
+		chainPruner:   deps.ChainPruner,
+		config:        deps.Config,
+		dag:           deps.DAG,
+		gasOracle:     deps.GasOracle,
+		localDAG:      deps.LocalDAG,
+		msgIndexer:    deps.MsgIndexer,
+		msgJournal:    deps.MsgJournal,
+		msgPool:       deps.MsgPool,
+		msgPreviewer:  deps.MsgPreviewer,
+		msgQueryer:    deps.MsgQueryer,
+		msgReplayer:   deps.MsgReplayer,
+		msgSender:     deps.MsgSender,
+		msgSubscriber: deps.MsgSubscriber,
+		msgWaiter:     deps.MsgWaiter,
+		network:       deps.Network,
+		outbox:        deps.Outbox,
+		storagedeals:  deps.Deals,
+		syncer:        deps.Syncer,
+		vouchers:      deps.Vouchers,
+		wallet:        deps.Wallet,
 	}
 }
 
@@ -107,6 +143,12 @@ func (api *API) ActorGetSignature(ctx context.Context, actorAddr address.Address
 	return api.chain.GetActorSignature(ctx, actorAddr, method)
 }
 
+// ActorGetState returns the decoded state of an actor from the latest state
+// on the chain
+func (api *API) ActorGetState(ctx context.Context, addr address.Address) (interface{}, error) {
+	return api.chain.GetActorState(ctx, addr)
+}
+
 // ActorLs returns a channel with actors from the latest state on the chain
 func (api *API) ActorLs(ctx context.Context) (<-chan state.GetAllActorsResult, error) {
 	return api.chain.LsActors(ctx)
@@ -128,28 +170,84 @@ func (api *API) ConfigGet(dottedPath string) (interface{}, error) {
 	return api.config.Get(dottedPath)
 }
 
+// ConfigOnChange registers apply to be called with the new config whenever
+// config parameters at or under the given path are set, so that a running
+// component can apply the change without a restart.
+func (api *API) ConfigOnChange(dottedPath string, apply func(*config.Config) error) {
+	api.config.OnChange(dottedPath, apply)
+}
+
 // ChainGetBlock gets a block by CID
 func (api *API) ChainGetBlock(ctx context.Context, id cid.Cid) (*types.Block, error) {
 	return api.chain.GetBlock(ctx, id)
 }
 
+// ChainBlockLatency summarizes how long after their timestamps the latest
+// blocks arrived from the network.
+func (api *API) ChainBlockLatency() *fcmetrics.BlockLatencyReport {
+	return api.blockLatency.Report()
+}
+
+// ChainExport writes the chain ending in the head to out as a CAR file,
+// including the state trees if includeState is set. progress is called with
+// the number of blocks written so far.
+func (api *API) ChainExport(ctx context.Context, out io.Writer, includeState bool, progress func(uint64)) error {
+	head, err := api.chain.Head()
+	if err != nil {
+		return err
+	}
+	return api.chainArchiver.Export(ctx, *head, includeState, out, progress)
+}
+
 // ChainHead returns the head tipset
 func (api *API) ChainHead() (*types.TipSet, error) {
 	return api.chain.Head()
 }
 
+// ChainImport validates the chain in a CAR file written by ChainExport and
+// adds it to the chain store, returning the key of its head. progress is
+// called with the number of blocks read so far.
+func (api *API) ChainImport(ctx context.Context, in io.Reader, progress func(uint64)) (types.SortedCidSet, error) {
+	return api.chainArchiver.Import(ctx, in, progress)
+}
+
 // ChainLs returns an iterator of tipsets from head to genesis
 func (api *API) ChainLs(ctx context.Context) (*chain.TipsetIterator, error) {
 	return api.chain.Ls(ctx)
 }
 
+// ChainPrune discards the states of the tipsets more than keep rounds below
+// the head, keeping their headers. keep must be at least the sync finality.
+func (api *API) ChainPrune(ctx context.Context, keep uint64) (*chain.PruneResult, error) {
+	return api.chainPruner.Prune(ctx, keep)
+}
+
+// ChainSetHead forces the head of the chain to a tipset the node has already
+// validated, regardless of its weight and of finality. It is meant for manual
+// recovery from a fork the node refuses to switch to.
+func (api *API) ChainSetHead(ctx context.Context, tipsetCids types.SortedCidSet) error {
+	return api.syncer.SetHead(ctx, tipsetCids)
+}
+
+// ChainSubscribeHeadChanges invokes the callback for every change of the
+// head, in order, with the tipsets the change reverts and applies. It returns
+// when the callback fails or the context is done.
+func (api *API) ChainSubscribeHeadChanges(ctx context.Context, cb func(*chain.HeadChange) error) error {
+	return api.chain.SubscribeHeadChanges(ctx, cb)
+}
+
 // ChainSampleRandomness produces a slice of random bytes sampled from a TipSet
-// in the blockchain at a given height, useful for things like PoSt challenge seed
-// generation.
+// in the blockchain at a given height, useful for things like seal tickets.
 func (api *API) ChainSampleRandomness(ctx context.Context, sampleHeight *types.BlockHeight) ([]byte, error) {
 	return api.chain.SampleRandomness(ctx, sampleHeight)
 }
 
+// ChainRand returns the randomness actors get for the given epoch, useful for
+// things like PoSt challenge seed generation.
+func (api *API) ChainRand(ctx context.Context, epoch *types.BlockHeight) ([]byte, error) {
+	return api.chain.Rand(ctx, epoch)
+}
+
 // DealsLs a slice of all storagedeals in the local datastore and possibly an error
 func (api *API) DealsLs() ([]*storagedeal.Deal, error) {
 	return api.storagedeals.Ls()
@@ -160,6 +258,16 @@ func (api *API) DealPut(storageDeal *storagedeal.Deal) error {
 	return api.storagedeals.Put(storageDeal)
 }
 
+// VouchersLs returns a slice of all payment vouchers in the local datastore and possibly an error
+func (api *API) VouchersLs() ([]*types.PaymentVoucher, error) {
+	return api.vouchers.Ls()
+}
+
+// VoucherPut puts a given payment voucher in the datastore
+func (api *API) VoucherPut(voucher *types.PaymentVoucher) error {
+	return api.vouchers.Put(voucher)
+}
+
 // OutboxQueues lists addresses with non-empty outbox queues (in no particular order).
 func (api *API) OutboxQueues() []address.Address {
 	return api.outbox.Queues()
@@ -180,6 +288,22 @@ func (api *API) MessagePoolPending() []*types.SignedMessage {
 	return api.msgPool.Pending()
 }
 
+// MessagePoolPendingFrom lists the messages from sender un-mined in the pool,
+// ordered by nonce.
+func (api *API) MessagePoolPendingFrom(sender address.Address) []*types.SignedMessage {
+	return api.msgPool.PendingFrom(sender)
+}
+
+// MessageJournalLs lists the messages sent from this node, in the order
+// they were sent, and their status. With pendingOnly, it lists only the
+// messages that have not reached the chain yet.
+func (api *API) MessageJournalLs(pendingOnly bool) []*core.JournalEntry {
+	if pendingOnly {
+		return api.msgJournal.Pending()
+	}
+	return api.msgJournal.Entries()
+}
+
 // MessagePoolGet fetches a message from the pool.
 func (api *API) MessagePoolGet(cid cid.Cid) (value *types.SignedMessage, ok bool) {
 	return api.msgPool.Get(cid)
@@ -196,6 +320,19 @@ func (api *API) MessagePreview(ctx context.Context, from, to address.Address, me
 	return api.msgPreviewer.Preview(ctx, from, to, method, params...)
 }
 
+// MessageGasEstimate suggests gas prices for messages to be included within
+// msg.GasPriceTargets blocks, from the prices of the messages in recent
+// blocks.
+func (api *API) MessageGasEstimate(ctx context.Context) (*msg.GasPriceEstimate, error) {
+	return api.gasOracle.Estimate(ctx)
+}
+
+// MessageGasPrice suggests a gas price for a message to be included within
+// target blocks.
+func (api *API) MessageGasPrice(ctx context.Context, target uint64) (types.AttoFIL, error) {
+	return api.gasOracle.Suggest(ctx, target)
+}
+
 // MessageQuery calls an actor's method using the most recent chain state. It is read-only,
 // it does not change any state. It is use to interrogate actor state. The from address
 // is optional; if not provided, an address will be chosen from the node's wallet.
@@ -203,6 +340,13 @@ func (api *API) MessageQuery(ctx context.Context, optFrom, to address.Address, m
 	return api.msgQueryer.Query(ctx, optFrom, to, method, params...)
 }
 
+// MessageQueryAt is like MessageQuery but calls the method against the state of
+// the tipset identified by baseKey, or of the head if baseKey is empty. It also
+// returns the gas the call would use.
+func (api *API) MessageQueryAt(ctx context.Context, optFrom, to address.Address, baseKey types.SortedCidSet, method string, params ...interface{}) ([][]byte, types.GasUnits, error) {
+	return api.msgQueryer.QueryAt(ctx, optFrom, to, baseKey, method, params...)
+}
+
 // MessageSend sends a message. It uses the default from address if none is given and signs the
 // message using the wallet. This call "sends" in the sense that it enqueues the
 // message in the msg pool and broadcasts it to the network; it does not wait for the
@@ -212,20 +356,54 @@ func (api *API) MessageSend(ctx context.Context, from, to address.Address, value
 	return api.msgSender.Send(ctx, from, to, value, gasPrice, gasLimit, method, params...)
 }
 
+// MessageSendWithNonce sends a message like MessageSend, but with the given
+// nonce rather than the next nonce of the from actor. The nonce must follow
+// the nonces of the messages from the actor already in the outbox.
+func (api *API) MessageSendWithNonce(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, nonce uint64, method string, params ...interface{}) (cid.Cid, error) {
+	return api.msgSender.SendWithNonce(ctx, from, to, value, gasPrice, gasLimit, nonce, method, params...)
+}
+
 // MessageFind returns a message and receipt from the blockchain, if it exists.
 func (api *API) MessageFind(ctx context.Context, msgCid cid.Cid) (*msg.ChainMessage, bool, error) {
 	return api.msgWaiter.Find(ctx, msgCid)
 }
 
+// MessageHistory returns the cids of the messages on chain sent from or to
+// addr, the most recent first.
+func (api *API) MessageHistory(addr address.Address) ([]cid.Cid, error) {
+	return api.msgIndexer.History(addr)
+}
+
+// MessageReplay re-executes a message that is on chain against the state of
+// the parent of the tipset it was executed in and traces what the VM does.
+func (api *API) MessageReplay(ctx context.Context, msgCid cid.Cid) (*msg.Replay, error) {
+	return api.msgReplayer.Replay(ctx, msgCid)
+}
+
+// MessageSubscribeEvents invokes the callback for every event matching the
+// filter that actors emit in the tipsets the head moves to from now on. It
+// returns an error if one is encountered or if the context is canceled.
+func (api *API) MessageSubscribeEvents(ctx context.Context, filter msg.EventFilter, cb func(*msg.ChainEvent) error) error {
+	return api.msgSubscriber.Subscribe(ctx, filter, cb)
+}
+
 // MessageWait invokes the callback when a message with the given cid appears on chain.
 // It will find the message in both the case that it is already on chain and
 // the case that it appears in a newly mined block. An error is returned if one is
 // encountered or if the context is canceled. Otherwise, it waits forever for the message
-// to appear on chain.
+// to appear on chain. The message must be as deep in the chain as the
+// mpool.waitConfidence config requires.
 func (api *API) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
 	return api.msgWaiter.Wait(ctx, msgCid, cb)
 }
 
+// MessageWaitConfirmed invokes the callback once the tipset a message with
+// the given cid was executed in has at least confidence tipsets on top of it,
+// waiting again if the tipset is orphaned by a reorg before then.
+func (api *API) MessageWaitConfirmed(ctx context.Context, msgCid cid.Cid, confidence uint64, cb func(*msg.ChainMessage) error) error {
+	return api.msgWaiter.WaitConfirmed(ctx, msgCid, confidence, cb)
+}
+
 // PubSubSubscribe subscribes to a topic for notifications from the filecoin network
 func (api *API) PubSubSubscribe(topic string) (pubsub.Subscription, error) {
 	return api.network.Subscribe(topic)
@@ -241,11 +419,22 @@ func (api *API) NetworkGetBandwidthStats() metrics.Stats {
 	return api.network.GetBandwidthStats()
 }
 
+// NetworkGetBandwidthByProtocol gets stats on the bandwidth used by each protocol
+func (api *API) NetworkGetBandwidthByProtocol() []net.ProtocolBandwidth {
+	return api.network.GetBandwidthByProtocol()
+}
+
 // NetworkGetPeerAddresses gets the current addresses of the node
 func (api *API) NetworkGetPeerAddresses() []ma.Multiaddr {
 	return api.network.GetPeerAddresses()
 }
 
+// NetworkGetReachability reports whether the node can be dialed by its peers,
+// and its public address if so
+func (api *API) NetworkGetReachability() (string, ma.Multiaddr) {
+	return api.network.GetReachability()
+}
+
 // NetworkGetPeerID gets the current peer id of the node
 func (api *API) NetworkGetPeerID() peer.ID {
 	return api.network.GetPeerID()
@@ -276,11 +465,37 @@ func (api *API) NetworkConnect(ctx context.Context, addrs []string) (<-chan net.
 	return api.network.Connect(ctx, addrs)
 }
 
+// NetworkDisconnect closes the connections to the given peers
+func (api *API) NetworkDisconnect(peers []string) ([]net.ConnectionResult, error) {
+	return api.network.Disconnect(peers)
+}
+
+// NetworkBans lists the peers banned for sending invalid chains
+func (api *API) NetworkBans() []net.PeerBan {
+	return api.network.Bans()
+}
+
+// NetworkClearBans lifts the bans of the given peers, or of all peers if none are given
+func (api *API) NetworkClearBans(peers ...peer.ID) {
+	api.network.ClearBans(peers...)
+}
+
+// NetworkLookupPeer finds the addresses of a peer and whether the node is connected to it
+func (api *API) NetworkLookupPeer(ctx context.Context, peerID peer.ID) (*net.PeerConnectivity, error) {
+	return api.network.LookupPeer(ctx, peerID)
+}
+
 // NetworkPeers lists peers currently available on the network
 func (api *API) NetworkPeers(ctx context.Context, verbose, latency, streams bool) (*net.SwarmConnInfos, error) {
 	return api.network.Peers(ctx, verbose, latency, streams)
 }
 
+// StateDiff returns the actors whose balance, nonce, head or code differ
+// between the states resulting from the tipsets from and to.
+func (api *API) StateDiff(ctx context.Context, from, to types.SortedCidSet) ([]*state.ActorDiff, error) {
+	return api.chain.StateDiff(ctx, from, to)
+}
+
 // SignBytes uses private key information associated with the given address to sign the given bytes.
 func (api *API) SignBytes(data []byte, addr address.Address) (types.Signature, error) {
 	return api.wallet.SignBytes(data, addr)
@@ -301,9 +516,56 @@ func (api *API) WalletGetPubKeyForAddress(addr address.Address) ([]byte, error)
 	return api.wallet.GetPubKeyForAddress(addr)
 }
 
-// WalletNewAddress generates a new wallet address
-func (api *API) WalletNewAddress() (address.Address, error) {
-	return wallet.NewAddress(api.wallet)
+// WalletNewAddress generates a new wallet address of the given protocol,
+// either secp256k1 or BLS.
+func (api *API) WalletNewAddress(protocol address.Protocol) (address.Address, error) {
+	return wallet.NewAddressWithProtocol(api.wallet, protocol)
+}
+
+// WalletInitHD makes the wallet derive its new addresses from a new mnemonic,
+// which it returns together with the existing addresses not derived from it.
+func (api *API) WalletInitHD() (string, []address.Address, error) {
+	return wallet.InitHD(api.wallet)
+}
+
+// WalletRestoreHD makes the wallet derive its addresses from mnemonic and
+// adds the addresses derived from it up to the last one used according to
+// isUsed.
+func (api *API) WalletRestoreHD(mnemonic string, isUsed func(address.Address) (bool, error)) ([]address.Address, error) {
+	return wallet.RestoreHD(api.wallet, mnemonic, isUsed)
+}
+
+// WalletEncrypt encrypts the wallet keys with passphrase and locks the wallet.
+func (api *API) WalletEncrypt(passphrase []byte) error {
+	return wallet.Encrypt(api.wallet, passphrase)
+}
+
+// WalletLock locks the wallet.
+func (api *API) WalletLock() error {
+	return wallet.Lock(api.wallet)
+}
+
+// WalletUnlock unlocks the wallet with passphrase, until timeout elapsed if
+// timeout is positive.
+func (api *API) WalletUnlock(passphrase []byte, timeout time.Duration) error {
+	return wallet.Unlock(api.wallet, passphrase, timeout)
+}
+
+// WalletStartSession makes the keys of addrs usable to sign while the wallet
+// is locked and returns the token to resume the session with.
+func (api *API) WalletStartSession(addrs []address.Address) ([]byte, error) {
+	return wallet.StartSession(api.wallet, addrs)
+}
+
+// WalletResumeSession makes the keys of the session started with token
+// usable to sign while the wallet is locked.
+func (api *API) WalletResumeSession(token []byte) error {
+	return wallet.ResumeSession(api.wallet, token)
+}
+
+// WalletEndSession forgets the keys of the wallet's session.
+func (api *API) WalletEndSession() error {
+	return wallet.EndSession(api.wallet)
 }
 
 // WalletImport adds a given set of KeyInfos to the wallet
@@ -332,6 +594,13 @@ func (api *API) DAGCat(ctx context.Context, c cid.Cid) (uio.DagReader, error) {
 	return api.dag.Cat(ctx, c)
 }
 
+// DAGHasLocal returns whether all the data of the DAG with root c is stored
+// in the local blockstore, so that it can be read without fetching it from the
+// network.
+func (api *API) DAGHasLocal(ctx context.Context, c cid.Cid) (bool, error) {
+	return api.localDAG.HasAll(ctx, c)
+}
+
 // DAGImportData adds data from an io reader to the merkledag and returns the
 // Cid of the given data. Once the data is in the DAG, it can fetched from the
 // node via Bitswap and a copy will be kept in the blockstore.
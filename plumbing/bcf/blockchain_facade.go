@@ -6,6 +6,12 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/vesting"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/exec"
@@ -63,6 +69,31 @@ func (chn *BlockChainFacade) Ls(ctx context.Context) (*chain.TipsetIterator, err
 	return chain.IterAncestors(ctx, chn.reader, tsas.TipSet), nil
 }
 
+// SubscribeHeadChanges invokes the callback for every change of the head, in
+// order, until the callback fails or the context is done.
+func (chn *BlockChainFacade) SubscribeHeadChanges(ctx context.Context, cb func(*chain.HeadChange) error) error {
+	ch := chn.reader.HeadEvents().Sub(chain.HeadChangeTopic)
+	defer chn.reader.HeadEvents().Unsub(ch, chain.HeadChangeTopic)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case raw, more := <-ch:
+			if !more {
+				return nil
+			}
+			change, ok := raw.(*chain.HeadChange)
+			if !ok {
+				return fmt.Errorf("unexpected type in channel: %T", raw)
+			}
+			if err := cb(change); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // GetBlock gets a block by CID
 func (chn *BlockChainFacade) GetBlock(ctx context.Context, id cid.Cid) (*types.Block, error) {
 	return chn.reader.GetBlock(ctx, id)
@@ -78,6 +109,17 @@ func (chn *BlockChainFacade) SampleRandomness(ctx context.Context, sampleHeight
 	return sampling.SampleChainRandomness(sampleHeight, tipSetBuffer)
 }
 
+// Rand returns the randomness actors get for the given epoch, derived from
+// the tickets of the heaviest chain.
+func (chn *BlockChainFacade) Rand(ctx context.Context, epoch *types.BlockHeight) ([]byte, error) {
+	tipSetBuffer, err := chain.GetRecentAncestorsOfHeaviestChain(ctx, chn.reader, epoch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get recent ancestors")
+	}
+
+	return sampling.Rand(epoch, tipSetBuffer)
+}
+
 // GetActor returns an actor from the latest state on the chain
 func (chn *BlockChainFacade) GetActor(ctx context.Context, addr address.Address) (*actor.Actor, error) {
 	st, err := chn.getLatestState(ctx)
@@ -129,6 +171,118 @@ func (chn *BlockChainFacade) GetActorSignature(ctx context.Context, actorAddr ad
 	return export, nil
 }
 
+// GetActorState returns the decoded state of the actor at addr in the latest
+// state on the chain. The payment broker's state is returned as its channels,
+// indexed by payer and channel id.
+func (chn *BlockChainFacade) GetActorState(ctx context.Context, addr address.Address) (interface{}, error) {
+	act, err := chn.GetActor(ctx, addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get actor")
+	}
+	if act.Empty() {
+		return nil, ErrNoActorImpl
+	}
+
+	storage := &readOnlyStorage{ctx: ctx, cst: chn.cst, head: act.Head}
+	if act.Code.Equals(types.PaymentBrokerActorCodeCid) {
+		return paymentbroker.AllChannels(ctx, storage)
+	}
+
+	newState, ok := actorStates[act.Code]
+	if !ok {
+		return nil, fmt.Errorf("no state type for actor code %s", act.Code)
+	}
+	if !act.Head.Defined() {
+		return nil, fmt.Errorf("actor %s has no state", addr)
+	}
+
+	chunk, err := storage.Get(act.Head)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load actor state")
+	}
+	st := newState()
+	if err := actor.UnmarshalStorage(chunk, st); err != nil {
+		return nil, errors.Wrap(err, "failed to decode actor state")
+	}
+	return st, nil
+}
+
+// actorStates returns new values of the state types of the actors whose
+// state GetActorState decodes, indexed by actor code.
+var actorStates = map[cid.Cid]func() interface{}{
+	types.MinerActorCodeCid:          func() interface{} { return &miner.State{} },
+	types.BootstrapMinerActorCodeCid: func() interface{} { return &miner.State{} },
+	types.StorageMarketActorCodeCid:  func() interface{} { return &storagemarket.State{} },
+	types.MultisigActorCodeCid:       func() interface{} { return &multisig.State{} },
+	types.VestingActorCodeCid:        func() interface{} { return &vesting.State{} },
+	types.InitActorCodeCid:           func() interface{} { return &initactor.State{} },
+}
+
+// readOnlyStorage gives actors' storage helpers read access to an actor's
+// state outside of the VM.
+type readOnlyStorage struct {
+	ctx  context.Context
+	cst  *hamt.CborIpldStore
+	head cid.Cid
+}
+
+var _ exec.Storage = (*readOnlyStorage)(nil)
+
+func (s *readOnlyStorage) Put(interface{}) (cid.Cid, error) {
+	return cid.Undef, errors.New("actor storage is read only")
+}
+
+func (s *readOnlyStorage) Get(c cid.Cid) ([]byte, error) {
+	blk, err := s.cst.Blocks.GetBlock(s.ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return blk.RawData(), nil
+}
+
+func (s *readOnlyStorage) Commit(cid.Cid, cid.Cid) error {
+	return errors.New("actor storage is read only")
+}
+
+func (s *readOnlyStorage) Head() cid.Cid {
+	return s.head
+}
+
+func (s *readOnlyStorage) Begin() exec.Transaction {
+	return readOnlyTransaction{}
+}
+
+// readOnlyTransaction is a transaction over a readOnlyStorage, which has no
+// changes to keep or discard.
+type readOnlyTransaction struct{}
+
+func (readOnlyTransaction) Commit() {}
+
+func (readOnlyTransaction) Rollback() {}
+
+// StateDiff returns the actors that differ between the states resulting from
+// the tipsets from and to, which must be in the store.
+func (chn *BlockChainFacade) StateDiff(ctx context.Context, from, to types.SortedCidSet) ([]*state.ActorDiff, error) {
+	fromSt, err := chn.tipSetState(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	toSt, err := chn.tipSetState(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	return state.Diff(ctx, fromSt, toSt)
+}
+
+// tipSetState loads the state resulting from the tipset with the given key.
+func (chn *BlockChainFacade) tipSetState(ctx context.Context, tsKey types.SortedCidSet) (state.Tree, error) {
+	tsas, err := chn.reader.GetTipSetAndState(tsKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get tipset %s", tsKey.String())
+	}
+	return state.LoadStateTree(ctx, chn.cst, tsas.TipSetStateRoot, builtin.Actors)
+}
+
 // getExecutable returns the builtin actor code from the latest state on the chain
 func (chn *BlockChainFacade) getLatestState(ctx context.Context) (state.Tree, error) {
 	head := chn.reader.GetHead()
@@ -1,14 +1,27 @@
 package cfg
 
 import (
-	"github.com/filecoin-project/go-filecoin/repo"
+	"strings"
 	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/config"
+	"github.com/filecoin-project/go-filecoin/repo"
 )
 
 // Config is plumbing implementation for setting and retrieving values from local config.
 type Config struct {
-	repo repo.Repo
-	lock sync.Mutex
+	repo  repo.Repo
+	lock  sync.Mutex
+	hooks []changeHook
+}
+
+// changeHook applies the changes of the config under key to a running
+// component.
+type changeHook struct {
+	key   string
+	apply func(*config.Config) error
 }
 
 // NewConfig returns a new Config.
@@ -16,7 +29,8 @@ func NewConfig(repo repo.Repo) *Config {
 	return &Config{repo: repo}
 }
 
-// Set sets a value in config
+// Set sets a value in config, and applies the change to the running
+// components that registered to be notified of it with OnChange.
 func (s *Config) Set(dottedKey string, jsonString string) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -26,10 +40,38 @@ func (s *Config) Set(dottedKey string, jsonString string) error {
 		return err
 	}
 
-	return s.repo.ReplaceConfig(cfg)
+	if err := s.repo.ReplaceConfig(cfg); err != nil {
+		return err
+	}
+
+	for _, hook := range s.hooks {
+		if !keysOverlap(dottedKey, hook.key) {
+			continue
+		}
+		if err := hook.apply(cfg); err != nil {
+			return errors.Wrapf(err, "config saved, but failed to apply the change of %s", hook.key)
+		}
+	}
+	return nil
 }
 
 // Get gets a value from config
 func (s *Config) Get(dottedKey string) (interface{}, error) {
 	return s.repo.Config().Get(dottedKey)
 }
+
+// OnChange registers apply to be called with the new config when a value
+// under dottedKey is set, so that a running component applies the change
+// without a restart.
+func (s *Config) OnChange(dottedKey string, apply func(*config.Config) error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.hooks = append(s.hooks, changeHook{key: dottedKey, apply: apply})
+}
+
+// keysOverlap returns whether setting one of the keys a and b changes the
+// value under the other.
+func keysOverlap(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
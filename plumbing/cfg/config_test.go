@@ -1,6 +1,8 @@
 package cfg
 
 import (
+	"errors"
+
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/config"
 	"github.com/filecoin-project/go-filecoin/repo"
@@ -65,9 +67,9 @@ func TestConfigSet(t *testing.T) {
 		assert.Equal(t, expected, cfg.Bootstrap)
 		assert.Equal(t, defaultCfg.Datastore, cfg.Datastore)
 
-		err = cfgAPI.Set("api.address", ":1234")
+		err = cfgAPI.Set("api.address", "/ip4/127.0.0.1/tcp/1234")
 		require.NoError(t, err)
-		assert.Equal(t, ":1234", cfg.API.Address)
+		assert.Equal(t, "/ip4/127.0.0.1/tcp/1234", cfg.API.Address)
 
 		testAddr := address.TestAddress2.String()
 		err = cfgAPI.Set("mining.minerAddress", testAddr)
@@ -128,3 +130,38 @@ func TestConfigSet(t *testing.T) {
 		assert.EqualError(t, err, `"heartbeat.nickname" must only contain letters`)
 	})
 }
+
+func TestConfigOnChange(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("applies changes to the registered keys", func(t *testing.T) {
+		repo := repo.NewInMemoryRepo()
+		cfgAPI := NewConfig(repo)
+
+		var applied []uint
+		cfgAPI.OnChange("mining.minBlockMessages", func(cfg *config.Config) error {
+			applied = append(applied, cfg.Mining.MinBlockMessages)
+			return nil
+		})
+
+		require.NoError(t, cfgAPI.Set("mining.minBlockMessages", "3"))
+		require.NoError(t, cfgAPI.Set("mining", `{"minBlockMessages": 5}`))
+		require.NoError(t, cfgAPI.Set("mining.maxMessageWaitMilliseconds", "100"))
+		require.NoError(t, cfgAPI.Set("heartbeat.nickname", "Nickleless"))
+
+		assert.Equal(t, []uint{3, 5}, applied)
+	})
+
+	t.Run("reports changes failing to apply", func(t *testing.T) {
+		repo := repo.NewInMemoryRepo()
+		cfgAPI := NewConfig(repo)
+
+		cfgAPI.OnChange("api", func(cfg *config.Config) error {
+			return errors.New("address in use")
+		})
+
+		err := cfgAPI.Set("api.address", "/ip4/127.0.0.1/tcp/1234")
+		assert.EqualError(t, err, "config saved, but failed to apply the change of api: address in use")
+		assert.Equal(t, "/ip4/127.0.0.1/tcp/1234", repo.Config().API.Address)
+	})
+}
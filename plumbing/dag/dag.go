@@ -89,6 +89,20 @@ func (dag *DAG) Cat(ctx context.Context, c cid.Cid) (uio.DagReader, error) {
 	return uio.NewDagReader(ctx, data, dag.dserv)
 }
 
+// HasAll returns whether all the blocks of the DAG with root c can be got from
+// the DAG's service. Over an offline block service, this is whether the whole
+// DAG is stored locally.
+func (dag *DAG) HasAll(ctx context.Context, c cid.Cid) (bool, error) {
+	err := merkledag.Walk(ctx, merkledag.GetLinksWithDAG(dag.dserv), c, cid.NewSet().Visit)
+	if err == ipld.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // ImportData adds data from an io stream to the merkledag and returns the Cid
 // of the given data
 func (dag *DAG) ImportData(ctx context.Context, data io.Reader) (ipld.Node, error) {
@@ -1,6 +1,7 @@
 package dag
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/ipfs/go-ipld-format"
 	"github.com/ipfs/go-merkledag"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -74,3 +76,41 @@ func TestDAGGet(t *testing.T) {
 		assert.Equal(t, ipldnode.Cid().String(), nodeBack.Cid().String())
 	})
 }
+
+func TestDAGHasAll(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	mds := datastore.NewMapDatastore()
+	bs := blockstore.NewBlockstore(mds)
+	offl := offline.Exchange(bs)
+	blkserv := blockservice.New(bs, offl)
+	dserv := merkledag.NewDAGService(blkserv)
+	dag := NewDAG(dserv)
+
+	// Big enough to be split in several blocks.
+	nd, err := dag.ImportData(ctx, bytes.NewReader(make([]byte, 1024*1024)))
+	require.NoError(t, err)
+	require.NotEmpty(t, nd.Links())
+
+	t.Run("whole DAG stored", func(t *testing.T) {
+		has, err := dag.HasAll(ctx, nd.Cid())
+		require.NoError(t, err)
+		assert.True(t, has)
+	})
+
+	t.Run("root missing", func(t *testing.T) {
+		has, err := dag.HasAll(ctx, types.SomeCid())
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("child missing", func(t *testing.T) {
+		require.NoError(t, bs.DeleteBlock(nd.Links()[0].Cid))
+
+		has, err := dag.HasAll(ctx, nd.Cid())
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+}
@@ -0,0 +1,147 @@
+package msg
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// GasOracleLookback is the number of tipsets below the head whose blocks the
+// GasOracle analyzes.
+const GasOracleLookback = 20
+
+// GasPriceTargets are the inclusion times, in blocks, the GasOracle suggests
+// gas prices for.
+var GasPriceTargets = []uint64{1, 3, 10}
+
+// DefaultGasPriceTarget is the inclusion time, in blocks, of the gas price
+// used for messages sent without an explicit gas price.
+const DefaultGasPriceTarget = 3
+
+// gasPriceConfidence is the probability with which a suggested gas price
+// gets a message included within its target.
+const gasPriceConfidence = 0.9
+
+// GasPriceSuggestion is a gas price expected to get a message included
+// within a number of blocks.
+type GasPriceSuggestion struct {
+	Blocks uint64
+	Price  types.AttoFIL
+}
+
+// GasPriceEstimate is a set of gas price suggestions and the data they stem
+// from.
+type GasPriceEstimate struct {
+	// Blocks is the number of blocks analyzed.
+	Blocks uint64
+	// Messages is the number of messages these blocks included.
+	Messages    uint64
+	Suggestions []GasPriceSuggestion
+}
+
+// GasOracle suggests gas prices from the prices of the messages included in
+// recent blocks. The lowest price a block included is taken as the price it
+// took to get into it, zero for blocks with room to spare, so a price at or
+// above the lowest prices of a fraction q of recent blocks is expected to get
+// a message into the next block with probability q, and into one of the next
+// n blocks with probability 1-(1-q)^n. For each target the oracle suggests the
+// lowest price for which that probability reaches gasPriceConfidence.
+type GasOracle struct {
+	// To get the recent blocks.
+	chainReader chain.ReadStore
+}
+
+// NewGasOracle returns a new GasOracle.
+func NewGasOracle(chainReader chain.ReadStore) *GasOracle {
+	return &GasOracle{chainReader: chainReader}
+}
+
+// Estimate suggests gas prices for each of GasPriceTargets.
+func (o *GasOracle) Estimate(ctx context.Context) (*GasPriceEstimate, error) {
+	minPrices, messages, err := o.blockMinPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &GasPriceEstimate{
+		Blocks:   uint64(len(minPrices)),
+		Messages: messages,
+	}
+	for _, target := range GasPriceTargets {
+		estimate.Suggestions = append(estimate.Suggestions, GasPriceSuggestion{
+			Blocks: target,
+			Price:  suggestGasPrice(minPrices, target),
+		})
+	}
+	return estimate, nil
+}
+
+// Suggest suggests a gas price to get a message included within target
+// blocks.
+func (o *GasOracle) Suggest(ctx context.Context, target uint64) (types.AttoFIL, error) {
+	if target == 0 {
+		return types.AttoFIL{}, errors.New("target must be at least one block")
+	}
+	minPrices, _, err := o.blockMinPrices(ctx)
+	if err != nil {
+		return types.AttoFIL{}, err
+	}
+	return suggestGasPrice(minPrices, target), nil
+}
+
+// blockMinPrices returns the lowest gas price included by each of the blocks
+// of the last GasOracleLookback tipsets, in increasing order, and the number
+// of messages they included.
+func (o *GasOracle) blockMinPrices(ctx context.Context) ([]*types.AttoFIL, uint64, error) {
+	tsas, err := o.chainReader.GetTipSetAndState(o.chainReader.GetHead())
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to get head")
+	}
+
+	var minPrices []*types.AttoFIL
+	var messages uint64
+	iter := chain.IterAncestors(ctx, o.chainReader, tsas.TipSet)
+	for i := 0; i < GasOracleLookback && !iter.Complete(); i++ {
+		for _, blk := range iter.Value() {
+			// The genesis block holds no messages and tells nothing of the
+			// demand for block space.
+			if blk.Parents.Len() == 0 {
+				continue
+			}
+			minPrice := types.ZeroAttoFIL
+			for j, smsg := range blk.Messages {
+				if j == 0 || smsg.GasPrice.LessThan(minPrice) {
+					minPrice = &smsg.GasPrice
+				}
+			}
+			minPrices = append(minPrices, minPrice)
+			messages += uint64(len(blk.Messages))
+		}
+		if err := iter.Next(); err != nil {
+			return nil, 0, errors.Wrap(err, "failed to walk the chain")
+		}
+	}
+
+	sort.Slice(minPrices, func(i, j int) bool { return minPrices[i].LessThan(minPrices[j]) })
+	return minPrices, messages, nil
+}
+
+// suggestGasPrice returns the lowest of the sorted minimum prices of blocks
+// that gets a message into one of target blocks with gasPriceConfidence.
+func suggestGasPrice(minPrices []*types.AttoFIL, target uint64) types.AttoFIL {
+	if len(minPrices) == 0 {
+		return *types.ZeroAttoFIL
+	}
+	// The fraction of blocks a price must get into.
+	fraction := 1 - math.Pow(1-gasPriceConfidence, 1/float64(target))
+	i := int(math.Ceil(fraction*float64(len(minPrices)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return *minPrices[i]
+}
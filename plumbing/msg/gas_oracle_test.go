@@ -0,0 +1,75 @@
+package msg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestGasOracle(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	d := requiredCommonDeps(t, consensus.DefaultGenesis)
+	oracle := NewGasOracle(d.chainStore)
+
+	t.Run("suggests nothing without blocks", func(t *testing.T) {
+		estimate, err := oracle.Estimate(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), estimate.Blocks)
+		require.Len(t, estimate.Suggestions, len(GasPriceTargets))
+		for _, suggestion := range estimate.Suggestions {
+			assert.True(t, suggestion.Price.IsZero())
+		}
+
+		_, err = oracle.Suggest(ctx, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("suggests prices from recent blocks", func(t *testing.T) {
+		pricedMsg := func(price int64) *types.SignedMessage {
+			msg := newSignedMessage()
+			msg.GasPrice = types.NewGasPrice(price)
+			return msg
+		}
+
+		// The lowest prices of the blocks are 1 to 10.
+		var msgSets [][][]*types.SignedMessage
+		for price := int64(1); price <= 10; price++ {
+			msgSets = append(msgSets, [][]*types.SignedMessage{{pricedMsg(price + 100), pricedMsg(price)}})
+		}
+		headTsas, err := d.chainStore.GetTipSetAndState(d.chainStore.GetHead())
+		require.NoError(t, err)
+		chn := core.NewChainWithMessages(d.cst, headTsas.TipSet, msgSets...)
+		for _, ts := range chn[1:] {
+			th.RequirePutTsas(ctx, t, d.chainStore, &chain.TipSetAndState{
+				TipSet:          ts,
+				TipSetStateRoot: ts.ToSlice()[0].StateRoot,
+			})
+		}
+		require.NoError(t, d.chainStore.SetHead(ctx, chn[len(chn)-1]))
+
+		estimate, err := oracle.Estimate(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10), estimate.Blocks)
+		assert.Equal(t, uint64(20), estimate.Messages)
+		assert.Equal(t, []GasPriceSuggestion{
+			{Blocks: 1, Price: types.NewGasPrice(9)},
+			{Blocks: 3, Price: types.NewGasPrice(6)},
+			{Blocks: 10, Price: types.NewGasPrice(3)},
+		}, estimate.Suggestions)
+
+		price, err := oracle.Suggest(ctx, DefaultGasPriceTarget)
+		require.NoError(t, err)
+		assert.Equal(t, types.NewGasPrice(6), price)
+	})
+}
@@ -0,0 +1,368 @@
+package msg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/cskr/pubsub"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func init() {
+	cbor.RegisterCborType(MessageLocation{})
+}
+
+const (
+	// msgIndexPrefix prefixes the keys mapping message cids to their
+	// locations.
+	msgIndexPrefix = "/msgindex/msgs"
+	// addrIndexPrefix prefixes the keys listing the messages of an address,
+	// as /<address>/<height>/<message cid>.
+	addrIndexPrefix = "/msgindex/addrs"
+)
+
+// indexHeadKey stores the key of the head the indexes are up to date with.
+var indexHeadKey = datastore.NewKey("/msgindex/head")
+
+// MessageLocation is where on chain a message was executed.
+type MessageLocation struct {
+	// TipSet is the key of the tipset the message was executed in.
+	TipSet types.SortedCidSet
+	// Block is the first block of the tipset including the message.
+	Block cid.Cid
+	// Height is the height of the tipset.
+	Height uint64
+}
+
+// indexerChainReader is the subset of the chain store the indexer follows.
+type indexerChainReader interface {
+	GetBlock(ctx context.Context, id cid.Cid) (*types.Block, error)
+	GetHead() types.SortedCidSet
+	HeadEvents() *pubsub.PubSub
+}
+
+// Indexer maintains indexes of the messages on chain in a datastore: the
+// location of each message by cid, and the messages sent from or to each
+// address. It follows the head of the chain, removing the messages of the
+// tipsets a reorg drops from the indexes, so that looking messages up does
+// not scan the chain.
+type Indexer struct {
+	chainReader indexerChainReader
+	ds          repo.Datastore
+}
+
+// NewIndexer returns an Indexer of the chain of chainReader storing its
+// indexes in ds.
+func NewIndexer(chainReader indexerChainReader, ds repo.Datastore) *Indexer {
+	return &Indexer{
+		chainReader: chainReader,
+		ds:          ds,
+	}
+}
+
+// Start brings the indexes up to date with the current head, indexing the
+// whole chain the first time, then keeps them up to date with the head in
+// the background until ctx is done.
+func (ix *Indexer) Start(ctx context.Context) error {
+	// Subscribe before catching up so that no head is missed in between.
+	ch := ix.chainReader.HeadEvents().Sub(chain.NewHeadTopic)
+
+	head, err := ix.loadTipSet(ctx, ix.chainReader.GetHead())
+	if err != nil {
+		ix.chainReader.HeadEvents().Unsub(ch, chain.NewHeadTopic)
+		return err
+	}
+	if err := ix.Update(ctx, head); err != nil {
+		ix.chainReader.HeadEvents().Unsub(ch, chain.NewHeadTopic)
+		return errors.Wrap(err, "failed to index the chain")
+	}
+
+	go func() {
+		defer ix.chainReader.HeadEvents().Unsub(ch, chain.NewHeadTopic)
+		for {
+			head, err := nextHead(ctx, ch)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Errorf("message indexer stopped: %s", err)
+				}
+				return
+			}
+			if err := ix.Update(ctx, head); err != nil {
+				log.Errorf("failed to index head %s: %s", head.String(), err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Update brings the indexes up to date with head, removing the messages of
+// the tipsets no longer on the chain and adding those of the new ones.
+func (ix *Indexer) Update(ctx context.Context, head types.TipSet) error {
+	indexedKey, err := ix.indexedHead()
+	if err != nil {
+		return err
+	}
+	if indexedKey.Equals(head.ToSortedCidSet()) {
+		return nil
+	}
+
+	var change *chain.HeadChange
+	if indexedKey.Empty() {
+		change, err = ix.wholeChain(ctx, head)
+	} else {
+		var indexed types.TipSet
+		indexed, err = ix.loadTipSet(ctx, indexedKey)
+		if err != nil {
+			return err
+		}
+		change, err = chain.CollectHeadChange(ctx, ix.chainReader, indexed, head)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Reverts are committed before applies are read, so that a message
+	// moving to another tipset in a reorg is indexed at its new location.
+	if err := ix.revert(change.Revert); err != nil {
+		return err
+	}
+	return ix.apply(change.Apply, head)
+}
+
+// Find returns the location of the message with msgCid on chain, if it is
+// on chain.
+func (ix *Indexer) Find(msgCid cid.Cid) (*MessageLocation, bool, error) {
+	data, err := ix.ds.Get(msgKey(msgCid))
+	if err == datastore.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to read message index")
+	}
+
+	var loc MessageLocation
+	if err := cbor.DecodeInto(data, &loc); err != nil {
+		return nil, false, errors.Wrap(err, "failed to decode message location")
+	}
+	return &loc, true, nil
+}
+
+// History returns the cids of the messages on chain sent from or to addr,
+// the most recent first.
+func (ix *Indexer) History(addr address.Address) ([]cid.Cid, error) {
+	results, err := ix.ds.Query(query.Query{Prefix: addrPrefix(addr).String() + "/", KeysOnly: true})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query address index")
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read address index")
+	}
+
+	// Keys end in /<height>/<cid> with heights padded to sort in order.
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+	var cids []cid.Cid
+	for _, k := range keys {
+		c, err := cid.Decode(datastore.NewKey(k).BaseNamespace())
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid address index key %s", k)
+		}
+		cids = append(cids, c)
+	}
+	return cids, nil
+}
+
+// revert removes the messages of tipsets from the indexes, unless they are
+// indexed at another location.
+func (ix *Indexer) revert(tipsets []types.TipSet) error {
+	if len(tipsets) == 0 {
+		return nil
+	}
+	batch, err := ix.ds.Batch()
+	if err != nil {
+		return err
+	}
+
+	for _, ts := range tipsets {
+		height, err := ts.Height()
+		if err != nil {
+			return err
+		}
+		err = forEachMessage(ts, func(msgCid cid.Cid, msg *types.SignedMessage, _ *types.Block) error {
+			loc, found, err := ix.Find(msgCid)
+			if err != nil {
+				return err
+			}
+			if !found || !loc.TipSet.Equals(ts.ToSortedCidSet()) {
+				return nil
+			}
+			if err := batch.Delete(msgKey(msgCid)); err != nil {
+				return err
+			}
+			if err := batch.Delete(addrKey(msg.From, height, msgCid)); err != nil {
+				return err
+			}
+			return batch.Delete(addrKey(msg.To, height, msgCid))
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to unindex tipset %s", ts.String())
+		}
+	}
+
+	return batch.Commit()
+}
+
+// apply adds the messages of tipsets to the indexes and records head as the
+// indexed head. Messages already indexed at a lower height keep their
+// location: only their first inclusion on chain is executed.
+func (ix *Indexer) apply(tipsets []types.TipSet, head types.TipSet) error {
+	batch, err := ix.ds.Batch()
+	if err != nil {
+		return err
+	}
+
+	// added holds the messages indexed by this batch, which Find does not
+	// see until it is committed.
+	added := make(map[cid.Cid]struct{})
+	for _, ts := range tipsets {
+		height, err := ts.Height()
+		if err != nil {
+			return err
+		}
+		err = forEachMessage(ts, func(msgCid cid.Cid, msg *types.SignedMessage, blk *types.Block) error {
+			if _, ok := added[msgCid]; ok {
+				return nil
+			}
+			loc, found, err := ix.Find(msgCid)
+			if err != nil {
+				return err
+			}
+			if found && loc.Height < height {
+				return nil
+			}
+			added[msgCid] = struct{}{}
+
+			data, err := cbor.DumpObject(&MessageLocation{TipSet: ts.ToSortedCidSet(), Block: blk.Cid(), Height: height})
+			if err != nil {
+				return err
+			}
+			if err := batch.Put(msgKey(msgCid), data); err != nil {
+				return err
+			}
+			if err := batch.Put(addrKey(msg.From, height, msgCid), []byte{}); err != nil {
+				return err
+			}
+			return batch.Put(addrKey(msg.To, height, msgCid), []byte{})
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to index tipset %s", ts.String())
+		}
+	}
+
+	headData, err := cbor.DumpObject(head.ToSortedCidSet())
+	if err != nil {
+		return err
+	}
+	if err := batch.Put(indexHeadKey, headData); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+// indexedHead returns the key of the head the indexes are up to date with,
+// empty if nothing was indexed yet.
+func (ix *Indexer) indexedHead() (types.SortedCidSet, error) {
+	var key types.SortedCidSet
+	data, err := ix.ds.Get(indexHeadKey)
+	if err == datastore.ErrNotFound {
+		return key, nil
+	}
+	if err != nil {
+		return key, errors.Wrap(err, "failed to read indexed head")
+	}
+	if err := cbor.DecodeInto(data, &key); err != nil {
+		return key, errors.Wrap(err, "failed to decode indexed head")
+	}
+	return key, nil
+}
+
+// wholeChain returns a change applying every tipset from genesis up to head.
+func (ix *Indexer) wholeChain(ctx context.Context, head types.TipSet) (*chain.HeadChange, error) {
+	var tipsets []types.TipSet
+	var err error
+	for it := chain.IterAncestors(ctx, ix.chainReader, head); !it.Complete(); err = it.Next() {
+		if err != nil {
+			return nil, err
+		}
+		tipsets = append(tipsets, it.Value())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	change := &chain.HeadChange{}
+	for i := len(tipsets) - 1; i >= 0; i-- {
+		change.Apply = append(change.Apply, tipsets[i])
+	}
+	return change, nil
+}
+
+func (ix *Indexer) loadTipSet(ctx context.Context, key types.SortedCidSet) (types.TipSet, error) {
+	ts := types.TipSet{}
+	for it := key.Iter(); !it.Complete(); it.Next() {
+		blk, err := ix.chainReader.GetBlock(ctx, it.Value())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get block %s", it.Value())
+		}
+		if err := ts.AddBlock(blk); err != nil {
+			return nil, err
+		}
+	}
+	return ts, nil
+}
+
+// forEachMessage calls cb with the messages of ts in the canonical order of
+// the tipset.
+func forEachMessage(ts types.TipSet, cb func(cid.Cid, *types.SignedMessage, *types.Block) error) error {
+	blks := ts.ToSlice()
+	types.SortBlocks(blks)
+	for _, blk := range blks {
+		for _, msg := range blk.Messages {
+			c, err := msg.Cid()
+			if err != nil {
+				return err
+			}
+			if err := cb(c, msg, blk); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func msgKey(msgCid cid.Cid) datastore.Key {
+	return datastore.NewKey(msgIndexPrefix).ChildString(msgCid.String())
+}
+
+func addrPrefix(addr address.Address) datastore.Key {
+	return datastore.NewKey(addrIndexPrefix).ChildString(addr.String())
+}
+
+func addrKey(addr address.Address, height uint64, msgCid cid.Cid) datastore.Key {
+	return addrPrefix(addr).ChildString(fmt.Sprintf("%020d", height)).ChildString(msgCid.String())
+}
@@ -0,0 +1,95 @@
+package msg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func mustCid(t *testing.T, m *types.SignedMessage) cid.Cid {
+	c, err := m.Cid()
+	require.NoError(t, err)
+	return c
+}
+
+func TestIndexer(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	d := requiredCommonDeps(t, consensus.DefaultGenesis)
+	ix := NewIndexer(d.chainStore, d.repo.ChainDatastore())
+
+	genesis, err := ix.loadTipSet(ctx, d.chainStore.GetHead())
+	require.NoError(t, err)
+
+	m1, m2, m3 := newSignedMessage(), newSignedMessage(), newSignedMessage()
+	mainChain := core.NewChainWithMessages(d.cst, genesis, smsgsSet{smsgs{m1}}, smsgsSet{smsgs{m2}})
+
+	t.Run("indexes the chain up to the head", func(t *testing.T) {
+		require.NoError(t, ix.Update(ctx, mainChain[2]))
+
+		loc, found, err := ix.Find(mustCid(t, m1))
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, uint64(1), loc.Height)
+		assert.True(t, loc.TipSet.Equals(mainChain[1].ToSortedCidSet()))
+		assert.Equal(t, mainChain[1].ToSlice()[0].Cid(), loc.Block)
+
+		_, found, err = ix.Find(mustCid(t, m3))
+		require.NoError(t, err)
+		assert.False(t, found)
+
+		// Most recent first, listed for both the sender and the receiver.
+		history, err := ix.History(m1.From)
+		require.NoError(t, err)
+		assert.Equal(t, []cid.Cid{mustCid(t, m2), mustCid(t, m1)}, history)
+
+		history, err = ix.History(m2.To)
+		require.NoError(t, err)
+		assert.Equal(t, []cid.Cid{mustCid(t, m2)}, history)
+	})
+
+	t.Run("reorgs move and drop messages", func(t *testing.T) {
+		// The fork drops m1 and includes m2 at another height, with m3.
+		fork := core.NewChainWithMessages(d.cst, genesis, smsgsSet{}, smsgsSet{}, smsgsSet{smsgs{m2, m3}})
+		require.NoError(t, ix.Update(ctx, fork[3]))
+
+		_, found, err := ix.Find(mustCid(t, m1))
+		require.NoError(t, err)
+		assert.False(t, found)
+
+		loc, found, err := ix.Find(mustCid(t, m2))
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, uint64(3), loc.Height)
+		assert.True(t, loc.TipSet.Equals(fork[3].ToSortedCidSet()))
+
+		history, err := ix.History(m1.From)
+		require.NoError(t, err)
+		assert.Len(t, history, 2)
+		assert.NotContains(t, history, mustCid(t, m1))
+
+		history, err = ix.History(m1.To)
+		require.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	t.Run("resumes from the indexed head", func(t *testing.T) {
+		resumed := NewIndexer(d.chainStore, d.repo.ChainDatastore())
+		head, err := resumed.indexedHead()
+		require.NoError(t, err)
+		assert.False(t, head.Empty())
+
+		_, found, err := resumed.Find(mustCid(t, m3))
+		require.NoError(t, err)
+		assert.True(t, found)
+	})
+}
@@ -27,11 +27,13 @@ type Previewer struct {
 	cst *hamt.CborIpldStore
 	// For vm storage.
 	bs bstore.Blockstore
+	// To charge gas as the network does at the head.
+	versions consensus.ProtocolVersionTable
 }
 
 // NewPreviewer constructs a Previewer.
-func NewPreviewer(wallet *wallet.Wallet, chainReader chain.ReadStore, cst *hamt.CborIpldStore, bs bstore.Blockstore) *Previewer {
-	return &Previewer{wallet, chainReader, cst, bs}
+func NewPreviewer(wallet *wallet.Wallet, chainReader chain.ReadStore, cst *hamt.CborIpldStore, bs bstore.Blockstore, versions consensus.ProtocolVersionTable) *Previewer {
+	return &Previewer{wallet, chainReader, cst, bs, versions}
 }
 
 // Preview sends a read-only message to an actor.
@@ -56,7 +58,7 @@ func (p *Previewer) Preview(ctx context.Context, optFrom, to address.Address, me
 	}
 
 	vms := vm.NewStorageMap(p.bs)
-	usedGas, err := consensus.PreviewQueryMethod(ctx, st, vms, to, method, encodedParams, optFrom, types.NewBlockHeight(h))
+	usedGas, err := consensus.PreviewQueryMethod(ctx, st, vms, p.versions, to, method, encodedParams, optFrom, types.NewBlockHeight(h))
 	if err != nil {
 		return types.NewGasUnits(0), errors.Wrap(err, "query method returned an error")
 	}
@@ -30,40 +30,52 @@ type Queryer struct {
 	cst *hamt.CborIpldStore
 	// For vm storage.
 	bs bstore.Blockstore
+	// To charge gas as the network does at the queried height.
+	versions consensus.ProtocolVersionTable
 }
 
 // NewQueryer constructs a Queryer.
-func NewQueryer(repo repo.Repo, wallet *wallet.Wallet, chainReader chain.ReadStore, cst *hamt.CborIpldStore, bs bstore.Blockstore) *Queryer {
-	return &Queryer{repo, wallet, chainReader, cst, bs}
+func NewQueryer(repo repo.Repo, wallet *wallet.Wallet, chainReader chain.ReadStore, cst *hamt.CborIpldStore, bs bstore.Blockstore, versions consensus.ProtocolVersionTable) *Queryer {
+	return &Queryer{repo, wallet, chainReader, cst, bs, versions}
 }
 
 // Query sends a read-only message to an actor.
 func (q *Queryer) Query(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	r, _, err := q.QueryAt(ctx, optFrom, to, types.SortedCidSet{}, method, params...)
+	return r, err
+}
+
+// QueryAt sends a read-only message to an actor against the state of the
+// tipset identified by baseKey, or of the head tipset if baseKey is empty.
+// It also returns the gas the call used.
+func (q *Queryer) QueryAt(ctx context.Context, optFrom, to address.Address, baseKey types.SortedCidSet, method string, params ...interface{}) ([][]byte, types.GasUnits, error) {
 	encodedParams, err := abi.ToEncodedValues(params...)
 	if err != nil {
-		return nil, errors.Wrap(err, "couldnt encode message params")
+		return nil, types.NewGasUnits(0), errors.Wrap(err, "couldnt encode message params")
 	}
 
-	headTs := q.chainReader.GetHead()
-	tsas, err := q.chainReader.GetTipSetAndState(headTs)
+	if baseKey.Empty() {
+		baseKey = q.chainReader.GetHead()
+	}
+	tsas, err := q.chainReader.GetTipSetAndState(baseKey)
 	if err != nil {
-		return nil, errors.Wrap(err, "couldnt get latest state root")
+		return nil, types.NewGasUnits(0), errors.Wrap(err, "couldnt get state root for tipset")
 	}
 	st, err := state.LoadStateTree(ctx, q.cst, tsas.TipSetStateRoot, builtin.Actors)
 	if err != nil {
-		return nil, errors.Wrap(err, "could load tree for latest state root")
+		return nil, types.NewGasUnits(0), errors.Wrap(err, "could load tree for state root")
 	}
 	h, err := tsas.TipSet.Height()
 	if err != nil {
-		return nil, errors.Wrap(err, "couldnt get base tipset height")
+		return nil, types.NewGasUnits(0), errors.Wrap(err, "couldnt get base tipset height")
 	}
 
 	vms := vm.NewStorageMap(q.bs)
-	r, ec, err := consensus.CallQueryMethod(ctx, st, vms, to, method, encodedParams, optFrom, types.NewBlockHeight(h))
+	r, ec, gas, err := consensus.CallQueryMethodWithGas(ctx, st, vms, q.versions, to, method, encodedParams, optFrom, types.NewBlockHeight(h))
 	if err != nil {
-		return nil, errors.Wrap(err, "querymethod returned an error")
+		return nil, gas, errors.Wrap(err, "querymethod returned an error")
 	} else if ec != 0 {
-		return nil, errors.Errorf("querymethod returned a non-zero error code %d", ec)
+		return nil, gas, errors.Errorf("querymethod returned a non-zero error code %d", ec)
 	}
-	return r, nil
+	return r, gas, nil
 }
@@ -0,0 +1,65 @@
+package msg
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/metrics"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var msgRebroadcastCt = metrics.NewInt64Counter("message_rebroadcast", "Number of messages rebroadcast because they did not reach the chain")
+
+// RebroadcastRounds is the number of rounds a message sent from this node
+// stays pending after it was last broadcast before it is broadcast again.
+const RebroadcastRounds = 3
+
+// Rebroadcaster broadcasts again the messages sent from this node that are
+// still pending in the journal, so that messages dropped by the network or by
+// the message pools of miners get another chance to be mined. Messages are
+// rebroadcast every RebroadcastRounds rounds until they reach the chain or
+// the journal expires them.
+type Rebroadcaster struct {
+	journal *core.MessageJournal
+	publish PublishFunc
+}
+
+// NewRebroadcaster returns a new Rebroadcaster publishing the pending
+// messages of journal.
+func NewRebroadcaster(journal *core.MessageJournal, publish PublishFunc) *Rebroadcaster {
+	return &Rebroadcaster{
+		journal: journal,
+		publish: publish,
+	}
+}
+
+// OnNewHeadTipset rebroadcasts the pending messages last broadcast
+// RebroadcastRounds or more rounds below the new head. It returns the number
+// of messages rebroadcast.
+func (r *Rebroadcaster) OnNewHeadTipset(ctx context.Context, newHead types.TipSet) (int, error) {
+	height, err := newHead.Height()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, entry := range r.journal.Pending() {
+		if height < entry.BroadcastAt+RebroadcastRounds {
+			continue
+		}
+		data, err := entry.Msg.Marshal()
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to marshal message %s", entry.Cid)
+		}
+		if err := r.publish(Topic, data); err != nil {
+			return count, errors.Wrapf(err, "failed to rebroadcast message %s", entry.Cid)
+		}
+		r.journal.Rebroadcast(entry.Cid, height)
+		msgRebroadcastCt.Inc(ctx, 1)
+		count++
+		log.Debugf("rebroadcast message %s sent at height %d", entry.Cid, entry.SentAt)
+	}
+	return count, nil
+}
@@ -0,0 +1,57 @@
+package msg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestRebroadcaster(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	keys := types.MustGenerateKeyInfo(1, types.GenerateKeyInfoSeed())
+	mm := types.NewMessageMaker(t, keys)
+	alice := mm.Addresses()[0]
+
+	journal := core.NewMessageJournal()
+	msg := mm.NewSignedMessage(alice, 1)
+	require.NoError(t, journal.Add(msg, 100))
+	data, err := msg.Marshal()
+	require.NoError(t, err)
+
+	var published [][]byte
+	publish := func(topic string, data []byte) error {
+		assert.Equal(t, Topic, topic)
+		published = append(published, data)
+		return nil
+	}
+	r := NewRebroadcaster(journal, publish)
+
+	headAt := func(height uint64) types.TipSet {
+		return testhelpers.RequireNewTipSet(t, &types.Block{Height: types.Uint64(height)})
+	}
+
+	count, err := r.OnNewHeadTipset(ctx, headAt(100+RebroadcastRounds-1))
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Empty(t, published)
+
+	count, err = r.OnNewHeadTipset(ctx, headAt(100+RebroadcastRounds))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, [][]byte{data}, published)
+	assert.Equal(t, uint64(2), journal.Pending()[0].Broadcasts)
+
+	// Not again until another RebroadcastRounds rounds have passed.
+	count, err = r.OnNewHeadTipset(ctx, headAt(100+RebroadcastRounds+1))
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,125 @@
+package msg
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	hamt "github.com/ipfs/go-hamt-ipld"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/sampling"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// Replayer re-executes historical messages.
+type Replayer struct {
+	// To find the message and the state of its parent tipset.
+	chainReader chain.ReadStore
+	// To load the state tree of the parent tipset.
+	cst *hamt.CborIpldStore
+	// For vm storage.
+	bs bstore.Blockstore
+}
+
+// NewReplayer constructs a Replayer.
+func NewReplayer(chainReader chain.ReadStore, cst *hamt.CborIpldStore, bs bstore.Blockstore) *Replayer {
+	return &Replayer{chainReader, cst, bs}
+}
+
+// Replay is the outcome of re-executing a message.
+type Replay struct {
+	Message *types.SignedMessage
+	// Block is the block the message was executed in.
+	Block cid.Cid
+	// Receipt is the receipt of the re-executed message.
+	Receipt *types.MessageReceipt
+	// ExecutionError is the error the message failed with, if any.
+	ExecutionError string
+	// Trace lists the operations the VM performed while executing the
+	// message.
+	Trace []*vm.TraceEvent
+}
+
+// Replay re-executes the message with the given cid against the state of the
+// parent of the tipset it was executed in, tracing what the VM does. Nothing
+// is stored.
+func (r *Replayer) Replay(ctx context.Context, msgCid cid.Cid) (*Replay, error) {
+	ts, msg, blk, err := r.findMessage(ctx, msgCid)
+	if err != nil {
+		return nil, err
+	}
+
+	parents, err := ts.Parents()
+	if err != nil {
+		return nil, err
+	}
+	tsas, err := r.chainReader.GetTipSetAndState(parents)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get parent tipset")
+	}
+	st, err := state.LoadStateTree(ctx, r.cst, tsas.TipSetStateRoot, builtin.Actors)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load parent state")
+	}
+
+	h, err := ts.Height()
+	if err != nil {
+		return nil, err
+	}
+	ancestorHeight := types.NewBlockHeight(consensus.AncestorRoundsNeeded)
+	ancestors, err := chain.GetRecentAncestors(ctx, tsas.TipSet, r.chainReader, types.NewBlockHeight(h), ancestorHeight, sampling.LookbackParameter)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get ancestors")
+	}
+
+	recorder := &vm.TraceRecorder{}
+	res, err := consensus.NewDefaultProcessor().ReplayMessage(ctx, st, vm.NewStorageMap(r.bs), ts, ancestors, msgCid, recorder)
+	if err != nil {
+		return nil, errors.Wrap(err, "message was not applied")
+	}
+
+	replay := &Replay{
+		Message: msg,
+		Block:   blk.Cid(),
+		Receipt: res.Receipt,
+		Trace:   recorder.Events,
+	}
+	if res.ExecutionError != nil {
+		replay.ExecutionError = res.ExecutionError.Error()
+	}
+	return replay, nil
+}
+
+// findMessage returns the tipset on the chain the message with msgCid was
+// executed in, along with the message and the first block containing it.
+func (r *Replayer) findMessage(ctx context.Context, msgCid cid.Cid) (types.TipSet, *types.SignedMessage, *types.Block, error) {
+	head, err := r.chainReader.GetTipSetAndState(r.chainReader.GetHead())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for iterator := chain.IterAncestors(ctx, r.chainReader, head.TipSet); !iterator.Complete(); err = iterator.Next() {
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		blks := iterator.Value().ToSlice()
+		types.SortBlocks(blks)
+		for _, blk := range blks {
+			for _, msg := range blk.Messages {
+				c, err := msg.Cid()
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				if c.Equals(msgCid) {
+					return iterator.Value(), msg, blk, nil
+				}
+			}
+		}
+	}
+	return nil, nil, nil, errors.Errorf("message %s not found on chain", msgCid)
+}
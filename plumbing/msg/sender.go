@@ -53,6 +53,8 @@ type Sender struct {
 	inbox *core.MessagePool
 	// Tracks outbound messages
 	outbox *core.MessageQueue
+	// Records what becomes of outbound messages
+	journal *core.MessageJournal
 	// Validates messages before sending them.
 	validator consensus.SignedMessageValidator
 	// Invoked to publish the new message to the network.
@@ -64,7 +66,7 @@ type Sender struct {
 // NewSender returns a new Sender. There should be exactly one of these per node because
 // sending locks to reduce nonce collisions.
 func NewSender(signer types.Signer, chainReader chain.ReadStore, cst *hamt.CborIpldStore, blockTimer BlockClock,
-	msgQueue *core.MessageQueue, msgPool *core.MessagePool,
+	msgQueue *core.MessageQueue, msgJournal *core.MessageJournal, msgPool *core.MessagePool,
 	validator consensus.SignedMessageValidator, publish PublishFunc) *Sender {
 	return &Sender{
 		signer:     signer,
@@ -73,13 +75,25 @@ func NewSender(signer types.Signer, chainReader chain.ReadStore, cst *hamt.CborI
 		blockTimer: blockTimer,
 		inbox:      msgPool,
 		outbox:     msgQueue,
+		journal:    msgJournal,
 		validator:  validator,
 		publish:    publish,
 	}
 }
 
 // Send sends a message. See api description.
-func (s *Sender) Send(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (out cid.Cid, err error) {
+func (s *Sender) Send(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	return s.send(ctx, from, to, value, gasPrice, gasLimit, nil, method, params...)
+}
+
+// SendWithNonce sends a message with the given nonce rather than the next
+// nonce of the sender. The nonce must follow the nonces of the messages from
+// the sender already in the outbox, if any.
+func (s *Sender) SendWithNonce(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, nonce uint64, method string, params ...interface{}) (cid.Cid, error) {
+	return s.send(ctx, from, to, value, gasPrice, gasLimit, &nonce, method, params...)
+}
+
+func (s *Sender) send(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, nonce *uint64, method string, params ...interface{}) (out cid.Cid, err error) {
 	defer func() {
 		if err != nil {
 			msgSendErrCt.Inc(ctx, 1)
@@ -110,12 +124,15 @@ func (s *Sender) Send(ctx context.Context, from, to address.Address, value *type
 		return cid.Undef, errors.Wrapf(err, "no actor at address %s", from)
 	}
 
-	nonce, err := nextNonce(fromActor, s.outbox, from)
-	if err != nil {
-		return cid.Undef, errors.Wrapf(err, "failed calculating nonce for actor %s", from)
+	if nonce == nil {
+		next, err := nextNonce(fromActor, s.outbox, from)
+		if err != nil {
+			return cid.Undef, errors.Wrapf(err, "failed calculating nonce for actor %s", from)
+		}
+		nonce = &next
 	}
 
-	msg := types.NewMessage(from, to, nonce, value, method, encodedParams)
+	msg := types.NewMessage(from, to, *nonce, value, method, encodedParams)
 	smsg, err := types.NewSignedMessage(*msg, s.signer, gasPrice, gasLimit)
 	if err != nil {
 		return cid.Undef, errors.Wrap(err, "failed to sign message")
@@ -143,6 +160,9 @@ func (s *Sender) Send(ctx context.Context, from, to address.Address, value *type
 	if _, err := s.inbox.Add(ctx, smsg); err != nil {
 		return cid.Undef, errors.Wrap(err, "failed to add message to message pool")
 	}
+	if err := s.journal.Add(smsg, height); err != nil {
+		return cid.Undef, errors.Wrap(err, "failed to add message to message journal")
+	}
 
 	if err = s.publish(Topic, smsgdata); err != nil {
 		return cid.Undef, errors.Wrap(err, "failed to publish message to network")
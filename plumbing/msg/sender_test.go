@@ -35,7 +35,7 @@ func TestSend(t *testing.T) {
 		pool := core.NewMessagePool(timer, config.NewDefaultConfig().Mpool, testhelpers.NewMockMessagePoolValidator())
 		nopPublish := func(string, []byte) error { return nil }
 
-		s := NewSender(w, chainStore, cst, timer, queue, pool, nullValidator{rejectMessages: true}, nopPublish)
+		s := NewSender(w, chainStore, cst, timer, queue, core.NewMessageJournal(), pool, nullValidator{rejectMessages: true}, nopPublish)
 		_, err := s.Send(context.Background(), addr, addr, types.NewAttoFILFromFIL(2), types.NewGasPrice(0), types.NewGasUnits(0), "")
 		assert.Errorf(t, err, "for testing")
 	})
@@ -55,15 +55,46 @@ func TestSend(t *testing.T) {
 			return nil
 		}
 
-		s := NewSender(w, chainStore, cst, timer, queue, pool, nullValidator{}, publish)
+		journal := core.NewMessageJournal()
+		s := NewSender(w, chainStore, cst, timer, queue, journal, pool, nullValidator{}, publish)
 		require.Empty(t, queue.List(addr))
 		require.Empty(t, pool.Pending())
 
-		_, err := s.Send(context.Background(), addr, toAddr, types.NewZeroAttoFIL(), types.NewGasPrice(0), types.NewGasUnits(0), "")
+		c, err := s.Send(context.Background(), addr, toAddr, types.NewZeroAttoFIL(), types.NewGasPrice(0), types.NewGasUnits(0), "")
 		require.NoError(t, err)
 		assert.Equal(t, uint64(1000), queue.List(addr)[0].Stamp)
 		assert.Equal(t, 1, len(pool.Pending()))
 		assert.True(t, publishCalled)
+
+		entry, ok := journal.Get(c)
+		require.True(t, ok)
+		assert.Equal(t, core.MessagePending, entry.Status)
+		assert.Equal(t, uint64(1000), entry.SentAt)
+	})
+
+	t.Run("send message with explicit nonce", func(t *testing.T) {
+		w, chainStore, cst := setupSendTest(t)
+		addr := w.Addresses()[0]
+		toAddr := address.NewForTestGetter()()
+		timer := testhelpers.NewTestMessagePoolAPI(1000)
+		queue := core.NewMessageQueue()
+		pool := core.NewMessagePool(timer, config.NewDefaultConfig().Mpool, testhelpers.NewMockMessagePoolValidator())
+		nopPublish := func(string, []byte) error { return nil }
+
+		s := NewSender(w, chainStore, cst, timer, queue, core.NewMessageJournal(), pool, nullValidator{}, nopPublish)
+
+		_, err := s.SendWithNonce(context.Background(), addr, toAddr, types.NewZeroAttoFIL(), types.NewGasPrice(0), types.NewGasUnits(0), 5, "")
+		require.NoError(t, err)
+		assert.Equal(t, types.Uint64(5), queue.List(addr)[0].Msg.Nonce)
+
+		// The next message without a nonce follows the explicit one.
+		_, err = s.Send(context.Background(), addr, toAddr, types.NewZeroAttoFIL(), types.NewGasPrice(0), types.NewGasUnits(0), "")
+		require.NoError(t, err)
+		assert.Equal(t, types.Uint64(6), queue.List(addr)[1].Msg.Nonce)
+
+		// A nonce not following the outbox is refused.
+		_, err = s.SendWithNonce(context.Background(), addr, toAddr, types.NewZeroAttoFIL(), types.NewGasPrice(0), types.NewGasUnits(0), 9, "")
+		assert.Error(t, err)
 	})
 
 	t.Run("send message avoids nonce race", func(t *testing.T) {
@@ -85,7 +116,7 @@ func TestSend(t *testing.T) {
 		pool := core.NewMessagePool(timer, mpoolCfg, testhelpers.NewMockMessagePoolValidator())
 		nopPublish := func(string, []byte) error { return nil }
 
-		s := NewSender(w, chainStore, cst, timer, queue, pool, nullValidator{}, nopPublish)
+		s := NewSender(w, chainStore, cst, timer, queue, core.NewMessageJournal(), pool, nullValidator{}, nopPublish)
 
 		var wg sync.WaitGroup
 		addTwentyMessages := func(batch int) {
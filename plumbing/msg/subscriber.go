@@ -0,0 +1,165 @@
+package msg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-hamt-ipld"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// EventFilter selects the events a subscription delivers. Unset fields match
+// every event.
+type EventFilter struct {
+	Emitter address.Address
+	Topic   string
+}
+
+// Matches returns true if the filter selects the event.
+func (f EventFilter) Matches(event *types.Event) bool {
+	if !f.Emitter.Empty() && f.Emitter != event.Emitter {
+		return false
+	}
+	return f.Topic == "" || f.Topic == event.Topic
+}
+
+// ChainEvent is an event committed on chain along with where it was emitted.
+type ChainEvent struct {
+	Event   *types.Event `json:"event"`
+	Message cid.Cid      `json:"message"`
+	Block   cid.Cid      `json:"block"`
+	Height  uint64       `json:"height"`
+}
+
+// Subscriber delivers the events actors emit as the chain grows.
+type Subscriber struct {
+	chainReader chain.ReadStore
+	// To get the receipts of the messages of new tipsets.
+	waiter *Waiter
+}
+
+// NewSubscriber returns a new Subscriber.
+func NewSubscriber(chainReader chain.ReadStore, bs bstore.Blockstore, cst *hamt.CborIpldStore) *Subscriber {
+	return &Subscriber{
+		chainReader: chainReader,
+		waiter:      NewWaiter(chainReader, bs, cst, 0),
+	}
+}
+
+// Subscribe invokes the callback for every event matching the filter that is
+// committed in a tipset the head moves to, in chain order. Events committed
+// before the call are not delivered. It returns when the callback or reading
+// the chain fails, or when the context is done.
+func (s *Subscriber) Subscribe(ctx context.Context, filter EventFilter, cb func(*ChainEvent) error) error {
+	ch := s.chainReader.HeadEvents().Sub(chain.NewHeadTopic)
+	defer s.chainReader.HeadEvents().Unsub(ch, chain.NewHeadTopic)
+
+	head, err := s.chainReader.GetTipSetAndState(s.chainReader.GetHead())
+	if err != nil {
+		return err
+	}
+	delivered, err := head.TipSet.Height()
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case raw, more := <-ch:
+			if !more {
+				return nil
+			}
+			switch raw := raw.(type) {
+			case error:
+				return raw
+			case types.TipSet:
+				if delivered, err = s.deliverUpTo(ctx, raw, delivered, filter, cb); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unexpected type in channel: %T", raw)
+			}
+		}
+	}
+}
+
+// deliverUpTo delivers the events of the tipsets from the one after height
+// delivered up to head, which need not be a child of the last head when the
+// head moves by more than one tipset, and returns the height of head.
+func (s *Subscriber) deliverUpTo(ctx context.Context, head types.TipSet, delivered uint64, filter EventFilter, cb func(*ChainEvent) error) (uint64, error) {
+	var tipSets []types.TipSet
+	var err error
+	for iterator := chain.IterAncestors(ctx, s.chainReader, head); !iterator.Complete(); err = iterator.Next() {
+		if err != nil {
+			return delivered, err
+		}
+		h, err := iterator.Value().Height()
+		if err != nil {
+			return delivered, err
+		}
+		if h <= delivered {
+			break
+		}
+		tipSets = append(tipSets, iterator.Value())
+	}
+
+	for i := len(tipSets) - 1; i >= 0; i-- {
+		if err := s.deliverTipSet(ctx, tipSets[i], filter, cb); err != nil {
+			return delivered, err
+		}
+		if delivered, err = tipSets[i].Height(); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// deliverTipSet delivers the matching events of the messages of ts.
+// TODO: getting the receipts of tipsets with more than one block applies the
+// tipset for every message. Receipts should be stored per tipset instead.
+func (s *Subscriber) deliverTipSet(ctx context.Context, ts types.TipSet, filter EventFilter, cb func(*ChainEvent) error) error {
+	h, err := ts.Height()
+	if err != nil {
+		return err
+	}
+
+	blks := ts.ToSlice()
+	types.SortBlocks(blks)
+	seen := make(map[string]struct{})
+	for _, blk := range blks {
+		for _, msg := range blk.Messages {
+			c, err := msg.Cid()
+			if err != nil {
+				return err
+			}
+			if _, ok := seen[c.String()]; ok {
+				continue
+			}
+			seen[c.String()] = struct{}{}
+
+			receipt, err := s.waiter.receiptFromTipSet(ctx, c, ts)
+			if err != nil {
+				return err
+			}
+			if receipt == nil {
+				continue
+			}
+			for _, event := range receipt.Events {
+				if !filter.Matches(event) {
+					continue
+				}
+				if err := cb(&ChainEvent{Event: event, Message: c, Block: blk.Cid(), Height: h}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
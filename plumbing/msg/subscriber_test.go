@@ -0,0 +1,91 @@
+package msg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestEventFilter(t *testing.T) {
+	tf.UnitTest(t)
+
+	event := &types.Event{Emitter: address.TestAddress, Topic: "redeem"}
+
+	assert.True(t, EventFilter{}.Matches(event))
+	assert.True(t, EventFilter{Emitter: address.TestAddress}.Matches(event))
+	assert.True(t, EventFilter{Topic: "redeem"}.Matches(event))
+	assert.True(t, EventFilter{Emitter: address.TestAddress, Topic: "redeem"}.Matches(event))
+	assert.False(t, EventFilter{Emitter: address.TestAddress2}.Matches(event))
+	assert.False(t, EventFilter{Topic: "close"}.Matches(event))
+}
+
+func TestSubscribe(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := requiredCommonDeps(t, consensus.DefaultGenesis)
+	subscriber := NewSubscriber(d.chainStore, d.blockstore, d.cst)
+
+	redeem := &types.Event{Emitter: address.PaymentBrokerAddress, Topic: "redeem", Payload: []byte{1}}
+	closed := &types.Event{Emitter: address.PaymentBrokerAddress, Topic: "close", Payload: []byte{2}}
+
+	m1, m2 := newSignedMessage(), newSignedMessage()
+	headTipSetAndState, err := d.chainStore.GetTipSetAndState(d.chainStore.GetHead())
+	require.NoError(t, err)
+	chainWithMsgs := core.NewChainWithMessages(d.cst, headTipSetAndState.TipSet, smsgsSet{smsgs{m1, m2}})
+	blk := chainWithMsgs[len(chainWithMsgs)-1].ToSlice()[0]
+	blk.MessageReceipts = []*types.MessageReceipt{
+		{Events: []*types.Event{redeem, closed}},
+		{Events: []*types.Event{redeem}},
+	}
+	ts := types.RequireNewTipSet(t, blk)
+
+	events := make(chan *ChainEvent, 3)
+	done := make(chan error)
+	go func() {
+		done <- subscriber.Subscribe(ctx, EventFilter{Topic: "redeem"}, func(event *ChainEvent) error {
+			events <- event
+			return nil
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	th.RequirePutTsas(ctx, t, d.chainStore, &chain.TipSetAndState{
+		TipSet:          ts,
+		TipSetStateRoot: blk.StateRoot,
+	})
+	require.NoError(t, d.chainStore.SetHead(ctx, ts))
+
+	m1Cid, err := m1.Cid()
+	require.NoError(t, err)
+	m2Cid, err := m2.Cid()
+	require.NoError(t, err)
+	for _, msgCid := range []cid.Cid{m1Cid, m2Cid} {
+		select {
+		case event := <-events:
+			assert.Equal(t, redeem, event.Event)
+			assert.Equal(t, msgCid, event.Message)
+			assert.Equal(t, blk.Cid(), event.Block)
+			assert.Equal(t, uint64(blk.Height), event.Height)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Empty(t, events)
+}
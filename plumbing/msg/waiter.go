@@ -26,6 +26,12 @@ type Waiter struct {
 	chainReader chain.ReadStore
 	cst         *hamt.CborIpldStore
 	bs          bstore.Blockstore
+	// confidence is the number of tipsets Wait waits for on top of the
+	// tipset containing a message before it considers the message executed.
+	confidence uint64
+	// index, if set, locates the messages Find looks for instead of a
+	// search of the chain, see UseIndex.
+	index *Indexer
 }
 
 // ChainMessage is an on-chain message with its block and receipt.
@@ -33,39 +39,64 @@ type ChainMessage struct {
 	Message *types.SignedMessage
 	Block   *types.Block
 	Receipt *types.MessageReceipt
+	// TipSet is the tipset the message was executed in.
+	TipSet types.TipSet `json:"-"`
 }
 
-// NewWaiter returns a new Waiter.
-func NewWaiter(chainStore chain.ReadStore, bs bstore.Blockstore, cst *hamt.CborIpldStore) *Waiter {
+// NewWaiter returns a new Waiter. Wait waits for confidence tipsets on top of
+// the tipset containing a message.
+func NewWaiter(chainStore chain.ReadStore, bs bstore.Blockstore, cst *hamt.CborIpldStore, confidence uint64) *Waiter {
 	return &Waiter{
 		chainReader: chainStore,
 		cst:         cst,
 		bs:          bs,
+		confidence:  confidence,
 	}
 }
 
+// UseIndex makes Find look messages up in index rather than search the
+// chain for them.
+func (w *Waiter) UseIndex(index *Indexer) {
+	w.index = index
+}
+
 // Find searches the blockchain history for a message (but doesn't wait).
 func (w *Waiter) Find(ctx context.Context, msgCid cid.Cid) (*ChainMessage, bool, error) {
+	if w.index != nil {
+		return w.findIndexed(ctx, msgCid)
+	}
+
 	headTipSetAndState, err := w.chainReader.GetTipSetAndState(w.chainReader.GetHead())
 	if err != nil {
 		return nil, false, err
 	}
-	return w.findMessage(ctx, &headTipSetAndState.TipSet, msgCid)
+	return w.findMessage(ctx, headTipSetAndState.TipSet, msgCid, map[string]struct{}{})
 }
 
-// Wait invokes the callback when a message with the given cid appears on chain.
-// See api description.
+// Wait invokes the callback when a message with the given cid appears on chain
+// with the confidence the waiter was constructed with. See WaitConfirmed.
 //
 // Note: this method does too much -- the callback should just receive the tipset
 // containing the message and the caller should pull the receipt out of the block
 // if in fact that's what it wants to do, using something like receiptFromTipset.
 // Something like receiptFromTipset is necessary because not every message in
 // a block will have a receipt in the tipset: it might be a duplicate message.
+func (w *Waiter) Wait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
+	return w.WaitConfirmed(ctx, msgCid, w.confidence, func(chainMsg *ChainMessage) error {
+		return cb(chainMsg.Block, chainMsg.Message, chainMsg.Receipt)
+	})
+}
+
+// WaitConfirmed invokes the callback once the tipset a message with the given
+// cid was executed in is on the chain with at least confidence tipsets on top
+// of it. If that tipset is orphaned by a reorg before then, it waits for the
+// message to appear on the new chain. It returns an error if one is
+// encountered or if the context is canceled.
 //
 // TODO: This implementation will become prohibitively expensive since it
 // traverses the entire chain. We should use an index instead.
 // https://github.com/filecoin-project/go-filecoin/issues/1518
-func (w *Waiter) Wait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
+func (w *Waiter) WaitConfirmed(ctx context.Context, msgCid cid.Cid, confidence uint64, cb func(*ChainMessage) error) error {
 	ctx = log.Start(ctx, "Waiter.Wait")
 	defer log.Finish(ctx)
 	log.Infof("Calling Waiter.Wait CID: %s", msgCid.String())
@@ -73,31 +104,71 @@ func (w *Waiter) Wait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block,
 	ch := w.chainReader.HeadEvents().Sub(chain.NewHeadTopic)
 	defer w.chainReader.HeadEvents().Unsub(ch, chain.NewHeadTopic)
 
-	chainMsg, found, err := w.Find(ctx, msgCid)
+	headTipSetAndState, err := w.chainReader.GetTipSetAndState(w.chainReader.GetHead())
 	if err != nil {
 		return err
 	}
-	if found {
-		return cb(chainMsg.Block, chainMsg.Message, chainMsg.Receipt)
-	}
+	head := headTipSetAndState.TipSet
 
-	chainMsg, found, err = w.waitForMessage(ctx, ch, msgCid)
-	if found {
-		return cb(chainMsg.Block, chainMsg.Message, chainMsg.Receipt)
+	// searched holds the tipsets known not to contain the message, so that
+	// new heads are only searched down to where they join the chain already
+	// searched.
+	searched := make(map[string]struct{})
+	var chainMsg *ChainMessage
+	for {
+		if chainMsg != nil {
+			onChain, err := w.isAncestor(ctx, chainMsg.TipSet, head)
+			if err != nil {
+				return err
+			}
+			if !onChain {
+				log.Infof("Waiter.Wait: tipset containing %s was orphaned, waiting again", msgCid.String())
+				chainMsg = nil
+			}
+		}
+
+		if chainMsg == nil {
+			var found bool
+			chainMsg, found, err = w.findMessage(ctx, head, msgCid, searched)
+			if err != nil {
+				return err
+			}
+			if !found {
+				chainMsg = nil
+			}
+		}
+
+		if chainMsg != nil {
+			confirmed, err := isConfirmed(chainMsg.TipSet, head, confidence)
+			if err != nil {
+				return err
+			}
+			if confirmed {
+				return cb(chainMsg)
+			}
+		}
+
+		head, err = nextHead(ctx, ch)
+		if err != nil {
+			return err
+		}
 	}
-	return err
 }
 
-// findMessage looks for a message CID in the chain and returns the message,
-// block and receipt, when it is found. Returns the found message/block or nil
-// if now block with the given CID exists in the chain.
-func (w *Waiter) findMessage(ctx context.Context, ts *types.TipSet, msgCid cid.Cid) (*ChainMessage, bool, error) {
+// findMessage looks for a message CID in ts and its ancestors that are not in
+// searched and returns the message, block and receipt, when it is found. The
+// tipsets searched are added to searched.
+func (w *Waiter) findMessage(ctx context.Context, ts types.TipSet, msgCid cid.Cid, searched map[string]struct{}) (*ChainMessage, bool, error) {
 	var err error
-	for iterator := chain.IterAncestors(ctx, w.chainReader, *ts); !iterator.Complete(); err = iterator.Next() {
+	for iterator := chain.IterAncestors(ctx, w.chainReader, ts); !iterator.Complete(); err = iterator.Next() {
 		if err != nil {
 			log.Errorf("Waiter.Wait: %s", err)
 			return nil, false, err
 		}
+		key := iterator.Value().String()
+		if _, ok := searched[key]; ok {
+			break
+		}
 		for _, blk := range iterator.Value() {
 			for _, msg := range blk.Messages {
 				c, err := msg.Cid()
@@ -109,51 +180,99 @@ func (w *Waiter) findMessage(ctx context.Context, ts *types.TipSet, msgCid cid.C
 					if err != nil {
 						return nil, false, errors.Wrap(err, "error retrieving receipt from tipset")
 					}
-					return &ChainMessage{msg, blk, recpt}, true, nil
+					return &ChainMessage{msg, blk, recpt, iterator.Value()}, true, nil
 				}
 			}
 		}
+		searched[key] = struct{}{}
 	}
 	return nil, false, nil
 }
 
-// waitForMessage looks for a message CID in a channel of tipsets and returns
-// the message, block and receipt, when it is found. Reads until the channel is
-// closed or the context done. Returns the found message/block (or nil if the
-// channel closed without finding it), whether it was found, or an error.
-func (w *Waiter) waitForMessage(ctx context.Context, ch <-chan interface{}, msgCid cid.Cid) (*ChainMessage, bool, error) {
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, false, ctx.Err()
-		case raw, more := <-ch:
-			if !more {
-				return nil, false, nil
-			}
-			switch raw := raw.(type) {
-			case error:
-				e := raw.(error)
-				log.Errorf("Waiter.Wait: %s", e)
-				return nil, false, e
-			case types.TipSet:
-				for _, blk := range raw {
-					for _, msg := range blk.Messages {
-						c, err := msg.Cid()
-						if err != nil {
-							return nil, false, err
-						}
-						if c.Equals(msgCid) {
-							recpt, err := w.receiptFromTipSet(ctx, msgCid, raw)
-							if err != nil {
-								return nil, false, errors.Wrap(err, "error retrieving receipt from tipset")
-							}
-							return &ChainMessage{msg, blk, recpt}, true, nil
-						}
-					}
-				}
-			default:
-				return nil, false, fmt.Errorf("unexpected type in channel: %T", raw)
-			}
+// findIndexed looks the location of a message up in the index and returns
+// the message, block and receipt, when it is on chain.
+func (w *Waiter) findIndexed(ctx context.Context, msgCid cid.Cid) (*ChainMessage, bool, error) {
+	loc, found, err := w.index.Find(msgCid)
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	tsas, err := w.chainReader.GetTipSetAndState(loc.TipSet)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to get tipset %s of message %s", loc.TipSet.String(), msgCid)
+	}
+	blk, ok := tsas.TipSet[loc.Block]
+	if !ok {
+		return nil, false, fmt.Errorf("block %s of message %s not in tipset %s", loc.Block, msgCid, loc.TipSet.String())
+	}
+	for _, msg := range blk.Messages {
+		c, err := msg.Cid()
+		if err != nil {
+			return nil, false, err
+		}
+		if !c.Equals(msgCid) {
+			continue
+		}
+		recpt, err := w.receiptFromTipSet(ctx, msgCid, tsas.TipSet)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "error retrieving receipt from tipset")
+		}
+		return &ChainMessage{msg, blk, recpt, tsas.TipSet}, true, nil
+	}
+	return nil, false, fmt.Errorf("message %s not in block %s", msgCid, loc.Block)
+}
+
+// isAncestor returns true if ts is head or one of its ancestors.
+func (w *Waiter) isAncestor(ctx context.Context, ts, head types.TipSet) (bool, error) {
+	h, err := ts.Height()
+	if err != nil {
+		return false, err
+	}
+	for iterator := chain.IterAncestors(ctx, w.chainReader, head); !iterator.Complete(); err = iterator.Next() {
+		if err != nil {
+			return false, err
+		}
+		ancestorHeight, err := iterator.Value().Height()
+		if err != nil {
+			return false, err
+		}
+		if ancestorHeight <= h {
+			return iterator.Value().Equals(ts), nil
+		}
+	}
+	return false, nil
+}
+
+// isConfirmed returns true if head is at least confidence tipsets above ts.
+func isConfirmed(ts, head types.TipSet, confidence uint64) (bool, error) {
+	h, err := ts.Height()
+	if err != nil {
+		return false, err
+	}
+	headHeight, err := head.Height()
+	if err != nil {
+		return false, err
+	}
+	return headHeight >= h+confidence, nil
+}
+
+// nextHead returns the next tipset published on the head events channel ch.
+func nextHead(ctx context.Context, ch <-chan interface{}) (types.TipSet, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case raw, more := <-ch:
+		if !more {
+			return nil, errors.New("head events closed")
+		}
+		switch raw := raw.(type) {
+		case error:
+			log.Errorf("Waiter.Wait: %s", raw)
+			return nil, raw
+		case types.TipSet:
+			return raw, nil
+		default:
+			return nil, fmt.Errorf("unexpected type in channel: %T", raw)
 		}
 	}
 }
@@ -46,12 +46,12 @@ type smsgsSet [][]*types.SignedMessage
 
 func setupTest(t *testing.T) (*hamt.CborIpldStore, *chain.DefaultStore, *Waiter) {
 	d := requiredCommonDeps(t, consensus.DefaultGenesis)
-	return d.cst, d.chainStore, NewWaiter(d.chainStore, d.blockstore, d.cst)
+	return d.cst, d.chainStore, NewWaiter(d.chainStore, d.blockstore, d.cst, 0)
 }
 
 func setupTestWithGif(t *testing.T, gif consensus.GenesisInitFunc) (*hamt.CborIpldStore, *chain.DefaultStore, *Waiter) {
 	d := requiredCommonDeps(t, gif)
-	return d.cst, d.chainStore, NewWaiter(d.chainStore, d.blockstore, d.cst)
+	return d.cst, d.chainStore, NewWaiter(d.chainStore, d.blockstore, d.cst, 0)
 }
 
 func TestWait(t *testing.T) {
@@ -241,3 +241,87 @@ func TestWaitRespectsContextCancel(t *testing.T) {
 		assert.Fail(t, "Wait should have returned when context was canceled")
 	}
 }
+
+func TestWaitConfirmed(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	putAndSetHead := func(t *testing.T, chainStore *chain.DefaultStore, tipSets ...types.TipSet) {
+		for _, ts := range tipSets {
+			th.RequirePutTsas(ctx, t, chainStore, &chain.TipSetAndState{
+				TipSet:          ts,
+				TipSetStateRoot: ts.ToSlice()[0].StateRoot,
+			})
+		}
+		require.NoError(t, chainStore.SetHead(ctx, tipSets[len(tipSets)-1]))
+	}
+
+	waitAsync := func(waiter *Waiter, msg *types.SignedMessage, confidence uint64) <-chan *ChainMessage {
+		msgCid, err := msg.Cid()
+		require.NoError(t, err)
+		results := make(chan *ChainMessage, 1)
+		go func() {
+			assert.NoError(t, waiter.WaitConfirmed(ctx, msgCid, confidence, func(chainMsg *ChainMessage) error {
+				results <- chainMsg
+				return nil
+			}))
+		}()
+		time.Sleep(10 * time.Millisecond)
+		return results
+	}
+
+	requireResult := func(t *testing.T, results <-chan *ChainMessage) *ChainMessage {
+		select {
+		case chainMsg := <-results:
+			return chainMsg
+		case <-time.After(2 * time.Second):
+			require.Fail(t, "timed out waiting for message")
+			return nil
+		}
+	}
+
+	t.Run("waits for confidence tipsets on top", func(t *testing.T) {
+		cst, chainStore, waiter := setupTest(t)
+		genesis, err := chainStore.GetTipSetAndState(chainStore.GetHead())
+		require.NoError(t, err)
+
+		m1 := newSignedMessage()
+		chn := core.NewChainWithMessages(cst, genesis.TipSet, smsgsSet{smsgs{m1}}, smsgsSet{}, smsgsSet{})
+		results := waitAsync(waiter, m1, 2)
+
+		putAndSetHead(t, chainStore, chn[1], chn[2])
+		time.Sleep(10 * time.Millisecond)
+		assert.Empty(t, results)
+
+		putAndSetHead(t, chainStore, chn[3])
+		chainMsg := requireResult(t, results)
+		assert.True(t, types.SmsgCidsEqual(m1, chainMsg.Message))
+		assert.Equal(t, chn[1], chainMsg.TipSet)
+	})
+
+	t.Run("waits again when the tipset is orphaned", func(t *testing.T) {
+		cst, chainStore, waiter := setupTest(t)
+		genesis, err := chainStore.GetTipSetAndState(chainStore.GetHead())
+		require.NoError(t, err)
+
+		m1 := newSignedMessage()
+		orphaned := core.NewChainWithMessages(cst, genesis.TipSet, smsgsSet{smsgs{m1}})
+		fork := core.NewChainWithMessages(cst, genesis.TipSet, smsgsSet{}, smsgsSet{}, smsgsSet{smsgs{m1}}, smsgsSet{})
+		results := waitAsync(waiter, m1, 1)
+
+		putAndSetHead(t, chainStore, orphaned[1])
+		time.Sleep(10 * time.Millisecond)
+		assert.Empty(t, results)
+
+		// the fork does not contain the message yet
+		putAndSetHead(t, chainStore, fork[1], fork[2])
+		time.Sleep(10 * time.Millisecond)
+		assert.Empty(t, results)
+
+		putAndSetHead(t, chainStore, fork[3], fork[4])
+		chainMsg := requireResult(t, results)
+		assert.True(t, types.SmsgCidsEqual(m1, chainMsg.Message))
+		assert.Equal(t, fork[3], chainMsg.TipSet)
+	})
+}
@@ -0,0 +1,79 @@
+package vchrs
+
+import (
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/util/convert"
+)
+
+// Store is plumbing implementation for storing signed payment vouchers
+type Store struct {
+	vouchersDs repo.Datastore
+}
+
+// PaymentVoucherPrefix is the datastore prefix for payment vouchers
+const PaymentVoucherPrefix = "paymentvouchers"
+
+// New returns a new Store.
+func New(vouchersDatastore repo.Datastore) *Store {
+	return &Store{vouchersDs: vouchersDatastore}
+}
+
+// Ls returns a slice of all stored vouchers, with a possible error
+func (store *Store) Ls() ([]*types.PaymentVoucher, error) {
+	var vouchers []*types.PaymentVoucher
+
+	results, err := store.vouchersDs.Query(query.Query{Prefix: "/" + PaymentVoucherPrefix})
+	if err != nil {
+		return vouchers, errors.Wrap(err, "failed to query vouchers from datastore")
+	}
+	for entry := range results.Next() {
+		var voucher types.PaymentVoucher
+		if err := cbor.DecodeInto(entry.Value, &voucher); err != nil {
+			return vouchers, errors.Wrap(err, "failed to unmarshal vouchers from datastore")
+		}
+		vouchers = append(vouchers, &voucher)
+	}
+
+	return vouchers, nil
+}
+
+// Put puts the voucher into the datastore. Vouchers are keyed by payer,
+// channel, amount, the height they are valid at and their condition, so
+// putting the same voucher twice is a no-op, while vouchers differing in any
+// of these are stored alongside each other.
+func (store *Store) Put(voucher *types.PaymentVoucher) error {
+	datum, err := cbor.DumpObject(voucher)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal voucher")
+	}
+
+	condition := "unconditional"
+	if voucher.Condition != nil {
+		conditionCid, err := convert.ToCid(voucher.Condition)
+		if err != nil {
+			return errors.Wrap(err, "could not hash voucher condition")
+		}
+		condition = conditionCid.String()
+	}
+
+	key := datastore.KeyWithNamespaces([]string{
+		PaymentVoucherPrefix,
+		voucher.Payer.String(),
+		voucher.Channel.KeyString(),
+		voucher.Amount.String(),
+		voucher.ValidAt.String(),
+		condition,
+	})
+	err = store.vouchersDs.Put(key, datum)
+	if err != nil {
+		return errors.Wrap(err, "could not save voucher to disk")
+	}
+
+	return nil
+}
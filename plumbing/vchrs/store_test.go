@@ -0,0 +1,82 @@
+package vchrs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/vchrs"
+	"github.com/filecoin-project/go-filecoin/repo"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestVoucherStoreRoundTrip(t *testing.T) {
+	tf.UnitTest(t)
+
+	addressMaker := address.NewForTestGetter()
+	store := vchrs.New(repo.NewInMemoryRepo().DealsDs)
+
+	voucher := &types.PaymentVoucher{
+		Channel:   *types.NewChannelID(19),
+		Payer:     addressMaker(),
+		Target:    addressMaker(),
+		Amount:    *types.NewAttoFILFromFIL(13),
+		ValidAt:   *types.NewBlockHeight(231),
+		Signature: []byte("signature"),
+	}
+
+	require.NoError(t, store.Put(voucher))
+	vouchers, err := store.Ls()
+	require.NoError(t, err)
+	require.Len(t, vouchers, 1)
+
+	assert.Equal(t, voucher.Channel, vouchers[0].Channel)
+	assert.Equal(t, voucher.Payer, vouchers[0].Payer)
+	assert.Equal(t, voucher.Target, vouchers[0].Target)
+	assert.Equal(t, voucher.Amount, vouchers[0].Amount)
+	assert.Equal(t, voucher.ValidAt, vouchers[0].ValidAt)
+	assert.Equal(t, voucher.Signature, vouchers[0].Signature)
+
+	t.Run("putting the same voucher twice stores it once", func(t *testing.T) {
+		require.NoError(t, store.Put(voucher))
+		vouchers, err := store.Ls()
+		require.NoError(t, err)
+		assert.Len(t, vouchers, 1)
+	})
+
+	t.Run("a voucher for the same amount valid at another height does not replace it", func(t *testing.T) {
+		later := *voucher
+		later.ValidAt = *types.NewBlockHeight(400)
+		later.Signature = []byte("other signature")
+
+		require.NoError(t, store.Put(&later))
+		vouchers, err := store.Ls()
+		require.NoError(t, err)
+		require.Len(t, vouchers, 2)
+
+		validAts := []types.BlockHeight{vouchers[0].ValidAt, vouchers[1].ValidAt}
+		assert.Contains(t, validAts, voucher.ValidAt)
+		assert.Contains(t, validAts, later.ValidAt)
+	})
+
+	t.Run("vouchers differing only in condition are stored apart", func(t *testing.T) {
+		store := vchrs.New(repo.NewInMemoryRepo().DealsDs)
+
+		conditioned := *voucher
+		conditioned.Condition = &types.Predicate{To: addressMaker(), Method: "verifyPieceStorage", Params: []interface{}{uint64(1)}}
+		otherCondition := *voucher
+		otherCondition.Condition = &types.Predicate{To: addressMaker(), Method: "verifyPieceStorage", Params: []interface{}{uint64(2)}}
+
+		require.NoError(t, store.Put(voucher))
+		require.NoError(t, store.Put(&conditioned))
+		require.NoError(t, store.Put(&otherCondition))
+		require.NoError(t, store.Put(&conditioned))
+
+		vouchers, err := store.Ls()
+		require.NoError(t, err)
+		assert.Len(t, vouchers, 3)
+	})
+}
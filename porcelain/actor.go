@@ -0,0 +1,69 @@
+package porcelain
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// actcAPI is the subset of the plumbing.API that ActorCreate uses.
+type actcAPI interface {
+	MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+}
+
+// ActorCreate asks the init actor to create an actor running code, funded
+// with value and constructed from params. It waits for the actor to appear
+// on chain and returns its address. A failure to create the actor is
+// translated with errs, the errors of the created actor.
+func ActorCreate(
+	ctx context.Context,
+	plumbing actcAPI,
+	from address.Address,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+	code cid.Cid,
+	value *types.AttoFIL,
+	errs map[uint8]error,
+	params ...interface{},
+) (address.Address, error) {
+	encodedParams, err := abi.ToEncodedValues(params...)
+	if err != nil {
+		return address.Undef, errors.Wrap(err, "could not encode params")
+	}
+
+	msgCid, err := plumbing.MessageSendWithDefaultAddress(
+		ctx,
+		from,
+		address.InitAddress,
+		value,
+		gasPrice,
+		gasLimit,
+		"exec",
+		code,
+		encodedParams,
+	)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	var actorAddr address.Address
+	err = plumbing.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) (err error) {
+		if receipt.ExitCode != uint8(0) {
+			return vmErrors.VMExitCodeToError(receipt.ExitCode, errs)
+		}
+		actorAddr, err = address.NewFromBytes(receipt.Return[0])
+		return err
+	})
+	if err != nil {
+		return address.Undef, err
+	}
+
+	return actorAddr, nil
+}
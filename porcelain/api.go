@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/plumbing"
+	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -44,6 +45,17 @@ func New(plumbing *plumbing.API) *API {
 	return &API{plumbing}
 }
 
+// BootstrapAddPeers adds peer addresses to the bootstrap peers in the config
+func (a *API) BootstrapAddPeers(addrs []string) ([]string, error) {
+	return BootstrapAddPeers(a, addrs)
+}
+
+// BootstrapRemovePeers removes peer addresses, or all of them, from the
+// bootstrap peers in the config
+func (a *API) BootstrapRemovePeers(addrs []string, all bool) ([]string, error) {
+	return BootstrapRemovePeers(a, addrs, all)
+}
+
 // ChainBlockHeight determines the current block height
 func (a *API) ChainBlockHeight() (*types.BlockHeight, error) {
 	return ChainBlockHeight(a)
@@ -59,6 +71,12 @@ func (a *API) DealGet(proposalCid cid.Cid) *storagedeal.Deal {
 	return DealGet(a, proposalCid)
 }
 
+// DealFindPieceMiner returns the miner of a posted or complete deal storing
+// the given piece
+func (a *API) DealFindPieceMiner(pieceRef cid.Cid) (address.Address, error) {
+	return DealFindPieceMiner(a, pieceRef)
+}
+
 // MessagePoolWait waits for the message pool to have at least messageCount unmined messages.
 // It's useful for integration testing.
 func (a *API) MessagePoolWait(ctx context.Context, messageCount uint) ([]*types.SignedMessage, error) {
@@ -94,13 +112,14 @@ func (a *API) MessageSendWithDefaultAddress(
 func (a *API) MinerCreate(
 	ctx context.Context,
 	accountAddr address.Address,
+	workerAddr address.Address,
 	gasPrice types.AttoFIL,
 	gasLimit types.GasUnits,
 	pledge uint64,
 	pid peer.ID,
 	collateral *types.AttoFIL,
 ) (_ *address.Address, err error) {
-	return MinerCreate(ctx, a, accountAddr, gasPrice, gasLimit, pledge, pid, collateral)
+	return MinerCreate(ctx, a, accountAddr, workerAddr, gasPrice, gasLimit, pledge, pid, collateral)
 }
 
 // MinerPreviewCreate previews the Gas cost of creating a miner
@@ -119,11 +138,96 @@ func (a *API) MinerGetAsk(ctx context.Context, minerAddr address.Address, askID
 	return MinerGetAsk(ctx, a, minerAddr, askID)
 }
 
+// MinerListDeals returns the locally stored deals made with the given miner
+func (a *API) MinerListDeals(minerAddr address.Address) ([]*storagedeal.Deal, error) {
+	return MinerListDeals(a, minerAddr)
+}
+
+// MinerPaymentsReport reconciles the payments of a miner's active deals
+func (a *API) MinerPaymentsReport(ctx context.Context, minerAddr address.Address) (*MinerPaymentsReportResult, error) {
+	return MinerPaymentsReport(ctx, a, minerAddr)
+}
+
+// MinerGetSectorExpirations returns when the deals in each committed sector of the given miner end
+func (a *API) MinerGetSectorExpirations(ctx context.Context, minerAddr address.Address) ([]*SectorExpiration, error) {
+	return MinerGetSectorExpirations(ctx, a, minerAddr)
+}
+
+// MinerListAsks returns the unexpired asks of the given miner
+func (a *API) MinerListAsks(ctx context.Context, minerAddr address.Address) ([]Ask, error) {
+	return MinerListAsks(ctx, a, minerAddr)
+}
+
+// MinerRemoveAsk retires an ask of the given miner. See implementation for details.
+func (a *API) MinerRemoveAsk(ctx context.Context, from address.Address, miner address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, askID uint64) (cid.Cid, error) {
+	return MinerRemoveAsk(ctx, a, from, miner, gasPrice, gasLimit, askID)
+}
+
+// MinerAddPledge increases the pledge of the given miner. See implementation for details.
+func (a *API) MinerAddPledge(ctx context.Context, from address.Address, miner address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, sectors uint64, collateral *types.AttoFIL) (cid.Cid, error) {
+	return MinerAddPledge(ctx, a, from, miner, gasPrice, gasLimit, sectors, collateral)
+}
+
+// MinerWithdrawPledge decreases the pledge of the given miner. See implementation for details.
+func (a *API) MinerWithdrawPledge(ctx context.Context, from address.Address, miner address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, sectors uint64) (cid.Cid, error) {
+	return MinerWithdrawPledge(ctx, a, from, miner, gasPrice, gasLimit, sectors)
+}
+
+// MultisigCreate creates a multisig actor. See implementation for details.
+func (a *API) MultisigCreate(ctx context.Context, from address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, signers []address.Address, required uint64, value *types.AttoFIL) (address.Address, error) {
+	return MultisigCreate(ctx, a, from, gasPrice, gasLimit, signers, required, value)
+}
+
+// MultisigPropose proposes a multisig transaction. See implementation for details.
+func (a *API) MultisigPropose(ctx context.Context, from address.Address, multisigAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, to address.Address, value *types.AttoFIL, method string, params []string) (cid.Cid, error) {
+	return MultisigPropose(ctx, a, from, multisigAddr, gasPrice, gasLimit, to, value, method, params)
+}
+
+// MultisigApprove approves a pending multisig transaction. See implementation for details.
+func (a *API) MultisigApprove(ctx context.Context, from address.Address, multisigAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, txID uint64) (cid.Cid, error) {
+	return MultisigApprove(ctx, a, from, multisigAddr, gasPrice, gasLimit, txID)
+}
+
+// MultisigCancel cancels a pending multisig transaction. See implementation for details.
+func (a *API) MultisigCancel(ctx context.Context, from address.Address, multisigAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, txID uint64) (cid.Cid, error) {
+	return MultisigCancel(ctx, a, from, multisigAddr, gasPrice, gasLimit, txID)
+}
+
+// VestingCreate creates a vesting actor. See implementation for details.
+func (a *API) VestingCreate(ctx context.Context, from address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, beneficiary address.Address, start, cliff, end *types.BlockHeight, value *types.AttoFIL) (address.Address, error) {
+	return VestingCreate(ctx, a, from, gasPrice, gasLimit, beneficiary, start, cliff, end, value)
+}
+
+// VestingWithdraw withdraws the vested funds of a vesting actor. See implementation for details.
+func (a *API) VestingWithdraw(ctx context.Context, from address.Address, vestingAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return VestingWithdraw(ctx, a, from, vestingAddr, gasPrice, gasLimit)
+}
+
+// VestingRevoke revokes the schedule of a vesting actor. See implementation for details.
+func (a *API) VestingRevoke(ctx context.Context, from address.Address, vestingAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return VestingRevoke(ctx, a, from, vestingAddr, gasPrice, gasLimit)
+}
+
+// FaucetTap asks the faucet of the network to fund an address. See implementation for details.
+func (a *API) FaucetTap(ctx context.Context, from address.Address, addr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return FaucetTap(ctx, a, from, addr, gasPrice, gasLimit)
+}
+
 // MinerGetOwnerAddress queries for the owner address of the given miner
 func (a *API) MinerGetOwnerAddress(ctx context.Context, minerAddr address.Address) (address.Address, error) {
 	return MinerGetOwnerAddress(ctx, a, minerAddr)
 }
 
+// MinerGetWorkerAddress queries for the worker address of the given miner
+func (a *API) MinerGetWorkerAddress(ctx context.Context, minerAddr address.Address) (address.Address, error) {
+	return MinerGetWorkerAddress(ctx, a, minerAddr)
+}
+
+// MinerChangeWorker replaces the worker of the given miner. See implementation for details.
+func (a *API) MinerChangeWorker(ctx context.Context, from address.Address, miner address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, workerAddr address.Address) (cid.Cid, error) {
+	return MinerChangeWorker(ctx, a, from, miner, gasPrice, gasLimit, workerAddr)
+}
+
 // MinerGetKey queries for the public key of the given miner
 func (a *API) MinerGetKey(ctx context.Context, minerAddr address.Address) ([]byte, error) {
 	return MinerGetKey(ctx, a, minerAddr)
@@ -134,6 +238,11 @@ func (a *API) MinerGetPeerID(ctx context.Context, minerAddr address.Address) (pe
 	return MinerGetPeerID(ctx, a, minerAddr)
 }
 
+// MinerGetInfo queries for the description of the given miner
+func (a *API) MinerGetInfo(ctx context.Context, minerAddr address.Address) (*MinerInfo, error) {
+	return MinerGetInfo(ctx, a, minerAddr)
+}
+
 // MinerSetPrice configures the price of storage. See implementation for details.
 func (a *API) MinerSetPrice(ctx context.Context, from address.Address, miner address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, price *types.AttoFIL, expiry *big.Int) (MinerSetPriceResponse, error) {
 	return MinerSetPrice(ctx, a, from, miner, gasPrice, gasLimit, price, expiry)
@@ -167,6 +276,17 @@ func (a *API) WalletDefaultAddress() (address.Address, error) {
 	return WalletDefaultAddress(a)
 }
 
+// WalletRestore restores the addresses derived from mnemonic into the wallet.
+func (a *API) WalletRestore(ctx context.Context, mnemonic string) ([]address.Address, error) {
+	return WalletRestore(ctx, a, mnemonic)
+}
+
+// WalletTransactions returns the messages on chain sent from or to addr with
+// their receipts, the most recent first.
+func (a *API) WalletTransactions(ctx context.Context, addr address.Address) ([]*msg.ChainMessage, error) {
+	return WalletTransactions(ctx, a, addr)
+}
+
 // PaymentChannelLs lists payment channels for a given payer
 func (a *API) PaymentChannelLs(
 	ctx context.Context,
@@ -176,6 +296,12 @@ func (a *API) PaymentChannelLs(
 	return PaymentChannelLs(ctx, a, fromAddr, payerAddr)
 }
 
+// PaymentChannelHistory returns the most recent redemptions of the channel of
+// payer, the oldest first.
+func (a *API) PaymentChannelHistory(ctx context.Context, payer address.Address, channel *types.ChannelID) ([]*paymentbroker.Redemption, error) {
+	return PaymentChannelHistory(ctx, a, payer, channel)
+}
+
 // PaymentChannelVoucher returns a signed payment channel voucher
 func (a *API) PaymentChannelVoucher(
 	ctx context.Context,
@@ -188,11 +314,48 @@ func (a *API) PaymentChannelVoucher(
 	return PaymentChannelVoucher(ctx, a, fromAddr, channel, amount, validAt, condition)
 }
 
+// PaymentChannelSignAssignment returns the signature of fromAddr on the
+// assignment of the channel of payer to newTarget
+func (a *API) PaymentChannelSignAssignment(
+	ctx context.Context,
+	fromAddr address.Address,
+	payer address.Address,
+	channel *types.ChannelID,
+	newTarget address.Address,
+) (types.Signature, error) {
+	return PaymentChannelSignAssignment(ctx, a, fromAddr, payer, channel, newTarget)
+}
+
+// PaymentChannelVoucherExport encodes all stored vouchers for a payer's
+// channel into a string for out-of-band delivery
+func (a *API) PaymentChannelVoucherExport(
+	ctx context.Context,
+	payerAddr address.Address,
+	channel *types.ChannelID,
+) (string, error) {
+	return PaymentChannelVoucherExport(ctx, a, payerAddr, channel)
+}
+
+// PaymentChannelVoucherImport validates and stores exported vouchers
+func (a *API) PaymentChannelVoucherImport(
+	ctx context.Context,
+	fromAddr address.Address,
+	encodedVouchers string,
+) ([]*types.PaymentVoucher, error) {
+	return PaymentChannelVoucherImport(ctx, a, fromAddr, encodedVouchers)
+}
+
 // ClientListAsks returns a channel with asks from the latest chain state
 func (a *API) ClientListAsks(ctx context.Context) <-chan Ask {
 	return ClientListAsks(ctx, a)
 }
 
+// ClientCheckReachability sets whether the miner of each ask answers a ping
+// within timeout.
+func (a *API) ClientCheckReachability(ctx context.Context, asks []Ask, timeout time.Duration) {
+	ClientCheckReachability(ctx, a, asks, timeout)
+}
+
 // PingMinerWithTimeout pings a storage or retrieval miner, waiting the given
 // timeout and returning desciptive errors.
 func (a *API) PingMinerWithTimeout(
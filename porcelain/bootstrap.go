@@ -0,0 +1,100 @@
+package porcelain
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/net"
+)
+
+type bootstrapPlumbing interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	ConfigSet(dottedPath string, paramJSON string) error
+}
+
+// BootstrapAddPeers adds the given peer addresses to the bootstrap peers in
+// the config and returns those that were not already there. Addresses must
+// be multiaddrs ending with the peer ID, e.g. /ip4/1.2.3.4/tcp/6000/ipfs/Qm...
+func BootstrapAddPeers(plumbing bootstrapPlumbing, addrs []string) ([]string, error) {
+	if _, err := net.PeerAddrsToPeerInfos(addrs); err != nil {
+		return nil, errors.Wrap(err, "invalid bootstrap peer address")
+	}
+
+	peers, err := bootstrapPeers(plumbing)
+	if err != nil {
+		return nil, err
+	}
+
+	var added []string
+	for _, addr := range addrs {
+		if containsString(peers, addr) || containsString(added, addr) {
+			continue
+		}
+		added = append(added, addr)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	return added, setBootstrapPeers(plumbing, append(peers, added...))
+}
+
+// BootstrapRemovePeers removes the given peer addresses from the bootstrap
+// peers in the config, or all of them if all is true, and returns the
+// addresses removed.
+func BootstrapRemovePeers(plumbing bootstrapPlumbing, addrs []string, all bool) ([]string, error) {
+	peers, err := bootstrapPeers(plumbing)
+	if err != nil {
+		return nil, err
+	}
+
+	if all {
+		if len(peers) == 0 {
+			return nil, nil
+		}
+		return peers, setBootstrapPeers(plumbing, []string{})
+	}
+
+	for _, addr := range addrs {
+		if !containsString(peers, addr) {
+			return nil, errors.Errorf("%s is not a bootstrap peer", addr)
+		}
+	}
+
+	remaining := []string{}
+	var removed []string
+	for _, peer := range peers {
+		if containsString(addrs, peer) {
+			removed = append(removed, peer)
+		} else {
+			remaining = append(remaining, peer)
+		}
+	}
+	return removed, setBootstrapPeers(plumbing, remaining)
+}
+
+func bootstrapPeers(plumbing bootstrapPlumbing) ([]string, error) {
+	peers, err := plumbing.ConfigGet("bootstrap.addresses")
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{}, peers.([]string)...), nil
+}
+
+func setBootstrapPeers(plumbing bootstrapPlumbing, peers []string) error {
+	peersJSON, err := json.Marshal(peers)
+	if err != nil {
+		return err
+	}
+	return plumbing.ConfigSet("bootstrap.addresses", string(peersJSON))
+}
+
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
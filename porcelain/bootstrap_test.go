@@ -0,0 +1,94 @@
+package porcelain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/repo"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+const (
+	bootstrapPeer1 = "/ip4/127.0.0.1/tcp/6000/ipfs/QmcZf59bWwK5XFi76CZX8cbJ4BhTzzA3gU1ZjYZcYW3dwt"
+	bootstrapPeer2 = "/ip4/104.131.131.82/tcp/4001/ipfs/QmaCpDMGvV2BGHeYERUEnRQAwe3N8SzbUtfsmvsqQLuvuJ"
+)
+
+func TestBootstrapAddPeers(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("adds new peers to the config", func(t *testing.T) {
+		config := cfg.NewConfig(repo.NewInMemoryRepo())
+
+		added, err := porcelain.BootstrapAddPeers(config, []string{bootstrapPeer1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{bootstrapPeer1}, added)
+
+		added, err = porcelain.BootstrapAddPeers(config, []string{bootstrapPeer1, bootstrapPeer2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{bootstrapPeer2}, added)
+
+		peers, err := config.Get("bootstrap.addresses")
+		require.NoError(t, err)
+		assert.Equal(t, []string{bootstrapPeer1, bootstrapPeer2}, peers)
+	})
+
+	t.Run("rejects addresses without a peer ID", func(t *testing.T) {
+		config := cfg.NewConfig(repo.NewInMemoryRepo())
+
+		_, err := porcelain.BootstrapAddPeers(config, []string{"/ip4/127.0.0.1/tcp/6000"})
+		assert.Error(t, err)
+
+		peers, err := config.Get("bootstrap.addresses")
+		require.NoError(t, err)
+		assert.Empty(t, peers)
+	})
+}
+
+func TestBootstrapRemovePeers(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("removes the given peers", func(t *testing.T) {
+		config := cfg.NewConfig(repo.NewInMemoryRepo())
+		_, err := porcelain.BootstrapAddPeers(config, []string{bootstrapPeer1, bootstrapPeer2})
+		require.NoError(t, err)
+
+		removed, err := porcelain.BootstrapRemovePeers(config, []string{bootstrapPeer1}, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{bootstrapPeer1}, removed)
+
+		peers, err := config.Get("bootstrap.addresses")
+		require.NoError(t, err)
+		assert.Equal(t, []string{bootstrapPeer2}, peers)
+	})
+
+	t.Run("fails on unknown peers", func(t *testing.T) {
+		config := cfg.NewConfig(repo.NewInMemoryRepo())
+		_, err := porcelain.BootstrapAddPeers(config, []string{bootstrapPeer1})
+		require.NoError(t, err)
+
+		_, err = porcelain.BootstrapRemovePeers(config, []string{bootstrapPeer1, bootstrapPeer2}, false)
+		assert.EqualError(t, err, bootstrapPeer2+" is not a bootstrap peer")
+
+		peers, err := config.Get("bootstrap.addresses")
+		require.NoError(t, err)
+		assert.Equal(t, []string{bootstrapPeer1}, peers)
+	})
+
+	t.Run("removes all peers", func(t *testing.T) {
+		config := cfg.NewConfig(repo.NewInMemoryRepo())
+		_, err := porcelain.BootstrapAddPeers(config, []string{bootstrapPeer1, bootstrapPeer2})
+		require.NoError(t, err)
+
+		removed, err := porcelain.BootstrapRemovePeers(config, nil, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{bootstrapPeer1, bootstrapPeer2}, removed)
+
+		peers, err := config.Get("bootstrap.addresses")
+		require.NoError(t, err)
+		assert.Empty(t, peers)
+	})
+}
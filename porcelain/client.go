@@ -3,6 +3,9 @@ package porcelain
 import (
 	"context"
 	"math/big"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/address"
@@ -10,6 +13,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/types"
 
 	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-peer"
 )
 
 // Ask is a result of querying for an ask, it may contain an error
@@ -18,8 +22,12 @@ type Ask struct {
 	Price  *types.AttoFIL
 	Expiry *types.BlockHeight
 	ID     uint64
+	// Reachable is whether the miner answered a ping, if it was checked.
+	Reachable *bool `json:",omitempty"`
 
-	Error error
+	// Error is not encoded, errors do not marshal to JSON and asks failing to
+	// load are not emitted.
+	Error error `json:"-"`
 }
 
 type claPlubming interface {
@@ -109,3 +117,60 @@ func getAskByID(ctx context.Context, plumbing claPlubming, addr address.Address,
 		Miner:  addr,
 	}, nil
 }
+
+// SortAsks orders asks by increasing price, then by miner and ID.
+func SortAsks(asks []Ask) {
+	sort.Slice(asks, func(i, j int) bool {
+		if !asks[i].Price.Equal(asks[j].Price) {
+			return asks[i].Price.LessThan(asks[j].Price)
+		}
+		if asks[i].Miner != asks[j].Miner {
+			return asks[i].Miner.String() < asks[j].Miner.String()
+		}
+		return asks[i].ID < asks[j].ID
+	})
+}
+
+// ccrPlumbing is the subset of the plumbing.API that ClientCheckReachability uses.
+type ccrPlumbing interface {
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	NetworkPing(ctx context.Context, pid peer.ID) (<-chan time.Duration, error)
+}
+
+// ClientCheckReachability sets whether the miner of each ask answers a ping
+// within timeout. Each miner is pinged once, all concurrently.
+func ClientCheckReachability(ctx context.Context, plumbing ccrPlumbing, asks []Ask, timeout time.Duration) {
+	var miners []address.Address
+	reachable := make(map[address.Address]bool)
+	for _, ask := range asks {
+		if _, ok := reachable[ask.Miner]; !ok {
+			reachable[ask.Miner] = false
+			miners = append(miners, ask.Miner)
+		}
+	}
+
+	var lk sync.Mutex
+	var wg sync.WaitGroup
+	for _, minerAddr := range miners {
+		wg.Add(1)
+		go func(minerAddr address.Address) {
+			defer wg.Done()
+			pid, err := MinerGetPeerID(ctx, plumbing, minerAddr)
+			if err != nil {
+				return
+			}
+			if err := PingMinerWithTimeout(ctx, pid, timeout, plumbing); err != nil {
+				return
+			}
+			lk.Lock()
+			reachable[minerAddr] = true
+			lk.Unlock()
+		}(minerAddr)
+	}
+	wg.Wait()
+
+	for i := range asks {
+		r := reachable[asks[i].Miner]
+		asks[i].Reachable = &r
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
@@ -12,10 +13,13 @@ import (
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
 
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-peer"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type claPlumbing struct {
@@ -129,3 +133,75 @@ func TestClientListAsks(t *testing.T) {
 		assert.Error(t, result.Error, "MESSAGE FAILURE")
 	})
 }
+
+func TestSortAsks(t *testing.T) {
+	tf.UnitTest(t)
+
+	addrGetter := address.NewForTestGetter()
+	minerA, minerB := addrGetter(), addrGetter()
+	if minerB.String() < minerA.String() {
+		minerA, minerB = minerB, minerA
+	}
+
+	asks := []porcelain.Ask{
+		{Miner: minerB, ID: 0, Price: types.NewAttoFILFromFIL(2)},
+		{Miner: minerB, ID: 1, Price: types.NewAttoFILFromFIL(1)},
+		{Miner: minerA, ID: 3, Price: types.NewAttoFILFromFIL(2)},
+		{Miner: minerA, ID: 2, Price: types.NewAttoFILFromFIL(2)},
+	}
+	porcelain.SortAsks(asks)
+
+	assert.Equal(t, minerB, asks[0].Miner)
+	assert.Equal(t, uint64(1), asks[0].ID)
+	assert.Equal(t, minerA, asks[1].Miner)
+	assert.Equal(t, uint64(2), asks[1].ID)
+	assert.Equal(t, minerA, asks[2].Miner)
+	assert.Equal(t, uint64(3), asks[2].ID)
+	assert.Equal(t, minerB, asks[3].Miner)
+}
+
+type reachabilityPlumbing struct {
+	peers     map[address.Address]peer.ID
+	reachable map[peer.ID]bool
+}
+
+func (rp *reachabilityPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	pid, ok := rp.peers[to]
+	if !ok {
+		return nil, errors.New("unknown miner")
+	}
+	return [][]byte{[]byte(pid)}, nil
+}
+
+func (rp *reachabilityPlumbing) NetworkPing(ctx context.Context, pid peer.ID) (<-chan time.Duration, error) {
+	out := make(chan time.Duration, 1)
+	if rp.reachable[pid] {
+		out <- time.Millisecond
+	}
+	return out, nil
+}
+
+func TestClientCheckReachability(t *testing.T) {
+	tf.UnitTest(t)
+
+	addrGetter := address.NewForTestGetter()
+	up, down, unknown := addrGetter(), addrGetter(), addrGetter()
+	upPeer, downPeer := th.RequireRandomPeerID(t), th.RequireRandomPeerID(t)
+	plumbing := &reachabilityPlumbing{
+		peers:     map[address.Address]peer.ID{up: upPeer, down: downPeer},
+		reachable: map[peer.ID]bool{upPeer: true},
+	}
+
+	asks := []porcelain.Ask{
+		{Miner: up, ID: 0},
+		{Miner: down, ID: 0},
+		{Miner: up, ID: 1},
+		{Miner: unknown, ID: 0},
+	}
+	porcelain.ClientCheckReachability(context.Background(), plumbing, asks, 10*time.Millisecond)
+
+	for i, expected := range []bool{true, false, true, false} {
+		require.NotNil(t, asks[i].Reachable)
+		assert.Equal(t, expected, *asks[i].Reachable)
+	}
+}
@@ -0,0 +1,47 @@
+package porcelain
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ErrNoFaucet is returned when tapping the faucet of a network without one.
+var ErrNoFaucet = errors.New("this network has no faucet")
+
+// ftPlumbing is the subset of the plumbing.API that FaucetTap uses.
+type ftPlumbing interface {
+	ActorGet(ctx context.Context, addr address.Address) (*actor.Actor, error)
+	ConfigGet(dottedPath string) (interface{}, error)
+	ConfigSet(dottedPath string, paramJSON string) error
+	MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+	WalletAddresses() []address.Address
+}
+
+// FaucetTap sends a message asking the faucet of the network to fund addr,
+// or the default wallet address if addr is undefined. Faucets only exist on
+// test networks.
+func FaucetTap(ctx context.Context, plumbing ftPlumbing, from address.Address, addr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	if _, err := plumbing.ActorGet(ctx, address.FaucetAddress); err != nil {
+		if state.IsActorNotFoundError(err) {
+			return cid.Undef, ErrNoFaucet
+		}
+		return cid.Undef, err
+	}
+
+	if addr.Empty() {
+		var err error
+		addr, err = WalletDefaultAddress(plumbing)
+		if err != nil {
+			return cid.Undef, err
+		}
+	}
+
+	return plumbing.MessageSendWithDefaultAddress(ctx, from, address.FaucetAddress, types.NewZeroAttoFIL(), gasPrice, gasLimit, "tap", addr)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/libp2p/go-libp2p-peer"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/abi"
 	minerActor "github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
@@ -30,6 +31,8 @@ type mcAPI interface {
 }
 
 // MinerCreate creates a new miner actor for the given account and returns its address.
+// The miner's worker, whose key signs blocks and PoSts, is workerAddr, or the owner
+// if workerAddr is empty. The worker key must be in the local wallet.
 // It will wait for the the actor to appear on-chain and add set the address to mining.minerAddress in the config.
 // TODO: add ability to pass in a KeyInfo to store for signing blocks.
 //       See https://github.com/filecoin-project/go-filecoin/issues/1843
@@ -37,6 +40,7 @@ func MinerCreate(
 	ctx context.Context,
 	plumbing mcAPI,
 	minerOwnerAddr address.Address,
+	workerAddr address.Address,
 	gasPrice types.AttoFIL,
 	gasLimit types.GasUnits,
 	pledge uint64,
@@ -63,7 +67,11 @@ func MinerCreate(
 		return nil, fmt.Errorf("can only have one miner per node")
 	}
 
-	pubKey, err := plumbing.WalletGetPubKeyForAddress(minerOwnerAddr)
+	if workerAddr.Empty() {
+		workerAddr = minerOwnerAddr
+	}
+
+	pubKey, err := plumbing.WalletGetPubKeyForAddress(workerAddr)
 	if err != nil {
 		return nil, err
 	}
@@ -295,6 +303,16 @@ func MinerGetOwnerAddress(ctx context.Context, plumbing mgoaAPI, minerAddr addre
 	return address.NewFromBytes(res[0])
 }
 
+// MinerGetWorkerAddress queries for the worker address of the given miner
+func MinerGetWorkerAddress(ctx context.Context, plumbing mgoaAPI, minerAddr address.Address) (address.Address, error) {
+	res, err := plumbing.MessageQuery(ctx, address.Undef, minerAddr, "getWorker")
+	if err != nil {
+		return address.Undef, err
+	}
+
+	return address.NewFromBytes(res[0])
+}
+
 // MinerGetKey queries for the public key of the given miner
 func MinerGetKey(ctx context.Context, plumbing mgoaAPI, minerAddr address.Address) ([]byte, error) {
 	res, err := plumbing.MessageQuery(ctx, address.Undef, minerAddr, "getKey")
@@ -325,6 +343,180 @@ func MinerGetAsk(ctx context.Context, plumbing mgaAPI, minerAddr address.Address
 	return ask, nil
 }
 
+// mlaAPI is the subset of the plumbing.API that MinerListAsks uses.
+type mlaAPI interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+}
+
+// MinerListAsks returns the unexpired asks of the given miner.
+// If minerAddr is empty, the default miner will be used.
+func MinerListAsks(ctx context.Context, plumbing mlaAPI, minerAddr address.Address) ([]Ask, error) {
+	if minerAddr.Empty() {
+		minerValue, err := plumbing.ConfigGet("mining.minerAddress")
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not get miner address in config")
+		}
+		var ok bool
+		minerAddr, ok = minerValue.(address.Address)
+		if !ok {
+			return nil, errors.New("Configured miner is not an address")
+		}
+	}
+
+	ret, err := plumbing.MessageQuery(ctx, address.Undef, minerAddr, "getAsks")
+	if err != nil {
+		return nil, err
+	}
+
+	var askIDs []uint64
+	if err := cbor.DecodeInto(ret[0], &askIDs); err != nil {
+		return nil, err
+	}
+
+	asks := make([]Ask, 0, len(askIDs))
+	for _, id := range askIDs {
+		ask, err := MinerGetAsk(ctx, plumbing, minerAddr, id)
+		if err != nil {
+			return nil, err
+		}
+		asks = append(asks, Ask{
+			Miner:  minerAddr,
+			Price:  ask.Price,
+			Expiry: ask.Expiry,
+			ID:     id,
+		})
+	}
+
+	return asks, nil
+}
+
+// mraAPI is the subset of the plumbing.API that MinerRemoveAsk uses.
+type mraAPI interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+}
+
+// MinerRemoveAsk sends a message retiring the given ask before it expires.
+// If miner is empty, the default miner will be used.
+func MinerRemoveAsk(
+	ctx context.Context,
+	plumbing mraAPI,
+	from address.Address,
+	miner address.Address,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+	askID uint64,
+) (cid.Cid, error) {
+	if miner.Empty() {
+		minerValue, err := plumbing.ConfigGet("mining.minerAddress")
+		if err != nil {
+			return cid.Undef, errors.Wrap(err, "Could not get miner address in config")
+		}
+		var ok bool
+		miner, ok = minerValue.(address.Address)
+		if !ok {
+			return cid.Undef, errors.New("Configured miner is not an address")
+		}
+	}
+
+	return plumbing.MessageSendWithDefaultAddress(
+		ctx,
+		from,
+		miner,
+		types.NewZeroAttoFIL(),
+		gasPrice,
+		gasLimit,
+		"removeAsk",
+		big.NewInt(0).SetUint64(askID),
+	)
+}
+
+// mapAPI is the subset of the plumbing.API that MinerAddPledge uses.
+type mapAPI interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+}
+
+// MinerAddPledge sends a message increasing the pledge of the given miner by
+// sectors, locking up collateral as additional collateral. If miner is
+// empty, the default miner will be used.
+func MinerAddPledge(
+	ctx context.Context,
+	plumbing mapAPI,
+	from address.Address,
+	miner address.Address,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+	sectors uint64,
+	collateral *types.AttoFIL,
+) (cid.Cid, error) {
+	if miner.Empty() {
+		minerValue, err := plumbing.ConfigGet("mining.minerAddress")
+		if err != nil {
+			return cid.Undef, errors.Wrap(err, "Could not get miner address in config")
+		}
+		var ok bool
+		miner, ok = minerValue.(address.Address)
+		if !ok {
+			return cid.Undef, errors.New("Configured miner is not an address")
+		}
+	}
+
+	return plumbing.MessageSendWithDefaultAddress(
+		ctx,
+		from,
+		miner,
+		collateral,
+		gasPrice,
+		gasLimit,
+		"addPledge",
+		big.NewInt(0).SetUint64(sectors),
+	)
+}
+
+// mwpAPI is the subset of the plumbing.API that MinerWithdrawPledge uses.
+type mwpAPI interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+}
+
+// MinerWithdrawPledge sends a message decreasing the pledge of the given
+// miner by sectors, which returns the collateral no longer needed to the
+// miner's owner. If miner is empty, the default miner will be used.
+func MinerWithdrawPledge(
+	ctx context.Context,
+	plumbing mwpAPI,
+	from address.Address,
+	miner address.Address,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+	sectors uint64,
+) (cid.Cid, error) {
+	if miner.Empty() {
+		minerValue, err := plumbing.ConfigGet("mining.minerAddress")
+		if err != nil {
+			return cid.Undef, errors.Wrap(err, "Could not get miner address in config")
+		}
+		var ok bool
+		miner, ok = minerValue.(address.Address)
+		if !ok {
+			return cid.Undef, errors.New("Configured miner is not an address")
+		}
+	}
+
+	return plumbing.MessageSendWithDefaultAddress(
+		ctx,
+		from,
+		miner,
+		types.NewZeroAttoFIL(),
+		gasPrice,
+		gasLimit,
+		"withdrawPledge",
+		big.NewInt(0).SetUint64(sectors),
+	)
+}
+
 // mgpidAPI is the subset of the plumbing.API that MinerGetPeerID uses.
 type mgpidAPI interface {
 	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
@@ -343,3 +535,159 @@ func MinerGetPeerID(ctx context.Context, plumbing mgpidAPI, minerAddr address.Ad
 	}
 	return pid, nil
 }
+
+// mcwAPI is the subset of the plumbing.API that MinerChangeWorker uses.
+type mcwAPI interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+	WalletGetPubKeyForAddress(addr address.Address) ([]byte, error)
+}
+
+// MinerChangeWorker sends a message from the miner's owner replacing the
+// miner's worker with workerAddr. The new worker key must be in the local
+// wallet. If miner is empty, the configured miner address is used.
+func MinerChangeWorker(
+	ctx context.Context,
+	plumbing mcwAPI,
+	from address.Address,
+	miner address.Address,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+	workerAddr address.Address,
+) (cid.Cid, error) {
+	if miner.Empty() {
+		minerValue, err := plumbing.ConfigGet("mining.minerAddress")
+		if err != nil {
+			return cid.Undef, errors.Wrap(err, "Could not get miner address in config")
+		}
+		var ok bool
+		miner, ok = minerValue.(address.Address)
+		if !ok {
+			return cid.Undef, errors.New("Configured miner is not an address")
+		}
+	}
+
+	pubKey, err := plumbing.WalletGetPubKeyForAddress(workerAddr)
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "could not get public key for new worker")
+	}
+
+	return plumbing.MessageSendWithDefaultAddress(
+		ctx,
+		from,
+		miner,
+		types.NewZeroAttoFIL(),
+		gasPrice,
+		gasLimit,
+		"changeWorker",
+		pubKey,
+	)
+}
+
+// MinerInfo describes a miner actor and its share of the power of the
+// storage market. Power is counted in sectors.
+type MinerInfo struct {
+	Owner  address.Address
+	Worker address.Address
+	PeerID peer.ID
+	// SectorSize is the size in bytes of a sealed sector.
+	SectorSize uint64
+	// PledgeSectors is the number of sectors the miner pledged to store and
+	// PledgeBytes their size in bytes.
+	PledgeSectors *big.Int
+	PledgeBytes   *big.Int
+	Collateral    *types.AttoFIL
+	// CommittedSectors is the number of sectors the miner committed.
+	CommittedSectors int
+	Power            *big.Int
+	TotalPower       *big.Int
+}
+
+// mgiAPI is the subset of the plumbing.API that MinerGetInfo uses.
+type mgiAPI interface {
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+}
+
+// MinerGetInfo queries the miner actor and the storage market for the
+// description of the given miner.
+func MinerGetInfo(ctx context.Context, plumbing mgiAPI, minerAddr address.Address) (*MinerInfo, error) {
+	query := func(to address.Address, method string) ([]byte, error) {
+		res, err := plumbing.MessageQuery(ctx, address.Undef, to, method)
+		if err != nil {
+			return nil, errors.Wrapf(err, "'%s' query message failed", method)
+		}
+		return res[0], nil
+	}
+
+	info := &MinerInfo{}
+	res, err := query(minerAddr, "getOwner")
+	if err != nil {
+		return nil, err
+	}
+	if info.Owner, err = address.NewFromBytes(res); err != nil {
+		return nil, err
+	}
+
+	if res, err = query(minerAddr, "getWorker"); err != nil {
+		return nil, err
+	}
+	if info.Worker, err = address.NewFromBytes(res); err != nil {
+		return nil, err
+	}
+
+	if res, err = query(minerAddr, "getPeerID"); err != nil {
+		return nil, err
+	}
+	if info.PeerID, err = peer.IDFromBytes(res); err != nil {
+		return nil, errors.Wrap(err, "could not decode to peer.ID from message-bytes")
+	}
+
+	if res, err = query(address.StorageMarketAddress, "getProofsMode"); err != nil {
+		return nil, err
+	}
+	var proofsMode types.ProofsMode
+	if err := cbor.DecodeInto(res, &proofsMode); err != nil {
+		return nil, errors.Wrap(err, "could not convert query message result to Mode")
+	}
+	sectorSize := types.OneKiBSectorSize
+	if proofsMode == types.LiveProofsMode {
+		sectorSize = types.TwoHundredFiftySixMiBSectorSize
+	}
+	info.SectorSize = sectorSize.Uint64()
+
+	if res, err = query(minerAddr, "getPledge"); err != nil {
+		return nil, err
+	}
+	info.PledgeSectors = big.NewInt(0).SetBytes(res)
+	info.PledgeBytes = big.NewInt(0).Mul(info.PledgeSectors, big.NewInt(0).SetUint64(info.SectorSize))
+
+	if res, err = query(minerAddr, "getCollateral"); err != nil {
+		return nil, err
+	}
+	info.Collateral = types.NewAttoFILFromBytes(res)
+
+	if res, err = query(minerAddr, "getSectorCommitments"); err != nil {
+		return nil, err
+	}
+	commitments, err := abi.Deserialize(res, abi.CommitmentsMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode sector commitments")
+	}
+	sectors, ok := commitments.Val.(map[string]types.Commitments)
+	if !ok {
+		return nil, errors.New("sector commitments have unexpected type")
+	}
+	info.CommittedSectors = len(sectors)
+
+	if res, err = query(minerAddr, "getPower"); err != nil {
+		return nil, err
+	}
+	info.Power = big.NewInt(0).SetBytes(res)
+
+	if res, err = query(address.StorageMarketAddress, "getTotalStorage"); err != nil {
+		return nil, err
+	}
+	info.TotalPower = big.NewInt(0).SetBytes(res)
+
+	return info, nil
+}
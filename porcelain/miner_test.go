@@ -10,6 +10,7 @@ import (
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/libp2p/go-libp2p-peer"
 
+	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
@@ -30,6 +31,7 @@ type minerCreate struct {
 	wallet  *wallet.Wallet
 	msgCid  cid.Cid
 	msgFail bool
+	params  []interface{}
 }
 
 func newMinerCreate(t *testing.T, msgFail bool, address address.Address) *minerCreate {
@@ -58,6 +60,7 @@ func (mpc *minerCreate) MessageSendWithDefaultAddress(ctx context.Context, from,
 		return cid.Cid{}, errors.New("Test Error")
 	}
 	mpc.msgCid = types.SomeCid()
+	mpc.params = params
 	return mpc.msgCid, nil
 }
 
@@ -91,6 +94,7 @@ func TestMinerCreate(t *testing.T) {
 			ctx,
 			plumbing,
 			address.Address{},
+			address.Address{},
 			types.NewGasPrice(0),
 			types.NewGasUnits(100),
 			1,
@@ -101,6 +105,34 @@ func TestMinerCreate(t *testing.T) {
 		assert.Equal(t, expectedAddress, *addr)
 	})
 
+	t.Run("uses the worker key when a worker is given", func(t *testing.T) {
+		ctx := context.Background()
+		plumbing := newMinerCreate(t, false, address.NewForTestGetter()())
+		collateral := types.NewAttoFILFromFIL(1)
+
+		ownerAddr, err := wallet.NewAddress(plumbing.wallet)
+		require.NoError(t, err)
+		workerAddr, err := wallet.NewAddress(plumbing.wallet)
+		require.NoError(t, err)
+
+		_, err = MinerCreate(
+			ctx,
+			plumbing,
+			ownerAddr,
+			workerAddr,
+			types.NewGasPrice(0),
+			types.NewGasUnits(100),
+			1,
+			"",
+			collateral,
+		)
+		require.NoError(t, err)
+
+		workerKey, err := plumbing.wallet.GetPubKeyForAddress(workerAddr)
+		require.NoError(t, err)
+		assert.Equal(t, workerKey, plumbing.params[1])
+	})
+
 	t.Run("failure to send", func(t *testing.T) {
 		ctx := context.Background()
 		plumbing := newMinerCreate(t, true, address.Address{})
@@ -110,6 +142,7 @@ func TestMinerCreate(t *testing.T) {
 			ctx,
 			plumbing,
 			address.Address{},
+			address.Address{},
 			types.NewGasPrice(0),
 			types.NewGasUnits(100),
 			1,
@@ -432,6 +465,85 @@ func TestMinerGetOwnerAddress(t *testing.T) {
 	assert.Equal(t, address.TestAddress, addr)
 }
 
+type minerGetWorkerPlumbing struct{}
+
+func (mgwp *minerGetWorkerPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	return [][]byte{address.TestAddress2.Bytes()}, nil
+}
+
+func TestMinerGetWorkerAddress(t *testing.T) {
+	tf.UnitTest(t)
+
+	addr, err := MinerGetWorkerAddress(context.Background(), &minerGetWorkerPlumbing{}, address.TestAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, address.TestAddress2, addr)
+}
+
+type minerChangeWorkerPlumbing struct {
+	config *cfg.Config
+	wallet *wallet.Wallet
+
+	to     address.Address
+	value  *types.AttoFIL
+	method string
+	params []interface{}
+}
+
+func newMinerChangeWorkerPlumbing(t *testing.T) *minerChangeWorkerPlumbing {
+	repo := repo.NewInMemoryRepo()
+	backend, err := wallet.NewDSBackend(repo.WalletDatastore())
+	require.NoError(t, err)
+	return &minerChangeWorkerPlumbing{
+		config: cfg.NewConfig(repo),
+		wallet: wallet.New(backend),
+	}
+}
+
+func (mcwp *minerChangeWorkerPlumbing) ConfigGet(dottedPath string) (interface{}, error) {
+	return mcwp.config.Get(dottedPath)
+}
+
+func (mcwp *minerChangeWorkerPlumbing) MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	mcwp.to = to
+	mcwp.value = value
+	mcwp.method = method
+	mcwp.params = params
+	return types.SomeCid(), nil
+}
+
+func (mcwp *minerChangeWorkerPlumbing) WalletGetPubKeyForAddress(addr address.Address) ([]byte, error) {
+	return mcwp.wallet.GetPubKeyForAddress(addr)
+}
+
+func TestMinerChangeWorker(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("sends changeWorker with the new worker key to the configured miner", func(t *testing.T) {
+		plumbing := newMinerChangeWorkerPlumbing(t)
+		minerAddr := address.NewForTestGetter()()
+		require.NoError(t, plumbing.config.Set("mining.minerAddress", minerAddr.String()))
+
+		workerAddr, err := wallet.NewAddress(plumbing.wallet)
+		require.NoError(t, err)
+
+		_, err = MinerChangeWorker(context.Background(), plumbing, address.Undef, address.Undef, types.NewGasPrice(0), types.NewGasUnits(100), workerAddr)
+		require.NoError(t, err)
+
+		workerKey, err := plumbing.wallet.GetPubKeyForAddress(workerAddr)
+		require.NoError(t, err)
+		assert.Equal(t, minerAddr, plumbing.to)
+		assert.Equal(t, "changeWorker", plumbing.method)
+		assert.Equal(t, []interface{}{workerKey}, plumbing.params)
+	})
+
+	t.Run("fails when the new worker key is not in the wallet", func(t *testing.T) {
+		plumbing := newMinerChangeWorkerPlumbing(t)
+
+		_, err := MinerChangeWorker(context.Background(), plumbing, address.Undef, address.TestAddress, types.NewGasPrice(0), types.NewGasUnits(100), address.TestAddress2)
+		assert.Error(t, err)
+	})
+}
+
 type minerGetPeerIDPlumbing struct{}
 
 func (mgop *minerGetPeerIDPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
@@ -451,6 +563,57 @@ func TestMinerGetPeerID(t *testing.T) {
 	assert.Equal(t, expected, id)
 }
 
+type minerGetInfoPlumbing struct{}
+
+func (mgip *minerGetInfoPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	var out []byte
+	var err error
+	switch method {
+	case "getOwner":
+		out = address.TestAddress.Bytes()
+	case "getWorker":
+		out = address.TestAddress2.Bytes()
+	case "getPeerID":
+		out = []byte(requirePeerID())
+	case "getProofsMode":
+		out, err = cbor.DumpObject(types.TestProofsMode)
+	case "getPledge":
+		out = big.NewInt(10).Bytes()
+	case "getCollateral":
+		out = types.NewAttoFILFromFIL(3).Bytes()
+	case "getSectorCommitments":
+		out, err = (&abi.Value{
+			Type: abi.CommitmentsMap,
+			Val:  map[string]types.Commitments{"1": {}, "2": {}},
+		}).Serialize()
+	case "getPower":
+		out = big.NewInt(2).Bytes()
+	case "getTotalStorage":
+		out = big.NewInt(8).Bytes()
+	default:
+		return nil, errors.New("unexpected method " + method)
+	}
+	return [][]byte{out}, err
+}
+
+func TestMinerGetInfo(t *testing.T) {
+	tf.UnitTest(t)
+
+	info, err := MinerGetInfo(context.Background(), &minerGetInfoPlumbing{}, address.TestAddress2)
+	require.NoError(t, err)
+
+	assert.Equal(t, address.TestAddress, info.Owner)
+	assert.Equal(t, address.TestAddress2, info.Worker)
+	assert.Equal(t, requirePeerID(), info.PeerID)
+	assert.Equal(t, types.OneKiBSectorSize.Uint64(), info.SectorSize)
+	assert.Equal(t, big.NewInt(10), info.PledgeSectors)
+	assert.Equal(t, big.NewInt(0).Mul(big.NewInt(10), big.NewInt(int64(types.OneKiBSectorSize.Uint64()))), info.PledgeBytes)
+	assert.True(t, types.NewAttoFILFromFIL(3).Equal(info.Collateral))
+	assert.Equal(t, 2, info.CommittedSectors)
+	assert.Equal(t, big.NewInt(2), info.Power)
+	assert.Equal(t, big.NewInt(8), info.TotalPower)
+}
+
 type minerGetAskPlumbing struct{}
 
 func (mgop *minerGetAskPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
@@ -476,6 +639,118 @@ func TestMinerGetAsk(t *testing.T) {
 	assert.Equal(t, big.NewInt(4), ask.ID)
 }
 
+type minerListAsksPlumbing struct {
+	config *cfg.Config
+	asks   map[uint64]miner.Ask
+
+	queried []address.Address
+}
+
+func (mlap *minerListAsksPlumbing) ConfigGet(dottedPath string) (interface{}, error) {
+	return mlap.config.Get(dottedPath)
+}
+
+func (mlap *minerListAsksPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	mlap.queried = append(mlap.queried, to)
+
+	var out []byte
+	var err error
+	switch method {
+	case "getAsks":
+		var ids []uint64
+		for id := range mlap.asks {
+			ids = append(ids, id)
+		}
+		out, err = cbor.DumpObject(ids)
+	case "getAsk":
+		out, err = cbor.DumpObject(mlap.asks[params[0].(*big.Int).Uint64()])
+	default:
+		return nil, errors.New("unexpected method " + method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{out}, nil
+}
+
+func TestMinerListAsks(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("lists the asks of the configured miner", func(t *testing.T) {
+		plumbing := &minerListAsksPlumbing{
+			config: cfg.NewConfig(repo.NewInMemoryRepo()),
+			asks: map[uint64]miner.Ask{
+				3: {Price: types.NewAttoFILFromFIL(32), Expiry: types.NewBlockHeight(41), ID: big.NewInt(3)},
+			},
+		}
+		minerAddr := address.NewForTestGetter()()
+		require.NoError(t, plumbing.config.Set("mining.minerAddress", minerAddr.String()))
+
+		asks, err := MinerListAsks(context.Background(), plumbing, address.Undef)
+		require.NoError(t, err)
+
+		require.Len(t, asks, 1)
+		assert.Equal(t, minerAddr, asks[0].Miner)
+		assert.Equal(t, uint64(3), asks[0].ID)
+		assert.Equal(t, types.NewAttoFILFromFIL(32), asks[0].Price)
+		assert.Equal(t, types.NewBlockHeight(41), asks[0].Expiry)
+		for _, to := range plumbing.queried {
+			assert.Equal(t, minerAddr, to)
+		}
+	})
+
+	t.Run("returns no asks for a miner without asks", func(t *testing.T) {
+		plumbing := &minerListAsksPlumbing{config: cfg.NewConfig(repo.NewInMemoryRepo())}
+
+		asks, err := MinerListAsks(context.Background(), plumbing, address.TestAddress)
+		require.NoError(t, err)
+		assert.Len(t, asks, 0)
+	})
+}
+
+func TestMinerRemoveAsk(t *testing.T) {
+	tf.UnitTest(t)
+
+	plumbing := newMinerChangeWorkerPlumbing(t)
+	minerAddr := address.NewForTestGetter()()
+	require.NoError(t, plumbing.config.Set("mining.minerAddress", minerAddr.String()))
+
+	_, err := MinerRemoveAsk(context.Background(), plumbing, address.Undef, address.Undef, types.NewGasPrice(0), types.NewGasUnits(100), 7)
+	require.NoError(t, err)
+
+	assert.Equal(t, minerAddr, plumbing.to)
+	assert.Equal(t, "removeAsk", plumbing.method)
+	assert.Equal(t, []interface{}{big.NewInt(7)}, plumbing.params)
+}
+
+func TestMinerPledge(t *testing.T) {
+	tf.UnitTest(t)
+
+	plumbing := newMinerChangeWorkerPlumbing(t)
+	minerAddr := address.NewForTestGetter()()
+	require.NoError(t, plumbing.config.Set("mining.minerAddress", minerAddr.String()))
+
+	t.Run("add sends the collateral with addPledge", func(t *testing.T) {
+		_, err := MinerAddPledge(context.Background(), plumbing, address.Undef, address.Undef, types.NewGasPrice(0), types.NewGasUnits(100), 5, types.NewAttoFILFromFIL(2))
+		require.NoError(t, err)
+
+		assert.Equal(t, minerAddr, plumbing.to)
+		assert.Equal(t, types.NewAttoFILFromFIL(2), plumbing.value)
+		assert.Equal(t, "addPledge", plumbing.method)
+		assert.Equal(t, []interface{}{big.NewInt(5)}, plumbing.params)
+	})
+
+	t.Run("withdraw sends withdrawPledge", func(t *testing.T) {
+		_, err := MinerWithdrawPledge(context.Background(), plumbing, address.Undef, address.Undef, types.NewGasPrice(0), types.NewGasUnits(100), 3)
+		require.NoError(t, err)
+
+		assert.Equal(t, minerAddr, plumbing.to)
+		assert.True(t, plumbing.value.IsZero())
+		assert.Equal(t, "withdrawPledge", plumbing.method)
+		assert.Equal(t, []interface{}{big.NewInt(3)}, plumbing.params)
+	})
+}
+
 func requirePeerID() peer.ID {
 	id, err := peer.IDB58Decode("QmWbMozPyW6Ecagtxq7SXBXXLY5BNdP1GwHB2WoZCKMvcb")
 	if err != nil {
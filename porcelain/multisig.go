@@ -0,0 +1,117 @@
+package porcelain
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// MultisigCreate creates a multisig actor holding value that requires
+// required of signers to approve each transaction. It waits for the actor to
+// appear on chain and returns its address.
+func MultisigCreate(
+	ctx context.Context,
+	plumbing actcAPI,
+	from address.Address,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+	signers []address.Address,
+	required uint64,
+	value *types.AttoFIL,
+) (address.Address, error) {
+	return ActorCreate(ctx, plumbing, from, gasPrice, gasLimit, types.MultisigActorCodeCid, value, multisig.Errors, signers, big.NewInt(0).SetUint64(required))
+}
+
+// msigpAPI is the subset of the plumbing.API that MultisigPropose uses.
+type msigpAPI interface {
+	ActorGetSignature(ctx context.Context, actorAddr address.Address, method string) (*exec.FunctionSignature, error)
+	MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+}
+
+// MultisigPropose sends a message proposing that the multisig send value to
+// the given address, calling method with params. The params are parsed
+// according to the signature of method, so they are only allowed when a
+// method is given.
+func MultisigPropose(
+	ctx context.Context,
+	plumbing msigpAPI,
+	from address.Address,
+	multisigAddr address.Address,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+	to address.Address,
+	value *types.AttoFIL,
+	method string,
+	params []string,
+) (cid.Cid, error) {
+	paramTypes := []uint64{}
+	encodedParams := []byte{}
+
+	if method == "" && len(params) > 0 {
+		return cid.Undef, errors.New("params can only be given together with a method")
+	}
+
+	if method != "" {
+		sig, err := plumbing.ActorGetSignature(ctx, to, method)
+		if err != nil {
+			return cid.Undef, errors.Wrap(err, "could not get method signature")
+		}
+
+		vals, err := abi.ParseValues(params, sig.Params)
+		if err != nil {
+			return cid.Undef, err
+		}
+
+		if len(vals) > 0 {
+			encodedParams, err = abi.EncodeValues(vals)
+			if err != nil {
+				return cid.Undef, errors.Wrap(err, "could not encode params")
+			}
+		}
+
+		for _, t := range sig.Params {
+			paramTypes = append(paramTypes, uint64(t))
+		}
+	}
+
+	return plumbing.MessageSendWithDefaultAddress(
+		ctx,
+		from,
+		multisigAddr,
+		types.NewZeroAttoFIL(),
+		gasPrice,
+		gasLimit,
+		"propose",
+		to,
+		value,
+		method,
+		paramTypes,
+		encodedParams,
+	)
+}
+
+// msigaAPI is the subset of the plumbing.API that MultisigApprove and
+// MultisigCancel use.
+type msigaAPI interface {
+	MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+}
+
+// MultisigApprove sends a message approving the pending transaction txID of
+// the multisig.
+func MultisigApprove(ctx context.Context, plumbing msigaAPI, from address.Address, multisigAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, txID uint64) (cid.Cid, error) {
+	return plumbing.MessageSendWithDefaultAddress(ctx, from, multisigAddr, types.NewZeroAttoFIL(), gasPrice, gasLimit, "approve", big.NewInt(0).SetUint64(txID))
+}
+
+// MultisigCancel sends a message canceling the pending transaction txID of
+// the multisig.
+func MultisigCancel(ctx context.Context, plumbing msigaAPI, from address.Address, multisigAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, txID uint64) (cid.Cid, error) {
+	return plumbing.MessageSendWithDefaultAddress(ctx, from, multisigAddr, types.NewZeroAttoFIL(), gasPrice, gasLimit, "cancel", big.NewInt(0).SetUint64(txID))
+}
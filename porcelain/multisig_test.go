@@ -0,0 +1,78 @@
+package porcelain_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	. "github.com/filecoin-project/go-filecoin/porcelain"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+type multisigPlumbing struct {
+	sig    *exec.FunctionSignature
+	to     address.Address
+	method string
+	params []interface{}
+}
+
+func (mp *multisigPlumbing) ActorGetSignature(ctx context.Context, actorAddr address.Address, method string) (*exec.FunctionSignature, error) {
+	return mp.sig, nil
+}
+
+func (mp *multisigPlumbing) MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	mp.to = to
+	mp.method = method
+	mp.params = params
+	return cid.Cid{}, nil
+}
+
+func TestMultisigPropose(t *testing.T) {
+	tf.UnitTest(t)
+
+	addrGetter := address.NewForTestGetter()
+	multisigAddr := addrGetter()
+	target := addrGetter()
+
+	t.Run("proposes a plain transfer", func(t *testing.T) {
+		plumbing := &multisigPlumbing{}
+		_, err := MultisigPropose(context.Background(), plumbing, address.Undef, multisigAddr, types.NewGasPrice(0), types.NewGasUnits(100), target, types.NewAttoFILFromFIL(3), "", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, multisigAddr, plumbing.to)
+		assert.Equal(t, "propose", plumbing.method)
+		assert.Equal(t, []interface{}{target, types.NewAttoFILFromFIL(3), "", []uint64{}, []byte{}}, plumbing.params)
+	})
+
+	t.Run("encodes params according to the method signature", func(t *testing.T) {
+		plumbing := &multisigPlumbing{
+			sig: &exec.FunctionSignature{Params: []abi.Type{abi.Integer}},
+		}
+		_, err := MultisigPropose(context.Background(), plumbing, address.Undef, multisigAddr, types.NewGasPrice(0), types.NewGasUnits(100), target, types.NewZeroAttoFIL(), "withdrawPledge", []string{"4"})
+		require.NoError(t, err)
+
+		expected, err := abi.ToEncodedValues(big.NewInt(4))
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{uint64(abi.Integer)}, plumbing.params[3])
+		assert.Equal(t, expected, plumbing.params[4])
+	})
+
+	t.Run("rejects params that do not match the signature", func(t *testing.T) {
+		plumbing := &multisigPlumbing{
+			sig: &exec.FunctionSignature{Params: []abi.Type{abi.Integer}},
+		}
+		_, err := MultisigPropose(context.Background(), plumbing, address.Undef, multisigAddr, types.NewGasPrice(0), types.NewGasUnits(100), target, types.NewZeroAttoFIL(), "withdrawPledge", nil)
+		assert.Error(t, err)
+
+		_, err = MultisigPropose(context.Background(), plumbing, address.Undef, multisigAddr, types.NewGasPrice(0), types.NewGasUnits(100), target, types.NewZeroAttoFIL(), "", []string{"4"})
+		assert.Error(t, err)
+	})
+}
@@ -2,8 +2,10 @@ package porcelain
 
 import (
 	"context"
+	"fmt"
 
 	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
@@ -51,6 +53,25 @@ func PaymentChannelLs(
 	return channels, nil
 }
 
+type pchPlumbing interface {
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+}
+
+// PaymentChannelHistory returns the most recent redemptions of the channel of
+// payer, the oldest first.
+func PaymentChannelHistory(ctx context.Context, plumbing pchPlumbing, payer address.Address, channel *types.ChannelID) ([]*paymentbroker.Redemption, error) {
+	values, err := plumbing.MessageQuery(ctx, address.Undef, address.PaymentBrokerAddress, "history", payer, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []*paymentbroker.Redemption
+	if err := cbor.DecodeInto(values[0], &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
 type pcvPlumbing interface {
 	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
 	SignBytes(data []byte, addr address.Address) (types.Signature, error)
@@ -97,3 +118,155 @@ func PaymentChannelVoucher(
 
 	return voucher, nil
 }
+
+// PaymentChannelSignAssignment returns the signature of fromAddr, the payer or
+// the target of the channel of payer, on the assignment of the channel to
+// newTarget.
+func PaymentChannelSignAssignment(
+	ctx context.Context,
+	plumbing pcvPlumbing,
+	fromAddr address.Address,
+	payer address.Address,
+	channel *types.ChannelID,
+	newTarget address.Address,
+) (types.Signature, error) {
+	if fromAddr.Empty() {
+		var err error
+		fromAddr, err = plumbing.WalletDefaultAddress()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	channels, err := PaymentChannelLs(ctx, plumbing, fromAddr, payer)
+	if err != nil {
+		return nil, err
+	}
+	ch, ok := channels[channel.KeyString()]
+	if !ok {
+		return nil, fmt.Errorf("payer %s has no channel %s", payer, channel)
+	}
+	if fromAddr != payer && fromAddr != ch.Target {
+		return nil, fmt.Errorf("%s is neither the payer nor the target of channel %s", fromAddr, channel)
+	}
+
+	return paymentbroker.SignAssignment(payer, channel, newTarget, ch.Assignments, fromAddr, plumbing)
+}
+
+type pcvePlumbing interface {
+	VouchersLs() ([]*types.PaymentVoucher, error)
+	WalletDefaultAddress() (address.Address, error)
+}
+
+// PaymentChannelVoucherExport encodes all locally stored vouchers for the
+// given payer's channel into a single string that can be delivered to the
+// channel's target out of band (e.g. over email or HTTP).
+func PaymentChannelVoucherExport(
+	ctx context.Context,
+	plumbing pcvePlumbing,
+	payerAddr address.Address,
+	channel *types.ChannelID,
+) (string, error) {
+	var err error
+	if payerAddr.Empty() {
+		payerAddr, err = plumbing.WalletDefaultAddress()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	stored, err := plumbing.VouchersLs()
+	if err != nil {
+		return "", err
+	}
+
+	var vouchers []*types.PaymentVoucher
+	for _, voucher := range stored {
+		if voucher.Payer == payerAddr && voucher.Channel.Equal(channel) {
+			vouchers = append(vouchers, voucher)
+		}
+	}
+	if len(vouchers) == 0 {
+		return "", fmt.Errorf("no vouchers found for channel %s of payer %s", channel, payerAddr)
+	}
+
+	return types.EncodeVouchers(vouchers)
+}
+
+type pcviPlumbing interface {
+	ChainHead() (*types.TipSet, error)
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	VoucherPut(voucher *types.PaymentVoucher) error
+	WalletDefaultAddress() (address.Address, error)
+}
+
+// PaymentChannelVoucherImport decodes vouchers created by
+// PaymentChannelVoucherExport, validates each of them against the current
+// state of its payment channel and stores them locally. No voucher is stored
+// unless all of them are valid.
+func PaymentChannelVoucherImport(
+	ctx context.Context,
+	plumbing pcviPlumbing,
+	fromAddr address.Address,
+	encodedVouchers string,
+) ([]*types.PaymentVoucher, error) {
+	vouchers, err := types.DecodeVouchers(encodedVouchers)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode vouchers")
+	}
+
+	height, err := ChainBlockHeight(plumbing)
+	if err != nil {
+		return nil, err
+	}
+
+	channelsByPayer := map[address.Address]map[string]*paymentbroker.PaymentChannel{}
+	for _, voucher := range vouchers {
+		channels, ok := channelsByPayer[voucher.Payer]
+		if !ok {
+			channels, err = PaymentChannelLs(ctx, plumbing, fromAddr, voucher.Payer)
+			if err != nil {
+				return nil, err
+			}
+			channelsByPayer[voucher.Payer] = channels
+		}
+
+		if err := validateVoucher(voucher, channels, height); err != nil {
+			return nil, errors.Wrapf(err, "invalid voucher for channel %s of payer %s", voucher.Channel.String(), voucher.Payer)
+		}
+	}
+
+	for _, voucher := range vouchers {
+		if err := plumbing.VoucherPut(voucher); err != nil {
+			return nil, err
+		}
+	}
+
+	return vouchers, nil
+}
+
+// validateVoucher checks that voucher is signed by its payer and can still be
+// redeemed against its channel, one of the payer's channels.
+func validateVoucher(voucher *types.PaymentVoucher, channels map[string]*paymentbroker.PaymentChannel, height *types.BlockHeight) error {
+	channel, ok := channels[voucher.Channel.KeyString()]
+	if !ok {
+		return errors.New("channel does not exist")
+	}
+	if !paymentbroker.VerifyVoucherSignature(channel.Scheme, voucher.Payer, &voucher.Channel, &voucher.Amount, &voucher.ValidAt, voucher.Condition, voucher.Signature) {
+		return errors.New("signature is not valid")
+	}
+	if channel.Target != voucher.Target {
+		return errors.New("target does not match channel target")
+	}
+	if voucher.Amount.GreaterThan(channel.Amount) {
+		return errors.New("amount exceeds channel funds")
+	}
+	if voucher.Amount.LessEqual(channel.AmountRedeemed) {
+		return errors.New("amount has already been redeemed")
+	}
+	if height.GreaterEqual(channel.Eol) {
+		return errors.New("channel has expired")
+	}
+
+	return nil
+}
@@ -117,3 +117,140 @@ func TestPaymentChannelVoucher(t *testing.T) {
 		assert.NotEqual(t, expectedVoucher.Signature, voucher.Signature)
 	})
 }
+
+type testPaymentChannelVoucherStorePlumbing struct {
+	testing  *testing.T
+	height   uint64
+	channels map[string]*paymentbroker.PaymentChannel
+	vouchers []*types.PaymentVoucher
+}
+
+func (p *testPaymentChannelVoucherStorePlumbing) ChainHead() (*types.TipSet, error) {
+	ts := types.RequireNewTipSet(p.testing, &types.Block{Height: types.Uint64(p.height)})
+	return &ts, nil
+}
+
+func (p *testPaymentChannelVoucherStorePlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	chnls, err := cbor.DumpObject(p.channels)
+	require.NoError(p.testing, err)
+	return [][]byte{chnls}, nil
+}
+
+func (p *testPaymentChannelVoucherStorePlumbing) VouchersLs() ([]*types.PaymentVoucher, error) {
+	return p.vouchers, nil
+}
+
+func (p *testPaymentChannelVoucherStorePlumbing) VoucherPut(voucher *types.PaymentVoucher) error {
+	p.vouchers = append(p.vouchers, voucher)
+	return nil
+}
+
+func (p *testPaymentChannelVoucherStorePlumbing) WalletDefaultAddress() (address.Address, error) {
+	return address.Undef, nil
+}
+
+func TestPaymentChannelVoucherExportImport(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	signer, _ := types.NewMockSignersAndKeyInfo(1)
+	payer := signer.Addresses[0]
+	target := address.NewForTestGetter()()
+	channelID := types.NewChannelID(5)
+
+	newVoucher := func(t *testing.T, amount *types.AttoFIL) *types.PaymentVoucher {
+		validAt := types.NewBlockHeight(0)
+		sig, err := paymentbroker.SignVoucher(channelID, amount, validAt, payer, nil, signer)
+		require.NoError(t, err)
+		return &types.PaymentVoucher{
+			Channel:   *channelID,
+			Payer:     payer,
+			Target:    target,
+			Amount:    *amount,
+			ValidAt:   *validAt,
+			Signature: sig,
+		}
+	}
+
+	newChannels := func() map[string]*paymentbroker.PaymentChannel {
+		return map[string]*paymentbroker.PaymentChannel{
+			channelID.KeyString(): {
+				Target:         target,
+				Amount:         types.NewAttoFILFromFIL(100),
+				AmountRedeemed: types.NewAttoFILFromFIL(10),
+				AgreedEol:      types.NewBlockHeight(50),
+				Eol:            types.NewBlockHeight(50),
+			},
+		}
+	}
+
+	exportVouchers := func(t *testing.T, vouchers ...*types.PaymentVoucher) string {
+		payerPlumbing := &testPaymentChannelVoucherStorePlumbing{testing: t, vouchers: vouchers}
+		encoded, err := porcelain.PaymentChannelVoucherExport(ctx, payerPlumbing, payer, channelID)
+		require.NoError(t, err)
+		return encoded
+	}
+
+	t.Run("exported vouchers are validated and stored on import", func(t *testing.T) {
+		otherChannel := newVoucher(t, types.NewAttoFILFromFIL(20))
+		otherChannel.Channel = *types.NewChannelID(6)
+
+		encoded := exportVouchers(t, newVoucher(t, types.NewAttoFILFromFIL(20)), newVoucher(t, types.NewAttoFILFromFIL(30)), otherChannel)
+
+		targetPlumbing := &testPaymentChannelVoucherStorePlumbing{testing: t, height: 10, channels: newChannels()}
+		imported, err := porcelain.PaymentChannelVoucherImport(ctx, targetPlumbing, address.Undef, encoded)
+		require.NoError(t, err)
+
+		require.Len(t, imported, 2)
+		assert.Equal(t, imported, targetPlumbing.vouchers)
+		assert.Equal(t, *types.NewAttoFILFromFIL(20), targetPlumbing.vouchers[0].Amount)
+		assert.Equal(t, *types.NewAttoFILFromFIL(30), targetPlumbing.vouchers[1].Amount)
+	})
+
+	t.Run("export fails when there are no vouchers for the channel", func(t *testing.T) {
+		payerPlumbing := &testPaymentChannelVoucherStorePlumbing{testing: t}
+		_, err := porcelain.PaymentChannelVoucherExport(ctx, payerPlumbing, payer, channelID)
+		assert.Error(t, err)
+	})
+
+	t.Run("import rejects invalid vouchers without storing any", func(t *testing.T) {
+		badSignature := newVoucher(t, types.NewAttoFILFromFIL(20))
+		badSignature.Amount = *types.NewAttoFILFromFIL(25)
+
+		wrongTarget := newVoucher(t, types.NewAttoFILFromFIL(20))
+		wrongTarget.Target = address.NewForTestGetter()()
+
+		cases := map[string]struct {
+			voucher *types.PaymentVoucher
+			height  uint64
+			errMsg  string
+		}{
+			"bad signature":    {badSignature, 10, "signature is not valid"},
+			"wrong target":     {wrongTarget, 10, "target does not match"},
+			"exceeds funds":    {newVoucher(t, types.NewAttoFILFromFIL(101)), 10, "exceeds channel funds"},
+			"already redeemed": {newVoucher(t, types.NewAttoFILFromFIL(10)), 10, "already been redeemed"},
+			"expired channel":  {newVoucher(t, types.NewAttoFILFromFIL(20)), 50, "channel has expired"},
+		}
+
+		for name, tc := range cases {
+			t.Run(name, func(t *testing.T) {
+				encoded := exportVouchers(t, newVoucher(t, types.NewAttoFILFromFIL(20)), tc.voucher)
+
+				targetPlumbing := &testPaymentChannelVoucherStorePlumbing{testing: t, height: tc.height, channels: newChannels()}
+				_, err := porcelain.PaymentChannelVoucherImport(ctx, targetPlumbing, address.Undef, encoded)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errMsg)
+				assert.Empty(t, targetPlumbing.vouchers)
+			})
+		}
+	})
+
+	t.Run("import fails for an unknown channel", func(t *testing.T) {
+		encoded := exportVouchers(t, newVoucher(t, types.NewAttoFILFromFIL(20)))
+
+		targetPlumbing := &testPaymentChannelVoucherStorePlumbing{testing: t, height: 10, channels: map[string]*paymentbroker.PaymentChannel{}}
+		_, err := porcelain.PaymentChannelVoucherImport(ctx, targetPlumbing, address.Undef, encoded)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "channel does not exist")
+	})
+}
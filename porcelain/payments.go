@@ -23,7 +23,7 @@ type cpPlumbing interface {
 	SignBytes(data []byte, addr address.Address) (types.Signature, error)
 }
 
-// CreatePaymentsParams structures all the parameters for the CreatePayments command. All values are required.
+// CreatePaymentsParams structures all the parameters for the CreatePayments command. All values but Condition are required.
 // The first payment will be valid at PaymentStart+PaymentInterval. Payment voucher will be created for every
 // PaymentInterval after that until PaymentStart+Duration is reached.
 // ChannelExpiry is when the channel closes and must be after the final payment is valid.
@@ -52,6 +52,10 @@ type CreatePaymentsParams struct {
 
 	// GasLimit is the maximum amount of gas to be paid creating the payment channel.
 	GasLimit types.GasUnits
+
+	// Condition is an optional predicate every payment is conditioned on, e.g.
+	// one of the conditions package.
+	Condition *types.Predicate
 }
 
 // CreatePaymentsReturn collects relevant stats from the create payments process
@@ -145,7 +149,7 @@ func CreatePayments(ctx context.Context, plumbing cpPlumbing, config CreatePayme
 		}
 
 		validAt := currentHeight.Add(types.NewBlockHeight(uint64(i+1) * config.PaymentInterval))
-		err = createPayment(ctx, plumbing, response, voucherAmount, validAt, nil)
+		err = createPayment(ctx, plumbing, response, voucherAmount, validAt, config.Condition)
 		if err != nil {
 			return response, err
 		}
@@ -153,7 +157,7 @@ func CreatePayments(ctx context.Context, plumbing cpPlumbing, config CreatePayme
 
 	if voucherAmount.LessThan(&config.Value) {
 		validAt := currentHeight.Add(types.NewBlockHeight(config.Duration))
-		err = createPayment(ctx, plumbing, response, &config.Value, validAt, nil)
+		err = createPayment(ctx, plumbing, response, &config.Value, validAt, config.Condition)
 		if err != nil {
 			return response, err
 		}
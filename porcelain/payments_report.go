@@ -0,0 +1,172 @@
+package porcelain
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// DealPayments reconciles the payments of a single storage deal.
+type DealPayments struct {
+	// ProposalCid identifies the deal.
+	ProposalCid cid.Cid `json:"proposalCid"`
+	// State is the state of the deal.
+	State storagedeal.State `json:"state"`
+	// Payer is the client paying for the deal.
+	Payer address.Address `json:"payer"`
+	// Channel is the payment channel the client pays through.
+	Channel *types.ChannelID `json:"channel"`
+	// Expected is the total price of the deal.
+	Expected *types.AttoFIL `json:"expected"`
+	// Received is the largest amount of the vouchers the miner holds for the
+	// channel. Vouchers are cumulative, so this is all the client has paid.
+	Received *types.AttoFIL `json:"received"`
+	// Redeemed is the amount redeemed from the channel on chain. It is nil
+	// if the channel is no longer on chain.
+	Redeemed *types.AttoFIL `json:"redeemed"`
+}
+
+// MinerPaymentsReportResult reconciles the payments of a miner's active deals.
+type MinerPaymentsReportResult struct {
+	Miner address.Address `json:"miner"`
+	Deals []*DealPayments `json:"deals"`
+	// Expected, Received and Redeemed are the totals over all deals.
+	Expected *types.AttoFIL `json:"expected"`
+	Received *types.AttoFIL `json:"received"`
+	Redeemed *types.AttoFIL `json:"redeemed"`
+}
+
+// mprAPI is the subset of the plumbing.API that MinerPaymentsReport uses.
+type mprAPI interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	DealsLs() ([]*storagedeal.Deal, error)
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	VouchersLs() ([]*types.PaymentVoucher, error)
+}
+
+// MinerPaymentsReport cross-references the active deals of the given miner,
+// or of the default miner if minerAddr is empty, with the vouchers received
+// for each deal's payment channel and the amounts redeemed from the channels
+// on chain. Deals that were rejected or failed are left out.
+func MinerPaymentsReport(ctx context.Context, plumbing mprAPI, minerAddr address.Address) (*MinerPaymentsReportResult, error) {
+	if minerAddr.Empty() {
+		minerValue, err := plumbing.ConfigGet("mining.minerAddress")
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not get miner address in config")
+		}
+		var ok bool
+		minerAddr, ok = minerValue.(address.Address)
+		if !ok {
+			return nil, errors.New("Configured miner is not an address")
+		}
+	}
+
+	deals, err := MinerListDeals(plumbing, minerAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := plumbing.VouchersLs()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list vouchers")
+	}
+
+	report := &MinerPaymentsReportResult{
+		Miner:    minerAddr,
+		Deals:    []*DealPayments{},
+		Expected: types.NewZeroAttoFIL(),
+		Received: types.NewZeroAttoFIL(),
+		Redeemed: types.NewZeroAttoFIL(),
+	}
+
+	// channels of each payer, queried once per payer
+	payerChannels := map[address.Address]map[string]*paymentbroker.PaymentChannel{}
+
+	for _, deal := range deals {
+		if !isActiveDeal(deal.Response.State) {
+			continue
+		}
+
+		payment := deal.Proposal.Payment
+		payments := &DealPayments{
+			ProposalCid: deal.Response.ProposalCid,
+			State:       deal.Response.State,
+			Payer:       payment.Payer,
+			Channel:     payment.Channel,
+			Expected:    deal.Proposal.TotalPrice,
+			Received:    types.NewZeroAttoFIL(),
+		}
+		if payments.Expected == nil {
+			payments.Expected = types.NewZeroAttoFIL()
+		}
+
+		if payment.Channel != nil {
+			vouchers := append([]*types.PaymentVoucher{}, payment.Vouchers...)
+			for _, voucher := range stored {
+				if voucher.Payer == payment.Payer && voucher.Channel.Equal(payment.Channel) {
+					vouchers = append(vouchers, voucher)
+				}
+			}
+			for _, voucher := range vouchers {
+				if voucher.Amount.GreaterThan(payments.Received) {
+					payments.Received = &voucher.Amount
+				}
+			}
+
+			channels, ok := payerChannels[payment.Payer]
+			if !ok {
+				channels, err = lsPaymentChannels(ctx, plumbing, payment.Payer)
+				if err != nil {
+					return nil, errors.Wrapf(err, "could not list channels of payer %s", payment.Payer)
+				}
+				payerChannels[payment.Payer] = channels
+			}
+			if channel, ok := channels[payment.Channel.KeyString()]; ok {
+				payments.Redeemed = channel.AmountRedeemed
+				report.Redeemed = report.Redeemed.Add(channel.AmountRedeemed)
+			}
+		}
+
+		report.Expected = report.Expected.Add(payments.Expected)
+		report.Received = report.Received.Add(payments.Received)
+		report.Deals = append(report.Deals, payments)
+	}
+
+	return report, nil
+}
+
+// isActiveDeal returns whether a deal in the given state is or may still be
+// paid for.
+func isActiveDeal(state storagedeal.State) bool {
+	switch state {
+	case storagedeal.Unknown, storagedeal.Rejected, storagedeal.Failed:
+		return false
+	default:
+		return true
+	}
+}
+
+type lpcAPI interface {
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+}
+
+// lsPaymentChannels returns the payment channels of payer on chain.
+func lsPaymentChannels(ctx context.Context, plumbing lpcAPI, payer address.Address) (map[string]*paymentbroker.PaymentChannel, error) {
+	values, err := plumbing.MessageQuery(ctx, address.Undef, address.PaymentBrokerAddress, "ls", payer)
+	if err != nil {
+		return nil, err
+	}
+
+	var channels map[string]*paymentbroker.PaymentChannel
+	if err := cbor.DecodeInto(values[0], &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
@@ -0,0 +1,129 @@
+package porcelain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
+	. "github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	"github.com/filecoin-project/go-filecoin/repo"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+type minerPaymentsReportPlumbing struct {
+	testing  *testing.T
+	config   *cfg.Config
+	deals    []*storagedeal.Deal
+	vouchers []*types.PaymentVoucher
+	channels map[address.Address]map[string]*paymentbroker.PaymentChannel
+	queries  int
+}
+
+func (p *minerPaymentsReportPlumbing) ConfigGet(dottedPath string) (interface{}, error) {
+	return p.config.Get(dottedPath)
+}
+
+func (p *minerPaymentsReportPlumbing) DealsLs() ([]*storagedeal.Deal, error) {
+	return p.deals, nil
+}
+
+func (p *minerPaymentsReportPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	p.queries++
+	channels := p.channels[params[0].(address.Address)]
+	if channels == nil {
+		channels = map[string]*paymentbroker.PaymentChannel{}
+	}
+	chnls, err := cbor.DumpObject(channels)
+	require.NoError(p.testing, err)
+	return [][]byte{chnls}, nil
+}
+
+func (p *minerPaymentsReportPlumbing) VouchersLs() ([]*types.PaymentVoucher, error) {
+	return p.vouchers, nil
+}
+
+func TestMinerPaymentsReport(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	addrGetter := address.NewForTestGetter()
+	minerAddr := addrGetter()
+	payer := addrGetter()
+
+	voucher := func(channel uint64, amount uint64) *types.PaymentVoucher {
+		return &types.PaymentVoucher{
+			Channel: *types.NewChannelID(channel),
+			Payer:   payer,
+			Target:  minerAddr,
+			Amount:  *types.NewAttoFILFromFIL(amount),
+		}
+	}
+	deal := func(state storagedeal.State, channel uint64, price uint64, vouchers ...*types.PaymentVoucher) *storagedeal.Deal {
+		return &storagedeal.Deal{
+			Miner: minerAddr,
+			Proposal: &storagedeal.Proposal{
+				TotalPrice: types.NewAttoFILFromFIL(price),
+				Payment: storagedeal.PaymentInfo{
+					Payer:    payer,
+					Channel:  types.NewChannelID(channel),
+					Vouchers: vouchers,
+				},
+			},
+			Response: &storagedeal.Response{State: state, ProposalCid: cid.Undef},
+		}
+	}
+
+	plumbing := &minerPaymentsReportPlumbing{
+		testing: t,
+		config:  cfg.NewConfig(repo.NewInMemoryRepo()),
+		deals: []*storagedeal.Deal{
+			deal(storagedeal.Complete, 1, 100, voucher(1, 50), voucher(1, 100)),
+			deal(storagedeal.Staged, 2, 40, voucher(2, 20)),
+			deal(storagedeal.Rejected, 3, 10, voucher(3, 10)),
+		},
+		// a voucher for channel 2 received after the proposal
+		vouchers: []*types.PaymentVoucher{voucher(2, 30)},
+		channels: map[address.Address]map[string]*paymentbroker.PaymentChannel{
+			payer: {
+				types.NewChannelID(1).KeyString(): {
+					Target:         minerAddr,
+					Amount:         types.NewAttoFILFromFIL(100),
+					AmountRedeemed: types.NewAttoFILFromFIL(50),
+					AgreedEol:      types.NewBlockHeight(50),
+					Eol:            types.NewBlockHeight(50),
+				},
+			},
+		},
+	}
+	require.NoError(t, plumbing.config.Set("mining.minerAddress", minerAddr.String()))
+
+	report, err := MinerPaymentsReport(ctx, plumbing, address.Undef)
+	require.NoError(t, err)
+
+	assert.Equal(t, minerAddr, report.Miner)
+	require.Len(t, report.Deals, 2)
+
+	assert.Equal(t, types.NewAttoFILFromFIL(100), report.Deals[0].Expected)
+	assert.Equal(t, types.NewAttoFILFromFIL(100), report.Deals[0].Received)
+	assert.Equal(t, types.NewAttoFILFromFIL(50), report.Deals[0].Redeemed)
+
+	assert.Equal(t, types.NewAttoFILFromFIL(40), report.Deals[1].Expected)
+	assert.Equal(t, types.NewAttoFILFromFIL(30), report.Deals[1].Received)
+	assert.Nil(t, report.Deals[1].Redeemed)
+
+	assert.Equal(t, types.NewAttoFILFromFIL(140), report.Expected)
+	assert.Equal(t, types.NewAttoFILFromFIL(130), report.Received)
+	assert.Equal(t, types.NewAttoFILFromFIL(50), report.Redeemed)
+
+	// channels are listed once per payer
+	assert.Equal(t, 1, plumbing.queries)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/conditions"
 	. "github.com/filecoin-project/go-filecoin/porcelain"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -55,11 +56,12 @@ func newTestCreatePaymentsPlumbing() *paymentsTestPlumbing {
 		},
 		messageQuery: func(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
 			voucher := &types.PaymentVoucher{
-				Channel: *channelID,
-				Payer:   payer,
-				Target:  target,
-				Amount:  *params[1].(*types.AttoFIL),
-				ValidAt: *params[2].(*types.BlockHeight),
+				Channel:   *channelID,
+				Payer:     payer,
+				Target:    target,
+				Amount:    *params[1].(*types.AttoFIL),
+				ValidAt:   *params[2].(*types.BlockHeight),
+				Condition: params[3].(*types.Predicate),
 			}
 			voucherBytes, err := actor.MarshalStorage(voucher)
 			if err != nil {
@@ -153,6 +155,18 @@ func TestCreatePayments(t *testing.T) {
 		assert.Equal(t, config.Value, paymentResponse.Vouchers[9].Amount)
 	})
 
+	t.Run("Conditions every payment on the given condition", func(t *testing.T) {
+		config := validPaymentsConfig()
+		config.Condition = conditions.PieceStorage(address.TestAddress, types.CommP{1}, 42)
+		paymentResponse, err := CreatePayments(context.Background(), successPlumbing, config)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, paymentResponse.Vouchers)
+		for _, voucher := range paymentResponse.Vouchers {
+			assert.Equal(t, config.Condition, voucher.Condition)
+		}
+	})
+
 	t.Run("Validates from", func(t *testing.T) {
 		config := validPaymentsConfig()
 		config.From = address.Undef
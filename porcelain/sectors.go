@@ -0,0 +1,115 @@
+package porcelain
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// SectorExpiration describes when the deals stored in a committed sector end.
+type SectorExpiration struct {
+	SectorID uint64 `json:"sectorId"`
+	// CommitHeight is the block height at which the sector was committed.
+	CommitHeight *types.BlockHeight `json:"commitHeight"`
+	// Expiry is the block height at which the last deal stored in the
+	// sector ends. The sector can be removed from then on.
+	Expiry *types.BlockHeight `json:"expiry"`
+	// Deals are the proposal cids of the deals stored in the sector.
+	Deals []cid.Cid `json:"deals"`
+}
+
+// mgseAPI is the subset of the plumbing.API that MinerGetSectorExpirations uses.
+type mgseAPI interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	DealsLs() ([]*storagedeal.Deal, error)
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+}
+
+// MinerGetSectorExpirations returns when the deals stored in each committed
+// sector of the given miner, or of the default miner if minerAddr is empty,
+// end, ordered by expiry. A deal ends Duration blocks after the sector it is
+// stored in was committed. Sectors storing no locally known deals and sectors
+// which are no longer committed are left out.
+func MinerGetSectorExpirations(ctx context.Context, plumbing mgseAPI, minerAddr address.Address) ([]*SectorExpiration, error) {
+	if minerAddr.Empty() {
+		minerValue, err := plumbing.ConfigGet("mining.minerAddress")
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not get miner address in config")
+		}
+		var ok bool
+		minerAddr, ok = minerValue.(address.Address)
+		if !ok {
+			return nil, errors.New("Configured miner is not an address")
+		}
+	}
+
+	deals, err := MinerListDeals(plumbing, minerAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := plumbing.MessageQuery(ctx, address.Undef, minerAddr, "getSectorCommitments")
+	if err != nil {
+		return nil, errors.Wrap(err, "'getSectorCommitments' query message failed")
+	}
+	commitments, err := abi.Deserialize(res[0], abi.CommitmentsMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode sector commitments")
+	}
+	committed, ok := commitments.Val.(map[string]types.Commitments)
+	if !ok {
+		return nil, errors.New("sector commitments have unexpected type")
+	}
+
+	sectors := make(map[uint64]*SectorExpiration)
+	for _, deal := range deals {
+		proofInfo := deal.Response.ProofInfo
+		if proofInfo == nil || (deal.Response.State != storagedeal.Posted && deal.Response.State != storagedeal.Complete) {
+			continue
+		}
+		if _, ok := committed[strconv.FormatUint(proofInfo.SectorID, 10)]; !ok {
+			continue
+		}
+
+		sector, ok := sectors[proofInfo.SectorID]
+		if !ok {
+			res, err := plumbing.MessageQuery(ctx, address.Undef, minerAddr, "getSectorCommitHeight", proofInfo.SectorID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not get commit height of sector %d", proofInfo.SectorID)
+			}
+			commitHeight := types.NewBlockHeightFromBytes(res[0])
+			sector = &SectorExpiration{
+				SectorID:     proofInfo.SectorID,
+				CommitHeight: commitHeight,
+				Expiry:       commitHeight,
+			}
+			sectors[proofInfo.SectorID] = sector
+		}
+
+		expiry := sector.CommitHeight.Add(types.NewBlockHeight(deal.Proposal.Duration))
+		if expiry.GreaterThan(sector.Expiry) {
+			sector.Expiry = expiry
+		}
+		sector.Deals = append(sector.Deals, deal.Response.ProposalCid)
+	}
+
+	expirations := make([]*SectorExpiration, 0, len(sectors))
+	for _, sector := range sectors {
+		expirations = append(expirations, sector)
+	}
+	sort.Slice(expirations, func(i, j int) bool {
+		if !expirations[i].Expiry.Equal(expirations[j].Expiry) {
+			return expirations[i].Expiry.LessThan(expirations[j].Expiry)
+		}
+		return expirations[i].SectorID < expirations[j].SectorID
+	})
+	return expirations, nil
+}
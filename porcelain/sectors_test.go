@@ -0,0 +1,93 @@
+package porcelain_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/address"
+	. "github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+type minerGetSectorExpirationsPlumbing struct {
+	deals         []*storagedeal.Deal
+	commitHeights map[uint64]uint64
+}
+
+func (p *minerGetSectorExpirationsPlumbing) ConfigGet(dottedPath string) (interface{}, error) {
+	return nil, errors.New("unexpected config get")
+}
+
+func (p *minerGetSectorExpirationsPlumbing) DealsLs() ([]*storagedeal.Deal, error) {
+	return p.deals, nil
+}
+
+func (p *minerGetSectorExpirationsPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	switch method {
+	case "getSectorCommitments":
+		commitments := map[string]types.Commitments{}
+		for sectorID := range p.commitHeights {
+			commitments[strconv.FormatUint(sectorID, 10)] = types.Commitments{}
+		}
+		out, err := (&abi.Value{Type: abi.CommitmentsMap, Val: commitments}).Serialize()
+		return [][]byte{out}, err
+	case "getSectorCommitHeight":
+		return [][]byte{types.NewBlockHeight(p.commitHeights[params[0].(uint64)]).Bytes()}, nil
+	default:
+		return nil, errors.New("unexpected method " + method)
+	}
+}
+
+func TestMinerGetSectorExpirations(t *testing.T) {
+	tf.UnitTest(t)
+
+	minerAddr := address.NewForTestGetter()()
+	newCid := types.NewCidForTestGetter()
+
+	deal := func(state storagedeal.State, sectorID uint64, duration uint64) *storagedeal.Deal {
+		return &storagedeal.Deal{
+			Miner:    minerAddr,
+			Proposal: &storagedeal.Proposal{Duration: duration},
+			Response: &storagedeal.Response{
+				State:       state,
+				ProposalCid: newCid(),
+				ProofInfo:   &storagedeal.ProofInfo{SectorID: sectorID},
+			},
+		}
+	}
+
+	deals := []*storagedeal.Deal{
+		deal(storagedeal.Posted, 1, 100),
+		deal(storagedeal.Complete, 1, 300),
+		deal(storagedeal.Posted, 2, 50),
+		// sector 3 was removed
+		deal(storagedeal.Posted, 3, 10),
+		deal(storagedeal.Failed, 2, 1000),
+	}
+	plumbing := &minerGetSectorExpirationsPlumbing{
+		deals:         deals,
+		commitHeights: map[uint64]uint64{1: 10, 2: 20},
+	}
+
+	expirations, err := MinerGetSectorExpirations(context.Background(), plumbing, minerAddr)
+	require.NoError(t, err)
+	require.Len(t, expirations, 2)
+
+	assert.Equal(t, uint64(2), expirations[0].SectorID)
+	assert.Equal(t, types.NewBlockHeight(20), expirations[0].CommitHeight)
+	assert.Equal(t, types.NewBlockHeight(70), expirations[0].Expiry)
+	assert.Len(t, expirations[0].Deals, 1)
+
+	assert.Equal(t, uint64(1), expirations[1].SectorID)
+	assert.Equal(t, types.NewBlockHeight(310), expirations[1].Expiry)
+	assert.Equal(t, deals[0].Response.ProposalCid, expirations[1].Deals[0])
+	assert.Equal(t, deals[1].Response.ProposalCid, expirations[1].Deals[1])
+}
@@ -2,7 +2,9 @@ package porcelain
 
 import (
 	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 )
 
@@ -23,3 +25,61 @@ func DealGet(plumbing strgdlsPlumbing, dealCid cid.Cid) *storagedeal.Deal {
 	}
 	return nil
 }
+
+// ErrNoDealForPiece is returned when no deal in the local datastore stores a
+// piece.
+var ErrNoDealForPiece = errors.New("no posted or complete deal stores the piece")
+
+// DealFindPieceMiner returns the miner of a deal in the local datastore that
+// stores the piece pieceRef, that is whose proposal has been posted to the
+// chain or is complete.
+func DealFindPieceMiner(plumbing strgdlsPlumbing, pieceRef cid.Cid) (address.Address, error) {
+	deals, err := plumbing.DealsLs()
+	if err != nil {
+		return address.Undef, err
+	}
+	for _, storageDeal := range deals {
+		if storageDeal.Proposal == nil || storageDeal.Response == nil || !storageDeal.Proposal.PieceRef.Equals(pieceRef) {
+			continue
+		}
+		if storageDeal.Response.State == storagedeal.Posted || storageDeal.Response.State == storagedeal.Complete {
+			return storageDeal.Miner, nil
+		}
+	}
+	return address.Undef, ErrNoDealForPiece
+}
+
+// mldAPI is the subset of the plumbing.API that MinerListDeals uses.
+type mldAPI interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	DealsLs() ([]*storagedeal.Deal, error)
+}
+
+// MinerListDeals returns the deals in the local datastore that were made with
+// the given miner. If minerAddr is empty, the default miner will be used.
+func MinerListDeals(plumbing mldAPI, minerAddr address.Address) ([]*storagedeal.Deal, error) {
+	if minerAddr.Empty() {
+		minerValue, err := plumbing.ConfigGet("mining.minerAddress")
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not get miner address in config")
+		}
+		var ok bool
+		minerAddr, ok = minerValue.(address.Address)
+		if !ok {
+			return nil, errors.New("Configured miner is not an address")
+		}
+	}
+
+	deals, err := plumbing.DealsLs()
+	if err != nil {
+		return nil, err
+	}
+
+	var minerDeals []*storagedeal.Deal
+	for _, storageDeal := range deals {
+		if storageDeal.Miner == minerAddr {
+			minerDeals = append(minerDeals, storageDeal)
+		}
+	}
+	return minerDeals, nil
+}
@@ -0,0 +1,108 @@
+package porcelain_test
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
+	. "github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	"github.com/filecoin-project/go-filecoin/repo"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+type minerListDealsPlumbing struct {
+	config *cfg.Config
+	deals  []*storagedeal.Deal
+}
+
+func (p *minerListDealsPlumbing) ConfigGet(dottedPath string) (interface{}, error) {
+	return p.config.Get(dottedPath)
+}
+
+func (p *minerListDealsPlumbing) DealsLs() ([]*storagedeal.Deal, error) {
+	return p.deals, nil
+}
+
+func TestMinerListDeals(t *testing.T) {
+	tf.UnitTest(t)
+
+	addrGetter := address.NewForTestGetter()
+	minerAddr := addrGetter()
+	otherMinerAddr := addrGetter()
+
+	plumbing := &minerListDealsPlumbing{
+		config: cfg.NewConfig(repo.NewInMemoryRepo()),
+		deals: []*storagedeal.Deal{
+			{Miner: minerAddr},
+			{Miner: otherMinerAddr},
+			{Miner: minerAddr},
+		},
+	}
+	require.NoError(t, plumbing.config.Set("mining.minerAddress", minerAddr.String()))
+
+	t.Run("lists the deals of the configured miner", func(t *testing.T) {
+		deals, err := MinerListDeals(plumbing, address.Undef)
+		require.NoError(t, err)
+
+		require.Len(t, deals, 2)
+		for _, deal := range deals {
+			assert.Equal(t, minerAddr, deal.Miner)
+		}
+	})
+
+	t.Run("lists the deals of the given miner", func(t *testing.T) {
+		deals, err := MinerListDeals(plumbing, otherMinerAddr)
+		require.NoError(t, err)
+
+		require.Len(t, deals, 1)
+		assert.Equal(t, otherMinerAddr, deals[0].Miner)
+	})
+}
+
+func TestDealFindPieceMiner(t *testing.T) {
+	tf.UnitTest(t)
+
+	addrGetter := address.NewForTestGetter()
+	minerAddr := addrGetter()
+	otherMinerAddr := addrGetter()
+	pieceRef := types.NewCidForTestGetter()()
+
+	deal := func(miner address.Address, piece cid.Cid, state storagedeal.State) *storagedeal.Deal {
+		return &storagedeal.Deal{
+			Miner:    miner,
+			Proposal: &storagedeal.Proposal{PieceRef: piece},
+			Response: &storagedeal.Response{State: state},
+		}
+	}
+
+	t.Run("finds the miner of a posted deal for the piece", func(t *testing.T) {
+		plumbing := &minerListDealsPlumbing{
+			deals: []*storagedeal.Deal{
+				deal(otherMinerAddr, pieceRef, storagedeal.Rejected),
+				deal(otherMinerAddr, types.SomeCid(), storagedeal.Complete),
+				deal(minerAddr, pieceRef, storagedeal.Posted),
+			},
+		}
+
+		miner, err := DealFindPieceMiner(plumbing, pieceRef)
+		require.NoError(t, err)
+		assert.Equal(t, minerAddr, miner)
+	})
+
+	t.Run("fails without a deal storing the piece", func(t *testing.T) {
+		plumbing := &minerListDealsPlumbing{
+			deals: []*storagedeal.Deal{
+				deal(minerAddr, pieceRef, storagedeal.Accepted),
+			},
+		}
+
+		_, err := DealFindPieceMiner(plumbing, pieceRef)
+		assert.Equal(t, ErrNoDealForPiece, err)
+	})
+}
@@ -0,0 +1,47 @@
+package porcelain
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/vesting"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// VestingCreate creates a vesting actor that locks value and releases it to
+// beneficiary linearly from start to end, with nothing released before
+// cliff. It waits for the actor to appear on chain and returns its address.
+func VestingCreate(
+	ctx context.Context,
+	plumbing actcAPI,
+	from address.Address,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+	beneficiary address.Address,
+	start *types.BlockHeight,
+	cliff *types.BlockHeight,
+	end *types.BlockHeight,
+	value *types.AttoFIL,
+) (address.Address, error) {
+	return ActorCreate(ctx, plumbing, from, gasPrice, gasLimit, types.VestingActorCodeCid, value, vesting.Errors, beneficiary, start, cliff, end)
+}
+
+// vestwAPI is the subset of the plumbing.API that VestingWithdraw and
+// VestingRevoke use.
+type vestwAPI interface {
+	MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+}
+
+// VestingWithdraw sends a message withdrawing the funds of the vesting actor
+// that have vested to its beneficiary.
+func VestingWithdraw(ctx context.Context, plumbing vestwAPI, from address.Address, vestingAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return plumbing.MessageSendWithDefaultAddress(ctx, from, vestingAddr, types.NewZeroAttoFIL(), gasPrice, gasLimit, "withdrawVested")
+}
+
+// VestingRevoke sends a message revoking the vesting actor's schedule, which
+// returns the funds that have not vested to its owner.
+func VestingRevoke(ctx context.Context, plumbing vestwAPI, from address.Address, vestingAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return plumbing.MessageSendWithDefaultAddress(ctx, from, vestingAddr, types.NewZeroAttoFIL(), gasPrice, gasLimit, "revoke")
+}
@@ -2,10 +2,13 @@ package porcelain
 
 import (
 	"context"
+
+	"github.com/ipfs/go-cid"
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -61,3 +64,48 @@ func WalletDefaultAddress(plumbing wdaPlumbing) (address.Address, error) {
 
 	return address.Undef, ErrNoDefaultFromAddress
 }
+
+type wrPlumbing interface {
+	ActorGet(ctx context.Context, addr address.Address) (*actor.Actor, error)
+	WalletRestoreHD(mnemonic string, isUsed func(address.Address) (bool, error)) ([]address.Address, error)
+}
+
+// WalletRestore restores the addresses derived from mnemonic into the wallet.
+// Addresses are taken as used if they have an actor in the latest state.
+func WalletRestore(ctx context.Context, plumbing wrPlumbing, mnemonic string) ([]address.Address, error) {
+	return plumbing.WalletRestoreHD(mnemonic, func(addr address.Address) (bool, error) {
+		_, err := plumbing.ActorGet(ctx, addr)
+		if state.IsActorNotFoundError(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+}
+
+type wtPlumbing interface {
+	MessageFind(ctx context.Context, msgCid cid.Cid) (*msg.ChainMessage, bool, error)
+	MessageHistory(addr address.Address) ([]cid.Cid, error)
+}
+
+// WalletTransactions returns the messages on chain sent from or to addr with
+// their receipts, the most recent first.
+func WalletTransactions(ctx context.Context, plumbing wtPlumbing, addr address.Address) ([]*msg.ChainMessage, error) {
+	history, err := plumbing.MessageHistory(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]*msg.ChainMessage, 0, len(history))
+	for _, msgCid := range history {
+		found, ok, err := plumbing.MessageFind(ctx, msgCid)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find message %s", msgCid)
+		}
+		// The chain may have moved on since the history was read.
+		if !ok {
+			continue
+		}
+		txs = append(txs, found)
+	}
+	return txs, nil
+}
@@ -0,0 +1,253 @@
+// +build !windows
+
+// Package bench measures how long this machine takes to add pieces, seal
+// sectors and generate proofs of spacetime, and the resources it uses doing
+// so, so that operators can size hardware before committing storage.
+package bench
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	bserv "github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-datastore"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/proofs"
+	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Phases of a round, in the order they run.
+const (
+	PhaseAddPiece = "add-piece"
+	PhaseSeal     = "seal"
+	PhasePoSt     = "post"
+)
+
+// Phase holds the measurements of a phase over all rounds.
+type Phase struct {
+	Name string
+	// WallTime is the time the phase took.
+	WallTime time.Duration
+	// CPUTime is the user and system CPU time the process used during the
+	// phase, on all cores.
+	CPUTime time.Duration
+	// Bytes is the number of bytes of sectors the phase processed.
+	Bytes uint64
+}
+
+// Throughput returns the number of bytes the phase processed per second of
+// wall time.
+func (p *Phase) Throughput() float64 {
+	if p.WallTime <= 0 {
+		return 0
+	}
+	return float64(p.Bytes) / p.WallTime.Seconds()
+}
+
+// Report is the result of a benchmark.
+type Report struct {
+	SectorSize uint64
+	Rounds     int
+	// Phases holds a measurement for each phase of the rounds.
+	Phases []*Phase
+	// MaxRSS is the peak resident memory of the process in bytes.
+	MaxRSS uint64
+}
+
+// Run adds a sector's worth of random data, seals it and generates a proof
+// of spacetime for it rounds times, with a sector builder storing its
+// sectors in a temporary directory under dir, and reports how long each
+// phase took. An empty dir uses the default temporary directory.
+func Run(ctx context.Context, sectorSize uint64, rounds int, dir string) (*Report, error) {
+	class, err := sectorClass(sectorSize)
+	if err != nil {
+		return nil, err
+	}
+	if rounds < 1 {
+		return nil, errors.New("the benchmark needs at least one round")
+	}
+
+	root, err := ioutil.TempDir(dir, "filecoin-bench")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(root) // nolint: errcheck
+
+	stagingDir, sealedDir := filepath.Join(root, "staging"), filepath.Join(root, "sealed")
+	for _, d := range []string{stagingDir, sealedDir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	blockStore := bstore.NewBlockstore(datastore.NewMapDatastore())
+	minerAddr, err := address.NewActorAddress([]byte("bench"))
+	if err != nil {
+		return nil, err
+	}
+	sb, err := sectorbuilder.NewRustSectorBuilder(sectorbuilder.RustSectorBuilderConfig{
+		BlockService:     bserv.New(blockStore, offline.Exchange(blockStore)),
+		LastUsedSectorID: 0,
+		MetadataDir:      stagingDir,
+		MinerAddr:        minerAddr,
+		SealedSectorDir:  sealedDir,
+		SectorClass:      class,
+		StagedSectorDir:  stagingDir,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create sector builder")
+	}
+	defer sb.Close() // nolint: errcheck
+
+	pieceSize, err := proofs.GetMaxUserBytesPerStagedSector(class.SectorSize())
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		SectorSize: sectorSize,
+		Rounds:     rounds,
+		Phases: []*Phase{
+			{Name: PhaseAddPiece},
+			{Name: PhaseSeal},
+			{Name: PhasePoSt},
+		},
+	}
+	addPiece, seal, post := report.Phases[0], report.Phases[1], report.Phases[2]
+
+	for i := 0; i < rounds; i++ {
+		piece := make([]byte, pieceSize)
+		if _, err := io.ReadFull(rand.Reader, piece); err != nil {
+			return nil, err
+		}
+		ref := dag.NewRawNode(piece).Cid()
+
+		var sectorID uint64
+		err := measure(addPiece, sectorSize, func() error {
+			var err error
+			sectorID, err = sb.AddPiece(ctx, ref, pieceSize, bytes.NewReader(piece))
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to add piece")
+		}
+
+		var sealed *sectorbuilder.SealedSectorMetadata
+		err = measure(seal, sectorSize, func() error {
+			var err error
+			sealed, err = sealSector(ctx, sb, sectorID)
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to seal sector")
+		}
+
+		var seed types.PoStChallengeSeed
+		if _, err := io.ReadFull(rand.Reader, seed[:]); err != nil {
+			return nil, err
+		}
+		err = measure(post, sectorSize, func() error {
+			_, err := sb.GeneratePoSt(sectorbuilder.GeneratePoStRequest{
+				SortedCommRs:  proofs.NewSortedCommRs(sealed.CommR),
+				ChallengeSeed: seed,
+			})
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to generate PoSt")
+		}
+	}
+
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return nil, err
+	}
+	report.MaxRSS = uint64(usage.Maxrss)
+	// Linux reports the peak resident memory in kilobytes, macOS in bytes.
+	if runtime.GOOS != "darwin" {
+		report.MaxRSS *= 1024
+	}
+
+	return report, nil
+}
+
+// sealSector seals the staged sectors and waits for sectorID to be sealed.
+// Sectors which fill up are sealed without being asked to, so the sector may
+// already be sealing.
+func sealSector(ctx context.Context, sb sectorbuilder.SectorBuilder, sectorID uint64) (*sectorbuilder.SealedSectorMetadata, error) {
+	if err := sb.SealAllStagedSectors(ctx, sectorbuilder.SealTicket{}); err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case res := <-sb.SectorSealResults():
+			if res.SectorID != sectorID {
+				continue
+			}
+			if res.SealingErr != nil {
+				return nil, res.SealingErr
+			}
+			return res.SealingResult, nil
+		}
+	}
+}
+
+// measure runs f and adds the wall and CPU time it took and the n bytes it
+// processed to phase.
+func measure(phase *Phase, n uint64, f func() error) error {
+	cpuBefore, err := cpuTime()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+
+	if err := f(); err != nil {
+		return err
+	}
+
+	phase.WallTime += time.Since(start)
+	cpuAfter, err := cpuTime()
+	if err != nil {
+		return err
+	}
+	phase.CPUTime += cpuAfter - cpuBefore
+	phase.Bytes += n
+	return nil
+}
+
+// cpuTime returns the user and system CPU time the process used so far.
+func cpuTime() (time.Duration, error) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, err
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), nil
+}
+
+// sectorClass returns the sector class of sectors of sectorSize bytes.
+func sectorClass(sectorSize uint64) (types.SectorClass, error) {
+	for _, class := range []types.SectorClass{types.NewTestSectorClass(), types.NewLiveSectorClass()} {
+		if class.SectorSize().Uint64() == sectorSize {
+			return class, nil
+		}
+	}
+	return types.SectorClass{}, errors.Errorf("unsupported sector size %d, use %d or %d", sectorSize,
+		types.OneKiBSectorSize.Uint64(), types.TwoHundredFiftySixMiBSectorSize.Uint64())
+}
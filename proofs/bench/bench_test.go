@@ -0,0 +1,36 @@
+// +build !windows
+
+package bench
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestRun(t *testing.T) {
+	tf.SectorBuilderTest(t)
+
+	report, err := Run(context.Background(), types.OneKiBSectorSize.Uint64(), 2, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, report.Rounds)
+	require.Len(t, report.Phases, 3)
+	for _, p := range report.Phases {
+		assert.True(t, p.WallTime > 0, p.Name)
+		assert.Equal(t, 2*types.OneKiBSectorSize.Uint64(), p.Bytes, p.Name)
+	}
+	assert.True(t, report.MaxRSS > 0)
+}
+
+func TestRunRejectsUnknownSectorSizes(t *testing.T) {
+	tf.UnitTest(t)
+
+	_, err := Run(context.Background(), 4096, 1, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,287 @@
+// Package params manages the cache of Groth parameters the proofs need to
+// seal sectors and generate proofs of spacetime: it downloads the parameter
+// files listed in the parameters.json manifest shipped with rust-fil-proofs,
+// verifies them against the manifest's checksums and reports the ones that
+// are missing.
+package params
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/minio/blake2b-simd"
+	"github.com/pkg/errors"
+)
+
+// CacheDirEnvVar is the environment variable libfilecoin_proofs reads the
+// location of the parameter cache from.
+const CacheDirEnvVar = "FILECOIN_PARAMETER_CACHE"
+
+// DefaultCacheDir is the parameter cache libfilecoin_proofs uses when
+// CacheDirEnvVar is not set.
+const DefaultCacheDir = "/tmp/filecoin-proof-parameters"
+
+// DefaultGateway is the IPFS gateway parameter files are downloaded from.
+const DefaultGateway = "https://ipfs.io/ipfs/"
+
+// manifestName is the file name of the manifest.
+const manifestName = "parameters.json"
+
+// digestLen is the number of hex characters of the blake2b digest of a
+// parameter file the manifest records.
+const digestLen = 32
+
+// ErrChecksumMismatch indicates that a parameter file does not match the
+// digest the manifest records for it.
+var ErrChecksumMismatch = errors.New("parameter file does not match its checksum")
+
+// File is the manifest entry of a parameter file.
+type File struct {
+	// Cid is the cid of the file on IPFS.
+	Cid string `json:"cid"`
+	// Digest is the first 32 hex characters of the blake2b-512 digest of the
+	// file.
+	Digest string `json:"digest"`
+	// SectorSize is the size of the sectors the parameters are for.
+	SectorSize uint64 `json:"sector_size"`
+}
+
+// Manifest maps the names of parameter files to their entries.
+type Manifest map[string]File
+
+// LoadManifest reads the manifest at path.
+func LoadManifest(path string) (Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read parameter manifest")
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode parameter manifest %s", path)
+	}
+	return m, nil
+}
+
+// FindManifest returns the path of the manifest, looking next to the running
+// executable, as in release bundles, and then where the build installs it.
+func FindManifest() (string, error) {
+	var candidates []string
+	if exe, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(exe), manifestName))
+	}
+	candidates = append(candidates, filepath.Join("proofs", "misc", manifestName))
+
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c, nil
+		}
+	}
+	return "", errors.Errorf("no parameter manifest found, looked in %s", strings.Join(candidates, ", "))
+}
+
+// CacheDir returns the parameter cache libfilecoin_proofs reads from.
+func CacheDir() string {
+	if dir := os.Getenv(CacheDirEnvVar); dir != "" {
+		return dir
+	}
+	return DefaultCacheDir
+}
+
+// Progress reports the progress of Fetch on a parameter file.
+type Progress struct {
+	Name string
+	// Status is one of "cached", "downloading" and "verified".
+	Status string
+	// Done is the number of bytes downloaded so far.
+	Done uint64
+	// Total is the size of the file, 0 if unknown.
+	Total uint64
+}
+
+// Manager fetches and verifies the parameter files of a manifest in a
+// cache directory.
+type Manager struct {
+	Manifest Manifest
+	Dir      string
+	Gateway  string
+	Client   *http.Client
+}
+
+// NewManager returns a Manager of the files of manifest in dir, downloading
+// them from DefaultGateway.
+func NewManager(manifest Manifest, dir string) *Manager {
+	return &Manager{
+		Manifest: manifest,
+		Dir:      dir,
+		Gateway:  DefaultGateway,
+		Client:   http.DefaultClient,
+	}
+}
+
+// Names returns the names of the files of the manifest for sectors of
+// sectorSize, or of all sizes if sectorSize is 0, in order.
+func (pm *Manager) Names(sectorSize uint64) []string {
+	var names []string
+	for name, f := range pm.Manifest {
+		if sectorSize == 0 || f.SectorSize == sectorSize {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Missing returns the names of the files for sectors of sectorSize that are
+// not in the cache. It does not verify the files that are.
+func (pm *Manager) Missing(sectorSize uint64) ([]string, error) {
+	var missing []string
+	for _, name := range pm.Names(sectorSize) {
+		_, err := os.Stat(pm.path(name))
+		if os.IsNotExist(err) {
+			missing = append(missing, name)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return missing, nil
+}
+
+// Verify checks the cached file name against the digest of the manifest. It
+// returns ErrChecksumMismatch if they differ.
+func (pm *Manager) Verify(name string) error {
+	return pm.verifyFile(name, pm.path(name))
+}
+
+// verifyFile checks the file at path against the digest of the manifest for
+// name.
+func (pm *Manager) verifyFile(name, path string) error {
+	f, ok := pm.Manifest[name]
+	if !ok {
+		return errors.Errorf("%s is not in the parameter manifest", name)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close() // nolint: errcheck
+
+	digest, err := digestOf(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", name)
+	}
+	if !strings.HasPrefix(digest, f.Digest) {
+		return errors.Wrapf(ErrChecksumMismatch, "%s has digest %s, expected %s", name, digest[:digestLen], f.Digest)
+	}
+	return nil
+}
+
+// Fetch downloads the files for sectors of sectorSize, or of all sizes if
+// sectorSize is 0, that are not in the cache or do not match their digest,
+// reporting its progress to progress. Files are verified before they are
+// moved into the cache, so the cache never holds a partial download.
+func (pm *Manager) Fetch(ctx context.Context, sectorSize uint64, progress func(Progress)) error {
+	if err := os.MkdirAll(pm.Dir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create parameter cache")
+	}
+
+	for _, name := range pm.Names(sectorSize) {
+		if err := pm.Verify(name); err == nil {
+			progress(Progress{Name: name, Status: "cached"})
+			continue
+		}
+		if err := pm.fetch(ctx, name, progress); err != nil {
+			return errors.Wrapf(err, "failed to fetch %s", name)
+		}
+		progress(Progress{Name: name, Status: "verified"})
+	}
+	return nil
+}
+
+func (pm *Manager) fetch(ctx context.Context, name string, progress func(Progress)) error {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(pm.Gateway, "/")+"/"+pm.Manifest[name].Cid, nil)
+	if err != nil {
+		return err
+	}
+	res, err := pm.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close() // nolint: errcheck
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("gateway responded %s", res.Status)
+	}
+
+	tmp, err := ioutil.TempFile(pm.Dir, name+".part")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+
+	var total uint64
+	if res.ContentLength > 0 {
+		total = uint64(res.ContentLength)
+	}
+	w := &progressWriter{w: tmp, report: func(done uint64) {
+		progress(Progress{Name: name, Status: "downloading", Done: done, Total: total})
+	}}
+	_, err = io.Copy(w, res.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := pm.verifyFile(name, tmp.Name()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), pm.path(name))
+}
+
+func (pm *Manager) path(name string) string {
+	return filepath.Join(pm.Dir, name)
+}
+
+// digestOf returns the hex encoded blake2b-512 digest of r.
+func digestOf(r io.Reader) (string, error) {
+	h := blake2b.New512()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// reportInterval is the number of bytes progressWriter writes between
+// reports.
+const reportInterval = 16 << 20
+
+// progressWriter reports the number of bytes written through it every
+// reportInterval bytes.
+type progressWriter struct {
+	w        io.Writer
+	done     uint64
+	reported uint64
+	report   func(done uint64)
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.done += uint64(n)
+	if pw.done-pw.reported >= reportInterval {
+		pw.reported = pw.done
+		pw.report(pw.done)
+	}
+	return n, err
+}
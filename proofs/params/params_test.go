@@ -0,0 +1,96 @@
+package params
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestManager(t *testing.T) {
+	tf.UnitTest(t)
+
+	small, large := []byte("small sector parameters"), []byte("large sector parameters")
+	files := map[string][]byte{"QmSmall": small, "QmLarge": large}
+
+	digest := func(data []byte) string {
+		d, err := digestOf(bytes.NewReader(data))
+		require.NoError(t, err)
+		return d[:digestLen]
+	}
+
+	var served int
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[filepath.Base(r.URL.Path)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		served++
+		w.Write(data) // nolint: errcheck
+	}))
+	defer gateway.Close()
+
+	dir, err := ioutil.TempDir("", "params")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	pm := NewManager(Manifest{
+		"small.params": {Cid: "QmSmall", Digest: digest(small), SectorSize: 1024},
+		"large.params": {Cid: "QmLarge", Digest: digest(large), SectorSize: 1 << 28},
+	}, dir)
+	pm.Gateway = gateway.URL + "/ipfs/"
+
+	var statuses []string
+	progress := func(p Progress) { statuses = append(statuses, p.Name+" "+p.Status) }
+
+	t.Run("fetches the files of a sector size", func(t *testing.T) {
+		missing, err := pm.Missing(1024)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"small.params"}, missing)
+
+		require.NoError(t, pm.Fetch(context.Background(), 1024, progress))
+		assert.Equal(t, []string{"small.params verified"}, statuses)
+		assert.NoError(t, pm.Verify("small.params"))
+
+		missing, err = pm.Missing(0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"large.params"}, missing)
+	})
+
+	t.Run("does not download cached files again", func(t *testing.T) {
+		statuses = nil
+		served = 0
+		require.NoError(t, pm.Fetch(context.Background(), 1024, progress))
+		assert.Equal(t, []string{"small.params cached"}, statuses)
+		assert.Equal(t, 0, served)
+	})
+
+	t.Run("replaces corrupt files", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "small.params"), []byte("corrupt"), 0644))
+		assert.Equal(t, ErrChecksumMismatch, errors.Cause(pm.Verify("small.params")))
+
+		require.NoError(t, pm.Fetch(context.Background(), 1024, progress))
+		assert.NoError(t, pm.Verify("small.params"))
+	})
+
+	t.Run("rejects downloads that do not match their checksum", func(t *testing.T) {
+		files["QmLarge"] = []byte("tampered")
+		err := pm.Fetch(context.Background(), 1<<28, progress)
+		assert.Equal(t, ErrChecksumMismatch, errors.Cause(err))
+
+		missing, err := pm.Missing(1 << 28)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"large.params"}, missing)
+	})
+}
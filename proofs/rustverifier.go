@@ -7,6 +7,7 @@ import (
 	logging "github.com/ipfs/go-log"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/metrics"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -17,6 +18,11 @@ import "C"
 
 var log = logging.Logger("fps") // nolint: deadcode
 
+var (
+	verifySealOp = metrics.NewSlowOp("proofs/verify_seal", time.Second)
+	verifyPoSTOp = metrics.NewSlowOp("proofs/verify_post", time.Second)
+)
+
 // RustVerifier provides proof-verification methods.
 type RustVerifier struct{}
 
@@ -33,6 +39,7 @@ func elapsed(what string) func() {
 // derived was valid, and an error if not.
 func (rp *RustVerifier) VerifySeal(req VerifySealRequest) (VerifySealResponse, error) {
 	defer elapsed("VerifySeal")()
+	defer verifySealOp.Start().Stop()
 
 	commDCBytes := C.CBytes(req.CommD[:])
 	defer C.free(commDCBytes)
@@ -77,6 +84,7 @@ func (rp *RustVerifier) VerifySeal(req VerifySealRequest) (VerifySealResponse, e
 // VerifyPoST verifies that a proof-of-spacetime is valid.
 func (rp *RustVerifier) VerifyPoST(req VerifyPoSTRequest) (VerifyPoSTResponse, error) {
 	defer elapsed("VerifyPoST")()
+	defer verifyPoSTOp.Start().Stop()
 
 	// validate verification request
 	if len(req.Proofs) == 0 {
@@ -30,8 +30,11 @@ type SectorBuilder interface {
 	// piece-bytes from a sealed sector.
 	ReadPieceFromSealedSector(pieceCid cid.Cid) (io.Reader, error)
 
-	// SealAllStagedSectors seals any non-empty staged sectors.
-	SealAllStagedSectors(ctx context.Context) error
+	// SealAllStagedSectors seals any non-empty staged sectors, binding each of
+	// them to the provided chain-sampled seal ticket. The ticket is reported
+	// back in the SealedSectorMetadata of each sector, so that the miner can
+	// reference it when precommitting the sector on chain.
+	SealAllStagedSectors(ctx context.Context, ticket SealTicket) error
 
 	// SectorSealResults returns an unbuffered channel that is sent a value
 	// whenever sealing completes. All calls to SectorSealResults will get the
@@ -40,6 +43,12 @@ type SectorBuilder interface {
 	// regardless of the number of times SectorSealResults is called.
 	SectorSealResults() <-chan SectorSealResult
 
+	// DeleteSealedSector deletes the replica of a sealed sector, e.g. once
+	// all the deals stored in it expired and the sector was removed from the
+	// miner's sectors on chain. The sector must no longer be included in the
+	// sectors passed to GeneratePoSt.
+	DeleteSealedSector(sectorID uint64) error
+
 	// GeneratePoSt creates a proof-of-spacetime for the replicas managed by
 	// the SectorBuilder. Its output includes the proof-of-spacetime proof which
 	// is posted to the blockchain along with any faults. The proof can be
@@ -73,6 +82,15 @@ type PieceInfo struct {
 	InclusionProof []byte  `json:"inclusionProof"`
 }
 
+// SealTicket is the randomness, sampled from the chain at BlockHeight, to
+// which a sector's replica is bound when it is sealed. The miner actor
+// re-samples the chain at BlockHeight when the sector is precommitted, so a
+// miner cannot seal a sector before the ticket exists.
+type SealTicket struct {
+	BlockHeight uint64
+	TicketBytes []byte
+}
+
 // SealedSectorMetadata is a sector that has been sealed by the PoRep setup process
 type SealedSectorMetadata struct {
 	CommD     types.CommD
@@ -81,6 +99,7 @@ type SealedSectorMetadata struct {
 	Pieces    []*PieceInfo // deprecated (will be removed soon)
 	Proof     types.PoRepProof
 	SectorID  uint64
+	Ticket    SealTicket
 }
 
 // GeneratePoStRequest represents a request to generate a proof-of-spacetime.
@@ -6,12 +6,15 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"os"
 	"runtime"
+	"sync"
 	"time"
 	"unsafe"
 
 	bserv "github.com/ipfs/go-blockservice"
 	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log"
 	"github.com/pkg/errors"
 
@@ -65,12 +68,29 @@ type RustSectorBuilder struct {
 	// SectorClass configures behavior of libfilecoin_proofs, including sector
 	// packing, sector sizes, sealing and PoSt generation performance.
 	SectorClass types.SectorClass
+
+	// sealTicketsLk protects sealTickets and lastSealTicket.
+	sealTicketsLk sync.Mutex
+
+	// sealTickets maps the id of a sector scheduled for sealing to the ticket
+	// it was scheduled with.
+	sealTickets map[uint64]SealTicket
+
+	// lastSealTicket is the most recent ticket provided to the builder. It is
+	// used for sectors which libfilecoin_proofs seals on its own accord (e.g.
+	// because they filled up) and for which no ticket was recorded.
+	lastSealTicket SealTicket
+
+	// sealedStore distributes sealed sectors across the sealed sector paths,
+	// nil if none are configured.
+	sealedStore *sealedStore
 }
 
 var _ SectorBuilder = &RustSectorBuilder{}
 
 // RustSectorBuilderConfig is a configuration object used when instantiating a
-// Rust-backed SectorBuilder through the FFI. All fields are required.
+// Rust-backed SectorBuilder through the FFI. All fields are required, except
+// SealedSectorPaths and SealedSectorLocations.
 type RustSectorBuilderConfig struct {
 	BlockService     bserv.BlockService
 	LastUsedSectorID uint64
@@ -79,6 +99,14 @@ type RustSectorBuilderConfig struct {
 	SealedSectorDir  string
 	StagedSectorDir  string
 	SectorClass      types.SectorClass
+
+	// SealedSectorPaths are the paths sealed sectors are moved to from
+	// SealedSectorDir, which then links to them. Sectors stay in
+	// SealedSectorDir if there are none.
+	SealedSectorPaths []SealedSectorPath
+	// SealedSectorLocations records the path holding each sealed sector. It
+	// is required if there are SealedSectorPaths.
+	SealedSectorLocations datastore.Datastore
 }
 
 // NewRustSectorBuilder instantiates a SectorBuilder through the FFI.
@@ -124,6 +152,13 @@ func NewRustSectorBuilder(cfg RustSectorBuilderConfig) (*RustSectorBuilder, erro
 		ptr:               unsafe.Pointer(resPtr.sector_builder),
 		sectorSealResults: make(chan SectorSealResult),
 		SectorClass:       cfg.SectorClass,
+		sealTickets:       make(map[uint64]SealTicket),
+	}
+	if len(cfg.SealedSectorPaths) > 0 {
+		if cfg.SealedSectorLocations == nil {
+			return nil, errors.New("sealed sector paths need a datastore to record sector locations in")
+		}
+		sb.sealedStore = newSealedStore(cfg.SealedSectorPaths, cfg.SealedSectorLocations)
 	}
 
 	// load staged sector metadata and use it to initialize the poller
@@ -280,6 +315,14 @@ func (sb *RustSectorBuilder) findSealedSectorMetadata(sectorID uint64) (*SealedS
 
 		proof := goBytes(resPtr.proof_ptr, resPtr.proof_len)
 
+		if sb.sealedStore != nil {
+			// the sector stays where it was sealed if it cannot be moved
+			access := C.GoString(resPtr.sector_access)
+			if _, err := sb.sealedStore.Place(sectorID, access); err != nil {
+				log.Errorf("failed to move sealed sector %d from %s: %s", sectorID, access, err)
+			}
+		}
+
 		ps, err := goPieceInfos(resPtr.pieces_ptr, resPtr.pieces_len)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to marshal from string to cid")
@@ -303,6 +346,7 @@ func (sb *RustSectorBuilder) findSealedSectorMetadata(sectorID uint64) (*SealedS
 			Pieces:    ps,
 			Proof:     proof,
 			SectorID:  sectorID,
+			Ticket:    sb.takeSealTicket(sectorID),
 		}, nil
 	} else {
 		// unknown
@@ -326,8 +370,55 @@ func (sb *RustSectorBuilder) ReadPieceFromSealedSector(pieceCid cid.Cid) (io.Rea
 	return bytes.NewReader(goBytes(resPtr.data_ptr, resPtr.data_len)), nil
 }
 
-// SealAllStagedSectors schedules sealing of all staged sectors.
-func (sb *RustSectorBuilder) SealAllStagedSectors(ctx context.Context) error {
+// DeleteSealedSector deletes the replica of a sealed sector, along with the
+// replica it links to if the sector was moved to a sealed sector path.
+//
+// TODO: libfilecoin_proofs does not yet expose a way to forget a sealed
+// sector, so it keeps reporting the sector as sealed and reading its pieces
+// fails. Drop the sector from its metadata through the FFI once
+// rust-fil-proofs supports it.
+func (sb *RustSectorBuilder) DeleteSealedSector(sectorID uint64) error {
+	resPtr := (*C.GetSealStatusResponse)(unsafe.Pointer(C.get_seal_status((*C.SectorBuilder)(sb.ptr), C.uint64_t(sectorID))))
+	defer C.destroy_get_seal_status_response(resPtr)
+
+	if resPtr.status_code != 0 {
+		return errors.New(C.GoString(resPtr.error_msg))
+	}
+	if resPtr.seal_status_code != C.Sealed {
+		return errors.Errorf("sector %d is not sealed", sectorID)
+	}
+
+	access := C.GoString(resPtr.sector_access)
+	if sb.sealedStore != nil {
+		if err := sb.sealedStore.Remove(sectorID, access); err != nil {
+			return errors.Wrapf(err, "failed to delete sealed sector %d", sectorID)
+		}
+	}
+	if err := os.Remove(access); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to delete sealed sector %d", sectorID)
+	}
+	return nil
+}
+
+// SealAllStagedSectors schedules sealing of all staged sectors, recording the
+// provided ticket for each of them.
+//
+// TODO: libfilecoin_proofs does not yet accept a ticket when sealing, so the
+// ticket is only tracked here and reported with the sealing results. Pass
+// the ticket bytes through the FFI once rust-fil-proofs supports it.
+func (sb *RustSectorBuilder) SealAllStagedSectors(ctx context.Context, ticket SealTicket) error {
+	staged, err := sb.stagedSectors()
+	if err != nil {
+		return errors.Wrap(err, "failed to load staged sectors")
+	}
+
+	sb.sealTicketsLk.Lock()
+	for _, m := range staged {
+		sb.sealTickets[m.sectorID] = ticket
+	}
+	sb.lastSealTicket = ticket
+	sb.sealTicketsLk.Unlock()
+
 	resPtr := (*C.SealAllStagedSectorsResponse)(unsafe.Pointer(C.seal_all_staged_sectors((*C.SectorBuilder)(sb.ptr))))
 	defer C.destroy_seal_all_staged_sectors_response(resPtr)
 
@@ -338,6 +429,21 @@ func (sb *RustSectorBuilder) SealAllStagedSectors(ctx context.Context) error {
 	return nil
 }
 
+// takeSealTicket returns the ticket recorded for the given sector and forgets
+// it. If no ticket was recorded, the most recent ticket is returned.
+func (sb *RustSectorBuilder) takeSealTicket(sectorID uint64) SealTicket {
+	sb.sealTicketsLk.Lock()
+	defer sb.sealTicketsLk.Unlock()
+
+	ticket, ok := sb.sealTickets[sectorID]
+	if !ok {
+		return sb.lastSealTicket
+	}
+	delete(sb.sealTickets, sectorID)
+
+	return ticket
+}
+
 // stagedSectors returns a slice of all staged sector metadata for the sector builder, or an error.
 func (sb *RustSectorBuilder) stagedSectors() ([]*stagedSectorMetadata, error) {
 	resPtr := (*C.GetStagedSectorsResponse)(unsafe.Pointer(C.get_staged_sectors((*C.SectorBuilder)(sb.ptr))))
@@ -0,0 +1,255 @@
+// +build !windows
+
+package sectorbuilder
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/pkg/errors"
+)
+
+// sealedLocationsPrefix prefixes the keys mapping sector ids to the sealed
+// sector paths holding them.
+const sealedLocationsPrefix = "/sectorbuilder/sealed"
+
+// ErrNoSealedSectorSpace indicates that none of the sealed sector paths has
+// room for a sealed sector.
+var ErrNoSealedSectorSpace = errors.New("no sealed sector path has enough free space")
+
+// SealedSectorPath is a directory sealed sectors are distributed to, usually
+// on its own disk.
+type SealedSectorPath struct {
+	Path string
+	// Weight is the share of the sealed sectors the path gets relative to the
+	// other paths. A weight of zero counts as one.
+	Weight uint64
+}
+
+// sealedLocation records the sealed sector path holding a sector.
+type sealedLocation struct {
+	Path string `json:"path"`
+}
+
+// sealedStore distributes the replicas of sealed sectors across sealed
+// sector paths in proportion to their weights, skipping the paths without
+// room for them. libfilecoin_proofs writes replicas to its sealed sector
+// directory, so a replica moved to another path is replaced with a link to
+// its new location, from which the proofs keep reading it. The store records
+// the path holding each sector in its datastore.
+type sealedStore struct {
+	paths []SealedSectorPath
+	ds    datastore.Datastore
+
+	// freeSpace returns the number of bytes available in the file system of
+	// a path.
+	freeSpace func(path string) (uint64, error)
+
+	// lk serializes placements so that they see each other's sectors.
+	lk sync.Mutex
+}
+
+func newSealedStore(paths []SealedSectorPath, ds datastore.Datastore) *sealedStore {
+	return &sealedStore{
+		paths:     paths,
+		ds:        ds,
+		freeSpace: freeSpace,
+	}
+}
+
+// Location returns the sealed sector path holding the replica of sectorID,
+// if the store placed it.
+func (s *sealedStore) Location(sectorID uint64) (string, bool, error) {
+	data, err := s.ds.Get(sealedLocationKey(sectorID))
+	if err == datastore.ErrNotFound {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, errors.Wrap(err, "failed to read sealed sector location")
+	}
+
+	var loc sealedLocation
+	if err := json.Unmarshal(data, &loc); err != nil {
+		return "", false, errors.Wrap(err, "failed to decode sealed sector location")
+	}
+	return loc.Path, true, nil
+}
+
+// Place moves the replica of sectorID at access to the sealed sector path
+// with the fewest sectors for its weight among those with room for it, and
+// links access to it. Placing a sector again does nothing.
+func (s *sealedStore) Place(sectorID uint64, access string) (string, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	if path, ok, err := s.Location(sectorID); err != nil || ok {
+		return path, err
+	}
+
+	info, err := os.Lstat(access)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to stat sealed sector")
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return "", errors.Errorf("sealed sector %s already links to another path", access)
+	}
+
+	path, err := s.choose(uint64(info.Size()))
+	if err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(path, filepath.Base(access))
+	if err := moveFile(access, dest); err != nil {
+		return "", errors.Wrapf(err, "failed to move sealed sector to %s", path)
+	}
+	if err := os.Symlink(dest, access); err != nil {
+		return "", errors.Wrap(err, "failed to link sealed sector")
+	}
+
+	data, err := json.Marshal(&sealedLocation{Path: path})
+	if err != nil {
+		return "", err
+	}
+	if err := s.ds.Put(sealedLocationKey(sectorID), data); err != nil {
+		return "", errors.Wrap(err, "failed to record sealed sector location")
+	}
+	return path, nil
+}
+
+// Remove deletes the replica of sectorID linked to from access, if the store
+// placed it, and forgets its location.
+func (s *sealedStore) Remove(sectorID uint64, access string) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	path, ok, err := s.Location(sectorID)
+	if err != nil || !ok {
+		return err
+	}
+
+	dest := filepath.Join(path, filepath.Base(access))
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to delete moved sealed sector")
+	}
+	if err := s.ds.Delete(sealedLocationKey(sectorID)); err != nil {
+		return errors.Wrap(err, "failed to delete sealed sector location")
+	}
+	return nil
+}
+
+// choose returns the path with the fewest sectors for its weight among those
+// with size bytes free.
+func (s *sealedStore) choose(size uint64) (string, error) {
+	counts, err := s.counts()
+	if err != nil {
+		return "", err
+	}
+
+	best := -1
+	var bestCount, bestWeight uint64
+	for i, p := range s.paths {
+		free, err := s.freeSpace(p.Path)
+		if err != nil {
+			log.Warningf("skipping sealed sector path %s: %s", p.Path, err)
+			continue
+		}
+		if free < size {
+			continue
+		}
+
+		weight := p.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		// counts[a]/weight[a] < counts[b]/weight[b], without dividing
+		if best < 0 || counts[p.Path]*bestWeight < bestCount*weight {
+			best, bestCount, bestWeight = i, counts[p.Path], weight
+		}
+	}
+	if best < 0 {
+		return "", ErrNoSealedSectorSpace
+	}
+	return s.paths[best].Path, nil
+}
+
+// counts returns the number of sectors the store placed in each path.
+func (s *sealedStore) counts() (map[string]uint64, error) {
+	results, err := s.ds.Query(query.Query{Prefix: sealedLocationsPrefix})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query sealed sector locations")
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read sealed sector locations")
+	}
+
+	counts := make(map[string]uint64)
+	for _, e := range entries {
+		var loc sealedLocation
+		if err := json.Unmarshal(e.Value, &loc); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode sealed sector location %s", e.Key)
+		}
+		counts[loc.Path]++
+	}
+	return counts, nil
+}
+
+func sealedLocationKey(sectorID uint64) datastore.Key {
+	return datastore.NewKey(sealedLocationsPrefix).ChildString(strconv.FormatUint(sectorID, 10))
+}
+
+// freeSpace returns the number of bytes available to unprivileged users in
+// the file system holding path.
+func freeSpace(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return st.Bavail * uint64(st.Bsize), nil
+}
+
+// moveFile renames src to dest, copying it when they are on different file
+// systems.
+func moveFile(src, dest string) error {
+	err := os.Rename(src, dest)
+	if err == nil {
+		return nil
+	}
+	if lerr, ok := err.(*os.LinkError); !ok || lerr.Err != syscall.EXDEV {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close() // nolint: errcheck
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()     // nolint: errcheck
+		os.Remove(dest) // nolint: errcheck
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()     // nolint: errcheck
+		os.Remove(dest) // nolint: errcheck
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dest) // nolint: errcheck
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,115 @@
+// +build !windows
+
+package sectorbuilder
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestSealedStore(t *testing.T) {
+	tf.UnitTest(t)
+
+	root, err := ioutil.TempDir("", "sealedstore")
+	require.NoError(t, err)
+	defer os.RemoveAll(root) // nolint: errcheck
+
+	sealedDir := filepath.Join(root, "sealed")
+	diskA, diskB, diskFull := filepath.Join(root, "a"), filepath.Join(root, "b"), filepath.Join(root, "full")
+	for _, d := range []string{sealedDir, diskA, diskB, diskFull} {
+		require.NoError(t, os.Mkdir(d, 0755))
+	}
+
+	store := newSealedStore([]SealedSectorPath{
+		{Path: diskA, Weight: 2},
+		{Path: diskB, Weight: 1},
+		{Path: diskFull, Weight: 10},
+	}, datastore.NewMapDatastore())
+	store.freeSpace = func(path string) (uint64, error) {
+		if path == diskFull {
+			return 1, nil
+		}
+		return 1 << 30, nil
+	}
+
+	seal := func(sectorID uint64) string {
+		access := filepath.Join(sealedDir, fmt.Sprintf("sector-%d", sectorID))
+		require.NoError(t, ioutil.WriteFile(access, []byte("replica"), 0644))
+		return access
+	}
+
+	t.Run("distributes sectors by weight, skipping full paths", func(t *testing.T) {
+		var placed []string
+		for id := uint64(1); id <= 6; id++ {
+			path, err := store.Place(id, seal(id))
+			require.NoError(t, err)
+			placed = append(placed, path)
+		}
+
+		counts, err := store.counts()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]uint64{diskA: 4, diskB: 2}, counts)
+		assert.NotContains(t, placed, diskFull)
+	})
+
+	t.Run("links sectors to their new location", func(t *testing.T) {
+		path, ok, err := store.Location(1)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		access := filepath.Join(sealedDir, "sector-1")
+		target, err := os.Readlink(access)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(path, "sector-1"), target)
+
+		data, err := ioutil.ReadFile(access)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("replica"), data)
+	})
+
+	t.Run("placing a sector again does nothing", func(t *testing.T) {
+		before, _, err := store.Location(1)
+		require.NoError(t, err)
+
+		after, err := store.Place(1, filepath.Join(sealedDir, "sector-1"))
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("fails when no path has room", func(t *testing.T) {
+		store.freeSpace = func(string) (uint64, error) { return 1, nil }
+		access := seal(7)
+
+		_, err := store.Place(7, access)
+		assert.Equal(t, ErrNoSealedSectorSpace, err)
+
+		_, ok, err := store.Location(7)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		info, err := os.Lstat(access)
+		require.NoError(t, err)
+		assert.True(t, info.Mode().IsRegular())
+	})
+
+	t.Run("removes the replicas of placed sectors", func(t *testing.T) {
+		path, _, err := store.Location(2)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Remove(2, filepath.Join(sealedDir, "sector-2")))
+
+		_, err = os.Stat(filepath.Join(path, "sector-2"))
+		assert.True(t, os.IsNotExist(err))
+		_, ok, err := store.Location(2)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
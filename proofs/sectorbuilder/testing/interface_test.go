@@ -13,6 +13,7 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
+	"github.com/filecoin-project/go-filecoin/sampling"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/filecoin-project/go-filecoin/types"
 
@@ -58,7 +59,7 @@ func TestSectorBuilder(t *testing.T) {
 		for i := 0; i < autoSealsToSchedule; i++ {
 			go func(n int) {
 				time.Sleep(time.Second * time.Duration(n))
-				err := h.SectorBuilder.SealAllStagedSectors(context.Background())
+				err := h.SectorBuilder.SealAllStagedSectors(context.Background(), sectorbuilder.SealTicket{})
 				if err != nil {
 					errs <- err
 				}
@@ -227,6 +228,29 @@ func TestSectorBuilder(t *testing.T) {
 		require.Equal(t, hex.EncodeToString(inputBytes), hex.EncodeToString(outputBytes))
 	})
 
+	t.Run("sealing results report the seal ticket", func(t *testing.T) {
+		h := NewBuilder(t).Build()
+		defer h.Close()
+
+		sectorID, _, err := h.AddPiece(context.Background(), RequireRandomBytes(t, h.MaxBytesPerSector/2))
+		require.NoError(t, err)
+
+		ticket := sectorbuilder.SealTicket{
+			BlockHeight: 42,
+			TicketBytes: RequireRandomBytes(t, 32),
+		}
+		require.NoError(t, h.SectorBuilder.SealAllStagedSectors(context.Background(), ticket))
+
+		select {
+		case val := <-h.SectorBuilder.SectorSealResults():
+			require.NoError(t, val.SealingErr)
+			require.Equal(t, sectorID, val.SealingResult.SectorID)
+			require.Equal(t, ticket, val.SealingResult.Ticket)
+		case <-time.After(MaxTimeToSealASector):
+			t.Fatalf("timed out waiting for seal to complete")
+		}
+	})
+
 	t.Run("sector builder resumes polling for staged sectors even after a restart", func(t *testing.T) {
 		stagingDir, err := ioutil.TempDir("", "staging")
 		if err != nil {
@@ -261,7 +285,7 @@ func TestSectorBuilder(t *testing.T) {
 		sectorIDSet.Store(sectorIDB, true)
 
 		// seal everything
-		err = hB.SectorBuilder.SealAllStagedSectors(context.Background())
+		err = hB.SectorBuilder.SealAllStagedSectors(context.Background(), sectorbuilder.SealTicket{})
 		require.NoError(t, err)
 
 		timeout := time.After(MaxTimeToSealASector * 2)
@@ -323,9 +347,13 @@ func TestSectorBuilder(t *testing.T) {
 			require.NoError(t, serr, "seal proof-verification produced an error")
 			require.True(t, sres.IsValid, "seal proof was not valid")
 
-			// TODO: This should be generates from some standard source of
-			// entropy, e.g. the blockchain
-			challengeSeed := types.PoStChallengeSeed{1, 2, 3}
+			// derive the seed from a chain the way the miner actor does
+			genesis := types.NewBlockForTest(nil, 0)
+			genesis.Ticket = []byte{1, 2, 3}
+			rand, err := sampling.Rand(types.NewBlockHeight(0), []types.TipSet{types.RequireNewTipSet(t, genesis)})
+			require.NoError(t, err)
+			var challengeSeed types.PoStChallengeSeed
+			copy(challengeSeed[:], rand)
 
 			sortedCommRs := proofs.NewSortedCommRs(val.SealingResult.CommR)
 
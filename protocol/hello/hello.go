@@ -14,10 +14,12 @@ import (
 	ma "github.com/multiformats/go-multiaddr"
 
 	cbu "github.com/filecoin-project/go-filecoin/cborutil"
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/metrics"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
+var networkErrCt = metrics.NewInt64Counter("hello_network_error", "Number of errors encountered in hello protocol due to peers of another network")
 var versionErrCt = metrics.NewInt64Counter("hello_version_error", "Number of errors encountered in hello protocol due to incorrect version")
 var genesisErrCt = metrics.NewInt64Counter("hello_genesis_error", "Number of errors encountered in hello protocol due to incorrect genesis block")
 var helloMsgErrCt = metrics.NewInt64Counter("hello_message_error", "Number of errors encountered in hello protocol due to malformed message")
@@ -37,6 +39,8 @@ type Message struct {
 	HeaviestTipSetHeight uint64
 	GenesisHash          cid.Cid
 	CommitSha            string
+	NetworkName          string
+	ProtocolVersion      uint64
 }
 
 type syncCallback func(from peer.ID, cids []cid.Cid, height uint64)
@@ -59,19 +63,21 @@ type Handler struct {
 	// for filling out our hello messages.
 	getHeaviestTipSet getTipSetFunc
 
-	net       string
+	// network is the network the node is part of. Peers of other networks,
+	// or running other protocol versions, are disconnected.
+	network   *consensus.Network
 	commitSha string
 }
 
 // New creates a new instance of the hello protocol and registers it to
 // the given host, with the provided callbacks.
-func New(h host.Host, gen cid.Cid, syncCallback syncCallback, getHeaviestTipSet getTipSetFunc, net string, commitSha string) *Handler {
+func New(h host.Host, gen cid.Cid, syncCallback syncCallback, getHeaviestTipSet getTipSetFunc, network *consensus.Network, commitSha string) *Handler {
 	hello := &Handler{
 		host:              h,
 		genesis:           gen,
 		chainSyncCB:       syncCallback,
 		getHeaviestTipSet: getHeaviestTipSet,
-		net:               net,
+		network:           network,
 		commitSha:         commitSha,
 	}
 	h.SetStreamHandler(protocol, hello.handleNewStream)
@@ -96,6 +102,11 @@ func (h *Handler) handleNewStream(s net.Stream) {
 	}
 
 	switch err := h.processHelloMessage(from, &hello); err {
+	case ErrWrongNetwork:
+		log.Debugf("network: %q does not match: %q, disconnecting from peer: %s", hello.NetworkName, h.network.Name, from)
+		networkErrCt.Inc(context.TODO(), 1)
+		s.Conn().Close() // nolint: errcheck
+		return
 	case ErrBadGenesis:
 		log.Debugf("genesis cid: %s does not match: %s, disconnecting from peer: %s", &hello.GenesisHash, h.genesis, from)
 		genesisErrCt.Inc(context.TODO(), 1)
@@ -106,23 +117,44 @@ func (h *Handler) handleNewStream(s net.Stream) {
 		versionErrCt.Inc(context.TODO(), 1)
 		s.Conn().Close() // nolint: errcheck
 		return
+	case ErrWrongProtocolVersion:
+		log.Debugf("protocol not at same version: peer has version %d at height %d, daemon expects version %d, disconnecting from peer: %s", hello.ProtocolVersion, hello.HeaviestTipSetHeight, h.network.VersionAt(types.NewBlockHeight(hello.HeaviestTipSetHeight)), from)
+		versionErrCt.Inc(context.TODO(), 1)
+		s.Conn().Close() // nolint: errcheck
+		return
 	case nil: // ok, noop
 	default:
 		log.Error(err)
 	}
 }
 
+// ErrWrongNetwork is the error returned when a peer is part of another network.
+var ErrWrongNetwork = fmt.Errorf("network mismatch")
+
 // ErrBadGenesis is the error returned when a mismatch in genesis blocks happens.
 var ErrBadGenesis = fmt.Errorf("bad genesis block")
 
 // ErrWrongVersion is the error returned when a mismatch in the code version happens.
 var ErrWrongVersion = fmt.Errorf("code version mismatch")
 
+// ErrWrongProtocolVersion is the error returned when a mismatch in the protocol version happens.
+var ErrWrongProtocolVersion = fmt.Errorf("protocol version mismatch")
+
+// processHelloMessage checks that the peer is part of our network, and runs
+// the protocol version our network runs at the height of the peer's head.
+// Peers on either side of an upgrade disagree on the version once their
+// heads pass its height.
 func (h *Handler) processHelloMessage(from peer.ID, msg *Message) error {
+	if msg.NetworkName != h.network.Name {
+		return ErrWrongNetwork
+	}
 	if !msg.GenesisHash.Equals(h.genesis) {
 		return ErrBadGenesis
 	}
-	if (h.net == "devnet-test" || h.net == "devnet-user") && msg.CommitSha != h.commitSha {
+	if msg.ProtocolVersion != h.network.VersionAt(types.NewBlockHeight(msg.HeaviestTipSetHeight)) {
+		return ErrWrongProtocolVersion
+	}
+	if (h.network.Name == "devnet-test" || h.network.Name == "devnet-user") && msg.CommitSha != h.commitSha {
 		return ErrWrongVersion
 	}
 
@@ -145,6 +177,8 @@ func (h *Handler) getOurHelloMessage() *Message {
 		HeaviestTipSetCids:   heaviest.ToSortedCidSet().ToSlice(),
 		HeaviestTipSetHeight: height,
 		CommitSha:            h.commitSha,
+		NetworkName:          h.network.Name,
+		ProtocolVersion:      h.network.VersionAt(types.NewBlockHeight(height)),
 	}
 }
 
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-filecoin/consensus"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -54,8 +55,8 @@ func TestHelloHandshake(t *testing.T) {
 	msc1, msc2 := new(mockSyncCallback), new(mockSyncCallback)
 	hg1, hg2 := &mockHeaviestGetter{heavy1}, &mockHeaviestGetter{heavy2}
 
-	New(a, genesisA.Cid(), msc1.SyncCallback, hg1.getHeaviestTipSet, "", "")
-	New(b, genesisA.Cid(), msc2.SyncCallback, hg2.getHeaviestTipSet, "", "")
+	New(a, genesisA.Cid(), msc1.SyncCallback, hg1.getHeaviestTipSet, consensus.NetworkByName(""), "")
+	New(b, genesisA.Cid(), msc2.SyncCallback, hg2.getHeaviestTipSet, consensus.NetworkByName(""), "")
 
 	msc1.On("SyncCallback", b.ID(), heavy2.ToSortedCidSet().ToSlice(), uint64(3)).Return()
 	msc2.On("SyncCallback", a.ID(), heavy1.ToSortedCidSet().ToSlice(), uint64(2)).Return()
@@ -108,8 +109,8 @@ func TestHelloBadGenesis(t *testing.T) {
 	msc1, msc2 := new(mockSyncCallback), new(mockSyncCallback)
 	hg1, hg2 := &mockHeaviestGetter{heavy1}, &mockHeaviestGetter{heavy2}
 
-	New(a, genesisA.Cid(), msc1.SyncCallback, hg1.getHeaviestTipSet, "", "")
-	New(b, genesisB.Cid(), msc2.SyncCallback, hg2.getHeaviestTipSet, "", "")
+	New(a, genesisA.Cid(), msc1.SyncCallback, hg1.getHeaviestTipSet, consensus.NetworkByName(""), "")
+	New(b, genesisB.Cid(), msc2.SyncCallback, hg2.getHeaviestTipSet, consensus.NetworkByName(""), "")
 
 	msc1.On("SyncCallback", mock.Anything, mock.Anything, mock.Anything).Return()
 	msc2.On("SyncCallback", mock.Anything, mock.Anything, mock.Anything).Return()
@@ -141,10 +142,10 @@ func TestHelloWrongVersion(t *testing.T) {
 	msc1, msc2 := new(mockSyncCallback), new(mockSyncCallback)
 	hg := &mockHeaviestGetter{heavy}
 
-	New(a, genesisA.Cid(), msc1.SyncCallback, hg.getHeaviestTipSet, "devnet-user", "sha1")
+	New(a, genesisA.Cid(), msc1.SyncCallback, hg.getHeaviestTipSet, consensus.NetworkByName("devnet-user"), "sha1")
 	msc1.On("SyncCallback", mock.Anything, mock.Anything, mock.Anything).Return()
 
-	New(b, genesisA.Cid(), msc2.SyncCallback, hg.getHeaviestTipSet, "devnet-user", "sha2")
+	New(b, genesisA.Cid(), msc2.SyncCallback, hg.getHeaviestTipSet, consensus.NetworkByName("devnet-user"), "sha2")
 	msc2.On("SyncCallback", mock.Anything, mock.Anything, mock.Anything).Return()
 
 	require.NoError(t, mn.LinkAll())
@@ -174,10 +175,82 @@ func TestHelloWrongVersionTestDevnet(t *testing.T) {
 	msc1, msc2 := new(mockSyncCallback), new(mockSyncCallback)
 	hg := &mockHeaviestGetter{heavy}
 
-	New(a, genesisA.Cid(), msc1.SyncCallback, hg.getHeaviestTipSet, "devnet-test", "sha1")
+	New(a, genesisA.Cid(), msc1.SyncCallback, hg.getHeaviestTipSet, consensus.NetworkByName("devnet-test"), "sha1")
 	msc1.On("SyncCallback", mock.Anything, mock.Anything, mock.Anything).Return()
 
-	New(b, genesisA.Cid(), msc2.SyncCallback, hg.getHeaviestTipSet, "devnet-test", "sha2")
+	New(b, genesisA.Cid(), msc2.SyncCallback, hg.getHeaviestTipSet, consensus.NetworkByName("devnet-test"), "sha2")
+	msc2.On("SyncCallback", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	require.NoError(t, mn.LinkAll())
+	require.NoError(t, mn.ConnectAllButSelf())
+
+	time.Sleep(time.Millisecond * 50)
+
+	msc1.AssertNumberOfCalls(t, "SyncCallback", 0)
+	msc2.AssertNumberOfCalls(t, "SyncCallback", 0)
+}
+
+func TestHelloWrongProtocolVersion(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mn, err := mocknet.WithNPeers(ctx, 2)
+	assert.NoError(t, err)
+
+	a, b := mn.Hosts()[0], mn.Hosts()[1]
+
+	genesisA := &types.Block{Nonce: 451}
+
+	heavy := th.RequireNewTipSet(t, &types.Block{Nonce: 1000, Height: 2})
+
+	msc1, msc2 := new(mockSyncCallback), new(mockSyncCallback)
+	hg := &mockHeaviestGetter{heavy}
+
+	New(a, genesisA.Cid(), msc1.SyncCallback, hg.getHeaviestTipSet, consensus.NetworkByName(""), "")
+	msc1.On("SyncCallback", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	// b upgraded to version 2 at height 1, so the heads at height 2 run
+	// different versions.
+	upgraded := &consensus.Network{ProtocolVersions: consensus.ProtocolVersionTable{
+		{Version: 1, Height: types.NewBlockHeight(0)},
+		{Version: 2, Height: types.NewBlockHeight(1)},
+	}}
+	New(b, genesisA.Cid(), msc2.SyncCallback, hg.getHeaviestTipSet, upgraded, "")
+	msc2.On("SyncCallback", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	require.NoError(t, mn.LinkAll())
+	require.NoError(t, mn.ConnectAllButSelf())
+
+	time.Sleep(time.Millisecond * 50)
+
+	msc1.AssertNumberOfCalls(t, "SyncCallback", 0)
+	msc2.AssertNumberOfCalls(t, "SyncCallback", 0)
+}
+
+func TestHelloWrongNetwork(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mn, err := mocknet.WithNPeers(ctx, 2)
+	assert.NoError(t, err)
+
+	a, b := mn.Hosts()[0], mn.Hosts()[1]
+
+	genesisA := &types.Block{Nonce: 451}
+
+	heavy := th.RequireNewTipSet(t, &types.Block{Nonce: 1000, Height: 2})
+
+	msc1, msc2 := new(mockSyncCallback), new(mockSyncCallback)
+	hg := &mockHeaviestGetter{heavy}
+
+	New(a, genesisA.Cid(), msc1.SyncCallback, hg.getHeaviestTipSet, consensus.NetworkByName("devnet-a"), "")
+	msc1.On("SyncCallback", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	New(b, genesisA.Cid(), msc2.SyncCallback, hg.getHeaviestTipSet, consensus.NetworkByName("devnet-b"), "")
 	msc2.On("SyncCallback", mock.Anything, mock.Anything, mock.Anything).Return()
 
 	require.NoError(t, mn.LinkAll())
@@ -217,8 +290,8 @@ func TestHelloMultiBlock(t *testing.T) {
 	msc1, msc2 := new(mockSyncCallback), new(mockSyncCallback)
 	hg1, hg2 := &mockHeaviestGetter{heavy1}, &mockHeaviestGetter{heavy2}
 
-	New(a, genesisA.Cid(), msc1.SyncCallback, hg1.getHeaviestTipSet, "", "")
-	New(b, genesisA.Cid(), msc2.SyncCallback, hg2.getHeaviestTipSet, "", "")
+	New(a, genesisA.Cid(), msc1.SyncCallback, hg1.getHeaviestTipSet, consensus.NetworkByName(""), "")
+	New(b, genesisA.Cid(), msc2.SyncCallback, hg2.getHeaviestTipSet, consensus.NetworkByName(""), "")
 
 	msc1.On("SyncCallback", b.ID(), heavy2.ToSortedCidSet().ToSlice(), uint64(3)).Return()
 	msc2.On("SyncCallback", a.ID(), heavy1.ToSortedCidSet().ToSlice(), uint64(2)).Return()
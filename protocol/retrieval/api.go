@@ -8,6 +8,7 @@ import (
 	"github.com/libp2p/go-libp2p-peer"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 // API here is the API for a retrieval client.
@@ -22,5 +23,17 @@ func NewAPI(rc *Client) API {
 
 // RetrievePiece retrieves bytes referenced by CID pieceCID
 func (a *API) RetrievePiece(ctx context.Context, pieceCID cid.Cid, mpid peer.ID, minerAddr address.Address) (io.ReadCloser, error) {
-	return a.rc.RetrievePiece(ctx, mpid, pieceCID)
+	return a.rc.RetrievePiece(ctx, mpid, pieceCID, "")
+}
+
+// RetrievePiecePath retrieves the bytes of the file at path within the DAG
+// referenced by CID pieceCID
+func (a *API) RetrievePiecePath(ctx context.Context, pieceCID cid.Cid, path string, mpid peer.ID, minerAddr address.Address) (io.ReadCloser, error) {
+	return a.rc.RetrievePiece(ctx, mpid, pieceCID, path)
+}
+
+// RetrievePaidPiece retrieves bytes referenced by CID pieceCID, paying for them
+// from the payment channel channel of payer
+func (a *API) RetrievePaidPiece(ctx context.Context, pieceCID cid.Cid, mpid peer.ID, minerAddr address.Address, payer address.Address, channel *types.ChannelID, maxPricePerChunk *types.AttoFIL) (io.ReadCloser, error) {
+	return a.rc.RetrievePaidPiece(ctx, mpid, pieceCID, payer, channel, maxPricePerChunk)
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"io"
 	"io/ioutil"
+	"math/big"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -12,10 +13,13 @@ import (
 	host "github.com/libp2p/go-libp2p-host"
 	inet "github.com/libp2p/go-libp2p-net"
 	"github.com/libp2p/go-libp2p-peer"
+	"github.com/libp2p/go-libp2p-protocol"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	cbu "github.com/filecoin-project/go-filecoin/cborutil"
 	"github.com/filecoin-project/go-filecoin/net"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 // RetrievePieceChunkSize defines the size of piece-chunks to be sent from miner to client. The maximum size of readable
@@ -24,6 +28,8 @@ import (
 const RetrievePieceChunkSize = 256 << 8
 
 type clientPorcelainAPI interface {
+	ChainBlockHeight() (*types.BlockHeight, error)
+	PaymentChannelVoucher(ctx context.Context, fromAddr address.Address, channel *types.ChannelID, amount *types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate) (voucher *types.PaymentVoucher, err error)
 	PingMinerWithTimeout(ctx context.Context, p peer.ID, to time.Duration) error
 }
 
@@ -43,25 +49,20 @@ func NewClient(host host.Host, blockTime time.Duration, api clientPorcelainAPI)
 	}
 }
 
-// RetrievePiece connects to a miner and transfers a piece of content.
-func (sc *Client) RetrievePiece(ctx context.Context, minerPeerID peer.ID, pieceCID cid.Cid) (io.ReadCloser, error) {
-	err := sc.api.PingMinerWithTimeout(ctx, minerPeerID, 15*time.Second)
-	if err == net.ErrPingSelf {
-		return nil, errors.New("attempting to retrieve piece from self. This is currently unsupported.  Please use a separate go-filecoin node as client")
-	}
+// RetrievePiece connects to a miner and transfers a piece of content. If path
+// is not empty only the file it names within the piece's DAG is transferred.
+func (sc *Client) RetrievePiece(ctx context.Context, minerPeerID peer.ID, pieceCID cid.Cid, path string) (io.ReadCloser, error) {
+	s, err := sc.newStream(ctx, minerPeerID, retrievalFreeProtocol)
 	if err != nil {
 		return nil, err
 	}
-	s, err := sc.host.NewStream(ctx, minerPeerID, retrievalFreeProtocol)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create stream to retrieval miner")
-	}
 	defer sc.safeCloseStream(s)
 
 	streamReader := cbu.NewMsgReader(s)
 
 	req := RetrievePieceRequest{
 		PieceRef: pieceCID,
+		Path:     path,
 	}
 
 	if err := cbu.NewMsgWriter(s).WriteMsg(&req); err != nil {
@@ -97,6 +98,96 @@ func (sc *Client) RetrievePiece(ctx context.Context, minerPeerID peer.ID, pieceC
 	return buffered, nil
 }
 
+// RetrievePaidPiece connects to a miner and transfers a piece of content,
+// paying for each chunk as it arrives with a voucher from payer's payment
+// channel. It fails without paying anything if the miner asks for more than
+// maxPricePerChunk.
+func (sc *Client) RetrievePaidPiece(ctx context.Context, minerPeerID peer.ID, pieceCID cid.Cid, payer address.Address, channel *types.ChannelID, maxPricePerChunk *types.AttoFIL) (io.ReadCloser, error) {
+	s, err := sc.newStream(ctx, minerPeerID, retrievalPaidProtocol)
+	if err != nil {
+		return nil, err
+	}
+	defer sc.safeCloseStream(s)
+
+	streamReader := cbu.NewMsgReader(s)
+	streamWriter := cbu.NewMsgWriter(s)
+
+	req := RetrievePaidPieceRequest{
+		PieceRef: pieceCID,
+		Payer:    payer,
+		Channel:  channel,
+	}
+
+	if err := streamWriter.WriteMsg(&req); err != nil {
+		return nil, errors.Wrap(err, "failed to write request message to stream")
+	}
+
+	var res RetrievePaidPieceResponse
+	if err := streamReader.ReadMsg(&res); err != nil {
+		return nil, errors.Wrap(err, "failed to read response message from stream")
+	}
+
+	if res.Status != Success {
+		return nil, errors.Errorf("could not retrieve piece - error from miner: %s", res.ErrorMessage)
+	}
+
+	if res.PricePerChunk == nil || res.PricePerChunk.GreaterThan(maxPricePerChunk) {
+		return nil, errors.Errorf("miner's price per chunk (%s) exceeds maximum (%s)", res.PricePerChunk, maxPricePerChunk)
+	}
+
+	validAt, err := sc.api.ChainBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf []byte
+	for chunks := int64(1); ; chunks++ {
+		var chunk RetrievePieceChunk
+		if err := streamReader.ReadMsg(&chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return nil, errors.Errorf("could not read chunk from stream: %s", err.Error())
+		}
+
+		buf = append(buf, chunk.Data...)
+
+		// Each voucher covers every chunk received so far.
+		amount := res.PricePerChunk.MulBigInt(big.NewInt(chunks))
+		voucher, err := sc.api.PaymentChannelVoucher(ctx, payer, channel, amount, validAt, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create voucher")
+		}
+
+		if err := streamWriter.WriteMsg(&RetrievePiecePayment{Voucher: voucher}); err != nil {
+			return nil, errors.Wrap(err, "failed to write payment to stream")
+		}
+	}
+
+	if uint64(len(buf)) != res.Size {
+		return nil, errors.Errorf("received %d bytes of piece but expected %d", len(buf), res.Size)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(buf)), nil
+}
+
+// newStream opens a stream to the miner after checking it is reachable.
+func (sc *Client) newStream(ctx context.Context, minerPeerID peer.ID, pid protocol.ID) (inet.Stream, error) {
+	err := sc.api.PingMinerWithTimeout(ctx, minerPeerID, 15*time.Second)
+	if err == net.ErrPingSelf {
+		return nil, errors.New("attempting to retrieve piece from self. This is currently unsupported.  Please use a separate go-filecoin node as client")
+	}
+	if err != nil {
+		return nil, err
+	}
+	s, err := sc.host.NewStream(ctx, minerPeerID, pid)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create stream to retrieval miner")
+	}
+	return s, nil
+}
+
 func (sc *Client) safeCloseStream(stream inet.Stream) {
 	if err := stream.Close(); err != nil {
 		log.Errorf("error closing stream: %s", err)
@@ -2,7 +2,17 @@
 //
 // 1. CLIENT opens /fil/retrieval/free/0.0.0 stream to MINER
 // 2. CLIENT sends MINER a RetrievePieceRequest
-// 3. MINER sends CLIENT a RetrievePieceResponse with Status set to Success if it has PieceRef in a sealed sector
-// 4. MINER sends CLIENT RetrievePieceChunks until all data associated with PieceRef has been sent
+// 3. MINER sends CLIENT a RetrievePieceResponse with Status set to Success if it has PieceRef in a sealed sector, or,
+// if the request names a Path, if it can resolve Path within its local copy of PieceRef's DAG
+// 4. MINER sends CLIENT RetrievePieceChunks until all data associated with PieceRef (or Path) has been sent
 // 5. CLIENT reads RetrievePieceChunk from stream until EOF and then closes stream
+//
+// Pieces can also be paid for over /fil/retrieval/paid/0.0.0:
+//
+// 1. CLIENT sends MINER a RetrievePaidPieceRequest naming a payment channel whose target is the miner's owner
+// 2. MINER sends CLIENT a RetrievePaidPieceResponse with Status set to Success, its price per chunk and the piece size
+// if it has PieceRef in a sealed sector and the channel holds enough funds to pay for the whole piece
+// 3. MINER sends CLIENT a RetrievePieceChunk and waits for a RetrievePiecePayment whose voucher pays for every chunk
+// sent so far, stopping if the voucher is invalid
+// 4. Once all chunks are sent MINER stores the last voucher for redemption and closes the stream
 package retrieval
@@ -1,39 +1,68 @@
 package retrieval
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math/big"
+	"strings"
 
+	bserv "github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs-exchange-offline"
 	logging "github.com/ipfs/go-log"
+	dag "github.com/ipfs/go-merkledag"
+	uio "github.com/ipfs/go-unixfs/io"
 	host "github.com/libp2p/go-libp2p-host"
 	inet "github.com/libp2p/go-libp2p-net"
 	"github.com/libp2p/go-libp2p-protocol"
+	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/address"
 	cbu "github.com/filecoin-project/go-filecoin/cborutil"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 var log = logging.Logger("/fil/retrieval")
 
 const retrievalFreeProtocol = protocol.ID("/fil/retrieval/free/0.0.0")
+const retrievalPaidProtocol = protocol.ID("/fil/retrieval/paid/0.0.0")
 
 // TODO: better name
 type minerNode interface {
 	Host() host.Host
+	BlockService() bserv.BlockService
 	SectorBuilder() sectorbuilder.SectorBuilder
 }
 
+type minerPorcelainAPI interface {
+	ChainBlockHeight() (*types.BlockHeight, error)
+	ConfigGet(dottedPath string) (interface{}, error)
+	DealsLs() ([]*storagedeal.Deal, error)
+	MinerGetOwnerAddress(ctx context.Context, minerAddr address.Address) (address.Address, error)
+	PaymentChannelLs(ctx context.Context, fromAddr address.Address, payerAddr address.Address) (map[string]*paymentbroker.PaymentChannel, error)
+	VoucherPut(voucher *types.PaymentVoucher) error
+}
+
 // Miner serves requests for pieces from RetrievalClients.
 type Miner struct {
-	node minerNode
+	node         minerNode
+	porcelainAPI minerPorcelainAPI
 }
 
-// NewMiner is used to create a Miner and bind a handling function to the piece retrieval protocol.
-func NewMiner(nd minerNode) *Miner {
+// NewMiner is used to create a Miner and bind handling functions to the piece retrieval protocols.
+func NewMiner(nd minerNode, porcelainAPI minerPorcelainAPI) *Miner {
 	rm := &Miner{
-		node: nd,
+		node:         nd,
+		porcelainAPI: porcelainAPI,
 	}
 
 	nd.Host().SetStreamHandler(retrievalFreeProtocol, rm.handleRetrievePieceForFree)
+	nd.Host().SetStreamHandler(retrievalPaidProtocol, rm.handleRetrievePaidPiece)
 
 	return rm
 }
@@ -47,7 +76,15 @@ func (rm *Miner) handleRetrievePieceForFree(s inet.Stream) {
 		return
 	}
 
-	reader, err := rm.node.SectorBuilder().ReadPieceFromSealedSector(req.PieceRef)
+	var reader io.Reader
+	err := rm.requireFreeRetrieval()
+	if err == nil {
+		if req.Path == "" {
+			reader, err = rm.node.SectorBuilder().ReadPieceFromSealedSector(req.PieceRef)
+		} else {
+			reader, err = rm.readPath(context.Background(), req.PieceRef, req.Path)
+		}
+	}
 	if err != nil {
 		log.Warningf("failed to obtain a reader for piece with CID %s: %s", req.PieceRef.String(), err)
 
@@ -67,6 +104,7 @@ func (rm *Miner) handleRetrievePieceForFree(s inet.Stream) {
 	if err != nil {
 		log.Errorf("failed to read all bytes: %s", err)
 	}
+	log.Debugf("serving %d bytes of piece with CID %s (path %q)", len(bs), req.PieceRef.String(), req.Path)
 
 	resp := RetrievePieceResponse{
 		Status: Success,
@@ -94,3 +132,290 @@ func (rm *Miner) handleRetrievePieceForFree(s inet.Stream) {
 		}
 	}
 }
+
+// requireFreeRetrieval returns an error if the miner charges for retrievals,
+// which must then go through the paid protocol.
+func (rm *Miner) requireFreeRetrieval() error {
+	price, err := rm.retrievalPrice()
+	if err != nil {
+		return err
+	}
+	if price.GreaterThan(types.ZeroAttoFIL) {
+		return fmt.Errorf("miner charges %s per chunk for retrievals, use the paid retrieval protocol", price.String())
+	}
+	return nil
+}
+
+// retrievalPrice returns the price per chunk the miner charges for
+// retrievals.
+func (rm *Miner) retrievalPrice() (*types.AttoFIL, error) {
+	priceValue, err := rm.porcelainAPI.ConfigGet("mining.retrievalPrice")
+	if err != nil {
+		return nil, err
+	}
+	price, ok := priceValue.(*types.AttoFIL)
+	if !ok {
+		return nil, errors.New("could not retrieve retrievalPrice from config")
+	}
+	return price, nil
+}
+
+// readPath returns a reader over the file at path within the unixfs DAG rooted
+// at pieceRef. Pieces are sealed as the flat bytes of their DAG's root file, so
+// a file within them can't be located in a sealed sector without unsealing the
+// whole piece. Instead path is resolved against the copy of the DAG the miner
+// fetched when accepting the storage deal, which is never fetched again from
+// the network. Only the DAGs of pieces the miner stores under a deal are
+// served, not any other DAG that happens to be in its blockstore.
+func (rm *Miner) readPath(ctx context.Context, pieceRef cid.Cid, path string) (io.Reader, error) {
+	if err := rm.requireStoredPiece(pieceRef); err != nil {
+		return nil, err
+	}
+
+	bs := rm.node.BlockService().Blockstore()
+	dserv := dag.NewDAGService(bserv.New(bs, offline.Exchange(bs)))
+
+	nd, err := dserv.Get(ctx, pieceRef)
+	if err != nil {
+		return nil, errors.Wrapf(err, "DAG for piece %s is not available", pieceRef.String())
+	}
+
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+
+		dir, err := uio.NewDirectoryFromNode(dserv, nd)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve %q in path %q", name, path)
+		}
+
+		nd, err = dir.Find(ctx, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve %q in path %q", name, path)
+		}
+	}
+
+	return uio.NewDagReader(ctx, nd, dserv)
+}
+
+// requireStoredPiece returns an error unless the miner stores pieceRef under a
+// deal it has posted to the chain.
+func (rm *Miner) requireStoredPiece(pieceRef cid.Cid) error {
+	minerAddr, err := rm.minerAddress()
+	if err != nil {
+		return err
+	}
+
+	deals, err := rm.porcelainAPI.DealsLs()
+	if err != nil {
+		return err
+	}
+	for _, storageDeal := range deals {
+		if storageDeal.Miner != minerAddr || storageDeal.Proposal == nil || storageDeal.Response == nil || !storageDeal.Proposal.PieceRef.Equals(pieceRef) {
+			continue
+		}
+		if storageDeal.Response.State == storagedeal.Posted || storageDeal.Response.State == storagedeal.Complete {
+			return nil
+		}
+	}
+	return fmt.Errorf("miner stores no piece with CID %s", pieceRef.String())
+}
+
+// minerAddress returns the address of the miner the node is configured to
+// mine with.
+func (rm *Miner) minerAddress() (address.Address, error) {
+	minerValue, err := rm.porcelainAPI.ConfigGet("mining.minerAddress")
+	if err != nil {
+		return address.Undef, err
+	}
+	minerAddr, ok := minerValue.(address.Address)
+	if !ok {
+		return address.Undef, errors.New("configured miner is not an address")
+	}
+	return minerAddr, nil
+}
+
+func (rm *Miner) handleRetrievePaidPiece(s inet.Stream) {
+	defer s.Close() // nolint: errcheck
+
+	ctx := context.Background()
+
+	var req RetrievePaidPieceRequest
+	if err := cbu.NewMsgReader(s).ReadMsg(&req); err != nil {
+		log.Errorf("failed to read paid piece retrieval request: %s", err)
+		return
+	}
+
+	price, channel, bs, err := rm.preparePaidRetrieval(ctx, &req)
+	if err != nil {
+		log.Warningf("rejecting paid retrieval of piece with CID %s: %s", req.PieceRef.String(), err)
+
+		resp := RetrievePaidPieceResponse{
+			Status:       Failure,
+			ErrorMessage: err.Error(),
+		}
+
+		if err := cbu.NewMsgWriter(s).WriteMsg(&resp); err != nil {
+			log.Warningf("failed to write response for piece with CID %s: %s", req.PieceRef.String(), err)
+		}
+
+		return
+	}
+
+	resp := RetrievePaidPieceResponse{
+		Status:        Success,
+		PricePerChunk: price,
+		Size:          uint64(len(bs)),
+	}
+
+	if err := cbu.NewMsgWriter(s).WriteMsg(&resp); err != nil {
+		log.Warningf("failed to write response for piece with CID %s: %s", req.PieceRef.String(), err)
+		return
+	}
+
+	// Vouchers are cumulative so only the latest one needs keeping for redemption.
+	var paid *types.PaymentVoucher
+	defer func() {
+		if paid == nil || paid.Amount.IsZero() {
+			return
+		}
+		if err := rm.porcelainAPI.VoucherPut(paid); err != nil {
+			log.Errorf("failed to store voucher for piece with CID %s: %s", req.PieceRef.String(), err)
+		}
+	}()
+
+	reader := cbu.NewMsgReader(s)
+	for i := 0; i < len(bs); i += RetrievePieceChunkSize {
+		end := i + RetrievePieceChunkSize
+
+		if end > len(bs) {
+			end = len(bs)
+		}
+
+		chunk := RetrievePieceChunk{
+			Data: bs[i:end],
+		}
+
+		if err := cbu.NewMsgWriter(s).WriteMsg(&chunk); err != nil {
+			log.Warningf("failed to write chunk for CID %s: %s", req.PieceRef.String(), err)
+			return
+		}
+
+		var payment RetrievePiecePayment
+		if err := reader.ReadMsg(&payment); err != nil {
+			log.Warningf("failed to read payment for CID %s: %s", req.PieceRef.String(), err)
+			return
+		}
+
+		height, err := rm.porcelainAPI.ChainBlockHeight()
+		if err != nil {
+			log.Warningf("stopping retrieval of piece with CID %s: %s", req.PieceRef.String(), err)
+			return
+		}
+
+		// Each payment covers every chunk sent so far.
+		due := price.MulBigInt(big.NewInt(int64(i/RetrievePieceChunkSize + 1)))
+		if err := validatePayment(&req, channel, due, height, payment.Voucher); err != nil {
+			log.Warningf("stopping retrieval of piece with CID %s: %s", req.PieceRef.String(), err)
+			return
+		}
+		paid = payment.Voucher
+	}
+}
+
+// preparePaidRetrieval checks that the channel in req can pay for the whole
+// piece and returns the price per chunk, the channel payments are made from
+// and the piece's bytes.
+func (rm *Miner) preparePaidRetrieval(ctx context.Context, req *RetrievePaidPieceRequest) (*types.AttoFIL, *paymentbroker.PaymentChannel, []byte, error) {
+	price, err := rm.retrievalPrice()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	minerAddr, err := rm.minerAddress()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	owner, err := rm.porcelainAPI.MinerGetOwnerAddress(ctx, minerAddr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if req.Channel == nil {
+		return nil, nil, nil, errors.New("request has no payment channel")
+	}
+
+	channels, err := rm.porcelainAPI.PaymentChannelLs(ctx, owner, req.Payer)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	channel, ok := channels[req.Channel.KeyString()]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("payer %s has no payment channel %s", req.Payer, req.Channel.String())
+	}
+	if channel.Target != owner {
+		return nil, nil, nil, fmt.Errorf("miner owner (%s) is not target of payment channel (%s)", owner, channel.Target)
+	}
+
+	height, err := rm.porcelainAPI.ChainBlockHeight()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if height.GreaterEqual(channel.Eol) {
+		return nil, nil, nil, errors.New("payment channel has expired")
+	}
+
+	reader, err := rm.node.SectorBuilder().ReadPieceFromSealedSector(req.PieceRef)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	bs, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	chunks := (len(bs) + RetrievePieceChunkSize - 1) / RetrievePieceChunkSize
+	total := price.MulBigInt(big.NewInt(int64(chunks)))
+	if available := channel.Amount.Sub(channel.AmountRedeemed); available.LessThan(total) {
+		return nil, nil, nil, fmt.Errorf("payment channel does not contain enough funds (%s < %s)", available, total)
+	}
+
+	return price, channel, bs, nil
+}
+
+// validatePayment checks that voucher pays at least due to the target of
+// channel, the channel in req, and that the payment broker would redeem it at
+// height: the miner only accepts vouchers it can redeem right away.
+func validatePayment(req *RetrievePaidPieceRequest, channel *paymentbroker.PaymentChannel, due *types.AttoFIL, height *types.BlockHeight, voucher *types.PaymentVoucher) error {
+	if voucher == nil {
+		return errors.New("payment contains no voucher")
+	}
+	if voucher.Payer != req.Payer || !voucher.Channel.Equal(req.Channel) {
+		return errors.New("voucher is not for the requested payment channel")
+	}
+	if voucher.Target != channel.Target {
+		return fmt.Errorf("voucher target (%s) is not miner owner (%s)", voucher.Target, channel.Target)
+	}
+	if voucher.Condition != nil {
+		return errors.New("voucher has a condition")
+	}
+	if voucher.ValidAt.GreaterThan(height) {
+		return fmt.Errorf("voucher is not valid until height %s", voucher.ValidAt.String())
+	}
+	if height.GreaterEqual(channel.Eol) {
+		return errors.New("payment channel has expired")
+	}
+	if voucher.Amount.LessThan(due) {
+		return fmt.Errorf("voucher amount (%s) is less than amount due (%s)", voucher.Amount.String(), due.String())
+	}
+	if voucher.Amount.GreaterThan(channel.Amount) {
+		return fmt.Errorf("voucher amount (%s) exceeds the funds of the payment channel (%s)", voucher.Amount.String(), channel.Amount.String())
+	}
+	if !paymentbroker.VerifyVoucherSignature(channel.Scheme, voucher.Payer, &voucher.Channel, &voucher.Amount, &voucher.ValidAt, voucher.Condition, voucher.Signature) {
+		return errors.New("invalid signature in voucher")
+	}
+	return nil
+}
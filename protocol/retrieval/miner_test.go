@@ -0,0 +1,208 @@
+package retrieval
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestValidatePayment(t *testing.T) {
+	tf.UnitTest(t)
+
+	signer, _ := types.NewMockSignersAndKeyInfo(1)
+	payer := signer.Addresses[0]
+	addrGetter := address.NewForTestGetter()
+	target := addrGetter()
+	channelID := types.NewChannelID(5)
+	height := types.NewBlockHeight(10)
+
+	req := &RetrievePaidPieceRequest{
+		Payer:   payer,
+		Channel: channelID,
+	}
+	channel := &paymentbroker.PaymentChannel{
+		Target:         target,
+		Amount:         types.NewAttoFILFromFIL(5),
+		AmountRedeemed: types.NewAttoFILFromFIL(0),
+		Eol:            types.NewBlockHeight(20),
+		Scheme:         paymentbroker.SchemeForAddress(payer),
+	}
+
+	newConditionalVoucher := func(amount *types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate) *types.PaymentVoucher {
+		sig, err := paymentbroker.SignVoucher(channelID, amount, validAt, payer, condition, signer)
+		require.NoError(t, err)
+
+		return &types.PaymentVoucher{
+			Channel:   *channelID,
+			Payer:     payer,
+			Target:    target,
+			Amount:    *amount,
+			ValidAt:   *validAt,
+			Condition: condition,
+			Signature: sig,
+		}
+	}
+	newVoucher := func(amount *types.AttoFIL) *types.PaymentVoucher {
+		return newConditionalVoucher(amount, types.NewBlockHeight(0), nil)
+	}
+
+	due := types.NewAttoFILFromFIL(2)
+
+	t.Run("accepts a voucher for the amount due", func(t *testing.T) {
+		assert.NoError(t, validatePayment(req, channel, due, height, newVoucher(due)))
+	})
+
+	t.Run("accepts a voucher for more than the amount due", func(t *testing.T) {
+		assert.NoError(t, validatePayment(req, channel, due, height, newVoucher(types.NewAttoFILFromFIL(3))))
+	})
+
+	t.Run("rejects a missing voucher", func(t *testing.T) {
+		assert.Error(t, validatePayment(req, channel, due, height, nil))
+	})
+
+	t.Run("rejects a voucher for less than the amount due", func(t *testing.T) {
+		err := validatePayment(req, channel, due, height, newVoucher(types.NewAttoFILFromFIL(1)))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "less than amount due")
+	})
+
+	t.Run("rejects a voucher for more than the channel holds", func(t *testing.T) {
+		err := validatePayment(req, channel, due, height, newVoucher(types.NewAttoFILFromFIL(6)))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeds the funds")
+	})
+
+	t.Run("rejects a voucher for another channel", func(t *testing.T) {
+		voucher := newVoucher(due)
+		voucher.Channel = *types.NewChannelID(6)
+
+		assert.Error(t, validatePayment(req, channel, due, height, voucher))
+	})
+
+	t.Run("rejects a voucher for another target", func(t *testing.T) {
+		otherChannel := *channel
+		otherChannel.Target = addrGetter()
+
+		assert.Error(t, validatePayment(req, &otherChannel, due, height, newVoucher(due)))
+	})
+
+	t.Run("rejects a voucher with a condition", func(t *testing.T) {
+		condition := &types.Predicate{To: addrGetter(), Method: "verifyPieceInclusion"}
+
+		err := validatePayment(req, channel, due, height, newConditionalVoucher(due, types.NewBlockHeight(0), condition))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "condition")
+	})
+
+	t.Run("rejects a voucher valid only at a later height", func(t *testing.T) {
+		assert.NoError(t, validatePayment(req, channel, due, height, newConditionalVoucher(due, height, nil)))
+
+		err := validatePayment(req, channel, due, height, newConditionalVoucher(due, types.NewBlockHeight(11), nil))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not valid until")
+	})
+
+	t.Run("rejects a voucher once the channel expired", func(t *testing.T) {
+		err := validatePayment(req, channel, due, types.NewBlockHeight(20), newVoucher(due))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expired")
+	})
+
+	t.Run("verifies the signature with the scheme of the channel", func(t *testing.T) {
+		blsChannel := *channel
+		blsChannel.Scheme = paymentbroker.SchemeBLS
+
+		err := validatePayment(req, &blsChannel, due, height, newVoucher(due))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "signature")
+	})
+
+	t.Run("rejects a voucher with a bad signature", func(t *testing.T) {
+		voucher := newVoucher(due)
+		voucher.Amount = *types.NewAttoFILFromFIL(4)
+
+		err := validatePayment(req, channel, due, height, voucher)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "signature")
+	})
+}
+
+type retrievalPriceTestAPI struct {
+	minerPorcelainAPI
+	price *types.AttoFIL
+}
+
+func (api *retrievalPriceTestAPI) ConfigGet(dottedPath string) (interface{}, error) {
+	return api.price, nil
+}
+
+func TestRequireFreeRetrieval(t *testing.T) {
+	tf.UnitTest(t)
+
+	free := &Miner{porcelainAPI: &retrievalPriceTestAPI{price: types.NewAttoFILFromFIL(0)}}
+	assert.NoError(t, free.requireFreeRetrieval())
+
+	paid := &Miner{porcelainAPI: &retrievalPriceTestAPI{price: types.NewAttoFILFromFIL(1)}}
+	err := paid.requireFreeRetrieval()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "paid retrieval protocol")
+}
+
+type storedPieceTestAPI struct {
+	minerPorcelainAPI
+	minerAddr address.Address
+	deals     []*storagedeal.Deal
+}
+
+func (api *storedPieceTestAPI) ConfigGet(dottedPath string) (interface{}, error) {
+	return api.minerAddr, nil
+}
+
+func (api *storedPieceTestAPI) DealsLs() ([]*storagedeal.Deal, error) {
+	return api.deals, nil
+}
+
+func TestRequireStoredPiece(t *testing.T) {
+	tf.UnitTest(t)
+
+	addrGetter := address.NewForTestGetter()
+	minerAddr := addrGetter()
+	otherMiner := addrGetter()
+	pieceRef := types.NewCidForTestGetter()()
+
+	newDeal := func(miner address.Address, state storagedeal.State) *storagedeal.Deal {
+		return &storagedeal.Deal{
+			Miner:    miner,
+			Proposal: &storagedeal.Proposal{PieceRef: pieceRef},
+			Response: &storagedeal.Response{State: state},
+		}
+	}
+	requireStoredPiece := func(deals ...*storagedeal.Deal) error {
+		rm := &Miner{porcelainAPI: &storedPieceTestAPI{minerAddr: minerAddr, deals: deals}}
+		return rm.requireStoredPiece(pieceRef)
+	}
+
+	t.Run("accepts a piece of a posted or complete deal", func(t *testing.T) {
+		assert.NoError(t, requireStoredPiece(newDeal(minerAddr, storagedeal.Posted)))
+		assert.NoError(t, requireStoredPiece(newDeal(minerAddr, storagedeal.Complete)))
+	})
+
+	t.Run("rejects a piece without a deal", func(t *testing.T) {
+		assert.Error(t, requireStoredPiece())
+	})
+
+	t.Run("rejects a piece of a deal that is not posted", func(t *testing.T) {
+		assert.Error(t, requireStoredPiece(newDeal(minerAddr, storagedeal.Staged)))
+	})
+
+	t.Run("rejects a piece of another miner's deal", func(t *testing.T) {
+		assert.Error(t, requireStoredPiece(newDeal(otherMiner, storagedeal.Posted)))
+	})
+}
@@ -6,12 +6,17 @@ import (
 	"testing"
 
 	"github.com/ipfs/go-cid"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/ipfs/go-unixfs"
+	uio "github.com/ipfs/go-unixfs/io"
 	"github.com/libp2p/go-libp2p-peer"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/node"
 	"github.com/filecoin-project/go-filecoin/protocol/retrieval"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -39,6 +44,69 @@ func TestRetrievalProtocolPieceNotFound(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestRetrievalProtocolPath(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	minerNode, clientNode, minerAddr, _ := configureMinerAndClient(t)
+
+	// The miner keeps the DAG of every piece it accepts a deal for, so a
+	// directory added straight to its blockstore, along with a posted deal
+	// for it, stands in for one.
+	dserv := dag.NewDAGService(minerNode.BlockService())
+	file := dag.NodeWithData(unixfs.FilePBData([]byte("hello"), 5))
+	require.NoError(t, dserv.Add(ctx, file))
+
+	sub := uio.NewDirectory(dserv)
+	require.NoError(t, sub.AddChild(ctx, "hello.txt", file))
+	subNode, err := sub.GetNode()
+	require.NoError(t, err)
+	require.NoError(t, dserv.Add(ctx, subNode))
+
+	root := uio.NewDirectory(dserv)
+	require.NoError(t, root.AddChild(ctx, "docs", subNode))
+	rootNode, err := root.GetNode()
+	require.NoError(t, err)
+	require.NoError(t, dserv.Add(ctx, rootNode))
+
+	require.NoError(t, minerNode.PorcelainAPI.DealPut(&storagedeal.Deal{
+		Miner:    minerAddr,
+		Proposal: &storagedeal.Proposal{PieceRef: rootNode.Cid()},
+		Response: &storagedeal.Response{
+			State:       storagedeal.Posted,
+			ProposalCid: types.NewCidForTestGetter()(),
+		},
+	}))
+
+	minerPID, err := minerNode.PorcelainAPI.MinerGetPeerID(ctx, minerAddr)
+	require.NoError(t, err)
+
+	t.Run("retrieves only the file at path", func(t *testing.T) {
+		r, err := clientNode.RetrievalAPI.RetrievePiecePath(ctx, rootNode.Cid(), "docs/hello.txt", minerPID, minerAddr)
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	t.Run("fails if path does not exist", func(t *testing.T) {
+		_, err := clientNode.RetrievalAPI.RetrievePiecePath(ctx, rootNode.Cid(), "docs/nope.txt", minerPID, minerAddr)
+		assert.Error(t, err)
+	})
+
+	t.Run("fails if miner stores no deal for the DAG", func(t *testing.T) {
+		_, err := clientNode.RetrievalAPI.RetrievePiecePath(ctx, subNode.Cid(), "hello.txt", minerPID, minerAddr)
+		assert.Error(t, err)
+	})
+
+	t.Run("fails if miner lacks the DAG", func(t *testing.T) {
+		_, err := clientNode.RetrievalAPI.RetrievePiecePath(ctx, types.NewCidForTestGetter()(), "docs/hello.txt", minerPID, minerAddr)
+		assert.Error(t, err)
+	})
+}
+
 func retrievePieceBytes(ctx context.Context, retrievalAPI *retrieval.API, data cid.Cid, minerPID peer.ID, addr address.Address) ([]byte, error) {
 	r, err := retrievalAPI.RetrievePiece(ctx, data, minerPID, addr)
 	if err != nil {
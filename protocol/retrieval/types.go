@@ -3,12 +3,18 @@ package retrieval
 import (
 	"github.com/ipfs/go-cid"
 	cbor "github.com/ipfs/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 func init() {
 	cbor.RegisterCborType(RetrievePieceRequest{})
 	cbor.RegisterCborType(RetrievePieceResponse{})
 	cbor.RegisterCborType(RetrievePieceChunk{})
+	cbor.RegisterCborType(RetrievePaidPieceRequest{})
+	cbor.RegisterCborType(RetrievePaidPieceResponse{})
+	cbor.RegisterCborType(RetrievePiecePayment{})
 }
 
 // RetrievePieceStatus communicates a successful (or failed) piece retrieval
@@ -28,6 +34,10 @@ const (
 // RetrievePieceRequest represents a retrieval miner's request for content.
 type RetrievePieceRequest struct {
 	PieceRef cid.Cid
+	// Path optionally names a file within the piece's unixfs DAG, e.g.
+	// "photos/cat.jpg". If set only that file is sent rather than the
+	// whole piece.
+	Path string
 }
 
 // RetrievePieceResponse contains the requested content.
@@ -40,3 +50,30 @@ type RetrievePieceResponse struct {
 type RetrievePieceChunk struct {
 	Data []byte
 }
+
+// RetrievePaidPieceRequest represents a retrieval client's request for
+// content it will pay for, chunk by chunk, from a payment channel.
+type RetrievePaidPieceRequest struct {
+	PieceRef cid.Cid
+	// Payer is the address of the account that created the payment channel.
+	Payer address.Address
+	// Channel is the id of the payment channel the client pays from. Its
+	// target must be the owner of the retrieval miner.
+	Channel *types.ChannelID
+}
+
+// RetrievePaidPieceResponse tells the client whether and at what price the
+// miner will send the requested content.
+type RetrievePaidPieceResponse struct {
+	Status       RetrievePieceStatus
+	ErrorMessage string
+	// PricePerChunk is the amount the client must pay for each chunk.
+	PricePerChunk *types.AttoFIL
+	// Size is the number of bytes in the piece.
+	Size uint64
+}
+
+// RetrievePiecePayment pays for all the chunks a client has received so far.
+type RetrievePiecePayment struct {
+	Voucher *types.PaymentVoucher
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/repo"
+)
+
+// CheckSectorMetadata checks the integrity of the sector metadata a storage
+// miner keeps in ds against the sealed sector files in sealedDir, while the
+// miner is not running. It reports metadata that cannot be decoded, sealed
+// sector files that are empty, and sealed sectors whose files are missing.
+//
+// With repair set, metadata that cannot be decoded is moved aside so the
+// miner can start again. The sealed sector files cannot be repaired.
+func CheckSectorMetadata(ds repo.Datastore, sealedDir string, repair bool) ([]*repo.Problem, error) {
+	var problems []*repo.Problem
+
+	key := datastore.KeyWithNamespaces([]string{dealsAwatingSealDatastorePrefix})
+	result, err := ds.Get(key)
+	if err != nil && err != datastore.ErrNotFound {
+		return nil, errors.Wrap(err, "failed to read deals awaiting seal")
+	}
+
+	var record dealsAwaitingSealStruct
+	if err == nil {
+		if err := json.Unmarshal(result, &record); err != nil {
+			problem := &repo.Problem{Description: "the record of deals awaiting seal is truncated"}
+			if repair {
+				aside := key.ChildString("corrupt")
+				if err := ds.Put(aside, result); err != nil {
+					return nil, errors.Wrap(err, "failed to move deals awaiting seal aside")
+				}
+				if err := ds.Delete(key); err != nil {
+					return nil, errors.Wrap(err, "failed to delete deals awaiting seal")
+				}
+				problem.Description += fmt.Sprintf("; moved it to %s, the deals in it will not be updated when their sectors are sealed", aside)
+				problem.Repaired = true
+			}
+			problems = append(problems, problem)
+		}
+	}
+
+	files, err := ioutil.ReadDir(sealedDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "failed to list sealed sectors")
+	}
+
+	sealedFiles := 0
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if file.Mode()&os.ModeSymlink != 0 {
+			// sectors moved to another sealed sector path are linked to
+			path := filepath.Join(sealedDir, file.Name())
+			target, err := os.Stat(path)
+			if err != nil {
+				problems = append(problems, &repo.Problem{
+					Description: fmt.Sprintf("sealed sector file %s links to a missing file: %s", path, err),
+				})
+				continue
+			}
+			file = target
+		}
+		if file.Size() == 0 {
+			problems = append(problems, &repo.Problem{
+				Description: fmt.Sprintf("sealed sector file %s is empty", filepath.Join(sealedDir, file.Name())),
+			})
+			continue
+		}
+		sealedFiles++
+	}
+
+	if len(record.SuccessfulSectors) > sealedFiles {
+		problems = append(problems, &repo.Problem{
+			Description: fmt.Sprintf("%d sectors are recorded as sealed but %s holds %d sealed sector files", len(record.SuccessfulSectors), sealedDir, sealedFiles),
+		})
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
+	"github.com/filecoin-project/go-filecoin/repo"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestCheckSectorMetadata(t *testing.T) {
+	tf.UnitTest(t)
+
+	key := datastore.KeyWithNamespaces([]string{dealsAwatingSealDatastorePrefix})
+
+	sealedDir, err := ioutil.TempDir("", "go-fil-test-sealed")
+	require.NoError(t, err)
+	defer os.RemoveAll(sealedDir) // nolint: errcheck
+
+	putRecord := func(ds repo.Datastore, sectorIDs ...uint64) {
+		record := &dealsAwaitingSealStruct{SuccessfulSectors: map[uint64]*sectorbuilder.SealedSectorMetadata{}}
+		for _, id := range sectorIDs {
+			record.SuccessfulSectors[id] = &sectorbuilder.SealedSectorMetadata{SectorID: id}
+		}
+		bytes, err := json.Marshal(record)
+		require.NoError(t, err)
+		require.NoError(t, ds.Put(key, bytes))
+	}
+
+	t.Run("consistent metadata has no problems", func(t *testing.T) {
+		ds := repo.NewInMemoryRepo().DealsDatastore()
+
+		problems, err := CheckSectorMetadata(ds, sealedDir, false)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(sealedDir, "sector"), []byte("replica"), 0644))
+		putRecord(ds, 1)
+
+		problems, err = CheckSectorMetadata(ds, sealedDir, false)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	t.Run("reports sealed sectors whose files are missing or empty", func(t *testing.T) {
+		ds := repo.NewInMemoryRepo().DealsDatastore()
+		putRecord(ds, 1, 2)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(sealedDir, "empty"), []byte{}, 0644))
+
+		problems, err := CheckSectorMetadata(ds, sealedDir, true)
+		require.NoError(t, err)
+		require.Len(t, problems, 2)
+		assert.Contains(t, problems[0].Description, "is empty")
+		assert.Contains(t, problems[1].Description, "2 sectors are recorded as sealed")
+		assert.False(t, problems[0].Repaired)
+		assert.False(t, problems[1].Repaired)
+	})
+
+	t.Run("moves truncated metadata aside", func(t *testing.T) {
+		ds := repo.NewInMemoryRepo().DealsDatastore()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(sealedDir, "empty"), []byte("replica"), 0644))
+
+		truncated := []byte(`{"SectorsToDeals":{`)
+		require.NoError(t, ds.Put(key, truncated))
+
+		problems, err := CheckSectorMetadata(ds, sealedDir, false)
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.False(t, problems[0].Repaired)
+
+		problems, err = CheckSectorMetadata(ds, sealedDir, true)
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.True(t, problems[0].Repaired)
+
+		has, err := ds.Has(key)
+		require.NoError(t, err)
+		assert.False(t, has)
+
+		aside, err := ds.Get(key.ChildString("corrupt"))
+		require.NoError(t, err)
+		assert.Equal(t, truncated, aside)
+	})
+
+	t.Run("reports links to sealed sectors that are missing", func(t *testing.T) {
+		ds := repo.NewInMemoryRepo().DealsDatastore()
+		require.NoError(t, os.Symlink(filepath.Join(sealedDir, "elsewhere"), filepath.Join(sealedDir, "moved")))
+
+		problems, err := CheckSectorMetadata(ds, sealedDir, false)
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.Contains(t, problems[0].Description, "links to a missing file")
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -16,14 +17,15 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
 	cbu "github.com/filecoin-project/go-filecoin/cborutil"
 	"github.com/filecoin-project/go-filecoin/net"
-	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/util/convert"
+	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
 )
 
 const (
@@ -44,6 +46,10 @@ const (
 	// ChannelExpiryInterval defines how long the channel remains open past the last voucher
 	ChannelExpiryInterval = 2000
 
+	// RedeemInterval defines how many blocks before the payment channel of a deal
+	// paid per epoch expires the miner redeems the latest voucher
+	RedeemInterval = 1000
+
 	// CreateChannelGasPrice is the gas price of the message used to create the payment channel
 	CreateChannelGasPrice = 1
 
@@ -53,17 +59,20 @@ const (
 
 type clientPorcelainAPI interface {
 	ChainBlockHeight() (*types.BlockHeight, error)
-	CreatePayments(ctx context.Context, config porcelain.CreatePaymentsParams) (*porcelain.CreatePaymentsReturn, error)
 	DealGet(cid.Cid) *storagedeal.Deal
 	DAGGetFileSize(context.Context, cid.Cid) (uint64, error)
 	DealPut(*storagedeal.Deal) error
 	DealsLs() ([]*storagedeal.Deal, error)
 	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	MessageSend(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
 	MinerGetAsk(ctx context.Context, minerAddr address.Address, askID uint64) (miner.Ask, error)
 	MinerGetOwnerAddress(ctx context.Context, minerAddr address.Address) (address.Address, error)
 	MinerGetPeerID(ctx context.Context, minerAddr address.Address) (peer.ID, error)
+	PaymentChannelVoucher(ctx context.Context, fromAddr address.Address, channel *types.ChannelID, amount *types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate) (*types.PaymentVoucher, error)
 	types.Signer
 	PingMinerWithTimeout(ctx context.Context, p peer.ID, to time.Duration) error
+	WalletAddresses() []address.Address
 	WalletDefaultAddress() (address.Address, error)
 }
 
@@ -74,6 +83,11 @@ type Client struct {
 	host                host.Host
 	log                 logging.EventLogger
 	ProtocolRequestFunc func(ctx context.Context, protocol protocol.ID, peer peer.ID, host host.Host, request interface{}, response interface{}) error
+
+	// paymentsInProcess is true while vouchers are sent to the miners of the
+	// deals paid per epoch.
+	paymentsLk        sync.Mutex
+	paymentsInProcess bool
 }
 
 // NewClient creates a new storage client.
@@ -167,26 +181,20 @@ func (smc *Client) ProposeDeal(ctx context.Context, miner address.Address, data
 		return nil, ctxSetup.Err()
 	}
 
-	// create payment information
-	cpResp, err := smc.api.CreatePayments(ctxSetup, porcelain.CreatePaymentsParams{
-		From:            fromAddress,
-		To:              minerOwner,
-		Value:           *price.MulBigInt(big.NewInt(int64(size * duration))),
-		Duration:        duration,
-		PaymentInterval: VoucherInterval,
-		ChannelExpiry:   *chainHeight.Add(types.NewBlockHeight(duration + ChannelExpiryInterval)),
-		GasPrice:        *types.NewAttoFIL(big.NewInt(CreateChannelGasPrice)),
-		GasLimit:        types.NewGasUnits(CreateChannelGasLimit),
-	})
+	// The deal is paid per epoch from now on, with vouchers sent to the miner
+	// once it has posted the deal. The channel stays open ChannelExpiryInterval
+	// blocks past the end of the deal so that the miner can redeem the last one.
+	channelExpiry := chainHeight.Add(types.NewBlockHeight(duration + ChannelExpiryInterval))
+	channel, channelMsgCid, err := smc.createChannel(ctxSetup, fromAddress, minerOwner, totalPrice, channelExpiry)
 	if err != nil {
-		return nil, errors.Wrap(err, "error creating payment")
+		return nil, errors.Wrap(err, "error creating payment channel")
 	}
 
-	proposal.Payment.Channel = cpResp.Channel
+	proposal.Payment.Channel = channel
 	proposal.Payment.PayChActor = address.PaymentBrokerAddress
 	proposal.Payment.Payer = fromAddress
-	proposal.Payment.ChannelMsgCid = &cpResp.ChannelMsgCid
-	proposal.Payment.Vouchers = cpResp.Vouchers
+	proposal.Payment.ChannelMsgCid = &channelMsgCid
+	proposal.Payment.PaymentStart = chainHeight
 
 	signedProposal, err := proposal.NewSignedProposal(fromAddress, smc.api)
 	if err != nil {
@@ -216,6 +224,38 @@ func (smc *Client) ProposeDeal(ctx context.Context, miner address.Address, data
 	return &response, nil
 }
 
+// createChannel creates a payment channel from payer to target holding value
+// and expiring at eol.
+func (smc *Client) createChannel(ctx context.Context, payer, target address.Address, value *types.AttoFIL, eol *types.BlockHeight) (*types.ChannelID, cid.Cid, error) {
+	msgCid, err := smc.api.MessageSend(
+		ctx,
+		payer,
+		address.PaymentBrokerAddress,
+		value,
+		*types.NewAttoFIL(big.NewInt(CreateChannelGasPrice)),
+		types.NewGasUnits(CreateChannelGasLimit),
+		"createChannel",
+		target,
+		eol,
+	)
+	if err != nil {
+		return nil, cid.Undef, err
+	}
+
+	var channel *types.ChannelID
+	err = smc.api.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+		if receipt.ExitCode != uint8(0) {
+			return vmErrors.VMExitCodeToError(receipt.ExitCode, paymentbroker.Errors)
+		}
+		channel = types.NewChannelIDFromBytes(receipt.Return[0])
+		return nil
+	})
+	if err != nil {
+		return nil, cid.Undef, err
+	}
+	return channel, msgCid, nil
+}
+
 func (smc *Client) recordResponse(resp *storagedeal.Response, miner address.Address, p *storagedeal.Proposal) error {
 	proposalCid, err := convert.ToCid(p)
 	if err != nil {
@@ -285,6 +325,122 @@ func (smc *Client) QueryDeal(ctx context.Context, proposalCid cid.Cid) (*storage
 	return &resp, nil
 }
 
+// OnNewHeaviestTipSet is a callback called by node, every time the latest
+// head is updated. It pays the miners of this client's deals paid per epoch
+// what they are owed at the head's height.
+func (smc *Client) OnNewHeaviestTipSet(ts types.TipSet) {
+	height, err := ts.Height()
+	if err != nil {
+		smc.log.Errorf("failed to get block height: %s", err)
+		return
+	}
+
+	smc.paymentsLk.Lock()
+	defer smc.paymentsLk.Unlock()
+	if smc.paymentsInProcess {
+		// the deals are still being paid for an earlier head
+		return
+	}
+	smc.paymentsInProcess = true
+
+	go func() {
+		defer func() {
+			smc.paymentsLk.Lock()
+			defer smc.paymentsLk.Unlock()
+			smc.paymentsInProcess = false
+		}()
+		smc.payDeals(context.Background(), types.NewBlockHeight(height))
+	}()
+}
+
+// payDeals sends a voucher to the miner of each deal paid per epoch by this
+// client which is owed more than the latest voucher it got.
+func (smc *Client) payDeals(ctx context.Context, height *types.BlockHeight) {
+	deals, err := smc.api.DealsLs()
+	if err != nil {
+		smc.log.Errorf("failed to list deals: %s", err)
+		return
+	}
+
+	payers := make(map[address.Address]struct{})
+	for _, addr := range smc.api.WalletAddresses() {
+		payers[addr] = struct{}{}
+	}
+
+	for _, d := range deals {
+		if d.Proposal.Payment.PaymentStart == nil || d.Response == nil {
+			continue
+		}
+		// miners store the deals proposed to them alongside
+		if _, ok := payers[d.Proposal.Payment.Payer]; !ok {
+			continue
+		}
+		if err := smc.payDeal(ctx, d, height); err != nil {
+			smc.log.Errorf("failed to pay for deal with proposal CID %s: %s", d.Response.ProposalCid.String(), err)
+		}
+	}
+}
+
+// payDeal sends the miner of d a voucher for what it is owed at height, once
+// the miner has posted the deal, and records it with the deal.
+func (smc *Client) payDeal(ctx context.Context, d *storagedeal.Deal, height *types.BlockHeight) error {
+	p := d.Proposal
+	paid := lastPayment(p)
+	if paid.GreaterEqual(p.TotalPrice) {
+		return nil
+	}
+	due := paymentDue(p, height)
+	if due.LessEqual(paid) {
+		return nil
+	}
+
+	if d.Response.ProofInfo == nil {
+		// the voucher's condition needs the sector the miner sealed the piece into
+		resp, err := smc.QueryDeal(ctx, d.Response.ProposalCid)
+		if err != nil {
+			return err
+		}
+		if resp.State == storagedeal.Unknown || resp.State == d.Response.State {
+			return nil
+		}
+		d.Response = resp
+		if err := smc.api.DealPut(d); err != nil {
+			return errors.Wrap(err, "failed to update deal")
+		}
+		if resp.ProofInfo == nil {
+			return nil
+		}
+	}
+	if d.Response.State != storagedeal.Posted {
+		return nil
+	}
+
+	voucher, err := smc.api.PaymentChannelVoucher(ctx, p.Payment.Payer, p.Payment.Channel, due, height, paymentCondition(d))
+	if err != nil {
+		return errors.Wrap(err, "failed to create voucher")
+	}
+
+	pid, err := smc.api.MinerGetPeerID(ctx, d.Miner)
+	if err != nil {
+		return err
+	}
+
+	req := &storagedeal.PaymentRequest{
+		ProposalCid: d.Response.ProposalCid,
+		Voucher:     voucher,
+	}
+	var resp storagedeal.PaymentResponse
+	if err := smc.ProtocolRequestFunc(ctx, dealPaymentProtocol, pid, smc.host, req, &resp); err != nil {
+		return errors.Wrap(err, "error sending voucher")
+	}
+	if !resp.Accepted {
+		return fmt.Errorf("miner rejected voucher: %s", resp.Message)
+	}
+
+	p.Payment.Vouchers = append(p.Payment.Vouchers, voucher)
+	return smc.api.DealPut(d)
+}
+
 func (smc *Client) isMaybeDupDeal(p *storagedeal.Proposal) bool {
 	deals, err := smc.api.DealsLs()
 	if err != nil {
@@ -15,7 +15,6 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/address"
-	"github.com/filecoin-project/go-filecoin/porcelain"
 	. "github.com/filecoin-project/go-filecoin/protocol/storage"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
@@ -87,18 +86,14 @@ func TestProposeDeal(t *testing.T) {
 		assert.Equal(t, &testAPI.msgCid, proposal.Payment.ChannelMsgCid)
 	})
 
-	t.Run("and creates payment info", func(t *testing.T) {
-		assert.Equal(t, int(duration/VoucherInterval), len(proposal.Payment.Vouchers))
-
-		lastValidAt := types.NewBlockHeight(0)
-		for i, voucher := range proposal.Payment.Vouchers {
-			assert.Equal(t, testAPI.channelID, &voucher.Channel)
-			assert.True(t, voucher.ValidAt.GreaterThan(lastValidAt))
-			assert.Equal(t, testAPI.target, voucher.Target)
-			assert.Equal(t, testAPI.perPayment.MulBigInt(big.NewInt(int64(i+1))), &voucher.Amount)
-			assert.Equal(t, testAPI.payer, voucher.Payer)
-			lastValidAt = &voucher.ValidAt
-		}
+	t.Run("and pays per epoch from now on", func(t *testing.T) {
+		assert.Empty(t, proposal.Payment.Vouchers)
+		assert.Equal(t, testAPI.blockHeight, proposal.Payment.PaymentStart)
+		assert.Equal(t, testAPI.payer, proposal.Payment.Payer)
+
+		expectedEol := testAPI.blockHeight.Add(types.NewBlockHeight(duration + ChannelExpiryInterval))
+		assert.Equal(t, expectedEol, testAPI.channelEol)
+		assert.Equal(t, proposal.TotalPrice, testAPI.channelValue)
 	})
 
 	t.Run("and sends proposal and stores response", func(t *testing.T) {
@@ -113,14 +108,15 @@ func TestProposeDeal(t *testing.T) {
 }
 
 type clientTestAPI struct {
-	blockHeight *types.BlockHeight
-	channelID   *types.ChannelID
-	msgCid      cid.Cid
-	payer       address.Address
-	target      address.Address
-	perPayment  *types.AttoFIL
-	testing     *testing.T
-	deals       map[cid.Cid]*storagedeal.Deal
+	blockHeight  *types.BlockHeight
+	channelID    *types.ChannelID
+	channelEol   *types.BlockHeight
+	channelValue *types.AttoFIL
+	msgCid       cid.Cid
+	payer        address.Address
+	target       address.Address
+	testing      *testing.T
+	deals        map[cid.Cid]*storagedeal.Deal
 }
 
 func newTestClientAPI(t *testing.T) *clientTestAPI {
@@ -133,7 +129,6 @@ func newTestClientAPI(t *testing.T) *clientTestAPI {
 		channelID:   types.NewChannelID(23),
 		payer:       addressGetter(),
 		target:      addressGetter(),
-		perPayment:  types.NewAttoFILFromFIL(10),
 		testing:     t,
 		deals:       make(map[cid.Cid]*storagedeal.Deal),
 	}
@@ -143,25 +138,27 @@ func (ctp *clientTestAPI) ChainBlockHeight() (*types.BlockHeight, error) {
 	return ctp.blockHeight, nil
 }
 
-func (ctp *clientTestAPI) CreatePayments(ctx context.Context, config porcelain.CreatePaymentsParams) (*porcelain.CreatePaymentsReturn, error) {
-	resp := &porcelain.CreatePaymentsReturn{
-		CreatePaymentsParams: config,
-		Channel:              ctp.channelID,
-		ChannelMsgCid:        ctp.msgCid,
-		GasAttoFIL:           types.NewAttoFILFromFIL(100),
-		Vouchers:             make([]*types.PaymentVoucher, 10),
-	}
+func (ctp *clientTestAPI) MessageSend(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	require.Equal(ctp.testing, "createChannel", method)
+	ctp.channelValue = value
+	ctp.channelEol = params[1].(*types.BlockHeight)
+	return ctp.msgCid, nil
+}
 
-	for i := 0; i < 10; i++ {
-		resp.Vouchers[i] = &types.PaymentVoucher{
-			Channel: *ctp.channelID,
-			Payer:   ctp.payer,
-			Target:  ctp.target,
-			Amount:  *ctp.perPayment.MulBigInt(big.NewInt(int64(i + 1))),
-			ValidAt: *ctp.blockHeight.Add(types.NewBlockHeight(uint64(i+1) * VoucherInterval)),
-		}
-	}
-	return resp, nil
+func (ctp *clientTestAPI) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
+	return cb(nil, nil, &types.MessageReceipt{Return: [][]byte{ctp.channelID.Bytes()}})
+}
+
+func (ctp *clientTestAPI) PaymentChannelVoucher(ctx context.Context, fromAddr address.Address, channel *types.ChannelID, amount *types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate) (*types.PaymentVoucher, error) {
+	return &types.PaymentVoucher{
+		Channel:   *channel,
+		Payer:     fromAddr,
+		Target:    ctp.target,
+		Amount:    *amount,
+		ValidAt:   *validAt,
+		Condition: condition,
+		Signature: testSignature,
+	}, nil
 }
 
 func (ctp *clientTestAPI) DAGGetFileSize(context.Context, cid.Cid) (uint64, error) {
@@ -195,6 +192,10 @@ func (ctp *clientTestAPI) SignBytes(data []byte, addr address.Address) (types.Si
 	return testSignature, nil
 }
 
+func (ctp *clientTestAPI) WalletAddresses() []address.Address {
+	return []address.Address{ctp.payer}
+}
+
 func (ctp *clientTestAPI) WalletDefaultAddress() (address.Address, error) {
 	// always just default address
 	return ctp.payer, nil
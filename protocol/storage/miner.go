@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -26,23 +27,31 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
 	cbu "github.com/filecoin-project/go-filecoin/cborutil"
+	"github.com/filecoin-project/go-filecoin/conditions"
 	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/util/convert"
+	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
 )
 
 var log = logging.Logger("/fil/storage")
 
 const makeDealProtocol = protocol.ID("/fil/storage/mk/1.0.0")
 const queryDealProtocol = protocol.ID("/fil/storage/qry/1.0.0")
+const dealPaymentProtocol = protocol.ID("/fil/storage/pay/1.0.0")
 
 // TODO: replace this with a queries to pick reasonable gas price and limits.
 const submitPostGasPrice = 1
 const submitPostGasLimit = 300
+const removeSectorGasPrice = 1
+const removeSectorGasLimit = 300
+const redeemGasPrice = 1
+const redeemGasLimit = 300
 
 const waitForPaymentChannelDuration = 2 * time.Minute
 
@@ -57,9 +66,18 @@ type Miner struct {
 
 	postInProcessLk sync.Mutex
 	postInProcess   *types.BlockHeight
+	// sectorsInRemoval holds the ids of the expired sectors being removed.
+	// It is protected by postInProcessLk, as no PoSt is started while
+	// sectors are being removed.
+	sectorsInRemoval map[uint64]struct{}
 
 	dealsAwaitingSeal *dealsAwaitingSealStruct
 
+	paymentsLk sync.Mutex
+	// redemptionsInProcess holds the proposal cids of the deals paid per
+	// epoch whose latest voucher is being redeemed.
+	redemptionsInProcess map[cid.Cid]struct{}
+
 	porcelainAPI minerPorcelain
 	node         node
 
@@ -72,16 +90,20 @@ type minerPorcelain interface {
 	ActorGetSignature(context.Context, address.Address, string) (*exec.FunctionSignature, error)
 
 	ChainBlockHeight() (*types.BlockHeight, error)
-	ChainSampleRandomness(ctx context.Context, sampleHeight *types.BlockHeight) ([]byte, error)
+	ChainRand(ctx context.Context, epoch *types.BlockHeight) ([]byte, error)
 	ConfigGet(dottedPath string) (interface{}, error)
 
 	DealsLs() ([]*storagedeal.Deal, error)
 	DealGet(cid.Cid) *storagedeal.Deal
 	DealPut(*storagedeal.Deal) error
 
+	MinerGetSectorExpirations(ctx context.Context, minerAddr address.Address) ([]*porcelain.SectorExpiration, error)
+
 	MessageSend(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
 	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
 	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+
+	VoucherPut(voucher *types.PaymentVoucher) error
 }
 
 // node is subset of node on which this protocol depends. These deps
@@ -127,10 +149,31 @@ func NewMiner(minerAddr, minerOwnerAddr address.Address, nd node, dealsDs repo.D
 
 	nd.Host().SetStreamHandler(makeDealProtocol, sm.handleMakeDeal)
 	nd.Host().SetStreamHandler(queryDealProtocol, sm.handleQueryDeal)
+	nd.Host().SetStreamHandler(dealPaymentProtocol, sm.handlePayment)
 
 	return sm, nil
 }
 
+// ResumeDeals restarts the processing of this miner's deals that were
+// accepted but not yet staged when the node last stopped. Deals that were
+// already staged are tracked until their sector is sealed by dealsAwaitingSeal.
+func (sm *Miner) ResumeDeals() error {
+	deals, err := sm.porcelainAPI.DealsLs()
+	if err != nil {
+		return errors.Wrap(err, "failed to list deals")
+	}
+
+	for _, d := range deals {
+		if d.Miner != sm.minerAddr || d.Response == nil || d.Response.State != storagedeal.Accepted {
+			continue
+		}
+		log.Infof("resuming deal with proposal CID %s", d.Response.ProposalCid.String())
+		go sm.processStorageDeal(d.Response.ProposalCid)
+	}
+
+	return nil
+}
+
 func (sm *Miner) handleMakeDeal(s inet.Stream) {
 	defer s.Close() // nolint: errcheck
 
@@ -165,6 +208,15 @@ func (sm *Miner) receiveStorageProposal(ctx context.Context, sp *storagedeal.Sig
 		return sm.proposalRejector(sm, p, fmt.Sprint("invalid deal signature"))
 	}
 
+	policy, err := sm.getDealPolicy()
+	if err != nil {
+		return sm.proposalRejector(sm, p, "failed to get deal policy")
+	}
+
+	if err := sm.checkDealPolicy(policy, p); err != nil {
+		return sm.proposalRejector(sm, p, err.Error())
+	}
+
 	if err := sm.validateDealPayment(ctx, p); err != nil {
 		return sm.proposalRejector(sm, p, err.Error())
 	}
@@ -178,6 +230,11 @@ func (sm *Miner) receiveStorageProposal(ctx context.Context, sp *storagedeal.Sig
 		return sm.proposalRejector(sm, p, fmt.Sprintf("piece is %s bytes but sector size is %d bytes", sp.Size.String(), sectorSize))
 	}
 
+	// the hook decides last, as it may be slow
+	if err := runDealPolicyHook(ctx, policy, p); err != nil {
+		return sm.proposalRejector(sm, p, err.Error())
+	}
+
 	// Payment is valid, everything else checks out, let's accept this proposal
 	return sm.proposalAcceptor(sm, p)
 }
@@ -222,6 +279,19 @@ func (sm *Miner) validateDealPayment(ctx context.Context, p *storagedeal.Proposa
 		return fmt.Errorf("could not get current block height")
 	}
 
+	// deals paid per epoch come without vouchers, see receivePayment
+	if p.Payment.PaymentStart != nil {
+		if p.Payment.PaymentStart.GreaterThan(blockHeight.Add(types.NewBlockHeight(VoucherInterval))) {
+			return errors.New("payments start after deal start interval")
+		}
+
+		expectedEol := paymentEnd(p).Add(types.NewBlockHeight(ChannelExpiryInterval))
+		if channel.Eol.LessThan(expectedEol) {
+			return fmt.Errorf("payment channel eol (%s) less than required eol (%s)", channel.Eol, expectedEol)
+		}
+		return nil
+	}
+
 	// require at least one payment
 	if len(p.Payment.Vouchers) < 1 {
 		return errors.New("deal proposal contains no payment vouchers")
@@ -237,7 +307,7 @@ func (sm *Miner) validateDealPayment(ctx context.Context, p *storagedeal.Proposa
 	lastValidAt := expectedFirstPayment
 	for _, v := range p.Payment.Vouchers {
 		// confirm signature is valid against expected actor and channel id
-		if !paymentbroker.VerifyVoucherSignature(p.Payment.Payer, p.Payment.Channel, &v.Amount, &v.ValidAt, v.Condition, v.Signature) {
+		if !paymentbroker.VerifyVoucherSignature(channel.Scheme, p.Payment.Payer, p.Payment.Channel, &v.Amount, &v.ValidAt, v.Condition, v.Signature) {
 			return errors.New("invalid signature in voucher")
 		}
 
@@ -303,6 +373,11 @@ func (sm *Miner) getPaymentChannel(ctx context.Context, p *storagedeal.Proposal)
 		return nil, err
 	}
 
+	return sm.lsPaymentChannel(ctx, p)
+}
+
+// lsPaymentChannel returns the current state of the payment channel of a deal.
+func (sm *Miner) lsPaymentChannel(ctx context.Context, p *storagedeal.Proposal) (*paymentbroker.PaymentChannel, error) {
 	payer := p.Payment.Payer
 
 	ret, err := sm.porcelainAPI.MessageQuery(ctx, address.Undef, address.PaymentBrokerAddress, "ls", payer)
@@ -383,7 +458,12 @@ func (sm *Miner) updateDealResponse(proposalCid cid.Cid, f func(*storagedeal.Res
 	if storageDeal == nil {
 		return fmt.Errorf("failed to get retrive deal with proposal CID %s", proposalCid.String())
 	}
-	f(storageDeal.Response)
+	resp := *storageDeal.Response
+	f(&resp)
+	if !storageDeal.Response.State.CanTransitionTo(resp.State) {
+		return fmt.Errorf("deal with proposal CID %s cannot move from state %s to %s", proposalCid.String(), storageDeal.Response.State, resp.State)
+	}
+	storageDeal.Response = &resp
 	err := sm.porcelainAPI.DealPut(storageDeal)
 	if err != nil {
 		return errors.Wrap(err, "failed to store updated deal response in datastore")
@@ -403,7 +483,7 @@ func (sm *Miner) processStorageDeal(proposalCid cid.Cid) {
 		log.Errorf("could not retrieve deal with proposal CID %s", proposalCid.String())
 	}
 	if d.Response.State != storagedeal.Accepted {
-		// TODO: handle resumption of deal processing across miner restarts
+		// Deals past Accepted are resumed through dealsAwaitingSeal.
 		log.Error("attempted to process an already started deal")
 		return
 	}
@@ -669,7 +749,8 @@ func (sm *Miner) currentProvingPeriodPoStChallengeSeed(ctx context.Context) (typ
 		return types.PoStChallengeSeed{}, errors.Wrap(err, "error obtaining current proving period")
 	}
 
-	bytes, err := sm.porcelainAPI.ChainSampleRandomness(ctx, currentProvingPeriodStart)
+	// must match the seed the miner actor checks the PoSt against
+	bytes, err := sm.porcelainAPI.ChainRand(ctx, currentProvingPeriodStart)
 	if err != nil {
 		return types.PoStChallengeSeed{}, errors.Wrap(err, "error sampling chain for randomness")
 	}
@@ -741,11 +822,22 @@ func (sm *Miner) getActorSectorCommitments(ctx context.Context) (map[string]type
 }
 
 // OnNewHeaviestTipSet is a callback called by node, every time the the latest
-// head is updated. It is used to check if we are in a new proving period and
-// need to trigger PoSt submission.
+// head is updated. It is used to redeem the payments of deals paid per epoch
+// and to check if we are in a new proving period and need to trigger PoSt
+// submission.
 func (sm *Miner) OnNewHeaviestTipSet(ts types.TipSet) {
 	ctx := context.Background()
 
+	height, err := ts.Height()
+	if err != nil {
+		log.Errorf("failed to get block height: %s", err)
+		return
+	}
+
+	h := types.NewBlockHeight(height)
+
+	sm.redeemPayments(h)
+
 	isBootstrapMinerActor, err := sm.isBootstrapMinerActor(ctx)
 	if err != nil {
 		log.Errorf("could not determine if actor created for bootstrapping: %s", err)
@@ -798,13 +890,12 @@ func (sm *Miner) OnNewHeaviestTipSet(ts types.TipSet) {
 		return
 	}
 
-	height, err := ts.Height()
-	if err != nil {
-		log.Errorf("failed to get block height: %s", err)
+	// a PoSt must prove exactly the sectors committed when it lands, so
+	// sectors are not removed while a PoSt is generated and vice versa
+	if sm.removeExpiredSectors(ctx, h) {
 		return
 	}
 
-	h := types.NewBlockHeight(height)
 	provingPeriodHeight := types.NewBlockHeight(miner.ProvingPeriodBlocks)
 	provingPeriodEnd := provingPeriodStart.Add(provingPeriodHeight)
 
@@ -828,6 +919,93 @@ func (sm *Miner) OnNewHeaviestTipSet(ts types.TipSet) {
 	}
 }
 
+// removeExpiredSectors starts removing the sectors in which all deals ended
+// by the given height, if the miner is configured to do so rather than keep
+// proving them, and reports whether any sectors are being removed. It must be
+// called with postInProcessLk held.
+func (sm *Miner) removeExpiredSectors(ctx context.Context, height *types.BlockHeight) bool {
+	if len(sm.sectorsInRemoval) > 0 {
+		return true
+	}
+
+	enabled, err := sm.porcelainAPI.ConfigGet("mining.removeExpiredSectors")
+	if err != nil {
+		log.Errorf("failed to get mining.removeExpiredSectors: %s", err)
+		return false
+	}
+	if remove, ok := enabled.(bool); !ok || !remove {
+		return false
+	}
+
+	expirations, err := sm.porcelainAPI.MinerGetSectorExpirations(ctx, sm.minerAddr)
+	if err != nil {
+		log.Errorf("failed to get sector expirations: %s", err)
+		return false
+	}
+
+	for _, expiration := range expirations {
+		if expiration.Expiry.GreaterThan(height) {
+			// expirations are ordered by expiry
+			break
+		}
+		if sm.sectorsInRemoval == nil {
+			sm.sectorsInRemoval = make(map[uint64]struct{})
+		}
+		sm.sectorsInRemoval[expiration.SectorID] = struct{}{}
+		go sm.removeSector(expiration.SectorID)
+	}
+
+	return len(sm.sectorsInRemoval) > 0
+}
+
+// removeSector removes an expired sector from the miner's sectors on chain,
+// which reduces the miner's power, and then deletes its replica.
+func (sm *Miner) removeSector(sectorID uint64) {
+	defer func() {
+		sm.postInProcessLk.Lock()
+		defer sm.postInProcessLk.Unlock()
+		delete(sm.sectorsInRemoval, sectorID)
+	}()
+
+	// TODO: figure out a more sensible timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	workerAddr, err := sm.getWorker(ctx)
+	if err != nil {
+		log.Errorf("failed to remove sector %d, as the miner worker can not be determined: %s", sectorID, err)
+		return
+	}
+
+	// TODO: algorithmically determine appropriate values for these
+	gasPrice := types.NewGasPrice(removeSectorGasPrice)
+	gasLimit := types.NewGasUnits(removeSectorGasLimit)
+
+	msgCid, err := sm.porcelainAPI.MessageSend(ctx, workerAddr, sm.minerAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "removeSector", sectorID)
+	if err != nil {
+		log.Errorf("failed to remove sector %d: %s", sectorID, err)
+		return
+	}
+
+	err = sm.porcelainAPI.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+		if receipt.ExitCode != uint8(0) {
+			return vmErrors.VMExitCodeToError(receipt.ExitCode, miner.Errors)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("removeSector message for sector %d failed: %s", sectorID, err)
+		return
+	}
+
+	if err := sm.node.SectorBuilder().DeleteSealedSector(sectorID); err != nil {
+		log.Errorf("removed sector %d but failed to delete its replica: %s", sectorID, err)
+		return
+	}
+
+	log.Infof("removed expired sector %d", sectorID)
+}
+
 func (sm *Miner) getProvingPeriodStart() (*types.BlockHeight, error) {
 	res, err := sm.porcelainAPI.MessageQuery(
 		context.Background(),
@@ -842,6 +1020,21 @@ func (sm *Miner) getProvingPeriodStart() (*types.BlockHeight, error) {
 	return types.NewBlockHeightFromBytes(res[0]), nil
 }
 
+// getWorker returns the address whose key currently signs for the miner.
+func (sm *Miner) getWorker(ctx context.Context) (address.Address, error) {
+	res, err := sm.porcelainAPI.MessageQuery(
+		ctx,
+		address.Undef,
+		sm.minerAddr,
+		"getWorker",
+	)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	return address.NewFromBytes(res[0])
+}
+
 // generatePoSt creates the required PoSt, given a list of sector ids and
 // matching seeds. It returns the Snark Proof for the PoSt, and a list of
 // sectors that faulted, if there were any faults.
@@ -902,7 +1095,14 @@ func (sm *Miner) submitPoSt(start, end *types.BlockHeight, seed types.PoStChalle
 	gasPrice := types.NewGasPrice(submitPostGasPrice)
 	gasLimit := types.NewGasUnits(submitPostGasLimit)
 
-	_, err = sm.porcelainAPI.MessageSend(ctx, sm.minerOwnerAddr, sm.minerAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "submitPoSt", proofs)
+	// PoSts are submitted by the worker so that the owner key can stay offline.
+	workerAddr, err := sm.getWorker(ctx)
+	if err != nil {
+		log.Errorf("failed to submit PoSt, as the miner worker can not be determined: %s", err)
+		return
+	}
+
+	_, err = sm.porcelainAPI.MessageSend(ctx, workerAddr, sm.minerAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "submitPoSt", proofs)
 	if err != nil {
 		log.Errorf("failed to submit PoSt: %s", err)
 		return
@@ -940,6 +1140,225 @@ func (sm *Miner) handleQueryDeal(s inet.Stream) {
 	}
 }
 
+func (sm *Miner) handlePayment(s inet.Stream) {
+	defer s.Close() // nolint: errcheck
+
+	var req storagedeal.PaymentRequest
+	if err := cbu.NewMsgReader(s).ReadMsg(&req); err != nil {
+		log.Errorf("received invalid payment: %s", err)
+		return
+	}
+
+	resp := &storagedeal.PaymentResponse{Accepted: true}
+	if err := sm.receivePayment(context.Background(), &req); err != nil {
+		log.Warningf("rejected payment for deal with proposal CID %s: %s", req.ProposalCid.String(), err)
+		resp = &storagedeal.PaymentResponse{Message: err.Error()}
+	}
+
+	if err := cbu.NewMsgWriter(s).WriteMsg(resp); err != nil {
+		log.Errorf("failed to write payment response: %s", err)
+	}
+}
+
+// receivePayment validates the voucher a client sends to pay for a posted
+// deal paid per epoch and stores it with the deal. The voucher must pay more
+// than the previous one, at most a voucher interval late, and be conditioned
+// on the storage of the deal's piece.
+func (sm *Miner) receivePayment(ctx context.Context, req *storagedeal.PaymentRequest) error {
+	sm.paymentsLk.Lock()
+	defer sm.paymentsLk.Unlock()
+
+	d := sm.porcelainAPI.DealGet(req.ProposalCid)
+	if d == nil || d.Miner != sm.minerAddr {
+		return fmt.Errorf("no deal with proposal CID %s", req.ProposalCid.String())
+	}
+	p := d.Proposal
+	if p.Payment.PaymentStart == nil {
+		return errors.New("deal is not paid per epoch")
+	}
+	if d.Response.State != storagedeal.Posted || d.Response.ProofInfo == nil {
+		return fmt.Errorf("deal is %s, not posted", d.Response.State)
+	}
+	if _, ok := sm.redemptionsInProcess[req.ProposalCid]; ok {
+		return errors.New("deal payments are being redeemed")
+	}
+
+	v := req.Voucher
+	if v == nil {
+		return errors.New("payment contains no voucher")
+	}
+	if v.Payer != p.Payment.Payer || !v.Channel.Equal(p.Payment.Channel) {
+		return errors.New("voucher is not for the deal's payment channel")
+	}
+	if v.Target != sm.minerOwnerAddr {
+		return fmt.Errorf("voucher target (%s) is not miner owner (%s)", v.Target, sm.minerOwnerAddr)
+	}
+	channel, err := sm.lsPaymentChannel(ctx, p)
+	if err != nil {
+		return err
+	}
+	if !paymentbroker.VerifyVoucherSignature(channel.Scheme, v.Payer, &v.Channel, &v.Amount, &v.ValidAt, v.Condition, v.Signature) {
+		return errors.New("invalid signature in voucher")
+	}
+	sameCondition, err := samePredicate(v.Condition, paymentCondition(d))
+	if err != nil {
+		return err
+	}
+	if !sameCondition {
+		return errors.New("voucher is not conditioned on the storage of the deal's piece")
+	}
+
+	if paid := lastPayment(p); v.Amount.LessEqual(paid) {
+		return fmt.Errorf("voucher amount (%s) does not exceed last payment (%s)", v.Amount.String(), paid.String())
+	}
+
+	blockHeight, err := sm.porcelainAPI.ChainBlockHeight()
+	if err != nil {
+		return errors.Wrap(err, "could not get current block height")
+	}
+	interval := types.NewBlockHeight(VoucherInterval)
+	if blockHeight.GreaterThan(interval) {
+		if due := paymentDue(p, blockHeight.Sub(interval)); v.Amount.LessThan(due) {
+			return fmt.Errorf("voucher amount (%s) less than amount due (%s)", v.Amount.String(), due.String())
+		}
+	}
+
+	if err := sm.porcelainAPI.VoucherPut(v); err != nil {
+		return errors.Wrap(err, "failed to store voucher")
+	}
+	p.Payment.Vouchers = append(p.Payment.Vouchers, v)
+	if err := sm.porcelainAPI.DealPut(d); err != nil {
+		return errors.Wrap(err, "failed to store deal")
+	}
+	return nil
+}
+
+// redeemPayments starts redeeming the latest voucher of each posted deal paid
+// per epoch that is either paid in full or whose payment channel expires in
+// RedeemInterval blocks.
+func (sm *Miner) redeemPayments(height *types.BlockHeight) {
+	deals, err := sm.porcelainAPI.DealsLs()
+	if err != nil {
+		log.Errorf("failed to list deals: %s", err)
+		return
+	}
+
+	sm.paymentsLk.Lock()
+	defer sm.paymentsLk.Unlock()
+
+	for _, d := range deals {
+		if d.Miner != sm.minerAddr || d.Response == nil || d.Response.State != storagedeal.Posted {
+			continue
+		}
+		p := d.Proposal
+		if p.Payment.PaymentStart == nil || len(p.Payment.Vouchers) == 0 {
+			continue
+		}
+
+		redeemAt := paymentEnd(p).Add(types.NewBlockHeight(ChannelExpiryInterval - RedeemInterval))
+		if lastPayment(p).LessThan(p.TotalPrice) && height.LessThan(redeemAt) {
+			continue
+		}
+
+		if _, ok := sm.redemptionsInProcess[d.Response.ProposalCid]; ok {
+			continue
+		}
+		if sm.redemptionsInProcess == nil {
+			sm.redemptionsInProcess = make(map[cid.Cid]struct{})
+		}
+		sm.redemptionsInProcess[d.Response.ProposalCid] = struct{}{}
+		go sm.redeemPayment(d.Response.ProposalCid)
+	}
+}
+
+// redeemPayment redeems the latest voucher of a deal paid per epoch, unless
+// its amount was already redeemed, and completes the deal.
+func (sm *Miner) redeemPayment(proposalCid cid.Cid) {
+	defer func() {
+		sm.paymentsLk.Lock()
+		defer sm.paymentsLk.Unlock()
+		delete(sm.redemptionsInProcess, proposalCid)
+	}()
+
+	// TODO: figure out a more sensible timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	d := sm.porcelainAPI.DealGet(proposalCid)
+	if d == nil {
+		log.Errorf("could not retrieve deal with proposal CID %s", proposalCid.String())
+		return
+	}
+	voucher := d.Proposal.Payment.Vouchers[len(d.Proposal.Payment.Vouchers)-1]
+
+	channel, err := sm.lsPaymentChannel(ctx, d.Proposal)
+	if err != nil {
+		log.Errorf("failed to get payment channel of deal with proposal CID %s: %s", proposalCid.String(), err)
+		return
+	}
+
+	if channel.AmountRedeemed.LessThan(&voucher.Amount) {
+		// TODO: algorithmically determine appropriate values for these
+		gasPrice := types.NewGasPrice(redeemGasPrice)
+		gasLimit := types.NewGasUnits(redeemGasLimit)
+
+		msgCid, err := sm.porcelainAPI.MessageSend(
+			ctx,
+			sm.minerOwnerAddr,
+			address.PaymentBrokerAddress,
+			types.ZeroAttoFIL,
+			gasPrice,
+			gasLimit,
+			"redeem",
+			voucher.Payer,
+			&voucher.Channel,
+			&voucher.Amount,
+			&voucher.ValidAt,
+			voucher.Condition,
+			[]byte(voucher.Signature),
+			conditions.PieceStorageParams(d.Response.ProofInfo.PieceInclusionProof),
+		)
+		if err != nil {
+			log.Errorf("failed to redeem voucher of deal with proposal CID %s: %s", proposalCid.String(), err)
+			return
+		}
+
+		err = sm.porcelainAPI.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+			if receipt.ExitCode != uint8(0) {
+				return vmErrors.VMExitCodeToError(receipt.ExitCode, paymentbroker.Errors)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Errorf("redeem message for deal with proposal CID %s failed: %s", proposalCid.String(), err)
+			return
+		}
+	}
+
+	err = sm.updateDealResponse(proposalCid, func(resp *storagedeal.Response) {
+		resp.State = storagedeal.Complete
+	})
+	if err != nil {
+		log.Errorf("redeemed payment but could not update deal to 'Complete' state: %s", err)
+		return
+	}
+
+	log.Infof("redeemed %s for deal with proposal CID %s", voucher.Amount.String(), proposalCid.String())
+}
+
+// samePredicate reports whether the predicates a and b encode the same call.
+func samePredicate(a, b *types.Predicate) (bool, error) {
+	encodedA, err := cbor.DumpObject(a)
+	if err != nil {
+		return false, err
+	}
+	encodedB, err := cbor.DumpObject(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(encodedA, encodedB), nil
+}
+
 func (sm *Miner) getSectorSize(ctx context.Context) (uint64, error) {
 	var proofsMode types.ProofsMode
 	values, err := sm.porcelainAPI.MessageQuery(ctx, address.Address{}, address.StorageMarketAddress, "getProofsMode")
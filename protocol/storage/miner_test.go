@@ -16,6 +16,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
+	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 	"github.com/filecoin-project/go-filecoin/repo"
@@ -137,6 +138,17 @@ func TestReceiveStorageProposal(t *testing.T) {
 		assert.Contains(t, res.Message, "invalid signature in voucher")
 	})
 
+	t.Run("Rejects proposals with vouchers not signed with the channel's scheme", func(t *testing.T) {
+		porcelainAPI, miner, proposal := defaultMinerTestSetup(t, VoucherInterval, defaultAmountInc)
+		porcelainAPI.channelScheme = paymentbroker.SchemeBLS
+
+		res, err := miner.receiveStorageProposal(context.Background(), proposal)
+		require.NoError(t, err)
+
+		assert.Equal(t, storagedeal.Rejected, res.State)
+		assert.Contains(t, res.Message, "invalid signature in voucher")
+	})
+
 	t.Run("Rejects proposals with when payments start too late", func(t *testing.T) {
 		porcelainAPI := newMinerTestPorcelain(t)
 		porcelainAPI.paymentStart = porcelainAPI.paymentStart.Add(types.NewBlockHeight(15))
@@ -176,6 +188,28 @@ func TestReceiveStorageProposal(t *testing.T) {
 		assert.Contains(t, res.Message, "voucher amount")
 	})
 
+	t.Run("Accepts proposals paid per epoch without vouchers", func(t *testing.T) {
+		porcelainAPI, miner, _ := defaultMinerTestSetup(t, VoucherInterval, defaultAmountInc)
+		proposal := testPerEpochDealProposal(porcelainAPI)
+
+		res, err := miner.receiveStorageProposal(context.Background(), proposal)
+		require.NoError(t, err)
+
+		assert.Equal(t, storagedeal.Accepted, res.State)
+	})
+
+	t.Run("Rejects proposals paid per epoch with too short channel eol", func(t *testing.T) {
+		porcelainAPI, miner, _ := defaultMinerTestSetup(t, VoucherInterval, defaultAmountInc)
+		porcelainAPI.channelEol = types.NewBlockHeight(12000)
+		proposal := testPerEpochDealProposal(porcelainAPI)
+
+		res, err := miner.receiveStorageProposal(context.Background(), proposal)
+		require.NoError(t, err)
+
+		assert.Equal(t, storagedeal.Rejected, res.State)
+		assert.Contains(t, res.Message, "less than required eol")
+	})
+
 	t.Run("Rejects proposals with invalid signature", func(t *testing.T) {
 		_, miner, proposal := defaultMinerTestSetup(t, VoucherInterval, defaultAmountInc)
 		proposal.Signature = []byte{'0', '0', '0'}
@@ -211,6 +245,101 @@ func TestReceiveStorageProposal(t *testing.T) {
 	})
 }
 
+func TestReceivePayment(t *testing.T) {
+	tf.UnitTest(t)
+
+	setup := func(t *testing.T) (*minerTestPorcelain, *Miner, *storagedeal.Deal) {
+		porcelainAPI := newMinerTestPorcelain(t)
+		miner := newTestMiner(porcelainAPI)
+		deal := &storagedeal.Deal{
+			Miner:    miner.minerAddr,
+			Proposal: &testPerEpochDealProposal(porcelainAPI).Proposal,
+			Response: &storagedeal.Response{
+				State:       storagedeal.Posted,
+				ProposalCid: types.SomeCid(),
+				ProofInfo:   &storagedeal.ProofInfo{SectorID: 3},
+			},
+		}
+		require.NoError(t, porcelainAPI.DealPut(deal))
+		return porcelainAPI, miner, deal
+	}
+
+	pay := func(miner *Miner, deal *storagedeal.Deal, voucher *types.PaymentVoucher) error {
+		return miner.receivePayment(context.Background(), &storagedeal.PaymentRequest{
+			ProposalCid: deal.Response.ProposalCid,
+			Voucher:     voucher,
+		})
+	}
+
+	t.Run("Accepts and stores increasing vouchers", func(t *testing.T) {
+		porcelainAPI, miner, deal := setup(t)
+
+		first := testDealVoucher(porcelainAPI, types.NewAttoFILFromFIL(250), paymentCondition(deal))
+		require.NoError(t, pay(miner, deal, first))
+		second := testDealVoucher(porcelainAPI, types.NewAttoFILFromFIL(500), paymentCondition(deal))
+		require.NoError(t, pay(miner, deal, second))
+
+		assert.Equal(t, []*types.PaymentVoucher{first, second}, porcelainAPI.vouchers)
+		assert.Equal(t, []*types.PaymentVoucher{first, second}, porcelainAPI.DealGet(deal.Response.ProposalCid).Proposal.Payment.Vouchers)
+	})
+
+	t.Run("Rejects vouchers not exceeding the last payment", func(t *testing.T) {
+		porcelainAPI, miner, deal := setup(t)
+
+		voucher := testDealVoucher(porcelainAPI, types.NewAttoFILFromFIL(250), paymentCondition(deal))
+		require.NoError(t, pay(miner, deal, voucher))
+
+		err := pay(miner, deal, voucher)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not exceed last payment")
+	})
+
+	t.Run("Rejects vouchers with another condition", func(t *testing.T) {
+		porcelainAPI, miner, deal := setup(t)
+
+		err := pay(miner, deal, testDealVoucher(porcelainAPI, types.NewAttoFILFromFIL(250), nil))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not conditioned on the storage of the deal's piece")
+	})
+
+	t.Run("Rejects vouchers paying less than due", func(t *testing.T) {
+		porcelainAPI, miner, deal := setup(t)
+		porcelainAPI.blockHeight = porcelainAPI.paymentStart.Add(types.NewBlockHeight(5 * VoucherInterval))
+
+		err := pay(miner, deal, testDealVoucher(porcelainAPI, types.NewAttoFILFromFIL(500), paymentCondition(deal)))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "less than amount due")
+	})
+
+	t.Run("Rejects vouchers not signed with the channel's scheme", func(t *testing.T) {
+		porcelainAPI, miner, deal := setup(t)
+		porcelainAPI.channelScheme = paymentbroker.SchemeBLS
+
+		err := pay(miner, deal, testDealVoucher(porcelainAPI, types.NewAttoFILFromFIL(250), paymentCondition(deal)))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid signature in voucher")
+	})
+
+	t.Run("Rejects vouchers for deals not yet posted", func(t *testing.T) {
+		porcelainAPI, miner, deal := setup(t)
+		deal.Response.State = storagedeal.Staged
+
+		err := pay(miner, deal, testDealVoucher(porcelainAPI, types.NewAttoFILFromFIL(250), paymentCondition(deal)))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not posted")
+	})
+
+	t.Run("Redeems the last voucher and completes the deal", func(t *testing.T) {
+		porcelainAPI, miner, deal := setup(t)
+
+		require.NoError(t, pay(miner, deal, testDealVoucher(porcelainAPI, deal.Proposal.TotalPrice, paymentCondition(deal))))
+		miner.redeemPayment(deal.Response.ProposalCid)
+
+		assert.Equal(t, []string{"redeem"}, porcelainAPI.sentMethods)
+		assert.Equal(t, storagedeal.Complete, porcelainAPI.DealGet(deal.Response.ProposalCid).Response.State)
+	})
+}
+
 func TestDealsAwaitingSeal(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -392,6 +521,25 @@ func TestOnCommitmentAddedToChain(t *testing.T) {
 	})
 }
 
+func TestUpdateDealResponseEnforcesTransitions(t *testing.T) {
+	tf.UnitTest(t)
+
+	proposalCid := types.NewCidForTestGetter()()
+	_, miner, proposal := minerWithAcceptedDealTestSetup(t, proposalCid, 777)
+
+	err := miner.updateDealResponse(proposalCid, func(resp *storagedeal.Response) {
+		resp.State = storagedeal.Accepted
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot move from state staged to accepted")
+	assert.Equal(t, storagedeal.Staged, miner.Query(proposal.Proposal.PieceRef).State)
+
+	require.NoError(t, miner.updateDealResponse(proposalCid, func(resp *storagedeal.Response) {
+		resp.State = storagedeal.Failed
+	}))
+	assert.Equal(t, storagedeal.Failed, miner.Query(proposal.Proposal.PieceRef).State)
+}
+
 type minerTestPorcelain struct {
 	config        *cfg.Config
 	payerAddress  address.Address
@@ -402,13 +550,16 @@ type minerTestPorcelain struct {
 	noChannels    bool
 	blockHeight   *types.BlockHeight
 	channelEol    *types.BlockHeight
+	channelScheme paymentbroker.SignatureScheme
 	paymentStart  *types.BlockHeight
 	deals         map[cid.Cid]*storagedeal.Deal
+	vouchers      []*types.PaymentVoucher
+	sentMethods   []string
 
 	testing *testing.T
 }
 
-func (mtp *minerTestPorcelain) ChainSampleRandomness(ctx context.Context, sampleHeight *types.BlockHeight) ([]byte, error) {
+func (mtp *minerTestPorcelain) ChainRand(ctx context.Context, epoch *types.BlockHeight) ([]byte, error) {
 	bytes := make([]byte, 42)
 	if _, err := rand.Read(bytes); err != nil {
 		panic(err)
@@ -452,6 +603,7 @@ func (mtp *minerTestPorcelain) ActorGetSignature(ctx context.Context, actorAddr
 }
 
 func (mtp *minerTestPorcelain) MessageSend(ctx context.Context, from, to address.Address, val *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	mtp.sentMethods = append(mtp.sentMethods, method)
 	return cid.Cid{}, nil
 }
 
@@ -459,6 +611,9 @@ func (mtp *minerTestPorcelain) MessageQuery(ctx context.Context, optFrom, to add
 	if method == "getProofsMode" {
 		return messageQueryGetProofsMode()
 	}
+	if method == "getWorker" {
+		return [][]byte{mtp.targetAddress.Bytes()}, nil
+	}
 	return mtp.messageQueryPaymentBrokerLs()
 }
 
@@ -477,6 +632,7 @@ func (mtp *minerTestPorcelain) messageQueryPaymentBrokerLs() ([][]byte, error) {
 			AmountRedeemed: types.NewAttoFILFromFIL(0),
 			AgreedEol:      mtp.channelEol,
 			Eol:            mtp.channelEol,
+			Scheme:         mtp.channelScheme,
 		}
 	}
 
@@ -493,10 +649,19 @@ func (mtp *minerTestPorcelain) ChainBlockHeight() (*types.BlockHeight, error) {
 	return mtp.blockHeight, nil
 }
 
+func (mtp *minerTestPorcelain) MinerGetSectorExpirations(ctx context.Context, minerAddr address.Address) ([]*porcelain.SectorExpiration, error) {
+	return nil, nil
+}
+
 func (mtp *minerTestPorcelain) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
 	return nil
 }
 
+func (mtp *minerTestPorcelain) VoucherPut(voucher *types.PaymentVoucher) error {
+	mtp.vouchers = append(mtp.vouchers, voucher)
+	return nil
+}
+
 func newTestMiner(api *minerTestPorcelain) *Miner {
 	return &Miner{
 		porcelainAPI:   api,
@@ -532,7 +697,7 @@ func minerWithAcceptedDealTestSetup(t *testing.T, proposalCid cid.Cid, sectorID
 
 	// create the response and the deal
 	resp := &storagedeal.Response{
-		State:       storagedeal.Accepted,
+		State:       storagedeal.Staged,
 		ProposalCid: proposalCid,
 		Signature:   proposal.Signature,
 	}
@@ -603,6 +768,34 @@ func testSignedDealProposal(porcelainAPI *minerTestPorcelain, vouchers []*types.
 	return signedProposal
 }
 
+// testPerEpochDealProposal returns a signed proposal for a deal paid per epoch
+// from the porcelain's payment start.
+func testPerEpochDealProposal(porcelainAPI *minerTestPorcelain) *storagedeal.SignedDealProposal {
+	proposal := testSignedDealProposal(porcelainAPI, nil, defaultPieceSize).Proposal
+	proposal.Payment.PaymentStart = porcelainAPI.paymentStart
+
+	signedProposal, err := proposal.NewSignedProposal(porcelainAPI.payerAddress, porcelainAPI.signer)
+	require.NoError(porcelainAPI.testing, err)
+	return signedProposal
+}
+
+// testDealVoucher returns a voucher for amount from the porcelain's payment
+// channel, valid at the current block height.
+func testDealVoucher(porcelainAPI *minerTestPorcelain, amount *types.AttoFIL, condition *types.Predicate) *types.PaymentVoucher {
+	signature, err := paymentbroker.SignVoucher(porcelainAPI.channelID, amount, porcelainAPI.blockHeight, porcelainAPI.payerAddress, condition, porcelainAPI.signer)
+	require.NoError(porcelainAPI.testing, err)
+
+	return &types.PaymentVoucher{
+		Channel:   *porcelainAPI.channelID,
+		Payer:     porcelainAPI.payerAddress,
+		Target:    porcelainAPI.targetAddress,
+		Amount:    *amount,
+		ValidAt:   *porcelainAPI.blockHeight,
+		Condition: condition,
+		Signature: signature,
+	}
+}
+
 func (mtp *minerTestPorcelain) DealsLs() ([]*storagedeal.Deal, error) {
 	var results []*storagedeal.Deal
 
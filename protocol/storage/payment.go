@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"math/big"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/conditions"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// pieceCommP returns the commitment of the piece pieceRef.
+// TODO: the fake piece inclusion proofs use the piece cid as CommP, compute
+// the real one when proofs are available
+// see https://github.com/filecoin-project/go-filecoin/issues/2629
+func pieceCommP(pieceRef cid.Cid) types.CommP {
+	var commP types.CommP
+	copy(commP[:], pieceRef.Bytes())
+	return commP
+}
+
+// paymentCondition returns the condition the vouchers paying for a posted
+// deal per epoch are conditioned on: the miner must still store the deal's
+// piece in the sector it was sealed into.
+func paymentCondition(d *storagedeal.Deal) *types.Predicate {
+	return conditions.PieceStorage(d.Miner, pieceCommP(d.Proposal.PieceRef), d.Response.ProofInfo.SectorID)
+}
+
+// paymentDue returns the amount the client of a deal paid per epoch owes at
+// height: the share of the total price for the whole VoucherIntervals since
+// payments started, or the total price once the deal has run its duration.
+func paymentDue(p *storagedeal.Proposal, height *types.BlockHeight) *types.AttoFIL {
+	start := p.Payment.PaymentStart
+	if height.LessEqual(start) {
+		return types.ZeroAttoFIL
+	}
+
+	elapsed := height.Sub(start).AsBigInt().Uint64()
+	if elapsed >= p.Duration {
+		return p.TotalPrice
+	}
+	elapsed -= elapsed % VoucherInterval
+	return p.TotalPrice.MulBigInt(big.NewInt(int64(elapsed))).DivBigInt(big.NewInt(int64(p.Duration)))
+}
+
+// paymentEnd returns the height at which a deal paid per epoch is paid in
+// full. Its payment channel must stay open ChannelExpiryInterval blocks past
+// it.
+func paymentEnd(p *storagedeal.Proposal) *types.BlockHeight {
+	return p.Payment.PaymentStart.Add(types.NewBlockHeight(p.Duration))
+}
+
+// lastPayment returns the amount of the latest voucher of a deal, or zero if
+// it has none.
+func lastPayment(p *storagedeal.Proposal) *types.AttoFIL {
+	if len(p.Payment.Vouchers) == 0 {
+		return types.ZeroAttoFIL
+	}
+	return &p.Payment.Vouchers[len(p.Payment.Vouchers)-1].Amount
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-host"
+	"github.com/libp2p/go-libp2p-peer"
+	"github.com/libp2p/go-libp2p-protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/conditions"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestPaymentDue(t *testing.T) {
+	tf.UnitTest(t)
+
+	p := &storagedeal.Proposal{
+		TotalPrice: types.NewAttoFILFromFIL(100),
+		Duration:   10 * VoucherInterval,
+		Payment:    storagedeal.PaymentInfo{PaymentStart: types.NewBlockHeight(500)},
+	}
+
+	assert.Equal(t, types.ZeroAttoFIL, paymentDue(p, types.NewBlockHeight(400)))
+	assert.Equal(t, types.ZeroAttoFIL, paymentDue(p, types.NewBlockHeight(500+VoucherInterval-1)))
+	assert.Equal(t, types.NewAttoFILFromFIL(10), paymentDue(p, types.NewBlockHeight(500+VoucherInterval)))
+	assert.Equal(t, types.NewAttoFILFromFIL(30), paymentDue(p, types.NewBlockHeight(500+3*VoucherInterval+10)))
+	assert.Equal(t, types.NewAttoFILFromFIL(100), paymentDue(p, types.NewBlockHeight(500+20*VoucherInterval)))
+}
+
+// payDealTestAPI implements the parts of the client porcelain API payDeal uses.
+type payDealTestAPI struct {
+	clientPorcelainAPI
+
+	deals map[cid.Cid]*storagedeal.Deal
+}
+
+func (api *payDealTestAPI) DealGet(c cid.Cid) *storagedeal.Deal {
+	return api.deals[c]
+}
+
+func (api *payDealTestAPI) DealPut(d *storagedeal.Deal) error {
+	api.deals[d.Response.ProposalCid] = d
+	return nil
+}
+
+func (api *payDealTestAPI) MinerGetPeerID(ctx context.Context, minerAddr address.Address) (peer.ID, error) {
+	return peer.ID("miner"), nil
+}
+
+func (api *payDealTestAPI) PaymentChannelVoucher(ctx context.Context, fromAddr address.Address, channel *types.ChannelID, amount *types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate) (*types.PaymentVoucher, error) {
+	return &types.PaymentVoucher{
+		Channel:   *channel,
+		Payer:     fromAddr,
+		Amount:    *amount,
+		ValidAt:   *validAt,
+		Condition: condition,
+	}, nil
+}
+
+func TestClientPayDeal(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	addressGetter := address.NewForTestGetter()
+	minerAddr, payer := addressGetter(), addressGetter()
+
+	proposalCid := types.SomeCid()
+	deal := &storagedeal.Deal{
+		Miner: minerAddr,
+		Proposal: &storagedeal.Proposal{
+			PieceRef:     types.SomeCid(),
+			TotalPrice:   types.NewAttoFILFromFIL(100),
+			Duration:     10 * VoucherInterval,
+			MinerAddress: minerAddr,
+			Payment: storagedeal.PaymentInfo{
+				Payer:        payer,
+				Channel:      types.NewChannelID(7),
+				PaymentStart: types.NewBlockHeight(100),
+			},
+		},
+		Response: &storagedeal.Response{
+			State:       storagedeal.Staged,
+			ProposalCid: proposalCid,
+		},
+	}
+	api := &payDealTestAPI{deals: map[cid.Cid]*storagedeal.Deal{proposalCid: deal}}
+
+	minerState := storagedeal.Staged
+	var payments []*storagedeal.PaymentRequest
+	client := NewClient(0, nil, api)
+	client.ProtocolRequestFunc = func(ctx context.Context, protocol protocol.ID, peer peer.ID, host host.Host, request interface{}, response interface{}) error {
+		switch protocol {
+		case queryDealProtocol:
+			resp := response.(*storagedeal.Response)
+			*resp = storagedeal.Response{State: minerState, ProposalCid: proposalCid}
+			if minerState == storagedeal.Posted {
+				resp.ProofInfo = &storagedeal.ProofInfo{SectorID: 42}
+			}
+		case dealPaymentProtocol:
+			payments = append(payments, request.(*storagedeal.PaymentRequest))
+			*response.(*storagedeal.PaymentResponse) = storagedeal.PaymentResponse{Accepted: true}
+		}
+		return nil
+	}
+
+	t.Run("pays nothing until the miner has posted the deal", func(t *testing.T) {
+		require.NoError(t, client.payDeal(ctx, deal, types.NewBlockHeight(100+2*VoucherInterval)))
+		assert.Empty(t, payments)
+	})
+
+	t.Run("pays for the elapsed intervals once the deal is posted", func(t *testing.T) {
+		minerState = storagedeal.Posted
+		require.NoError(t, client.payDeal(ctx, deal, types.NewBlockHeight(100+3*VoucherInterval+10)))
+		require.Len(t, payments, 1)
+
+		voucher := payments[0].Voucher
+		assert.Equal(t, proposalCid, payments[0].ProposalCid)
+		assert.Equal(t, types.NewAttoFILFromFIL(30), &voucher.Amount)
+		assert.Equal(t, conditions.PieceStorage(minerAddr, pieceCommP(deal.Proposal.PieceRef), 42), voucher.Condition)
+
+		stored := api.DealGet(proposalCid)
+		assert.Equal(t, storagedeal.Posted, stored.Response.State)
+		assert.Equal(t, []*types.PaymentVoucher{voucher}, stored.Proposal.Payment.Vouchers)
+	})
+
+	t.Run("pays nothing more within the same interval", func(t *testing.T) {
+		require.NoError(t, client.payDeal(ctx, deal, types.NewBlockHeight(100+4*VoucherInterval-1)))
+		assert.Len(t, payments, 1)
+	})
+
+	t.Run("pays the total price once the deal has ended and then stops", func(t *testing.T) {
+		require.NoError(t, client.payDeal(ctx, deal, types.NewBlockHeight(100+10*VoucherInterval)))
+		require.Len(t, payments, 2)
+		assert.Equal(t, types.NewAttoFILFromFIL(100), &payments[1].Voucher.Amount)
+
+		require.NoError(t, client.payDeal(ctx, deal, types.NewBlockHeight(100+11*VoucherInterval)))
+		assert.Len(t, payments, 2)
+	})
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/config"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+)
+
+// getDealPolicy returns the rules the miner accepts deals by.
+func (sm *Miner) getDealPolicy() (*config.DealPolicyConfig, error) {
+	policyValue, err := sm.porcelainAPI.ConfigGet("mining.dealPolicy")
+	if err != nil {
+		return nil, err
+	}
+	policy, ok := policyValue.(*config.DealPolicyConfig)
+	if !ok || policy == nil {
+		return nil, errors.New("could not retrieve dealPolicy from config")
+	}
+	return policy, nil
+}
+
+// checkDealPolicy returns an error telling why the miner's deal policy
+// rejects p, if it does. The hook, if any, is left to runDealPolicyHook.
+func (sm *Miner) checkDealPolicy(policy *config.DealPolicyConfig, p *storagedeal.Proposal) error {
+	client := p.Payment.Payer
+	for _, addr := range policy.ClientBlacklist {
+		if addr == client {
+			return fmt.Errorf("client %s is blacklisted", client)
+		}
+	}
+	if len(policy.ClientWhitelist) > 0 {
+		whitelisted := false
+		for _, addr := range policy.ClientWhitelist {
+			whitelisted = whitelisted || addr == client
+		}
+		if !whitelisted {
+			return fmt.Errorf("client %s is not whitelisted", client)
+		}
+	}
+
+	if policy.MaxPieceSize > 0 && p.Size.Uint64() > policy.MaxPieceSize {
+		return fmt.Errorf("piece is %s bytes but the miner accepts at most %d bytes", p.Size.String(), policy.MaxPieceSize)
+	}
+
+	if policy.MinPrice != nil && policy.MinPrice.IsPositive() {
+		// price per byte per block >= MinPrice is implied by
+		// total price >= MinPrice * bytes * blocks
+		minTotalPrice := policy.MinPrice.MulBigInt(big.NewInt(0).SetUint64(p.Size.Uint64())).MulBigInt(big.NewInt(0).SetUint64(p.Duration))
+		if p.TotalPrice.LessThan(minTotalPrice) {
+			return fmt.Errorf("proposed price (%s) is less than the minimum (%s) given a minimum price of %s", p.TotalPrice.String(), minTotalPrice.String(), policy.MinPrice.String())
+		}
+	}
+
+	if policy.MaxOpenDeals > 0 {
+		deals, err := sm.porcelainAPI.DealsLs()
+		if err != nil {
+			return errors.Wrap(err, "failed to list deals")
+		}
+		var open uint
+		for _, d := range deals {
+			if d.Miner != sm.minerAddr || d.Response == nil {
+				continue
+			}
+			switch d.Response.State {
+			case storagedeal.Accepted, storagedeal.Started, storagedeal.Staged:
+				open++
+			}
+		}
+		if open >= policy.MaxOpenDeals {
+			return fmt.Errorf("miner has too many open deals (%d)", open)
+		}
+	}
+
+	return nil
+}
+
+// runDealPolicyHook runs the policy's hook on p and returns an error telling
+// why it rejected p, if it did.
+func runDealPolicyHook(ctx context.Context, policy *config.DealPolicyConfig, p *storagedeal.Proposal) error {
+	if policy.Hook == "" {
+		return nil
+	}
+
+	timeout, err := time.ParseDuration(policy.HookTimeout)
+	if err != nil {
+		return errors.Wrap(err, "invalid deal policy hook timeout")
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	proposalJSON, err := json.Marshal(p)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode proposal")
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, policy.Hook)
+	cmd.Stdin = bytes.NewReader(proposalJSON)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return errors.New("deal policy hook timed out")
+		}
+		if _, ok := err.(*exec.ExitError); !ok {
+			log.Errorf("failed to run deal policy hook %s: %s", policy.Hook, err)
+			return errors.New("deal policy hook failed")
+		}
+		reason := strings.TrimSpace(output.String())
+		if reason == "" {
+			reason = err.Error()
+		}
+		return fmt.Errorf("rejected by deal policy: %s", reason)
+	}
+	return nil
+}
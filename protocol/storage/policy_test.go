@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestDealPolicy(t *testing.T) {
+	tf.UnitTest(t)
+
+	propose := func(t *testing.T, setPolicy func(*minerTestPorcelain)) *storagedeal.Response {
+		porcelainAPI, miner, proposal := defaultMinerTestSetup(t, VoucherInterval, defaultAmountInc)
+		setPolicy(porcelainAPI)
+
+		res, err := miner.receiveStorageProposal(context.Background(), proposal)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("accepts deals by default", func(t *testing.T) {
+		res := propose(t, func(*minerTestPorcelain) {})
+		assert.Equal(t, storagedeal.Accepted, res.State)
+	})
+
+	t.Run("rejects blacklisted clients", func(t *testing.T) {
+		res := propose(t, func(api *minerTestPorcelain) {
+			require.NoError(t, api.config.Set("mining.dealPolicy.clientBlacklist", fmt.Sprintf("[%q]", api.payerAddress)))
+		})
+		assert.Equal(t, storagedeal.Rejected, res.State)
+		assert.Contains(t, res.Message, "is blacklisted")
+	})
+
+	t.Run("rejects clients missing from the whitelist", func(t *testing.T) {
+		res := propose(t, func(api *minerTestPorcelain) {
+			require.NoError(t, api.config.Set("mining.dealPolicy.clientWhitelist", fmt.Sprintf("[%q]", address.TestAddress)))
+		})
+		assert.Equal(t, storagedeal.Rejected, res.State)
+		assert.Contains(t, res.Message, "is not whitelisted")
+
+		res = propose(t, func(api *minerTestPorcelain) {
+			require.NoError(t, api.config.Set("mining.dealPolicy.clientWhitelist", fmt.Sprintf("[%q, %q]", address.TestAddress, api.payerAddress)))
+		})
+		assert.Equal(t, storagedeal.Accepted, res.State)
+	})
+
+	t.Run("rejects pieces larger than the maximum", func(t *testing.T) {
+		res := propose(t, func(api *minerTestPorcelain) {
+			require.NoError(t, api.config.Set("mining.dealPolicy.maxPieceSize", "999"))
+		})
+		assert.Equal(t, storagedeal.Rejected, res.State)
+		assert.Equal(t, "piece is 1000 bytes but the miner accepts at most 999 bytes", res.Message)
+	})
+
+	t.Run("rejects prices below the minimum", func(t *testing.T) {
+		res := propose(t, func(api *minerTestPorcelain) {
+			require.NoError(t, api.config.Set("mining.dealPolicy.minPrice", `".0005"`))
+		})
+		assert.Equal(t, storagedeal.Rejected, res.State)
+		assert.Contains(t, res.Message, "is less than the minimum")
+	})
+
+	t.Run("rejects deals above the maximum of open deals", func(t *testing.T) {
+		res := propose(t, func(api *minerTestPorcelain) {
+			require.NoError(t, api.config.Set("mining.dealPolicy.maxOpenDeals", "1"))
+			require.NoError(t, api.DealPut(&storagedeal.Deal{
+				Response: &storagedeal.Response{State: storagedeal.Staged, ProposalCid: types.SomeCid()},
+			}))
+		})
+		assert.Equal(t, storagedeal.Rejected, res.State)
+		assert.Contains(t, res.Message, "too many open deals")
+	})
+
+	t.Run("asks the hook", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("hook is a shell script")
+		}
+
+		dir, err := ioutil.TempDir("", "dealpolicy")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir) // nolint: errcheck
+
+		hook := func(script string) string {
+			path := filepath.Join(dir, fmt.Sprintf("hook-%d", len(script)))
+			require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+			return path
+		}
+		accept := hook("grep -q PieceRef\n")
+		reject := hook("echo 'not today'\nexit 1\n")
+
+		res := propose(t, func(api *minerTestPorcelain) {
+			require.NoError(t, api.config.Set("mining.dealPolicy.hook", accept))
+		})
+		assert.Equal(t, storagedeal.Accepted, res.State)
+
+		res = propose(t, func(api *minerTestPorcelain) {
+			require.NoError(t, api.config.Set("mining.dealPolicy.hook", reject))
+		})
+		assert.Equal(t, storagedeal.Rejected, res.State)
+		assert.Equal(t, "rejected by deal policy: not today", res.Message)
+	})
+}
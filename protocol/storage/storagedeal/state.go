@@ -56,3 +56,34 @@ func (s State) String() string {
 		return fmt.Sprintf("<unrecognized %d>", s)
 	}
 }
+
+// transitions lists the states a deal may move to from each state. A miner
+// accepts or rejects a proposal, fetches and stages the piece into a sector,
+// posts the sealed sector's commitment, and completes the deal once it is
+// proven. Rejected, Failed and Complete are final.
+var transitions = map[State][]State{
+	Unknown:  {Accepted, Rejected},
+	Accepted: {Started, Staged, Failed},
+	Started:  {Staged, Failed},
+	Staged:   {Posted, Failed},
+	Posted:   {Complete, Failed},
+}
+
+// CanTransitionTo returns true if a deal in state s may move to state next.
+// Staying in the same state is always allowed.
+func (s State) CanTransitionTo(next State) bool {
+	if s == next {
+		return true
+	}
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal returns true if a deal in state s can no longer change state.
+func (s State) IsFinal() bool {
+	return len(transitions[s]) == 0
+}
@@ -0,0 +1,41 @@
+package storagedeal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestStateTransitions(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("follows the deal lifecycle", func(t *testing.T) {
+		lifecycle := []State{Unknown, Accepted, Staged, Posted, Complete}
+		for i := 1; i < len(lifecycle); i++ {
+			assert.True(t, lifecycle[i-1].CanTransitionTo(lifecycle[i]), "%s -> %s", lifecycle[i-1], lifecycle[i])
+		}
+	})
+
+	t.Run("can fail until complete", func(t *testing.T) {
+		for _, s := range []State{Accepted, Started, Staged, Posted} {
+			assert.True(t, s.CanTransitionTo(Failed), s.String())
+		}
+	})
+
+	t.Run("cannot skip or go backwards", func(t *testing.T) {
+		assert.False(t, Accepted.CanTransitionTo(Posted))
+		assert.False(t, Staged.CanTransitionTo(Accepted))
+		assert.False(t, Unknown.CanTransitionTo(Staged))
+	})
+
+	t.Run("final states stay put", func(t *testing.T) {
+		for _, s := range []State{Rejected, Failed, Complete} {
+			assert.True(t, s.IsFinal(), s.String())
+			assert.True(t, s.CanTransitionTo(s))
+			assert.False(t, s.CanTransitionTo(Accepted))
+		}
+		assert.False(t, Staged.IsFinal())
+	})
+}
@@ -16,6 +16,8 @@ func init() {
 	cbor.RegisterCborType(ProofInfo{})
 	cbor.RegisterCborType(QueryRequest{})
 	cbor.RegisterCborType(Deal{})
+	cbor.RegisterCborType(PaymentRequest{})
+	cbor.RegisterCborType(PaymentResponse{})
 }
 
 // PaymentInfo contains all the payment related information for a storage deal.
@@ -38,6 +40,12 @@ type PaymentInfo struct {
 	// cashed out contingent on the agreed upon data being provably within a
 	// live sector in the miners control on-chain
 	Vouchers []*types.PaymentVoucher
+
+	// PaymentStart is the block height from which the deal is paid per epoch.
+	// Such a deal comes with no vouchers, the client sends them to the miner
+	// as the deal progresses instead. It is nil when Vouchers pay for the
+	// whole deal up front.
+	PaymentStart *types.BlockHeight
 }
 
 // Proposal is the information sent over the wire, when a client proposes a deal to a miner.
@@ -139,3 +147,22 @@ type ProofInfo struct {
 type QueryRequest struct {
 	Cid cid.Cid
 }
+
+// PaymentRequest is sent by a client to pay the miner of a deal paid per epoch.
+type PaymentRequest struct {
+	// ProposalCid is the cid of the proposal of the deal being paid for
+	ProposalCid cid.Cid
+
+	// Voucher pays for the deal up to now. It replaces the vouchers the
+	// client sent before.
+	Voucher *types.PaymentVoucher
+}
+
+// PaymentResponse is the miner's answer to a PaymentRequest.
+type PaymentResponse struct {
+	// Accepted is true if the miner accepted the voucher
+	Accepted bool
+
+	// Message tells why the voucher was not accepted
+	Message string
+}
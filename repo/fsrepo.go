@@ -481,6 +481,16 @@ func APIAddrFromRepoPath(repoPath string) (string, error) {
 	return apiAddrFromFile(filepath.Join(repoPath, apiFile))
 }
 
+// ConfigFromRepoPath reads the config of the filecoin repo at repoPath
+// without opening, and so locking, the repo.
+func ConfigFromRepoPath(repoPath string) (*config.Config, error) {
+	repoPath, err := homedir.Expand(repoPath)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("can't resolve local repo path %s", repoPath))
+	}
+	return config.ReadFile(filepath.Join(repoPath, configFilename))
+}
+
 // APIAddrFromFile reads the address from the API file at the given path.
 // A relevant comment from a similar function at go-ipfs/repo/fsrepo/fsrepo.go:
 // This is a concurrent operation, meaning that any process may read this file.
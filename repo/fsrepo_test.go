@@ -51,11 +51,30 @@ const (
 	"mining": {
 		"minerAddress": "empty",
 		"autoSealIntervalSeconds": 120,
-		"storagePrice": "0"
+		"storagePrice": "0",
+		"retrievalPrice": "0",
+		"minBlockMessages": 0,
+		"maxMessageWaitMilliseconds": 0,
+		"removeExpiredSectors": false,
+		"dealPolicy": {
+			"minPrice": "0",
+			"maxPieceSize": 0,
+			"clientWhitelist": [],
+			"clientBlacklist": [],
+			"maxOpenDeals": 0,
+			"hook": "",
+			"hookTimeout": "30s"
+		}
 	},
 	"mpool": {
 		"maxPoolSize": 10000,
-		"maxNonceGap": "100"
+		"maxNonceGap": "100",
+		"waitConfidence": 0,
+		"maxMessageSize": 32768,
+		"peerMessageRate": 10,
+		"peerMessageBurst": 100,
+		"messageTimeout": 6,
+		"replaceByFeeBump": 10
 	},
 	"net": "",
 	"observability": {
@@ -70,12 +89,36 @@ const (
 			"jaegerEndpoint": "http://localhost:14268/api/traces"
 		}
 	},
+	"paych": {
+		"watch": false
+	},
+	"pruning": {
+		"keepStates": 0,
+		"period": "1h"
+	},
 	"sectorbase": {
-		"rootdir": ""
+		"rootdir": "",
+		"sealedPaths": []
 	},
 	"swarm": {
 		"address": "/ip4/0.0.0.0/tcp/6000"
 	},
+	"sync": {
+		"checkpointTipSet": "",
+		"checkpointStateRoot": "",
+		"finality": 0,
+		"chainWeight": "power",
+		"validationWorkers": 0
+	},
+	"timesync": {
+		"ntpServer": "pool.ntp.org",
+		"checkPeriod": "10m",
+		"maxDrift": "5s"
+	},
+	"update": {
+		"checkEnabled": false,
+		"manifestUrl": ""
+	},
 	"wallet": {
 		"defaultAddress": "empty"
 	}
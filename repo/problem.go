@@ -0,0 +1,10 @@
+package repo
+
+// Problem is an inconsistency found by an integrity check of the data in a
+// repo.
+type Problem struct {
+	// Description says what is inconsistent and, if it was repaired, how.
+	Description string `json:"description"`
+	// Repaired is true if the inconsistency has been repaired.
+	Repaired bool `json:"repaired"`
+}
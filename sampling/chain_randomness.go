@@ -1,6 +1,9 @@
 package sampling
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/types"
@@ -65,3 +68,24 @@ func SampleChainRandomness(sampleHeight *types.BlockHeight, tipSetsSortedByBlock
 
 	return tipSetsSortedByBlockHeightDescending[lookbackIdx].MinTicket()
 }
+
+// Rand derives 32 bytes of randomness for the given epoch from the ticket
+// SampleChainRandomness samples at it. The epoch is hashed in with the ticket
+// so that epochs sampling the same ticket, like the early epochs that all
+// fall back to the genesis ticket, get different randomness. Tickets can not
+// be chosen by the miners producing them, so neither can the randomness.
+//
+// Rand is useful for anything actors and miners must agree on that must not
+// be predictable ahead of the epoch, like PoSt challenge seeds.
+func Rand(epoch *types.BlockHeight, tipSetsSortedByBlockHeightDescending []types.TipSet) ([]byte, error) {
+	ticket, err := SampleChainRandomness(epoch, tipSetsSortedByBlockHeightDescending)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, epoch.AsBigInt().Uint64())
+
+	h := sha256.Sum256(append(append([]byte{}, ticket...), buf[:n]...))
+	return h[:], nil
+}
@@ -85,3 +85,31 @@ func TestSamplingChainRandomness(t *testing.T) {
 		assert.Equal(t, []byte(strconv.Itoa(0)), r)
 	})
 }
+
+func TestRand(t *testing.T) {
+	tf.UnitTest(t)
+
+	chain := testhelpers.RequireTipSetChain(t, 20)
+
+	r10, err := sampling.Rand(types.NewBlockHeight(10), chain)
+	require.NoError(t, err)
+	assert.Len(t, r10, 32)
+
+	again, err := sampling.Rand(types.NewBlockHeight(10), chain)
+	require.NoError(t, err)
+	assert.Equal(t, r10, again)
+
+	r11, err := sampling.Rand(types.NewBlockHeight(11), chain)
+	require.NoError(t, err)
+	assert.NotEqual(t, r10, r11)
+
+	// epochs that fall back to the genesis ticket still differ
+	r1, err := sampling.Rand(types.NewBlockHeight(1), chain)
+	require.NoError(t, err)
+	r2, err := sampling.Rand(types.NewBlockHeight(2), chain)
+	require.NoError(t, err)
+	assert.NotEqual(t, r1, r2)
+
+	_, err = sampling.Rand(types.NewBlockHeight(30), chain)
+	assert.Error(t, err)
+}
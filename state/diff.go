@@ -0,0 +1,64 @@
+package state
+
+import (
+	"context"
+	"sort"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+)
+
+// ActorDiff is the change of an actor between two state trees. Before is nil
+// if the actor is only in the second tree and After is nil if it is only in
+// the first.
+type ActorDiff struct {
+	Address address.Address `json:"address"`
+	Before  *actor.Actor    `json:"before"`
+	After   *actor.Actor    `json:"after"`
+}
+
+// Diff returns the changes of the actors that differ between the trees from
+// and to, ordered by address. Actors differ if their code, head, nonce or
+// balance differ.
+func Diff(ctx context.Context, from, to Tree) ([]*ActorDiff, error) {
+	diffs := make(map[address.Address]*ActorDiff)
+	err := from.ForEachActor(ctx, func(addr address.Address, a *actor.Actor) error {
+		diffs[addr] = &ActorDiff{Address: addr, Before: a}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = to.ForEachActor(ctx, func(addr address.Address, a *actor.Actor) error {
+		diff, ok := diffs[addr]
+		if !ok {
+			diffs[addr] = &ActorDiff{Address: addr, After: a}
+			return nil
+		}
+		if actorsEqual(diff.Before, a) {
+			delete(diffs, addr)
+			return nil
+		}
+		diff.After = a
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*ActorDiff
+	for _, diff := range diffs {
+		out = append(out, diff)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Address.String() < out[j].Address.String()
+	})
+	return out, nil
+}
+
+func actorsEqual(a, b *actor.Actor) bool {
+	return a.Code.Equals(b.Code) &&
+		a.Head.Equals(b.Head) &&
+		a.Nonce == b.Nonce &&
+		a.Balance.Equal(b.Balance)
+}
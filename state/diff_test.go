@@ -0,0 +1,66 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-hamt-ipld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestDiff(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	cst := hamt.NewCborStore()
+	from := NewEmptyStateTree(cst)
+	to := NewEmptyStateTree(cst)
+
+	addrGetter := address.NewForTestGetter()
+	unchanged, changed, removed, added := addrGetter(), addrGetter(), addrGetter(), addrGetter()
+
+	require.NoError(t, from.SetActor(ctx, unchanged, actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(1))))
+	require.NoError(t, to.SetActor(ctx, unchanged, actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(1))))
+
+	before := actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(2))
+	after := actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(3))
+	after.IncNonce()
+	require.NoError(t, from.SetActor(ctx, changed, before))
+	require.NoError(t, to.SetActor(ctx, changed, after))
+
+	require.NoError(t, from.SetActor(ctx, removed, actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(4))))
+	require.NoError(t, to.SetActor(ctx, added, actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(5))))
+
+	diffs, err := Diff(ctx, from, to)
+	require.NoError(t, err)
+
+	byAddr := make(map[address.Address]*ActorDiff)
+	for _, diff := range diffs {
+		byAddr[diff.Address] = diff
+	}
+	assert.Len(t, diffs, 3)
+	assert.NotContains(t, byAddr, unchanged)
+
+	require.Contains(t, byAddr, changed)
+	assert.True(t, types.NewAttoFILFromFIL(2).Equal(byAddr[changed].Before.Balance))
+	assert.True(t, types.NewAttoFILFromFIL(3).Equal(byAddr[changed].After.Balance))
+	assert.Equal(t, types.Uint64(1), byAddr[changed].After.Nonce)
+
+	require.Contains(t, byAddr, removed)
+	assert.NotNil(t, byAddr[removed].Before)
+	assert.Nil(t, byAddr[removed].After)
+
+	require.Contains(t, byAddr, added)
+	assert.Nil(t, byAddr[added].Before)
+	assert.NotNil(t, byAddr[added].After)
+
+	for i := 1; i < len(diffs); i++ {
+		assert.True(t, diffs[i-1].Address.String() < diffs[i].Address.String())
+	}
+}
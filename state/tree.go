@@ -3,6 +3,7 @@ package state
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-hamt-ipld"
@@ -14,6 +15,12 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/metrics"
+)
+
+var (
+	loadTreeOp  = metrics.NewSlowOp("state/load_tree", 50*time.Millisecond)
+	flushTreeOp = metrics.NewSlowOp("state/flush_tree", 100*time.Millisecond)
 )
 
 // tree is a state tree that maps addresses to actors.
@@ -49,6 +56,8 @@ var _ Tree = &tree{}
 
 // LoadStateTree loads the state tree referenced by the given cid.
 func LoadStateTree(ctx context.Context, store *hamt.CborIpldStore, c cid.Cid, builtinActors map[cid.Cid]exec.ExecutableActor) (Tree, error) {
+	defer loadTreeOp.Start().Stop()
+
 	root, err := hamt.LoadNode(ctx, store, c)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to load node")
@@ -84,6 +93,8 @@ func newEmptyStateTree(store *hamt.CborIpldStore) *tree {
 // Flush serialized the state tree and flushes unflushed changes to the backing
 // datastore. The cid of the state tree is returned.
 func (t *tree) Flush(ctx context.Context) (cid.Cid, error) {
+	defer flushTreeOp.Start().Stop()
+
 	if err := t.root.Flush(ctx); err != nil {
 		return cid.Undef, err
 	}
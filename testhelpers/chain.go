@@ -3,7 +3,6 @@ package testhelpers
 import (
 	"context"
 	"errors"
-	"fmt"
 	"testing"
 
 	"github.com/ipfs/go-cid"
@@ -42,7 +41,9 @@ type FakeChildParams struct {
 // ticket that would validate that the child's miner is elected by consensus.
 // In fact MkFakeChild does not assign a miner address to the block at all.
 //
-// MkFakeChild assigns blocks correct parent weight, height, and parent headers.
+// MkFakeChild assigns blocks correct parent weight, height, and parent headers,
+// and draws their tickets from the parent's and signs them with the key
+// MinerPubKey of the Signer.
 // Chains created with this function are useful for validating chain syncing
 // and chain storing behavior, and the weight related methods of the consensus
 // interface.  They are not useful for testing the full range of consensus
@@ -112,6 +113,10 @@ func MkFakeChildCore(parent types.TipSet,
 	newBlock.Nonce = types.Uint64(nonce)
 	newBlock.StateRoot = stateRoot
 
+	if err := consensus.SignBlock(newBlock, minerPubKey, signer); err != nil {
+		return nil, err
+	}
+
 	return newBlock, nil
 }
 
@@ -178,27 +183,31 @@ func RequirePutTsas(ctx context.Context, t *testing.T, chn chain.Store, tsas *ch
 	require.NoError(t, err)
 }
 
-// MakeProofAndWinningTicket generates a proof and ticket that will pass validateMining.
-func MakeProofAndWinningTicket(signerPubKey []byte, minerPower uint64, totalPower uint64, signer consensus.TicketSigner) (types.PoStProof, types.Signature, error) {
+// RequireWinningBlock moves blk, a child of parent, up by as many null rounds
+// as it takes for the ticket drawn with the key signerPubKey to win with
+// minerPower out of totalPower, as a miner would. It gives blk that ticket and
+// a proof that will pass validateMining and signs blk again with that key, as
+// the ticket is part of the signed data. Blocks with the same key and parent
+// draw the same tickets, so they end up at the same height.
+func RequireWinningBlock(t *testing.T, blk *types.Block, parent types.TipSet, signerPubKey []byte, minerPower uint64, totalPower uint64, signer consensus.TicketSigner) {
+	t.Helper()
+	require.True(t, totalPower/minerPower <= 100000, "minerPower is too small for totalPower to draw a winning ticket")
 
-	poStProof := make([]byte, types.OnePoStProofPartition.ProofLen())
-	var ticket types.Signature
-
-	if totalPower/minerPower > 100000 {
-		return poStProof, ticket, errors.New("MakeProofAndWinningTicket: minerPower is too small for totalPower to generate a winning ticket")
-	}
+	parentHeight, err := parent.Height()
+	require.NoError(t, err)
+	require.True(t, uint64(blk.Height) > parentHeight, "block must be higher than its parent")
 
-	for {
-		poStProof = MakeRandomPoSTProofForTest()
-		ticket, err := consensus.CreateTicket(poStProof, signerPubKey, signer)
-		if err != nil {
-			errStr := fmt.Sprintf("error creating ticket: %s", err)
-			panic(errStr)
-		}
+	for nullBlkCount := uint64(blk.Height) - parentHeight - 1; ; nullBlkCount++ {
+		ticket, err := consensus.CreateTicket(parent, nullBlkCount, signerPubKey, signer)
+		require.NoError(t, err)
 		if consensus.CompareTicketPower(ticket, minerPower, totalPower) {
-			return poStProof, ticket, nil
+			blk.Height = types.Uint64(parentHeight + nullBlkCount + 1)
+			blk.Ticket = ticket
+			break
 		}
 	}
+	blk.Proof = MakeRandomPoSTProofForTest()
+	require.NoError(t, consensus.SignBlock(blk, signerPubKey, signer))
 }
 
 ///// Fake traversal block provider implementation
@@ -228,9 +237,16 @@ func (bs *FakeBlockProvider) GetBlock(ctx context.Context, cid cid.Cid) (*types.
 
 // NewBlockWithMessages creates and stores a new block in this provider.
 func (bs *FakeBlockProvider) NewBlockWithMessages(nonce uint64, messages []*types.SignedMessage, parents ...*types.Block) *types.Block {
+	return bs.NewBlockWithReceipts(nonce, messages, nil, parents...)
+}
+
+// NewBlockWithReceipts creates and stores a new block with the receipts of
+// its messages in this provider.
+func (bs *FakeBlockProvider) NewBlockWithReceipts(nonce uint64, messages []*types.SignedMessage, receipts []*types.MessageReceipt, parents ...*types.Block) *types.Block {
 	b := &types.Block{
-		Nonce:    types.Uint64(nonce),
-		Messages: messages,
+		Nonce:           types.Uint64(nonce),
+		Messages:        messages,
+		MessageReceipts: receipts,
 	}
 
 	if len(parents) > 0 {
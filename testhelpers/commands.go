@@ -530,7 +530,7 @@ func (td *TestDaemon) WaitForMessageRequireSuccess(msgCid cid.Cid) *types.Messag
 	trim := strings.Trim(td.RunSuccess(args...).ReadStdout(), "\n")
 	rcpt := &types.MessageReceipt{}
 	require.NoError(td.test, json.Unmarshal([]byte(trim), rcpt))
-	require.Equal(td.test, 0, int(rcpt.ExitCode))
+	require.Equal(td.test, 0, int(rcpt.ExitCode), rcpt.Error)
 	return rcpt
 }
 
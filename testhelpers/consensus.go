@@ -2,6 +2,7 @@ package testhelpers
 
 import (
 	"context"
+	"math/big"
 	"testing"
 
 	cid "github.com/ipfs/go-cid"
@@ -38,6 +39,12 @@ func (tv *TestView) HasPower(ctx context.Context, st state.Tree, bstore blocksto
 	return true
 }
 
+// WorkerAddr returns the miner address itself, as test miners are their own
+// workers.
+func (tv *TestView) WorkerAddr(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (address.Address, error) {
+	return mAddr, nil
+}
+
 // RequireNewTipSet instantiates and returns a new tipset of the given blocks
 // and requires that the setup validation succeed.
 func RequireNewTipSet(t *testing.T, blks ...*types.Block) types.TipSet {
@@ -77,22 +84,43 @@ func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstor
 	return true
 }
 
+// WorkerAddr returns the miner address itself, as test miners are their own
+// workers.
+func (tv *TestPowerTableView) WorkerAddr(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (address.Address, error) {
+	return mAddr, nil
+}
+
 // NewValidTestBlockFromTipSet creates a block for when proofs & power table don't need
 // to be correct
 func NewValidTestBlockFromTipSet(baseTipSet types.TipSet, stateRootCid cid.Cid, height uint64, minerAddr address.Address, minerPubKey []byte, signer consensus.TicketSigner) *types.Block {
 	poStProof := MakeRandomPoSTProofForTest()
-	ticket, _ := consensus.CreateTicket(poStProof, minerPubKey, signer)
+	var nullBlkCount uint64
+	if baseHeight, err := baseTipSet.Height(); err == nil && height > baseHeight {
+		nullBlkCount = height - baseHeight - 1
+	}
+	ticket, _ := consensus.CreateTicket(baseTipSet, nullBlkCount, minerPubKey, signer)
+
+	// Claim the least weight the parents can have, so that syncers accept
+	// the block. The genesis block weighs nothing.
+	var parentWeight uint64
+	if baseHeight, err := baseTipSet.Height(); err == nil && baseHeight > 0 {
+		parentWeight, _ = baseTipSet.ParentWeight()
+		blocksWeight, _ := types.BigToFixed(new(big.Float).SetUint64(uint64(len(baseTipSet)) * consensus.ECV))
+		parentWeight += blocksWeight
+	}
 
-	return &types.Block{
+	blk := &types.Block{
 		Miner:        minerAddr,
 		Ticket:       ticket,
 		Parents:      baseTipSet.ToSortedCidSet(),
-		ParentWeight: types.Uint64(10000 * height),
+		ParentWeight: types.Uint64(parentWeight),
 		Height:       types.Uint64(height),
 		Nonce:        types.Uint64(height),
 		StateRoot:    stateRootCid,
 		Proof:        poStProof,
 	}
+	_ = consensus.SignBlock(blk, minerPubKey, signer)
+	return blk
 }
 
 // MakeRandomPoSTProofForTest creates a random proof.
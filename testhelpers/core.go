@@ -3,6 +3,7 @@ package testhelpers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"testing"
 
@@ -59,8 +60,10 @@ func RequireNewAccountActor(t *testing.T, value *types.AttoFIL) *actor.Actor {
 func RequireNewMinerActor(t *testing.T, vms vm.StorageMap, addr address.Address, owner address.Address, key []byte, pledge uint64, pid peer.ID, coll *types.AttoFIL) *actor.Actor {
 	act := actor.NewActor(types.MinerActorCodeCid, types.NewZeroAttoFIL())
 	storage := vms.NewStorage(addr, act)
-	initializerData := miner.NewState(owner, key, big.NewInt(int64(pledge)), pid, coll)
-	err := (&miner.Actor{}).InitializeState(storage, initializerData)
+	worker, err := address.NewSecp256k1Address(key)
+	require.NoError(t, err)
+	initializerData := miner.NewState(owner, worker, key, big.NewInt(int64(pledge)), pid, coll)
+	err = (&miner.Actor{}).InitializeState(storage, initializerData)
 	require.NoError(t, err)
 	require.NoError(t, storage.Flush())
 	return act
@@ -90,14 +93,16 @@ func RequireRandomPeerID(t *testing.T) peer.ID {
 	return pid
 }
 
-// TestMessagePoolAPI provides a simple BlockTimer interface implementation.
+// TestMessagePoolAPI provides a simple MessagePoolAPI implementation.
 type TestMessagePoolAPI struct {
 	Height uint64
+	// Actors are the actors of the latest state.
+	Actors map[address.Address]*actor.Actor
 }
 
 // NewTestMessagePoolAPI creates a new TestMessagePoolAPI.
 func NewTestMessagePoolAPI(h uint64) *TestMessagePoolAPI {
-	return &TestMessagePoolAPI{Height: h}
+	return &TestMessagePoolAPI{Height: h, Actors: make(map[address.Address]*actor.Actor)}
 }
 
 // MockMessagePoolValidator is a mock validator
@@ -123,6 +128,15 @@ func (tbt *TestMessagePoolAPI) BlockHeight() (uint64, error) {
 	return tbt.Height, nil
 }
 
+// ActorFromLatestState returns the actor at addr from Actors.
+func (tbt *TestMessagePoolAPI) ActorFromLatestState(ctx context.Context, addr address.Address) (*actor.Actor, error) {
+	act, ok := tbt.Actors[addr]
+	if !ok {
+		return nil, fmt.Errorf("no actor at address %s", addr)
+	}
+	return act, nil
+}
+
 // VMStorage creates a new storage object backed by an in memory datastore
 func VMStorage() vm.StorageMap {
 	return vm.NewStorageMap(blockstore.NewBlockstore(datastore.NewMapDatastore()))
@@ -27,7 +27,7 @@ func CreateMinerMessage(from address.Address, nonce uint64, pledge uint64, pid p
 	return types.NewMessage(from, address.StorageMarketAddress, nonce, collateral, "createMiner", params), nil
 }
 
-// CommitSectorMessage creates a message to commit a sector.
+// CommitSectorMessage creates a message to commit a sector of a bootstrap miner.
 func CommitSectorMessage(miner, from address.Address, nonce, sectorID uint64, commD, commR, commRStar, proof []byte) (*types.Message, error) {
 	params, err := abi.ToEncodedValues(sectorID, commD, commR, commRStar, proof)
 	if err != nil {
@@ -0,0 +1,162 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/libp2p/go-libp2p/p2p/net/mock"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/gengen/util"
+	"github.com/filecoin-project/go-filecoin/node"
+	"github.com/filecoin-project/go-filecoin/repo"
+)
+
+// Builder is used to create a Harness of in-process nodes
+type Builder struct {
+	t          *testing.T
+	numNodes   int
+	genesisCfg *gengen.GenesisCfg
+	configOpts []node.ConfigOpt
+	initOpts   []node.InitOpt
+}
+
+// NewBuilder dispenses a harness builder, building two nodes by default
+func NewBuilder(t *testing.T) *Builder {
+	return &Builder{
+		t:        t,
+		numNodes: 2,
+	}
+}
+
+// Nodes sets the number of nodes to build
+func (b *Builder) Nodes(numNodes int) *Builder {
+	b.numNodes = numNodes
+
+	return b
+}
+
+// GenesisCfg sets the configuration of the genesis block of the nodes. Node i
+// is given key i, so the configuration needs a key for each node, and the
+// first miner must be owned by key 0 and have the peer ID of
+// node.PeerKeys[0], as the first node mines with it. By default each node's
+// key is given 10000 FIL and the miner has all the power.
+func (b *Builder) GenesisCfg(cfg *gengen.GenesisCfg) *Builder {
+	b.genesisCfg = cfg
+
+	return b
+}
+
+// ConfigOpts adds options to the configuration of every node
+func (b *Builder) ConfigOpts(opts ...node.ConfigOpt) *Builder {
+	b.configOpts = append(b.configOpts, opts...)
+
+	return b
+}
+
+// InitOpts adds options to the initialization of the repo of every node
+func (b *Builder) InitOpts(opts ...node.InitOpt) *Builder {
+	b.initOpts = append(b.initOpts, opts...)
+
+	return b
+}
+
+// Build consumes builder and produces a new testing harness. The nodes are
+// linked over the harness' mock network, call Start to start and connect
+// them.
+func (b *Builder) Build(ctx context.Context) *Harness {
+	b.t.Helper()
+
+	cfg := b.genesisCfg
+	if cfg == nil {
+		cfg = defaultGenesisCfg(b.numNodes)
+	}
+	require.True(b.t, cfg.Keys >= b.numNodes, "genesis has %d keys for %d nodes", cfg.Keys, b.numNodes)
+	require.NotEmpty(b.t, cfg.Miners, "genesis has no miner")
+
+	h := &Harness{
+		t:    b.t,
+		Seed: node.MakeChainSeed(b.t, cfg),
+		Net:  mocknet.New(ctx),
+	}
+	for i := 0; i < b.numNodes; i++ {
+		h.Nodes = append(h.Nodes, b.buildNode(ctx, h, i))
+	}
+	require.NoError(b.t, h.Net.LinkAll())
+
+	h.MinerAddr, _ = h.Seed.GiveMiner(b.t, h.Nodes[0], 0)
+
+	return h
+}
+
+// buildNode builds node i of the harness on a host of its mock network,
+// giving it key i as its default address.
+func (b *Builder) buildNode(ctx context.Context, h *Harness, i int) *node.Node {
+	b.t.Helper()
+
+	peerKey := node.PeerKeys[0]
+	if i > 0 {
+		peerKey = genPeerKey(int64(i))
+	}
+	initOpts := []node.InitOpt{
+		node.PeerKeyOpt(peerKey),
+		node.DefaultWalletAddressOpt(h.Seed.Addr(b.t, i)),
+	}
+
+	r := repo.NewInMemoryRepo()
+	sectorDir, err := ioutil.TempDir("", "go-fil-test-sectors")
+	require.NoError(b.t, err)
+	r.Config().SectorBase.RootDir = sectorDir
+	require.NoError(b.t, node.Init(ctx, r, h.Seed.GenesisInitFunc, append(initOpts, b.initOpts...)...))
+
+	addr, err := ma.NewMultiaddr(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", 4000+i))
+	require.NoError(b.t, err)
+	host, err := h.Net.AddPeer(peerKey, addr)
+	require.NoError(b.t, err)
+
+	opts, err := node.OptionsFromRepo(r)
+	require.NoError(b.t, err)
+	opts = append(opts, node.Libp2pHost(host))
+	opts = append(opts, node.DefaultTestingConfig()...)
+	opts = append(opts, b.configOpts...)
+
+	nd, err := node.New(ctx, opts...)
+	require.NoError(b.t, err)
+	h.Seed.GiveKey(b.t, nd, i)
+
+	return nd
+}
+
+func defaultGenesisCfg(numNodes int) *gengen.GenesisCfg {
+	cfg := &gengen.GenesisCfg{
+		Keys: numNodes,
+		Miners: []gengen.Miner{
+			{
+				Owner:  0,
+				Power:  100,
+				PeerID: node.TestGenCfg.Miners[0].PeerID,
+			},
+		},
+	}
+	for i := 0; i < numNodes; i++ {
+		cfg.PreAlloc = append(cfg.PreAlloc, "10000")
+	}
+
+	return cfg
+}
+
+// genPeerKey deterministically generates the libp2p key of a node, which is
+// much faster than the RSA key nodes generate by default.
+func genPeerKey(seed int64) crypto.PrivKey {
+	priv, _, err := crypto.GenerateEd25519Key(rand.New(rand.NewSource(seed)))
+	if err != nil {
+		panic(err)
+	}
+
+	return priv
+}
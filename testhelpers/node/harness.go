@@ -0,0 +1,112 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/node"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// WaitTimeout is how long the harness waits for blocks and messages to reach
+// the nodes.
+var WaitTimeout = 10 * time.Second
+
+// Harness is a set of fully wired in-process nodes sharing a genesis block
+// and connected over a mock network, verifying proofs with a fake verifier.
+// The first node mines with the genesis miner, only when MineOnce is called,
+// so that tests decide when messages are executed.
+type Harness struct {
+	t *testing.T
+
+	// Seed is the chain seed the genesis block of the nodes was made from.
+	Seed *node.ChainSeed
+	// Net is the mock network the nodes are linked over.
+	Net mocknet.Mocknet
+	// Nodes are the nodes of the harness, node i has key i of the seed as
+	// its default address.
+	Nodes []*node.Node
+	// MinerAddr is the address of the miner the first node mines with.
+	MinerAddr address.Address
+}
+
+// Start starts the nodes and connects each to all others.
+func (h *Harness) Start() {
+	h.t.Helper()
+	node.StartNodes(h.t, h.Nodes)
+	require.NoError(h.t, h.Net.ConnectAllButSelf())
+}
+
+// Stop stops the nodes.
+func (h *Harness) Stop() {
+	node.StopNodes(h.Nodes)
+}
+
+// Miner returns the node mining.
+func (h *Harness) Miner() *node.Node {
+	return h.Nodes[0]
+}
+
+// Addr returns the default address of node i.
+func (h *Harness) Addr(i int) address.Address {
+	return h.Seed.Addr(h.t, i)
+}
+
+// MineOnce mines a block on top of the head of the miner, including the
+// messages in its message pool, and waits for all nodes to sync it.
+func (h *Harness) MineOnce(ctx context.Context) *types.Block {
+	h.t.Helper()
+	blk, err := h.Miner().BlockMiningAPI.MiningOnce(ctx)
+	require.NoError(h.t, err)
+	h.WaitForBlock(blk.Cid())
+
+	return blk
+}
+
+// MineMessage waits for the message to reach the message pool of the miner,
+// mines it, and returns its receipt.
+func (h *Harness) MineMessage(ctx context.Context, msgCid cid.Cid) *types.MessageReceipt {
+	h.t.Helper()
+	h.waitFor(func() bool {
+		_, ok := h.Miner().MsgPool.Get(msgCid)
+		return ok
+	}, "message %s did not reach the miner", msgCid)
+
+	h.MineOnce(ctx)
+
+	chainMsg, found, err := h.Miner().PorcelainAPI.MessageFind(ctx, msgCid)
+	require.NoError(h.t, err)
+	require.True(h.t, found, "message %s was not mined", msgCid)
+
+	return chainMsg.Receipt
+}
+
+// WaitForBlock waits for the head of every node to include the block.
+func (h *Harness) WaitForBlock(blkCid cid.Cid) {
+	h.t.Helper()
+	for _, nd := range h.Nodes {
+		nd := nd
+		h.waitFor(func() bool {
+			return nd.ChainReader.GetHead().Has(blkCid)
+		}, "node %s did not sync block %s", nd.Host().ID().Pretty(), blkCid)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test if it does not within
+// WaitTimeout.
+func (h *Harness) waitFor(cond func() bool, format string, args ...interface{}) {
+	h.t.Helper()
+	deadline := time.Now().Add(WaitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			h.t.Fatalf(format, args...)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
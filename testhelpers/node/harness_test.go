@@ -0,0 +1,53 @@
+package node_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	tnode "github.com/filecoin-project/go-filecoin/testhelpers/node"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestHarness(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	t.Run("syncs the blocks the miner mines", func(t *testing.T) {
+		h := tnode.NewBuilder(t).Nodes(3).Build(ctx)
+		h.Start()
+		defer h.Stop()
+
+		h.MineOnce(ctx)
+		blk := h.MineOnce(ctx)
+
+		for _, nd := range h.Nodes {
+			assert.Equal(t, types.NewSortedCidSet(blk.Cid()), nd.ChainReader.GetHead())
+		}
+	})
+
+	t.Run("executes the messages the nodes send", func(t *testing.T) {
+		h := tnode.NewBuilder(t).Build(ctx)
+		h.Start()
+		defer h.Stop()
+
+		msgCid, err := h.Nodes[1].PorcelainAPI.MessageSend(ctx, h.Addr(1), address.PaymentBrokerAddress, types.NewAttoFILFromFIL(10), types.NewGasPrice(1), types.NewGasUnits(300), "createChannel", h.Addr(0), types.NewBlockHeight(100))
+		require.NoError(t, err)
+
+		receipt := h.MineMessage(ctx, msgCid)
+		require.Equal(t, uint8(0), receipt.ExitCode, receipt.Error)
+
+		channels, err := h.Nodes[0].PorcelainAPI.PaymentChannelLs(ctx, h.Addr(0), h.Addr(1))
+		require.NoError(t, err)
+		require.Len(t, channels, 1)
+		for _, channel := range channels {
+			assert.Equal(t, h.Addr(0), channel.Target)
+			assert.Equal(t, types.NewAttoFILFromFIL(10), channel.Amount)
+		}
+	})
+}
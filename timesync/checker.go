@@ -0,0 +1,131 @@
+package timesync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+	"github.com/pkg/errors"
+)
+
+var log = logging.Logger("timesync")
+
+// ErrExcessiveDrift is returned by Checker.Err when the local clock has
+// drifted further from the NTP server than the allowed maximum.
+var ErrExcessiveDrift = errors.New("local clock drift exceeds the allowed maximum")
+
+// Checker periodically measures the drift of the local clock against an NTP
+// server. Miners consult it before mining because blocks stamped with a badly
+// wrong time are rejected by the rest of the network. To stop a Checker cancel
+// the context passed in Start() or call Stop().
+type Checker struct {
+	// Config
+	// Server is the NTP server queried, as host or host:port.
+	Server string
+	// Period is the interval between drift measurements.
+	Period time.Duration
+	// MaxDrift is the largest drift in either direction considered healthy.
+	MaxDrift time.Duration
+	// QueryTimeout bounds how long a single measurement may take.
+	QueryTimeout time.Duration
+
+	// Dependencies
+	// Query does the measurement. Usually QueryOffset.
+	Query func(ctx context.Context, server string) (time.Duration, error)
+
+	// Bookkeeping
+	lk       sync.Mutex
+	offset   time.Duration
+	measured bool
+	cancel   context.CancelFunc
+}
+
+// NewChecker returns a new Checker measuring the local clock against server.
+func NewChecker(server string, period, maxDrift time.Duration) *Checker {
+	return &Checker{
+		Server:       server,
+		Period:       period,
+		MaxDrift:     maxDrift,
+		QueryTimeout: 5 * time.Second,
+		Query:        QueryOffset,
+	}
+}
+
+// Start measures the drift right away and then once every Period. Cancel
+// `ctx` or call Stop() to stop it.
+func (c *Checker) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+
+	go func() {
+		ticker := time.NewTicker(c.Period)
+		defer ticker.Stop()
+
+		for {
+			if err := c.Check(ctx); err != nil {
+				log.Warningf("failed to check clock drift against %s: %s", c.Server, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the Checker.
+func (c *Checker) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// Check takes a single drift measurement and records it. A failed
+// measurement leaves the previous one in place.
+func (c *Checker) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.QueryTimeout)
+	defer cancel()
+
+	offset, err := c.Query(ctx, c.Server)
+	if err != nil {
+		return err
+	}
+
+	c.lk.Lock()
+	c.offset = offset
+	c.measured = true
+	c.lk.Unlock()
+
+	if abs(offset) > c.MaxDrift {
+		log.Errorf("local clock is off by %s from %s, more than the allowed %s", offset, c.Server, c.MaxDrift)
+	}
+	return nil
+}
+
+// Offset returns the most recently measured offset of the local clock and
+// whether any measurement has succeeded yet.
+func (c *Checker) Offset() (time.Duration, bool) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return c.offset, c.measured
+}
+
+// Err returns ErrExcessiveDrift if the most recent measurement found the
+// local clock to be off by more than MaxDrift. It returns nil if no
+// measurement has succeeded, since an unknown drift is not known to be bad.
+func (c *Checker) Err() error {
+	offset, measured := c.Offset()
+	if measured && abs(offset) > c.MaxDrift {
+		return errors.Wrapf(ErrExcessiveDrift, "clock is off by %s (max %s)", offset, c.MaxDrift)
+	}
+	return nil
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
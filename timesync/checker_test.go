@@ -0,0 +1,101 @@
+package timesync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestCheckerErr(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	t.Run("no error before any measurement", func(t *testing.T) {
+		c := NewChecker("ntp.example", time.Minute, time.Second)
+		c.Query = func(context.Context, string) (time.Duration, error) {
+			return 0, errors.New("unreachable")
+		}
+
+		assert.Error(t, c.Check(ctx))
+		_, measured := c.Offset()
+		assert.False(t, measured)
+		assert.NoError(t, c.Err())
+	})
+
+	t.Run("no error within max drift", func(t *testing.T) {
+		c := NewChecker("ntp.example", time.Minute, time.Second)
+		c.Query = func(context.Context, string) (time.Duration, error) {
+			return -500 * time.Millisecond, nil
+		}
+
+		require.NoError(t, c.Check(ctx))
+		assert.NoError(t, c.Err())
+	})
+
+	t.Run("excessive drift in either direction", func(t *testing.T) {
+		for _, drift := range []time.Duration{2 * time.Second, -2 * time.Second} {
+			c := NewChecker("ntp.example", time.Minute, time.Second)
+			c.Query = func(context.Context, string) (time.Duration, error) {
+				return drift, nil
+			}
+
+			require.NoError(t, c.Check(ctx))
+			assert.Equal(t, ErrExcessiveDrift, errors.Cause(c.Err()))
+		}
+	})
+
+	t.Run("failed measurement keeps the previous one", func(t *testing.T) {
+		c := NewChecker("ntp.example", time.Minute, time.Second)
+		c.Query = func(context.Context, string) (time.Duration, error) {
+			return time.Minute, nil
+		}
+		require.NoError(t, c.Check(ctx))
+
+		c.Query = func(context.Context, string) (time.Duration, error) {
+			return 0, errors.New("unreachable")
+		}
+		assert.Error(t, c.Check(ctx))
+
+		offset, measured := c.Offset()
+		assert.True(t, measured)
+		assert.Equal(t, time.Minute, offset)
+		assert.Error(t, c.Err())
+	})
+}
+
+func TestCheckerStartAndStop(t *testing.T) {
+	tf.UnitTest(t)
+
+	// Start should measure right away and then periodically until stopped.
+	var lk sync.Mutex
+	calls := 0
+	c := NewChecker("ntp.example", 10*time.Millisecond, time.Second)
+	c.Query = func(context.Context, string) (time.Duration, error) {
+		lk.Lock()
+		defer lk.Unlock()
+		calls++
+		return 0, nil
+	}
+
+	c.Start(context.Background())
+	time.Sleep(100 * time.Millisecond)
+	c.Stop()
+
+	lk.Lock()
+	stoppedAt := calls
+	lk.Unlock()
+	assert.True(t, stoppedAt > 1)
+
+	time.Sleep(50 * time.Millisecond)
+	lk.Lock()
+	defer lk.Unlock()
+	assert.True(t, calls <= stoppedAt+1)
+}
@@ -0,0 +1,108 @@
+package timesync
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ntpPort is the well known port NTP servers listen on.
+	ntpPort = "123"
+	// ntpPacketSize is the size of an NTP packet without extensions.
+	ntpPacketSize = 48
+	// ntpEpochOffset is the number of seconds between the NTP epoch (1900)
+	// and the unix epoch (1970).
+	ntpEpochOffset = 2208988800
+
+	// sntpClientRequest sets leap indicator 0, version 4 and mode 3 (client).
+	sntpClientRequest = 0<<6 | 4<<3 | 3
+	// sntpServerMode is the mode a server answers a client request with.
+	sntpServerMode = 4
+
+	originTimeOffset   = 24
+	receiveTimeOffset  = 32
+	transmitTimeOffset = 40
+)
+
+// QueryOffset asks the NTP server at addr for the current time using SNTP
+// (RFC 4330) and returns the estimated offset of the local clock from the
+// server's. A positive offset means the local clock is behind. If addr does
+// not include a port the NTP port is used.
+func QueryOffset(ctx context.Context, addr string) (time.Duration, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, ntpPort)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to dial ntp server %s", addr)
+	}
+	defer conn.Close() // nolint: errcheck
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return 0, err
+		}
+	}
+
+	req := make([]byte, ntpPacketSize)
+	req[0] = sntpClientRequest
+	sent := time.Now()
+	putNTPTime(req[transmitTimeOffset:], sent)
+
+	if _, err := conn.Write(req); err != nil {
+		return 0, errors.Wrap(err, "failed to send ntp request")
+	}
+
+	resp := make([]byte, ntpPacketSize)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read ntp response")
+	}
+	received := time.Now()
+
+	return offsetFromResponse(req, resp[:n], sent, received)
+}
+
+// offsetFromResponse validates an SNTP response to req and computes the clock
+// offset from it and the local send and receive times.
+func offsetFromResponse(req, resp []byte, sent, received time.Time) (time.Duration, error) {
+	if len(resp) < ntpPacketSize {
+		return 0, errors.Errorf("ntp response too short: %d bytes", len(resp))
+	}
+	if mode := resp[0] & 0x7; mode != sntpServerMode {
+		return 0, errors.Errorf("unexpected ntp response mode %d", mode)
+	}
+	// A stratum of zero is a "kiss-o'-death" telling us to back off.
+	if stratum := resp[1]; stratum == 0 {
+		return 0, errors.New("ntp server sent kiss-o'-death")
+	}
+	if string(resp[originTimeOffset:originTimeOffset+8]) != string(req[transmitTimeOffset:transmitTimeOffset+8]) {
+		return 0, errors.New("ntp response does not match request")
+	}
+
+	serverReceived := getNTPTime(resp[receiveTimeOffset:])
+	serverTransmitted := getNTPTime(resp[transmitTimeOffset:])
+
+	return (serverReceived.Sub(sent) + serverTransmitted.Sub(received)) / 2, nil
+}
+
+// putNTPTime writes t into b as a 64 bit NTP timestamp.
+func putNTPTime(b []byte, t time.Time) {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := (uint64(t.Nanosecond()) << 32) / uint64(time.Second)
+	binary.BigEndian.PutUint64(b, secs<<32|frac)
+}
+
+// getNTPTime reads a 64 bit NTP timestamp from b.
+func getNTPTime(b []byte) time.Time {
+	v := binary.BigEndian.Uint64(b)
+	secs := int64(v>>32) - ntpEpochOffset
+	nanos := ((v & 0xffffffff) * uint64(time.Second)) >> 32
+	return time.Unix(secs, int64(nanos))
+}
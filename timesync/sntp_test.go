@@ -0,0 +1,97 @@
+package timesync
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+// startFakeNTPServer answers a single SNTP request with a clock that is ahead
+// of the local one by skew. mutate may corrupt the response.
+func startFakeNTPServer(t *testing.T, skew time.Duration, mutate func([]byte)) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		defer conn.Close() // nolint: errcheck
+
+		req := make([]byte, ntpPacketSize)
+		_, from, err := conn.ReadFrom(req)
+		if err != nil {
+			return
+		}
+
+		resp := make([]byte, ntpPacketSize)
+		resp[0] = 4<<3 | sntpServerMode
+		resp[1] = 1 // stratum
+		copy(resp[originTimeOffset:originTimeOffset+8], req[transmitTimeOffset:transmitTimeOffset+8])
+		now := time.Now().Add(skew)
+		putNTPTime(resp[receiveTimeOffset:], now)
+		putNTPTime(resp[transmitTimeOffset:], now)
+		if mutate != nil {
+			mutate(resp)
+		}
+
+		_, _ = conn.WriteTo(resp, from)
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestNTPTimeRoundTrip(t *testing.T) {
+	tf.UnitTest(t)
+
+	b := make([]byte, 8)
+	now := time.Now()
+	putNTPTime(b, now)
+
+	// Converting to and from NTP fractions truncates, so allow for losing
+	// a nanosecond.
+	lost := now.Sub(getNTPTime(b))
+	assert.True(t, lost >= 0 && lost <= time.Nanosecond)
+}
+
+func TestQueryOffset(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("measures a clock ahead of ours", func(t *testing.T) {
+		addr := startFakeNTPServer(t, time.Hour, nil)
+
+		offset, err := QueryOffset(ctx, addr)
+		require.NoError(t, err)
+		assert.InDelta(t, float64(time.Hour), float64(offset), float64(time.Second))
+	})
+
+	t.Run("measures a clock behind ours", func(t *testing.T) {
+		addr := startFakeNTPServer(t, -time.Hour, nil)
+
+		offset, err := QueryOffset(ctx, addr)
+		require.NoError(t, err)
+		assert.InDelta(t, float64(-time.Hour), float64(offset), float64(time.Second))
+	})
+
+	t.Run("rejects kiss-o'-death", func(t *testing.T) {
+		addr := startFakeNTPServer(t, 0, func(resp []byte) { resp[1] = 0 })
+
+		_, err := QueryOffset(ctx, addr)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "kiss-o'-death")
+	})
+
+	t.Run("rejects a response to another request", func(t *testing.T) {
+		addr := startFakeNTPServer(t, 0, func(resp []byte) { resp[originTimeOffset]++ })
+
+		_, err := QueryOffset(ctx, addr)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match")
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/commands"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 
 	"github.com/ipfs/go-cid"
@@ -27,11 +28,11 @@ func (f *Filecoin) ClientCat(ctx context.Context, cid cid.Cid) (io.ReadCloser, e
 
 // ClientImport runs the client import data command against the filecoin process.
 func (f *Filecoin) ClientImport(ctx context.Context, data files.File) (cid.Cid, error) {
-	var out cid.Cid
+	var out commands.ClientImportResult
 	if err := f.RunCmdJSONWithStdin(ctx, data, &out, "go-filecoin", "client", "import"); err != nil {
 		return cid.Undef, err
 	}
-	return out, nil
+	return out.Cid, nil
 }
 
 // ClientProposeStorageDeal runs the client propose-storage-deal command against the filecoin process.
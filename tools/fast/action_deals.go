@@ -0,0 +1,20 @@
+package fast
+
+import (
+	"context"
+
+	cid "github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+)
+
+// DealsShow runs the `deals show` command against the filecoin process
+func (f *Filecoin) DealsShow(ctx context.Context, dealCid cid.Cid) (*storagedeal.Deal, error) {
+	var out storagedeal.Deal
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, "go-filecoin", "deals", "show", dealCid.String()); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
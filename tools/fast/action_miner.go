@@ -2,6 +2,7 @@ package fast
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
 
@@ -67,6 +68,79 @@ func (f *Filecoin) MinerOwner(ctx context.Context, minerAddr address.Address) (a
 	return out, nil
 }
 
+// MinerWorker runs the `miner worker` command against the filecoin process
+func (f *Filecoin) MinerWorker(ctx context.Context, minerAddr address.Address) (address.Address, error) {
+	var out address.Address
+
+	sMinerAddr := minerAddr.String()
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, "go-filecoin", "miner", "worker", sMinerAddr); err != nil {
+		return address.Undef, err
+	}
+
+	return out, nil
+}
+
+// MinerChangeWorker runs the `miner change-worker` command against the filecoin process
+func (f *Filecoin) MinerChangeWorker(ctx context.Context, workerAddr address.Address, options ...ActionOption) (cid.Cid, error) {
+	var out cid.Cid
+
+	args := []string{"go-filecoin", "miner", "change-worker"}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	args = append(args, workerAddr.String())
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
+		return cid.Undef, err
+	}
+
+	return out, nil
+}
+
+// MinerAskLs runs the `miner ask ls` command against the filecoin process
+func (f *Filecoin) MinerAskLs(ctx context.Context, options ...ActionOption) (*json.Decoder, error) {
+	args := []string{"go-filecoin", "miner", "ask", "ls"}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	return f.RunCmdLDJSONWithStdin(ctx, nil, args...)
+}
+
+// MinerDealsLs runs the `miner deals ls` command against the filecoin process
+func (f *Filecoin) MinerDealsLs(ctx context.Context, options ...ActionOption) (*json.Decoder, error) {
+	args := []string{"go-filecoin", "miner", "deals", "ls"}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	return f.RunCmdLDJSONWithStdin(ctx, nil, args...)
+}
+
+// MinerAskRm runs the `miner ask rm` command against the filecoin process
+func (f *Filecoin) MinerAskRm(ctx context.Context, askID uint64, options ...ActionOption) (cid.Cid, error) {
+	var out cid.Cid
+
+	args := []string{"go-filecoin", "miner", "ask", "rm"}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	args = append(args, fmt.Sprintf("%d", askID))
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
+		return cid.Undef, err
+	}
+
+	return out, nil
+}
+
 // MinerPledge runs the `miner pledge` command against the filecoin process
 func (f *Filecoin) MinerPledge(ctx context.Context, minerAddr address.Address) (*big.Int, error) {
 	var out big.Int
@@ -148,3 +148,37 @@ func (f *Filecoin) PaychVoucher(ctx context.Context, channel *types.ChannelID, a
 
 	return out, nil
 }
+
+// PaychVouchersExport runs the `paych vouchers export` command against the filecoin process.
+func (f *Filecoin) PaychVouchersExport(ctx context.Context, channel *types.ChannelID, options ...ActionOption) (string, error) {
+	var out string
+
+	args := []string{"go-filecoin", "paych", "vouchers", "export", channel.String()}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+// PaychVouchersImport runs the `paych vouchers import` command against the filecoin process.
+func (f *Filecoin) PaychVouchersImport(ctx context.Context, vouchers string, options ...ActionOption) ([]*types.PaymentVoucher, error) {
+	var out []*types.PaymentVoucher
+
+	args := []string{"go-filecoin", "paych", "vouchers", "import", vouchers}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
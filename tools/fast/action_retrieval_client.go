@@ -11,8 +11,14 @@ import (
 )
 
 // RetrievalClientRetrievePiece runs the retrieval-client retrieve-piece commands against the filecoin process.
-func (f *Filecoin) RetrievalClientRetrievePiece(ctx context.Context, pieceCID cid.Cid, minerAddr address.Address) (io.ReadCloser, error) {
-	out, err := f.RunCmdWithStdin(ctx, nil, "go-filecoin", "retrieval-client", "retrieve-piece", minerAddr.String(), pieceCID.String())
+func (f *Filecoin) RetrievalClientRetrievePiece(ctx context.Context, pieceCID cid.Cid, minerAddr address.Address, options ...ActionOption) (io.ReadCloser, error) {
+	args := []string{"go-filecoin", "retrieval-client", "retrieve-piece", minerAddr.String(), pieceCID.String()}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	out, err := f.RunCmdWithStdin(ctx, nil, args...)
 	if err != nil {
 		return nil, err
 	}
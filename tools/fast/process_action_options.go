@@ -115,3 +115,26 @@ func AOValidAt(bh *types.BlockHeight) ActionOption {
 		return []string{"--validat", sBH}
 	}
 }
+
+// AOPath provides the `--path=<path>` option to actions
+func AOPath(path string) ActionOption {
+	return func() []string {
+		return []string{"--path", path}
+	}
+}
+
+// AOChannel provides the `--channel=<id>` option to actions
+func AOChannel(channel *types.ChannelID) ActionOption {
+	sChannel := channel.String()
+	return func() []string {
+		return []string{"--channel", sChannel}
+	}
+}
+
+// AOMaxPrice provides the `--max-price=<fil>` option to actions
+func AOMaxPrice(price *big.Float) ActionOption {
+	sPrice := price.Text('f', -1)
+	return func() []string {
+		return []string{"--max-price", sPrice}
+	}
+}
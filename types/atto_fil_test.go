@@ -173,6 +173,15 @@ func TestMulInt(t *testing.T) {
 	})
 }
 
+func TestDivBigInt(t *testing.T) {
+	tf.UnitTest(t)
+
+	x := AttoFIL{val: big.NewInt(200)}
+
+	assert.Equal(t, NewAttoFIL(big.NewInt(20)), x.DivBigInt(big.NewInt(10)))
+	assert.Equal(t, NewAttoFIL(big.NewInt(22)), x.DivBigInt(big.NewInt(9)))
+}
+
 func TestDivCeil(t *testing.T) {
 	tf.UnitTest(t)
 
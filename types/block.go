@@ -54,6 +54,22 @@ type Block struct {
 	// a challenge
 	Proof PoStProof `json:"proof"`
 
+	// Timestamp is the unix time in seconds at which the block was generated.
+	// It may not precede its parents' timestamps and may not lie too far in
+	// the future. It is omitted when zero so that blocks predating the field,
+	// such as existing genesis blocks, keep their cids.
+	Timestamp Uint64 `json:"timestamp" refmt:",omitempty"`
+
+	// ProtocolVersion is the version of the protocol the block was mined
+	// under, which must be the version the network runs at the block's
+	// height. It is omitted when zero so that genesis blocks keep their cids.
+	ProtocolVersion Uint64 `json:"protocolVersion" refmt:",omitempty"`
+
+	// BlockSig is the signature of the miner's worker over the rest of the
+	// block, see SignatureData. Only the genesis block is unsigned, and it is
+	// omitted there so that genesis blocks keep their cids.
+	BlockSig Signature `json:"blockSig" refmt:",omitempty"`
+
 	cachedCid cid.Cid
 
 	cachedBytes []byte
@@ -96,6 +112,21 @@ func (b *Block) Cid() cid.Cid {
 	return b.cachedCid
 }
 
+// SignatureData returns the bytes the miner's worker signs to produce
+// BlockSig: the encoding of the block without its signature.
+func (b *Block) SignatureData() []byte {
+	unsigned := *b
+	unsigned.BlockSig = nil
+	unsigned.cachedCid = cid.Undef
+	unsigned.cachedBytes = nil
+
+	data, err := cbor.DumpObject(&unsigned)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
 // IsParentOf returns true if the argument is a parent of the receiver.
 func (b Block) IsParentOf(c Block) bool {
 	return c.Parents.Has(b.Cid())
@@ -72,15 +72,44 @@ func TestTriangleEncoding(t *testing.T) {
 			ParentWeight:    Uint64(1000),
 			Proof:           NewTestPoSt(),
 			StateRoot:       SomeCid(),
+			Timestamp:       Uint64(1),
+			ProtocolVersion: Uint64(1),
+			BlockSig:        []byte{0x04, 0x05, 0x06},
 		}
 		s := reflect.TypeOf(*b)
 		// This check is here to request that you add a non-zero value for new fields
 		// to the above (and update the field count below).
-		require.Equal(t, 12, s.NumField()) // Note: this also counts private fields
+		require.Equal(t, 15, s.NumField()) // Note: this also counts private fields
 		testRoundTrip(t, b)
 	})
 }
 
+func TestBlockSignatureData(t *testing.T) {
+	tf.UnitTest(t)
+
+	addr := mockSigner.Addresses[0]
+	b := &Block{
+		Miner:     addr,
+		Height:    Uint64(1),
+		StateRoot: SomeCid(),
+	}
+	unsignedCid := b.Cid()
+
+	sig, err := mockSigner.SignBytes(b.SignatureData(), addr)
+	require.NoError(t, err)
+	signed := *b
+	signed.BlockSig = sig
+	signed.cachedCid = cid.Undef
+	signed.cachedBytes = nil
+
+	assert.Equal(t, b.SignatureData(), signed.SignatureData())
+	assert.NotEqual(t, unsignedCid, signed.Cid())
+	assert.True(t, IsValidSignature(signed.SignatureData(), addr, signed.BlockSig))
+
+	signed.Height = Uint64(2)
+	assert.False(t, IsValidSignature(signed.SignatureData(), addr, signed.BlockSig))
+}
+
 func TestBlockIsParentOf(t *testing.T) {
 	tf.UnitTest(t)
 
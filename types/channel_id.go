@@ -75,6 +75,11 @@ func (z *ChannelID) Equal(y *ChannelID) bool {
 	return z.val.Cmp(y.val) == 0
 }
 
+// IsNegative returns true if z is less than zero.
+func (z *ChannelID) IsNegative() bool {
+	return z.val.Sign() < 0
+}
+
 // String returns a string version of the ID
 func (z *ChannelID) String() string {
 	return z.val.String()
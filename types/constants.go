@@ -1,6 +1,8 @@
 package types
 
 import (
+	"fmt"
+
 	"github.com/ipfs/go-cid"
 	ipld "github.com/ipfs/go-ipld-format"
 	dag "github.com/ipfs/go-merkledag"
@@ -40,20 +42,52 @@ var BootstrapMinerActorCodeObj ipld.Node
 // BootstrapMinerActorCodeCid is the cid of the above object
 var BootstrapMinerActorCodeCid cid.Cid
 
+// MultisigActorCodeObj is the code representation of the builtin multisig actor.
+var MultisigActorCodeObj ipld.Node
+
+// MultisigActorCodeCid is the cid of the above object
+var MultisigActorCodeCid cid.Cid
+
+// VestingActorCodeObj is the code representation of the builtin vesting actor.
+var VestingActorCodeObj ipld.Node
+
+// VestingActorCodeCid is the cid of the above object
+var VestingActorCodeCid cid.Cid
+
+// InitActorCodeObj is the code representation of the builtin init actor.
+var InitActorCodeObj ipld.Node
+
+// InitActorCodeCid is the cid of the above object
+var InitActorCodeCid cid.Cid
+
+// FaucetActorCodeObj is the code representation of the builtin faucet actor.
+var FaucetActorCodeObj ipld.Node
+
+// FaucetActorCodeCid is the cid of the above object
+var FaucetActorCodeCid cid.Cid
+
 // ActorCodeCidTypeNames maps Actor codeCid's to the name of the associated Actor type.
 var ActorCodeCidTypeNames = make(map[cid.Cid]string)
 
 func init() {
-	AccountActorCodeObj = dag.NewRawNode([]byte("accountactor"))
+	AccountActorCodeObj = NewVersionedActorCodeObj("accountactor", 1)
 	AccountActorCodeCid = AccountActorCodeObj.Cid()
-	StorageMarketActorCodeObj = dag.NewRawNode([]byte("storagemarket"))
+	StorageMarketActorCodeObj = NewVersionedActorCodeObj("storagemarket", 1)
 	StorageMarketActorCodeCid = StorageMarketActorCodeObj.Cid()
-	PaymentBrokerActorCodeObj = dag.NewRawNode([]byte("paymentbroker"))
+	PaymentBrokerActorCodeObj = NewVersionedActorCodeObj("paymentbroker", 1)
 	PaymentBrokerActorCodeCid = PaymentBrokerActorCodeObj.Cid()
-	MinerActorCodeObj = dag.NewRawNode([]byte("mineractor"))
+	MinerActorCodeObj = NewVersionedActorCodeObj("mineractor", 1)
 	MinerActorCodeCid = MinerActorCodeObj.Cid()
-	BootstrapMinerActorCodeObj = dag.NewRawNode([]byte("bootstrapmineractor"))
+	BootstrapMinerActorCodeObj = NewVersionedActorCodeObj("bootstrapmineractor", 1)
 	BootstrapMinerActorCodeCid = BootstrapMinerActorCodeObj.Cid()
+	MultisigActorCodeObj = NewVersionedActorCodeObj("multisigactor", 1)
+	MultisigActorCodeCid = MultisigActorCodeObj.Cid()
+	VestingActorCodeObj = NewVersionedActorCodeObj("vestingactor", 1)
+	VestingActorCodeCid = VestingActorCodeObj.Cid()
+	InitActorCodeObj = NewVersionedActorCodeObj("initactor", 1)
+	InitActorCodeCid = InitActorCodeObj.Cid()
+	FaucetActorCodeObj = NewVersionedActorCodeObj("faucetactor", 1)
+	FaucetActorCodeCid = FaucetActorCodeObj.Cid()
 
 	// New Actors need to be added here.
 	// TODO: Make this work with reflection -- but note that nasty import cycles lie on that path.
@@ -63,6 +97,21 @@ func init() {
 	ActorCodeCidTypeNames[PaymentBrokerActorCodeCid] = "PaymentBrokerActor"
 	ActorCodeCidTypeNames[MinerActorCodeCid] = "MinerActor"
 	ActorCodeCidTypeNames[BootstrapMinerActorCodeCid] = "MinerActor"
+	ActorCodeCidTypeNames[MultisigActorCodeCid] = "MultisigActor"
+	ActorCodeCidTypeNames[VestingActorCodeCid] = "VestingActor"
+	ActorCodeCidTypeNames[InitActorCodeCid] = "InitActor"
+	ActorCodeCidTypeNames[FaucetActorCodeCid] = "FaucetActor"
+}
+
+// NewVersionedActorCodeObj returns the code representation of the given
+// version of the builtin actor called name. Version 1 is represented by the
+// bare name, so the code cids of actors that predate versioning are
+// unchanged.
+func NewVersionedActorCodeObj(name string, version uint64) ipld.Node {
+	if version <= 1 {
+		return dag.NewRawNode([]byte(name))
+	}
+	return dag.NewRawNode([]byte(fmt.Sprintf("%s/v%d", name, version)))
 }
 
 // ActorCodeTypeName returns the (string) name of the Go type of the actor with cid, code.
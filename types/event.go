@@ -0,0 +1,24 @@
+package types
+
+import (
+	cbor "github.com/ipfs/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/address"
+)
+
+func init() {
+	cbor.RegisterCborType(Event{})
+}
+
+// Event is something an actor emits while processing a message so that
+// clients can observe it without polling the actor's state. Events are
+// committed in the receipt of the message.
+type Event struct {
+	// Emitter is the address of the actor that emitted the event.
+	Emitter address.Address `json:"emitter"`
+	// Topic names what happened, e.g. "redeem".
+	Topic string `json:"topic"`
+	// Payload is data the actor attaches to the event; its meaning depends
+	// on the emitter and topic.
+	Payload []byte `json:"payload"`
+}
@@ -6,6 +6,7 @@ import (
 	cbor "github.com/ipfs/go-ipld-cbor"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/crypto"
 )
 
@@ -56,13 +57,23 @@ func (ki *KeyInfo) Equals(other *KeyInfo) bool {
 	return bytes.Equal(ki.PrivateKey, other.PrivateKey)
 }
 
-// Address returns the address for this keyinfo
+// Address returns the address for this keyinfo, a BLS address for BLS keys
+// and a secp256k1 address otherwise.
 func (ki *KeyInfo) Address() (address.Address, error) {
-	// TODO: Use the address type we are running on from the config.
+	if ki.Curve == BLS {
+		return address.NewBLSAddress(ki.PublicKey())
+	}
 	return address.NewSecp256k1Address(ki.PublicKey())
 }
 
-// PublicKey returns the public key part as uncompressed bytes.
+// PublicKey returns the public key part, compressed for BLS keys and
+// uncompressed otherwise.
 func (ki *KeyInfo) PublicKey() []byte {
+	if ki.Curve == BLS {
+		var priv bls.PrivateKey
+		copy(priv[:], ki.PrivateKey)
+		pub := bls.PrivateKeyPublicKey(priv)
+		return pub[:]
+	}
 	return crypto.PublicKey(ki.PrivateKey)
 }
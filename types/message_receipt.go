@@ -10,9 +10,16 @@ func init() {
 
 // MessageReceipt represents the result of sending a message.
 type MessageReceipt struct {
-	// `0` is success, anything else is an error code in unix style.
+	// `0` is success, anything else is an error code in unix style. Codes
+	// above vm errors.ReservedErrors are defined by the actor the message was
+	// sent to, in its Errors map.
 	ExitCode uint8 `json:"exitCode"`
 
+	// Error is the message of the error the message failed with, such as the
+	// revert message an actor returned along with the exit code. It is empty
+	// on success.
+	Error string `json:"error,omitempty" refmt:",omitempty"`
+
 	// Return contains the return values, if any, from processing a message.
 	// This can be non-empty even in the case of error (e.g., to provide
 	// programmatically readable detail about errors).
@@ -20,4 +27,8 @@ type MessageReceipt struct {
 
 	// GasAttoFIL Charge is the actual amount of FIL transferred from the sender to the miner for processing the message
 	GasAttoFIL *AttoFIL `json:"gasAttoFIL"`
+
+	// Events are the events actors emitted while processing the message, in
+	// the order they were emitted.
+	Events []*Event `json:"events,omitempty" refmt:",omitempty"`
 }
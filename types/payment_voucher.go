@@ -1,8 +1,12 @@
 package types
 
 import (
+	"bytes"
+	"crypto/sha256"
+
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/multiformats/go-multibase"
+	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
 )
@@ -49,28 +53,105 @@ type PaymentVoucher struct {
 	Signature Signature `json:"signature"`
 }
 
-// DecodeVoucher creates a *PaymentVoucher from a base58, Cbor-encoded one
+// VoucherFormatVersion is the version of the string encoding of vouchers
+// Encode produces.
+const VoucherFormatVersion = 1
+
+// voucherChecksumLen is the length of the checksum ending encoded vouchers.
+const voucherChecksumLen = 4
+
+var (
+	// ErrVoucherChecksum is returned when decoding a voucher whose checksum
+	// does not match its content, e.g. because it was truncated or mistyped.
+	ErrVoucherChecksum = errors.New("voucher checksum does not match")
+	// ErrUnknownVoucherVersion is returned when decoding a voucher encoded in
+	// a format version this node does not know.
+	ErrUnknownVoucherVersion = errors.New("unknown voucher format version")
+)
+
+// DecodeVoucher creates a *PaymentVoucher from its string encoding created by
+// Encode. Vouchers in the legacy base58, Cbor-encoded format are accepted too.
 func DecodeVoucher(voucherRaw string) (*PaymentVoucher, error) {
-	_, cborVoucher, err := multibase.Decode(voucherRaw)
+	voucher, _, err := DecodeVoucherWithVersion(voucherRaw)
+	return voucher, err
+}
+
+// DecodeVoucherWithVersion is DecodeVoucher also returning the format version
+// the voucher was encoded in, 0 for the legacy format.
+func DecodeVoucherWithVersion(voucherRaw string) (*PaymentVoucher, uint64, error) {
+	base, data, err := multibase.Decode(voucherRaw)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	version := uint64(0)
+	cborVoucher := data
+	if base != multibase.Base58BTC {
+		if base != multibase.Base64url || len(data) < 1+voucherChecksumLen {
+			return nil, 0, errors.New("malformed voucher")
+		}
+		body, sum := data[:len(data)-voucherChecksumLen], data[len(data)-voucherChecksumLen:]
+		if !bytes.Equal(sum, voucherChecksum(body)) {
+			return nil, 0, ErrVoucherChecksum
+		}
+		version = uint64(body[0])
+		if version != VoucherFormatVersion {
+			return nil, version, ErrUnknownVoucherVersion
+		}
+		cborVoucher = body[1:]
 	}
 
 	var voucher PaymentVoucher
 	err = cbor.DecodeInto(cborVoucher, &voucher)
 	if err != nil {
-		return nil, err
+		return nil, version, err
 	}
 
-	return &voucher, nil
+	return &voucher, version, nil
 }
 
-// Encode creates a base58, Cbor-encoded string representation
+// Encode creates a compact string representation of the voucher: the format
+// version, the Cbor-encoded voucher and a checksum, base64url encoded.
 func (voucher *PaymentVoucher) Encode() (string, error) {
 	cborVoucher, err := cbor.DumpObject(voucher)
 	if err != nil {
 		return "", err
 	}
 
-	return multibase.Encode(multibase.Base58BTC, cborVoucher)
+	body := append([]byte{VoucherFormatVersion}, cborVoucher...)
+	return multibase.Encode(multibase.Base64url, append(body, voucherChecksum(body)...))
+}
+
+// voucherChecksum is the first bytes of the sha256 digest of data.
+func voucherChecksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:voucherChecksumLen]
+}
+
+// DecodeVouchers creates a slice of *PaymentVoucher from a base58, Cbor-encoded
+// bundle created by EncodeVouchers.
+func DecodeVouchers(vouchersRaw string) ([]*PaymentVoucher, error) {
+	_, cborVouchers, err := multibase.Decode(vouchersRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	var vouchers []*PaymentVoucher
+	err = cbor.DecodeInto(cborVouchers, &vouchers)
+	if err != nil {
+		return nil, err
+	}
+
+	return vouchers, nil
+}
+
+// EncodeVouchers creates a base58, Cbor-encoded string representation of a
+// bundle of vouchers, suitable for delivering them out of band.
+func EncodeVouchers(vouchers []*PaymentVoucher) (string, error) {
+	cborVouchers, err := cbor.DumpObject(vouchers)
+	if err != nil {
+		return "", err
+	}
+
+	return multibase.Encode(multibase.Base58BTC, cborVouchers)
 }
@@ -3,6 +3,8 @@ package types
 import (
 	"testing"
 
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/multiformats/go-multibase"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -44,3 +46,104 @@ func TestPaymentVoucherEncodingRoundTrip(t *testing.T) {
 	assert.Equal(t, condition.Method, decodedPaymentVoucher.Condition.Method)
 	assert.Equal(t, condition.Params, decodedPaymentVoucher.Condition.Params)
 }
+
+func TestPaymentVouchersEncodingRoundTrip(t *testing.T) {
+	addrGetter := address.NewForTestGetter()
+	payer := addrGetter()
+	target := addrGetter()
+
+	vouchers := []*PaymentVoucher{
+		{
+			Channel:   *NewChannelID(5),
+			Payer:     payer,
+			Target:    target,
+			Amount:    *NewAttoFILFromFIL(10),
+			ValidAt:   *NewBlockHeight(25),
+			Signature: Signature("sig1"),
+		},
+		{
+			Channel:   *NewChannelID(5),
+			Payer:     payer,
+			Target:    target,
+			Amount:    *NewAttoFILFromFIL(20),
+			ValidAt:   *NewBlockHeight(30),
+			Signature: Signature("sig2"),
+		},
+	}
+
+	raw, err := EncodeVouchers(vouchers)
+	require.NoError(t, err)
+	decoded, err := DecodeVouchers(raw)
+	require.NoError(t, err)
+
+	require.Len(t, decoded, 2)
+	for i, v := range vouchers {
+		assert.Equal(t, v.Channel, decoded[i].Channel)
+		assert.Equal(t, v.Payer, decoded[i].Payer)
+		assert.Equal(t, v.Target, decoded[i].Target)
+		assert.Equal(t, v.Amount, decoded[i].Amount)
+		assert.Equal(t, v.ValidAt, decoded[i].ValidAt)
+		assert.Equal(t, v.Signature, decoded[i].Signature)
+	}
+}
+
+func TestPaymentVoucherFormat(t *testing.T) {
+	addrGetter := address.NewForTestGetter()
+	voucher := &PaymentVoucher{
+		Channel:   *NewChannelID(5),
+		Payer:     addrGetter(),
+		Target:    addrGetter(),
+		Amount:    *NewAttoFILFromFIL(10),
+		ValidAt:   *NewBlockHeight(25),
+		Signature: Signature("sig"),
+	}
+
+	t.Run("encodes the format version", func(t *testing.T) {
+		raw, err := voucher.Encode()
+		require.NoError(t, err)
+
+		decoded, version, err := DecodeVoucherWithVersion(raw)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(VoucherFormatVersion), version)
+		assert.Equal(t, voucher.Signature, decoded.Signature)
+	})
+
+	t.Run("rejects corrupted vouchers", func(t *testing.T) {
+		raw, err := voucher.Encode()
+		require.NoError(t, err)
+		_, data, err := multibase.Decode(raw)
+		require.NoError(t, err)
+
+		data[len(data)/2] ^= 0xff
+		corrupted, err := multibase.Encode(multibase.Base64url, data)
+		require.NoError(t, err)
+		_, err = DecodeVoucher(corrupted)
+		assert.Equal(t, ErrVoucherChecksum, err)
+
+		_, err = DecodeVoucher(raw[:len(raw)-2])
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects unknown versions", func(t *testing.T) {
+		cborVoucher, err := cbor.DumpObject(voucher)
+		require.NoError(t, err)
+		body := append([]byte{VoucherFormatVersion + 1}, cborVoucher...)
+		raw, err := multibase.Encode(multibase.Base64url, append(body, voucherChecksum(body)...))
+		require.NoError(t, err)
+
+		_, err = DecodeVoucher(raw)
+		assert.Equal(t, ErrUnknownVoucherVersion, err)
+	})
+
+	t.Run("decodes legacy vouchers", func(t *testing.T) {
+		cborVoucher, err := cbor.DumpObject(voucher)
+		require.NoError(t, err)
+		legacy, err := multibase.Encode(multibase.Base58BTC, cborVoucher)
+		require.NoError(t, err)
+
+		decoded, version, err := DecodeVoucherWithVersion(legacy)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), version)
+		assert.Equal(t, voucher.Amount, decoded.Amount)
+	})
+}
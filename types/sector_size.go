@@ -30,3 +30,10 @@ func (s SectorSize) Uint64() uint64 {
 		panic(fmt.Sprintf("unexpected value %v", s))
 	}
 }
+
+// PaddedPieceSize returns the number of bytes a piece of the given number of
+// user bytes takes in a sector once bit-padded: every 254 bits of user data
+// take 256 bits.
+func PaddedPieceSize(unpadded uint64) uint64 {
+	return (unpadded*8 + 253) / 254 * 32
+}
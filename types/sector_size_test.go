@@ -0,0 +1,21 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestPaddedPieceSize(t *testing.T) {
+	tf.UnitTest(t)
+
+	assert.Equal(t, uint64(0), PaddedPieceSize(0))
+	assert.Equal(t, uint64(32), PaddedPieceSize(1))
+	assert.Equal(t, uint64(32), PaddedPieceSize(31))
+	assert.Equal(t, uint64(64), PaddedPieceSize(32))
+	// The user bytes of a whole sector fill it.
+	assert.Equal(t, OneKiBSectorSize.Uint64(), PaddedPieceSize(1016))
+	assert.Equal(t, TwoHundredFiftySixMiBSectorSize.Uint64(), PaddedPieceSize(266338304))
+}
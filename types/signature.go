@@ -4,6 +4,7 @@ import (
 	logging "github.com/ipfs/go-log"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
 	wutil "github.com/filecoin-project/go-filecoin/wallet/util"
 )
 
@@ -13,8 +14,13 @@ var log = logging.Logger("types")
 type Signature []byte
 
 // IsValidSignature cryptographically verifies that 'sig' is the signed hash of 'data' with
-// the public key belonging to `addr`.
+// the public key belonging to `addr`. Signatures for BLS addresses are BLS
+// signatures, all others are secp256k1 signatures.
 func IsValidSignature(data []byte, addr address.Address, sig Signature) bool {
+	if addr.Protocol() == address.BLS {
+		return IsValidBLSSignature(data, addr, sig)
+	}
+
 	maybePk, err := wutil.Ecrecover(data, sig)
 	if err != nil {
 		// Any error returned from Ecrecover means this signature is not valid.
@@ -29,3 +35,22 @@ func IsValidSignature(data []byte, addr address.Address, sig Signature) bool {
 
 	return maybeAddr == addr
 }
+
+// IsValidBLSSignature cryptographically verifies that 'sig' is a BLS signature
+// of 'data' by the key of the BLS address `addr`.
+func IsValidBLSSignature(data []byte, addr address.Address, sig Signature) bool {
+	if addr.Protocol() != address.BLS || len(sig) != bls.SignatureBytes {
+		return false
+	}
+
+	var pubKey bls.PublicKey
+	if len(addr.Payload()) != bls.PublicKeyBytes {
+		return false
+	}
+	copy(pubKey[:], addr.Payload())
+
+	var blsSig bls.Signature
+	copy(blsSig[:], sig)
+
+	return bls.Verify(blsSig, []bls.Digest{bls.Hash(data)}, []bls.PublicKey{pubKey})
+}
@@ -131,6 +131,20 @@ func (ts TipSet) MinTicket() (Signature, error) {
 	return min, nil
 }
 
+// MaxTimestamp returns the latest timestamp of all blocks in the tipset.
+func (ts TipSet) MaxTimestamp() (Uint64, error) {
+	if len(ts) == 0 {
+		return Uint64(0), ErrEmptyTipSet
+	}
+	var max Uint64
+	for _, b := range ts {
+		if b.Timestamp > max {
+			max = b.Timestamp
+		}
+	}
+	return max, nil
+}
+
 // Height returns the height of a tipset.
 func (ts TipSet) Height() (uint64, error) {
 	if len(ts) == 0 {
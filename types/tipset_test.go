@@ -188,6 +188,26 @@ func TestTipSetMinTicket(t *testing.T) {
 	assert.Equal(t, Signature([]byte{0}), mt)
 }
 
+func TestTipSetMaxTimestamp(t *testing.T) {
+	tf.UnitTest(t)
+
+	b1 := block(t, 1, cid1, uint64(1137), "1")
+	b1.Timestamp = 100
+	b2 := block(t, 1, cid1, uint64(1137), "2")
+	b2.Timestamp = 300
+	b3 := block(t, 1, cid1, uint64(1137), "3")
+	b3.Timestamp = 200
+
+	ts, err := NewTipSet(b1, b2, b3)
+	require.NoError(t, err)
+	max, err := ts.MaxTimestamp()
+	assert.NoError(t, err)
+	assert.Equal(t, Uint64(300), max)
+
+	_, err = TipSet{}.MaxTimestamp()
+	assert.Equal(t, ErrEmptyTipSet, err)
+}
+
 func TestTipSetHeight(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -0,0 +1,97 @@
+// Package update checks a published release manifest for newer releases of
+// go-filecoin and for network upgrades this build cannot follow.
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Manifest describes the latest release and the scheduled network upgrades.
+type Manifest struct {
+	// LatestVersion is the human readable name of the latest release.
+	LatestVersion string `json:"latestVersion"`
+	// LatestCommit is the git commit the latest release was built from.
+	LatestCommit string `json:"latestCommit"`
+	// Upgrades lists the network upgrades, on every network.
+	Upgrades []NetworkUpgrade `json:"upgrades"`
+}
+
+// NetworkUpgrade is a block height from which a network requires nodes to
+// implement a given protocol version.
+type NetworkUpgrade struct {
+	// Network is the name of the network, as set in the `net` config key.
+	Network         string `json:"network"`
+	Height          uint64 `json:"height"`
+	ProtocolVersion uint64 `json:"protocolVersion"`
+}
+
+// Result is the outcome of comparing a build against a Manifest.
+type Result struct {
+	LatestVersion   string
+	UpdateAvailable bool
+	// Warnings describes the upgrades this build does not support.
+	Warnings []string
+}
+
+// FetchManifest downloads the manifest at url. The request carries no
+// information about the node making it.
+func FetchManifest(ctx context.Context, url string) (*Manifest, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid manifest url")
+	}
+	req.Header.Set("User-Agent", "go-filecoin")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch release manifest")
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch release manifest: %s", resp.Status)
+	}
+
+	var m Manifest
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, errors.Wrap(err, "failed to decode release manifest")
+	}
+	return &m, nil
+}
+
+// Check compares a build of commit, running on network, with m. An upgrade is
+// supported when the build's protocol version table for the network already
+// runs the upgrade's version at its height.
+func Check(m *Manifest, commit string, network *consensus.Network) *Result {
+	res := &Result{
+		LatestVersion:   m.LatestVersion,
+		UpdateAvailable: m.LatestCommit != "" && m.LatestCommit != commit,
+	}
+
+	for _, upgrade := range m.Upgrades {
+		if upgrade.Network != network.Name {
+			continue
+		}
+		supported := network.VersionAt(types.NewBlockHeight(upgrade.Height))
+		if upgrade.ProtocolVersion <= supported {
+			continue
+		}
+		res.Warnings = append(res.Warnings, fmt.Sprintf(
+			"network upgrade at height %d requires protocol version %d, this build runs %d there: update before then",
+			upgrade.Height, upgrade.ProtocolVersion, supported))
+	}
+
+	return res
+}
@@ -0,0 +1,74 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/consensus"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestCheck(t *testing.T) {
+	tf.UnitTest(t)
+
+	network := &consensus.Network{
+		Name: "devnet-user",
+		ProtocolVersions: consensus.ProtocolVersionTable{
+			{Version: 1, Height: types.NewBlockHeight(0)},
+			{Version: 2, Height: types.NewBlockHeight(150)},
+		},
+	}
+	m := &Manifest{
+		LatestVersion: "0.2.0",
+		LatestCommit:  "abc",
+		Upgrades: []NetworkUpgrade{
+			{Network: "devnet-user", Height: 100, ProtocolVersion: 1},
+			{Network: "devnet-user", Height: 150, ProtocolVersion: 2},
+			{Network: "devnet-user", Height: 200, ProtocolVersion: 3},
+			{Network: "devnet-test", Height: 300, ProtocolVersion: 3},
+		},
+	}
+
+	t.Run("up to date", func(t *testing.T) {
+		res := Check(m, "abc", consensus.NetworkByName("devnet-nightly"))
+		assert.Equal(t, "0.2.0", res.LatestVersion)
+		assert.False(t, res.UpdateAvailable)
+		assert.Empty(t, res.Warnings)
+	})
+
+	t.Run("warns about unsupported upgrades on its network only", func(t *testing.T) {
+		res := Check(m, "def", network)
+		assert.True(t, res.UpdateAvailable)
+		require.Len(t, res.Warnings, 1)
+		assert.Contains(t, res.Warnings[0], "height 200")
+		assert.Contains(t, res.Warnings[0], "runs 2 there")
+	})
+}
+
+func TestFetchManifest(t *testing.T) {
+	tf.UnitTest(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/manifest.json" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"latestVersion":"0.2.0","latestCommit":"abc","upgrades":[{"network":"devnet-user","height":200,"protocolVersion":2}]}`) // nolint: errcheck
+	}))
+	defer server.Close()
+
+	m, err := FetchManifest(context.Background(), server.URL+"/manifest.json")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", m.LatestCommit)
+	assert.Equal(t, []NetworkUpgrade{{Network: "devnet-user", Height: 200, ProtocolVersion: 2}}, m.Upgrades)
+
+	_, err = FetchManifest(context.Background(), server.URL+"/missing")
+	assert.Error(t, err)
+}
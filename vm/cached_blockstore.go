@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs-blockstore"
+
+	"github.com/filecoin-project/go-filecoin/metrics"
+)
+
+var blockCacheHitCt = metrics.NewInt64Counter("vm_block_cache_hit", "Number of actor state blocks read from the VM block cache")
+var blockCacheMissCt = metrics.NewInt64Counter("vm_block_cache_miss", "Number of actor state blocks read from the datastore because they were not in the VM block cache")
+
+// DefaultBlockCacheSize is the number of blocks a CachedBlockstore keeps by
+// default.
+const DefaultBlockCacheSize = 1 << 14
+
+// CachedBlockstore is a blockstore keeping the blocks read most recently in
+// memory. Applying the messages of a block reads the same nodes of actor
+// state over and over, such as the root of the payment broker's channels by
+// payer or the storage market's miner set, which would otherwise be fetched
+// from the datastore for each message. Blocks are immutable, so cached
+// blocks never go stale; deleting a block evicts it. Only reads of whole
+// blocks are cached, Has is always answered by the underlying blockstore.
+type CachedBlockstore struct {
+	blockstore.Blockstore
+
+	lk   sync.Mutex
+	size int
+	// order lists the cached blocks, the most recently read first.
+	order  *list.List
+	blocks map[cid.Cid]*list.Element
+	hits   uint64
+	misses uint64
+}
+
+var _ blockstore.Blockstore = (*CachedBlockstore)(nil)
+
+// NewCachedBlockstore returns a CachedBlockstore over bs keeping up to size
+// blocks.
+func NewCachedBlockstore(bs blockstore.Blockstore, size int) *CachedBlockstore {
+	return &CachedBlockstore{
+		Blockstore: bs,
+		size:       size,
+		order:      list.New(),
+		blocks:     make(map[cid.Cid]*list.Element),
+	}
+}
+
+// Get returns the block from the cache, or reads it from the underlying
+// blockstore and caches it.
+func (bs *CachedBlockstore) Get(c cid.Cid) (blocks.Block, error) {
+	blk, ok := bs.cached(c)
+	bs.record(ok)
+	if ok {
+		return blk, nil
+	}
+
+	blk, err := bs.Blockstore.Get(c)
+	if err != nil {
+		return nil, err
+	}
+	bs.add(blk)
+	return blk, nil
+}
+
+// GetSize returns the size of the block, from the cache if it is there.
+func (bs *CachedBlockstore) GetSize(c cid.Cid) (int, error) {
+	if blk, ok := bs.cached(c); ok {
+		return len(blk.RawData()), nil
+	}
+	return bs.Blockstore.GetSize(c)
+}
+
+// DeleteBlock evicts the block from the cache and deletes it from the
+// underlying blockstore.
+func (bs *CachedBlockstore) DeleteBlock(c cid.Cid) error {
+	bs.lk.Lock()
+	if elem, ok := bs.blocks[c]; ok {
+		bs.order.Remove(elem)
+		delete(bs.blocks, c)
+	}
+	bs.lk.Unlock()
+
+	return bs.Blockstore.DeleteBlock(c)
+}
+
+// Stats returns the number of reads answered from the cache and the number
+// of reads that went to the underlying blockstore.
+func (bs *CachedBlockstore) Stats() (hits uint64, misses uint64) {
+	bs.lk.Lock()
+	defer bs.lk.Unlock()
+	return bs.hits, bs.misses
+}
+
+func (bs *CachedBlockstore) cached(c cid.Cid) (blocks.Block, bool) {
+	bs.lk.Lock()
+	defer bs.lk.Unlock()
+	elem, ok := bs.blocks[c]
+	if !ok {
+		return nil, false
+	}
+	bs.order.MoveToFront(elem)
+	return elem.Value.(blocks.Block), true
+}
+
+// record counts a read answered from the cache if hit, or else one that went
+// to the underlying blockstore.
+func (bs *CachedBlockstore) record(hit bool) {
+	bs.lk.Lock()
+	if hit {
+		bs.hits++
+	} else {
+		bs.misses++
+	}
+	bs.lk.Unlock()
+
+	if hit {
+		blockCacheHitCt.Inc(context.TODO(), 1)
+	} else {
+		blockCacheMissCt.Inc(context.TODO(), 1)
+	}
+}
+
+func (bs *CachedBlockstore) add(blk blocks.Block) {
+	bs.lk.Lock()
+	defer bs.lk.Unlock()
+	if _, ok := bs.blocks[blk.Cid()]; ok {
+		return
+	}
+	bs.blocks[blk.Cid()] = bs.order.PushFront(blk)
+	if bs.order.Len() > bs.size {
+		oldest := bs.order.Back()
+		bs.order.Remove(oldest)
+		delete(bs.blocks, oldest.Value.(blocks.Block).Cid())
+	}
+}
@@ -0,0 +1,71 @@
+package vm
+
+import (
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-ipfs-blockstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestCachedBlockstore(t *testing.T) {
+	tf.UnitTest(t)
+
+	newBlocks := func(t *testing.T, bs blockstore.Blockstore, n int) []blocks.Block {
+		var blks []blocks.Block
+		for i := 0; i < n; i++ {
+			blk := blocks.NewBlock([]byte{byte(i)})
+			require.NoError(t, bs.Put(blk))
+			blks = append(blks, blk)
+		}
+		return blks
+	}
+
+	t.Run("reads blocks read before from the cache", func(t *testing.T) {
+		bs := NewCachedBlockstore(blockstore.NewBlockstore(datastore.NewMapDatastore()), 2)
+		blks := newBlocks(t, bs, 1)
+
+		for i := 0; i < 3; i++ {
+			got, err := bs.Get(blks[0].Cid())
+			require.NoError(t, err)
+			assert.Equal(t, blks[0].RawData(), got.RawData())
+		}
+		hits, misses := bs.Stats()
+		assert.Equal(t, uint64(2), hits)
+		assert.Equal(t, uint64(1), misses)
+
+		size, err := bs.GetSize(blks[0].Cid())
+		require.NoError(t, err)
+		assert.Equal(t, 1, size)
+	})
+
+	t.Run("evicts the least recently read block once full", func(t *testing.T) {
+		bs := NewCachedBlockstore(blockstore.NewBlockstore(datastore.NewMapDatastore()), 2)
+		blks := newBlocks(t, bs, 3)
+
+		for _, i := range []int{0, 1, 0, 2, 0, 1} {
+			_, err := bs.Get(blks[i].Cid())
+			require.NoError(t, err)
+		}
+		// 1 was evicted by 2, while 0 was kept by being read again.
+		hits, misses := bs.Stats()
+		assert.Equal(t, uint64(2), hits)
+		assert.Equal(t, uint64(4), misses)
+	})
+
+	t.Run("evicts deleted blocks", func(t *testing.T) {
+		bs := NewCachedBlockstore(blockstore.NewBlockstore(datastore.NewMapDatastore()), 2)
+		blks := newBlocks(t, bs, 1)
+
+		_, err := bs.Get(blks[0].Cid())
+		require.NoError(t, err)
+		require.NoError(t, bs.DeleteBlock(blks[0].Cid()))
+
+		_, err = bs.Get(blks[0].Cid())
+		assert.Equal(t, blockstore.ErrNotFound, err)
+	})
+}
@@ -17,6 +17,10 @@ import (
 	"github.com/filecoin-project/go-filecoin/vm/errors"
 )
 
+// MaxSendDepth is the maximum depth of the messages actors send while a
+// message is applied, the message applied being at depth 0.
+const MaxSendDepth = 64
+
 // Context is the only thing exposed to an actor while executing.
 // All methods on the Context are ABI methods exposed to actors.
 type Context struct {
@@ -28,6 +32,14 @@ type Context struct {
 	gasTracker  *GasTracker
 	blockHeight *types.BlockHeight
 	ancestors   []types.TipSet
+	tracer      Tracer
+	depth       int
+	// callers are the addresses of the actors executing the methods that sent
+	// the enclosing messages, outermost first.
+	callers []address.Address
+	// events is shared by the contexts of all the messages sent while
+	// applying a message.
+	events *[]*types.Event
 
 	deps *deps // Inject external dependencies so we can unit test robustly.
 }
@@ -44,10 +56,16 @@ type NewContextParams struct {
 	GasTracker  *GasTracker
 	BlockHeight *types.BlockHeight
 	Ancestors   []types.TipSet
+	// Tracer is optional; it is notified of the operations the VM performs.
+	Tracer Tracer
 }
 
 // NewVMContext returns an initialized context.
 func NewVMContext(params NewContextParams) *Context {
+	tracer := params.Tracer
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
 	return &Context{
 		from:        params.From,
 		to:          params.To,
@@ -57,6 +75,8 @@ func NewVMContext(params NewContextParams) *Context {
 		gasTracker:  params.GasTracker,
 		blockHeight: params.BlockHeight,
 		ancestors:   params.Ancestors,
+		tracer:      tracer,
+		events:      new([]*types.Event),
 		deps:        makeDeps(params.State),
 	}
 }
@@ -64,8 +84,12 @@ func NewVMContext(params NewContextParams) *Context {
 var _ exec.VMContext = (*Context)(nil)
 
 // Storage returns an implementation of the storage module for this context.
+// Reads and writes through it are charged according to the gas table.
 func (ctx *Context) Storage() exec.Storage {
-	return ctx.storageMap.NewStorage(ctx.message.To, ctx.to)
+	return &meteredStorage{
+		Storage: ctx.storageMap.NewStorage(ctx.message.To, ctx.to),
+		ctx:     ctx,
+	}
 }
 
 // Message retrieves the message associated with this context.
@@ -93,7 +117,14 @@ func (ctx *Context) ReadStorage() ([]byte, error) {
 
 // Charge attempts to add the given cost to the accrued gas cost of this transaction
 func (ctx *Context) Charge(cost types.GasUnits) error {
-	return ctx.gasTracker.Charge(cost)
+	err := ctx.gasTracker.Charge(cost)
+	ctx.tracer.Charge(ctx.depth, cost, err)
+	return err
+}
+
+// GasTable returns the gas table operations are charged with.
+func (ctx *Context) GasTable() *exec.GasTable {
+	return ctx.gasTracker.Table()
 }
 
 // GasUnits retrieves the gas cost so far
@@ -133,10 +164,23 @@ func (ctx *Context) IsFromAccountActor() bool {
 func (ctx *Context) Send(to address.Address, method string, value *types.AttoFIL, params []interface{}) ([][]byte, uint8, error) {
 	deps := ctx.deps
 
+	if err := ctx.Charge(ctx.GasTable().Send); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
 	// the message sender is the `to` actor, so this is what we set as `from` in the new message
 	from := ctx.Message().To
 	fromActor := ctx.to
 
+	if ctx.depth+1 > MaxSendDepth {
+		return nil, errors.ErrSendDepthExceeded, errors.Errors[errors.ErrSendDepthExceeded]
+	}
+	// An actor calling back into an actor up the stack could find, and change,
+	// its state in the middle of an update. Plain transfers run no code.
+	if method != "" && ctx.isExecuting(to) {
+		return nil, errors.ErrReentrantSend, errors.Errors[errors.ErrReentrantSend]
+	}
+
 	vals, err := deps.ToValues(params)
 	if err != nil {
 		return nil, 1, errors.FaultErrorWrap(err, "failed to convert inputs to abi values")
@@ -169,8 +213,12 @@ func (ctx *Context) Send(to address.Address, method string, value *types.AttoFIL
 		GasTracker:  ctx.gasTracker,
 		BlockHeight: ctx.blockHeight,
 		Ancestors:   ctx.ancestors,
+		Tracer:      ctx.tracer,
 	}
 	innerCtx := NewVMContext(innerParams)
+	innerCtx.depth = ctx.depth + 1
+	innerCtx.callers = append(ctx.callers[:len(ctx.callers):len(ctx.callers)], from)
+	innerCtx.events = ctx.events
 
 	out, ret, err := deps.Send(context.Background(), innerCtx)
 	if err != nil {
@@ -180,6 +228,17 @@ func (ctx *Context) Send(to address.Address, method string, value *types.AttoFIL
 	return out, ret, nil
 }
 
+// isExecuting returns whether the actor at addr is executing a method of one of
+// the enclosing messages, other than the one of this context.
+func (ctx *Context) isExecuting(addr address.Address) bool {
+	for _, caller := range ctx.callers {
+		if caller == addr {
+			return true
+		}
+	}
+	return false
+}
+
 // AddressForNewActor creates computes the address for a new actor in the same
 // way that ethereum does.  Note that this will not work if we allow the
 // creation of multiple contracts in a given invocation (nonce will remain the
@@ -244,6 +303,38 @@ func (ctx *Context) SampleChainRandomness(sampleHeight *types.BlockHeight) ([]by
 	return sampling.SampleChainRandomness(sampleHeight, ctx.ancestors)
 }
 
+// Rand returns randomness for the given epoch derived from the tickets of the
+// block's ancestors. All nodes applying the block get the same randomness.
+func (ctx *Context) Rand(epoch *types.BlockHeight) ([]byte, error) {
+	return sampling.Rand(epoch, ctx.ancestors)
+}
+
+// EmitEvent emits an event from the actor the message is sent to. The event is
+// committed in the receipt of the message unless the method emitting it
+// reverts.
+func (ctx *Context) EmitEvent(topic string, payload []byte) error {
+	if topic == "" {
+		return errors.NewRevertError("event topic must not be empty")
+	}
+
+	gasTable := ctx.GasTable()
+	if err := ctx.Charge(gasTable.Event + gasTable.EventByte*types.GasUnits(len(payload))); err != nil {
+		return errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	*ctx.events = append(*ctx.events, &types.Event{
+		Emitter: ctx.message.To,
+		Topic:   topic,
+		Payload: payload,
+	})
+	return nil
+}
+
+// Events returns the events emitted so far while applying the message.
+func (ctx *Context) Events() []*types.Event {
+	return *ctx.events
+}
+
 // Dependency injection setup.
 
 // makeDeps returns a VMContext's external dependencies with their standard values set.
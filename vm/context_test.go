@@ -239,6 +239,61 @@ func TestVMContextSendFailures(t *testing.T) {
 		assert.Equal(t, []string{"ToValues", "EncodeValues", "GetOrCreateActor", "Send"}, calls)
 	})
 
+	t.Run("refuses to send deeper than MaxSendDepth", func(t *testing.T) {
+		ctx := NewVMContext(vmCtxParams)
+		ctx.deps = &deps{}
+		ctx.depth = MaxSendDepth
+
+		_, code, err := ctx.Send(newAddress(), "foo", nil, []interface{}{})
+
+		assert.Error(t, err)
+		assert.Equal(t, errors.ErrSendDepthExceeded, int(code))
+		assert.True(t, errors.ShouldRevert(err))
+	})
+
+	t.Run("refuses to call back into an actor up the stack", func(t *testing.T) {
+		caller := newAddress()
+		ctx := NewVMContext(vmCtxParams)
+		ctx.deps = &deps{}
+		ctx.callers = []address.Address{caller}
+
+		_, code, err := ctx.Send(caller, "foo", nil, []interface{}{})
+
+		assert.Error(t, err)
+		assert.Equal(t, errors.ErrReentrantSend, int(code))
+		assert.True(t, errors.ShouldRevert(err))
+	})
+
+	t.Run("transfers to an actor up the stack", func(t *testing.T) {
+		caller := newAddress()
+		var innerCtx *Context
+		deps := &deps{
+			EncodeValues: func(_ []*abi.Value) ([]byte, error) {
+				return nil, nil
+			},
+			GetOrCreateActor: func(_ context.Context, _ address.Address, f func() (*actor.Actor, error)) (*actor.Actor, error) {
+				return f()
+			},
+			Send: func(ctx context.Context, vmCtx *Context) ([][]byte, uint8, error) {
+				innerCtx = vmCtx
+				return nil, 0, nil
+			},
+			ToValues: func(_ []interface{}) ([]*abi.Value, error) {
+				return nil, nil
+			},
+		}
+		ctx := NewVMContext(vmCtxParams)
+		ctx.deps = deps
+		ctx.callers = []address.Address{caller}
+
+		_, code, err := ctx.Send(caller, "", types.NewAttoFILFromFIL(1), nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, int(code))
+		assert.Equal(t, 1, innerCtx.depth)
+		assert.Equal(t, []address.Address{caller, vmCtxParams.Message.To}, innerCtx.callers)
+	})
+
 	t.Run("creates new actor from cid", func(t *testing.T) {
 		ctx := context.Background()
 		vmctx := NewVMContext(vmCtxParams)
@@ -286,6 +341,24 @@ func TestVMContextIsAccountActor(t *testing.T) {
 	assert.False(t, ctx.IsFromAccountActor())
 }
 
+func TestVMContextEmitEvent(t *testing.T) {
+	tf.UnitTest(t)
+
+	table := GasTables[2]
+	gasTracker := NewGasTrackerWithTable(table)
+	gasTracker.MsgGasLimit = types.NewGasUnits(1000)
+	msg := types.NewMessage(address.TestAddress, address.TestAddress2, 0, nil, "foo", nil)
+	ctx := NewVMContext(NewContextParams{Message: msg, GasTracker: gasTracker})
+
+	require.NoError(t, ctx.EmitEvent("topic", []byte{1, 2, 3}))
+	assert.Equal(t, table.Event+3*table.EventByte, ctx.GasUnits())
+	assert.Equal(t, []*types.Event{{Emitter: address.TestAddress2, Topic: "topic", Payload: []byte{1, 2, 3}}}, ctx.Events())
+
+	err := ctx.EmitEvent("", nil)
+	assert.True(t, errors.ShouldRevert(err))
+	assert.Len(t, ctx.Events(), 1)
+}
+
 func TestVMContextRand(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -366,4 +439,17 @@ func TestVMContextRand(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, []byte(strconv.Itoa(0)), r)
 	})
+
+	t.Run("Rand hashes the sampled ticket with the epoch", func(t *testing.T) {
+		ctx := NewVMContext(NewContextParams{
+			Ancestors: tipSetsDescBlockHeight,
+		})
+
+		r, err := ctx.Rand(types.NewBlockHeight(uint64(20)))
+		require.NoError(t, err)
+		expected, err := sampling.Rand(types.NewBlockHeight(uint64(20)), tipSetsDescBlockHeight)
+		require.NoError(t, err)
+		assert.Equal(t, expected, r)
+		assert.NotEqual(t, []byte(strconv.Itoa(17)), r)
+	})
 }
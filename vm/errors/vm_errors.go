@@ -14,6 +14,12 @@ const (
 	ErrMissingExport
 	// ErrNoActorCode indicates the recipient's code could not be loaded.
 	ErrNoActorCode
+	// ErrSendDepthExceeded is the error code for a message sent from nested
+	// messages deeper than the VM allows
+	ErrSendDepthExceeded
+	// ErrReentrantSend is the error code for a message that calls a method of
+	// an actor already executing one
+	ErrReentrantSend
 )
 
 // Errors is a map from exit codes to errors.
@@ -24,6 +30,8 @@ var Errors = map[uint8]error{
 	ErrInsufficientBalance:         NewCodedRevertError(ErrInsufficientBalance, "not enough balance"),
 	ErrMissingExport:               NewCodedRevertError(ErrInsufficientBalance, "actor does not export method"),
 	ErrNoActorCode:                 NewCodedRevertError(ErrNoActorCode, "actor code not found"),
+	ErrSendDepthExceeded:           NewCodedRevertError(ErrSendDepthExceeded, "maximum send depth exceeded"),
+	ErrReentrantSend:               NewCodedRevertError(ErrReentrantSend, "actor may not be reentered"),
 }
 
 // VMExitCodeToError tries to locate an error in either the VM errors or the provide error map
@@ -0,0 +1,52 @@
+package vm
+
+import (
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// GasTables are the gas tables of the versions of the network protocol, keyed
+// by version. Pricing only changes with a new protocol version so that all
+// nodes apply the messages of a block the same way.
+var GasTables = map[uint64]*exec.GasTable{
+	// Version 1 predates the gas table and only charges method calls.
+	1: {
+		MethodCall: 100,
+	},
+	2: {
+		MessageBase:           50,
+		ParamByte:             1,
+		SignatureVerification: 20,
+		MethodCall:            100,
+		Send:                  20,
+		StorageGet:            10,
+		StorageGetByte:        1,
+		StoragePut:            20,
+		StoragePutByte:        2,
+		Event:                 20,
+		EventByte:             1,
+	},
+}
+
+// GasTableAt returns the gas table of the given protocol version, which is
+// the table of the latest version up to it that has one.
+func GasTableAt(version uint64) *exec.GasTable {
+	var table *exec.GasTable
+	var tableVersion uint64
+	for v, t := range GasTables {
+		if v <= version && (table == nil || v > tableVersion) {
+			table, tableVersion = t, v
+		}
+	}
+	if table == nil {
+		return GasTables[1]
+	}
+	return table
+}
+
+// MessageGas returns the gas charged for applying msg before the VM runs the
+// method it calls: the base cost of a message, the cost of its params and the
+// cost of verifying its signature.
+func MessageGas(table *exec.GasTable, msg *types.Message) types.GasUnits {
+	return table.MessageBase + table.ParamByte*types.GasUnits(len(msg.Params)) + table.SignatureVerification
+}
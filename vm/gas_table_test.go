@@ -0,0 +1,85 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-ipfs-blockstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestGasTableAt(t *testing.T) {
+	tf.UnitTest(t)
+
+	assert.Equal(t, GasTables[1], GasTableAt(0))
+	assert.Equal(t, GasTables[1], GasTableAt(1))
+	assert.Equal(t, GasTables[2], GasTableAt(2))
+	// versions without a table of their own keep the latest one
+	assert.Equal(t, GasTables[2], GasTableAt(100))
+}
+
+func TestMessageGas(t *testing.T) {
+	tf.UnitTest(t)
+
+	msg := types.NewMessage(address.TestAddress, address.TestAddress2, 0, types.ZeroAttoFIL, "foo", []byte{1, 2, 3})
+
+	assert.Equal(t, types.NewGasUnits(0), MessageGas(GasTables[1], msg))
+
+	table := GasTables[2]
+	expected := table.MessageBase + 3*table.ParamByte + table.SignatureVerification
+	assert.Equal(t, expected, MessageGas(table, msg))
+}
+
+func TestMeteredStorage(t *testing.T) {
+	tf.UnitTest(t)
+
+	table := GasTables[2]
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+
+	newStorage := func(limit types.GasUnits) (*meteredStorage, *GasTracker) {
+		gasTracker := NewGasTrackerWithTable(table)
+		gasTracker.MsgGasLimit = limit
+		act := actor.NewActor(cid.Undef, types.ZeroAttoFIL)
+		ctx := NewVMContext(NewContextParams{GasTracker: gasTracker})
+		return &meteredStorage{Storage: NewStorage(bs, act), ctx: ctx}, gasTracker
+	}
+
+	t.Run("charges for puts and gets by size", func(t *testing.T) {
+		storage, gasTracker := newStorage(types.NewGasUnits(1000))
+
+		c, err := storage.Put([]string{"foo", "bar"})
+		require.NoError(t, err)
+		data, err := storage.Storage.Get(c)
+		require.NoError(t, err)
+		size := types.GasUnits(len(data))
+
+		putCost := table.StoragePut + size*table.StoragePutByte
+		assert.Equal(t, putCost, gasTracker.gasConsumedByMessage)
+
+		_, err = storage.Get(c)
+		require.NoError(t, err)
+		assert.Equal(t, putCost+table.StorageGet+size*table.StorageGetByte, gasTracker.gasConsumedByMessage)
+	})
+
+	t.Run("fails when out of gas", func(t *testing.T) {
+		storage, _ := newStorage(table.StoragePut)
+
+		_, err := storage.Put([]string{"foo", "bar"})
+		assert.EqualError(t, err, "gas cost exceeds gas limit")
+	})
+
+	t.Run("does not charge for chunks it cannot find", func(t *testing.T) {
+		storage, gasTracker := newStorage(types.NewGasUnits(1000))
+
+		_, err := storage.Get(types.NewCidForTestGetter()())
+		assert.Equal(t, ErrNotFound, err)
+		assert.Equal(t, types.NewGasUnits(0), gasTracker.gasConsumedByMessage)
+	})
+}
@@ -1,6 +1,7 @@
 package vm
 
 import (
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm/errors"
 )
@@ -8,14 +9,23 @@ import (
 // GasTracker maintains the state of gas usage throughout the execution of a block and a message
 type GasTracker struct {
 	MsgGasLimit          types.GasUnits
+	table                *exec.GasTable
 	gasConsumedByBlock   types.GasUnits
 	gasConsumedByMessage types.GasUnits
 }
 
-// NewGasTracker initializes a new empty gas tracker
+// NewGasTracker initializes a new empty gas tracker that prices operations
+// according to the gas table of the first protocol version.
 func NewGasTracker() *GasTracker {
+	return NewGasTrackerWithTable(GasTables[1])
+}
+
+// NewGasTrackerWithTable initializes a new empty gas tracker that prices
+// operations according to the given gas table.
+func NewGasTrackerWithTable(table *exec.GasTable) *GasTracker {
 	return &GasTracker{
 		MsgGasLimit:          types.NewGasUnits(0),
+		table:                table,
 		gasConsumedByBlock:   types.NewGasUnits(0),
 		gasConsumedByMessage: types.NewGasUnits(0),
 	}
@@ -40,6 +50,11 @@ func (gasTracker *GasTracker) Charge(cost types.GasUnits) error {
 	return nil
 }
 
+// Table returns the gas table the tracker prices operations with.
+func (gasTracker *GasTracker) Table() *exec.GasTable {
+	return gasTracker.table
+}
+
 // GasAboveBlockLimit will return true if the MsgGasLimit of the current message is greater than the block gas limit.
 func (gasTracker *GasTracker) GasAboveBlockLimit() bool {
 	return gasTracker.MsgGasLimit > types.BlockGasLimit
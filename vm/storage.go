@@ -169,6 +169,28 @@ func (s Storage) Head() cid.Cid {
 	return s.actor.Head
 }
 
+// Begin starts a transaction over the head of the actor's memory. Chunks put
+// during the transaction are left staged when it is rolled back; they are
+// unlinked from the head so Flush does not write them.
+func (s Storage) Begin() exec.Transaction {
+	return &storageTransaction{actor: s.actor, head: s.actor.Head}
+}
+
+// storageTransaction restores the head of an actor's memory on rollback.
+type storageTransaction struct {
+	actor *actor.Actor
+	head  cid.Cid
+}
+
+// Commit keeps the head the actor's memory has now.
+func (tx *storageTransaction) Commit() {}
+
+// Rollback restores the head the actor's memory had when the transaction
+// began.
+func (tx *storageTransaction) Rollback() {
+	tx.actor.Head = tx.head
+}
+
 // Prune removes all chunks that are unlinked
 func (s *Storage) Prune() error {
 	liveIds, err := s.liveDescendantIds(s.actor.Head)
@@ -243,3 +265,46 @@ func (s Storage) liveDescendantIds(id cid.Cid) (*cid.Set, error) {
 
 	return ids, nil
 }
+
+// meteredStorage charges the gas for the chunks an actor reads from and puts
+// into its storage.
+type meteredStorage struct {
+	exec.Storage
+	ctx *Context
+}
+
+var _ exec.Storage = (*meteredStorage)(nil)
+
+// Put adds a node to storage and charges for its size.
+func (s *meteredStorage) Put(v interface{}) (cid.Cid, error) {
+	c, err := s.Storage.Put(v)
+	if err != nil {
+		return cid.Undef, err
+	}
+	data, err := s.Storage.Get(c)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	s.ctx.tracer.StoragePut(s.ctx.depth, c, len(data))
+	table := s.ctx.GasTable()
+	if err := s.ctx.Charge(table.StoragePut + table.StoragePutByte*types.GasUnits(len(data))); err != nil {
+		return cid.Undef, err
+	}
+	return c, nil
+}
+
+// Get retrieves a chunk from storage and charges for its size.
+func (s *meteredStorage) Get(c cid.Cid) ([]byte, error) {
+	data, err := s.Storage.Get(c)
+	if err != nil {
+		return data, err
+	}
+
+	s.ctx.tracer.StorageGet(s.ctx.depth, c, len(data))
+	table := s.ctx.GasTable()
+	if err := s.ctx.Charge(table.StorageGet + table.StorageGetByte*types.GasUnits(len(data))); err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
@@ -0,0 +1,103 @@
+package vm
+
+import (
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Tracer is notified of the operations the VM performs while it applies a
+// message. Depth is 0 for the message applied and one more than the depth of
+// the sender for every message an actor sends.
+type Tracer interface {
+	// Send is called before the VM runs the message.
+	Send(depth int, msg *types.Message)
+	// Return is called when the method the message calls returns.
+	Return(depth int, code uint8, err error)
+	// Charge is called for every charge of gas. The error is set when the
+	// charge exceeds the gas limit.
+	Charge(depth int, cost types.GasUnits, err error)
+	// StorageGet is called for every chunk an actor reads from its storage.
+	StorageGet(depth int, c cid.Cid, size int)
+	// StoragePut is called for every chunk an actor puts into its storage.
+	StoragePut(depth int, c cid.Cid, size int)
+}
+
+// noopTracer is the tracer of contexts that are not traced.
+type noopTracer struct{}
+
+var _ Tracer = noopTracer{}
+
+func (noopTracer) Send(int, *types.Message)          {}
+func (noopTracer) Return(int, uint8, error)          {}
+func (noopTracer) Charge(int, types.GasUnits, error) {}
+func (noopTracer) StorageGet(int, cid.Cid, int)      {}
+func (noopTracer) StoragePut(int, cid.Cid, int)      {}
+
+// TraceKind is the kind of an operation in a trace.
+type TraceKind string
+
+const (
+	// TraceSend is the kind of Tracer.Send events.
+	TraceSend = TraceKind("send")
+	// TraceReturn is the kind of Tracer.Return events.
+	TraceReturn = TraceKind("return")
+	// TraceCharge is the kind of Tracer.Charge events.
+	TraceCharge = TraceKind("charge")
+	// TraceStorageGet is the kind of Tracer.StorageGet events.
+	TraceStorageGet = TraceKind("get")
+	// TraceStoragePut is the kind of Tracer.StoragePut events.
+	TraceStoragePut = TraceKind("put")
+)
+
+// TraceEvent is an operation recorded by a TraceRecorder. Only the fields
+// that apply to its kind are set.
+type TraceEvent struct {
+	Depth    int            `json:"depth"`
+	Kind     TraceKind      `json:"kind"`
+	Message  *types.Message `json:"message,omitempty"`
+	ExitCode uint8          `json:"exitCode,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Gas      types.GasUnits `json:"gas,omitempty"`
+	Chunk    *cid.Cid       `json:"chunk,omitempty"`
+	Size     int            `json:"size,omitempty"`
+}
+
+// TraceRecorder is a Tracer that records the operations in order.
+type TraceRecorder struct {
+	Events []*TraceEvent
+}
+
+var _ Tracer = (*TraceRecorder)(nil)
+
+// Send records a message send.
+func (r *TraceRecorder) Send(depth int, msg *types.Message) {
+	r.Events = append(r.Events, &TraceEvent{Depth: depth, Kind: TraceSend, Message: msg})
+}
+
+// Return records the return of a method.
+func (r *TraceRecorder) Return(depth int, code uint8, err error) {
+	r.Events = append(r.Events, &TraceEvent{Depth: depth, Kind: TraceReturn, ExitCode: code, Error: errorString(err)})
+}
+
+// Charge records a charge of gas.
+func (r *TraceRecorder) Charge(depth int, cost types.GasUnits, err error) {
+	r.Events = append(r.Events, &TraceEvent{Depth: depth, Kind: TraceCharge, Gas: cost, Error: errorString(err)})
+}
+
+// StorageGet records a read from actor storage.
+func (r *TraceRecorder) StorageGet(depth int, c cid.Cid, size int) {
+	r.Events = append(r.Events, &TraceEvent{Depth: depth, Kind: TraceStorageGet, Chunk: &c, Size: size})
+}
+
+// StoragePut records a write to actor storage.
+func (r *TraceRecorder) StoragePut(depth int, c cid.Cid, size int) {
+	r.Events = append(r.Events, &TraceEvent{Depth: depth, Kind: TraceStoragePut, Chunk: &c, Size: size})
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,72 @@
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-ipfs-blockstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestTraceRecorder(t *testing.T) {
+	tf.UnitTest(t)
+
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	vms := NewStorageMap(bs)
+
+	from := actor.NewActor(types.SomeCid(), types.NewAttoFILFromFIL(100))
+	to := actor.NewActor(types.SomeCid(), types.NewAttoFILFromFIL(0))
+	toAddr := address.NewForTestGetter()()
+	require.NoError(t, (&actor.FakeActor{}).InitializeState(vms.NewStorage(toAddr, to), &actor.FakeActorStorage{}))
+
+	tree := state.NewCachedStateTree(&state.MockStateTree{NoMocks: true, BuiltinActors: map[cid.Cid]exec.ExecutableActor{
+		to.Code: &actor.FakeActor{},
+	}})
+
+	msg := types.NewMessageForTestGetter()()
+	msg.To = toAddr
+	msg.Value = nil
+	msg.Method = "goodCall"
+
+	recorder := &TraceRecorder{}
+	vmCtx := NewVMContext(NewContextParams{
+		From:        from,
+		To:          to,
+		Message:     msg,
+		State:       tree,
+		StorageMap:  vms,
+		GasTracker:  NewGasTracker(),
+		BlockHeight: types.NewBlockHeight(0),
+		Tracer:      recorder,
+	})
+	_, _, err := Send(context.Background(), vmCtx)
+	require.NoError(t, err)
+
+	events := recorder.Events
+	require.True(t, len(events) >= 2)
+
+	assert.Equal(t, TraceSend, events[0].Kind)
+	assert.Equal(t, msg, events[0].Message)
+	assert.Equal(t, TraceReturn, events[len(events)-1].Kind)
+	assert.Equal(t, uint8(0), events[len(events)-1].ExitCode)
+
+	kinds := map[TraceKind]bool{}
+	for _, event := range events {
+		assert.Equal(t, 0, event.Depth)
+		kinds[event.Kind] = true
+	}
+	// the fake actor reads its state and writes it back
+	assert.True(t, kinds[TraceStorageGet])
+	assert.True(t, kinds[TraceStoragePut])
+	assert.True(t, kinds[TraceCharge])
+}
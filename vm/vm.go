@@ -26,7 +26,12 @@ type sendDeps struct {
 }
 
 // send executes a message pass inside the VM. It exists alongside Send so that we can inject its dependencies during test.
-func send(ctx context.Context, deps sendDeps, vmCtx *Context) ([][]byte, uint8, error) {
+func send(ctx context.Context, deps sendDeps, vmCtx *Context) (ret [][]byte, code uint8, err error) {
+	vmCtx.tracer.Send(vmCtx.depth, vmCtx.message)
+	defer func() {
+		vmCtx.tracer.Return(vmCtx.depth, code, err)
+	}()
+
 	if vmCtx.message.Value != nil {
 		if err := deps.transfer(vmCtx.from, vmCtx.to, vmCtx.message.Value); err != nil {
 			if errors.ShouldRevert(err) {
@@ -51,7 +56,18 @@ func send(ctx context.Context, deps sendDeps, vmCtx *Context) ([][]byte, uint8,
 		return nil, 1, errors.Errors[errors.ErrMissingExport]
 	}
 
+	// Discard the changes the method makes to the actor's storage and the
+	// events it emits if it reverts, so actors need not clean up after
+	// themselves.
+	tx := vmCtx.Storage().Begin()
+	emitted := len(*vmCtx.events)
 	r, code, err := actor.MakeTypedExport(toExecutable, vmCtx.message.Method)(vmCtx)
+	if errors.ShouldRevert(err) {
+		tx.Rollback()
+		*vmCtx.events = (*vmCtx.events)[:emitted]
+	} else {
+		tx.Commit()
+	}
 	if r != nil {
 		var rv [][]byte
 		err = cbor.DecodeInto(r, &rv)
@@ -10,6 +10,7 @@ import (
 	xerrors "github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -17,6 +18,7 @@ import (
 
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTransfer(t *testing.T) {
@@ -137,3 +139,103 @@ func TestSendErrorHandling(t *testing.T) {
 		assert.True(t, errors.ShouldRevert(sendErr))
 	})
 }
+
+func TestSendRollsBackStorageOnRevert(t *testing.T) {
+	tf.UnitTest(t)
+
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	vms := NewStorageMap(bs)
+	newMsg := types.NewMessageForTestGetter()
+
+	from := actor.NewActor(types.SomeCid(), types.NewAttoFILFromFIL(100))
+	to := actor.NewActor(types.SomeCid(), types.NewAttoFILFromFIL(0))
+	toAddr := address.NewForTestGetter()()
+	require.NoError(t, (&actor.FakeActor{}).InitializeState(vms.NewStorage(toAddr, to), &actor.FakeActorStorage{}))
+	initialHead := to.Head
+
+	tree := state.NewCachedStateTree(&state.MockStateTree{NoMocks: true, BuiltinActors: map[cid.Cid]exec.ExecutableActor{
+		to.Code: &actor.FakeActor{},
+	}})
+
+	sendMethod := func(method string) (uint8, error) {
+		msg := newMsg()
+		msg.To = toAddr
+		msg.Value = nil
+		msg.Method = method
+
+		vmCtx := NewVMContext(NewContextParams{
+			From:        from,
+			To:          to,
+			Message:     msg,
+			State:       tree,
+			StorageMap:  vms,
+			GasTracker:  NewGasTracker(),
+			BlockHeight: types.NewBlockHeight(0),
+		})
+		_, code, err := Send(context.Background(), vmCtx)
+		return code, err
+	}
+
+	t.Run("discards storage changes of reverted methods", func(t *testing.T) {
+		_, err := sendMethod("returnRevertError")
+		assert.True(t, errors.ShouldRevert(err))
+		assert.Equal(t, initialHead, to.Head)
+	})
+
+	t.Run("keeps storage changes of successful methods", func(t *testing.T) {
+		code, err := sendMethod("goodCall")
+		require.NoError(t, err)
+		assert.Equal(t, uint8(0), code)
+		assert.NotEqual(t, initialHead, to.Head)
+	})
+}
+
+func TestSendEvents(t *testing.T) {
+	tf.UnitTest(t)
+
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	vms := NewStorageMap(bs)
+	newMsg := types.NewMessageForTestGetter()
+
+	from := actor.NewActor(types.SomeCid(), types.NewAttoFILFromFIL(100))
+	to := actor.NewActor(types.SomeCid(), types.NewAttoFILFromFIL(0))
+	toAddr := address.NewForTestGetter()()
+	require.NoError(t, (&actor.FakeActor{}).InitializeState(vms.NewStorage(toAddr, to), &actor.FakeActorStorage{}))
+
+	tree := state.NewCachedStateTree(&state.MockStateTree{NoMocks: true, BuiltinActors: map[cid.Cid]exec.ExecutableActor{
+		to.Code: &actor.FakeActor{},
+	}})
+
+	sendMethod := func(method string) (*Context, error) {
+		msg := newMsg()
+		msg.To = toAddr
+		msg.Value = nil
+		msg.Method = method
+
+		vmCtx := NewVMContext(NewContextParams{
+			From:        from,
+			To:          to,
+			Message:     msg,
+			State:       tree,
+			StorageMap:  vms,
+			GasTracker:  NewGasTracker(),
+			BlockHeight: types.NewBlockHeight(0),
+		})
+		_, _, err := Send(context.Background(), vmCtx)
+		return vmCtx, err
+	}
+
+	t.Run("records the events of successful methods", func(t *testing.T) {
+		vmCtx, err := sendMethod("emitEvent")
+		require.NoError(t, err)
+
+		require.Len(t, vmCtx.Events(), 1)
+		assert.Equal(t, &types.Event{Emitter: toAddr, Topic: "fake", Payload: []byte("payload")}, vmCtx.Events()[0])
+	})
+
+	t.Run("discards the events of reverted methods", func(t *testing.T) {
+		vmCtx, err := sendMethod("emitEventAndRevertError")
+		assert.True(t, errors.ShouldRevert(err))
+		assert.Empty(t, vmCtx.Events())
+	})
+}
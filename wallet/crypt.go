@@ -0,0 +1,111 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+func init() {
+	cbor.RegisterCborType(cryptParams{})
+}
+
+// Parameters of the scrypt derivation of the keystore key from the
+// passphrase, as recommended for interactive logins in 2017.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	cryptKeySize = 32
+	cryptSalt    = 32
+)
+
+// cryptCheck is sealed with the keystore key to tell wrong passphrases.
+var cryptCheck = []byte("go-filecoin keystore")
+
+var (
+	// ErrWalletLocked is returned when using the keys of a locked wallet.
+	ErrWalletLocked = errors.New("wallet is locked")
+	// ErrWrongPassphrase is returned when unlocking a wallet with a wrong passphrase.
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	// ErrNotEncrypted is returned when unlocking a wallet without passphrase.
+	ErrNotEncrypted = errors.New("wallet is not encrypted")
+)
+
+// cryptParams are the parameters stored alongside an encrypted keystore.
+type cryptParams struct {
+	Salt []byte
+	// Check is cryptCheck sealed with the keystore key.
+	Check []byte
+}
+
+// newCryptParams returns the parameters of a new keystore encrypted with
+// passphrase and its key.
+func newCryptParams(passphrase []byte) (*cryptParams, []byte, error) {
+	salt := make([]byte, cryptSalt)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to generate salt")
+	}
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, cryptKeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	check, err := seal(key, cryptCheck)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &cryptParams{Salt: salt, Check: check}, key, nil
+}
+
+// key derives the keystore key from passphrase, and fails with
+// ErrWrongPassphrase if it is not the keystore's passphrase.
+func (p *cryptParams) key(passphrase []byte) ([]byte, error) {
+	key, err := scrypt.Key(passphrase, p.Salt, scryptN, scryptR, scryptP, cryptKeySize)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := unseal(key, p.Check); err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return key, nil
+}
+
+// seal encrypts and authenticates plaintext with AES-GCM under key. The
+// random nonce is prepended to the result.
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to generate nonce")
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// unseal decrypts data sealed under key.
+func unseal(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("sealed data too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -1,15 +1,21 @@
 package wallet
 
 import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	ds "github.com/ipfs/go-datastore"
 	dsq "github.com/ipfs/go-datastore/query"
+	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/crypto"
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -18,28 +24,64 @@ import (
 
 const (
 	// SECP256K1 is a curve used to computer private keys
-	SECP256K1 = "secp256k1"
+	SECP256K1 = types.SECP256K1
+	// BLS is the curve of BLS private keys
+	BLS = types.BLS
 )
 
 // DSBackendType is the reflect type of the DSBackend.
 var DSBackendType = reflect.TypeOf(&DSBackend{})
 
+// Apart from the keys, which are stored under their address, the datastore
+// holds the seed new addresses are derived from and the index of the next
+// derived key under the hdPrefix namespace, the encryption parameters under
+// the cryptPrefix namespace and the keys of the signing session under the
+// sessionPrefix namespace.
+const (
+	hdPrefix      = "hd"
+	cryptPrefix   = "crypt"
+	sessionPrefix = "session"
+)
+
+var (
+	hdSeedKey      = ds.KeyWithNamespaces([]string{hdPrefix, "seed"})
+	hdNextIndexKey = ds.KeyWithNamespaces([]string{hdPrefix, "next"})
+	cryptParamsKey = ds.KeyWithNamespaces([]string{cryptPrefix, "params"})
+)
+
+// ErrInvalidSessionToken is returned when resuming a session with a token that
+// does not match the stored session.
+var ErrInvalidSessionToken = errors.New("invalid session token")
+
 // DSBackend is a wallet backend implementation for storing addresses in a datastore.
+//
+// The keys and the HD seed may be encrypted with a passphrase. The backend is
+// then locked until unlocked with the passphrase, and can neither sign nor
+// create keys while locked, except for signing with the keys of a session.
 type DSBackend struct {
 	lk sync.RWMutex
 
-	// TODO: use a better interface that supports time locks, encryption, etc.
 	ds repo.Datastore
 
 	// TODO: proper cache
 	cache map[address.Address]struct{}
+
+	// params are the encryption parameters, nil if the keys are stored in
+	// plain text.
+	params *cryptParams
+	// key decrypts the keys while the backend is unlocked.
+	key       []byte
+	lockTimer *time.Timer
+
+	// sessionKeys are the keys usable to sign while locked.
+	sessionKeys map[address.Address]*types.KeyInfo
 }
 
 var _ Backend = (*DSBackend)(nil)
 
 // NewDSBackend constructs a new backend using the passed in datastore.
-func NewDSBackend(ds repo.Datastore) (*DSBackend, error) {
-	result, err := ds.Query(dsq.Query{
+func NewDSBackend(dstore repo.Datastore) (*DSBackend, error) {
+	result, err := dstore.Query(dsq.Query{
 		KeysOnly: true,
 	})
 	if err != nil {
@@ -53,6 +95,9 @@ func NewDSBackend(ds repo.Datastore) (*DSBackend, error) {
 
 	cache := make(map[address.Address]struct{})
 	for _, el := range list {
+		if isReservedKey(el.Key) {
+			continue
+		}
 		parsedAddr, err := address.NewFromString(strings.Trim(el.Key, "/"))
 		if err != nil {
 			return nil, errors.Wrapf(err, "trying to restore invalid address: %s", el.Key)
@@ -60,12 +105,35 @@ func NewDSBackend(ds repo.Datastore) (*DSBackend, error) {
 		cache[parsedAddr] = struct{}{}
 	}
 
+	var params *cryptParams
+	pb, err := dstore.Get(cryptParamsKey)
+	if err == nil {
+		params = &cryptParams{}
+		if err := cbor.DecodeInto(pb, params); err != nil {
+			return nil, errors.Wrap(err, "failed to decode encryption parameters")
+		}
+	} else if err != ds.ErrNotFound {
+		return nil, errors.Wrap(err, "failed to read encryption parameters")
+	}
+
 	return &DSBackend{
-		ds:    ds,
-		cache: cache,
+		ds:          dstore,
+		cache:       cache,
+		params:      params,
+		sessionKeys: make(map[address.Address]*types.KeyInfo),
 	}, nil
 }
 
+// isReservedKey returns true if key is not the key of an address.
+func isReservedKey(key string) bool {
+	for _, prefix := range []string{hdPrefix, cryptPrefix, sessionPrefix} {
+		if strings.HasPrefix(key, "/"+prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // ImportKey loads the address in `ai` and KeyInfo `ki` into the backend
 func (backend *DSBackend) ImportKey(ki *types.KeyInfo) error {
 	return backend.putKeyInfo(ki)
@@ -93,18 +161,40 @@ func (backend *DSBackend) HasAddress(addr address.Address) bool {
 	return ok
 }
 
-// NewAddress creates a new address and stores it.
+// NewAddress creates a new secp256k1 address and stores it.
 // Safe for concurrent access.
 func (backend *DSBackend) NewAddress() (address.Address, error) {
-	prv, err := crypto.GenerateKey()
-	if err != nil {
-		return address.Undef, err
-	}
+	return backend.NewAddressWithProtocol(address.SECP256K1)
+}
 
-	// TODO: maybe the above call should just return a keyinfo?
-	ki := &types.KeyInfo{
-		PrivateKey: prv,
-		Curve:      SECP256K1,
+// NewAddressWithProtocol creates a new address of the given protocol, either
+// secp256k1 or BLS, and stores it. Once the backend has an HD seed, secp256k1
+// keys are derived from it rather than random.
+// Safe for concurrent access.
+func (backend *DSBackend) NewAddressWithProtocol(protocol address.Protocol) (address.Address, error) {
+	ki := &types.KeyInfo{}
+	switch protocol {
+	case address.SECP256K1:
+		if backend.HasHDSeed() {
+			derived, err := backend.nextDerivedKeyInfo()
+			if err != nil {
+				return address.Undef, err
+			}
+			ki = derived
+			break
+		}
+		prv, err := crypto.GenerateKey()
+		if err != nil {
+			return address.Undef, err
+		}
+		ki.PrivateKey = prv
+		ki.Curve = SECP256K1
+	case address.BLS:
+		prv := bls.PrivateKeyGenerate()
+		ki.PrivateKey = prv[:]
+		ki.Curve = BLS
+	default:
+		return address.Undef, errors.Errorf("cannot create keys for address protocol %d", protocol)
 	}
 
 	if err := backend.putKeyInfo(ki); err != nil {
@@ -114,6 +204,79 @@ func (backend *DSBackend) NewAddress() (address.Address, error) {
 	return ki.Address()
 }
 
+// HasHDSeed returns true if the backend derives its new secp256k1 keys from
+// an HD seed.
+func (backend *DSBackend) HasHDSeed() bool {
+	has, err := backend.ds.Has(hdSeedKey)
+	return err == nil && has
+}
+
+// SetHDSeed makes the backend derive its new secp256k1 keys from seed,
+// starting at index next. It fails if the backend has a seed already.
+func (backend *DSBackend) SetHDSeed(seed []byte, next uint32) error {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	if backend.HasHDSeed() {
+		return ErrHDSeedExists
+	}
+	sealed, err := backend.sealValue(seed)
+	if err != nil {
+		return err
+	}
+	if err := backend.putNextIndex(next); err != nil {
+		return err
+	}
+	if err := backend.ds.Put(hdSeedKey, sealed); err != nil {
+		return errors.Wrap(err, "failed to store seed")
+	}
+	return nil
+}
+
+// nextDerivedKeyInfo derives the key at the next index from the seed and
+// moves the next index past it.
+func (backend *DSBackend) nextDerivedKeyInfo() (*types.KeyInfo, error) {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	sealed, err := backend.ds.Get(hdSeedKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read seed")
+	}
+	seed, err := backend.unsealValue(sealed)
+	if err != nil {
+		return nil, err
+	}
+	nb, err := backend.ds.Get(hdNextIndexKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read next key index")
+	}
+
+	// skip the astronomically unlikely indexes that yield no valid key
+	for next := binary.BigEndian.Uint32(nb); ; next++ {
+		ki, err := DeriveKeyInfo(seed, next)
+		if errors.Cause(err) == crypto.ErrInvalidChildKey {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := backend.putNextIndex(next + 1); err != nil {
+			return nil, err
+		}
+		return ki, nil
+	}
+}
+
+func (backend *DSBackend) putNextIndex(next uint32) error {
+	var nb [4]byte
+	binary.BigEndian.PutUint32(nb[:], next)
+	if err := backend.ds.Put(hdNextIndexKey, nb[:]); err != nil {
+		return errors.Wrap(err, "failed to store next key index")
+	}
+	return nil
+}
+
 func (backend *DSBackend) putKeyInfo(ki *types.KeyInfo) error {
 	a, err := ki.Address()
 	if err != nil {
@@ -127,6 +290,10 @@ func (backend *DSBackend) putKeyInfo(ki *types.KeyInfo) error {
 	if err != nil {
 		return err
 	}
+	kib, err = backend.sealValue(kib)
+	if err != nil {
+		return err
+	}
 
 	if err := backend.ds.Put(ds.NewKey(a.String()), kib); err != nil {
 		return errors.Wrap(err, "failed to store new address")
@@ -137,18 +304,31 @@ func (backend *DSBackend) putKeyInfo(ki *types.KeyInfo) error {
 }
 
 // SignBytes cryptographically signs `data` using the private key `priv`.
+// While the backend is locked only the keys of the session can sign.
 func (backend *DSBackend) SignBytes(data []byte, addr address.Address) (types.Signature, error) {
-	ki, err := backend.GetKeyInfo(addr)
-	if err != nil {
-		return nil, err
+	backend.lk.RLock()
+	ki, ok := backend.sessionKeys[addr]
+	backend.lk.RUnlock()
+	if !ok {
+		var err error
+		if ki, err = backend.GetKeyInfo(addr); err != nil {
+			return nil, err
+		}
 	}
 
+	if ki.Type() == BLS {
+		return wutil.SignBLS(ki.Key(), data)
+	}
 	return wutil.Sign(ki.Key(), data)
 }
 
 // Verify cryptographically verifies that 'sig' is the signed hash of 'data' with
-// the public key `pk`.
+// the public key `pk`. Keys of the length of BLS public keys are taken as BLS
+// keys.
 func (backend *DSBackend) Verify(data, pk []byte, sig types.Signature) bool {
+	if len(pk) == bls.PublicKeyBytes {
+		return wutil.VerifyBLS(pk, data, sig)
+	}
 	return crypto.Verify(pk, data, sig)
 }
 
@@ -159,11 +339,23 @@ func (backend *DSBackend) GetKeyInfo(addr address.Address) (*types.KeyInfo, erro
 		return nil, errors.New("backend does not contain address")
 	}
 
-	// kib is a cbor of types.KeyInfo
+	backend.lk.RLock()
+	defer backend.lk.RUnlock()
+
+	return backend.getKeyInfo(addr)
+}
+
+// getKeyInfo reads the key of addr. The caller must hold the lock.
+func (backend *DSBackend) getKeyInfo(addr address.Address) (*types.KeyInfo, error) {
+	// kib is a cbor of types.KeyInfo, possibly sealed
 	kib, err := backend.ds.Get(ds.NewKey(addr.String()))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to fetch private key from backend")
 	}
+	kib, err = backend.unsealValue(kib)
+	if err != nil {
+		return nil, err
+	}
 
 	ki := &types.KeyInfo{}
 	if err := ki.Unmarshal(kib); err != nil {
@@ -172,3 +364,251 @@ func (backend *DSBackend) GetKeyInfo(addr address.Address) (*types.KeyInfo, erro
 
 	return ki, nil
 }
+
+// IsEncrypted returns true if the keys are encrypted with a passphrase.
+func (backend *DSBackend) IsEncrypted() bool {
+	backend.lk.RLock()
+	defer backend.lk.RUnlock()
+
+	return backend.params != nil
+}
+
+// IsLocked returns true if the keys are encrypted and the backend is not
+// unlocked.
+func (backend *DSBackend) IsLocked() bool {
+	backend.lk.RLock()
+	defer backend.lk.RUnlock()
+
+	return backend.params != nil && backend.key == nil
+}
+
+// Encrypt encrypts the keys and the HD seed stored in plain text with
+// passphrase, and locks the backend.
+func (backend *DSBackend) Encrypt(passphrase []byte) error {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	if backend.params != nil {
+		return errors.New("wallet is encrypted already")
+	}
+	params, key, err := newCryptParams(passphrase)
+	if err != nil {
+		return err
+	}
+
+	var keys []ds.Key
+	for addr := range backend.cache {
+		keys = append(keys, ds.NewKey(addr.String()))
+	}
+	if backend.HasHDSeed() {
+		keys = append(keys, hdSeedKey)
+	}
+
+	batch, err := backend.ds.Batch()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		plain, err := backend.ds.Get(k)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", k)
+		}
+		sealed, err := seal(key, plain)
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(k, sealed); err != nil {
+			return err
+		}
+	}
+	pb, err := cbor.DumpObject(params)
+	if err != nil {
+		return err
+	}
+	if err := batch.Put(cryptParamsKey, pb); err != nil {
+		return err
+	}
+	if err := batch.Commit(); err != nil {
+		return errors.Wrap(err, "failed to store encrypted keys")
+	}
+
+	backend.params = params
+	return nil
+}
+
+// Unlock unlocks the backend with passphrase until it is locked again, or
+// until timeout elapsed if timeout is positive.
+func (backend *DSBackend) Unlock(passphrase []byte, timeout time.Duration) error {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	if backend.params == nil {
+		return ErrNotEncrypted
+	}
+	key, err := backend.params.key(passphrase)
+	if err != nil {
+		return err
+	}
+
+	backend.key = key
+	if backend.lockTimer != nil {
+		backend.lockTimer.Stop()
+		backend.lockTimer = nil
+	}
+	if timeout > 0 {
+		backend.lockTimer = time.AfterFunc(timeout, backend.Lock)
+	}
+	return nil
+}
+
+// Lock locks the backend. The keys of the session remain usable to sign.
+func (backend *DSBackend) Lock() {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	backend.key = nil
+	if backend.lockTimer != nil {
+		backend.lockTimer.Stop()
+		backend.lockTimer = nil
+	}
+}
+
+// StartSession makes the keys of addrs usable to sign while the backend is
+// locked, replacing any previous session, and returns the token resuming the
+// session after a restart. The keys are stored encrypted with the token.
+func (backend *DSBackend) StartSession(addrs []address.Address) ([]byte, error) {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	if err := backend.endSession(); err != nil {
+		return nil, err
+	}
+
+	token := make([]byte, cryptKeySize)
+	if _, err := io.ReadFull(rand.Reader, token); err != nil {
+		return nil, errors.Wrap(err, "failed to generate session token")
+	}
+
+	keys := make(map[address.Address]*types.KeyInfo)
+	for _, addr := range addrs {
+		if _, ok := backend.cache[addr]; !ok {
+			return nil, errors.Errorf("backend does not contain address %s", addr)
+		}
+		ki, err := backend.getKeyInfo(addr)
+		if err != nil {
+			return nil, err
+		}
+		kib, err := ki.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		sealed, err := seal(token, kib)
+		if err != nil {
+			return nil, err
+		}
+		if err := backend.ds.Put(sessionKey(addr), sealed); err != nil {
+			return nil, errors.Wrap(err, "failed to store session key")
+		}
+		keys[addr] = ki
+	}
+
+	backend.sessionKeys = keys
+	return token, nil
+}
+
+// ResumeSession makes the keys of the session started with token usable to
+// sign while the backend is locked.
+func (backend *DSBackend) ResumeSession(token []byte) error {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	result, err := backend.ds.Query(dsq.Query{Prefix: "/" + sessionPrefix})
+	if err != nil {
+		return errors.Wrap(err, "failed to query session keys")
+	}
+	entries, err := result.Rest()
+	if err != nil {
+		return errors.Wrap(err, "failed to read session keys")
+	}
+
+	keys := make(map[address.Address]*types.KeyInfo)
+	for _, entry := range entries {
+		kib, err := unseal(token, entry.Value)
+		if err != nil {
+			return ErrInvalidSessionToken
+		}
+		ki := &types.KeyInfo{}
+		if err := ki.Unmarshal(kib); err != nil {
+			return errors.Wrap(err, "failed to unmarshal session key")
+		}
+		addr, err := ki.Address()
+		if err != nil {
+			return err
+		}
+		keys[addr] = ki
+	}
+	if len(keys) == 0 {
+		return ErrInvalidSessionToken
+	}
+
+	backend.sessionKeys = keys
+	return nil
+}
+
+// EndSession forgets the keys of the session.
+func (backend *DSBackend) EndSession() error {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	return backend.endSession()
+}
+
+func (backend *DSBackend) endSession() error {
+	result, err := backend.ds.Query(dsq.Query{Prefix: "/" + sessionPrefix, KeysOnly: true})
+	if err != nil {
+		return errors.Wrap(err, "failed to query session keys")
+	}
+	entries, err := result.Rest()
+	if err != nil {
+		return errors.Wrap(err, "failed to read session keys")
+	}
+	for _, entry := range entries {
+		if err := backend.ds.Delete(ds.NewKey(entry.Key)); err != nil {
+			return errors.Wrap(err, "failed to delete session key")
+		}
+	}
+	backend.sessionKeys = make(map[address.Address]*types.KeyInfo)
+	return nil
+}
+
+func sessionKey(addr address.Address) ds.Key {
+	return ds.KeyWithNamespaces([]string{sessionPrefix, addr.String()})
+}
+
+// sealValue encrypts a value to store if the keys are encrypted. The caller
+// must hold the lock.
+func (backend *DSBackend) sealValue(value []byte) ([]byte, error) {
+	if backend.params == nil {
+		return value, nil
+	}
+	if backend.key == nil {
+		return nil, ErrWalletLocked
+	}
+	return seal(backend.key, value)
+}
+
+// unsealValue decrypts a stored value if the keys are encrypted. The caller
+// must hold the lock.
+func (backend *DSBackend) unsealValue(value []byte) ([]byte, error) {
+	if backend.params == nil {
+		return value, nil
+	}
+	if backend.key == nil {
+		return nil, ErrWalletLocked
+	}
+	plain, err := unseal(backend.key, value)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt key")
+	}
+	return plain, nil
+}
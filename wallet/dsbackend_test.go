@@ -1,14 +1,18 @@
 package wallet
 
 import (
+	"bytes"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 func TestDSBackendSimple(t *testing.T) {
@@ -132,3 +136,90 @@ func TestDSBackendParallel(t *testing.T) {
 	wg.Wait()
 	assert.Len(t, fs.Addresses(), 10)
 }
+
+func TestDSBackendEncryption(t *testing.T) {
+	tf.UnitTest(t)
+
+	data := []byte("data to sign")
+	passphrase := []byte("correct horse battery staple")
+
+	newEncrypted := func(t *testing.T) (*DSBackend, datastore.Batching, address.Address) {
+		ds := datastore.NewMapDatastore()
+		fs, err := NewDSBackend(ds)
+		require.NoError(t, err)
+		addr, err := fs.NewAddress()
+		require.NoError(t, err)
+		require.NoError(t, fs.Encrypt(passphrase))
+		return fs, ds, addr
+	}
+
+	t.Run("locked keys neither sign nor are created", func(t *testing.T) {
+		fs, _, addr := newEncrypted(t)
+		assert.True(t, fs.IsLocked())
+
+		_, err := fs.SignBytes(data, addr)
+		assert.Equal(t, ErrWalletLocked, err)
+		_, err = fs.GetKeyInfo(addr)
+		assert.Equal(t, ErrWalletLocked, err)
+		_, err = fs.NewAddress()
+		assert.Equal(t, ErrWalletLocked, err)
+	})
+
+	t.Run("unlocked keys sign until locked", func(t *testing.T) {
+		fs, ds, addr := newEncrypted(t)
+
+		assert.Equal(t, ErrWrongPassphrase, fs.Unlock([]byte("wrong"), 0))
+		require.NoError(t, fs.Unlock(passphrase, 0))
+		sig, err := fs.SignBytes(data, addr)
+		require.NoError(t, err)
+		assert.True(t, types.IsValidSignature(data, addr, sig))
+
+		// the keys are encrypted at rest and after a restart
+		ki, err := fs.GetKeyInfo(addr)
+		require.NoError(t, err)
+		stored, err := ds.Get(datastore.NewKey(addr.String()))
+		require.NoError(t, err)
+		assert.False(t, bytes.Contains(stored, ki.PrivateKey))
+		restarted, err := NewDSBackend(ds)
+		require.NoError(t, err)
+		assert.True(t, restarted.IsLocked())
+		assert.True(t, restarted.HasAddress(addr))
+
+		fs.Lock()
+		_, err = fs.SignBytes(data, addr)
+		assert.Equal(t, ErrWalletLocked, err)
+	})
+
+	t.Run("unlocking times out", func(t *testing.T) {
+		fs, _, _ := newEncrypted(t)
+		require.NoError(t, fs.Unlock(passphrase, time.Millisecond))
+		deadline := time.Now().Add(time.Second)
+		for !fs.IsLocked() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		assert.True(t, fs.IsLocked())
+	})
+
+	t.Run("session keys sign while locked", func(t *testing.T) {
+		fs, ds, addr := newEncrypted(t)
+		require.NoError(t, fs.Unlock(passphrase, 0))
+		token, err := fs.StartSession([]address.Address{addr})
+		require.NoError(t, err)
+		fs.Lock()
+
+		_, err = fs.SignBytes(data, addr)
+		assert.NoError(t, err)
+
+		restarted, err := NewDSBackend(ds)
+		require.NoError(t, err)
+		assert.Equal(t, ErrInvalidSessionToken, restarted.ResumeSession([]byte("wrong token, 32 bytes long......")))
+		require.NoError(t, restarted.ResumeSession(token))
+		sig, err := restarted.SignBytes(data, addr)
+		require.NoError(t, err)
+		assert.True(t, types.IsValidSignature(data, addr, sig))
+
+		require.NoError(t, restarted.EndSession())
+		_, err = restarted.SignBytes(data, addr)
+		assert.Equal(t, ErrWalletLocked, err)
+	})
+}
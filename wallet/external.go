@@ -0,0 +1,119 @@
+package wallet
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
+	"github.com/filecoin-project/go-filecoin/crypto"
+	"github.com/filecoin-project/go-filecoin/types"
+	wutil "github.com/filecoin-project/go-filecoin/wallet/util"
+)
+
+// ExternalBackendType is the reflect type of the ExternalBackend.
+var ExternalBackendType = reflect.TypeOf(&ExternalBackend{})
+
+// ErrExternalKey is returned when asking for the private key of an address
+// held by an external signer.
+var ErrExternalKey = errors.New("key is held by an external signer")
+
+// SignerTransport carries the requests of an ExternalBackend to the signer
+// holding its keys, such as another process or a hardware wallet.
+type SignerTransport interface {
+	// Addresses returns the addresses the signer holds the keys of.
+	Addresses() ([]address.Address, error)
+	// Sign returns the signature of data by the key of addr.
+	Sign(addr address.Address, data []byte) (types.Signature, error)
+}
+
+// ExternalBackend is a wallet backend whose private keys never enter the
+// node: it delegates signing to an external signer over a transport.
+type ExternalBackend struct {
+	lk        sync.RWMutex
+	transport SignerTransport
+	addrs     map[address.Address]struct{}
+}
+
+var _ Backend = (*ExternalBackend)(nil)
+var _ types.Signer = (*ExternalBackend)(nil)
+
+// NewExternalBackend constructs a backend for the addresses of the signer at
+// the other end of transport.
+func NewExternalBackend(transport SignerTransport) (*ExternalBackend, error) {
+	backend := &ExternalBackend{transport: transport}
+	if err := backend.Refresh(); err != nil {
+		return nil, err
+	}
+	return backend, nil
+}
+
+// Refresh fetches the addresses of the signer again.
+func (backend *ExternalBackend) Refresh() error {
+	list, err := backend.transport.Addresses()
+	if err != nil {
+		return errors.Wrap(err, "failed to list the addresses of the external signer")
+	}
+	addrs := make(map[address.Address]struct{}, len(list))
+	for _, addr := range list {
+		addrs[addr] = struct{}{}
+	}
+
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+	backend.addrs = addrs
+	return nil
+}
+
+// Addresses returns the addresses of the signer.
+func (backend *ExternalBackend) Addresses() []address.Address {
+	backend.lk.RLock()
+	defer backend.lk.RUnlock()
+
+	var out []address.Address
+	for addr := range backend.addrs {
+		out = append(out, addr)
+	}
+	return out
+}
+
+// HasAddress returns true if the signer holds the key of addr.
+func (backend *ExternalBackend) HasAddress(addr address.Address) bool {
+	backend.lk.RLock()
+	defer backend.lk.RUnlock()
+
+	_, ok := backend.addrs[addr]
+	return ok
+}
+
+// SignBytes asks the signer to sign data with the key of addr, and checks the
+// signature it returns.
+func (backend *ExternalBackend) SignBytes(data []byte, addr address.Address) (types.Signature, error) {
+	if !backend.HasAddress(addr) {
+		return nil, errors.New("backend does not contain address")
+	}
+	sig, err := backend.transport.Sign(addr, data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "external signer failed to sign for %s", addr)
+	}
+	if !types.IsValidSignature(data, addr, sig) {
+		return nil, errors.Errorf("external signer returned an invalid signature for %s", addr)
+	}
+	return sig, nil
+}
+
+// Verify cryptographically verifies that 'sig' is the signed hash of 'data' with
+// the public key `pk`.
+func (backend *ExternalBackend) Verify(data, pk []byte, sig types.Signature) bool {
+	if len(pk) == bls.PublicKeyBytes {
+		return wutil.VerifyBLS(pk, data, sig)
+	}
+	return crypto.Verify(pk, data, sig)
+}
+
+// GetKeyInfo fails, the keys never leave the signer.
+func (backend *ExternalBackend) GetKeyInfo(addr address.Address) (*types.KeyInfo, error) {
+	return nil, ErrExternalKey
+}
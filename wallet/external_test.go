@@ -0,0 +1,80 @@
+package wallet_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/wallet"
+)
+
+func TestExternalBackend(t *testing.T) {
+	tf.UnitTest(t)
+
+	// The signer process holds its keys in a backend of its own.
+	signer, err := wallet.NewDSBackend(datastore.NewMapDatastore())
+	require.NoError(t, err)
+	addr, err := signer.NewAddress()
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "external-signer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	ln, err := net.Listen("unix", filepath.Join(dir, "signer.sock"))
+	require.NoError(t, err)
+	defer ln.Close()                  // nolint: errcheck
+	go wallet.ServeSigner(ln, signer) // nolint: errcheck
+
+	external, err := wallet.NewExternalBackend(wallet.NewSocketTransport(filepath.Join(dir, "signer.sock")))
+	require.NoError(t, err)
+
+	local, err := wallet.NewDSBackend(datastore.NewMapDatastore())
+	require.NoError(t, err)
+	localAddr, err := local.NewAddress()
+	require.NoError(t, err)
+
+	w := wallet.New(local, external)
+	assert.Equal(t, []address.Address{addr}, external.Addresses())
+	assert.Len(t, w.Addresses(), 2)
+
+	t.Run("signs for the addresses of the signer", func(t *testing.T) {
+		backend, err := w.Find(addr)
+		require.NoError(t, err)
+		assert.Equal(t, external, backend)
+
+		data := []byte("data to sign")
+		sig, err := w.SignBytes(data, addr)
+		require.NoError(t, err)
+		assert.True(t, types.IsValidSignature(data, addr, sig))
+	})
+
+	t.Run("local addresses are still signed locally", func(t *testing.T) {
+		backend, err := w.Find(localAddr)
+		require.NoError(t, err)
+		assert.Equal(t, local, backend)
+	})
+
+	t.Run("private keys stay with the signer", func(t *testing.T) {
+		_, err := external.GetKeyInfo(addr)
+		assert.Equal(t, wallet.ErrExternalKey, err)
+	})
+
+	t.Run("unknown addresses are refused", func(t *testing.T) {
+		_, err := external.SignBytes([]byte("data"), localAddr)
+		assert.Error(t, err)
+	})
+
+	t.Run("unreachable signer", func(t *testing.T) {
+		_, err := wallet.NewExternalBackend(wallet.NewSocketTransport(filepath.Join(dir, "missing.sock")))
+		assert.Error(t, err)
+	})
+}
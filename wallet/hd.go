@@ -0,0 +1,159 @@
+package wallet
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/crypto"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+const (
+	// FilecoinCoinType is the coin type of Filecoin in BIP44 derivation
+	// paths, as registered in SLIP-0044.
+	FilecoinCoinType = 461
+
+	// mnemonicEntropyBits is the entropy of new mnemonics, 24 words.
+	mnemonicEntropyBits = 256
+
+	// restoreGapLimit is the number of consecutive unused addresses after
+	// which restoring a wallet stops looking for used ones.
+	restoreGapLimit = 20
+)
+
+// ErrHDSeedExists is returned when initializing a wallet that already
+// derives its addresses from a mnemonic.
+var ErrHDSeedExists = errors.New("wallet already has a mnemonic")
+
+// NewMnemonic returns a new random BIP39 mnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropyBits)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate entropy")
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// SeedFromMnemonic returns the BIP39 seed of mnemonic, without passphrase.
+func SeedFromMnemonic(mnemonic string) ([]byte, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid mnemonic")
+	}
+	return seed, nil
+}
+
+// DeriveKeyInfo returns the secp256k1 key at the given index of the seed,
+// derived along the BIP44 path m/44'/461'/0'/0/index.
+func DeriveKeyInfo(seed []byte, index uint32) (*types.KeyInfo, error) {
+	key, err := crypto.NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	path := []uint32{
+		44 + crypto.HardenedKeyStart,
+		FilecoinCoinType + crypto.HardenedKeyStart,
+		crypto.HardenedKeyStart,
+		0,
+		index,
+	}
+	for _, i := range path {
+		if key, err = key.Child(i); err != nil {
+			return nil, errors.Wrapf(err, "failed to derive key %d", index)
+		}
+	}
+	return &types.KeyInfo{PrivateKey: key.PrivateKey, Curve: SECP256K1}, nil
+}
+
+// InitHD makes the default backend of the wallet derive its new addresses
+// from a new mnemonic, which it returns. The existing addresses are not
+// derived from the mnemonic and are returned too, they must still be backed
+// up by exporting their keys.
+func InitHD(w *Wallet) (string, []address.Address, error) {
+	backend, err := defaultDSBackend(w)
+	if err != nil {
+		return "", nil, err
+	}
+
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		return "", nil, err
+	}
+	seed, err := SeedFromMnemonic(mnemonic)
+	if err != nil {
+		return "", nil, err
+	}
+
+	underived := backend.Addresses()
+	if err := backend.SetHDSeed(seed, 0); err != nil {
+		return "", nil, err
+	}
+	return mnemonic, underived, nil
+}
+
+// RestoreHD makes the default backend of the wallet derive its addresses
+// from mnemonic and adds the addresses already derived from it. The derived
+// addresses are scanned in order until restoreGapLimit consecutive ones are
+// unused according to isUsed, and all the addresses up to the last used one
+// are added and returned.
+func RestoreHD(w *Wallet, mnemonic string, isUsed func(address.Address) (bool, error)) ([]address.Address, error) {
+	backend, err := defaultDSBackend(w)
+	if err != nil {
+		return nil, err
+	}
+	if backend.HasHDSeed() {
+		return nil, ErrHDSeedExists
+	}
+
+	seed, err := SeedFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+
+	var kis []*types.KeyInfo
+	var count int
+	for i := uint32(0); len(kis)-count < restoreGapLimit; i++ {
+		ki, err := DeriveKeyInfo(seed, i)
+		if errors.Cause(err) == crypto.ErrInvalidChildKey {
+			// indexes yielding no valid key are skipped
+			kis = append(kis, nil)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		kis = append(kis, ki)
+
+		addr, err := ki.Address()
+		if err != nil {
+			return nil, err
+		}
+		used, err := isUsed(addr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check address %s", addr)
+		}
+		if used {
+			count = len(kis)
+		}
+	}
+
+	var out []address.Address
+	for _, ki := range kis[:count] {
+		if ki == nil {
+			continue
+		}
+		if err := backend.ImportKey(ki); err != nil {
+			return nil, err
+		}
+		addr, err := ki.Address()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, addr)
+	}
+	if err := backend.SetHDSeed(seed, uint32(count)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
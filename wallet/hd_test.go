@@ -0,0 +1,95 @@
+package wallet_test
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/wallet"
+)
+
+func TestHDWallet(t *testing.T) {
+	tf.UnitTest(t)
+
+	newWallet := func(t *testing.T, ds datastore.Batching) *wallet.Wallet {
+		backend, err := wallet.NewDSBackend(ds)
+		require.NoError(t, err)
+		return wallet.New(backend)
+	}
+
+	t.Run("derives new addresses from the mnemonic", func(t *testing.T) {
+		ds := datastore.NewMapDatastore()
+		w := newWallet(t, ds)
+		random, err := wallet.NewAddress(w)
+		require.NoError(t, err)
+
+		mnemonic, underived, err := wallet.InitHD(w)
+		require.NoError(t, err)
+		assert.Equal(t, []address.Address{random}, underived)
+
+		seed, err := wallet.SeedFromMnemonic(mnemonic)
+		require.NoError(t, err)
+		for i := uint32(0); i < 2; i++ {
+			addr, err := wallet.NewAddress(w)
+			require.NoError(t, err)
+			ki, err := wallet.DeriveKeyInfo(seed, i)
+			require.NoError(t, err)
+			expected, err := ki.Address()
+			require.NoError(t, err)
+			assert.Equal(t, expected, addr)
+		}
+
+		// the seed survives restarts and is not taken for an address
+		restarted := newWallet(t, ds)
+		assert.Len(t, restarted.Addresses(), 3)
+		addr, err := wallet.NewAddress(restarted)
+		require.NoError(t, err)
+		ki, err := wallet.DeriveKeyInfo(seed, 2)
+		require.NoError(t, err)
+		expected, err := ki.Address()
+		require.NoError(t, err)
+		assert.Equal(t, expected, addr)
+
+		_, _, err = wallet.InitHD(restarted)
+		assert.Equal(t, wallet.ErrHDSeedExists, err)
+	})
+
+	t.Run("restores the used derived addresses", func(t *testing.T) {
+		w := newWallet(t, datastore.NewMapDatastore())
+		mnemonic, _, err := wallet.InitHD(w)
+		require.NoError(t, err)
+		var derived []address.Address
+		for i := 0; i < 3; i++ {
+			addr, err := wallet.NewAddress(w)
+			require.NoError(t, err)
+			derived = append(derived, addr)
+		}
+
+		// the second address was never used
+		used := map[address.Address]bool{derived[0]: true, derived[2]: true}
+		restored := newWallet(t, datastore.NewMapDatastore())
+		addrs, err := wallet.RestoreHD(restored, mnemonic, func(addr address.Address) (bool, error) {
+			return used[addr], nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, derived, addrs)
+		for _, addr := range derived {
+			assert.True(t, restored.HasAddress(addr))
+		}
+
+		// new addresses continue after the restored ones
+		next, err := wallet.NewAddress(restored)
+		require.NoError(t, err)
+		assert.NotContains(t, derived, next)
+	})
+
+	t.Run("rejects invalid mnemonics", func(t *testing.T) {
+		w := newWallet(t, datastore.NewMapDatastore())
+		_, err := wallet.RestoreHD(w, "not a mnemonic", func(address.Address) (bool, error) { return false, nil })
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,87 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
+	"github.com/filecoin-project/go-filecoin/crypto"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// KeyFormatVersion is the version of the key format written by EncodeKey.
+//
+// A key is encoded as the hex encoding of the JSON object
+//
+//	{"version":1,"type":"<type>","privateKey":"<hex private key>"}
+//
+// where type is either "secp256k1" or "bls", and both private keys are 32
+// bytes. Later versions may add fields; DecodeKey rejects the versions it
+// does not know.
+const KeyFormatVersion = 1
+
+// encodedKey is the JSON object of the key format.
+type encodedKey struct {
+	Version    int    `json:"version"`
+	Type       string `json:"type"`
+	PrivateKey string `json:"privateKey"`
+}
+
+// EncodeKey encodes ki in the key format of KeyFormatVersion.
+func EncodeKey(ki *types.KeyInfo) (string, error) {
+	if err := checkKey(ki.Curve, ki.PrivateKey); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(&encodedKey{
+		Version:    KeyFormatVersion,
+		Type:       ki.Curve,
+		PrivateKey: hex.EncodeToString(ki.PrivateKey),
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// DecodeKey decodes a key encoded by EncodeKey. Surrounding whitespace is
+// ignored.
+func DecodeKey(s string) (*types.KeyInfo, error) {
+	data, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.Wrap(err, "key is not hex encoded")
+	}
+	var ek encodedKey
+	if err := json.Unmarshal(data, &ek); err != nil {
+		return nil, errors.Wrap(err, "key is not a JSON object")
+	}
+	if ek.Version < 1 || ek.Version > KeyFormatVersion {
+		return nil, errors.Errorf("unsupported key format version %d", ek.Version)
+	}
+	priv, err := hex.DecodeString(ek.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "private key is not hex encoded")
+	}
+	if err := checkKey(ek.Type, priv); err != nil {
+		return nil, err
+	}
+	return &types.KeyInfo{PrivateKey: priv, Curve: ek.Type}, nil
+}
+
+func checkKey(keyType string, priv []byte) error {
+	var size int
+	switch keyType {
+	case types.SECP256K1:
+		size = crypto.PrivateKeyBytes
+	case types.BLS:
+		size = bls.PrivateKeyBytes
+	default:
+		return errors.Errorf("unknown key type %q", keyType)
+	}
+	if len(priv) != size {
+		return errors.Errorf("%s private key must be %d bytes, not %d", keyType, size, len(priv))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package wallet_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/wallet"
+)
+
+func TestKeyFormat(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("round trips secp256k1 and bls keys", func(t *testing.T) {
+		blsKey := bls.PrivateKeyGenerate()
+		kis := []types.KeyInfo{
+			types.MustGenerateKeyInfo(1, types.GenerateKeyInfoSeed())[0],
+			{PrivateKey: blsKey[:], Curve: types.BLS},
+		}
+		for _, ki := range kis {
+			encoded, err := wallet.EncodeKey(&ki)
+			require.NoError(t, err)
+			decoded, err := wallet.DecodeKey(encoded + "\n")
+			require.NoError(t, err)
+			assert.True(t, ki.Equals(decoded))
+		}
+	})
+
+	t.Run("encoding is stable", func(t *testing.T) {
+		ki := &types.KeyInfo{PrivateKey: bytes.Repeat([]byte{1}, 32), Curve: types.SECP256K1}
+		encoded, err := wallet.EncodeKey(ki)
+		require.NoError(t, err)
+		assert.Equal(t, "7b2276657273696f6e223a312c2274797065223a22736563703235366b31222c22707269766174654b6579223a2230313031303130313031303130313031303130313031303130313031303130313031303130313031303130313031303130313031303130313031303130313031227d", encoded)
+	})
+
+	t.Run("rejects invalid keys", func(t *testing.T) {
+		for _, doc := range []string{
+			`{"version":2,"type":"secp256k1","privateKey":"` + hex.EncodeToString(make([]byte, 32)) + `"}`,
+			`{"version":1,"type":"ed25519","privateKey":"` + hex.EncodeToString(make([]byte, 32)) + `"}`,
+			`{"version":1,"type":"bls","privateKey":"` + hex.EncodeToString(make([]byte, 31)) + `"}`,
+			`{"version":1,"type":"bls","privateKey":"not hex"}`,
+			`not json`,
+		} {
+			_, err := wallet.DecodeKey(hex.EncodeToString([]byte(doc)))
+			assert.Error(t, err, doc)
+		}
+		_, err := wallet.DecodeKey("not hex")
+		assert.Error(t, err)
+	})
+}
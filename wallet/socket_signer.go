@@ -0,0 +1,129 @@
+package wallet
+
+import (
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// socketSignerTimeout bounds a request to a socket signer, long enough for a
+// user to confirm a signature on a hardware wallet.
+const socketSignerTimeout = 2 * time.Minute
+
+// signerRequest is a request to a socket signer. Method is either
+// "addresses" or "sign".
+type signerRequest struct {
+	Method  string           `json:"method"`
+	Address *address.Address `json:"address,omitempty"`
+	Data    []byte           `json:"data,omitempty"`
+}
+
+// signerResponse is the response of a socket signer to a request.
+type signerResponse struct {
+	Addresses []address.Address `json:"addresses,omitempty"`
+	Signature types.Signature   `json:"signature,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// SocketTransport is the transport to a signer process listening on a unix
+// socket. Each request is a JSON object sent over a new connection, answered
+// by a JSON object; see ServeSigner for the server side. Hardware wallets
+// such as a Ledger are reached through such a process bridging to the
+// device.
+type SocketTransport struct {
+	path string
+}
+
+var _ SignerTransport = (*SocketTransport)(nil)
+
+// NewSocketTransport returns a transport to the signer listening on the unix
+// socket at path.
+func NewSocketTransport(path string) *SocketTransport {
+	return &SocketTransport{path: path}
+}
+
+// Addresses returns the addresses of the signer.
+func (t *SocketTransport) Addresses() ([]address.Address, error) {
+	resp, err := t.call(&signerRequest{Method: "addresses"})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Addresses, nil
+}
+
+// Sign asks the signer to sign data with the key of addr.
+func (t *SocketTransport) Sign(addr address.Address, data []byte) (types.Signature, error) {
+	resp, err := t.call(&signerRequest{Method: "sign", Address: &addr, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+func (t *SocketTransport) call(req *signerRequest) (*signerResponse, error) {
+	conn, err := net.DialTimeout("unix", t.path, socketSignerTimeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to signer at %s", t.path)
+	}
+	defer conn.Close() // nolint: errcheck
+	if err := conn.SetDeadline(time.Now().Add(socketSignerTimeout)); err != nil {
+		return nil, err
+	}
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, errors.Wrap(err, "failed to send request to signer")
+	}
+	resp := &signerResponse{}
+	if err := json.NewDecoder(conn).Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "failed to read response of signer")
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// ServeSigner answers the requests of socket transports accepted on ln with
+// the keys of backend, until ln is closed.
+func ServeSigner(ln net.Listener, backend Backend) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go serveSignerConn(conn, backend)
+	}
+}
+
+func serveSignerConn(conn net.Conn, backend Backend) {
+	defer conn.Close() // nolint: errcheck
+
+	req := &signerRequest{}
+	resp := &signerResponse{}
+	if err := json.NewDecoder(conn).Decode(req); err != nil {
+		resp.Error = "invalid request: " + err.Error()
+	} else {
+		switch req.Method {
+		case "addresses":
+			resp.Addresses = backend.Addresses()
+		case "sign":
+			if req.Address == nil {
+				resp.Error = "missing address"
+				break
+			}
+			sig, err := backend.SignBytes(req.Data, *req.Address)
+			if err != nil {
+				resp.Error = err.Error()
+			}
+			resp.Signature = sig
+		default:
+			resp.Error = "unknown method " + req.Method
+		}
+	}
+	json.NewEncoder(conn).Encode(resp) // nolint: errcheck
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/minio/blake2b-simd"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/crypto"
 )
 
@@ -36,3 +37,27 @@ func Ecrecover(data, signature []byte) ([]byte, error) {
 	hash := blake2b.Sum256(data)
 	return crypto.EcRecover(hash[:], signature)
 }
+
+// SignBLS signs `data` using the BLS private key `priv`.
+func SignBLS(priv, data []byte) ([]byte, error) {
+	if len(priv) != bls.PrivateKeyBytes {
+		return nil, errors.Errorf("invalid BLS private key length %d", len(priv))
+	}
+	var key bls.PrivateKey
+	copy(key[:], priv)
+	sig := bls.PrivateKeySign(key, data)
+	return sig[:], nil
+}
+
+// VerifyBLS verifies that 'sig' is a BLS signature of 'data' by the BLS
+// public key `pk`.
+func VerifyBLS(pk []byte, data, signature []byte) bool {
+	if len(pk) != bls.PublicKeyBytes || len(signature) != bls.SignatureBytes {
+		return false
+	}
+	var pubKey bls.PublicKey
+	copy(pubKey[:], pk)
+	var sig bls.Signature
+	copy(sig[:], signature)
+	return bls.Verify(sig, []bls.Digest{bls.Hash(data)}, []bls.PublicKey{pubKey})
+}
@@ -6,10 +6,12 @@ import (
 	"reflect"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/types"
 	wutil "github.com/filecoin-project/go-filecoin/wallet/util"
 )
@@ -125,8 +127,12 @@ func (w *Wallet) GetAddressForPubKey(pk []byte) (address.Address, error) {
 }
 
 // Verify cryptographically verifies that 'sig' is the signed hash of 'data' with
-// the public key `pk`.
+// the public key `pk`. Keys of the length of BLS public keys are taken as BLS
+// keys.
 func (w *Wallet) Verify(data []byte, pk []byte, sig types.Signature) (bool, error) {
+	if len(pk) == bls.PublicKeyBytes {
+		return wutil.VerifyBLS(pk, data, sig), nil
+	}
 	return wutil.Verify(pk, data, sig)
 }
 
@@ -138,15 +144,29 @@ func (w *Wallet) Ecrecover(data []byte, sig types.Signature) ([]byte, error) {
 	return wutil.Ecrecover(data, sig)
 }
 
-// NewAddress creates a new account address on the default wallet backend.
+// NewAddress creates a new secp256k1 account address on the default wallet
+// backend.
 func NewAddress(w *Wallet) (address.Address, error) {
+	return NewAddressWithProtocol(w, address.SECP256K1)
+}
+
+// NewAddressWithProtocol creates a new account address of the given protocol,
+// either secp256k1 or BLS, on the default wallet backend.
+func NewAddressWithProtocol(w *Wallet, protocol address.Protocol) (address.Address, error) {
+	backend, err := defaultDSBackend(w)
+	if err != nil {
+		return address.Undef, err
+	}
+	return backend.NewAddressWithProtocol(protocol)
+}
+
+// defaultDSBackend returns the default backend of the wallet.
+func defaultDSBackend(w *Wallet) (*DSBackend, error) {
 	backends := w.Backends(DSBackendType)
 	if len(backends) == 0 {
-		return address.Undef, fmt.Errorf("missing default ds backend")
+		return nil, fmt.Errorf("missing default ds backend")
 	}
-
-	backend := (backends[0]).(*DSBackend)
-	return backend.NewAddress()
+	return (backends[0]).(*DSBackend), nil
 }
 
 // GetPubKeyForAddress returns the public key in the keystore associated with
@@ -228,3 +248,65 @@ func (w *Wallet) Export(addrs []address.Address) ([]*types.KeyInfo, error) {
 
 	return out, nil
 }
+
+// Encrypt encrypts the keys of the default backend with passphrase and locks
+// it.
+func Encrypt(w *Wallet, passphrase []byte) error {
+	backend, err := defaultDSBackend(w)
+	if err != nil {
+		return err
+	}
+	return backend.Encrypt(passphrase)
+}
+
+// Lock locks the default backend of the wallet.
+func Lock(w *Wallet) error {
+	backend, err := defaultDSBackend(w)
+	if err != nil {
+		return err
+	}
+	if !backend.IsEncrypted() {
+		return ErrNotEncrypted
+	}
+	backend.Lock()
+	return nil
+}
+
+// Unlock unlocks the default backend of the wallet with passphrase, until
+// timeout elapsed if timeout is positive.
+func Unlock(w *Wallet, passphrase []byte, timeout time.Duration) error {
+	backend, err := defaultDSBackend(w)
+	if err != nil {
+		return err
+	}
+	return backend.Unlock(passphrase, timeout)
+}
+
+// StartSession makes the keys of addrs usable to sign while the default
+// backend is locked and returns the token to resume the session with.
+func StartSession(w *Wallet, addrs []address.Address) ([]byte, error) {
+	backend, err := defaultDSBackend(w)
+	if err != nil {
+		return nil, err
+	}
+	return backend.StartSession(addrs)
+}
+
+// ResumeSession makes the keys of the session started with token usable to
+// sign while the default backend is locked.
+func ResumeSession(w *Wallet, token []byte) error {
+	backend, err := defaultDSBackend(w)
+	if err != nil {
+		return err
+	}
+	return backend.ResumeSession(token)
+}
+
+// EndSession forgets the keys of the session of the default backend.
+func EndSession(w *Wallet) error {
+	backend, err := defaultDSBackend(w)
+	if err != nil {
+		return err
+	}
+	return backend.EndSession()
+}
@@ -66,6 +66,44 @@ func TestWalletSimple(t *testing.T) {
 	}
 }
 
+func TestBLSSignAndVerify(t *testing.T) {
+	tf.UnitTest(t)
+
+	ds := datastore.NewMapDatastore()
+	fs, err := wallet.NewDSBackend(ds)
+	require.NoError(t, err)
+	w := wallet.New(fs)
+
+	t.Log("create a new BLS address")
+	addr, err := wallet.NewAddressWithProtocol(w, address.BLS)
+	require.NoError(t, err)
+	assert.Equal(t, address.BLS, addr.Protocol())
+	assert.True(t, w.HasAddress(addr))
+
+	t.Log("sign and verify with the address")
+	data := []byte("THESE BYTES WILL BE SIGNED")
+	sig, err := w.SignBytes(data, addr)
+	require.NoError(t, err)
+	assert.True(t, types.IsValidSignature(data, addr, sig))
+	assert.False(t, types.IsValidSignature([]byte("OTHER BYTES"), addr, sig))
+
+	t.Log("verify with the public key")
+	pk, err := w.GetPubKeyForAddress(addr)
+	require.NoError(t, err)
+	valid, err := w.Verify(data, pk, sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	t.Log("the key survives export and import")
+	kis, err := w.Export([]address.Address{addr})
+	require.NoError(t, err)
+	other, err := wallet.NewDSBackend(datastore.NewMapDatastore())
+	require.NoError(t, err)
+	imported, err := wallet.New(other).Import(kis)
+	require.NoError(t, err)
+	assert.Equal(t, []address.Address{addr}, imported)
+}
+
 func TestSimpleSignAndVerify(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -211,17 +249,22 @@ func TestWallet_CreateTicket(t *testing.T) {
 	pubKey, err := w.GetPubKeyForAddress(addr)
 	require.NoError(t, err)
 
+	parents, err := types.NewTipSet(&types.Block{Ticket: types.Signature{0xbb}})
+	require.NoError(t, err)
+
 	t.Run("Returns real ticket and nil error with good params", func(t *testing.T) {
-		proof := types.PoStProof{0xbb}
-		ticket, err := consensus.CreateTicket(proof, pubKey, w)
+		ticket, err := consensus.CreateTicket(parents, 0, pubKey, w)
 		assert.NoError(t, err)
 		assert.NotNil(t, ticket)
+
+		again, err := consensus.CreateTicket(parents, 0, pubKey, w)
+		assert.NoError(t, err)
+		assert.Equal(t, ticket, again)
 	})
 
 	t.Run("Returns error and empty ticket when signer is invalid", func(t *testing.T) {
-		proof := types.PoStProof{0xc0}
 		badPubKey := []byte{0xf0}
-		ticket, err := consensus.CreateTicket(proof, badPubKey, w)
+		ticket, err := consensus.CreateTicket(parents, 0, badPubKey, w)
 		assert.Error(t, err, "t, SignBytes error in CreateTicket: public key not found")
 		assert.Equal(t, types.Signature(nil), ticket)
 	})